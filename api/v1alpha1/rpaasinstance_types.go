@@ -8,6 +8,7 @@ import (
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	nginxv1alpha1 "github.com/tsuru/nginx-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -56,6 +57,11 @@ type RpaasInstanceSpec struct {
 	// +optional
 	TLS []nginxv1alpha1.NginxTLS `json:"tls,omitempty"`
 
+	// OCSPStapling holds the names of the TLS secrets whose certificates have
+	// OCSP stapling enabled.
+	// +optional
+	OCSPStapling []string `json:"ocspStapling,omitempty"`
+
 	// Service to expose the nginx instance
 	// +optional
 	Service *nginxv1alpha1.NginxService `json:"service,omitempty"`
@@ -203,8 +209,20 @@ type DNSConfig struct {
 type Location struct {
 	Path        string `json:"path"`
 	Destination string `json:"destination,omitempty"`
-	Content     *Value `json:"content,omitempty"`
-	ForceHTTPS  bool   `json:"forceHTTPS,omitempty"`
+	// Destinations splits the traffic of the location among many backends
+	// according to their weights (should not be combined with Destination).
+	// +optional
+	Destinations []WeightedDestination `json:"destinations,omitempty"`
+	Content      *Value                `json:"content,omitempty"`
+	ForceHTTPS   bool                  `json:"forceHTTPS,omitempty"`
+}
+
+type WeightedDestination struct {
+	// Destination is the host address which the requests are forwarded to.
+	Destination string `json:"destination"`
+	// Weight is the share of requests sent to this destination, relative to
+	// the sum of weights of the location.
+	Weight int32 `json:"weight"`
 }
 
 type ValueSource struct {
@@ -227,6 +245,12 @@ type File struct {
 
 const CertificateNameDefault = "default"
 
+const (
+	RPSAggregationAverage = "avg"
+	RPSAggregationMax     = "max"
+	RPSAggregationP95     = "p95"
+)
+
 // RpaasInstanceAutoscaleSpec describes the behavior of HorizontalPodAutoscaler.
 type RpaasInstanceAutoscaleSpec struct {
 	// MaxReplicas is the upper limit for the number of replicas that can be set
@@ -247,13 +271,32 @@ type RpaasInstanceAutoscaleSpec struct {
 	// int32(80) equals to 80%.
 	// +optional
 	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// TargetMemoryAverageValue is the target average memory usage over all the
+	// pods as an absolute quantity, e.g. "512Mi". It cannot be set along with
+	// TargetMemoryUtilizationPercentage.
+	// +optional
+	TargetMemoryAverageValue *resource.Quantity `json:"targetMemoryAverageValue,omitempty"`
 	// TargetRequestsPerSecond is the target rate of HTTP requests (in a second)
 	// pods should keep before scaling up/down pods.
 	// +optional
 	TargetRequestsPerSecond *int32 `json:"targetRequestsPerSecond,omitempty"`
+	// RPSWindow is the period over which the requests per second are aggregated
+	// before being compared to TargetRequestsPerSecond. Defaults to the range
+	// used by the RPS query template itself.
+	// +optional
+	RPSWindow *metav1.Duration `json:"rpsWindow,omitempty"`
+	// RPSAggregation is how the requests per second are aggregated over
+	// RPSWindow, either "avg", "max" or "p95" (95th percentile). Defaults to
+	// "avg".
+	// +kubebuilder:validation:Enum=avg;max;p95
+	// +optional
+	RPSAggregation string `json:"rpsAggregation,omitempty"`
 	// Schedules are the time windows where the minimum replica count should change.
 	// +optional
 	Schedules []ScheduledWindow `json:"schedules,omitempty"`
+	// Mirror makes the number of replicas follow the one of another instance.
+	// +optional
+	Mirror *AutoscaleMirror `json:"mirror,omitempty"`
 	// KEDAOptions defines the options used when creating autoscaling resources via KEDA's API.
 	// +optional
 	KEDAOptions *AutoscaleKEDAOptions `json:"kedaOptions,omitempty"`
@@ -271,6 +314,19 @@ type ScheduledWindow struct {
 	Timezone string `json:"timezone,omitempty"`
 }
 
+// AutoscaleMirror scales an instance proportionally to the replicas of another
+// instance. It requires KEDA and a Prometheus server (see AutoscaleKEDAOptions)
+// collecting the kube_deployment_status_replicas metric from kube-state-metrics.
+type AutoscaleMirror struct {
+	// Instance is the name of the source instance, which must live in the same
+	// namespace.
+	Instance string `json:"instance"`
+	// Ratio is the number of replicas for each replica of the source instance,
+	// as a decimal number greater than zero (e.g. "0.5"). Defaults to "1".
+	// +optional
+	Ratio string `json:"ratio,omitempty"`
+}
+
 type AutoscaleKEDAOptions struct {
 	// Enabled whether should use KEDA as the autoscaling controller.
 	// +optional
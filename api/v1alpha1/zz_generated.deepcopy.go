@@ -13,6 +13,7 @@ import (
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	apiv1alpha1 "github.com/tsuru/nginx-operator/api/v1alpha1"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -56,6 +57,21 @@ func (in *AutoscaleKEDAOptions) DeepCopy() *AutoscaleKEDAOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscaleMirror) DeepCopyInto(out *AutoscaleMirror) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscaleMirror.
+func (in *AutoscaleMirror) DeepCopy() *AutoscaleMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscaleMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Bind) DeepCopyInto(out *Bind) {
 	*out = *in
@@ -223,6 +239,11 @@ func (in *File) DeepCopy() *File {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Location) DeepCopyInto(out *Location) {
 	*out = *in
+	if in.Destinations != nil {
+		in, out := &in.Destinations, &out.Destinations
+		*out = make([]WeightedDestination, len(*in))
+		copy(*out, *in)
+	}
 	if in.Content != nil {
 		in, out := &in.Content, &out.Content
 		*out = new(Value)
@@ -432,16 +453,31 @@ func (in *RpaasInstanceAutoscaleSpec) DeepCopyInto(out *RpaasInstanceAutoscaleSp
 		*out = new(int32)
 		**out = **in
 	}
+	if in.TargetMemoryAverageValue != nil {
+		in, out := &in.TargetMemoryAverageValue, &out.TargetMemoryAverageValue
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 	if in.TargetRequestsPerSecond != nil {
 		in, out := &in.TargetRequestsPerSecond, &out.TargetRequestsPerSecond
 		*out = new(int32)
 		**out = **in
 	}
+	if in.RPSWindow != nil {
+		in, out := &in.RPSWindow, &out.RPSWindow
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	if in.Schedules != nil {
 		in, out := &in.Schedules, &out.Schedules
 		*out = make([]ScheduledWindow, len(*in))
 		copy(*out, *in)
 	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(AutoscaleMirror)
+		**out = **in
+	}
 	if in.KEDAOptions != nil {
 		in, out := &in.KEDAOptions, &out.KEDAOptions
 		*out = new(AutoscaleKEDAOptions)
@@ -565,6 +601,11 @@ func (in *RpaasInstanceSpec) DeepCopyInto(out *RpaasInstanceSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.OCSPStapling != nil {
+		in, out := &in.OCSPStapling, &out.OCSPStapling
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Service != nil {
 		in, out := &in.Service, &out.Service
 		*out = new(apiv1alpha1.NginxService)
@@ -825,3 +866,18 @@ func (in *ValueSource) DeepCopy() *ValueSource {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightedDestination) DeepCopyInto(out *WeightedDestination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightedDestination.
+func (in *WeightedDestination) DeepCopy() *WeightedDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightedDestination)
+	in.DeepCopyInto(out)
+	return out
+}
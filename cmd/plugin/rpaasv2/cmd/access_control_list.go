@@ -20,9 +20,9 @@ func NewCmdAccessControlList() *cli.Command {
 		Name:  "acl",
 		Usage: "Manages ACL of rpaas instances",
 		Subcommands: []*cli.Command{
-			NewCmdAddAccessControlList(),
+			withInstanceFile(NewCmdAddAccessControlList()),
 			NewCmdListAccessControlList(),
-			NewCmdRemoveAccessControlList(),
+			withInstanceFile(NewCmdRemoveAccessControlList()),
 		},
 	}
 }
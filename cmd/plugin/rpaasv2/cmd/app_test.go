@@ -0,0 +1,412 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/direct"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestClientTLSFlags(t *testing.T) {
+	t.Run("client certificate without key", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--rpaas-url", "https://rpaas.example.com", "--client-cert", "/tmp/client.crt", "info", "-i", "my-instance"})
+		assert.EqualError(t, err, "rpaasv2: client certificate and key must be provided together")
+	})
+
+	t.Run("missing CA file", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--rpaas-url", "https://rpaas.example.com", "--ca-file", "/path/to/not/found/ca.crt", "autoscale", "info", "-i", "my-instance"})
+		assert.ErrorContains(t, err, "rpaasv2: could not read CA file:")
+	})
+
+	t.Run("skipping TLS verification prints a warning", func(t *testing.T) {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		client := &fake.FakeClient{
+			FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+				return &types.InstanceInfo{Name: "my-instance"}, nil
+			},
+		}
+		err := NewApp(stdout, stderr, client).Run([]string{"./rpaasv2", "--insecure-skip-verify", "info", "-i", "my-instance", "-o", "json"})
+		require.NoError(t, err)
+		assert.Equal(t, "WARNING: TLS certificate verification is disabled, connections are subject to man-in-the-middle attacks!\n", stderr.String())
+	})
+}
+
+func TestClientHeaderFlags(t *testing.T) {
+	t.Run("malformed header", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--header", "X-Debug", "routes", "list", "-i", "my-instance"})
+		assert.EqualError(t, err, `invalid header "X-Debug": must be in the key=value format`)
+	})
+
+	t.Run("headers are sent along with every request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "some-token", r.Header.Get("X-Debug-Token"))
+			assert.Equal(t, "abc-123", r.Header.Get("X-Request-Id"))
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"minReplicas": 1, "maxReplicas": 5, "cpu": 50}`)
+		}))
+		defer server.Close()
+
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		err := NewApp(stdout, stderr, nil).Run([]string{"./rpaasv2", "--rpaas-url", server.URL, "--header", "X-Debug-Token=some-token", "--header", "X-Request-Id=abc-123", "-v", "autoscale", "info", "-i", "my-instance"})
+		require.NoError(t, err)
+		assert.Contains(t, stderr.String(), fmt.Sprintf("--> GET %s/resources/my-instance/autoscale\n", server.URL))
+		assert.Contains(t, stderr.String(), "    X-Debug-Token: [REDACTED]\n")
+		assert.Regexp(t, `<-- 200 OK in [0-9.]+[µnm]?s \(X-Request-Id: abc-123\)\n`, stderr.String())
+		assert.NotContains(t, stderr.String(), `    < {"cpu":50`)
+	})
+
+	t.Run("bodies are printed on very verbose mode", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"minReplicas": 1, "maxReplicas": 5, "cpu": 50}`)
+		}))
+		defer server.Close()
+
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		err := NewApp(stdout, stderr, nil).Run([]string{"./rpaasv2", "--rpaas-url", server.URL, "-vv", "autoscale", "info", "-i", "my-instance"})
+		require.NoError(t, err)
+		assert.Contains(t, stderr.String(), `    < {"minReplicas": 1, "maxReplicas": 5, "cpu": 50}`)
+	})
+}
+
+func TestClientAuthTokenCommandFlag(t *testing.T) {
+	t.Run("along with the RPaaS API URL", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--rpaas-url", "https://rpaas.example.com", "--auth-token-command", "echo some-token", "routes", "list", "-i", "my-instance"})
+		assert.EqualError(t, err, "--auth-token-command can only be used through Tsuru, i.e. without --rpaas-url")
+	})
+
+	t.Run("token is sent along with every request", func(t *testing.T) {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			assert.Equal(t, "Bearer short-lived-token", r.Header.Get("Authorization"))
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Query().Get("callback") == "/resources/my-instance/autoscale" {
+				fmt.Fprintf(w, `{"minReplicas": 1, "maxReplicas": 5, "cpu": 50}`)
+				return
+			}
+
+			fmt.Fprintf(w, `{"paths": []}`)
+		}))
+		defer server.Close()
+
+		for _, args := range [][]string{{"routes", "list", "-s", "rpaasv2", "-i", "my-instance"}, {"autoscale", "info", "-s", "rpaasv2", "-i", "my-instance"}} {
+			stderr := &bytes.Buffer{}
+			err := NewApp(&bytes.Buffer{}, stderr, nil).Run(append([]string{"./rpaasv2", "--tsuru-target", server.URL, "--auth-token-command", "echo short-lived-token", "-v"}, args...))
+			require.NoError(t, err)
+			assert.Contains(t, stderr.String(), "    Authorization: [REDACTED]\n")
+			assert.NotContains(t, stderr.String(), "short-lived-token")
+		}
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+}
+
+func TestClientImpersonationFlags(t *testing.T) {
+	t.Run("groups without user", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--as-group", "admins", "routes", "list", "-i", "my-instance"})
+		assert.EqualError(t, err, "--as-group can only be used along with --as")
+	})
+
+	tests := []struct {
+		name           string
+		flags          []string
+		expectedUser   []string
+		expectedGroups []string
+	}{
+		{
+			name: "without impersonation flags",
+		},
+		{
+			name:         "with --as",
+			flags:        []string{"--as", "alice@example.com"},
+			expectedUser: []string{"alice@example.com"},
+		},
+		{
+			name:           "with --as and --as-group",
+			flags:          []string{"--as", "alice@example.com", "--as-group", "admins", "--as-group", "sre"},
+			expectedUser:   []string{"alice@example.com"},
+			expectedGroups: []string{"admins", "sre"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedUser, r.Header.Values("Impersonate-User"))
+				assert.Equal(t, tt.expectedGroups, r.Header.Values("Impersonate-Group"))
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"minReplicas": 1, "maxReplicas": 5, "cpu": 50}`)
+			}))
+			defer server.Close()
+
+			args := append([]string{"./rpaasv2", "--rpaas-url", server.URL}, tt.flags...)
+			err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run(append(args, "autoscale", "info", "-i", "my-instance"))
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestClientRateLimitFlag(t *testing.T) {
+	t.Run("negative rate limit", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--rate-limit", "-1", "routes", "list", "-i", "my-instance"})
+		assert.EqualError(t, err, "--rate-limit must not be negative")
+	})
+
+	t.Run("requests rejected by the server rate limit are retried", func(t *testing.T) {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"minReplicas": 1, "maxReplicas": 5, "cpu": 50}`)
+		}))
+		defer server.Close()
+
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		err := NewApp(stdout, stderr, nil).Run([]string{"./rpaasv2", "--rpaas-url", server.URL, "--rate-limit", "10", "autoscale", "info", "-i", "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, requests)
+		assert.Contains(t, stdout.String(), "max replicas: 5\n")
+	})
+}
+
+func TestClientConnectTimeoutFlag(t *testing.T) {
+	t.Run("negative connect timeout", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--connect-timeout", "-1s", "routes", "list", "-i", "my-instance"})
+		assert.EqualError(t, err, "--connect-timeout must not be negative")
+	})
+
+	t.Run("server hanging on the TLS handshake", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer l.Close()
+
+		go func() {
+			// NOTE: accepts the connections but never answers them.
+			var conns []net.Conn
+			defer func() {
+				for _, conn := range conns {
+					conn.Close()
+				}
+			}()
+
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+
+				conns = append(conns, conn)
+			}
+		}()
+
+		start := time.Now()
+		err = NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--rpaas-url", "https://" + l.Addr().String(), "--timeout", "30s", "--connect-timeout", "100ms", "autoscale", "info", "-i", "my-instance"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "TLS handshake timeout")
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+}
+
+func TestClientWireFormatFlag(t *testing.T) {
+	t.Run("unsupported wire format", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--wire-format", "protobuf", "routes", "list", "-i", "my-instance"})
+		assert.EqualError(t, err, `unsupported wire format "protobuf" (one of: json, msgpack)`)
+	})
+
+	t.Run("routes decoded from MessagePack", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/resources/my-instance/route", r.URL.Path)
+			assert.Equal(t, "application/msgpack, application/json;q=0.9", r.Header.Get("Accept"))
+
+			data, err := types.MarshalMsgpack(map[string]interface{}{
+				"paths": []types.Route{{Path: "/app", Destination: "app.tsuru.example.com"}},
+			})
+			require.NoError(t, err)
+
+			w.Header().Set("Content-Type", types.MsgpackContentType)
+			w.Write(data)
+		}))
+		defer server.Close()
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--rpaas-url", server.URL, "--wire-format", "msgpack", "routes", "list", "-i", "my-instance"})
+		require.NoError(t, err)
+		assert.Contains(t, stdout.String(), "| /app | app.tsuru.example.com |")
+	})
+}
+
+func TestClientTryTimeoutFlag(t *testing.T) {
+	t.Run("negative try timeout", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--try-timeout", "-1s", "routes", "list", "-i", "my-instance"})
+		assert.EqualError(t, err, "--try-timeout must not be negative")
+	})
+
+	t.Run("try timeout longer than the total timeout", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--timeout", "5s", "--try-timeout", "10s", "routes", "list", "-i", "my-instance"})
+		assert.EqualError(t, err, "--try-timeout must be shorter than --timeout, which bounds all the attempts")
+	})
+
+	t.Run("attempts timing out are retried", func(t *testing.T) {
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) == 1 {
+				<-r.Context().Done()
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"minReplicas": 1, "maxReplicas": 5, "cpu": 50}`)
+		}))
+		defer server.Close()
+
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		err := NewApp(stdout, stderr, nil).Run([]string{"./rpaasv2", "--rpaas-url", server.URL, "--timeout", "5s", "--timeout-per-try", "100ms", "autoscale", "info", "-i", "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+		assert.Contains(t, stdout.String(), "max replicas: 5\n")
+	})
+}
+
+func TestClientStrictFlag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"version": "v0.40.0", "buildDate": "2023-01-01"}`)
+	}))
+	defer server.Close()
+
+	stdout := &bytes.Buffer{}
+	err := NewApp(stdout, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--rpaas-url", server.URL, "version", "-i", "my-instance"})
+	require.NoError(t, err)
+	assert.Contains(t, stdout.String(), "v0.40.0")
+
+	err = NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--rpaas-url", server.URL, "--strict", "version", "-i", "my-instance"})
+	assert.ErrorContains(t, err, `json: unknown field "buildDate"`)
+}
+
+type directFakeClient struct {
+	fake.FakeClient
+	autoscale *autogenerated.Autoscale
+}
+
+func (f *directFakeClient) GetAutoscale(ctx context.Context, instance string) (*autogenerated.Autoscale, error) {
+	return f.autoscale, nil
+}
+
+func TestClientDirectFlags(t *testing.T) {
+	t.Run("direct mode along with the RPaaS URL", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--rpaas-url", "https://rpaas.example.com", "--direct", "info", "-i", "my-instance"})
+		assert.EqualError(t, err, "--direct and --kubeconfig cannot be used along with --rpaas-url")
+	})
+
+	t.Run("missing kubeconfig file", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--kubeconfig", filepath.Join(t.TempDir(), "missing"), "routes", "list", "-i", "my-instance"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "could not set up the Kubernetes client: ")
+	})
+
+	t.Run("autoscale settings read by the client", func(t *testing.T) {
+		client := &directFakeClient{autoscale: &autogenerated.Autoscale{MinReplicas: 2, MaxReplicas: 5}}
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "--direct", "autoscale", "info", "-i", "my-instance", "--json"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"minReplicas": 2, "maxReplicas": 5}`, stdout.String())
+	})
+
+	t.Run("commands which need the RPaaS API", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &directFakeClient{}).Run([]string{"./rpaasv2", "--direct", "autoscale", "remove", "-i", "my-instance"})
+		assert.ErrorIs(t, err, direct.ErrReadOnly)
+	})
+}
+
+func TestClientFieldManagerFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "default field manager",
+			expected: "rpaasv2-cli",
+		},
+		{
+			name:     "custom field manager",
+			args:     []string{"--field-manager", "nightly-job"},
+			expected: "nightly-job",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					assert.Empty(t, r.Header.Get(rpaasclient.FieldManagerHeader))
+					fmt.Fprint(w, `{"paths": []}`)
+					return
+				}
+
+				assert.Equal(t, tt.expected, r.Header.Get(rpaasclient.FieldManagerHeader))
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `{"message": "Apply failed with 1 conflict: conflict with \"some-controller\" using extensions.tsuru.io/v1alpha1: .spec.replicas"}`)
+			}))
+			defer server.Close()
+
+			args := append([]string{"./rpaasv2", "--rpaas-url", server.URL}, tt.args...)
+			err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run(append(args, "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app.tsuru.example.com"))
+			assert.EqualError(t, err, `rpaasv2: conflicting change: .spec.replicas is owned by field manager "some-controller"`)
+		})
+	}
+}
+
+func TestClientCacheFlag(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "/resources/flavors", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"name": "mint", "description": "Mint flavor"}]`)
+	}))
+	defer server.Close()
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	listFlavors := func(flags ...string) {
+		args := append(append([]string{"./rpaasv2", "--rpaas-url", server.URL}, flags...), "flavors", "list")
+		require.NoError(t, NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run(args))
+	}
+
+	listFlavors()
+	listFlavors()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests), "flavors should not be cached by default")
+
+	listFlavors("--cache")
+	listFlavors("--cache")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
@@ -5,15 +5,28 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lnquy/cron"
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
 
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
 )
 
@@ -23,8 +36,8 @@ func NewCmdAutoscale() *cli.Command {
 		Usage: "Manages autoscaling settings of an instance",
 		Subcommands: []*cli.Command{
 			NewCmdGetAutoscale(),
-			NewCmdUpdateAutoscale(),
-			NewCmdRemoveAutoscale(),
+			withInstanceFile(NewCmdUpdateAutoscale()),
+			withInstanceFile(NewCmdRemoveAutoscale()),
 		},
 	}
 }
@@ -38,12 +51,18 @@ func NewCmdUpdateAutoscale() *cli.Command {
 # Scale up/down based on 75% of CPU utilization over all pods:
 rpaasv2 autoscale update -s my-instance -i my-instance --min 2 --max 20 --cpu 75
 
+# Scale up/down based on an average memory usage of 512Mi per pod:
+rpaasv2 autoscale update -s my-service -i my-instance --min 2 --max 20 --memory-value 512Mi
+
 # Scale up/down based on avg 100 HTTP requests per second:
 rpaasv2 autoscale update -s my-service -i my-instance --min 2 --max 20 --rps 100
 
 # Combine the two targets above together:
 rpaasv2 autoscale update -s my-service -i my-instance --min 2 --max 20 --cpu 75 --rps 100
 
+# Scale up/down based on the highest HTTP requests per second seen over the last 2 minutes:
+rpaasv2 autoscale update -s my-service -i my-instance --min 2 --max 20 --rps 100 --rps-window 2m --rps-aggregation max
+
 # Set a scheduled window to scale up at least one replica every weekday from 8 AM until 8 PM.
 rpaasv2 autoscale update -s my-service -i my-instance \
 	--min 0 --max 20 \
@@ -56,6 +75,14 @@ rpaasv2 autoscale update -s my-service -i my-instance \
 	--min 2 --max 10 \
 	--schedule '{"minReplicas": 10, "start": "00 20 * * 2", "end": "00 00 * * 3"}' \
 	--schedule '{"minReplicas": 10, "start": "00 00 * * 0", "end": "59 23 * * 0"}'
+
+# Keep one replica for every two replicas of another instance (requires KEDA and a
+# Prometheus server collecting kube_deployment_status_replicas from kube-state-metrics,
+# besides both instances living in the same namespace):
+rpaasv2 autoscale update -s my-service -i my-instance --min 1 --max 20 --mirror-instance other-instance --mirror-ratio 0.5
+
+# Copy the autoscale settings from another instance, overriding the max replicas:
+rpaasv2 autoscale update -s my-service -i my-instance --copy-from other-instance --max 30
 `,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
@@ -75,9 +102,8 @@ rpaasv2 autoscale update -s my-service -i my-instance \
 				Required: false,
 			},
 			&cli.IntFlag{
-				Name:     "max",
-				Usage:    "the upper limit of replicas that can be reached",
-				Required: true,
+				Name:  "max",
+				Usage: "the upper limit of replicas that can be reached (required unless --copy-from is set)",
 			},
 			&cli.IntFlag{
 				Name:        "cpu",
@@ -91,58 +117,183 @@ rpaasv2 autoscale update -s my-service -i my-instance \
 				Usage:       "the target average memory utilization on all the replicas (in percentage format, e.g. 80 equals to 80%)",
 				DefaultText: "N/A",
 			},
+			&cli.StringFlag{
+				Name:        "memory-value",
+				Usage:       "the target average memory usage on all the replicas as an absolute quantity (e.g. 512Mi), instead of the percentage on --memory (an empty value removes it)",
+				DefaultText: "N/A",
+			},
 			&cli.IntFlag{
 				Name:        "rps",
 				Aliases:     []string{"requests-per-second"},
 				Usage:       "the target average of HTTP requests per seconds between replicas (e.g. 100, means 100 req/s)",
 				DefaultText: "N/A",
 			},
+			&cli.DurationFlag{
+				Name:        "rps-window",
+				Usage:       "the period over which the requests per second are aggregated before comparing them to the RPS target (e.g. 2m)",
+				DefaultText: "N/A",
+			},
+			&cli.StringFlag{
+				Name:        "rps-aggregation",
+				Usage:       fmt.Sprintf("how the requests per second are aggregated over the RPS window (one of: %s)", strings.Join(rpsAggregations, ", ")),
+				DefaultText: "avg",
+			},
 			&cli.StringSliceFlag{
 				Name:    "schedule",
 				Aliases: []string{"scheduled-window"},
 				Usage:   "the time-window where the instance can scale in/out regardless of traffic or resource utilization",
 			},
+			&cli.StringFlag{
+				Name:        "mirror-instance",
+				Usage:       "the instance name whose number of replicas should be followed (an empty value stops mirroring)",
+				DefaultText: "N/A",
+			},
+			&cli.Float64Flag{
+				Name:        "mirror-ratio",
+				Usage:       "the number of replicas for each replica of the mirrored instance (e.g. 0.5 means half of them)",
+				DefaultText: "1",
+			},
+			&cli.StringFlag{
+				Name:  "copy-from",
+				Usage: "the instance name whose autoscale settings should be copied (other flags take precedence over copied values)",
+			},
+			&cli.StringFlag{
+				Name:  "copy-from-service",
+				Usage: "the Tsuru service name of the instance set on --copy-from (defaults to --service)",
+			},
 		},
 		Action: runUpdateAutoscale,
 	}
 }
 
 func runUpdateAutoscale(c *cli.Context) error {
-	var schedules []autogenerated.ScheduledWindow
-	for _, s := range c.StringSlice("schedule") {
-		var sw autogenerated.ScheduledWindow
-		if err := json.Unmarshal([]byte(s), &sw); err != nil {
+	var autoscale autogenerated.Autoscale
+	if source := c.String("copy-from"); source != "" {
+		copied, err := getAutoscaleToCopy(c, source)
+		if err != nil {
 			return err
 		}
 
-		schedules = append(schedules, sw)
+		autoscale = *copied
+	} else if c.IsSet("copy-from-service") {
+		return fmt.Errorf("--copy-from-service can only be used along with --copy-from")
+	} else if !c.IsSet("max") {
+		return fmt.Errorf("--max must be provided unless --copy-from is set")
 	}
 
-	var cpu *int32
-	if n := c.Int("cpu"); c.IsSet("cpu") && n > 0 {
-		cpu = autogenerated.PtrInt32(int32(n))
+	if c.IsSet("schedule") {
+		var schedules []autogenerated.ScheduledWindow
+		for _, s := range c.StringSlice("schedule") {
+			var sw autogenerated.ScheduledWindow
+			if err := json.Unmarshal([]byte(s), &sw); err != nil {
+				return err
+			}
+
+			schedules = append(schedules, sw)
+		}
+
+		autoscale.Schedules = schedules
 	}
 
-	var memory *int32
-	if n := c.Int("memory"); c.IsSet("memory") && n > 0 {
-		memory = autogenerated.PtrInt32(int32(n))
+	if c.IsSet("cpu") {
+		autoscale.Cpu = nil
+		if n := c.Int("cpu"); n > 0 {
+			autoscale.Cpu = autogenerated.PtrInt32(int32(n))
+		}
+	}
+
+	if c.IsSet("memory") && c.IsSet("memory-value") {
+		return fmt.Errorf("--memory cannot be used along with --memory-value")
+	}
+
+	if c.IsSet("memory") {
+		autoscale.Memory, autoscale.MemoryValue = nil, nil
+		if n := c.Int("memory"); n > 0 {
+			autoscale.Memory = autogenerated.PtrInt32(int32(n))
+		}
+	}
+
+	if c.IsSet("memory-value") {
+		autoscale.Memory, autoscale.MemoryValue = nil, nil
+		if v := c.String("memory-value"); v != "" {
+			memory, err := resource.ParseQuantity(v)
+			if err != nil {
+				return fmt.Errorf("invalid --memory-value %q: %w", v, err)
+			}
+
+			if memory.Sign() <= 0 {
+				return fmt.Errorf("--memory-value must be greater than zero")
+			}
+
+			autoscale.MemoryValue = autogenerated.PtrString(memory.String())
+		}
 	}
 
-	var rps *int32
-	if n := c.Int("rps"); c.IsSet("rps") && n > 0 {
-		rps = autogenerated.PtrInt32(int32(n))
+	if c.IsSet("rps") {
+		autoscale.Rps = nil
+		if n := c.Int("rps"); n > 0 {
+			autoscale.Rps = autogenerated.PtrInt32(int32(n))
+		} else {
+			// NOTE: the RPS window and aggregation make no sense without
+			// the RPS target, so they're dropped along with it.
+			autoscale.RpsWindow, autoscale.RpsAggregation = nil, nil
+		}
 	}
 
-	autoscale := autogenerated.Autoscale{
-		MinReplicas: int32(c.Int("min")),
-		MaxReplicas: int32(c.Int("max")),
-		Cpu:         cpu,
-		Memory:      memory,
-		Rps:         rps,
-		Schedules:   schedules,
+	if c.IsSet("rps-window") {
+		window := c.Duration("rps-window")
+		if window <= 0 {
+			return fmt.Errorf("--rps-window must be a positive duration")
+		}
+
+		autoscale.RpsWindow = autogenerated.PtrString(window.String())
 	}
 
-	_, err := NewAutogeneratedClient(c).RpaasApi.UpdateAutoscale(c.Context, c.String("instance")).Autoscale(autoscale).Execute()
+	if c.IsSet("rps-aggregation") {
+		aggregation := c.String("rps-aggregation")
+		if !isValidRPSAggregation(aggregation) {
+			return fmt.Errorf("unknown --rps-aggregation %q, it must be one of: %s", aggregation, strings.Join(rpsAggregations, ", "))
+		}
+
+		autoscale.RpsAggregation = autogenerated.PtrString(aggregation)
+	}
+
+	if c.IsSet("mirror-instance") {
+		autoscale.MirrorInstance = nil
+		if source := c.String("mirror-instance"); source != "" {
+			autoscale.MirrorInstance = autogenerated.PtrString(source)
+		} else {
+			autoscale.MirrorRatio = nil
+		}
+	}
+
+	if c.IsSet("mirror-ratio") {
+		ratio := c.Float64("mirror-ratio")
+		if ratio <= 0 {
+			return fmt.Errorf("--mirror-ratio must be greater than zero")
+		}
+
+		if autoscale.MirrorInstance == nil {
+			return fmt.Errorf("--mirror-ratio can only be used along with --mirror-instance")
+		}
+
+		autoscale.MirrorRatio = autogenerated.PtrFloat64(ratio)
+	}
+
+	if c.IsSet("min") {
+		autoscale.MinReplicas = int32(c.Int("min"))
+	}
+
+	if c.IsSet("max") {
+		autoscale.MaxReplicas = int32(c.Int("max"))
+	}
+
+	client, err := NewAutogeneratedClient(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.RpaasApi.UpdateAutoscale(c.Context, c.String("instance")).Autoscale(autoscale).Execute()
 	if err != nil {
 		return fmt.Errorf("could not update the autoscale on RPaaS API: %w", err)
 	}
@@ -151,11 +302,46 @@ func runUpdateAutoscale(c *cli.Context) error {
 	return nil
 }
 
+var rpsAggregations = []string{"avg", "max", "p95"}
+
+func isValidRPSAggregation(aggregation string) bool {
+	for _, a := range rpsAggregations {
+		if a == aggregation {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getAutoscaleToCopy(c *cli.Context, source string) (*autogenerated.Autoscale, error) {
+	service := c.String("service")
+	if c.IsSet("copy-from-service") {
+		service = c.String("copy-from-service")
+	}
+
+	client, err := newAutogeneratedClientFor(c, service, source)
+	if err != nil {
+		return nil, err
+	}
+
+	autoscale, _, err := client.RpaasApi.GetAutoscale(c.Context, source).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("could not get autoscale of %s from RPaaS API: %w", source, err)
+	}
+
+	if autoscale == nil || autoscale.MaxReplicas == 0 {
+		return nil, fmt.Errorf("instance %s has no autoscale configured", source)
+	}
+
+	return autoscale, nil
+}
+
 func NewCmdGetAutoscale() *cli.Command {
 	return &cli.Command{
 		Name:  "info",
 		Usage: "Shows  the autoscaling settings",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "service",
 				Aliases: []string{"tsuru-service", "s"},
@@ -172,22 +358,546 @@ func NewCmdGetAutoscale() *cli.Command {
 				Usage: "show as JSON instead of go template format",
 				Value: false,
 			},
-		},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "the output format (one of: json, table-wide), json is the same as --json and table-wide the same as --no-wrap",
+			},
+			&cli.BoolFlag{
+				Name:  "explain",
+				Usage: "explains the number of replicas each trigger would request given the metrics observed by the autoscaler",
+			},
+			&cli.IntFlag{
+				Name:  "current-replicas",
+				Usage: "overrides the current number of replicas used on --explain (defaults to the one from the autoscaler or the instance)",
+			},
+			&cli.Float64Flag{
+				Name:  "observed-cpu",
+				Usage: "overrides the observed average CPU utilization (in %) used on --explain",
+			},
+			&cli.Float64Flag{
+				Name:  "observed-memory",
+				Usage: "overrides the observed average memory utilization (in %) used on --explain",
+			},
+			&cli.Float64Flag{
+				Name:  "observed-rps",
+				Usage: "overrides the observed average requests per second per replica used on --explain",
+			},
+			&cli.IntFlag{
+				Name:  "next-windows",
+				Usage: "shows when each scheduled window starts and ends in its next N occurrences, in the window's timezone",
+			},
+			&cli.BoolFlag{
+				Name:  "raw",
+				Usage: "shows the HorizontalPodAutoscaler or KEDA ScaledObject generated from the autoscale settings (in YAML, or JSON along with --json) instead of the settings",
+			},
+		}, append(tableWidthFlags(), outputFieldFlags()...)...),
 		Action: runGetAutoscale,
 	}
 }
 
 func runGetAutoscale(c *cli.Context) error {
-	autoscale, _, err := NewAutogeneratedClient(c).RpaasApi.GetAutoscale(c.Context, c.String("instance")).Execute()
+	output := c.String("output")
+	if output != "" && output != "json" && output != "table-wide" {
+		return fmt.Errorf("unsupported output format %q (one of: json, table-wide)", output)
+	}
+
+	width, err := tableWidthFromFlags(c, c.Bool("no-wrap") || output == "table-wide")
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("json") {
+		output = "json"
+	}
+
+	if err := checkOutputField(c, output); err != nil {
+		return err
+	}
+
+	nextWindows := c.Int("next-windows")
+	if c.IsSet("next-windows") && nextWindows <= 0 {
+		return fmt.Errorf("--next-windows must be greater than zero")
+	}
+
+	if nextWindows > 0 && output == "json" {
+		return fmt.Errorf("--next-windows cannot be used along with --json")
+	}
+
+	if c.Bool("raw") {
+		if c.Bool("explain") || nextWindows > 0 || output == "table-wide" {
+			return fmt.Errorf("--raw cannot be used along with --explain, --next-windows or -o table-wide")
+		}
+
+		return writeAutoscaleObject(c, output)
+	}
+
+	autoscale, err := getAutoscale(c)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		return writeJSONOutput(c, autoscale)
+	}
+
+	writeAutoscale(c.App.Writer, autoscale, width)
+
+	if nextWindows > 0 {
+		if err = writeNextScheduledWindows(c.App.Writer, autoscale, nextWindows, timeNow()); err != nil {
+			return err
+		}
+	}
+
+	if !c.Bool("explain") {
+		return nil
+	}
+
+	if autoscale == nil || autoscale.MaxReplicas == 0 {
+		return fmt.Errorf("instance %s has no autoscale configured", formatInstanceName(c))
+	}
+
+	status, err := getAutoscalerStatus(c)
+	if err != nil {
+		return err
+	}
+
+	replicas, err := getCurrentReplicas(c, status)
+	if err != nil {
+		return err
+	}
+
+	explainAutoscale(c.App.Writer, autoscale, replicas, status.Observed.override(observedMetricsFromFlags(c)), timeNow())
+	return nil
+}
+
+// writeAutoscaleObject writes the autoscaler the operator generated for the
+// instance as it is in the cluster, so that users can tell why it scales the
+// way it does.
+// autoscaleGetter is implemented by the clients which get the autoscale
+// settings on their own rather than through the RPaaS API (see
+// direct.Client).
+type autoscaleGetter interface {
+	GetAutoscale(ctx context.Context, instance string) (*autogenerated.Autoscale, error)
+}
+
+func getAutoscale(c *cli.Context) (*autogenerated.Autoscale, error) {
+	if isDirectMode(c) {
+		if err := setupClient(c); err != nil {
+			return nil, err
+		}
+
+		client, err := getClient(c)
+		if err != nil {
+			return nil, err
+		}
+
+		getter, ok := client.(autoscaleGetter)
+		if !ok {
+			return nil, fmt.Errorf("the client does not support getting the autoscale settings")
+		}
+
+		return getter.GetAutoscale(c.Context, c.String("instance"))
+	}
+
+	client, err := NewAutogeneratedClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	autoscale, _, err := client.RpaasApi.GetAutoscale(c.Context, c.String("instance")).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("could not get autoscale from RPaaS API: %w", err)
+	}
+
+	return autoscale, nil
+}
+
+func writeAutoscaleObject(c *cli.Context, output string) error {
+	if err := setupClient(c); err != nil {
+		return err
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	obj, err := client.GetAutoscaleObject(c.Context, rpaasclient.GetAutoscaleArgs{Instance: c.String("instance")})
+	if errors.Is(err, rpaasclient.ErrAutoscaleObjectUnsupported) {
+		return fmt.Errorf("cannot show the autoscaler of %s: %w (upgrade the API to use --raw)", formatInstanceName(c), err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		return writeJSONOutput(c, obj.Object)
+	}
+
+	return writeYAML(c.App.Writer, obj.Object)
+}
+
+// hpaTolerance is the ratio within which the HorizontalPodAutoscaler keeps
+// the current number of replicas (see --horizontal-pod-autoscaler-tolerance).
+const hpaTolerance = 0.1
+
+var timeNow = time.Now
+
+type observedMetrics struct {
+	CPU    *float64
+	Memory *float64
+	RPS    *float64
+}
+
+func observedMetricsFromFlags(c *cli.Context) observedMetrics {
+	get := func(name string) *float64 {
+		if !c.IsSet(name) {
+			return nil
+		}
+
+		v := c.Float64(name)
+		return &v
+	}
+
+	return observedMetrics{
+		CPU:    get("observed-cpu"),
+		Memory: get("observed-memory"),
+		RPS:    get("observed-rps"),
+	}
+}
+
+// override returns the metrics along with the ones set on other.
+func (m observedMetrics) override(other observedMetrics) observedMetrics {
+	for _, f := range []struct{ dst, src **float64 }{{&m.CPU, &other.CPU}, {&m.Memory, &other.Memory}, {&m.RPS, &other.RPS}} {
+		if *f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+
+	return m
+}
+
+// autoscalerStatus is the state reported by the autoscaler the operator
+// generated for the instance.
+type autoscalerStatus struct {
+	Replicas *int32
+	Observed observedMetrics
+}
+
+// getAutoscalerStatus returns the current replicas and metrics from the
+// status of the HorizontalPodAutoscaler, if any. It's empty when the API is
+// too old to expose the autoscaler, when it's not applied yet or when it's a
+// KEDA ScaledObject, whose status does not carry the metrics.
+func getAutoscalerStatus(c *cli.Context) (autoscalerStatus, error) {
+	if err := setupClient(c); err != nil {
+		return autoscalerStatus{}, err
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return autoscalerStatus{}, err
+	}
+
+	obj, err := client.GetAutoscaleObject(c.Context, rpaasclient.GetAutoscaleArgs{Instance: c.String("instance")})
+	var httpErr *rpaasclient.ErrUnexpectedStatusCode
+	if errors.Is(err, rpaasclient.ErrAutoscaleObjectUnsupported) || (errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound) {
+		return autoscalerStatus{}, nil
+	}
+
+	if err != nil {
+		return autoscalerStatus{}, err
+	}
+
+	return autoscalerStatusFromObject(obj), nil
+}
+
+func autoscalerStatusFromObject(obj *unstructured.Unstructured) autoscalerStatus {
+	var status autoscalerStatus
+	if obj.GetKind() != "HorizontalPodAutoscaler" {
+		return status
+	}
+
+	var hpa autoscalingv2.HorizontalPodAutoscaler
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &hpa); err != nil {
+		return status
+	}
+
+	if hpa.Status.CurrentReplicas > 0 {
+		status.Replicas = pointer.Int32(hpa.Status.CurrentReplicas)
+	}
+
+	for _, m := range hpa.Status.CurrentMetrics {
+		if m.Resource == nil || m.Resource.Current.AverageUtilization == nil {
+			continue
+		}
+
+		v := float64(*m.Resource.Current.AverageUtilization)
+		switch m.Resource.Name {
+		case corev1.ResourceCPU:
+			status.Observed.CPU = &v
+		case corev1.ResourceMemory:
+			status.Observed.Memory = &v
+		}
+	}
+
+	return status
+}
+
+func getCurrentReplicas(c *cli.Context, status autoscalerStatus) (int32, error) {
+	if c.IsSet("current-replicas") {
+		return int32(c.Int("current-replicas")), nil
+	}
+
+	if status.Replicas != nil {
+		return *status.Replicas, nil
+	}
+
+	if err := setupClient(c); err != nil {
+		return 0, err
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := client.Info(c.Context, rpaasclient.InfoArgs{Instance: c.String("instance")})
+	if err != nil {
+		return 0, err
+	}
+
+	if info.Replicas != nil {
+		return *info.Replicas, nil
+	}
+
+	return int32(len(info.Pods)), nil
+}
+
+// explainAutoscale writes the number of replicas each trigger requests
+// following the HorizontalPodAutoscaler algorithm, i.e.
+// ceil(current replicas * observed / target), and which one prevails.
+func explainAutoscale(w io.Writer, autoscale *autogenerated.Autoscale, current int32, observed observedMetrics, now time.Time) {
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Current replicas: %d\n", current)
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Trigger", "Target", "Observed", "Desired replicas"})
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(false)
+
+	var desired int32
+	var winner string
+	var missing, unexplained []string
+
+	explain := func(name string, target *int32, observed *float64, unit string) {
+		if target == nil {
+			return
+		}
+
+		if observed == nil {
+			missing = append(missing, name)
+			table.Append([]string{name, fmt.Sprintf("%d%s", *target, unit), "?", fmt.Sprintf("ceil(%d * <observed> / %d)", current, *target)})
+			return
+		}
+
+		n, formula := hpaDesiredReplicas(current, *observed, *target)
+		table.Append([]string{name, fmt.Sprintf("%d%s", *target, unit), fmt.Sprintf("%s%s", strconv.FormatFloat(*observed, 'f', -1, 64), unit), formula})
+		if winner == "" || n > desired {
+			desired, winner = n, name
+		}
+	}
+
+	explain("CPU", autoscale.Cpu, observed.CPU, "%")
+	explain("Memory", autoscale.Memory, observed.Memory, "%")
+	if memory := autoscale.GetMemoryValue(); memory != "" {
+		// NOTE: --observed-memory is a percentage, which cannot be compared
+		// to an absolute target.
+		unexplained = append(unexplained, "Memory")
+		table.Append([]string{"Memory", memory, "?", "unknown (absolute target)"})
+	}
+	explain("RPS", autoscale.Rps, observed.RPS, " req/s")
+	table.Render()
+
+	minReplicas := autoscale.MinReplicas
+	for i, s := range autoscale.Schedules {
+		active := isScheduledWindowActive(s, now)
+		status := "inactive"
+		if active {
+			status = "active"
+		}
+
+		fmt.Fprintf(w, "Schedule window %d (min replicas: %d): %s\n", i+1, s.MinReplicas, status)
+		if active && s.MinReplicas > minReplicas {
+			minReplicas = s.MinReplicas
+		}
+	}
+
+	fmt.Fprintf(w, "Effective bounds: %d-%d replica(s)\n", minReplicas, autoscale.MaxReplicas)
+
+	switch {
+	case winner == "" && len(missing) > 0:
+		fmt.Fprintf(w, "Expected replicas: unknown, provide the observed values of: %s\n", strings.Join(missing, ", "))
+
+	case winner == "" && len(unexplained) > 0:
+		fmt.Fprintf(w, "Expected replicas: unknown, absolute targets of %s cannot be explained\n", strings.Join(unexplained, ", "))
+
+	case winner == "":
+		fmt.Fprintf(w, "Expected replicas: %d (min replicas)\n", minReplicas)
+
+	default:
+		expected, reason := min(max(desired, minReplicas), autoscale.MaxReplicas), fmt.Sprintf("requested by %s", winner)
+		if expected != desired {
+			reason = fmt.Sprintf("%s requested %d, bounded by the effective bounds", winner, desired)
+		}
+
+		if len(missing) > 0 {
+			fmt.Fprintf(w, "Expected replicas: at least %d (%s; missing observed values of: %s)\n", expected, reason, strings.Join(missing, ", "))
+			return
+		}
+
+		if len(unexplained) > 0 {
+			fmt.Fprintf(w, "Expected replicas: at least %d (%s; absolute targets not explained: %s)\n", expected, reason, strings.Join(unexplained, ", "))
+			return
+		}
+
+		fmt.Fprintf(w, "Expected replicas: %d (%s)\n", expected, reason)
+	}
+}
+
+func hpaDesiredReplicas(current int32, observed float64, target int32) (int32, string) {
+	formula := fmt.Sprintf("ceil(%d * %s / %d)", current, strconv.FormatFloat(observed, 'f', -1, 64), target)
+
+	ratio := observed / float64(target)
+	if math.Abs(ratio-1) <= hpaTolerance {
+		return current, fmt.Sprintf("%s ~ %d (within tolerance)", formula, current)
+	}
+
+	n := int32(math.Ceil(float64(current) * ratio))
+	return n, fmt.Sprintf("%s = %d", formula, n)
+}
+
+// isScheduledWindowActive returns whether now is between the last start and
+// end of the window, i.e. the window ends before it starts again.
+func isScheduledWindowActive(s autogenerated.ScheduledWindow, now time.Time) bool {
+	prefix := ""
+	if tz := s.GetTimezone(); tz != "" {
+		prefix = fmt.Sprintf("CRON_TZ=%s ", tz)
+	}
+
+	start, err := cronParser.Parse(prefix + s.Start)
+	if err != nil {
+		return false
+	}
+
+	end, err := cronParser.Parse(prefix + s.End)
 	if err != nil {
-		return fmt.Errorf("could not get autoscale from RPaaS API: %w", err)
+		return false
 	}
 
-	if outputAsJSON := c.Bool("json"); outputAsJSON {
-		return writeJSON(c.App.Writer, autoscale)
+	return end.Next(now).Before(start.Next(now))
+}
+
+// scheduledWindowOccurrence is when a scheduled window starts and ends, where
+// a zero Start means the window was already active.
+type scheduledWindowOccurrence struct {
+	Start time.Time
+	End   time.Time
+}
+
+// nextScheduledWindows returns the next n occurrences of the window after now,
+// in the window's timezone. An active window counts as the first occurrence.
+func nextScheduledWindows(s autogenerated.ScheduledWindow, now time.Time, n int) ([]scheduledWindowOccurrence, error) {
+	location := time.UTC
+	prefix := ""
+	if tz := s.GetTimezone(); tz != "" {
+		var err error
+		if location, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", tz, err)
+		}
+
+		prefix = fmt.Sprintf("CRON_TZ=%s ", tz)
+	}
+
+	start, err := cronParser.Parse(prefix + s.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start %q: %w", s.Start, err)
+	}
+
+	end, err := cronParser.Parse(prefix + s.End)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end %q: %w", s.End, err)
+	}
+
+	var occurrences []scheduledWindowOccurrence
+	t := now.In(location)
+	if isScheduledWindowActive(s, now) {
+		t = end.Next(t)
+		occurrences = append(occurrences, scheduledWindowOccurrence{End: t})
+	}
+
+	// NOTE: a window whose end never fires again (e.g. on February 30th) has
+	// no further occurrences.
+	for len(occurrences) < n && !t.IsZero() {
+		o := scheduledWindowOccurrence{Start: start.Next(t)}
+		if o.Start.IsZero() {
+			// NOTE: the schedule never fires again (e.g. on February 30th).
+			break
+		}
+
+		o.End = end.Next(o.Start)
+		occurrences = append(occurrences, o)
+		t = o.End
+	}
+
+	return occurrences, nil
+}
+
+func writeNextScheduledWindows(w io.Writer, autoscale *autogenerated.Autoscale, n int, now time.Time) error {
+	if autoscale == nil || len(autoscale.Schedules) == 0 {
+		fmt.Fprintln(w, "\nNo scheduled windows configured")
+		return nil
+	}
+
+	const layout = "Mon, 02 Jan 2006 15:04 MST"
+
+	for i, s := range autoscale.Schedules {
+		occurrences, err := nextScheduledWindows(s, now, n)
+		if err != nil {
+			return fmt.Errorf("could not compute the next occurrences of window %d: %w", i+1, err)
+		}
+
+		timezone := s.GetTimezone()
+		if timezone == "" {
+			timezone = "UTC"
+		}
+
+		fmt.Fprintf(w, "\nNext occurrences of window %d (min replicas: %d, timezone: %s):\n", i+1, s.MinReplicas, timezone)
+
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"Start", "End"})
+		table.SetAutoFormatHeaders(false)
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAutoWrapText(false)
+		for _, o := range occurrences {
+			start := "active now"
+			if !o.Start.IsZero() {
+				start = o.Start.Format(layout)
+			}
+
+			end := "never"
+			if !o.End.IsZero() {
+				end = o.End.Format(layout)
+			}
+
+			table.Append([]string{start, end})
+		}
+		table.Render()
 	}
 
-	writeAutoscale(c.App.Writer, autoscale)
 	return nil
 }
 
@@ -208,22 +918,81 @@ func NewCmdRemoveAutoscale() *cli.Command {
 				Usage:    "the reverse proxy instance name",
 				Required: true,
 			},
+			&cli.BoolFlag{
+				Name:  "keep-replicas",
+				Usage: "pins the number of replicas to the current ready ones, preventing the instance from going back to its static replicas",
+			},
 		},
 		Action: runRemoveAutoscale,
 	}
 }
 
 func runRemoveAutoscale(c *cli.Context) error {
-	_, err := NewAutogeneratedClient(c).RpaasApi.RemoveAutoscale(c.Context, c.String("instance")).Execute()
+	var ready int32
+	if c.Bool("keep-replicas") {
+		var err error
+		if ready, err = getReadyReplicas(c); err != nil {
+			return err
+		}
+
+		if ready == 0 {
+			return fmt.Errorf("cannot keep the replicas as the instance has no ready replicas")
+		}
+	}
+
+	client, err := NewAutogeneratedClient(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.RpaasApi.RemoveAutoscale(c.Context, c.String("instance")).Execute()
 	if err != nil {
 		return fmt.Errorf("could not delete the autoscale on RPaaS API: %w", err)
 	}
 
 	fmt.Fprintf(c.App.Writer, "Autoscale of %s successfully removed\n", formatInstanceName(c))
+
+	if ready == 0 {
+		return nil
+	}
+
+	// NOTE: the regular client was set up by getReadyReplicas above.
+	rpaasClient, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	err = rpaasClient.Scale(c.Context, rpaasclient.ScaleArgs{Instance: c.String("instance"), Replicas: ready})
+	if err != nil {
+		return fmt.Errorf("autoscale was removed but the replicas could not be pinned to %d: %w", ready, err)
+	}
+
+	fmt.Fprintf(c.App.Writer, "Replicas of %s pinned to %d\n", formatInstanceName(c), ready)
 	return nil
 }
 
-func writeAutoscale(w io.Writer, autoscale *autogenerated.Autoscale) {
+func getReadyReplicas(c *cli.Context) (int32, error) {
+	if err := setupClient(c); err != nil {
+		return 0, err
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := client.Info(c.Context, rpaasclient.InfoArgs{Instance: c.String("instance")})
+	if err != nil {
+		return 0, err
+	}
+
+	ready, _ := countReplicas(info.Pods, nil)
+	return ready, nil
+}
+
+// writeAutoscale writes the autoscale settings as a table fitting into width
+// columns, where zero means no limit.
+func writeAutoscale(w io.Writer, autoscale *autogenerated.Autoscale, width int) {
 	if autoscale == nil {
 		return
 	}
@@ -231,23 +1000,42 @@ func writeAutoscale(w io.Writer, autoscale *autogenerated.Autoscale) {
 	fmt.Fprintf(w, "min replicas: %d\n", autoscale.MinReplicas)
 	fmt.Fprintf(w, "max replicas: %d\n", autoscale.MaxReplicas)
 
-	table := tablewriter.NewWriter(w)
-	table.SetHeader([]string{"Triggers", "trigger details"})
-	table.SetAutoFormatHeaders(false)
-	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
-	table.SetAutoWrapText(false)
-	table.SetRowLine(false)
+	header := []string{"Triggers", "trigger details"}
+	var rows [][]string
 
 	if autoscale.Cpu != nil {
-		table.Append([]string{"CPU", fmt.Sprintf("%d%%", int(*autoscale.Cpu))})
+		rows = append(rows, []string{"CPU", fmt.Sprintf("%d%%", int(*autoscale.Cpu))})
 	}
 
 	if autoscale.Memory != nil {
-		table.Append([]string{"Memory", fmt.Sprintf("%d%%", int(*autoscale.Memory))})
+		rows = append(rows, []string{"Memory", fmt.Sprintf("%d%%", int(*autoscale.Memory))})
+	}
+
+	if memory := autoscale.GetMemoryValue(); memory != "" {
+		rows = append(rows, []string{"Memory", memory})
 	}
 
 	if autoscale.Rps != nil {
-		table.Append([]string{"RPS", fmt.Sprintf("%d req/s", int(*autoscale.Rps))})
+		rps := fmt.Sprintf("%d req/s", int(*autoscale.Rps))
+		if window := autoscale.GetRpsWindow(); window != "" {
+			aggregation := autoscale.GetRpsAggregation()
+			if aggregation == "" {
+				aggregation = "avg"
+			}
+
+			rps = fmt.Sprintf("%s (%s over %s)", rps, aggregation, window)
+		}
+
+		rows = append(rows, []string{"RPS", rps})
+	}
+
+	if source := autoscale.GetMirrorInstance(); source != "" {
+		ratio := 1.0
+		if autoscale.MirrorRatio != nil {
+			ratio = *autoscale.MirrorRatio
+		}
+
+		rows = append(rows, []string{"Mirror", fmt.Sprintf("%s (%v replica(s) per replica)", source, ratio)})
 	}
 
 	var schedules strings.Builder
@@ -274,9 +1062,32 @@ func writeAutoscale(w io.Writer, autoscale *autogenerated.Autoscale) {
 	}
 
 	if text := schedules.String(); text != "" {
-		table.Append([]string{"Schedule(s)", text})
+		rows = append(rows, []string{"Schedule(s)", text})
+	}
+
+	if width > 0 {
+		// NOTE: the details column gets the width left by the triggers column
+		// and the borders, i.e. "| " + triggers + " | " + details + " |".
+		triggersWidth := len(header[0])
+		for _, row := range rows {
+			triggersWidth = max(triggersWidth, len(row[0]))
+		}
 
+		detailsWidth := max(width-triggersWidth-7, len(header[1]))
+		for _, row := range rows {
+			row[1] = wrapText(row[1], detailsWidth)
+		}
 	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_CENTER)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(false)
+	// NOTE: wrapped lines holding only a number must not be right-aligned.
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.AppendBulk(rows)
 	table.Render()
 }
 
@@ -7,15 +7,22 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"k8s.io/utils/pointer"
 
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func TestGetAutoscale(t *testing.T) {
@@ -61,6 +68,144 @@ max replicas: 5
 `,
 		},
 
+		"when autoscale has a memory value target": {
+			args: []string{"autoscale", "info", "-s", "my-service", "-i", "my-instance"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(autogenerated.Autoscale{
+					MinReplicas: 2,
+					MaxReplicas: 5,
+					MemoryValue: autogenerated.PtrString("512Mi"),
+				})
+			}),
+			expected: `min replicas: 2
+max replicas: 5
++----------+-----------------+
+| Triggers | trigger details |
++----------+-----------------+
+| Memory   | 512Mi           |
++----------+-----------------+
+`,
+		},
+
+		"projecting a field of the JSON output": {
+			args: []string{"autoscale", "info", "-s", "my-service", "-i", "my-instance", "--output", "json", "--field", "maxReplicas"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(autogenerated.Autoscale{
+					MinReplicas: 2,
+					MaxReplicas: 5,
+					Schedules: []autogenerated.ScheduledWindow{
+						{MinReplicas: 1, Start: "00 08 * * 1-5", End: "00 20 * * 1-5"},
+					},
+				})
+			}),
+			expected: "5\n",
+		},
+
+		"projecting a nested field of the JSON output": {
+			args: []string{"autoscale", "info", "-s", "my-service", "-i", "my-instance", "--json", "--field", "schedules[0].start"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(autogenerated.Autoscale{
+					MinReplicas: 2,
+					MaxReplicas: 5,
+					Schedules: []autogenerated.ScheduledWindow{
+						{MinReplicas: 1, Start: "00 08 * * 1-5", End: "00 20 * * 1-5"},
+					},
+				})
+			}),
+			expected: "00 08 * * 1-5\n",
+		},
+
+		"projecting a missing field of the JSON output": {
+			args: []string{"autoscale", "info", "-s", "my-service", "-i", "my-instance", "-o", "json", "--field", "schedules[1].start"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(autogenerated.Autoscale{
+					MinReplicas: 2,
+					MaxReplicas: 5,
+					Schedules: []autogenerated.ScheduledWindow{
+						{MinReplicas: 1, Start: "00 08 * * 1-5", End: "00 20 * * 1-5"},
+					},
+				})
+			}),
+			expectedError: `field "schedules[1].start" not found in the output`,
+		},
+
+		"projecting a missing field allowing it": {
+			args: []string{"autoscale", "info", "-s", "my-service", "-i", "my-instance", "-o", "json", "--field", "rps", "--allow-missing"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(autogenerated.Autoscale{
+					MinReplicas: 2,
+					MaxReplicas: 5,
+					Schedules: []autogenerated.ScheduledWindow{
+						{MinReplicas: 1, Start: "00 08 * * 1-5", End: "00 20 * * 1-5"},
+					},
+				})
+			}),
+			expected: "",
+		},
+
+		"projecting a field without JSON output": {
+			args: []string{"autoscale", "info", "-s", "my-service", "-i", "my-instance", "--field", "maxReplicas"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(autogenerated.Autoscale{
+					MinReplicas: 2,
+					MaxReplicas: 5,
+					Schedules: []autogenerated.ScheduledWindow{
+						{MinReplicas: 1, Start: "00 08 * * 1-5", End: "00 20 * * 1-5"},
+					},
+				})
+			}),
+			expectedError: "--field and --allow-missing can only be used along with --output json",
+		},
+
+		"with RPS window and aggregation": {
+			args: []string{"autoscale", "info", "-s", "my-service", "-i", "my-instance"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(autogenerated.Autoscale{
+					MinReplicas:    2,
+					MaxReplicas:    5,
+					Rps:            autogenerated.PtrInt32(100),
+					RpsWindow:      autogenerated.PtrString("2m0s"),
+					RpsAggregation: autogenerated.PtrString("p95"),
+				})
+			}),
+			expected: `min replicas: 2
+max replicas: 5
++----------+---------------------------+
+| Triggers |      trigger details      |
++----------+---------------------------+
+| RPS      | 100 req/s (p95 over 2m0s) |
++----------+---------------------------+
+`,
+		},
+
+		"mirroring another instance": {
+			args: []string{"autoscale", "info", "-s", "my-service", "-i", "my-instance"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(autogenerated.Autoscale{
+					MinReplicas:    1,
+					MaxReplicas:    20,
+					MirrorInstance: autogenerated.PtrString("other-instance"),
+					MirrorRatio:    autogenerated.PtrFloat64(0.5),
+				})
+			}),
+			expected: `min replicas: 1
+max replicas: 20
++----------+---------------------------------------------+
+| Triggers |               trigger details               |
++----------+---------------------------------------------+
+| Mirror   | other-instance (0.5 replica(s) per replica) |
++----------+---------------------------------------------+
+`,
+		},
+
 		"with schedules": {
 			args: []string{"autoscale", "info", "-s", "my-service", "-i", "my-instance"},
 			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -199,6 +344,87 @@ func TestRemoveAutoscale(t *testing.T) {
 	}
 }
 
+func TestRemoveAutoscaleKeepingReplicas(t *testing.T) {
+	var removed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" && r.URL.Path == "/resources/my-instance/autoscale" {
+			removed = true
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name            string
+		pods            []clientTypes.Pod
+		scaleErr        error
+		expected        string
+		expectedError   string
+		expectedRemoved bool
+		expectedScale   int32
+	}{
+		{
+			name: "pinning the replicas to the ready ones",
+			pods: []clientTypes.Pod{
+				{Name: "my-instance-abc", Status: "Running", Ready: true},
+				{Name: "my-instance-def", Status: "Running", Ready: true},
+				{Name: "my-instance-ghi", Status: "Running", Ready: true},
+				{Name: "my-instance-jkl", Status: "Pending"},
+			},
+			expected:        "Autoscale of my-service/my-instance successfully removed\nReplicas of my-service/my-instance pinned to 3\n",
+			expectedRemoved: true,
+			expectedScale:   3,
+		},
+		{
+			name:          "when there are no ready replicas",
+			pods:          []clientTypes.Pod{{Name: "my-instance-abc", Status: "Pending"}},
+			expectedError: "cannot keep the replicas as the instance has no ready replicas",
+		},
+		{
+			name:            "when the replicas cannot be pinned",
+			pods:            []clientTypes.Pod{{Name: "my-instance-abc", Status: "Running", Ready: true}},
+			scaleErr:        fmt.Errorf("some error"),
+			expectedError:   "autoscale was removed but the replicas could not be pinned to 1: some error",
+			expectedRemoved: true,
+			expectedScale:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removed = false
+
+			var scaled int32
+			client := &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*clientTypes.InstanceInfo, error) {
+					assert.Equal(t, "my-instance", args.Instance)
+					return &clientTypes.InstanceInfo{Replicas: pointer.Int32(1), Pods: tt.pods}, nil
+				},
+				FakeScale: func(args rpaasclient.ScaleArgs) error {
+					assert.True(t, removed, "autoscale must be removed before scaling")
+					scaled = args.Replicas
+					return tt.scaleErr
+				},
+			}
+
+			var stdout bytes.Buffer
+			err := NewApp(&stdout, io.Discard, client).Run([]string{"rpaasv2", "--rpaas-url", server.URL, "autoscale", "remove", "-s", "my-service", "-i", "my-instance", "--keep-replicas"})
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, stdout.String())
+			}
+
+			assert.Equal(t, tt.expectedRemoved, removed)
+			assert.Equal(t, tt.expectedScale, scaled)
+		})
+	}
+}
+
 func TestUpdateAutoscale(t *testing.T) {
 	t.Parallel()
 
@@ -255,6 +481,102 @@ func TestUpdateAutoscale(t *testing.T) {
 			expected: "Autoscale of my-service/my-instance successfully updated!\n",
 		},
 
+		"with memory value": {
+			args: []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--min", "2", "--max", "10", "--memory-value", "0.5Gi"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var data map[string]any
+				err := json.NewDecoder(r.Body).Decode(&data)
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"minReplicas": float64(2), "maxReplicas": float64(10), "memoryValue": "512Mi"}, data)
+
+				w.WriteHeader(http.StatusNoContent)
+			}),
+			expected: "Autoscale of my-service/my-instance successfully updated!\n",
+		},
+
+		"with memory value and percentage at once": {
+			args:          []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--max", "10", "--memory", "80", "--memory-value", "512Mi"},
+			handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			expectedError: "--memory cannot be used along with --memory-value",
+		},
+
+		"with invalid memory value": {
+			args:          []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--max", "10", "--memory-value", "512MB"},
+			handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			expectedError: `invalid --memory-value "512MB": quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'`,
+		},
+
+		"with zero memory value": {
+			args:          []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--max", "10", "--memory-value", "0"},
+			handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			expectedError: "--memory-value must be greater than zero",
+		},
+
+		"with RPS window and aggregation": {
+			args: []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--min", "2", "--max", "10", "--rps", "100", "--rps-window", "2m", "--rps-aggregation", "max"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var data map[string]any
+				err := json.NewDecoder(r.Body).Decode(&data)
+				require.NoError(t, err)
+
+				expected := map[string]any{
+					"minReplicas":    float64(2),
+					"maxReplicas":    float64(10),
+					"rps":            float64(100),
+					"rpsWindow":      "2m0s",
+					"rpsAggregation": "max",
+				}
+				assert.Equal(t, expected, data)
+
+				w.WriteHeader(http.StatusNoContent)
+			}),
+			expected: "Autoscale of my-service/my-instance successfully updated!\n",
+		},
+
+		"with non-positive RPS window": {
+			args:          []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--max", "10", "--rps", "100", "--rps-window", "0s"},
+			handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			expectedError: "--rps-window must be a positive duration",
+		},
+
+		"with unknown RPS aggregation": {
+			args:          []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--max", "10", "--rps", "100", "--rps-window", "1m", "--rps-aggregation", "median"},
+			handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			expectedError: `unknown --rps-aggregation "median", it must be one of: avg, max, p95`,
+		},
+
+		"mirroring another instance": {
+			args: []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--min", "1", "--max", "20", "--mirror-instance", "other-instance", "--mirror-ratio", "0.5"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var data map[string]any
+				err := json.NewDecoder(r.Body).Decode(&data)
+				require.NoError(t, err)
+
+				expected := map[string]any{
+					"minReplicas":    float64(1),
+					"maxReplicas":    float64(20),
+					"mirrorInstance": "other-instance",
+					"mirrorRatio":    float64(0.5),
+				}
+				assert.Equal(t, expected, data)
+
+				w.WriteHeader(http.StatusNoContent)
+			}),
+			expected: "Autoscale of my-service/my-instance successfully updated!\n",
+		},
+
+		"with non-positive mirror ratio": {
+			args:          []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--max", "10", "--mirror-instance", "other-instance", "--mirror-ratio", "0"},
+			handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			expectedError: "--mirror-ratio must be greater than zero",
+		},
+
+		"with mirror ratio but no instance to mirror": {
+			args:          []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--max", "10", "--cpu", "80", "--mirror-ratio", "2"},
+			handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			expectedError: "--mirror-ratio can only be used along with --mirror-instance",
+		},
+
 		"with schedules": {
 			args: []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--min", "0", "--max", "10", "--schedule", `{"minReplicas": 1, "start": "00 08 * * 1-5", "end": "00 20 * * 1-5"}`, "--schedule", `{"minReplicas": 3, "start": "00 12 * * 1-5", "end": "00 13 * * 1-5"}`},
 			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -278,6 +600,96 @@ func TestUpdateAutoscale(t *testing.T) {
 			}),
 			expected: "Autoscale of my-service/my-instance successfully updated!\n",
 		},
+
+		"without --max nor --copy-from": {
+			args:          []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--min", "2"},
+			handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			expectedError: "--max must be provided unless --copy-from is set",
+		},
+
+		"copying from another instance and overriding some fields": {
+			args: []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--copy-from", "other-instance", "--max", "30", "--cpu", "0"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					assert.Contains(t, r.URL.String(), "/resources/other-instance/autoscale")
+					if strings.HasPrefix(r.URL.Path, "/1.0/services/") {
+						assert.Equal(t, "/1.0/services/my-service/proxy/other-instance", r.URL.Path)
+					}
+
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(autogenerated.Autoscale{
+						MinReplicas: 2,
+						MaxReplicas: 10,
+						Cpu:         autogenerated.PtrInt32(75),
+						Rps:         autogenerated.PtrInt32(100),
+						Schedules:   []autogenerated.ScheduledWindow{{MinReplicas: 5, Start: "00 08 * * 1-5", End: "00 20 * * 1-5"}},
+					})
+					return
+				}
+
+				assert.Contains(t, r.URL.String(), "/resources/my-instance/autoscale")
+
+				var data map[string]any
+				err := json.NewDecoder(r.Body).Decode(&data)
+				require.NoError(t, err)
+
+				expected := map[string]any{
+					"minReplicas": float64(2),
+					"maxReplicas": float64(30),
+					"rps":         float64(100),
+					"schedules": []any{
+						map[string]any{"minReplicas": float64(5), "start": "00 08 * * 1-5", "end": "00 20 * * 1-5"},
+					},
+				}
+				assert.Equal(t, expected, data)
+
+				w.WriteHeader(http.StatusNoContent)
+			}),
+			expected: "Autoscale of my-service/my-instance successfully updated!\n",
+		},
+
+		"copying from an instance of another service": {
+			args: []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--copy-from", "other-instance", "--copy-from-service", "other-service"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					if strings.HasPrefix(r.URL.Path, "/1.0/services/") {
+						assert.Equal(t, "/1.0/services/other-service/proxy/other-instance", r.URL.Path)
+					}
+
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(autogenerated.Autoscale{MinReplicas: 1, MaxReplicas: 5, Memory: autogenerated.PtrInt32(80)})
+					return
+				}
+
+				if strings.HasPrefix(r.URL.Path, "/1.0/services/") {
+					assert.Equal(t, "/1.0/services/my-service/proxy/my-instance", r.URL.Path)
+				}
+
+				var data map[string]any
+				err := json.NewDecoder(r.Body).Decode(&data)
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"minReplicas": float64(1), "maxReplicas": float64(5), "memory": float64(80)}, data)
+
+				w.WriteHeader(http.StatusNoContent)
+			}),
+			expected: "Autoscale of my-service/my-instance successfully updated!\n",
+		},
+
+		"copying from an instance without autoscale": {
+			args: []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--copy-from", "other-instance"},
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, http.MethodGet, r.Method)
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, "null")
+			}),
+			expectedError: "instance other-instance has no autoscale configured",
+		},
+
+		"using --copy-from-service without --copy-from": {
+			args:          []string{"autoscale", "update", "-s", "my-service", "-i", "my-instance", "--max", "10", "--copy-from-service", "other-service"},
+			handler:       http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			expectedError: "--copy-from-service can only be used along with --copy-from",
+		},
 	}
 
 	for _, serverGen := range AllRpaasAPIServerGenerators {
@@ -303,3 +715,546 @@ func TestUpdateAutoscale(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAutoscaleExplain(t *testing.T) {
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC) }
+
+	autoscale := autogenerated.Autoscale{
+		MinReplicas: 2,
+		MaxReplicas: 10,
+		Cpu:         autogenerated.PtrInt32(50),
+		Memory:      autogenerated.PtrInt32(70),
+		Rps:         autogenerated.PtrInt32(100),
+		Schedules: []autogenerated.ScheduledWindow{
+			{MinReplicas: 6, Start: "00 08 * * *", End: "00 20 * * *"},
+			{MinReplicas: 8, Start: "00 22 * * *", End: "00 23 * * *", Timezone: pointer.String("America/Sao_Paulo")},
+		},
+	}
+
+	autoscaleTable := `min replicas: 2
+max replicas: 10
++-------------+------------------------------------+
+|  Triggers   |          trigger details           |
++-------------+------------------------------------+
+| CPU         | 50%                                |
+| Memory      | 70%                                |
+| RPS         | 100 req/s                          |
+| Schedule(s) | Window 1:                          |
+|             |   Min replicas: 6                  |
+|             |   Start: At 08:00 AM (00 08 * * *) |
+|             |   End: At 08:00 PM (00 20 * * *)   |
+|             |                                    |
+|             | Window 2:                          |
+|             |   Min replicas: 8                  |
+|             |   Start: At 10:00 PM (00 22 * * *) |
+|             |   End: At 11:00 PM (00 23 * * *)   |
+|             |   Timezone: America/Sao_Paulo      |
++-------------+------------------------------------+
+`
+
+	tests := map[string]struct {
+		args          []string
+		autoscale     autogenerated.Autoscale
+		object        string
+		expected      string
+		expectedError string
+	}{
+		"when some observed values are missing": {
+			args:      []string{"--explain", "--current-replicas", "4", "--observed-cpu", "80", "--observed-rps", "90"},
+			autoscale: autoscale,
+			expected: autoscaleTable + `
+Current replicas: 4
++---------+-----------+----------+-------------------------------------------+
+| Trigger | Target    | Observed | Desired replicas                          |
++---------+-----------+----------+-------------------------------------------+
+| CPU     | 50%       | 80%      | ceil(4 * 80 / 50) = 7                     |
+| Memory  | 70%       | ?        | ceil(4 * <observed> / 70)                 |
+| RPS     | 100 req/s | 90 req/s | ceil(4 * 90 / 100) ~ 4 (within tolerance) |
++---------+-----------+----------+-------------------------------------------+
+Schedule window 1 (min replicas: 6): active
+Schedule window 2 (min replicas: 8): inactive
+Effective bounds: 6-10 replica(s)
+Expected replicas: at least 7 (requested by CPU; missing observed values of: Memory)
+`,
+		},
+		"when the desired replicas exceed the max replicas": {
+			args:      []string{"--explain", "--current-replicas", "4", "--observed-cpu", "200", "--observed-memory", "10", "--observed-rps", "100"},
+			autoscale: autoscale,
+			expected: autoscaleTable + `
+Current replicas: 4
++---------+-----------+-----------+--------------------------------------------+
+| Trigger | Target    | Observed  | Desired replicas                           |
++---------+-----------+-----------+--------------------------------------------+
+| CPU     | 50%       | 200%      | ceil(4 * 200 / 50) = 16                    |
+| Memory  | 70%       | 10%       | ceil(4 * 10 / 70) = 1                      |
+| RPS     | 100 req/s | 100 req/s | ceil(4 * 100 / 100) ~ 4 (within tolerance) |
++---------+-----------+-----------+--------------------------------------------+
+Schedule window 1 (min replicas: 6): active
+Schedule window 2 (min replicas: 8): inactive
+Effective bounds: 6-10 replica(s)
+Expected replicas: 10 (CPU requested 16, bounded by the effective bounds)
+`,
+		},
+		"when the metrics come from the HorizontalPodAutoscaler": {
+			args:      []string{"--explain", "--observed-rps", "90"},
+			autoscale: autoscale,
+			object: `{
+				"apiVersion": "autoscaling/v2",
+				"kind": "HorizontalPodAutoscaler",
+				"metadata": {"name": "my-instance"},
+				"status": {
+					"currentReplicas": 4,
+					"currentMetrics": [
+						{"type": "Resource", "resource": {"name": "cpu", "current": {"averageUtilization": 80, "averageValue": "400m"}}},
+						{"type": "Resource", "resource": {"name": "memory", "current": {"averageUtilization": 10, "averageValue": "100Mi"}}}
+					]
+				}
+			}`,
+			expected: autoscaleTable + `
+Current replicas: 4
++---------+-----------+----------+-------------------------------------------+
+| Trigger | Target    | Observed | Desired replicas                          |
++---------+-----------+----------+-------------------------------------------+
+| CPU     | 50%       | 80%      | ceil(4 * 80 / 50) = 7                     |
+| Memory  | 70%       | 10%      | ceil(4 * 10 / 70) = 1                     |
+| RPS     | 100 req/s | 90 req/s | ceil(4 * 90 / 100) ~ 4 (within tolerance) |
++---------+-----------+----------+-------------------------------------------+
+Schedule window 1 (min replicas: 6): active
+Schedule window 2 (min replicas: 8): inactive
+Effective bounds: 6-10 replica(s)
+Expected replicas: 7 (requested by CPU)
+`,
+		},
+		"when the observed flags override the HorizontalPodAutoscaler": {
+			args:      []string{"--explain", "--current-replicas", "2", "--observed-cpu", "100"},
+			autoscale: autogenerated.Autoscale{MinReplicas: 1, MaxReplicas: 5, Cpu: autogenerated.PtrInt32(50)},
+			object: `{
+				"apiVersion": "autoscaling/v2",
+				"kind": "HorizontalPodAutoscaler",
+				"metadata": {"name": "my-instance"},
+				"status": {
+					"currentReplicas": 4,
+					"currentMetrics": [{"type": "Resource", "resource": {"name": "cpu", "current": {"averageUtilization": 10}}}]
+				}
+			}`,
+			expected: `min replicas: 1
+max replicas: 5
++----------+-----------------+
+| Triggers | trigger details |
++----------+-----------------+
+| CPU      | 50%             |
++----------+-----------------+
+
+Current replicas: 2
++---------+--------+----------+------------------------+
+| Trigger | Target | Observed | Desired replicas       |
++---------+--------+----------+------------------------+
+| CPU     | 50%    | 100%     | ceil(2 * 100 / 50) = 4 |
++---------+--------+----------+------------------------+
+Effective bounds: 1-5 replica(s)
+Expected replicas: 4 (requested by CPU)
+`,
+		},
+		"when current replicas come from the instance and nothing is observed": {
+			args:      []string{"--explain"},
+			autoscale: autogenerated.Autoscale{MinReplicas: 1, MaxReplicas: 5, Cpu: autogenerated.PtrInt32(75)},
+			expected: `min replicas: 1
+max replicas: 5
++----------+-----------------+
+| Triggers | trigger details |
++----------+-----------------+
+| CPU      | 75%             |
++----------+-----------------+
+
+Current replicas: 3
++---------+--------+----------+---------------------------+
+| Trigger | Target | Observed | Desired replicas          |
++---------+--------+----------+---------------------------+
+| CPU     | 75%    | ?        | ceil(3 * <observed> / 75) |
++---------+--------+----------+---------------------------+
+Effective bounds: 1-5 replica(s)
+Expected replicas: unknown, provide the observed values of: CPU
+`,
+		},
+		"when autoscale has a memory value target": {
+			args:      []string{"--explain", "--current-replicas", "4", "--observed-cpu", "80"},
+			autoscale: autogenerated.Autoscale{MinReplicas: 1, MaxReplicas: 10, Cpu: autogenerated.PtrInt32(50), MemoryValue: autogenerated.PtrString("512Mi")},
+			expected: `min replicas: 1
+max replicas: 10
++----------+-----------------+
+| Triggers | trigger details |
++----------+-----------------+
+| CPU      | 50%             |
+| Memory   | 512Mi           |
++----------+-----------------+
+
+Current replicas: 4
++---------+--------+----------+---------------------------+
+| Trigger | Target | Observed | Desired replicas          |
++---------+--------+----------+---------------------------+
+| CPU     | 50%    | 80%      | ceil(4 * 80 / 50) = 7     |
+| Memory  | 512Mi  | ?        | unknown (absolute target) |
++---------+--------+----------+---------------------------+
+Effective bounds: 1-10 replica(s)
+Expected replicas: at least 7 (requested by CPU; absolute targets not explained: Memory)
+`,
+		},
+		"when instance has no autoscale": {
+			args:          []string{"--explain", "--current-replicas", "1"},
+			expectedError: "instance my-service/my-instance has no autoscale configured",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if strings.HasSuffix(r.URL.Path, "/info") {
+					fmt.Fprintf(w, `{"replicas": 3}`)
+					return
+				}
+
+				if strings.HasSuffix(r.URL.Path, "/autoscale/object") {
+					if tt.object == "" {
+						w.WriteHeader(http.StatusNotFound)
+						fmt.Fprintf(w, `{"message": "no autoscaler found for instance \"my-instance\""}`)
+						return
+					}
+
+					fmt.Fprint(w, tt.object)
+					return
+				}
+
+				if tt.autoscale.MaxReplicas == 0 {
+					fmt.Fprintf(w, "null")
+					return
+				}
+
+				json.NewEncoder(w).Encode(tt.autoscale)
+			}))
+			defer server.Close()
+
+			var stdout bytes.Buffer
+			args := append([]string{"rpaasv2", "--rpaas-url", server.URL, "autoscale", "info", "-s", "my-service", "-i", "my-instance"}, tt.args...)
+			err := NewApp(&stdout, io.Discard, nil).Run(args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
+
+func TestGetAutoscaleNextWindows(t *testing.T) {
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	// NOTE: daylight saving time starts on 2023-03-12 at 02:00 in New York.
+	timeNow = func() time.Time { return time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC) }
+
+	autoscale := autogenerated.Autoscale{
+		MinReplicas: 2,
+		MaxReplicas: 10,
+		Schedules: []autogenerated.ScheduledWindow{
+			{MinReplicas: 6, Start: "00 08 * * *", End: "00 20 * * *"},
+			{MinReplicas: 8, Start: "30 01 * * *", End: "30 03 * * *", Timezone: pointer.String("America/New_York")},
+			// NOTE: 02:30 doesn't exist in New York on 2023-03-12.
+			{MinReplicas: 4, Start: "30 02 * * *", End: "45 02 * * *", Timezone: pointer.String("America/New_York")},
+		},
+	}
+
+	tests := map[string]struct {
+		args          []string
+		autoscale     autogenerated.Autoscale
+		expected      string
+		expectedError string
+	}{
+		"with an invalid number of windows": {
+			args:          []string{"--next-windows", "0"},
+			expectedError: "--next-windows must be greater than zero",
+		},
+		"along with JSON output": {
+			args:          []string{"--next-windows", "3", "--json"},
+			expectedError: "--next-windows cannot be used along with --json",
+		},
+		"with an invalid timezone": {
+			args: []string{"--next-windows", "1"},
+			autoscale: autogenerated.Autoscale{
+				MinReplicas: 1,
+				MaxReplicas: 5,
+				Schedules:   []autogenerated.ScheduledWindow{{MinReplicas: 2, Start: "00 08 * * *", End: "00 20 * * *", Timezone: pointer.String("Mars/Olympus_Mons")}},
+			},
+			expectedError: `could not compute the next occurrences of window 1: invalid timezone "Mars/Olympus_Mons": unknown time zone Mars/Olympus_Mons`,
+		},
+		"without scheduled windows": {
+			args:      []string{"--next-windows", "3"},
+			autoscale: autogenerated.Autoscale{MinReplicas: 1, MaxReplicas: 5, Cpu: autogenerated.PtrInt32(75)},
+			expected: `min replicas: 1
+max replicas: 5
++----------+-----------------+
+| Triggers | trigger details |
++----------+-----------------+
+| CPU      | 75%             |
++----------+-----------------+
+
+No scheduled windows configured
+`,
+		},
+		"across a daylight saving time change": {
+			args:      []string{"--next-windows", "3"},
+			autoscale: autoscale,
+			expected: `min replicas: 2
+max replicas: 10
++-------------+------------------------------------+
+|  Triggers   |          trigger details           |
++-------------+------------------------------------+
+| Schedule(s) | Window 1:                          |
+|             |   Min replicas: 6                  |
+|             |   Start: At 08:00 AM (00 08 * * *) |
+|             |   End: At 08:00 PM (00 20 * * *)   |
+|             |                                    |
+|             | Window 2:                          |
+|             |   Min replicas: 8                  |
+|             |   Start: At 01:30 AM (30 01 * * *) |
+|             |   End: At 03:30 AM (30 03 * * *)   |
+|             |   Timezone: America/New_York       |
+|             |                                    |
+|             | Window 3:                          |
+|             |   Min replicas: 4                  |
+|             |   Start: At 02:30 AM (30 02 * * *) |
+|             |   End: At 02:45 AM (45 02 * * *)   |
+|             |   Timezone: America/New_York       |
++-------------+------------------------------------+
+
+Next occurrences of window 1 (min replicas: 6, timezone: UTC):
++----------------------------+----------------------------+
+| Start                      | End                        |
++----------------------------+----------------------------+
+| active now                 | Fri, 10 Mar 2023 20:00 UTC |
+| Sat, 11 Mar 2023 08:00 UTC | Sat, 11 Mar 2023 20:00 UTC |
+| Sun, 12 Mar 2023 08:00 UTC | Sun, 12 Mar 2023 20:00 UTC |
++----------------------------+----------------------------+
+
+Next occurrences of window 2 (min replicas: 8, timezone: America/New_York):
++----------------------------+----------------------------+
+| Start                      | End                        |
++----------------------------+----------------------------+
+| Sat, 11 Mar 2023 01:30 EST | Sat, 11 Mar 2023 03:30 EST |
+| Sun, 12 Mar 2023 01:30 EST | Sun, 12 Mar 2023 03:30 EDT |
+| Mon, 13 Mar 2023 01:30 EDT | Mon, 13 Mar 2023 03:30 EDT |
++----------------------------+----------------------------+
+
+Next occurrences of window 3 (min replicas: 4, timezone: America/New_York):
++----------------------------+----------------------------+
+| Start                      | End                        |
++----------------------------+----------------------------+
+| Sat, 11 Mar 2023 02:30 EST | Sat, 11 Mar 2023 02:45 EST |
+| Mon, 13 Mar 2023 02:30 EDT | Mon, 13 Mar 2023 02:45 EDT |
+| Tue, 14 Mar 2023 02:30 EDT | Tue, 14 Mar 2023 02:45 EDT |
++----------------------------+----------------------------+
+`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(tt.autoscale)
+			}))
+			defer server.Close()
+
+			var stdout bytes.Buffer
+			args := append([]string{"rpaasv2", "--rpaas-url", server.URL, "autoscale", "info", "-s", "my-service", "-i", "my-instance"}, tt.args...)
+			err := NewApp(&stdout, io.Discard, nil).Run(args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
+
+func TestGetAutoscaleMaxWidth(t *testing.T) {
+	autoscale := autogenerated.Autoscale{
+		MinReplicas: 2,
+		MaxReplicas: 10,
+		Cpu:         autogenerated.PtrInt32(75),
+		Schedules: []autogenerated.ScheduledWindow{
+			{MinReplicas: 6, Start: "*/15 9-17 * * 1-5", End: "00 20 * * 1-5", Timezone: pointer.String("America/Sao_Paulo")},
+		},
+	}
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(autoscale)
+		}))
+		defer server.Close()
+
+		var stdout bytes.Buffer
+		err := NewApp(&stdout, io.Discard, nil).Run(append([]string{"rpaasv2", "--rpaas-url", server.URL, "autoscale", "info", "-i", "my-instance"}, args...))
+		return stdout.String(), err
+	}
+
+	// words returns the fields of the table, leaving its borders out.
+	words := func(table string) []string {
+		return strings.FieldsFunc(table, func(r rune) bool {
+			return r == ' ' || r == '\n' || r == '|' || r == '+' || r == '-'
+		})
+	}
+
+	t.Run("wrapping the schedules to fit into a narrow width", func(t *testing.T) {
+		stdout, err := run(t, "--max-width", "40")
+		require.NoError(t, err)
+		assert.Equal(t, `min replicas: 2
+max replicas: 10
++-------------+------------------------+
+|  Triggers   |    trigger details     |
++-------------+------------------------+
+| CPU         | 75%                    |
+| Schedule(s) | Window 1:              |
+|             |   Min replicas: 6      |
+|             |   Start: Every 15      |
+|             |     minutes, between   |
+|             |     09:00 AM and 05:59 |
+|             |     PM, Monday through |
+|             |     Friday (*/15 9-17  |
+|             |     * * 1-5)           |
+|             |   End: At 08:00 PM,    |
+|             |     Monday through     |
+|             |     Friday (00 20 * *  |
+|             |     1-5)               |
+|             |   Timezone:            |
+|             |     America/Sao_Paulo  |
++-------------+------------------------+
+`, stdout)
+
+		for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+			assert.LessOrEqual(t, len(line), 40, line)
+		}
+
+		full, err := run(t, "--no-wrap")
+		require.NoError(t, err)
+		assert.Equal(t, words(full), words(stdout), "no data must be lost on wrapping")
+	})
+
+	t.Run("without wrapping", func(t *testing.T) {
+		stdout, err := run(t, "--no-wrap")
+		require.NoError(t, err)
+		assert.Contains(t, stdout, "|   Start: Every 15 minutes, between 09:00 AM and 05:59 PM, Monday through Friday (*/15 9-17 * * 1-5) |\n")
+
+		wide, err := run(t, "-o", "table-wide")
+		require.NoError(t, err)
+		assert.Equal(t, stdout, wide)
+	})
+
+	t.Run("with a width narrower than the details header", func(t *testing.T) {
+		stdout, err := run(t, "--max-width", "10")
+		require.NoError(t, err)
+		assert.Contains(t, stdout, "|    trigger details    |\n")
+		assert.Contains(t, stdout, "|             |   Min replicas:       |\n|             |     6                 |\n")
+		assert.Contains(t, stdout, "|             |     America/Sao_Paulo |\n")
+	})
+
+	t.Run("along with --no-wrap", func(t *testing.T) {
+		_, err := run(t, "--max-width", "40", "--no-wrap")
+		assert.EqualError(t, err, "--max-width cannot be used along with --no-wrap")
+
+		_, err = run(t, "--max-width", "40", "-o", "table-wide")
+		assert.EqualError(t, err, "--max-width cannot be used along with --no-wrap")
+	})
+
+	t.Run("with an invalid width", func(t *testing.T) {
+		_, err := run(t, "--max-width", "0")
+		assert.EqualError(t, err, "--max-width must be greater than zero")
+	})
+}
+
+func TestGetAutoscaleRaw(t *testing.T) {
+	const hpa = `{"apiVersion":"autoscaling/v2","kind":"HorizontalPodAutoscaler","metadata":{"name":"my-instance","namespace":"rpaasv2"},"spec":{"maxReplicas":10,"metrics":[{"resource":{"name":"cpu","target":{"averageUtilization":75,"type":"Utilization"}},"type":"Resource"}],"minReplicas":2,"scaleTargetRef":{"apiVersion":"apps/v1","kind":"Deployment","name":"my-instance"}}}`
+
+	run := func(t *testing.T, handler http.HandlerFunc, args ...string) (string, error) {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		var stdout bytes.Buffer
+		err := NewApp(&stdout, io.Discard, nil).Run(append([]string{"rpaasv2", "--rpaas-url", server.URL, "autoscale", "info", "-i", "my-instance", "--raw"}, args...))
+		return stdout.String(), err
+	}
+
+	withObject := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/resources/my-instance/autoscale/object", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, hpa)
+	}
+
+	t.Run("as YAML", func(t *testing.T) {
+		stdout, err := run(t, withObject)
+		require.NoError(t, err)
+		assert.Equal(t, `apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: my-instance
+  namespace: rpaasv2
+spec:
+  maxReplicas: 10
+  metrics:
+  - resource:
+      name: cpu
+      target:
+        averageUtilization: 75
+        type: Utilization
+    type: Resource
+  minReplicas: 2
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: my-instance
+`, stdout)
+	})
+
+	t.Run("as JSON", func(t *testing.T) {
+		stdout, err := run(t, withObject, "--json")
+		require.NoError(t, err)
+		assert.JSONEq(t, hpa, stdout)
+	})
+
+	t.Run("when the API does not expose the object", func(t *testing.T) {
+		_, err := run(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"message":"Not Found"}`)
+		})
+		assert.EqualError(t, err, "cannot show the autoscaler of my-instance: rpaasv2: the API does not expose the autoscaler object generated for the instance (upgrade the API to use --raw)")
+	})
+
+	t.Run("when the instance has no autoscaler", func(t *testing.T) {
+		_, err := run(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"no autoscaler found for instance \"my-instance\": autoscale is either not set or not applied yet"}`)
+		})
+		assert.ErrorContains(t, err, `no autoscaler found for instance \"my-instance\"`)
+	})
+
+	t.Run("along with --explain", func(t *testing.T) {
+		_, err := run(t, withObject, "--explain")
+		assert.EqualError(t, err, "--raw cannot be used along with --explain, --next-windows or -o table-wide")
+	})
+}
+
+func TestNextScheduledWindowsWhenTheEndNeverFires(t *testing.T) {
+	now := time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	// NOTE: February 30th never happens.
+	occurrences, err := nextScheduledWindows(autogenerated.ScheduledWindow{MinReplicas: 2, Start: "00 08 * * *", End: "00 00 30 2 *"}, now, 3)
+	require.NoError(t, err)
+	require.Len(t, occurrences, 1)
+	assert.True(t, occurrences[0].End.IsZero())
+}
@@ -0,0 +1,310 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/util"
+)
+
+// batchTarget is a service instance listed on --instance-file.
+type batchTarget struct {
+	Service  string
+	Instance string
+}
+
+func (t batchTarget) String() string {
+	if t.Service == "" {
+		return t.Instance
+	}
+
+	return fmt.Sprintf("%s/%s", t.Service, t.Instance)
+}
+
+type batchResult struct {
+	Target batchTarget
+	Status string
+	Error  error
+}
+
+const (
+	batchSucceeded = "succeeded"
+	batchFailed    = "failed"
+	batchSkipped   = "skipped"
+)
+
+// withInstanceFile makes cmd run over every instance listed on a file given
+// by --instance-file, rather than on the one set on --instance. Instances are
+// operated up to --parallelism at once, each one with its output lines
+// prefixed by its name, and a summary of the results is printed at the end.
+// Unless --continue-on-error is set, no further instances are operated once
+// one of them fails.
+func withInstanceFile(cmd *cli.Command) *cli.Command {
+	cmd.Flags = append(cmd.Flags,
+		&cli.PathFlag{
+			Name:  "instance-file",
+			Usage: "file listing the instances to operate on instead of --instance, one \"service/instance\" (or just \"instance\", using --service) per line, where blank lines and lines starting with # are ignored",
+		},
+		&cli.IntFlag{
+			Name:  "parallelism",
+			Usage: "number of instances from --instance-file operated at once",
+			Value: 1,
+		},
+		&cli.BoolFlag{
+			Name:  "continue-on-error",
+			Usage: "keeps operating the instances from --instance-file after one of them fails",
+		},
+	)
+
+	// NOTE: the instance is taken from the file instead, so it's only
+	// required when there's no file.
+	for _, f := range cmd.Flags {
+		if sf, ok := f.(*cli.StringFlag); ok && sf.Name == "instance" {
+			sf.Required = false
+		}
+	}
+
+	before, action := cmd.Before, cmd.Action
+	cmd.Before = func(c *cli.Context) error {
+		if err := checkInstanceFileFlags(c); err != nil {
+			return err
+		}
+
+		if before == nil {
+			return nil
+		}
+
+		return before(c)
+	}
+
+	cmd.Action = func(c *cli.Context) error {
+		if !c.IsSet("instance-file") {
+			return action(c)
+		}
+
+		return runOnInstanceFile(c, action)
+	}
+
+	return cmd
+}
+
+func checkInstanceFileFlags(c *cli.Context) error {
+	if !c.IsSet("instance-file") {
+		if c.String("instance") == "" {
+			return fmt.Errorf("Required flag %q not set", "instance")
+		}
+
+		if c.IsSet("parallelism") || c.IsSet("continue-on-error") {
+			return fmt.Errorf("--parallelism and --continue-on-error can only be used along with --instance-file")
+		}
+
+		return nil
+	}
+
+	if c.IsSet("instance") {
+		return fmt.Errorf("--instance cannot be used along with --instance-file")
+	}
+
+	if c.Int("parallelism") < 1 {
+		return fmt.Errorf("--parallelism must be a positive number")
+	}
+
+	return nil
+}
+
+func runOnInstanceFile(c *cli.Context, action cli.ActionFunc) error {
+	path := c.Path("instance-file")
+	targets, err := readInstanceFile(path, c.String("service"))
+	if err != nil {
+		return err
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	var outMu, errMu sync.Mutex
+	results := make([]batchResult, len(targets))
+	sem := make(chan struct{}, c.Int("parallelism"))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var stopped bool
+
+	for i, t := range targets {
+		results[i] = batchResult{Target: t, Status: batchSkipped}
+
+		sem <- struct{}{}
+
+		mu.Lock()
+		stop := stopped
+		mu.Unlock()
+
+		if stop {
+			<-sem
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, t batchTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			prefix := fmt.Sprintf("[%s] ", t)
+			stdout := util.NewLineForwarder(c.App.Writer, &outMu, prefix)
+			stderr := util.NewLineForwarder(c.App.ErrWriter, &errMu, prefix)
+
+			err := runOnInstance(c, action, client, t, stdout, stderr)
+			stdout.Flush()
+			stderr.Flush()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			results[i].Status, results[i].Error = batchSucceeded, err
+			if err != nil {
+				results[i].Status = batchFailed
+				stopped = stopped || !c.Bool("continue-on-error")
+			}
+		}(i, t)
+	}
+
+	wg.Wait()
+
+	writeBatchResults(c.App.Writer, results)
+
+	var failed, skipped int
+	for _, r := range results {
+		switch r.Status {
+		case batchFailed:
+			failed++
+		case batchSkipped:
+			skipped++
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+
+	if skipped > 0 {
+		return fmt.Errorf("failed on %d of %d instance(s), skipping %d of them (see --continue-on-error)", failed, len(results), skipped)
+	}
+
+	return fmt.Errorf("failed on %d of %d instance(s)", failed, len(results))
+}
+
+// runOnInstance runs action as if --service and --instance were set to the
+// target's ones. It does so on a child context defining just those flags,
+// so that the others are still looked up on the parent context.
+func runOnInstance(c *cli.Context, action cli.ActionFunc, client rpaasclient.Client, t batchTarget, stdout, stderr io.Writer) error {
+	set := flag.NewFlagSet(c.Command.Name, flag.ContinueOnError)
+	set.String("instance", t.Instance, "")
+	set.String("service", c.String("service"), "")
+
+	if err := set.Parse(append([]string{"--"}, c.Args().Slice()...)); err != nil {
+		return err
+	}
+
+	set.Set("instance", t.Instance)
+	if t.Service != "" {
+		set.Set("service", t.Service)
+	}
+
+	app := *c.App
+	app.Writer, app.ErrWriter = stdout, stderr
+
+	tc := cli.NewContext(&app, set, c)
+	tc.Command = c.Command
+
+	if t.Service != "" {
+		var err error
+		if client, err = client.SetService(t.Service); err != nil {
+			return err
+		}
+	}
+
+	setClient(tc, client)
+	return action(tc)
+}
+
+// readInstanceFile reads the instances listed on path, taking service as the
+// service of the ones given without it.
+func readInstanceFile(path, service string) ([]batchTarget, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var targets []batchTarget
+	seen := make(map[batchTarget]bool)
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		t := batchTarget{Service: service, Instance: line}
+		if s, i, found := strings.Cut(line, "/"); found {
+			t = batchTarget{Service: s, Instance: i}
+		}
+
+		if t.Instance == "" || (t.Service == "" && strings.Contains(line, "/")) || strings.ContainsAny(t.Instance, "/ \t") || strings.ContainsAny(t.Service, " \t") {
+			return nil, fmt.Errorf("%s:%d: invalid instance %q, must be in the \"service/instance\" or \"instance\" format", path, n, line)
+		}
+
+		if seen[t] {
+			continue
+		}
+
+		seen[t] = true
+		targets = append(targets, t)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no instance found in %s", path)
+	}
+
+	return targets, nil
+}
+
+func writeBatchResults(w io.Writer, results []batchResult) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Instance", "Result", "Error"})
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.SetRowLine(false)
+
+	for _, r := range results {
+		var errMsg string
+		if r.Error != nil {
+			errMsg = r.Error.Error()
+		}
+
+		table.Append([]string{r.Target.String(), r.Status, errMsg})
+	}
+
+	table.Render()
+}
@@ -0,0 +1,161 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestInstanceFile(t *testing.T) {
+	instanceFile := filepath.Join(t.TempDir(), "instances.txt")
+	require.NoError(t, os.WriteFile(instanceFile, []byte(`# instances to purge
+my-instance-a
+
+other-service/my-instance-b
+my-instance-c
+my-instance-a
+`), 0644))
+
+	newClient := func(failing ...string) (*fake.FakeClient, func() []string) {
+		var mu sync.Mutex
+		var purged []string
+		return &fake.FakeClient{
+			FakePurgeCache: func(args client.PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+				mu.Lock()
+				purged = append(purged, args.Instance)
+				mu.Unlock()
+
+				for _, f := range failing {
+					if f == args.Instance {
+						return nil, fmt.Errorf("instance %q not found", args.Instance)
+					}
+				}
+
+				return []types.PurgeCacheResult{{Path: args.Paths[0], InstancesPurged: 1, Pods: []types.PurgeCachePodResult{{Pod: args.Instance + "-1", Purged: true}}}}, nil
+			},
+		}, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return purged
+		}
+	}
+
+	t.Run("operates every instance from the file", func(t *testing.T) {
+		fc, purged := newClient()
+
+		var services []string
+		fc.FakeSetService = func(service string) error {
+			services = append(services, service)
+			return nil
+		}
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, fc).Run([]string{"./rpaasv2", "purge", "-s", "my-service", "--instance-file", instanceFile, "-p", "/index.html"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"my-instance-a", "my-instance-b", "my-instance-c"}, purged())
+		assert.Equal(t, []string{"my-service", "other-service", "my-service"}, services)
+		assert.Equal(t, `[my-service/my-instance-a] Purge of cache in my-service/my-instance-a:
+[my-service/my-instance-a]   /index.html: purged on 1 of 1 pod(s)
+[my-service/my-instance-a]     my-instance-a-1: purged
+[other-service/my-instance-b] Purge of cache in other-service/my-instance-b:
+[other-service/my-instance-b]   /index.html: purged on 1 of 1 pod(s)
+[other-service/my-instance-b]     my-instance-b-1: purged
+[my-service/my-instance-c] Purge of cache in my-service/my-instance-c:
+[my-service/my-instance-c]   /index.html: purged on 1 of 1 pod(s)
+[my-service/my-instance-c]     my-instance-c-1: purged
++-----------------------------+-----------+-------+
+| Instance                    | Result    | Error |
++-----------------------------+-----------+-------+
+| my-service/my-instance-a    | succeeded |       |
+| other-service/my-instance-b | succeeded |       |
+| my-service/my-instance-c    | succeeded |       |
++-----------------------------+-----------+-------+
+`, stdout.String())
+	})
+
+	t.Run("stops on the first failure", func(t *testing.T) {
+		fc, purged := newClient("my-instance-b")
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, fc).Run([]string{"./rpaasv2", "purge", "--instance-file", instanceFile, "-p", "/index.html"})
+		assert.EqualError(t, err, "failed on 1 of 3 instance(s), skipping 1 of them (see --continue-on-error)")
+		assert.Equal(t, []string{"my-instance-a", "my-instance-b"}, purged())
+		assert.Contains(t, stdout.String(), `+-----------------------------+-----------+------------------------------------+
+| Instance                    | Result    | Error                              |
++-----------------------------+-----------+------------------------------------+
+| my-instance-a               | succeeded |                                    |
+| other-service/my-instance-b | failed    | instance "my-instance-b" not found |
+| my-instance-c               | skipped   |                                    |
++-----------------------------+-----------+------------------------------------+
+`)
+	})
+
+	t.Run("continues on error in parallel", func(t *testing.T) {
+		fc, purged := newClient("my-instance-a", "my-instance-b")
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, fc).Run([]string{"./rpaasv2", "purge", "--instance-file", instanceFile, "-p", "/index.html", "--parallelism", "3", "--continue-on-error"})
+		assert.EqualError(t, err, "failed on 2 of 3 instance(s)")
+		assert.ElementsMatch(t, []string{"my-instance-a", "my-instance-b", "my-instance-c"}, purged())
+		assert.Contains(t, stdout.String(), "| my-instance-c               | succeeded |")
+	})
+
+	t.Run("invalid instance file", func(t *testing.T) {
+		invalidFile := filepath.Join(t.TempDir(), "instances.txt")
+		require.NoError(t, os.WriteFile(invalidFile, []byte("my-instance-a\nmy-service/my/instance\n"), 0644))
+
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{}).Run([]string{"./rpaasv2", "purge", "--instance-file", invalidFile, "-p", "/index.html"})
+		assert.EqualError(t, err, fmt.Sprintf(`%s:2: invalid instance "my-service/my/instance", must be in the "service/instance" or "instance" format`, invalidFile))
+	})
+
+	t.Run("empty instance file", func(t *testing.T) {
+		emptyFile := filepath.Join(t.TempDir(), "instances.txt")
+		require.NoError(t, os.WriteFile(emptyFile, []byte("# nothing here\n"), 0644))
+
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{}).Run([]string{"./rpaasv2", "purge", "--instance-file", emptyFile, "-p", "/index.html"})
+		assert.EqualError(t, err, fmt.Sprintf("no instance found in %s", emptyFile))
+	})
+
+	t.Run("flag combinations", func(t *testing.T) {
+		tests := []struct {
+			args          []string
+			expectedError string
+		}{
+			{
+				args:          []string{"./rpaasv2", "purge", "-p", "/index.html"},
+				expectedError: `Required flag "instance" not set`,
+			},
+			{
+				args:          []string{"./rpaasv2", "purge", "-i", "my-instance", "--instance-file", instanceFile, "-p", "/index.html"},
+				expectedError: "--instance cannot be used along with --instance-file",
+			},
+			{
+				args:          []string{"./rpaasv2", "purge", "-i", "my-instance", "--parallelism", "2", "-p", "/index.html"},
+				expectedError: "--parallelism and --continue-on-error can only be used along with --instance-file",
+			},
+			{
+				args:          []string{"./rpaasv2", "purge", "--instance-file", instanceFile, "--parallelism", "0", "-p", "/index.html"},
+				expectedError: "--parallelism must be a positive number",
+			},
+		}
+
+		for _, tt := range tests {
+			err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{}).Run(tt.args)
+			assert.EqualError(t, err, tt.expectedError)
+		}
+	})
+}
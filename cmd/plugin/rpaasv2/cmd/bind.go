@@ -0,0 +1,239 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func NewCmdBind() *cli.Command {
+	return &cli.Command{
+		Name:  "bind",
+		Usage: "Manages the apps bound to an instance",
+		Subcommands: []*cli.Command{
+			NewCmdListBinds(),
+			NewCmdWaitBinds(),
+		},
+	}
+}
+
+func NewCmdListBinds() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "Shows the apps bound to an instance and whether they're reachable from it",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "the output format (one of: json)",
+			},
+		}, outputFieldFlags()...),
+		Before: setupClient,
+		Action: runListBinds,
+	}
+}
+
+func runListBinds(c *cli.Context) error {
+	output := c.String("output")
+	if output != "" && output != "json" {
+		return fmt.Errorf("unsupported output format %q (one of: json)", output)
+	}
+
+	if err := checkOutputField(c, output); err != nil {
+		return err
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	binds, err := client.ListBinds(c.Context, rpaasclient.ListBindsArgs{Instance: c.String("instance")})
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		if binds == nil {
+			binds = []clientTypes.BindStatus{}
+		}
+
+		return writeJSONOutput(c, binds)
+	}
+
+	if len(binds) == 0 {
+		fmt.Fprintf(c.App.Writer, "No apps bound to %s\n", formatInstanceName(c))
+		return nil
+	}
+
+	fmt.Fprint(c.App.Writer, writeBindsStatusOnTableFormat(binds))
+	return nil
+}
+
+// writeBindsStatusOnTableFormat renders the bound apps along with their
+// health, ending with a warning about the unhealthy ones (if any).
+func writeBindsStatusOnTableFormat(binds []clientTypes.BindStatus) string {
+	var unhealthy int
+	data := [][]string{}
+	for _, b := range binds {
+		if !b.Healthy {
+			unhealthy++
+		}
+
+		data = append(data, []string{b.App, b.Address, bindState(b)})
+	}
+
+	var buffer bytes.Buffer
+	table := tablewriter.NewWriter(&buffer)
+	table.SetHeader([]string{"App", "Address", "Health"})
+	table.SetRowLine(true)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(true)
+	table.AppendBulk(data)
+	table.Render()
+
+	if unhealthy > 0 {
+		fmt.Fprintf(&buffer, "WARNING: %d of %d bound app(s) unhealthy\n", unhealthy, len(binds))
+	}
+
+	return buffer.String()
+}
+
+func NewCmdWaitBinds() *cli.Command {
+	return &cli.Command{
+		Name:  "wait",
+		Usage: "Waits until apps are bound to an instance and reachable from it",
+		Description: `Meant to be run right after binding apps (e.g. "tsuru service instance bind"),
+so that deploy scripts only go on once the instance can reach them.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:     "app",
+				Aliases:  []string{"a"},
+				Usage:    "the app to wait for (can be used multiple times)",
+				Required: true,
+			},
+			&cli.DurationFlag{
+				Name:  "wait-timeout",
+				Usage: "time limit to wait for the apps to be bound and reachable",
+				Value: 5 * time.Minute,
+			},
+		},
+		Before: setupClient,
+		Action: runWaitBinds,
+	}
+}
+
+func runWaitBinds(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	apps := c.StringSlice("app")
+	states := make(map[string]string)
+
+	var healthy map[string]clientTypes.BindStatus
+	var lastErr error
+	err = pollUntil(c.Context, c.Duration("wait-timeout"), func(ctx context.Context) (bool, error) {
+		binds, err := client.ListBinds(ctx, rpaasclient.ListBindsArgs{Instance: c.String("instance")})
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+
+		lastErr = nil
+		healthy = make(map[string]clientTypes.BindStatus)
+		for _, app := range apps {
+			state := "not bound yet"
+			for _, b := range binds {
+				if b.App != app {
+					continue
+				}
+
+				state = bindState(b)
+				if b.Healthy {
+					healthy[app] = b
+				}
+			}
+
+			if states[app] != state {
+				fmt.Fprintf(c.App.Writer, "Waiting for app %q: %s\n", app, state)
+				states[app] = state
+			}
+		}
+
+		return len(healthy) == len(apps), nil
+	})
+	if errors.Is(err, errPollTimeout) {
+		if lastErr != nil {
+			return fmt.Errorf("timed out waiting for apps to be bound to %s: %w", formatInstanceName(c), lastErr)
+		}
+
+		var pending []string
+		for _, app := range apps {
+			if _, ok := healthy[app]; !ok {
+				pending = append(pending, fmt.Sprintf("%s (%s)", app, states[app]))
+			}
+		}
+
+		return fmt.Errorf("timed out waiting for apps to be bound to %s: %s", formatInstanceName(c), strings.Join(pending, ", "))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, app := range apps {
+		fmt.Fprintf(c.App.Writer, "App %q is bound to %s and reachable at %s\n", app, formatInstanceName(c), healthy[app].Address)
+	}
+
+	return nil
+}
+
+func bindState(b clientTypes.BindStatus) string {
+	if b.Healthy {
+		return "healthy"
+	}
+
+	if b.Error != "" {
+		return fmt.Sprintf("unhealthy: %s", b.Error)
+	}
+
+	return "unhealthy"
+}
@@ -0,0 +1,181 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestListBinds(t *testing.T) {
+	binds := []clientTypes.BindStatus{
+		{App: "app1", Address: "app1.tsuru.example.com", Healthy: true},
+		{App: "app2", Address: "app2.tsuru.example.com", Error: "cannot connect to backend: connection refused"},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		binds         []clientTypes.BindStatus
+		err           error
+		expected      string
+		expectedError string
+	}{
+		{
+			name:          "when listing fails",
+			args:          []string{"./rpaasv2", "bind", "list", "-i", "my-instance"},
+			err:           fmt.Errorf("some error"),
+			expectedError: "some error",
+		},
+		{
+			name:          "with unsupported output format",
+			args:          []string{"./rpaasv2", "bind", "list", "-i", "my-instance", "-o", "yaml"},
+			expectedError: `unsupported output format "yaml" (one of: json)`,
+		},
+		{
+			name:     "when there are no binds",
+			args:     []string{"./rpaasv2", "bind", "list", "-s", "rpaasv2", "-i", "my-instance"},
+			expected: "No apps bound to rpaasv2/my-instance\n",
+		},
+		{
+			name:  "with some unhealthy backend",
+			args:  []string{"./rpaasv2", "bind", "list", "-i", "my-instance"},
+			binds: binds,
+			expected: `+------+------------------------+--------------------------------+
+| App  | Address                | Health                         |
++------+------------------------+--------------------------------+
+| app1 | app1.tsuru.example.com | healthy                        |
++------+------------------------+--------------------------------+
+| app2 | app2.tsuru.example.com | unhealthy: cannot connect to   |
+|      |                        | backend: connection refused    |
++------+------------------------+--------------------------------+
+WARNING: 1 of 2 bound app(s) unhealthy
+`,
+		},
+		{
+			name:  "on JSON format",
+			args:  []string{"./rpaasv2", "bind", "list", "-i", "my-instance", "-o", "json"},
+			binds: binds,
+			expected: `[
+	{
+		"app": "app1",
+		"address": "app1.tsuru.example.com",
+		"healthy": true
+	},
+	{
+		"app": "app2",
+		"address": "app2.tsuru.example.com",
+		"healthy": false,
+		"error": "cannot connect to backend: connection refused"
+	}
+]
+`,
+		},
+		{
+			name:     "on JSON format without binds",
+			args:     []string{"./rpaasv2", "bind", "list", "-i", "my-instance", "-o", "json"},
+			expected: "[]\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := &fake.FakeClient{
+				FakeListBinds: func(args client.ListBindsArgs) ([]clientTypes.BindStatus, error) {
+					assert.Equal(t, "my-instance", args.Instance)
+					return tt.binds, tt.err
+				},
+			}
+
+			stdout := &bytes.Buffer{}
+			err := NewApp(stdout, &bytes.Buffer{}, fakeClient).Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
+
+func TestWaitBinds(t *testing.T) {
+	defer func(d time.Duration) { waitPollInterval = d }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
+	t.Run("until the apps are bound and healthy", func(t *testing.T) {
+		calls := 0
+		fakeClient := &fake.FakeClient{
+			FakeListBinds: func(args client.ListBindsArgs) ([]clientTypes.BindStatus, error) {
+				assert.Equal(t, client.ListBindsArgs{Instance: "my-instance"}, args)
+				calls++
+				switch calls {
+				case 1:
+					return []clientTypes.BindStatus{{App: "other", Address: "other.tsuru.example.com", Healthy: true}}, nil
+				case 2:
+					return nil, fmt.Errorf("some transient error")
+				case 3:
+					return []clientTypes.BindStatus{
+						{App: "app1", Address: "app1.tsuru.example.com", Error: "connection refused"},
+						{App: "app2", Address: "app2.tsuru.example.com", Healthy: true},
+					}, nil
+				default:
+					return []clientTypes.BindStatus{
+						{App: "app1", Address: "app1.tsuru.example.com", Healthy: true},
+						{App: "app2", Address: "app2.tsuru.example.com", Healthy: true},
+					}, nil
+				}
+			},
+		}
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, fakeClient).Run([]string{"./rpaasv2", "bind", "wait", "-i", "my-instance", "--app", "app1", "--app", "app2"})
+		require.NoError(t, err)
+		assert.Equal(t, `Waiting for app "app1": not bound yet
+Waiting for app "app2": not bound yet
+Waiting for app "app1": unhealthy: connection refused
+Waiting for app "app2": healthy
+Waiting for app "app1": healthy
+App "app1" is bound to my-instance and reachable at app1.tsuru.example.com
+App "app2" is bound to my-instance and reachable at app2.tsuru.example.com
+`, stdout.String())
+	})
+
+	t.Run("when the timeout is reached", func(t *testing.T) {
+		fakeClient := &fake.FakeClient{
+			FakeListBinds: func(args client.ListBindsArgs) ([]clientTypes.BindStatus, error) {
+				return []clientTypes.BindStatus{{App: "app1", Address: "app1.tsuru.example.com", Error: "connection refused"}}, nil
+			},
+		}
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, fakeClient).Run([]string{"./rpaasv2", "bind", "wait", "-s", "rpaasv2", "-i", "my-instance", "--app", "app1", "--app", "app2", "--wait-timeout", "20ms"})
+		assert.EqualError(t, err, "timed out waiting for apps to be bound to rpaasv2/my-instance: app1 (unhealthy: connection refused), app2 (not bound yet)")
+		assert.Equal(t, `Waiting for app "app1": unhealthy: connection refused
+Waiting for app "app2": not bound yet
+`, stdout.String())
+	})
+
+	t.Run("when listing keeps failing until the timeout", func(t *testing.T) {
+		fakeClient := &fake.FakeClient{
+			FakeListBinds: func(args client.ListBindsArgs) ([]clientTypes.BindStatus, error) {
+				return nil, fmt.Errorf("some error")
+			},
+		}
+
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, fakeClient).Run([]string{"./rpaasv2", "bind", "wait", "-i", "my-instance", "--app", "app1", "--wait-timeout", "20ms"})
+		assert.EqualError(t, err, "timed out waiting for apps to be bound to my-instance: some error")
+	})
+}
@@ -5,14 +5,24 @@
 package cmd
 
 import (
-	"encoding/json"
+	"bytes"
+	"cmp"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
 
+	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/urfave/cli/v2"
 
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/theme"
 	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
 	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
@@ -22,9 +32,11 @@ func NewCmdBlocks() *cli.Command {
 		Name:  "blocks",
 		Usage: "Manages raw NGINX configuration fragments",
 		Subcommands: []*cli.Command{
-			NewCmdDeleteBlock(),
+			withInstanceFile(NewCmdDeleteBlock()),
+			NewCmdDiffBlocks(),
+			NewCmdGetBlock(),
 			NewCmdListBlocks(),
-			NewCmdUpdateBlock(),
+			withInstanceFile(NewCmdUpdateBlock()),
 		},
 	}
 }
@@ -34,7 +46,7 @@ func NewCmdUpdateBlock() *cli.Command {
 		Name:    "update",
 		Aliases: []string{"add"},
 		Usage:   "Inserts raw NGINX configuration on a context",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "service",
 				Aliases: []string{"tsuru-service", "s"},
@@ -53,12 +65,41 @@ func NewCmdUpdateBlock() *cli.Command {
 				Required: true,
 			},
 			&cli.PathFlag{
-				Name:     "content",
-				Aliases:  []string{"content-file", "c"},
-				Usage:    "path in the system to the NGINX configuration",
-				Required: true,
+				Name:    "content",
+				Aliases: []string{"content-file", "c"},
+				Usage:   "path in the system to the NGINX configuration",
 			},
-		},
+			&cli.StringFlag{
+				Name:  "append",
+				Usage: "NGINX configuration added to the end of the current content of the block, instead of replacing it with --content",
+			},
+			&cli.StringFlag{
+				Name:  "prepend",
+				Usage: "NGINX configuration added to the beginning of the current content of the block, instead of replacing it with --content",
+			},
+			&cli.BoolFlag{
+				Name:  "template",
+				Usage: "render the content as a Go template delimited by [[ and ]] (e.g. \"listen [[ .port ]];\") before sending it, leaving the {{ }} actions rendered by the server untouched",
+			},
+			&cli.StringSliceFlag{
+				Name:  "set",
+				Usage: "variable in the KEY=VALUE format available to the template (requires --template, can be used multiple times)",
+			},
+			&cli.PathFlag{
+				Name:    "backup-dir",
+				Aliases: []string{"backup"},
+				Usage:   "directory where the current content of the block is saved (in a timestamped file) before being overwritten",
+			},
+			&cli.StringFlag{
+				Name:  "on-conflict",
+				Usage: "what to do when the blocks are changed by someone else meanwhile: fail, overwrite (re-fetches and retries) or merge (retries only if this very block was left untouched, so that only updates of distinct blocks are merged)",
+				Value: "overwrite",
+			},
+			&cli.BoolFlag{
+				Name:  "validate-remote",
+				Usage: "checks the block with \"nginx -t\" on a pod of the instance (i.e. on its very NGINX build) before updating it, which is aborted when the block is invalid",
+			},
+		}, expandEnvFlags()...),
 		Before: setupClient,
 		Action: runUpdateBlock,
 	}
@@ -70,17 +111,65 @@ func runUpdateBlock(c *cli.Context) error {
 		return err
 	}
 
+	if c.IsSet("append") || c.IsSet("prepend") {
+		return runEditBlock(c, client)
+	}
+
+	if !c.IsSet("content") {
+		return fmt.Errorf("either --content or --append/--prepend must be provided")
+	}
+
 	content, err := os.ReadFile(c.Path("content"))
 	if err != nil {
 		return err
 	}
 
+	content, err = expandEnvFromFlags(c, content)
+	if err != nil {
+		return err
+	}
+
+	onConflict, err := onConflictFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	if !c.Bool("template") && c.IsSet("set") {
+		return fmt.Errorf("--set can only be used along with --template")
+	}
+
+	if c.Bool("template") {
+		var values map[string]string
+		values, err = parseTemplateValues(c.StringSlice("set"))
+		if err != nil {
+			return err
+		}
+
+		content, err = renderBlockTemplate(string(content), values)
+		if err != nil {
+			return err
+		}
+	}
+
 	args := rpaasclient.UpdateBlockArgs{
 		Instance: c.String("instance"),
 		Name:     c.String("name"),
 		Content:  string(content),
 	}
-	err = client.UpdateBlock(c.Context, args)
+
+	if c.Bool("validate-remote") {
+		if err = validateBlockRemotely(c, client, args); err != nil {
+			return err
+		}
+	}
+
+	if dir := c.Path("backup-dir"); dir != "" {
+		if err = backupBlock(c, client, args.Name, dir); err != nil {
+			return err
+		}
+	}
+
+	err = updateBlockOnConflict(c, client, args, onConflict, nil)
 	if err != nil {
 		return err
 	}
@@ -89,6 +178,332 @@ func runUpdateBlock(c *cli.Context) error {
 	return nil
 }
 
+func onConflictFromFlags(c *cli.Context) (string, error) {
+	onConflict := c.String("on-conflict")
+	if !slices.Contains([]string{"fail", "overwrite", "merge"}, onConflict) {
+		return "", fmt.Errorf("invalid --on-conflict %q (one of: fail, overwrite, merge)", onConflict)
+	}
+
+	return onConflict, nil
+}
+
+// runEditBlock adds the --prepend and --append snippets around the current
+// content of the block. On conflicts, the snippets are added again to the
+// content fetched anew, so that concurrent changes are kept.
+func runEditBlock(c *cli.Context, client rpaasclient.Client) error {
+	if c.IsSet("content") {
+		return fmt.Errorf("--content cannot be used along with --append or --prepend")
+	}
+
+	if c.Bool("template") || c.IsSet("set") {
+		return fmt.Errorf("--template and --set cannot be used along with --append or --prepend")
+	}
+
+	var snippets [2]string
+	for i, name := range []string{"prepend", "append"} {
+		if !c.IsSet(name) {
+			continue
+		}
+
+		snippet, err := expandEnvFromFlags(c, []byte(c.String(name)))
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(string(snippet)) == "" {
+			return fmt.Errorf("--%s must not be empty", name)
+		}
+
+		snippets[i] = string(snippet)
+	}
+
+	onConflict, err := onConflictFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	edit := func(current string) (string, error) {
+		content := editBlockContent(current, snippets[0], snippets[1])
+		if name == "lua-server" || name == "lua-worker" {
+			return content, nil
+		}
+
+		if err := checkBlockBraces(content); err != nil {
+			return "", fmt.Errorf("the %q block would be left with unbalanced braces: %w", name, err)
+		}
+
+		return content, nil
+	}
+
+	blocks, err := client.ListBlocks(c.Context, rpaasclient.ListBlocksArgs{Instance: c.String("instance")})
+	if err != nil {
+		return err
+	}
+
+	current, _ := blockContent(blocks, name)
+	content, err := edit(current)
+	if err != nil {
+		return err
+	}
+
+	args := rpaasclient.UpdateBlockArgs{
+		Instance: c.String("instance"),
+		Name:     name,
+		Content:  content,
+	}
+
+	if c.Bool("validate-remote") {
+		if err = validateBlockRemotely(c, client, args); err != nil {
+			return err
+		}
+	}
+
+	if dir := c.Path("backup-dir"); dir != "" {
+		if err = backupBlock(c, client, args.Name, dir); err != nil {
+			return err
+		}
+	}
+
+	if err = updateBlockOnConflict(c, client, args, onConflict, edit); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "NGINX configuration fragment inserted at %q context\n", args.Name)
+	return nil
+}
+
+// editBlockContent returns the content between prefix and suffix, each one
+// starting on a line of its own.
+func editBlockContent(content, prefix, suffix string) string {
+	var parts []string
+	for _, part := range []string{prefix, content, suffix} {
+		if part = strings.TrimRight(part, "\n"); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, "\n") + "\n"
+}
+
+// checkBlockBraces checks whether every brace of the NGINX configuration
+// is closed, ignoring the ones within comments and quoted strings.
+func checkBlockBraces(content string) error {
+	var opened []int
+	var quote byte
+	line := 1
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		if ch == '\n' {
+			line++
+		}
+
+		switch {
+		case quote != 0 && ch == '\\':
+			i++
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			for i+1 < len(content) && content[i+1] != '\n' {
+				i++
+			}
+		case ch == '{':
+			opened = append(opened, line)
+		case ch == '}':
+			if len(opened) == 0 {
+				return fmt.Errorf("unexpected \"}\" on line %d", line)
+			}
+
+			opened = opened[:len(opened)-1]
+		}
+	}
+
+	if quote != 0 {
+		return fmt.Errorf("unterminated %c quoted string", quote)
+	}
+
+	if len(opened) > 0 {
+		return fmt.Errorf("missing \"}\" for the \"{\" on line %d", opened[len(opened)-1])
+	}
+
+	return nil
+}
+
+// validateBlockRemotely checks the block on a pod of the instance, writing
+// out what NGINX complains about when it's invalid. APIs lacking the
+// validation leave the block unchecked.
+func validateBlockRemotely(c *cli.Context, client rpaasclient.Client, args rpaasclient.UpdateBlockArgs) error {
+	result, err := client.ValidateBlock(c.Context, rpaasclient.ValidateBlockArgs{Instance: args.Instance, Name: args.Name, Content: args.Content})
+	if errors.Is(err, rpaasclient.ErrValidateBlockUnsupported) {
+		fmt.Fprintln(c.App.ErrWriter, "WARNING: the API does not support validating blocks, updating the block without validating it (upgrade the API or drop --validate-remote)")
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !result.Valid {
+		output := result.Output
+		if output != "" && !strings.HasSuffix(output, "\n") {
+			output += "\n"
+		}
+
+		fmt.Fprint(c.App.ErrWriter, output)
+		return fmt.Errorf("the %q block is invalid according to nginx -t on pod %s, so it was not updated", args.Name, result.Pod)
+	}
+
+	fmt.Fprintf(c.App.Writer, "NGINX configuration validated on pod %s\n", result.Pod)
+	return nil
+}
+
+const maxBlockConflictRetries = 3
+
+// updateBlockOnConflict updates the block only if no other block was changed
+// since they were fetched. Otherwise, according to onConflict, it either fails,
+// retries (overwrite) or retries as long as the same block was not changed
+// meanwhile (merge). When set, edit makes the content sent out of the current
+// one on every attempt.
+func updateBlockOnConflict(c *cli.Context, client rpaasclient.Client, args rpaasclient.UpdateBlockArgs, onConflict string, edit func(current string) (string, error)) error {
+	blocks, version, err := client.ListBlocksWithVersion(c.Context, rpaasclient.ListBlocksArgs{Instance: args.Instance})
+	if err != nil {
+		return err
+	}
+
+	original, _ := blockContent(blocks, args.Name)
+
+	for retries := 0; ; retries++ {
+		args.Version = version
+
+		if edit != nil {
+			current, _ := blockContent(blocks, args.Name)
+			if args.Content, err = edit(current); err != nil {
+				return err
+			}
+		}
+
+		err = client.UpdateBlock(c.Context, args)
+		if !errors.Is(err, rpaasclient.ErrBlocksConflict) || onConflict == "fail" {
+			return err
+		}
+
+		if retries == maxBlockConflictRetries {
+			return fmt.Errorf("giving up after %d retries: %w", retries, err)
+		}
+
+		blocks, version, err = client.ListBlocksWithVersion(c.Context, rpaasclient.ListBlocksArgs{Instance: args.Instance})
+		if err != nil {
+			return err
+		}
+
+		if current, _ := blockContent(blocks, args.Name); onConflict == "merge" && current != original {
+			return fmt.Errorf("cannot merge: block %q was changed meanwhile", args.Name)
+		}
+
+		fmt.Fprintf(c.App.ErrWriter, "Warning: blocks were changed meanwhile, retrying the update of the %q block\n", args.Name)
+	}
+}
+
+func blockContent(blocks []clientTypes.Block, name string) (string, bool) {
+	index := slices.IndexFunc(blocks, func(b clientTypes.Block) bool { return b.Name == name })
+	if index < 0 {
+		return "", false
+	}
+
+	return blocks[index].Content, true
+}
+
+// backupBlock writes the current content of the block into a timestamped file
+// within dir, so it can be restored later with "blocks update --content".
+func backupBlock(c *cli.Context, client rpaasclient.Client, name, dir string) error {
+	blocks, err := client.ListBlocks(c.Context, rpaasclient.ListBlocksArgs{Instance: c.String("instance")})
+	if err != nil {
+		return fmt.Errorf("could not fetch the current blocks to back up: %w", err)
+	}
+
+	content, found := blockContent(blocks, name)
+	if !found {
+		fmt.Fprintf(c.App.Writer, "No previous content of the %q block, skipping the backup\n", name)
+		return nil
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	prefix := filepath.Join(dir, fmt.Sprintf("%s-%s-%s", c.String("instance"), name, timeNow().UTC().Format("20060102T150405Z")))
+	filename, err := writeNewFile(prefix, ".conf", []byte(content))
+	if err != nil {
+		return fmt.Errorf("could not write the block backup: %w", err)
+	}
+
+	fmt.Fprintf(c.App.Writer, "Previous content of the %q block saved to %s\n", name, filename)
+	return nil
+}
+
+// writeNewFile writes data into <prefix><suffix>, never overwriting an
+// existing file: a counter is appended to the prefix (e.g. <prefix>-1<suffix>)
+// until a free name is found.
+func writeNewFile(prefix, suffix string, data []byte) (string, error) {
+	for i := 0; ; i++ {
+		filename := prefix + suffix
+		if i > 0 {
+			filename = fmt.Sprintf("%s-%d%s", prefix, i, suffix)
+		}
+
+		f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if _, err = f.Write(data); err != nil {
+			f.Close()
+			return "", err
+		}
+
+		return filename, f.Close()
+	}
+}
+
+func parseTemplateValues(pairs []string) (map[string]string, error) {
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid template variable %q: must be in the KEY=VALUE format", pair)
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
+
+// renderBlockTemplate renders the client-side variables of the block, which
+// use their own delimiters since blocks are rendered again by the server,
+// where the {{ }} actions (e.g. {{ .Instance.Name }}) are meant to be.
+func renderBlockTemplate(content string, values map[string]string) ([]byte, error) {
+	tmpl, err := template.New("block").Delims("[[", "]]").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the block template: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	if err = tmpl.Execute(&buffer, values); err != nil {
+		return nil, fmt.Errorf("could not render the block template: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
 func NewCmdDeleteBlock() *cli.Command {
 	return &cli.Command{
 		Name:    "delete",
@@ -137,11 +552,96 @@ func runDeleteBlock(c *cli.Context) error {
 	return nil
 }
 
+func NewCmdGetBlock() *cli.Command {
+	return &cli.Command{
+		Name:    "get",
+		Aliases: []string{"show"},
+		Usage:   "Shows the content of a NGINX configuration fragment",
+		Description: `
+Writes the content of the block as is, so it can be saved into a file:
+
+rpaasv2 blocks get -s my-service -i my-instance --name server > server.conf
+`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "name",
+				Aliases:  []string{"context", "n"},
+				Usage:    "the NGINX context name of the fragment (supported values: root, http, server, lua-server, lua-worker)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "the output format (one of: raw, json, yaml), where raw is the content alone",
+				Value:   "raw",
+			},
+		}, outputFieldFlags()...),
+		Before: setupClient,
+		Action: runGetBlock,
+	}
+}
+
+type blockInfo struct {
+	Instance string `json:"instance"`
+	Name     string `json:"name"`
+	Size     int    `json:"size"`
+	Content  string `json:"content"`
+}
+
+func runGetBlock(c *cli.Context) error {
+	format := c.String("output")
+	if !slices.Contains([]string{"raw", "json", "yaml"}, format) {
+		return fmt.Errorf("unsupported output format %q (one of: raw, json, yaml)", format)
+	}
+
+	if err := checkOutputField(c, format); err != nil {
+		return err
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	blocks, err := client.ListBlocks(c.Context, rpaasclient.ListBlocksArgs{Instance: c.String("instance")})
+	if err != nil {
+		return err
+	}
+
+	name := c.String("name")
+	content, found := blockContent(blocks, name)
+	if !found {
+		return fmt.Errorf("block %q not found in %s", name, formatInstanceName(c))
+	}
+
+	if format != "raw" {
+		return writeOutput(c, format, blockInfo{Instance: c.String("instance"), Name: name, Size: len(content), Content: content}, nil)
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	fmt.Fprint(c.App.Writer, content)
+	return nil
+}
+
 func NewCmdListBlocks() *cli.Command {
 	return &cli.Command{
 		Name:  "list",
 		Usage: "Shows the NGINX configuration fragments on the instance",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "service",
 				Aliases: []string{"tsuru-service", "s"},
@@ -156,10 +656,11 @@ func NewCmdListBlocks() *cli.Command {
 			&cli.BoolFlag{
 				Name:    "raw-output",
 				Aliases: []string{"r"},
-				Usage:   "show as JSON instead of table format",
+				Usage:   "show as JSON instead of table format (same as --output json)",
 				Value:   false,
 			},
-		},
+			outputFlag("table", "json", "yaml", "csv", "jsonl"),
+		}, append(sortFlags("name", "size"), outputFieldFlags()...)...),
 		Before: setupClient,
 		Action: runListBlocks,
 	}
@@ -177,12 +678,23 @@ func runListBlocks(c *cli.Context) error {
 		return err
 	}
 
+	err = sortList(c, blocks, map[string]func(a, b clientTypes.Block) int{
+		"name": func(a, b clientTypes.Block) int { return strings.Compare(a.Name, b.Name) },
+		"size": func(a, b clientTypes.Block) int { return cmp.Compare(len(a.Content), len(b.Content)) },
+	})
+	if err != nil {
+		return err
+	}
+
+	format := c.String("output")
 	if c.Bool("raw-output") {
-		return writeBlocksOnJSONFormat(c.App.Writer, blocks)
+		format = "json"
 	}
 
-	writeBlocksOnTableFormat(c.App.Writer, blocks)
-	return nil
+	return writeListOutput(c, format, blocks, blocksRecords(blocks), func(w io.Writer) error {
+		writeBlocksOnTableFormat(w, blocks)
+		return nil
+	})
 }
 
 func writeBlocksOnTableFormat(w io.Writer, blocks []clientTypes.Block) {
@@ -200,12 +712,241 @@ func writeBlocksOnTableFormat(w io.Writer, blocks []clientTypes.Block) {
 	table.Render()
 }
 
-func writeBlocksOnJSONFormat(w io.Writer, blocks []clientTypes.Block) error {
-	message, err := json.MarshalIndent(blocks, "", "\t")
+func blocksRecords(blocks []clientTypes.Block) records {
+	rec := records{Header: []string{"Context", "Configuration"}}
+	for _, block := range blocks {
+		rec.Rows = append(rec.Rows, []string{block.Name, block.Content})
+	}
+
+	return rec
+}
+
+func NewCmdDiffBlocks() *cli.Command {
+	return &cli.Command{
+		Name:  "diff",
+		Usage: "Shows what would change by pushing local NGINX configuration fragments",
+		Description: `
+Prints a unified diff between the installed blocks and the local files, exiting
+with non-zero status when they differ.
+
+# Compare the server block with a local file:
+rpaasv2 blocks diff -s my-service -i my-instance --name server --from-file new.conf
+
+# Compare every block with the files named after them (e.g. http.conf, server.conf):
+rpaasv2 blocks diff -s my-service -i my-instance --from-dir ./blocks
+`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "name",
+				Aliases: []string{"context", "n"},
+				Usage:   "the NGINX context name of the fragment being compared (requires --from-file)",
+			},
+			&cli.PathFlag{
+				Name:    "from-file",
+				Aliases: []string{"f"},
+				Usage:   "path in the system to the NGINX configuration",
+			},
+			&cli.PathFlag{
+				Name:  "from-dir",
+				Usage: "path in the system to a directory with one <context>.conf file per block, compared against all blocks",
+			},
+			&cli.BoolFlag{
+				Name:    "without-color",
+				Aliases: []string{"no-color"},
+				Usage:   "defines whether or not to display colorful output.",
+			},
+		}, expandEnvFlags()...),
+		Before: setupClient,
+		Action: runDiffBlocks,
+	}
+}
+
+func runDiffBlocks(c *cli.Context) error {
+	if c.IsSet("from-file") == c.IsSet("from-dir") {
+		return fmt.Errorf("either --from-file or --from-dir must be provided")
+	}
+
+	if c.IsSet("from-file") && c.String("name") == "" {
+		return fmt.Errorf("--name must be provided along with --from-file")
+	}
+
+	local, err := localBlocksForDiff(c)
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintln(w, string(message))
-	return nil
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	blocks, err := client.ListBlocks(c.Context, rpaasclient.ListBlocksArgs{Instance: c.String("instance")})
+	if err != nil {
+		return err
+	}
+
+	installed := make(map[string]string)
+	for _, b := range blocks {
+		installed[b.Name] = b.Content
+	}
+
+	names := make([]string, 0, len(local))
+	for name := range local {
+		names = append(names, name)
+	}
+
+	if c.IsSet("from-dir") {
+		// blocks without a local file would be gone after pushing the directory
+		for name := range installed {
+			if _, found := local[name]; !found {
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		toFile := "/dev/null"
+		if f, found := local[name]; found {
+			toFile = f.path
+		}
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(installed[name]),
+			B:        splitLines(local[name].content),
+			FromFile: fmt.Sprintf("%s (%s)", formatInstanceName(c), name),
+			ToFile:   toFile,
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+
+		sb.WriteString(diff)
+	}
+
+	if sb.Len() == 0 {
+		fmt.Fprintf(c.App.Writer, "No differences found in blocks of %s.\n", formatInstanceName(c))
+		return nil
+	}
+
+	diff := sb.String()
+	if th := themeFromFlags(c); th.Enabled() {
+		diff = colorizeDiff(diff, th)
+	}
+
+	fmt.Fprint(c.App.Writer, diff)
+	return fmt.Errorf("blocks of %s differ from the local files", formatInstanceName(c))
+}
+
+type localBlock struct {
+	path    string
+	content string
+}
+
+func localBlocksForDiff(c *cli.Context) (map[string]localBlock, error) {
+	if path := c.Path("from-file"); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err = expandEnvFromFlags(c, content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		return map[string]localBlock{c.String("name"): {path: path, content: string(content)}}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(c.Path("from-dir"), "*.conf"))
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make(map[string]localBlock)
+	for _, path := range files {
+		name := strings.TrimSuffix(filepath.Base(path), ".conf")
+		if !slices.Contains(allowedBlockTypes, name) {
+			return nil, fmt.Errorf("%s does not match a block name (one of: %s)", path, strings.Join(allowedBlockTypes, ", "))
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err = expandEnvFromFlags(c, content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		blocks[name] = localBlock{path: path, content: string(content)}
+	}
+
+	return blocks, nil
+}
+
+// splitLines is like difflib.SplitLines but without the extra empty line when
+// the text already ends with a new line.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	return lines[:len(lines)-1]
+}
+
+// colorizeDiff paints the file headers, the removed and added lines and the
+// hunk headers with the diff colors of the theme (e.g. bold, red, green and
+// cyan on the dark one).
+func colorizeDiff(diff string, th theme.Theme) string {
+	header, removed, added, hunk := th.DiffHeader.New(), th.DiffRemoved.New(), th.DiffAdded.New(), th.DiffHunk.New()
+
+	original := strings.SplitAfter(diff, "\n")
+	lines := slices.Clone(original)
+	for i, line := range original {
+		text, newLine := strings.CutSuffix(line, "\n")
+		var painter *color.Color
+		switch {
+		// NOTE: removed Lua comments also start with "---", so file headers
+		// are told apart by coming in pairs.
+		case strings.HasPrefix(text, "--- ") && i+1 < len(original) && strings.HasPrefix(original[i+1], "+++ "),
+			strings.HasPrefix(text, "+++ ") && i > 0 && strings.HasPrefix(original[i-1], "--- "):
+			painter = header
+		case strings.HasPrefix(text, "-"):
+			painter = removed
+		case strings.HasPrefix(text, "+"):
+			painter = added
+		case strings.HasPrefix(text, "@@"):
+			painter = hunk
+		default:
+			continue
+		}
+
+		lines[i] = painter.Sprint(text)
+		if newLine {
+			lines[i] += "\n"
+		}
+	}
+
+	return strings.Join(lines, "")
 }
@@ -8,7 +8,10 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -28,6 +31,17 @@ func TestUpdateBlock(t *testing.T) {
 	require.NoError(t, blockFile.Close())
 	defer os.Remove(blockFile.Name())
 
+	templateFile, err := os.CreateTemp("", "nginx.*.cfg.tmpl")
+	require.NoError(t, err)
+	_, err = templateFile.Write([]byte("server_name [[ .host ]];\nlisten [[ .port ]];\nadd_header X-Instance {{ .Instance.Name }};\n"))
+	require.NoError(t, err)
+	require.NoError(t, templateFile.Close())
+	defer os.Remove(templateFile.Name())
+
+	envFile := filepath.Join(t.TempDir(), "server.conf")
+	require.NoError(t, os.WriteFile(envFile, []byte("proxy_pass http://${BACKEND_HOST}$${request_uri};\n"), 0644))
+	t.Setenv("BACKEND_HOST", "app.tsuru.example.com")
+
 	tests := []struct {
 		name          string
 		args          []string
@@ -67,6 +81,52 @@ func TestUpdateBlock(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "rendering the content as a template",
+			args:     []string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "server", "--content", templateFile.Name(), "--template", "--set", "host=example.com", "--set", "port=8080"},
+			expected: "NGINX configuration fragment inserted at \"server\" context\n",
+			client: &fake.FakeClient{
+				FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+					assert.Equal(t, "server_name example.com;\nlisten 8080;\nadd_header X-Instance {{ .Instance.Name }};\n", args.Content)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when template references an undefined variable",
+			args:          []string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "server", "--content", templateFile.Name(), "--template", "--set", "host=example.com"},
+			expectedError: `could not render the block template: template: block:2:10: executing "block" at <.port>: map has no entry for key "port"`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when a variable is not in the KEY=VALUE format",
+			args:          []string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "server", "--content", templateFile.Name(), "--template", "--set", "host"},
+			expectedError: `invalid template variable "host": must be in the KEY=VALUE format`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:     "expanding environment variables",
+			args:     []string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "server", "--content", envFile, "--expand-env"},
+			expected: "NGINX configuration fragment inserted at \"server\" context\n",
+			client: &fake.FakeClient{
+				FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+					assert.Equal(t, "proxy_pass http://app.tsuru.example.com${request_uri};\n", args.Content)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when allowing empty environment variables without --expand-env",
+			args:          []string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "server", "--content", envFile, "--allow-empty-env"},
+			expectedError: "--allow-empty-env can only be used along with --expand-env",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when setting variables without --template",
+			args:          []string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "server", "--content", templateFile.Name(), "--set", "host=example.com"},
+			expectedError: "--set can only be used along with --template",
+			client:        &fake.FakeClient{},
+		},
 	}
 
 	for _, tt := range tests {
@@ -87,6 +147,397 @@ func TestUpdateBlock(t *testing.T) {
 	}
 }
 
+func TestUpdateBlockAppendPrepend(t *testing.T) {
+	current := "location /health {\n    return 200;\n}\n"
+
+	tests := []struct {
+		name          string
+		args          []string
+		blocks        []clientTypes.Block
+		expected      string
+		expectedError string
+		expectedSent  []string
+	}{
+		{
+			name:         "appending and prepending snippets",
+			args:         []string{"--name", "server", "--prepend", "set $backend app;", "--append", "location /static {\n    root /var/www; # {\n}"},
+			blocks:       []clientTypes.Block{{Name: "http", Content: "# other block"}, {Name: "server", Content: current}},
+			expected:     "NGINX configuration fragment inserted at \"server\" context\n",
+			expectedSent: []string{"set $backend app;\nlocation /health {\n    return 200;\n}\nlocation /static {\n    root /var/www; # {\n}\n"},
+		},
+		{
+			name:         "appending to a block not set yet",
+			args:         []string{"--name", "http", "--append", "gzip on;\n"},
+			expected:     "NGINX configuration fragment inserted at \"http\" context\n",
+			expectedSent: []string{"gzip on;\n"},
+		},
+		{
+			name:          "when the result has unbalanced braces",
+			args:          []string{"--name", "server", "--append", "location /static {\n    return 200 \"}\";"},
+			blocks:        []clientTypes.Block{{Name: "server", Content: current}},
+			expectedError: `the "server" block would be left with unbalanced braces: missing "}" for the "{" on line 4`,
+		},
+		{
+			name:          "when the snippet closes a brace not opened",
+			args:          []string{"--name", "server", "--prepend", "}"},
+			blocks:        []clientTypes.Block{{Name: "server", Content: current}},
+			expectedError: `the "server" block would be left with unbalanced braces: unexpected "}" on line 1`,
+		},
+		{
+			name:         "braces of Lua blocks are not checked",
+			args:         []string{"--name", "lua-server", "--append", "local t = {#items}"},
+			expected:     "NGINX configuration fragment inserted at \"lua-server\" context\n",
+			expectedSent: []string{"local t = {#items}\n"},
+		},
+		{
+			name:          "when the snippet is empty",
+			args:          []string{"--name", "server", "--append", " "},
+			expectedError: "--append must not be empty",
+		},
+		{
+			name:          "along with the content",
+			args:          []string{"--name", "server", "--append", "gzip on;", "--content", "/dev/null"},
+			expectedError: "--content cannot be used along with --append or --prepend",
+		},
+		{
+			name:          "without content nor snippets",
+			args:          []string{"--name", "server"},
+			expectedError: "either --content or --append/--prepend must be provided",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent []string
+			client := &fake.FakeClient{
+				FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+					return tt.blocks, nil
+				},
+				FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+					sent = append(sent, args.Content)
+					return nil
+				},
+			}
+
+			stdout := &bytes.Buffer{}
+			err := NewApp(stdout, &bytes.Buffer{}, client).Run(append([]string{"./rpaasv2", "blocks", "update", "-i", "my-instance"}, tt.args...))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Empty(t, sent)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Equal(t, tt.expectedSent, sent)
+		})
+	}
+}
+
+func TestUpdateBlockAppendOnConflict(t *testing.T) {
+	versions := []string{"v1", "v2"}
+	contents := []string{"gzip on;\n", "gzip on;\ngzip_types text/css;\n"}
+
+	var listed int
+	var sent []string
+	client := &fake.FakeClient{
+		FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+			return []clientTypes.Block{{Name: "http", Content: contents[0]}}, nil
+		},
+		FakeListBlocksWithVersion: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, string, error) {
+			i := min(listed, len(versions)-1)
+			listed++
+			return []clientTypes.Block{{Name: "http", Content: contents[i]}}, versions[i], nil
+		},
+		FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+			sent = append(sent, args.Content)
+			if args.Version == "v1" {
+				return rpaasclient.ErrBlocksConflict
+			}
+
+			return nil
+		},
+	}
+
+	stderr := &bytes.Buffer{}
+	err := NewApp(&bytes.Buffer{}, stderr, client).Run([]string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "http", "--append", "gzip_min_length 1024;"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"gzip on;\ngzip_min_length 1024;\n",
+		"gzip on;\ngzip_types text/css;\ngzip_min_length 1024;\n",
+	}, sent)
+	assert.Equal(t, "Warning: blocks were changed meanwhile, retrying the update of the \"http\" block\n", stderr.String())
+}
+
+func TestUpdateBlockValidateRemote(t *testing.T) {
+	blockFile := filepath.Join(t.TempDir(), "server.conf")
+	require.NoError(t, os.WriteFile(blockFile, []byte("lisen 8080;"), 0644))
+
+	tests := []struct {
+		name           string
+		result         *clientTypes.BlockValidation
+		validateErr    error
+		expected       string
+		expectedStderr string
+		expectedError  string
+		expectedUpdate bool
+	}{
+		{
+			name:           "when the block is valid",
+			result:         &clientTypes.BlockValidation{Pod: "my-instance-0", Valid: true, Output: "nginx: configuration file nginx.conf test is successful\n"},
+			expected:       "NGINX configuration validated on pod my-instance-0\nNGINX configuration fragment inserted at \"server\" context\n",
+			expectedUpdate: true,
+		},
+		{
+			name:           "when the block is invalid",
+			result:         &clientTypes.BlockValidation{Pod: "my-instance-0", Output: "nginx: [emerg] unknown directive \"lisen\" in nginx.conf:42\nnginx: configuration file nginx.conf test failed"},
+			expectedStderr: "nginx: [emerg] unknown directive \"lisen\" in nginx.conf:42\nnginx: configuration file nginx.conf test failed\n",
+			expectedError:  "the \"server\" block is invalid according to nginx -t on pod my-instance-0, so it was not updated",
+		},
+		{
+			name:           "when the API does not support validating blocks",
+			validateErr:    rpaasclient.ErrValidateBlockUnsupported,
+			expected:       "NGINX configuration fragment inserted at \"server\" context\n",
+			expectedStderr: "WARNING: the API does not support validating blocks, updating the block without validating it (upgrade the API or drop --validate-remote)\n",
+			expectedUpdate: true,
+		},
+		{
+			name:          "when the validation fails",
+			validateErr:   fmt.Errorf("no running pod of instance \"my-instance\" to validate the block on"),
+			expectedError: "no running pod of instance \"my-instance\" to validate the block on",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated bool
+			client := &fake.FakeClient{
+				FakeValidateBlock: func(args rpaasclient.ValidateBlockArgs) (*clientTypes.BlockValidation, error) {
+					assert.Equal(t, rpaasclient.ValidateBlockArgs{Instance: "my-instance", Name: "server", Content: "lisen 8080;"}, args)
+					return tt.result, tt.validateErr
+				},
+				FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+					updated = true
+					return nil
+				},
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			err := NewApp(stdout, stderr, client).Run([]string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "server", "--content", blockFile, "--validate-remote"})
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+			assert.Equal(t, tt.expectedUpdate, updated)
+		})
+	}
+}
+
+func TestUpdateBlockWithBackup(t *testing.T) {
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return time.Date(2023, time.May, 10, 12, 30, 45, 0, time.UTC) }
+
+	blockFile := filepath.Join(t.TempDir(), "server.conf")
+	require.NoError(t, os.WriteFile(blockFile, []byte("# new content"), 0644))
+
+	tests := []struct {
+		name           string
+		blocks         []clientTypes.Block
+		listErr        error
+		expected       string
+		expectedBackup string
+		expectedError  string
+	}{
+		{
+			name:           "when the block exists",
+			blocks:         []clientTypes.Block{{Name: "http", Content: "# http"}, {Name: "server", Content: "# previous content"}},
+			expected:       "Previous content of the \"server\" block saved to <dir>/my-instance-server-20230510T123045Z.conf\nNGINX configuration fragment inserted at \"server\" context\n",
+			expectedBackup: "# previous content",
+		},
+		{
+			name:     "when the block does not exist yet",
+			blocks:   []clientTypes.Block{{Name: "http", Content: "# http"}},
+			expected: "No previous content of the \"server\" block, skipping the backup\nNGINX configuration fragment inserted at \"server\" context\n",
+		},
+		{
+			name:          "when the current blocks cannot be fetched",
+			listErr:       fmt.Errorf("some error"),
+			expectedError: "could not fetch the current blocks to back up: some error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "backups")
+
+			var updated bool
+			client := &fake.FakeClient{
+				FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+					assert.Equal(t, rpaasclient.ListBlocksArgs{Instance: "my-instance"}, args)
+					assert.False(t, updated, "the backup must be taken before updating the block")
+					return tt.blocks, tt.listErr
+				},
+				FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+					assert.Equal(t, rpaasclient.UpdateBlockArgs{Instance: "my-instance", Name: "server", Content: "# new content"}, args)
+					updated = true
+					return nil
+				},
+			}
+
+			stdout := &bytes.Buffer{}
+			err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "server", "--content", blockFile, "--backup-dir", dir})
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.False(t, updated)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, updated)
+			assert.Equal(t, tt.expected, strings.ReplaceAll(stdout.String(), dir, "<dir>"))
+
+			if tt.expectedBackup == "" {
+				assert.NoDirExists(t, dir)
+				return
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, "my-instance-server-20230510T123045Z.conf"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedBackup, string(data))
+		})
+	}
+
+	t.Run("when backing up twice within the same second", func(t *testing.T) {
+		dir := t.TempDir()
+
+		content := "# first content"
+		client := &fake.FakeClient{
+			FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+				return []clientTypes.Block{{Name: "server", Content: content}}, nil
+			},
+		}
+
+		for _, expected := range []string{"my-instance-server-20230510T123045Z.conf", "my-instance-server-20230510T123045Z-1.conf"} {
+			stdout := &bytes.Buffer{}
+			err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "server", "--content", blockFile, "--backup-dir", dir})
+			require.NoError(t, err)
+			assert.Contains(t, stdout.String(), filepath.Join(dir, expected))
+			content = "# second content"
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "my-instance-server-20230510T123045Z.conf"))
+		require.NoError(t, err)
+		assert.Equal(t, "# first content", string(data))
+
+		data, err = os.ReadFile(filepath.Join(dir, "my-instance-server-20230510T123045Z-1.conf"))
+		require.NoError(t, err)
+		assert.Equal(t, "# second content", string(data))
+	})
+}
+
+func TestUpdateBlockOnConflict(t *testing.T) {
+	blockFile := filepath.Join(t.TempDir(), "server.conf")
+	require.NoError(t, os.WriteFile(blockFile, []byte("# new content"), 0644))
+
+	tests := []struct {
+		name          string
+		onConflict    string
+		listings      [][]clientTypes.Block
+		conflicts     int
+		expected      string
+		expectedWarns int
+		expectedError string
+	}{
+		{
+			name:          "when --on-conflict is invalid",
+			onConflict:    "ignore",
+			expectedError: `invalid --on-conflict "ignore" (one of: fail, overwrite, merge)`,
+		},
+		{
+			name:     "when no one changed the blocks meanwhile",
+			listings: [][]clientTypes.Block{{{Name: "server", Content: "# old content"}}},
+			expected: "NGINX configuration fragment inserted at \"server\" context\n",
+		},
+		{
+			name:          "when failing on conflicts",
+			onConflict:    "fail",
+			listings:      [][]clientTypes.Block{{{Name: "server", Content: "# old content"}}},
+			conflicts:     1,
+			expectedError: "rpaasv2: blocks were changed concurrently",
+		},
+		{
+			name:          "when overwriting on conflicts (default)",
+			listings:      [][]clientTypes.Block{{{Name: "server", Content: "# old content"}}, {{Name: "server", Content: "# someone else's content"}}},
+			conflicts:     1,
+			expected:      "NGINX configuration fragment inserted at \"server\" context\n",
+			expectedWarns: 1,
+		},
+		{
+			name:          "when overwriting keeps conflicting",
+			listings:      [][]clientTypes.Block{{{Name: "server", Content: "# old content"}}, {}, {}, {}},
+			conflicts:     4,
+			expectedError: "giving up after 3 retries: rpaasv2: blocks were changed concurrently",
+		},
+		{
+			name:          "when merging updates of distinct blocks",
+			onConflict:    "merge",
+			listings:      [][]clientTypes.Block{{{Name: "server", Content: "# old content"}}, {{Name: "http", Content: "# http"}, {Name: "server", Content: "# old content"}}},
+			conflicts:     1,
+			expected:      "NGINX configuration fragment inserted at \"server\" context\n",
+			expectedWarns: 1,
+		},
+		{
+			name:          "when merging but the same block was changed meanwhile",
+			onConflict:    "merge",
+			listings:      [][]clientTypes.Block{{{Name: "server", Content: "# old content"}}, {{Name: "server", Content: "# someone else's content"}}},
+			conflicts:     1,
+			expectedError: `cannot merge: block "server" was changed meanwhile`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var listed, updated int
+			client := &fake.FakeClient{
+				FakeListBlocksWithVersion: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, string, error) {
+					assert.Equal(t, rpaasclient.ListBlocksArgs{Instance: "my-instance"}, args)
+					require.Less(t, listed, len(tt.listings))
+					listed++
+					return tt.listings[listed-1], fmt.Sprintf("v%d", listed), nil
+				},
+				FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+					updated++
+					assert.Equal(t, rpaasclient.UpdateBlockArgs{Instance: "my-instance", Name: "server", Content: "# new content", Version: fmt.Sprintf("v%d", listed)}, args)
+					if updated <= tt.conflicts {
+						return rpaasclient.ErrBlocksConflict
+					}
+					return nil
+				},
+			}
+
+			args := []string{"./rpaasv2", "blocks", "update", "-i", "my-instance", "--name", "server", "--content", blockFile}
+			if tt.onConflict != "" {
+				args = append(args, "--on-conflict", tt.onConflict)
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			err := NewApp(stdout, stderr, client).Run(args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Equal(t, tt.expectedWarns, strings.Count(stderr.String(), "Warning: blocks were changed meanwhile"))
+		})
+	}
+}
+
 func TestDeleteBlock(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -190,6 +641,67 @@ func TestListBlocks(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "when listing blocks on CSV format",
+			args: []string{"./rpaasv2", "blocks", "list", "-i", "my-instance", "--output", "csv"},
+			expected: "Context,Configuration\r\n" +
+				"http,\"log_format main '$remote_addr, $status';\r\nmap $host $x { default \"\"\"\"; }\"\r\n" +
+				"server,# plain\r\n",
+			client: &fake.FakeClient{
+				FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+					return []clientTypes.Block{
+						{Name: "http", Content: "log_format main '$remote_addr, $status';\nmap $host $x { default \"\"; }"},
+						{Name: "server", Content: "# plain"},
+					}, nil
+				},
+			},
+		},
+		{
+			name:          "when listing blocks on an unknown format",
+			args:          []string{"./rpaasv2", "blocks", "list", "-i", "my-instance", "--output", "xml"},
+			expectedError: `unsupported output format "xml" (one of: table, json, yaml, csv, jsonl)`,
+			client: &fake.FakeClient{
+				FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+					return nil, nil
+				},
+			},
+		},
+		{
+			name: "when sorting blocks by size in reverse order",
+			args: []string{"./rpaasv2", "blocks", "list", "-i", "my-instance", "-o", "csv", "--sort", "size", "--reverse"},
+			expected: "Context,Configuration\r\n" +
+				"server,# some server configuration\r\n" +
+				"http,# some HTTP configuration\r\n" +
+				"root,# some configuration\r\n" +
+				"lua-worker,# some configuration\r\n",
+			client: &fake.FakeClient{
+				FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+					return []clientTypes.Block{
+						{Name: "root", Content: "# some configuration"},
+						{Name: "http", Content: "# some HTTP configuration"},
+						{Name: "lua-worker", Content: "# some configuration"},
+						{Name: "server", Content: "# some server configuration"},
+					}, nil
+				},
+			},
+		},
+		{
+			name: "when sorting blocks by name",
+			args: []string{"./rpaasv2", "blocks", "list", "-i", "my-instance", "-o", "csv", "--sort", "name"},
+			expected: "Context,Configuration\r\n" +
+				"http,# some HTTP configuration\r\n" +
+				"root,# some configuration\r\n" +
+				"server,# some server configuration\r\n",
+			client: &fake.FakeClient{
+				FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+					return []clientTypes.Block{
+						{Name: "server", Content: "# some server configuration"},
+						{Name: "root", Content: "# some configuration"},
+						{Name: "http", Content: "# some HTTP configuration"},
+					}, nil
+				},
+			},
+		},
 		{
 			name: "when listing blocks on raw format",
 			args: []string{"./rpaasv2", "blocks", "list", "-i", "my-instance", "--raw-output"},
@@ -236,3 +748,233 @@ func TestListBlocks(t *testing.T) {
 		})
 	}
 }
+
+func TestGetBlock(t *testing.T) {
+	client := &fake.FakeClient{
+		FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+			assert.Equal(t, rpaasclient.ListBlocksArgs{Instance: "my-instance"}, args)
+			return []clientTypes.Block{
+				{Name: "http", Content: "# some HTTP configuration"},
+				{Name: "server", Content: "location /health {\n    return 200;\n}\n"},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        rpaasclient.Client
+	}{
+		{
+			name:     "writing the raw content",
+			args:     []string{"./rpaasv2", "blocks", "get", "-i", "my-instance", "--name", "server"},
+			expected: "location /health {\n    return 200;\n}\n",
+			client:   client,
+		},
+		{
+			name:     "writing the raw content without a trailing new line",
+			args:     []string{"./rpaasv2", "blocks", "get", "-i", "my-instance", "--name", "http"},
+			expected: "# some HTTP configuration\n",
+			client:   client,
+		},
+		{
+			name: "on JSON format",
+			args: []string{"./rpaasv2", "blocks", "get", "-s", "my-service", "-i", "my-instance", "--name", "server", "-o", "json"},
+			expected: `{
+	"instance": "my-instance",
+	"name": "server",
+	"size": 37,
+	"content": "location /health {\n    return 200;\n}\n"
+}
+`,
+			client: client,
+		},
+		{
+			name:     "with a field of the JSON output",
+			args:     []string{"./rpaasv2", "blocks", "get", "-i", "my-instance", "--name", "server", "-o", "json", "--field", "size"},
+			expected: "37\n",
+			client:   client,
+		},
+		{
+			name:          "when the block does not exist",
+			args:          []string{"./rpaasv2", "blocks", "get", "-s", "my-service", "-i", "my-instance", "--name", "lua-server"},
+			expectedError: `block "lua-server" not found in my-service/my-instance`,
+			client:        client,
+		},
+		{
+			name:          "with an unsupported output format",
+			args:          []string{"./rpaasv2", "blocks", "get", "-i", "my-instance", "--name", "server", "-o", "table"},
+			expectedError: `unsupported output format "table" (one of: raw, json, yaml)`,
+			client:        client,
+		},
+		{
+			name:          "with a field of the raw output",
+			args:          []string{"./rpaasv2", "blocks", "get", "-i", "my-instance", "--name", "server", "--field", "size"},
+			expectedError: "--field and --allow-missing can only be used along with --output json",
+			client:        client,
+		},
+		{
+			name:          "when ListBlocks returns an error",
+			args:          []string{"./rpaasv2", "blocks", "get", "-i", "my-instance", "--name", "server"},
+			expectedError: "some error",
+			client: &fake.FakeClient{
+				FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+					return nil, fmt.Errorf("some error")
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			err := NewApp(stdout, stderr, tt.client).Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
+
+func TestDiffBlocks(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "http.conf"), []byte("# some http config\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "server.conf"), []byte("# new server config\nlocation /x {}\n"), 0644))
+
+	envFile := filepath.Join(t.TempDir(), "server.conf")
+	require.NoError(t, os.WriteFile(envFile, []byte("# ${SERVER_VERSION} server config\nlocation /x {}\n"), 0644))
+
+	client := &fake.FakeClient{
+		FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+			assert.Equal(t, rpaasclient.ListBlocksArgs{Instance: "my-instance"}, args)
+			return []clientTypes.Block{
+				{Name: "http", Content: "# some http config\n"},
+				{Name: "server", Content: "# old server config\nlocation /x {}\n"},
+				{Name: "lua-server", Content: "-- some lua code\n"},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		unsetEnv      bool
+		expectedError string
+	}{
+		{
+			name:          "without any local file",
+			args:          []string{"./rpaasv2", "blocks", "diff", "-i", "my-instance"},
+			expectedError: "either --from-file or --from-dir must be provided",
+		},
+		{
+			name:          "when --from-file is missing --name",
+			args:          []string{"./rpaasv2", "blocks", "diff", "-i", "my-instance", "--from-file", "{{dir}}/http.conf"},
+			expectedError: "--name must be provided along with --from-file",
+		},
+		{
+			name:     "when the block is the same",
+			args:     []string{"./rpaasv2", "blocks", "diff", "-s", "rpaasv2", "-i", "my-instance", "--name", "http", "--from-file", "{{dir}}/http.conf"},
+			expected: "No differences found in blocks of rpaasv2/my-instance.\n",
+		},
+		{
+			name: "when the block differs",
+			args: []string{"./rpaasv2", "blocks", "diff", "-i", "my-instance", "--name", "server", "--from-file", "{{dir}}/server.conf", "--without-color"},
+			expected: `--- my-instance (server)
++++ {{dir}}/server.conf
+@@ -1,2 +1,2 @@
+-# old server config
++# new server config
+ location /x {}
+`,
+			expectedError: "blocks of my-instance differ from the local files",
+		},
+		{
+			name: "comparing every block from a directory",
+			args: []string{"./rpaasv2", "blocks", "diff", "-i", "my-instance", "--from-dir", "{{dir}}"},
+			expected: "\x1b[1m--- my-instance (lua-server)\x1b[0m\n" +
+				"\x1b[1m+++ /dev/null\x1b[0m\n" +
+				"\x1b[36m@@ -1 +0,0 @@\x1b[0m\n" +
+				"\x1b[31m--- some lua code\x1b[0m\n" +
+				"\x1b[1m--- my-instance (server)\x1b[0m\n" +
+				"\x1b[1m+++ {{dir}}/server.conf\x1b[0m\n" +
+				"\x1b[36m@@ -1,2 +1,2 @@\x1b[0m\n" +
+				"\x1b[31m-# old server config\x1b[0m\n" +
+				"\x1b[32m+# new server config\x1b[0m\n" +
+				" location /x {}\n",
+			expectedError: "blocks of my-instance differ from the local files",
+		},
+		{
+			name: "with the light theme",
+			args: []string{"./rpaasv2", "--theme", "light", "blocks", "diff", "-i", "my-instance", "--name", "server", "--from-file", "{{dir}}/server.conf"},
+			expected: "\x1b[1m--- my-instance (server)\x1b[0m\n" +
+				"\x1b[1m+++ {{dir}}/server.conf\x1b[0m\n" +
+				"\x1b[34m@@ -1,2 +1,2 @@\x1b[0m\n" +
+				"\x1b[31m-# old server config\x1b[0m\n" +
+				"\x1b[32m+# new server config\x1b[0m\n" +
+				" location /x {}\n",
+			expectedError: "blocks of my-instance differ from the local files",
+		},
+		{
+			name: "with the none theme",
+			args: []string{"./rpaasv2", "--theme", "none", "blocks", "diff", "-i", "my-instance", "--name", "server", "--from-file", "{{dir}}/server.conf"},
+			expected: `--- my-instance (server)
++++ {{dir}}/server.conf
+@@ -1,2 +1,2 @@
+-# old server config
++# new server config
+ location /x {}
+`,
+			expectedError: "blocks of my-instance differ from the local files",
+		},
+		{
+			name:     "expanding environment variables",
+			args:     []string{"./rpaasv2", "blocks", "diff", "-i", "my-instance", "--name", "server", "--from-file", envFile, "--expand-env"},
+			expected: "No differences found in blocks of my-instance.\n",
+		},
+		{
+			name:          "expanding unset environment variables",
+			args:          []string{"./rpaasv2", "blocks", "diff", "-i", "my-instance", "--name", "server", "--from-file", envFile, "--expand-env"},
+			unsetEnv:      true,
+			expectedError: envFile + ": environment variables not set: SERVER_VERSION (use --allow-empty-env to replace them with empty strings)",
+		},
+		{
+			name:          "with an unknown theme",
+			args:          []string{"./rpaasv2", "--theme", "solarized", "blocks", "diff", "-i", "my-instance", "--name", "server", "--from-file", "{{dir}}/server.conf"},
+			expectedError: `unknown theme "solarized" (one of: dark, light, none)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SERVER_VERSION", "old")
+			if tt.unsetEnv {
+				os.Unsetenv("SERVER_VERSION")
+			}
+
+			var args []string
+			for _, arg := range tt.args {
+				args = append(args, strings.ReplaceAll(arg, "{{dir}}", dir))
+			}
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, client)
+			err := app.Run(args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, strings.ReplaceAll(tt.expected, "{{dir}}", dir), stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
@@ -5,10 +5,23 @@
 package cmd
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
@@ -23,12 +36,182 @@ func NewCmdCertificates() *cli.Command {
 		Aliases: []string{"certificate"},
 		Usage:   "Manages TLS certificates",
 		Subcommands: []*cli.Command{
-			NewCmdUpdateCertitifcate(),
-			NewCmdDeleteCertitifcate(),
+			NewCmdListCertificates(),
+			withInstanceFile(NewCmdUpdateCertitifcate()),
+			withInstanceFile(NewCmdDeleteCertitifcate()),
+			withInstanceFile(NewCmdRotateCertificate()),
+			NewCmdExportCertificates(),
 		},
 	}
 }
 
+func NewCmdListCertificates() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "Shows the certificates of an instance along with their key types",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "audit",
+				Usage: "audits the TLS protocol versions and cipher suites accepted on the hosts of the certificates instead of listing them, flagging the weak ones (TLS older than 1.2, RC4 and 3DES)",
+			},
+			&cli.BoolFlag{
+				Name:  "fail-on-weak",
+				Usage: "fails when the audit finds weak TLS configurations",
+			},
+			&cli.DurationFlag{
+				Name:  "expiring-within",
+				Usage: "shows only the certificates expired or expiring within this period (e.g. 720h), failing when there's any of them (meant for monitoring jobs)",
+			},
+			outputFlag("table", "json", "yaml", "csv", "jsonl"),
+		}, outputFieldFlags()...),
+		Before: setupClient,
+		Action: runListCertificates,
+	}
+}
+
+func runListCertificates(c *cli.Context) error {
+	if c.Bool("fail-on-weak") && !c.Bool("audit") {
+		return fmt.Errorf("--fail-on-weak can only be used along with --audit")
+	}
+
+	expiringWithin := c.Duration("expiring-within")
+	if c.IsSet("expiring-within") {
+		if c.Bool("audit") {
+			return fmt.Errorf("--expiring-within cannot be used along with --audit")
+		}
+
+		if expiringWithin <= 0 {
+			return fmt.Errorf("--expiring-within must be greater than zero")
+		}
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	certs, err := client.ListCertificates(c.Context, rpaasclient.ListCertificatesArgs{Instance: c.String("instance")})
+	if err != nil {
+		return err
+	}
+
+	metadata := certificatesMetadata(certs)
+	if c.Bool("audit") {
+		return runCertificatesAudit(c, client, metadata)
+	}
+
+	if c.IsSet("expiring-within") {
+		metadata = expiringCertificates(metadata, timeNow().Add(expiringWithin))
+	}
+
+	for i := range metadata {
+		if !metadata[i].OCSPStapling {
+			continue
+		}
+
+		for _, cert := range certs {
+			if cert.Name == metadata[i].Name {
+				metadata[i].OCSP = checkOCSPStatus(c.Context, cert.Certificate)
+			}
+		}
+	}
+
+	err = writeListOutput(c, c.String("output"), metadata, certificatesRecords(metadata), func(w io.Writer) error {
+		if len(metadata) == 0 && c.IsSet("expiring-within") {
+			fmt.Fprintf(w, "No certificates expiring within %s in %s\n", shortDuration(expiringWithin), formatInstanceName(c))
+			return nil
+		}
+
+		if len(metadata) == 0 {
+			fmt.Fprintf(w, "No certificates found in %s\n", formatInstanceName(c))
+			return nil
+		}
+
+		fmt.Fprint(w, writeCertificatesMetadataOnTableFormat(metadata))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.IsSet("expiring-within") && len(metadata) > 0 {
+		return fmt.Errorf("%d certificate(s) expiring within %s", len(metadata), shortDuration(expiringWithin))
+	}
+
+	return nil
+}
+
+// expiringCertificates returns the certificates which are no longer valid
+// at deadline, including the already expired ones.
+func expiringCertificates(certs []certificateMetadata, deadline time.Time) []certificateMetadata {
+	expiring := []certificateMetadata{}
+	for _, c := range certs {
+		if c.NotAfter != nil && c.NotAfter.Before(deadline) {
+			expiring = append(expiring, c)
+		}
+	}
+
+	return expiring
+}
+
+// shortDuration formats d without its trailing zero units, e.g. 720h rather
+// than 720h0m0s.
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+
+	return s
+}
+
+func certificatesRecords(certs []certificateMetadata) records {
+	rec := records{Header: []string{"Name", "Key type", "DNS names", "Not after", "SHA256 fingerprint"}}
+	stapling := hasOCSPStapling(certs)
+	if stapling {
+		rec.Header = append(rec.Header, "OCSP status", "OCSP next update")
+	}
+
+	for _, c := range certs {
+		var notAfter string
+		if c.NotAfter != nil {
+			notAfter = c.NotAfter.UTC().Format(time.RFC3339)
+		}
+
+		row := []string{c.Name, c.KeyType, strings.Join(c.DNSNames, ","), notAfter, c.Fingerprint}
+		if stapling {
+			var status, nextUpdate string
+			if c.OCSP != nil {
+				status = c.OCSP.Status
+				if c.OCSP.NextUpdate != nil {
+					nextUpdate = c.OCSP.NextUpdate.UTC().Format(time.RFC3339)
+				}
+			}
+
+			row = append(row, status, nextUpdate)
+		}
+
+		rec.Rows = append(rec.Rows, row)
+	}
+
+	return rec
+}
+
 func NewCmdUpdateCertitifcate() *cli.Command {
 	return &cli.Command{
 		Name:    "update",
@@ -48,7 +231,7 @@ func NewCmdUpdateCertitifcate() *cli.Command {
 			},
 			&cli.StringFlag{
 				Name:  "name",
-				Usage: "an identifier for the current certificate and key",
+				Usage: "an identifier for the current certificate and key - each named certificate is served (via SNI) for the DNS names it holds, e.g. use the host name as the identifier",
 				Value: "default",
 			},
 			&cli.PathFlag{
@@ -77,6 +260,23 @@ func NewCmdUpdateCertitifcate() *cli.Command {
 				Name:  "issuer",
 				Usage: "a Cert Manager Issuer name (its usage requires --cert-manager)",
 			},
+			&cli.BoolFlag{
+				Name:  "staple-ocsp",
+				Usage: "whether NGINX should staple the OCSP responses of the certificate (the OCSP responder of its issuer must be reachable from the instance, setting resolvers on the plan when needed)",
+			},
+			&cli.BoolFlag{
+				Name:  "if-changed",
+				Usage: "skip the upload when the installed certificate is the same as the given one",
+			},
+			&cli.BoolFlag{
+				Name:  "wait",
+				Usage: "whether should wait until every pod serves the new certificate",
+			},
+			&cli.DurationFlag{
+				Name:  "wait-timeout",
+				Usage: "time limit to wait for the pods to serve the new certificate (requires --wait)",
+				Value: 5 * time.Minute,
+			},
 		},
 		Before: setupClient,
 		Action: runUpdateCertificate,
@@ -89,6 +289,10 @@ func runUpdateCertificate(c *cli.Context) error {
 		return err
 	}
 
+	if c.IsSet("staple-ocsp") && c.Bool("cert-manager") {
+		return fmt.Errorf("--staple-ocsp cannot be used along with --cert-manager")
+	}
+
 	handled, err := updateCertManagerCertificate(c, client)
 	if err != nil || handled {
 		return err
@@ -110,15 +314,205 @@ func runUpdateCertificate(c *cli.Context) error {
 		Certificate: string(certificate),
 		Key:         string(key),
 	}
+
+	if c.IsSet("staple-ocsp") {
+		stapleOCSP := c.Bool("staple-ocsp")
+		args.StapleOCSP = &stapleOCSP
+	}
+
+	if block, _ := pem.Decode(certificate); block != nil {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			for _, warning := range []string{weakPublicKeyWarning(cert), certificateNameWarning(args.Name, cert)} {
+				if warning != "" {
+					fmt.Fprintf(c.App.ErrWriter, "WARNING: %s\n", warning)
+				}
+			}
+		}
+	}
+
+	if c.Bool("if-changed") {
+		unchanged, err := isCertificateUnchanged(c, client, args)
+		if err != nil {
+			return err
+		}
+
+		if unchanged {
+			fmt.Fprintf(c.App.Writer, "certificate %q unchanged in %s\n", args.Name, formatInstanceName(c))
+			return nil
+		}
+	}
+
 	err = client.UpdateCertificate(c.Context, args)
 	if err != nil {
 		return err
 	}
 
 	fmt.Fprintf(c.App.Writer, "certificate %q updated in %s\n", args.Name, formatInstanceName(c))
+
+	if !c.Bool("wait") {
+		return nil
+	}
+
+	return waitForCertificatePropagation(c, client, args)
+}
+
+// waitForCertificatePropagation polls the certificate served by each pod
+// until all of them serve the given one, or the timeout is reached. Failures
+// are retried, as pods may refuse connections while NGINX is reloaded.
+func waitForCertificatePropagation(c *cli.Context, client rpaasclient.Client, args rpaasclient.UpdateCertificateArgs) error {
+	expected, err := certificateFingerprints(args.Certificate)
+	if err != nil {
+		return err
+	}
+
+	var serving, total int
+	var lastErr error
+	last := -1
+	err = pollUntil(c.Context, c.Duration("wait-timeout"), func(ctx context.Context) (bool, error) {
+		status, err := client.GetCertificateStatus(ctx, rpaasclient.CertificateStatusArgs{Instance: args.Instance, Name: args.Name})
+		if err != nil {
+			lastErr = err
+			return false, nil
+		}
+
+		serving, total, lastErr = 0, len(status), nil
+		for _, pod := range status {
+			if pod.Error == "" && slices.Equal(pod.Fingerprints, expected) {
+				serving++
+			}
+		}
+
+		if serving != last {
+			fmt.Fprintf(c.App.Writer, "Waiting for certificate: %d of %d pod(s) serving it\n", serving, total)
+			last = serving
+		}
+
+		return total > 0 && serving == total, nil
+	})
+	if errors.Is(err, errPollTimeout) {
+		if lastErr != nil {
+			return fmt.Errorf("timed out waiting for pods to serve certificate %q: %w", args.Name, lastErr)
+		}
+
+		return fmt.Errorf("timed out waiting for pods to serve certificate %q: %d of %d pod(s) serving it", args.Name, serving, total)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "All %d pod(s) are serving certificate %q\n", total, args.Name)
 	return nil
 }
 
+func isCertificateUnchanged(c *cli.Context, client rpaasclient.Client, args rpaasclient.UpdateCertificateArgs) (bool, error) {
+	expected, err := certificateFingerprints(args.Certificate)
+	if err != nil {
+		return false, err
+	}
+
+	certs, err := client.ListCertificates(c.Context, rpaasclient.ListCertificatesArgs{Instance: args.Instance})
+	if err != nil {
+		return false, err
+	}
+
+	for _, cert := range certs {
+		if cert.Name != args.Name {
+			continue
+		}
+
+		current, err := certificateFingerprints(cert.Certificate)
+		if err != nil {
+			// an unparseable installed certificate is always replaced
+			return false, nil
+		}
+
+		if args.StapleOCSP != nil && *args.StapleOCSP != cert.OCSPStapling {
+			return false, nil
+		}
+
+		return slices.Equal(expected, current), nil
+	}
+
+	return false, nil
+}
+
+// certificateFingerprints returns the SHA-256 of every certificate (in DER)
+// found in the PEM data, sorted so the chain order does not matter.
+func certificateFingerprints(data string) ([]string, error) {
+	var fingerprints []string
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		sum := sha256.Sum256(block.Bytes)
+		fingerprints = append(fingerprints, hex.EncodeToString(sum[:]))
+	}
+
+	if len(fingerprints) == 0 {
+		return nil, fmt.Errorf("no certificate found in PEM data")
+	}
+
+	sort.Strings(fingerprints)
+	return fingerprints, nil
+}
+
+// publicKeyType describes the certificate's public key as e.g. "RSA 2048"
+// or "ECDSA P-256".
+func publicKeyType(cert *x509.Certificate) string {
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ECDSA %s", pub.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return "Ed25519"
+	}
+
+	return cert.PublicKeyAlgorithm.String()
+}
+
+// weakPublicKeyWarning returns why the certificate's public key may be
+// rejected by clients, if so.
+func weakPublicKeyWarning(cert *x509.Certificate) string {
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if size := pub.N.BitLen(); size < 2048 {
+			return fmt.Sprintf("the certificate has an RSA key of %d bits, smaller than the recommended minimum of 2048 bits", size)
+		}
+
+	case *ecdsa.PublicKey:
+		if name := pub.Curve.Params().Name; name != "P-256" && name != "P-384" {
+			return fmt.Sprintf("the certificate has an ECDSA key on the %s curve, which is not supported by many clients (prefer P-256 or P-384)", name)
+		}
+	}
+
+	return ""
+}
+
+// certificateNameWarning returns why the certificate may not be the intended
+// one for its name, if so. Only names looking like host names (e.g.
+// "www.example.com") are checked against the certificate's DNS names.
+func certificateNameWarning(name string, cert *x509.Certificate) string {
+	if !strings.Contains(name, ".") {
+		return ""
+	}
+
+	if err := cert.VerifyHostname(name); err == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("the certificate name %q looks like a host name, but the certificate is not valid for it (DNS names: %s)", name, strings.Join(cert.DNSNames, ", "))
+}
+
 func updateCertManagerCertificate(c *cli.Context, client rpaasclient.Client) (bool, error) {
 	if !c.Bool("cert-manager") {
 		if c.String("issuer") != "" || len(c.StringSlice("dns")) > 0 || len(c.StringSlice("ip")) > 0 {
@@ -208,6 +602,252 @@ func runDeleteCertificate(c *cli.Context) error {
 	return nil
 }
 
+func NewCmdRotateCertificate() *cli.Command {
+	return &cli.Command{
+		Name:  "rotate",
+		Usage: "Replaces an installed certificate and key without downtime",
+		Description: `
+The new certificate is first uploaded under a temporary name (e.g. "default-rotate")
+and only swapped into the active name once every pod is serving it. The
+temporary certificate is removed at the end.
+
+# Rotate the "default" certificate, restoring the old one if anything goes wrong:
+rpaasv2 certificates rotate -s my-service -i my-instance --cert new.crt --key new.key --rollback-on-error
+`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "the identifier of the certificate being rotated",
+				Value: "default",
+			},
+			&cli.PathFlag{
+				Name:     "certificate",
+				Aliases:  []string{"cert", "cert-file"},
+				Usage:    "path in the system where the new certificate (in PEM format) is located",
+				Required: true,
+			},
+			&cli.PathFlag{
+				Name:     "key",
+				Aliases:  []string{"key-file"},
+				Usage:    "path in the system where the new key (in PEM format) is located",
+				Required: true,
+			},
+			&cli.DurationFlag{
+				Name:  "wait-timeout",
+				Usage: "how long to wait for the pods to serve each certificate change",
+				Value: 5 * time.Minute,
+			},
+			&cli.BoolFlag{
+				Name:  "rollback-on-error",
+				Usage: "restore the previous certificate and key if swapping them fails",
+			},
+		},
+		Before: setupClient,
+		Action: runRotateCertificate,
+	}
+}
+
+func runRotateCertificate(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	certificate, err := os.ReadFile(c.Path("certificate"))
+	if err != nil {
+		return err
+	}
+
+	key, err := os.ReadFile(c.Path("key"))
+	if err != nil {
+		return err
+	}
+
+	instance, name := c.String("instance"), c.String("name")
+
+	certs, err := client.ListCertificates(c.Context, rpaasclient.ListCertificatesArgs{Instance: instance})
+	if err != nil {
+		return err
+	}
+
+	index := slices.IndexFunc(certs, func(cert clientTypes.Certificate) bool { return cert.Name == name })
+	if index < 0 {
+		return fmt.Errorf("certificate %q not found in %s, use \"certificates update\" to add it", name, formatInstanceName(c))
+	}
+
+	previous := certs[index]
+	temporary := name + clientTypes.CertificateRotationSuffix
+
+	fmt.Fprintf(c.App.Writer, "Uploading the new certificate as %q\n", temporary)
+	err = client.UpdateCertificate(c.Context, rpaasclient.UpdateCertificateArgs{
+		Instance:    instance,
+		Name:        temporary,
+		Certificate: string(certificate),
+		Key:         string(key),
+		RotationOf:  name,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = swapCertificate(c, client, previous, temporary, string(certificate), string(key))
+
+	fmt.Fprintf(c.App.Writer, "Removing the temporary certificate %q\n", temporary)
+	if deleteErr := client.DeleteCertificate(c.Context, rpaasclient.DeleteCertificateArgs{Instance: instance, Name: temporary}); deleteErr != nil {
+		fmt.Fprintf(c.App.ErrWriter, "WARNING: could not remove the temporary certificate %q: %v\n", temporary, deleteErr)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "certificate %q rotated in %s\n", name, formatInstanceName(c))
+	return nil
+}
+
+// swapCertificate installs the new certificate under the active name once the
+// pods serve the temporary one, restoring the previous one on failure when
+// asked to.
+func swapCertificate(c *cli.Context, client rpaasclient.Client, previous clientTypes.Certificate, temporary, certificate, key string) error {
+	err := waitForCertificatePropagation(c, client, rpaasclient.UpdateCertificateArgs{
+		Instance:    c.String("instance"),
+		Name:        temporary,
+		Certificate: certificate,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Swapping the new certificate into %q\n", previous.Name)
+	args := rpaasclient.UpdateCertificateArgs{
+		Instance:    c.String("instance"),
+		Name:        previous.Name,
+		Certificate: certificate,
+		Key:         key,
+		RotationOf:  temporary,
+	}
+	err = client.UpdateCertificate(c.Context, args)
+	if err == nil {
+		err = waitForCertificatePropagation(c, client, args)
+	}
+
+	if err == nil || !c.Bool("rollback-on-error") {
+		return err
+	}
+
+	fmt.Fprintf(c.App.ErrWriter, "Swapping failed, restoring the previous certificate %q: %v\n", previous.Name, err)
+	rollbackErr := client.UpdateCertificate(c.Context, rpaasclient.UpdateCertificateArgs{
+		Instance:    c.String("instance"),
+		Name:        previous.Name,
+		Certificate: previous.Certificate,
+		Key:         previous.Key,
+		RotationOf:  temporary,
+	})
+	if rollbackErr != nil {
+		return fmt.Errorf("could not restore the previous certificate: %w (swap error: %v)", rollbackErr, err)
+	}
+
+	return fmt.Errorf("certificate %q was restored to the previous one: %w", previous.Name, err)
+}
+
+func NewCmdExportCertificates() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Downloads the certificate chains (without their private keys) installed in an instance",
+		Description: `
+Each certificate chain (leaf first) is written in PEM format to <output-dir>/<name>.crt
+or, when no output directory is given, concatenated to the standard output.
+
+# Save the "default" certificate chain into the current directory:
+rpaasv2 certificates export -s my-service -i my-instance --cert-name default --output-dir .
+`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "cert-name",
+				Usage: "exports only the certificate with this identifier",
+			},
+			&cli.PathFlag{
+				Name:  "output-dir",
+				Usage: "directory where each certificate chain is written to (defaults to the standard output)",
+			},
+		},
+		Before: setupClient,
+		Action: runExportCertificates,
+	}
+}
+
+func runExportCertificates(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	certs, err := client.ExportCertificates(c.Context, rpaasclient.ExportCertificatesArgs{
+		Instance: c.String("instance"),
+		Name:     c.String("cert-name"),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(certs) == 0 {
+		fmt.Fprintf(c.App.ErrWriter, "No certificates found in %s\n", formatInstanceName(c))
+		return nil
+	}
+
+	dir := c.Path("output-dir")
+	if dir == "" {
+		for _, cert := range certs {
+			fmt.Fprint(c.App.Writer, cert.Certificate)
+		}
+
+		return nil
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, cert := range certs {
+		// certificate names come from the server, so they must not be able
+		// to point anywhere outside the output directory
+		if cert.Name == "" || cert.Name == "." || cert.Name == ".." || strings.ContainsAny(cert.Name, `/\`) {
+			return fmt.Errorf("cannot export the certificate %q: invalid file name", cert.Name)
+		}
+
+		filename := filepath.Join(dir, cert.Name+".crt")
+		if err = os.WriteFile(filename, []byte(cert.Certificate), 0644); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "Exported the certificate %q to %s\n", cert.Name, filename)
+	}
+
+	return nil
+}
+
 func writeCertificatesInfoOnTableFormat(w io.Writer, certs []clientTypes.CertificateInfo) {
 	var data [][]string
 	for _, c := range certs {
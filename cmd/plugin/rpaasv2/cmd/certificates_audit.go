@@ -0,0 +1,328 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+const (
+	tlsAuditSourceHandshake     = "handshake"
+	tlsAuditSourceConfiguration = "configuration"
+)
+
+var (
+	// tlsAuditPort is the port where the instances serve HTTPS.
+	tlsAuditPort = "443"
+
+	// tlsAuditTimeout bounds each of the handshakes made against a host.
+	tlsAuditTimeout = 5 * time.Second
+
+	sslProtocolsRegexp = regexp.MustCompile(`(?m)^\s*ssl_protocols\s+([^;]+);`)
+	sslCiphersRegexp   = regexp.MustCompile(`(?m)^\s*ssl_ciphers\s+["']?([^;"']+)["']?\s*;`)
+)
+
+// tlsAudit holds the TLS protocol versions and cipher suites accepted on a
+// host of an instance, along with the weak ones among them.
+type tlsAudit struct {
+	Host        string   `json:"host"`
+	Address     string   `json:"address,omitempty"`
+	Source      string   `json:"source"`
+	Protocols   []string `json:"protocols,omitempty"`
+	CipherSuite string   `json:"cipherSuite,omitempty"`
+	Findings    []string `json:"findings,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// tlsAuditVersions are the protocol versions probed, from the oldest one.
+var tlsAuditVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+func runCertificatesAudit(c *cli.Context, client rpaasclient.Client, certs []certificateMetadata) error {
+	instance := c.String("instance")
+	hosts := auditableHosts(certs)
+
+	var audits []tlsAudit
+	if len(hosts) > 0 {
+		info, err := client.Info(c.Context, rpaasclient.InfoArgs{Instance: instance})
+		if err != nil {
+			return err
+		}
+
+		address := externalAddress(info.Addresses)
+
+		var blocks []clientTypes.Block
+		for _, host := range hosts {
+			audit := tlsAudit{Host: host, Address: address, Source: tlsAuditSourceHandshake}
+			if address != "" {
+				err = auditHandshake(c.Context, &audit)
+			}
+
+			// NOTE: internal-only hosts (or the ones unreachable from here)
+			// can't be handshaked, so what's configured on the instance is
+			// reported instead.
+			if address == "" || isDialError(err) {
+				if blocks == nil {
+					if blocks, err = client.ListBlocks(c.Context, rpaasclient.ListBlocksArgs{Instance: instance}); err != nil {
+						return err
+					}
+				}
+
+				audit = auditConfiguration(host, blocks)
+			} else if err != nil {
+				audit.Error = err.Error()
+			}
+
+			audits = append(audits, audit)
+		}
+	}
+
+	var weak int
+	for _, a := range audits {
+		if len(a.Findings) > 0 {
+			weak++
+		}
+	}
+
+	err := writeListOutput(c, c.String("output"), audits, tlsAuditRecords(audits), func(w io.Writer) error {
+		if len(audits) == 0 {
+			fmt.Fprintf(w, "No hosts to audit in %s\n", formatInstanceName(c))
+			return nil
+		}
+
+		writeTLSAuditOnTableFormat(w, audits)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if weak > 0 && c.Bool("fail-on-weak") {
+		return fmt.Errorf("found weak TLS configurations on %d of %d host(s)", weak, len(audits))
+	}
+
+	return nil
+}
+
+// auditableHosts returns the DNS names of certs, but the wildcard ones which
+// can't be handshaked.
+func auditableHosts(certs []certificateMetadata) []string {
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, cert := range certs {
+		for _, name := range cert.DNSNames {
+			if strings.HasPrefix(name, "*") || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			hosts = append(hosts, name)
+		}
+	}
+
+	sort.Strings(hosts)
+	return hosts
+}
+
+func externalAddress(addresses []clientTypes.InstanceAddress) string {
+	for _, a := range addresses {
+		if a.Type != clientTypes.InstanceAddressTypeClusterExternal {
+			continue
+		}
+
+		if a.Hostname != "" {
+			return a.Hostname
+		}
+
+		if a.IP != "" {
+			return a.IP
+		}
+	}
+
+	return ""
+}
+
+// auditHandshake fills audit by handshaking with its address once per
+// protocol version, then once more offering just the weak cipher suites.
+// The certificates aren't verified, as they're not the subject of the audit.
+func auditHandshake(ctx context.Context, audit *tlsAudit) error {
+	var firstErr error
+	for _, version := range tlsAuditVersions {
+		state, err := tlsHandshake(ctx, audit.Host, audit.Address, version, version, allCipherSuites())
+		if err != nil {
+			if isDialError(err) {
+				return err
+			}
+
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		protocol := tls.VersionName(version)
+		audit.Protocols = append(audit.Protocols, protocol)
+		audit.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+
+		if version < tls.VersionTLS12 {
+			audit.Findings = append(audit.Findings, fmt.Sprintf("accepts %s", protocol))
+		}
+	}
+
+	if len(audit.Protocols) == 0 {
+		return firstErr
+	}
+
+	if weak := weakCipherSuites(); len(weak) > 0 {
+		state, err := tlsHandshake(ctx, audit.Host, audit.Address, tls.VersionTLS10, tls.VersionTLS12, weak)
+		if isDialError(err) {
+			return err
+		}
+
+		if err == nil {
+			audit.Findings = append(audit.Findings, fmt.Sprintf("accepts %s", tls.CipherSuiteName(state.CipherSuite)))
+		}
+	}
+
+	return nil
+}
+
+func tlsHandshake(ctx context.Context, host, address string, minVersion, maxVersion uint16, cipherSuites []uint16) (*tls.ConnectionState, error) {
+	ctx, cancel := context.WithTimeout(ctx, tlsAuditTimeout)
+	defer cancel()
+
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: true,
+			MinVersion:         minVersion,
+			MaxVersion:         maxVersion,
+			CipherSuites:       cipherSuites,
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, tlsAuditPort))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	return &state, nil
+}
+
+// isDialError tells whether err happened while connecting to the host,
+// rather than while handshaking with it.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func allCipherSuites() []uint16 {
+	var suites []uint16
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		suites = append(suites, s.ID)
+	}
+
+	return suites
+}
+
+func weakCipherSuites() []uint16 {
+	var suites []uint16
+	for _, s := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if isWeakCipher(s.Name) {
+			suites = append(suites, s.ID)
+		}
+	}
+
+	return suites
+}
+
+// isWeakCipher tells whether the cipher suite named name (either on the IANA
+// or on the OpenSSL naming) uses RC4 or 3DES.
+func isWeakCipher(name string) bool {
+	name = strings.ToUpper(name)
+	return strings.Contains(name, "RC4") || strings.Contains(name, "3DES") || strings.Contains(name, "DES-CBC3")
+}
+
+// auditConfiguration fills the audit of host from the ssl_protocols and
+// ssl_ciphers directives set on the blocks of the instance. When they're
+// not set, the NGINX defaults apply and nothing is reported.
+func auditConfiguration(host string, blocks []clientTypes.Block) tlsAudit {
+	audit := tlsAudit{Host: host, Source: tlsAuditSourceConfiguration}
+	for _, b := range blocks {
+		for _, m := range sslProtocolsRegexp.FindAllStringSubmatch(b.Content, -1) {
+			audit.Protocols = strings.Fields(m[1])
+		}
+
+		for _, m := range sslCiphersRegexp.FindAllStringSubmatch(b.Content, -1) {
+			audit.CipherSuite = strings.TrimSpace(m[1])
+		}
+	}
+
+	for _, p := range audit.Protocols {
+		if p == "SSLv2" || p == "SSLv3" || p == "TLSv1" || p == "TLSv1.1" {
+			audit.Findings = append(audit.Findings, fmt.Sprintf("allows %s", p))
+		}
+	}
+
+	for _, c := range strings.Split(audit.CipherSuite, ":") {
+		if c != "" && !strings.HasPrefix(c, "!") && !strings.HasPrefix(c, "-") && isWeakCipher(c) {
+			audit.Findings = append(audit.Findings, fmt.Sprintf("allows %s", c))
+		}
+	}
+
+	return audit
+}
+
+func tlsAuditRecords(audits []tlsAudit) records {
+	rec := records{Header: []string{"Host", "Address", "Source", "Protocols", "Cipher suite", "Findings", "Error"}}
+	for _, a := range audits {
+		rec.Rows = append(rec.Rows, []string{a.Host, a.Address, a.Source, strings.Join(a.Protocols, ","), a.CipherSuite, strings.Join(a.Findings, ","), a.Error})
+	}
+
+	return rec
+}
+
+func writeTLSAuditOnTableFormat(w io.Writer, audits []tlsAudit) {
+	var data [][]string
+	for _, a := range audits {
+		protocols := strings.Join(a.Protocols, "\n")
+		if a.Source == tlsAuditSourceConfiguration && len(a.Protocols) == 0 {
+			protocols = "NGINX defaults"
+		}
+
+		findings := strings.Join(a.Findings, "\n")
+		if a.Error != "" {
+			findings = fmt.Sprintf("could not audit: %s", a.Error)
+		}
+
+		data = append(data, []string{a.Host, a.Source, protocols, a.CipherSuite, findings})
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Host", "Source", "Protocols", "Cipher suite", "Findings"})
+	table.SetRowLine(true)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.AppendBulk(data)
+	table.Render()
+}
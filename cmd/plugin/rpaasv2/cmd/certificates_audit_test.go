@@ -0,0 +1,162 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestCertificatesAudit(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), DNSNames: []string{"www.example.com", "*.example.com"}}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certs := []types.Certificate{{Name: "www.example.com", Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}}
+
+	newServer := func(t *testing.T, config *tls.Config) string {
+		server := httptest.NewUnstartedServer(http.NotFoundHandler())
+		server.TLS = config
+		server.Config.ErrorLog = log.New(io.Discard, "", 0)
+		server.StartTLS()
+		t.Cleanup(server.Close)
+
+		host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+		require.NoError(t, err)
+
+		previous := tlsAuditPort
+		tlsAuditPort = port
+		t.Cleanup(func() { tlsAuditPort = previous })
+		return host
+	}
+
+	tests := []struct {
+		name          string
+		config        *tls.Config
+		addresses     []types.InstanceAddress
+		blocks        []types.Block
+		args          []string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:   "with a host accepting only strong configurations",
+			config: &tls.Config{MinVersion: tls.VersionTLS12},
+			args:   []string{"--audit"},
+			expected: `+-----------------+-----------+-----------+------------------------+----------+
+| Host            | Source    | Protocols | Cipher suite           | Findings |
++-----------------+-----------+-----------+------------------------+----------+
+| www.example.com | handshake | TLS 1.2   | TLS_AES_128_GCM_SHA256 |          |
+|                 |           | TLS 1.3   |                        |          |
++-----------------+-----------+-----------+------------------------+----------+
+`,
+		},
+		{
+			name: "with a host accepting weak configurations",
+			config: &tls.Config{
+				MinVersion:   tls.VersionTLS10,
+				CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA, tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA},
+			},
+			args:          []string{"--audit", "-o", "csv", "--fail-on-weak"},
+			expected:      "Host,Address,Source,Protocols,Cipher suite,Findings,Error\r\nwww.example.com,127.0.0.1,handshake,\"TLS 1.0,TLS 1.1,TLS 1.2,TLS 1.3\",TLS_AES_128_GCM_SHA256,\"accepts TLS 1.0,accepts TLS 1.1,accepts TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA\",\r\n",
+			expectedError: "found weak TLS configurations on 1 of 1 host(s)",
+		},
+		{
+			name:      "with an internal-only host",
+			addresses: []types.InstanceAddress{{Type: types.InstanceAddressTypeClusterInternal, IP: "10.0.0.1"}},
+			blocks: []types.Block{
+				{Name: "server", Content: "ssl_protocols TLSv1 TLSv1.2;\nssl_ciphers 'HIGH:!RC4:3DES';\n"},
+			},
+			args: []string{"--audit", "--fail-on-weak"},
+			expected: `+-----------------+---------------+-----------+----------------+--------------+
+| Host            | Source        | Protocols | Cipher suite   | Findings     |
++-----------------+---------------+-----------+----------------+--------------+
+| www.example.com | configuration | TLSv1     | HIGH:!RC4:3DES | allows TLSv1 |
+|                 |               | TLSv1.2   |                | allows 3DES  |
++-----------------+---------------+-----------+----------------+--------------+
+`,
+			expectedError: "found weak TLS configurations on 1 of 1 host(s)",
+		},
+		{
+			name:      "with an unreachable host and no TLS configuration",
+			addresses: []types.InstanceAddress{{Type: types.InstanceAddressTypeClusterExternal, IP: "127.0.0.1"}},
+			args:      []string{"--audit"},
+			expected: `+-----------------+---------------+----------------+--------------+----------+
+| Host            | Source        | Protocols      | Cipher suite | Findings |
++-----------------+---------------+----------------+--------------+----------+
+| www.example.com | configuration | NGINX defaults |              |          |
++-----------------+---------------+----------------+--------------+----------+
+`,
+		},
+		{
+			name:          "fail on weak without audit",
+			args:          []string{"--fail-on-weak"},
+			expectedError: "--fail-on-weak can only be used along with --audit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addresses := tt.addresses
+			if tt.config != nil {
+				host := newServer(t, tt.config)
+				addresses = []types.InstanceAddress{{Type: types.InstanceAddressTypeClusterExternal, IP: host}}
+			} else if addresses != nil && addresses[0].Type == types.InstanceAddressTypeClusterExternal {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+				_, port, _ := net.SplitHostPort(listener.Addr().String())
+				listener.Close()
+
+				previous := tlsAuditPort
+				tlsAuditPort = port
+				t.Cleanup(func() { tlsAuditPort = previous })
+			}
+
+			client := &fake.FakeClient{
+				FakeListCertificates: func(args rpaasclient.ListCertificatesArgs) ([]types.Certificate, error) {
+					return certs, nil
+				},
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					assert.Equal(t, "my-instance", args.Instance)
+					return &types.InstanceInfo{Addresses: addresses}, nil
+				},
+				FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]types.Block, error) {
+					return tt.blocks, nil
+				},
+			}
+
+			args := []string{"./rpaasv2", "certificates", "list", "-i", "my-instance"}
+			stdout := &bytes.Buffer{}
+			err := NewApp(stdout, &bytes.Buffer{}, client).Run(append(args, tt.args...))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const ocspStatusUnavailable = "unavailable"
+
+// ocspTimeout bounds each of the requests made to fetch the issuer and to
+// query its OCSP responder.
+var ocspTimeout = 5 * time.Second
+
+// ocspStatus is the revocation status of a certificate according to the OCSP
+// responder of its issuer.
+type ocspStatus struct {
+	Status     string     `json:"status"`
+	NextUpdate *time.Time `json:"nextUpdate,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// checkOCSPStatus queries the OCSP responder (found on the AIA extension) of
+// the leaf certificate in the PEM chain. Failures, e.g. an unreachable
+// responder, are reported on the returned status instead.
+func checkOCSPStatus(ctx context.Context, data string) *ocspStatus {
+	leaf, issuer, err := leafAndIssuer(ctx, []byte(data))
+	if err != nil {
+		return &ocspStatus{Status: ocspStatusUnavailable, Error: err.Error()}
+	}
+
+	if len(leaf.OCSPServer) == 0 {
+		return &ocspStatus{Status: ocspStatusUnavailable, Error: "certificate has no OCSP responder"}
+	}
+
+	req, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return &ocspStatus{Status: ocspStatusUnavailable, Error: err.Error()}
+	}
+
+	body, err := ocspHTTPRequest(ctx, http.MethodPost, leaf.OCSPServer[0], req)
+	if err != nil {
+		return &ocspStatus{Status: ocspStatusUnavailable, Error: err.Error()}
+	}
+
+	rsp, err := ocsp.ParseResponseForCert(body, leaf, issuer)
+	if err != nil {
+		return &ocspStatus{Status: ocspStatusUnavailable, Error: fmt.Sprintf("invalid OCSP response: %s", err)}
+	}
+
+	status := &ocspStatus{Status: "unknown"}
+	switch rsp.Status {
+	case ocsp.Good:
+		status.Status = "good"
+	case ocsp.Revoked:
+		status.Status = "revoked"
+	}
+
+	if !rsp.NextUpdate.IsZero() {
+		status.NextUpdate = &rsp.NextUpdate
+	}
+
+	return status
+}
+
+// leafAndIssuer returns the leaf certificate of the PEM chain along with its
+// issuer, which is downloaded from the AIA extension when the chain lacks it.
+func leafAndIssuer(ctx context.Context, data []byte) (*x509.Certificate, *x509.Certificate, error) {
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		chain = append(chain, cert)
+	}
+
+	if len(chain) == 0 {
+		return nil, nil, errors.New("no certificate found")
+	}
+
+	leaf := chain[0]
+	for _, cert := range chain[1:] {
+		if leaf.CheckSignatureFrom(cert) == nil {
+			return leaf, cert, nil
+		}
+	}
+
+	if len(leaf.IssuingCertificateURL) == 0 {
+		return nil, nil, errors.New("issuer certificate not found in the chain nor in the AIA extension")
+	}
+
+	raw, err := ocspHTTPRequest(ctx, http.MethodGet, leaf.IssuingCertificateURL[0], nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not fetch the issuer certificate: %w", err)
+	}
+
+	if block, _ := pem.Decode(raw); block != nil {
+		raw = block.Bytes
+	}
+
+	issuer, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not parse the issuer certificate: %w", err)
+	}
+
+	return leaf, issuer, nil
+}
+
+func ocspHTTPRequest(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, ocspTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/ocsp-request")
+	}
+
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code from %s: %s", url, rsp.Status)
+	}
+
+	return io.ReadAll(rsp.Body)
+}
+
+func formatOCSPStatus(c certificateMetadata) string {
+	switch {
+	case !c.OCSPStapling:
+		return "disabled"
+
+	case c.OCSP == nil:
+		return "enabled"
+
+	case c.OCSP.Error != "":
+		return fmt.Sprintf("%s: %s", c.OCSP.Status, c.OCSP.Error)
+
+	case c.OCSP.NextUpdate != nil:
+		return fmt.Sprintf("%s (next update: %s)", c.OCSP.Status, formatTime(*c.OCSP.NextUpdate))
+	}
+
+	return c.OCSP.Status
+}
+
+func hasOCSPStapling(certs []certificateMetadata) bool {
+	for _, c := range certs {
+		if c.OCSPStapling {
+			return true
+		}
+	}
+
+	return false
+}
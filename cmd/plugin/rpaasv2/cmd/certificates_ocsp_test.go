@@ -0,0 +1,161 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestListCertificatesWithOCSPStapling(t *testing.T) {
+	nextUpdate := time.Date(2023, time.March, 16, 12, 0, 0, 0, time.UTC)
+
+	var ocspStatusCode int
+	var issuerDER []byte
+	var issuer *x509.Certificate
+	var issuerKey crypto.Signer
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/issuer.der" {
+			w.Write(issuerDER)
+			return
+		}
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/ocsp-request", r.Header.Get("Content-Type"))
+		if ocspStatusCode != http.StatusOK {
+			w.WriteHeader(ocspStatusCode)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req, err := ocsp.ParseRequest(body)
+		require.NoError(t, err)
+
+		status := ocsp.Good
+		if req.SerialNumber.Int64() == 3 {
+			status = ocsp.Revoked
+		}
+
+		rsp, err := ocsp.CreateResponse(issuer, issuer, ocsp.Response{
+			Status:       status,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   nextUpdate.Add(-24 * time.Hour),
+			NextUpdate:   nextUpdate,
+			RevokedAt:    nextUpdate.Add(-48 * time.Hour),
+		}, issuerKey)
+		require.NoError(t, err)
+		w.Write(rsp)
+	}))
+	defer server.Close()
+
+	issuerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	issuerTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	issuerDER, err = x509.CreateCertificate(rand.Reader, issuerTemplate, issuerTemplate, issuerKey.Public(), issuerKey)
+	require.NoError(t, err)
+	issuer, err = x509.ParseCertificate(issuerDER)
+	require.NoError(t, err)
+
+	newLeaf := func(serial int64) string {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			DNSNames:              []string{"www.example.com"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			OCSPServer:            []string{server.URL + "/ocsp"},
+			IssuingCertificateURL: []string{server.URL + "/issuer.der"},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), issuerKey)
+		require.NoError(t, err)
+		return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	}
+
+	issuerPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: issuerDER}))
+	certs := []types.Certificate{
+		{Name: "chain", Certificate: newLeaf(2) + issuerPEM, OCSPStapling: true},
+		{Name: "leaf-only", Certificate: newLeaf(3), OCSPStapling: true},
+		{Name: "not-stapled", Certificate: newLeaf(4)},
+	}
+
+	run := func(t *testing.T, args ...string) string {
+		client := &fake.FakeClient{
+			FakeListCertificates: func(args rpaasclient.ListCertificatesArgs) ([]types.Certificate, error) {
+				return certs, nil
+			},
+		}
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, client).Run(append([]string{"./rpaasv2", "certificates", "list", "-i", "my-instance"}, args...))
+		require.NoError(t, err)
+		return stdout.String()
+	}
+
+	t.Run("with a reachable responder", func(t *testing.T) {
+		ocspStatusCode = http.StatusOK
+
+		var metadata []certificateMetadata
+		require.NoError(t, json.Unmarshal([]byte(run(t, "-o", "json")), &metadata))
+		require.Len(t, metadata, 3)
+		assert.Equal(t, &ocspStatus{Status: "good", NextUpdate: &nextUpdate}, metadata[0].OCSP)
+		assert.Equal(t, &ocspStatus{Status: "revoked", NextUpdate: &nextUpdate}, metadata[1].OCSP)
+		assert.Nil(t, metadata[2].OCSP)
+
+		table := run(t)
+		assert.Contains(t, table, "| OCSP stapling ")
+		assert.Contains(t, table, "| good (next update: 2023-03-16T12:00:00Z) ")
+		assert.Contains(t, table, "| revoked (next update: 2023-03-16T12:00:00Z) ")
+		assert.Contains(t, table, "| disabled ")
+	})
+
+	t.Run("with an unavailable responder", func(t *testing.T) {
+		ocspStatusCode = http.StatusServiceUnavailable
+
+		var metadata []certificateMetadata
+		require.NoError(t, json.Unmarshal([]byte(run(t, "-o", "json")), &metadata))
+		require.Len(t, metadata, 3)
+		assert.Equal(t, &ocspStatus{Status: "unavailable", Error: "unexpected status code from " + server.URL + "/ocsp: 503 Service Unavailable"}, metadata[0].OCSP)
+	})
+
+	t.Run("with an unreachable responder", func(t *testing.T) {
+		defer func(d time.Duration) { ocspTimeout = d }(ocspTimeout)
+		ocspTimeout = time.Nanosecond
+
+		status := checkOCSPStatus(context.Background(), certs[0].Certificate)
+		assert.Equal(t, "unavailable", status.Status)
+		assert.Contains(t, status.Error, "deadline exceeded")
+	})
+}
@@ -0,0 +1,315 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func NewCmdDescribe() *cli.Command {
+	return &cli.Command{
+		Name:  "describe",
+		Usage: "Shows the full state of an instance (info, autoscale, blocks, routes, certificates, ACLs and events)",
+		Description: `Fetches every piece of an instance's state at once, which is handy to be
+attached to support tickets. A failure fetching one of the sections is reported
+in that section, while the others are shown as usual.`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "the output format (one of: json)",
+			},
+		}, outputFieldFlags()...),
+		Before: setupClient,
+		Action: runDescribe,
+	}
+}
+
+// instanceDescription bundles every section of an instance's state. Sections
+// which could not be fetched are left empty and their errors are kept in
+// Errors, by section name.
+type instanceDescription struct {
+	Info         *clientTypes.InstanceInfo     `json:"info,omitempty"`
+	Autoscale    *autogenerated.Autoscale      `json:"autoscale,omitempty"`
+	Blocks       []clientTypes.Block           `json:"blocks"`
+	Routes       []clientTypes.Route           `json:"routes"`
+	Certificates []certificateMetadata         `json:"certificates"`
+	ACLs         []clientTypes.AllowedUpstream `json:"acls"`
+	Events       []clientTypes.Event           `json:"events"`
+	Errors       map[string]string             `json:"errors,omitempty"`
+}
+
+func runDescribe(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	output := c.String("output")
+	if output != "" && output != "json" {
+		return fmt.Errorf("unsupported output format %q (one of: json)", output)
+	}
+
+	if err := checkOutputField(c, output); err != nil {
+		return err
+	}
+
+	d, err := describeInstance(c, client, c.String("service"), c.String("instance"))
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		return writeJSONOutput(c, d)
+	}
+
+	writeInstanceDescription(c.App.Writer, formatInstanceName(c), d)
+	return nil
+}
+
+func describeInstance(c *cli.Context, client rpaasclient.Client, service, instance string) (*instanceDescription, error) {
+	// NOTE: the API client may set the credentials on c.Context, so it must be
+	// built before the context is shared among the fetchers below.
+	apiClient, err := newAutogeneratedClientFor(c, service, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := c.Context
+	d := &instanceDescription{
+		Blocks:       []clientTypes.Block{},
+		Routes:       []clientTypes.Route{},
+		Certificates: []certificateMetadata{},
+		ACLs:         []clientTypes.AllowedUpstream{},
+		Events:       []clientTypes.Event{},
+	}
+
+	var mu sync.Mutex
+	setError := func(section string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if d.Errors == nil {
+			d.Errors = make(map[string]string)
+		}
+
+		d.Errors[section] = err.Error()
+	}
+
+	fetchers := []func(){
+		func() {
+			info, err := client.Info(ctx, rpaasclient.InfoArgs{Instance: instance})
+			if err != nil {
+				// NOTE: the recent events are part of the info.
+				setError("info", err)
+				setError("events", err)
+				return
+			}
+
+			d.Info = info
+			if info.Events != nil {
+				d.Events = info.Events
+			}
+		},
+		func() {
+			autoscale, _, err := apiClient.RpaasApi.GetAutoscale(ctx, instance).Execute()
+			if err != nil {
+				setError("autoscale", fmt.Errorf("could not get autoscale from RPaaS API: %w", err))
+				return
+			}
+
+			d.Autoscale = autoscale
+		},
+		func() {
+			blocks, err := client.ListBlocks(ctx, rpaasclient.ListBlocksArgs{Instance: instance})
+			if err != nil {
+				setError("blocks", err)
+				return
+			}
+
+			if blocks != nil {
+				d.Blocks = blocks
+			}
+		},
+		func() {
+			routes, err := client.ListRoutes(ctx, rpaasclient.ListRoutesArgs{Instance: instance})
+			if err != nil {
+				setError("routes", err)
+				return
+			}
+
+			if routes != nil {
+				d.Routes = routes
+			}
+		},
+		func() {
+			certs, err := client.ListCertificates(ctx, rpaasclient.ListCertificatesArgs{Instance: instance})
+			if err != nil {
+				setError("certificates", err)
+				return
+			}
+
+			d.Certificates = certificatesMetadata(certs)
+		},
+		func() {
+			acls, err := client.ListAccessControlList(ctx, instance)
+			if err != nil {
+				setError("acls", err)
+				return
+			}
+
+			if acls != nil {
+				d.ACLs = acls
+			}
+		},
+	}
+
+	var wg sync.WaitGroup
+	for _, fetch := range fetchers {
+		wg.Add(1)
+		go func(fetch func()) {
+			defer wg.Done()
+			fetch()
+		}(fetch)
+	}
+
+	wg.Wait()
+
+	return d, nil
+}
+
+func writeInstanceDescription(w io.Writer, instance string, d *instanceDescription) {
+	fmt.Fprintf(w, "Instance: %s\n", instance)
+
+	writeDescriptionSection(w, "Info", d.Errors["info"], func() string {
+		var b strings.Builder
+		fmt.Fprintf(&b, "Name: %s\n", d.Info.Name)
+		fmt.Fprintf(&b, "Team owner: %s\n", d.Info.Team)
+		fmt.Fprintf(&b, "Plan: %s\n", d.Info.Plan)
+		fmt.Fprintf(&b, "Flavors: %s\n", strings.Join(d.Info.Flavors, ", "))
+
+		summary := rpaasclient.NewInstanceInfo(d.Info, timeNow())
+		fmt.Fprintf(&b, "Pods: (current: %d / desired: %d)\n", len(d.Info.Pods), summary.Replicas.Desired)
+		b.WriteString(writePodsOnTableFormat(d.Info.Pods))
+		return b.String()
+	})
+
+	writeDescriptionSection(w, "Autoscale", d.Errors["autoscale"], func() string {
+		if d.Autoscale == nil {
+			return ""
+		}
+
+		return writeAutoscaleOnTableFormat(d.Autoscale)
+	})
+
+	writeDescriptionSection(w, "Blocks", d.Errors["blocks"], func() string {
+		if len(d.Blocks) == 0 {
+			return ""
+		}
+
+		return writeInfoBlocksOnTableFormat(d.Blocks)
+	})
+
+	writeDescriptionSection(w, "Routes", d.Errors["routes"], func() string {
+		if len(d.Routes) == 0 {
+			return ""
+		}
+
+		return writeInfoRoutesOnTableFormat(d.Routes)
+	})
+
+	writeDescriptionSection(w, "Certificates", d.Errors["certificates"], func() string {
+		if len(d.Certificates) == 0 {
+			return ""
+		}
+
+		return writeCertificatesMetadataOnTableFormat(d.Certificates)
+	})
+
+	writeDescriptionSection(w, "ACLs", d.Errors["acls"], func() string {
+		return writeAccessControlListOnTableFormat(d.ACLs)
+	})
+
+	writeDescriptionSection(w, "Events", d.Errors["events"], func() string {
+		if len(d.Events) == 0 {
+			return ""
+		}
+
+		return writeEventsOnTableFormat(d.Events)
+	})
+}
+
+func writeDescriptionSection(w io.Writer, title, err string, render func() string) {
+	fmt.Fprintf(w, "\n%s:\n", title)
+
+	if err != "" {
+		fmt.Fprintf(w, "  could not be fetched: %s\n", err)
+		return
+	}
+
+	text := render()
+	if text == "" {
+		fmt.Fprintln(w, "  none")
+		return
+	}
+
+	fmt.Fprint(w, text)
+}
+
+func writeCertificatesMetadataOnTableFormat(certs []certificateMetadata) string {
+	var data [][]string
+	for _, c := range certs {
+		var notAfter string
+		if c.NotAfter != nil {
+			notAfter = formatTime(*c.NotAfter)
+		}
+
+		row := []string{c.Name, c.KeyType, strings.Join(c.DNSNames, "\n"), notAfter, c.Fingerprint}
+		if hasOCSPStapling(certs) {
+			row = append(row, formatOCSPStatus(c))
+		}
+
+		data = append(data, row)
+	}
+
+	header := []string{"Name", "Key type", "DNS names", "Not after", "SHA256 fingerprint"}
+	if hasOCSPStapling(certs) {
+		header = append(header, "OCSP stapling")
+	}
+
+	var buffer bytes.Buffer
+	table := tablewriter.NewWriter(&buffer)
+	table.SetHeader(header)
+	table.SetRowLine(true)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.AppendBulk(data)
+	table.Render()
+
+	return buffer.String()
+}
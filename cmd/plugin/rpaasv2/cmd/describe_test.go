@@ -0,0 +1,212 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestDescribe(t *testing.T) {
+	autoscaleServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/resources/my-instance/autoscale":
+			json.NewEncoder(w).Encode(autogenerated.Autoscale{MinReplicas: 2, MaxReplicas: 10, Cpu: autogenerated.PtrInt32(75)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer autoscaleServer.Close()
+
+	newFakeClient := func() *fake.FakeClient {
+		return &fake.FakeClient{
+			FakeInfo: func(args rpaasclient.InfoArgs) (*clientTypes.InstanceInfo, error) {
+				return &clientTypes.InstanceInfo{
+					Name:     args.Instance,
+					Team:     "my-team",
+					Plan:     "basic",
+					Flavors:  []string{"strawberry"},
+					Replicas: func(n int32) *int32 { return &n }(2),
+					Events: []clientTypes.Event{
+						{Type: "Normal", Reason: "Updated", Message: "instance updated", Count: 1, First: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC), Last: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)},
+					},
+				}, nil
+			},
+			FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+				return []clientTypes.Block{{Name: "http", Content: "# some http config"}}, nil
+			},
+			FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+				return []clientTypes.Route{{Path: "/", Destination: "app.tsuru.example.com"}}, nil
+			},
+			FakeListCertificates: func(args rpaasclient.ListCertificatesArgs) ([]clientTypes.Certificate, error) {
+				return []clientTypes.Certificate{{Name: "default", Certificate: "invalid certificate", Key: "*** private ***"}}, nil
+			},
+			FakeListAccessControlList: func(instance string) ([]clientTypes.AllowedUpstream, error) {
+				return []clientTypes.AllowedUpstream{{Host: "10.0.0.1", Port: 8080}}, nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		client        func() *fake.FakeClient
+		expected      string
+		expectedError string
+	}{
+		{
+			name:          "with unsupported output format",
+			args:          []string{"./rpaasv2", "describe", "-i", "my-instance", "-o", "yaml"},
+			client:        newFakeClient,
+			expectedError: `unsupported output format "yaml" (one of: json)`,
+		},
+		{
+			name: "when some sections cannot be fetched",
+			args: []string{"./rpaasv2", "describe", "-s", "my-service", "-i", "other-instance"},
+			client: func() *fake.FakeClient {
+				c := newFakeClient()
+				c.FakeInfo = func(args rpaasclient.InfoArgs) (*clientTypes.InstanceInfo, error) {
+					return nil, fmt.Errorf("some info error")
+				}
+				c.FakeListBlocks = func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+					return nil, fmt.Errorf("some blocks error")
+				}
+				c.FakeListRoutes = func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return nil, nil
+				}
+				return c
+			},
+			expected: `Instance: my-service/other-instance
+
+Info:
+  could not be fetched: some info error
+
+Autoscale:
+  could not be fetched: could not get autoscale from RPaaS API: 404 Not Found
+
+Blocks:
+  could not be fetched: some blocks error
+
+Routes:
+  none
+
+Certificates:
++---------+----------+-----------+-----------+--------------------+
+| Name    | Key type | DNS names | Not after | SHA256 fingerprint |
++---------+----------+-----------+-----------+--------------------+
+| default |          |           |           |                    |
++---------+----------+-----------+-----------+--------------------+
+
+ACLs:
++----------+------+
+| Host     | Port |
++----------+------+
+| 10.0.0.1 | 8080 |
++----------+------+
+
+Events:
+  could not be fetched: some info error
+`,
+		},
+		{
+			name: "on JSON format",
+			args: []string{"./rpaasv2", "describe", "-i", "my-instance", "-o", "json"},
+			client: func() *fake.FakeClient {
+				c := newFakeClient()
+				c.FakeListAccessControlList = func(instance string) ([]clientTypes.AllowedUpstream, error) {
+					return nil, fmt.Errorf("some ACL error")
+				}
+				return c
+			},
+			expected: `{
+	"info": {
+		"replicas": 2,
+		"plan": "basic",
+		"team": "my-team",
+		"name": "my-instance",
+		"flavors": [
+			"strawberry"
+		],
+		"events": [
+			{
+				"first": "2023-05-01T12:00:00Z",
+				"last": "2023-05-01T12:00:00Z",
+				"type": "Normal",
+				"reason": "Updated",
+				"message": "instance updated",
+				"count": 1
+			}
+		]
+	},
+	"autoscale": {
+		"cpu": 75,
+		"maxReplicas": 10,
+		"minReplicas": 2
+	},
+	"blocks": [
+		{
+			"block_name": "http",
+			"content": "# some http config"
+		}
+	],
+	"routes": [
+		{
+			"path": "/",
+			"destination": "app.tsuru.example.com"
+		}
+	],
+	"certificates": [
+		{
+			"name": "default"
+		}
+	],
+	"acls": [],
+	"events": [
+		{
+			"first": "2023-05-01T12:00:00Z",
+			"last": "2023-05-01T12:00:00Z",
+			"type": "Normal",
+			"reason": "Updated",
+			"message": "instance updated",
+			"count": 1
+		}
+	],
+	"errors": {
+		"acls": "some ACL error"
+	}
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			args := append([]string{tt.args[0], "--rpaas-url", autoscaleServer.URL}, tt.args[1:]...)
+			err := NewApp(stdout, &bytes.Buffer{}, tt.client()).Run(args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+var diffSections = []string{"blocks", "routes", "autoscale", "certificates"}
+
+func NewCmdDiff() *cli.Command {
+	return &cli.Command{
+		Name:  "diff",
+		Usage: "Compares the configuration of two instances",
+		Description: `
+Prints a unified diff of blocks, routes, autoscale and certificates (metadata
+only) between two instances, exiting with non-zero status when they differ.
+
+# Compare two instances of the same service:
+rpaasv2 diff -s my-service -i my-instance --other-instance my-new-instance
+
+# Compare only blocks and routes of instances on different services:
+rpaasv2 diff -s my-service -i my-instance --other-service other-service --other-instance my-instance --only blocks,routes
+`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "other-service",
+				Usage: "the Tsuru service name of the instance to compare with (defaults to --service)",
+			},
+			&cli.StringFlag{
+				Name:     "other-instance",
+				Aliases:  []string{"to"},
+				Usage:    "the reverse proxy instance name to compare with",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:  "only",
+				Usage: fmt.Sprintf("restricts the comparison to these sections (any of: %s)", strings.Join(diffSections, ", ")),
+			},
+		},
+		Before: setupClient,
+		Action: runDiff,
+	}
+}
+
+func runDiff(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	sections := diffSections
+	if c.IsSet("only") {
+		sections = nil
+		for _, s := range strings.Split(strings.Join(c.StringSlice("only"), ","), ",") {
+			s = strings.TrimSpace(s)
+			if !slices.Contains(diffSections, s) {
+				return fmt.Errorf("invalid section %q (any of: %s)", s, strings.Join(diffSections, ", "))
+			}
+
+			sections = append(sections, s)
+		}
+	}
+
+	service, otherService := c.String("service"), c.String("service")
+	if c.IsSet("other-service") {
+		otherService = c.String("other-service")
+	}
+
+	otherClient := client
+	if otherService != service {
+		if otherClient, err = client.SetService(otherService); err != nil {
+			return err
+		}
+	}
+
+	from, err := instanceConfigForDiff(c, client, service, c.String("instance"), sections)
+	if err != nil {
+		return err
+	}
+
+	to, err := instanceConfigForDiff(c, otherClient, otherService, c.String("other-instance"), sections)
+	if err != nil {
+		return err
+	}
+
+	fromName := formatServiceInstanceName(service, c.String("instance"))
+	toName := formatServiceInstanceName(otherService, c.String("other-instance"))
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	if err != nil {
+		return err
+	}
+
+	if diff == "" {
+		fmt.Fprintf(c.App.Writer, "No differences found between %s and %s.\n", fromName, toName)
+		return nil
+	}
+
+	fmt.Fprint(c.App.Writer, diff)
+	return fmt.Errorf("instances %s and %s differ", fromName, toName)
+}
+
+// certificateMetadata is the comparable part of a certificate, leaving the
+// private key out.
+type certificateMetadata struct {
+	Name         string     `json:"name"`
+	KeyType      string     `json:"keyType,omitempty"`
+	Fingerprint  string     `json:"sha256Fingerprint,omitempty"`
+	DNSNames     []string   `json:"dnsNames,omitempty"`
+	NotAfter     *time.Time `json:"notAfter,omitempty"`
+	OCSPStapling bool       `json:"ocspStapling,omitempty"`
+
+	// OCSP is only checked when listing the certificates.
+	OCSP *ocspStatus `json:"ocsp,omitempty"`
+}
+
+func instanceConfigForDiff(c *cli.Context, client rpaasclient.Client, service, instance string, sections []string) (string, error) {
+	var sb strings.Builder
+	for _, section := range sections {
+		var data interface{}
+		switch section {
+		case "blocks":
+			blocks, err := client.ListBlocks(c.Context, rpaasclient.ListBlocksArgs{Instance: instance})
+			if err != nil {
+				return "", err
+			}
+
+			blocks = append([]clientTypes.Block{}, blocks...)
+			sort.Slice(blocks, func(i, j int) bool { return blocks[i].Name < blocks[j].Name })
+			data = blocks
+
+		case "routes":
+			routes, err := client.ListRoutes(c.Context, rpaasclient.ListRoutesArgs{Instance: instance})
+			if err != nil {
+				return "", err
+			}
+
+			routes = append([]clientTypes.Route{}, routes...)
+			sort.Slice(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
+			data = routes
+
+		case "autoscale":
+			apiClient, err := newAutogeneratedClientFor(c, service, instance)
+			if err != nil {
+				return "", err
+			}
+
+			autoscale, _, err := apiClient.RpaasApi.GetAutoscale(c.Context, instance).Execute()
+			if err != nil {
+				return "", fmt.Errorf("could not get autoscale from RPaaS API: %w", err)
+			}
+
+			data = autoscale
+
+		case "certificates":
+			certs, err := client.ListCertificates(c.Context, rpaasclient.ListCertificatesArgs{Instance: instance})
+			if err != nil {
+				return "", err
+			}
+
+			data = certificatesMetadata(certs)
+		}
+
+		content, err := yaml.Marshal(data)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&sb, "# %s\n%s\n", section, content)
+	}
+
+	return sb.String(), nil
+}
+
+func certificatesMetadata(certs []clientTypes.Certificate) []certificateMetadata {
+	metadata := []certificateMetadata{}
+	for _, cert := range certs {
+		m := certificateMetadata{Name: cert.Name, OCSPStapling: cert.OCSPStapling}
+		if block, _ := pem.Decode([]byte(cert.Certificate)); block != nil {
+			sum := sha256.Sum256(block.Bytes)
+			m.Fingerprint = hex.EncodeToString(sum[:])
+
+			if x509Cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+				m.KeyType = publicKeyType(x509Cert)
+				m.DNSNames = x509Cert.DNSNames
+				m.NotAfter = &x509Cert.NotAfter
+			}
+		}
+
+		metadata = append(metadata, m)
+	}
+
+	sort.Slice(metadata, func(i, j int) bool { return metadata[i].Name < metadata[j].Name })
+	return metadata
+}
+
+func formatServiceInstanceName(service, instance string) string {
+	if service == "" {
+		return instance
+	}
+
+	return fmt.Sprintf("%s/%s", service, instance)
+}
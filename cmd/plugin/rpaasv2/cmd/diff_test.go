@@ -0,0 +1,139 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestDiff(t *testing.T) {
+	autoscaleServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/resources/instance1/autoscale":
+			json.NewEncoder(w).Encode(autogenerated.Autoscale{MinReplicas: 2, MaxReplicas: 10, Cpu: autogenerated.PtrInt32(75)})
+		case "/resources/instance2/autoscale":
+			json.NewEncoder(w).Encode(autogenerated.Autoscale{MinReplicas: 2, MaxReplicas: 20, Cpu: autogenerated.PtrInt32(75)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer autoscaleServer.Close()
+
+	blocks := map[string][]clientTypes.Block{
+		"instance1": {{Name: "server", Content: "# some server config"}, {Name: "http", Content: "# some http config"}},
+		"instance2": {{Name: "http", Content: "# some http config"}, {Name: "server", Content: "# other server config"}},
+	}
+
+	routes := map[string][]clientTypes.Route{
+		"instance1": {{Path: "/", Destination: "app.tsuru.example.com"}},
+		"instance2": {{Path: "/", Destination: "app.tsuru.example.com"}},
+	}
+
+	newFakeClient := func() *fake.FakeClient {
+		return &fake.FakeClient{
+			FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+				return blocks[args.Instance], nil
+			},
+			FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+				return routes[args.Instance], nil
+			},
+			FakeListCertificates: func(args rpaasclient.ListCertificatesArgs) ([]clientTypes.Certificate, error) {
+				return []clientTypes.Certificate{{Name: "default", Certificate: "invalid certificate", Key: "*** private ***"}}, nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        *fake.FakeClient
+	}{
+		{
+			name:          "with an invalid section",
+			args:          []string{"./rpaasv2", "diff", "-i", "instance1", "--to", "instance2", "--only", "blocks,plans"},
+			expectedError: `invalid section "plans" (any of: blocks, routes, autoscale, certificates)`,
+			client:        newFakeClient(),
+		},
+		{
+			name:     "when instances are the same",
+			args:     []string{"./rpaasv2", "diff", "-s", "rpaasv2", "-i", "instance1", "--to", "instance1"},
+			client:   newFakeClient(),
+			expected: "No differences found between rpaasv2/instance1 and rpaasv2/instance1.\n",
+		},
+		{
+			name:          "when instances differ",
+			args:          []string{"./rpaasv2", "diff", "-s", "rpaasv2", "-i", "instance1", "--to", "instance2"},
+			client:        newFakeClient(),
+			expectedError: "instances rpaasv2/instance1 and rpaasv2/instance2 differ",
+			expected: `--- rpaasv2/instance1
++++ rpaasv2/instance2
+@@ -2,7 +2,7 @@
+ - block_name: http
+   content: '# some http config'
+ - block_name: server
+-  content: '# some server config'
++  content: '# other server config'
+ 
+ # routes
+ - destination: app.tsuru.example.com
+@@ -10,7 +10,7 @@
+ 
+ # autoscale
+ cpu: 75
+-maxReplicas: 10
++maxReplicas: 20
+ minReplicas: 2
+ 
+ # certificates
+`,
+		},
+		{
+			name:     "comparing only some sections of instances on different services",
+			args:     []string{"./rpaasv2", "diff", "-s", "rpaasv2", "-i", "instance1", "--other-service", "rpaasv2-other", "--to", "instance2", "--only", "routes,certificates"},
+			client:   newFakeClient(),
+			expected: "No differences found between rpaasv2/instance1 and rpaasv2-other/instance2.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var services []string
+			tt.client.FakeSetService = func(service string) error {
+				services = append(services, service)
+				return nil
+			}
+
+			stdout := &bytes.Buffer{}
+			args := append([]string{tt.args[0], "--rpaas-url", autoscaleServer.URL}, tt.args[1:]...)
+			err := NewApp(stdout, &bytes.Buffer{}, tt.client).Run(args)
+			assert.Equal(t, tt.expected, stdout.String())
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			if strings.Contains(tt.name, "different services") {
+				assert.Equal(t, []string{"rpaasv2-other"}, services)
+			}
+		})
+	}
+}
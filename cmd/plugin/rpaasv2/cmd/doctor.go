@@ -0,0 +1,252 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/cmd/doctor"
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func NewCmdDoctor() *cli.Command {
+	return &cli.Command{
+		Name:  "doctor",
+		Usage: "Diagnoses common misconfigurations of an instance, suggesting how to fix them",
+		Description: `Runs read-only checks against the instance, looking for:
+  - HTTPS-only routes on instances without TLS certificates;
+  - expired certificates, or the ones expiring soon;
+  - autoscales whose min replicas is greater than their max replicas;
+  - blocks refused by NGINX;
+  - bound apps unreachable from the instance, or no bound app at all.
+
+Exits with error when any error-level issue is found. On "--output junit",
+writes a JUnit test suite where every check is a test case, failing on
+error-level issues, so that CI pipelines can report the instance health.`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			outputFlag("table", "json", "yaml", "csv", "jsonl", "junit"),
+		}, outputFieldFlags()...),
+		Before: setupClient,
+		Action: runDoctor,
+	}
+}
+
+func runDoctor(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	instance := c.String("instance")
+	info, err := client.Info(c.Context, rpaasclient.InfoArgs{Instance: instance})
+	if err != nil {
+		return err
+	}
+
+	binds, err := client.ListBinds(c.Context, rpaasclient.ListBindsArgs{Instance: instance})
+	if err != nil {
+		return err
+	}
+
+	validations, err := validateBlocks(c, client, instance, info.Blocks)
+	if err != nil {
+		return err
+	}
+
+	findings := doctor.Run(doctor.Instance{
+		Info:             info,
+		Binds:            binds,
+		BlockValidations: validations,
+		Now:              timeNow(),
+	})
+
+	if c.String("output") == "junit" {
+		err = writeDoctorOnJUnitFormat(c, findings, validations == nil && len(info.Blocks) > 0)
+	} else {
+		err = writeListOutput(c, c.String("output"), findings, doctorRecords(findings), func(w io.Writer) error {
+			if len(findings) == 0 {
+				fmt.Fprintf(w, "No issues found in %s\n", formatInstanceName(c))
+				return nil
+			}
+
+			writeDoctorOnTableFormat(w, findings)
+			return nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if n := doctor.CountErrors(findings); n > 0 {
+		return fmt.Errorf("found %d error(s) in %s", n, formatInstanceName(c))
+	}
+
+	return nil
+}
+
+// validateBlocks checks every block with "nginx -t" on a pod of the instance.
+// APIs lacking the block validation leave the blocks unchecked.
+func validateBlocks(c *cli.Context, client rpaasclient.Client, instance string, blocks []clientTypes.Block) (map[string]clientTypes.BlockValidation, error) {
+	validations := make(map[string]clientTypes.BlockValidation)
+	for _, b := range blocks {
+		result, err := client.ValidateBlock(c.Context, rpaasclient.ValidateBlockArgs{Instance: instance, Name: b.Name, Content: b.Content})
+		if errors.Is(err, rpaasclient.ErrValidateBlockUnsupported) {
+			fmt.Fprintln(c.App.ErrWriter, "WARNING: the API does not support validating blocks, skipping their checks")
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		validations[b.Name] = *result
+	}
+
+	return validations, nil
+}
+
+func doctorRecords(findings []doctor.Finding) records {
+	rec := records{Header: []string{"Severity", "Check", "Message", "Fix"}}
+	for _, f := range findings {
+		rec.Rows = append(rec.Rows, []string{string(f.Severity), f.Check, f.Message, f.Fix})
+	}
+
+	return rec
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Skipped   *junitMessage `xml:"skipped"`
+	Failure   *junitMessage `xml:"failure"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// writeDoctorOnJUnitFormat writes one test case per doctor check, in the
+// order they run. Error-level findings fail their check, while warnings are
+// only reported on its output. Times are always zero so that the report is
+// the same for the same findings.
+func writeDoctorOnJUnitFormat(c *cli.Context, findings []doctor.Finding, blocksSkipped bool) error {
+	if err := checkOutputField(c, "junit"); err != nil {
+		return err
+	}
+
+	suite := junitTestSuite{Name: formatInstanceName(c), Time: "0"}
+	for _, check := range doctor.Checks {
+		tc := junitTestCase{Name: check.Name, ClassName: "rpaasv2.doctor", Time: "0"}
+
+		var failures, output []string
+		for _, f := range findings {
+			if f.Check != check.Name {
+				continue
+			}
+
+			line := fmt.Sprintf("%s: %s", f.Severity, f.Message)
+			if f.Fix != "" {
+				line += fmt.Sprintf(" (fix: %s)", f.Fix)
+			}
+
+			if f.Severity == doctor.SeverityError {
+				failures = append(failures, f.Message)
+			}
+
+			output = append(output, line)
+		}
+
+		switch {
+		case len(failures) > 0:
+			tc.Failure = &junitMessage{Message: strings.Join(failures, "; "), Type: string(doctor.SeverityError), Text: strings.Join(output, "\n")}
+			suite.Failures++
+
+		case len(output) > 0:
+			tc.SystemOut = strings.Join(output, "\n")
+
+		case check.Name == "blocks" && blocksSkipped:
+			tc.Skipped = &junitMessage{Message: "the API does not support validating blocks"}
+			suite.Skipped++
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+	}
+
+	suites := junitTestSuites{
+		Name:     "rpaasv2 doctor",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     "0",
+		Suites:   []junitTestSuite{suite},
+	}
+
+	w := c.App.Writer
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func writeDoctorOnTableFormat(w io.Writer, findings []doctor.Finding) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Severity", "Check", "Message", "Fix"})
+	table.SetRowLine(true)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(true)
+	table.AppendBulk(doctorRecords(findings).Rows)
+	table.Render()
+}
@@ -0,0 +1,236 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package doctor diagnoses common misconfigurations of an instance out of its
+// current state, suggesting how to fix each of them.
+package doctor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// certificateExpirationWarning is how long before expiring a certificate is
+// reported.
+const certificateExpirationWarning = 15 * 24 * time.Hour
+
+// Finding is an issue found on an instance, along with how to fix it.
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Fix      string   `json:"fix,omitempty"`
+}
+
+// Instance is the state of an instance the checks run against.
+type Instance struct {
+	Info  *clientTypes.InstanceInfo
+	Binds []clientTypes.BindStatus
+
+	// BlockValidations holds the result of "nginx -t" for each block, by the
+	// block name. It's nil when the blocks weren't validated.
+	BlockValidations map[string]clientTypes.BlockValidation
+
+	// Now is the time the certificates expiration is checked against.
+	Now time.Time
+}
+
+// Check is a read-only check of an instance.
+type Check struct {
+	Name string
+	Run  func(instance Instance) []Finding
+}
+
+// Checks are the checks run by Run, in order.
+var Checks = []Check{
+	{Name: "routes", Run: CheckHTTPSOnlyRoutes},
+	{Name: "certificates", Run: CheckCertificates},
+	{Name: "autoscale", Run: CheckAutoscale},
+	{Name: "blocks", Run: CheckBlocks},
+	{Name: "binds", Run: CheckBinds},
+}
+
+// Run runs every check against instance, returning the findings of all of
+// them.
+func Run(instance Instance) []Finding {
+	findings := []Finding{}
+	for _, check := range Checks {
+		for _, f := range check.Run(instance) {
+			f.Check = check.Name
+			findings = append(findings, f)
+		}
+	}
+
+	return findings
+}
+
+// CountErrors returns the number of error-level findings.
+func CountErrors(findings []Finding) int {
+	var n int
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			n++
+		}
+	}
+
+	return n
+}
+
+// CheckHTTPSOnlyRoutes reports the HTTPS-only routes on instances without TLS
+// certificates, which cannot be reached at all.
+func CheckHTTPSOnlyRoutes(instance Instance) []Finding {
+	if instance.Info == nil || len(instance.Info.Certificates) > 0 {
+		return nil
+	}
+
+	var findings []Finding
+	for _, r := range instance.Info.Routes {
+		if !r.HTTPSOnly {
+			continue
+		}
+
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("route %q only accepts HTTPS, but there's no TLS certificate", r.Path),
+			Fix:      fmt.Sprintf("add a certificate with \"certificates update\", or accept HTTP with \"routes update --path %s --https-only=false\"", r.Path),
+		})
+	}
+
+	return findings
+}
+
+// CheckCertificates reports the certificates which are expired or about to
+// expire.
+func CheckCertificates(instance Instance) []Finding {
+	if instance.Info == nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, cert := range instance.Info.Certificates {
+		fix := fmt.Sprintf("renew it with \"certificates update --name %s\"", cert.Name)
+
+		switch {
+		case !instance.Now.Before(cert.ValidUntil):
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("certificate %q expired on %s", cert.Name, cert.ValidUntil.UTC().Format(time.RFC3339)),
+				Fix:      fix,
+			})
+
+		case cert.ValidUntil.Sub(instance.Now) < certificateExpirationWarning:
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("certificate %q expires on %s", cert.Name, cert.ValidUntil.UTC().Format(time.RFC3339)),
+				Fix:      fix,
+			})
+		}
+	}
+
+	return findings
+}
+
+// CheckAutoscale reports autoscales whose minimum number of replicas exceeds
+// the maximum one (e.g. after the resource was edited by hand).
+func CheckAutoscale(instance Instance) []Finding {
+	if instance.Info == nil || instance.Info.Autoscale == nil {
+		return nil
+	}
+
+	a := instance.Info.Autoscale
+	if a.MinReplicas <= a.MaxReplicas {
+		return nil
+	}
+
+	return []Finding{{
+		Severity: SeverityError,
+		Message:  fmt.Sprintf("autoscale min replicas (%d) is greater than its max replicas (%d)", a.MinReplicas, a.MaxReplicas),
+		Fix:      "set consistent limits with \"autoscale update --min <min> --max <max>\"",
+	}}
+}
+
+// CheckBlocks reports the blocks which NGINX refuses.
+func CheckBlocks(instance Instance) []Finding {
+	var names []string
+	for name := range instance.BlockValidations {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var findings []Finding
+	for _, name := range names {
+		v := instance.BlockValidations[name]
+		if v.Valid {
+			continue
+		}
+
+		message := fmt.Sprintf("block %q fails validation", name)
+		if output := strings.TrimSpace(v.Output); output != "" {
+			message = fmt.Sprintf("%s: %s", message, output)
+		}
+
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Message:  message,
+			Fix:      fmt.Sprintf("fix the block, checking it with \"blocks update --name %s --validate-remote\"", name),
+		})
+	}
+
+	return findings
+}
+
+// CheckBinds reports the bound apps unreachable from the instance, as well as
+// instances without any bound app nor route for "/", which reply every
+// request out of the routes with 404.
+func CheckBinds(instance Instance) []Finding {
+	var findings []Finding
+	if instance.Info != nil && len(instance.Info.Binds) == 0 && !hasRootRoute(instance.Info.Routes) {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  "no app is bound and there's no route for \"/\", so the requests out of the routes get 404 \"instance not bound\"",
+			Fix:      "bind an app with \"tsuru service instance bind\", or add a route with \"routes update --path /\"",
+		})
+	}
+
+	for _, b := range instance.Binds {
+		if b.Healthy {
+			continue
+		}
+
+		message := fmt.Sprintf("bound app %q (%s) is unreachable from the instance", b.App, b.Address)
+		if b.Error != "" {
+			message = fmt.Sprintf("%s: %s", message, b.Error)
+		}
+
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Message:  message,
+			Fix:      "check whether the app is running and its address is reachable from the instance's network",
+		})
+	}
+
+	return findings
+}
+
+func hasRootRoute(routes []clientTypes.Route) bool {
+	for _, r := range routes {
+		if r.Path == "/" {
+			return true
+		}
+	}
+
+	return false
+}
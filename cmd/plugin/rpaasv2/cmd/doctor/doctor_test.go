@@ -0,0 +1,188 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package doctor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tsuru/rpaas-operator/api/v1alpha1"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+var now = time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+func TestCheckHTTPSOnlyRoutes(t *testing.T) {
+	routes := []clientTypes.Route{
+		{Path: "/", Destination: "app.tsuru.example.com"},
+		{Path: "/admin", Destination: "admin.tsuru.example.com", HTTPSOnly: true},
+	}
+
+	t.Run("without certificates", func(t *testing.T) {
+		findings := CheckHTTPSOnlyRoutes(Instance{Info: &clientTypes.InstanceInfo{Routes: routes}})
+		assert.Equal(t, []Finding{{
+			Severity: SeverityError,
+			Message:  `route "/admin" only accepts HTTPS, but there's no TLS certificate`,
+			Fix:      `add a certificate with "certificates update", or accept HTTP with "routes update --path /admin --https-only=false"`,
+		}}, findings)
+	})
+
+	t.Run("with certificates", func(t *testing.T) {
+		findings := CheckHTTPSOnlyRoutes(Instance{Info: &clientTypes.InstanceInfo{
+			Routes:       routes,
+			Certificates: []clientTypes.CertificateInfo{{Name: "default", ValidUntil: now.AddDate(1, 0, 0)}},
+		}})
+		assert.Empty(t, findings)
+	})
+}
+
+func TestCheckCertificates(t *testing.T) {
+	findings := CheckCertificates(Instance{
+		Now: now,
+		Info: &clientTypes.InstanceInfo{
+			Certificates: []clientTypes.CertificateInfo{
+				{Name: "default", ValidUntil: now.AddDate(1, 0, 0)},
+				{Name: "expiring", ValidUntil: now.AddDate(0, 0, 7)},
+				{Name: "expired", ValidUntil: now.AddDate(0, 0, -1)},
+			},
+		},
+	})
+	assert.Equal(t, []Finding{
+		{
+			Severity: SeverityWarning,
+			Message:  `certificate "expiring" expires on 2023-03-22T12:00:00Z`,
+			Fix:      `renew it with "certificates update --name expiring"`,
+		},
+		{
+			Severity: SeverityError,
+			Message:  `certificate "expired" expired on 2023-03-14T12:00:00Z`,
+			Fix:      `renew it with "certificates update --name expired"`,
+		},
+	}, findings)
+}
+
+func TestCheckAutoscale(t *testing.T) {
+	tests := []struct {
+		name      string
+		autoscale *autogenerated.Autoscale
+		expected  []Finding
+	}{
+		{
+			name: "without autoscale",
+		},
+		{
+			name:      "with consistent limits",
+			autoscale: &autogenerated.Autoscale{MinReplicas: 3, MaxReplicas: 3},
+		},
+		{
+			name:      "with min replicas greater than max replicas",
+			autoscale: &autogenerated.Autoscale{MinReplicas: 10, MaxReplicas: 3},
+			expected: []Finding{{
+				Severity: SeverityError,
+				Message:  "autoscale min replicas (10) is greater than its max replicas (3)",
+				Fix:      `set consistent limits with "autoscale update --min <min> --max <max>"`,
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CheckAutoscale(Instance{Info: &clientTypes.InstanceInfo{Autoscale: tt.autoscale}}))
+		})
+	}
+}
+
+func TestCheckBlocks(t *testing.T) {
+	findings := CheckBlocks(Instance{
+		BlockValidations: map[string]clientTypes.BlockValidation{
+			"server": {Valid: false, Output: "nginx: [emerg] unknown directive \"foo\"\n"},
+			"http":   {Valid: true},
+			"root":   {Valid: false},
+		},
+	})
+	assert.Equal(t, []Finding{
+		{
+			Severity: SeverityError,
+			Message:  `block "root" fails validation`,
+			Fix:      `fix the block, checking it with "blocks update --name root --validate-remote"`,
+		},
+		{
+			Severity: SeverityError,
+			Message:  `block "server" fails validation: nginx: [emerg] unknown directive "foo"`,
+			Fix:      `fix the block, checking it with "blocks update --name server --validate-remote"`,
+		},
+	}, findings)
+
+	assert.Empty(t, CheckBlocks(Instance{}))
+}
+
+func TestCheckBinds(t *testing.T) {
+	tests := []struct {
+		name     string
+		instance Instance
+		expected []Finding
+	}{
+		{
+			name: "with a healthy bound app",
+			instance: Instance{
+				Info:  &clientTypes.InstanceInfo{Binds: []v1alpha1.Bind{{Name: "app", Host: "app.tsuru.example.com"}}},
+				Binds: []clientTypes.BindStatus{{App: "app", Address: "app.tsuru.example.com", Healthy: true}},
+			},
+		},
+		{
+			name: "with an unhealthy bound app",
+			instance: Instance{
+				Info:  &clientTypes.InstanceInfo{Binds: []v1alpha1.Bind{{Name: "app", Host: "app.tsuru.example.com"}}},
+				Binds: []clientTypes.BindStatus{{App: "app", Address: "app.tsuru.example.com", Error: "connection refused"}},
+			},
+			expected: []Finding{{
+				Severity: SeverityError,
+				Message:  `bound app "app" (app.tsuru.example.com) is unreachable from the instance: connection refused`,
+				Fix:      "check whether the app is running and its address is reachable from the instance's network",
+			}},
+		},
+		{
+			name:     "without bound apps",
+			instance: Instance{Info: &clientTypes.InstanceInfo{Routes: []clientTypes.Route{{Path: "/api", Destination: "api.tsuru.example.com"}}}},
+			expected: []Finding{{
+				Severity: SeverityWarning,
+				Message:  `no app is bound and there's no route for "/", so the requests out of the routes get 404 "instance not bound"`,
+				Fix:      `bind an app with "tsuru service instance bind", or add a route with "routes update --path /"`,
+			}},
+		},
+		{
+			name:     "without bound apps but with a route for /",
+			instance: Instance{Info: &clientTypes.InstanceInfo{Routes: []clientTypes.Route{{Path: "/", Destination: "app.tsuru.example.com"}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, CheckBinds(tt.instance))
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	findings := Run(Instance{
+		Now: now,
+		Info: &clientTypes.InstanceInfo{
+			Routes:    []clientTypes.Route{{Path: "/", Destination: "app.tsuru.example.com", HTTPSOnly: true}},
+			Autoscale: &autogenerated.Autoscale{MinReplicas: 5, MaxReplicas: 2},
+		},
+	})
+
+	var checks []string
+	for _, f := range findings {
+		checks = append(checks, f.Check)
+	}
+
+	assert.Equal(t, []string{"routes", "autoscale"}, checks)
+	assert.Equal(t, 2, CountErrors(findings))
+	assert.Equal(t, []Finding{}, Run(Instance{Info: &clientTypes.InstanceInfo{Routes: []clientTypes.Route{{Path: "/"}}}}))
+}
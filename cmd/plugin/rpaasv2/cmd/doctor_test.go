@@ -0,0 +1,201 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/api/v1alpha1"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestDoctor(t *testing.T) {
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC) }
+
+	healthy := &types.InstanceInfo{
+		Routes:       []types.Route{{Path: "/admin", Destination: "admin.tsuru.example.com", HTTPSOnly: true}},
+		Blocks:       []types.Block{{Name: "server", Content: "listen 8080;"}},
+		Binds:        []v1alpha1.Bind{{Name: "app", Host: "app.tsuru.example.com"}},
+		Certificates: []types.CertificateInfo{{Name: "default", ValidUntil: time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)}},
+		Autoscale:    &autogenerated.Autoscale{MinReplicas: 2, MaxReplicas: 10},
+	}
+
+	broken := &types.InstanceInfo{
+		Routes:    []types.Route{{Path: "/admin", Destination: "admin.tsuru.example.com", HTTPSOnly: true}},
+		Blocks:    []types.Block{{Name: "server", Content: "foo bar;"}},
+		Binds:     []v1alpha1.Bind{{Name: "app", Host: "app.tsuru.example.com"}},
+		Autoscale: &autogenerated.Autoscale{MinReplicas: 10, MaxReplicas: 2},
+	}
+
+	tests := []struct {
+		name                string
+		args                []string
+		info                *types.InstanceInfo
+		validateUnsupported bool
+		expected            string
+		expectedStderr      string
+		expectedError       string
+	}{
+		{
+			name:     "without issues",
+			args:     []string{"./rpaasv2", "doctor", "-s", "rpaasv2", "-i", "my-instance"},
+			info:     healthy,
+			expected: "No issues found in rpaasv2/my-instance\n",
+		},
+		{
+			name: "with issues",
+			args: []string{"./rpaasv2", "doctor", "-i", "my-instance"},
+			info: broken,
+			expected: `+----------+-----------+--------------------------------+--------------------------------+
+| Severity | Check     | Message                        | Fix                            |
++----------+-----------+--------------------------------+--------------------------------+
+| error    | routes    | route "/admin" only accepts    | add a certificate with         |
+|          |           | HTTPS, but there's no TLS      | "certificates update", or      |
+|          |           | certificate                    | accept HTTP with "routes       |
+|          |           |                                | update --path /admin           |
+|          |           |                                | --https-only=false"            |
++----------+-----------+--------------------------------+--------------------------------+
+| error    | autoscale | autoscale min replicas (10) is | set consistent limits with     |
+|          |           | greater than its max replicas  | "autoscale update --min <min>  |
+|          |           | (2)                            | --max <max>"                   |
++----------+-----------+--------------------------------+--------------------------------+
+| error    | blocks    | block "server" fails           | fix the block, checking it     |
+|          |           | validation: nginx: [emerg]     | with "blocks update --name     |
+|          |           | unknown directive "foo"        | server --validate-remote"      |
++----------+-----------+--------------------------------+--------------------------------+
+| error    | binds     | bound app "app"                | check whether the app is       |
+|          |           | (app.tsuru.example.com) is     | running and its address is     |
+|          |           | unreachable from the instance: | reachable from the instance's  |
+|          |           | connection refused             | network                        |
++----------+-----------+--------------------------------+--------------------------------+
+`,
+			expectedError: "found 4 error(s) in my-instance",
+		},
+		{
+			name:          "with issues on CSV format",
+			args:          []string{"./rpaasv2", "doctor", "-i", "my-instance", "-o", "csv"},
+			info:          &types.InstanceInfo{Binds: []v1alpha1.Bind{{Name: "app", Host: "app.tsuru.example.com"}}, Autoscale: &autogenerated.Autoscale{MinReplicas: 10, MaxReplicas: 2}},
+			expected:      "Severity,Check,Message,Fix\r\nerror,autoscale,autoscale min replicas (10) is greater than its max replicas (2),\"set consistent limits with \"\"autoscale update --min <min> --max <max>\"\"\"\r\n",
+			expectedError: "found 1 error(s) in my-instance",
+		},
+		{
+			name: "with issues on JUnit format",
+			args: []string{"./rpaasv2", "doctor", "-i", "my-instance", "-o", "junit"},
+			info: &types.InstanceInfo{
+				Blocks:       []types.Block{{Name: "server", Content: "foo bar;"}},
+				Binds:        []v1alpha1.Bind{{Name: "app", Host: "app.tsuru.example.com"}},
+				Certificates: []types.CertificateInfo{{Name: "default", ValidUntil: time.Date(2023, time.March, 20, 12, 0, 0, 0, time.UTC)}},
+			},
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="rpaasv2 doctor" tests="5" failures="1" errors="0" skipped="0" time="0">
+	<testsuite name="my-instance" tests="5" failures="1" errors="0" skipped="0" time="0">
+		<testcase name="routes" classname="rpaasv2.doctor" time="0"></testcase>
+		<testcase name="certificates" classname="rpaasv2.doctor" time="0">
+			<system-out>warning: certificate &#34;default&#34; expires on 2023-03-20T12:00:00Z (fix: renew it with &#34;certificates update --name default&#34;)</system-out>
+		</testcase>
+		<testcase name="autoscale" classname="rpaasv2.doctor" time="0"></testcase>
+		<testcase name="blocks" classname="rpaasv2.doctor" time="0">
+			<failure message="block &#34;server&#34; fails validation: nginx: [emerg] unknown directive &#34;foo&#34;" type="error">error: block &#34;server&#34; fails validation: nginx: [emerg] unknown directive &#34;foo&#34; (fix: fix the block, checking it with &#34;blocks update --name server --validate-remote&#34;)</failure>
+		</testcase>
+		<testcase name="binds" classname="rpaasv2.doctor" time="0"></testcase>
+	</testsuite>
+</testsuites>
+`,
+			expectedError: "found 1 error(s) in my-instance",
+		},
+		{
+			name:                "skipping blocks on JUnit format when the API does not support validating them",
+			args:                []string{"./rpaasv2", "doctor", "-s", "rpaasv2", "-i", "my-instance", "-o", "junit"},
+			info:                healthy,
+			validateUnsupported: true,
+			expected: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="rpaasv2 doctor" tests="5" failures="0" errors="0" skipped="1" time="0">
+	<testsuite name="rpaasv2/my-instance" tests="5" failures="0" errors="0" skipped="1" time="0">
+		<testcase name="routes" classname="rpaasv2.doctor" time="0"></testcase>
+		<testcase name="certificates" classname="rpaasv2.doctor" time="0"></testcase>
+		<testcase name="autoscale" classname="rpaasv2.doctor" time="0"></testcase>
+		<testcase name="blocks" classname="rpaasv2.doctor" time="0">
+			<skipped message="the API does not support validating blocks"></skipped>
+		</testcase>
+		<testcase name="binds" classname="rpaasv2.doctor" time="0"></testcase>
+	</testsuite>
+</testsuites>
+`,
+			expectedStderr: "WARNING: the API does not support validating blocks, skipping their checks\n",
+		},
+		{
+			name:          "JUnit format along with --field",
+			args:          []string{"./rpaasv2", "doctor", "-i", "my-instance", "-o", "junit", "--field", "message"},
+			info:          healthy,
+			expectedError: "--field and --allow-missing can only be used along with --output json",
+		},
+		{
+			name:                "when the API does not support validating blocks",
+			args:                []string{"./rpaasv2", "doctor", "-i", "my-instance", "-o", "json"},
+			info:                &types.InstanceInfo{Blocks: []types.Block{{Name: "server", Content: "foo bar;"}}},
+			validateUnsupported: true,
+			expected: `[
+	{
+		"check": "binds",
+		"severity": "warning",
+		"message": "no app is bound and there's no route for \"/\", so the requests out of the routes get 404 \"instance not bound\"",
+		"fix": "bind an app with \"tsuru service instance bind\", or add a route with \"routes update --path /\""
+	}
+]
+`,
+			expectedStderr: "WARNING: the API does not support validating blocks, skipping their checks\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+					assert.Equal(t, "my-instance", args.Instance)
+					return tt.info, nil
+				},
+				FakeListBinds: func(args client.ListBindsArgs) ([]types.BindStatus, error) {
+					assert.Equal(t, client.ListBindsArgs{Instance: "my-instance"}, args)
+					if tt.info == broken {
+						return []types.BindStatus{{App: "app", Address: "app.tsuru.example.com", Error: "connection refused"}}, nil
+					}
+
+					return []types.BindStatus{{App: "app", Address: "app.tsuru.example.com", Healthy: true}}, nil
+				},
+				FakeValidateBlock: func(args client.ValidateBlockArgs) (*types.BlockValidation, error) {
+					if tt.validateUnsupported {
+						return nil, client.ErrValidateBlockUnsupported
+					}
+
+					if args.Content == "foo bar;" {
+						return &types.BlockValidation{Pod: "my-instance-abc", Output: `nginx: [emerg] unknown directive "foo"`}, nil
+					}
+
+					return &types.BlockValidation{Pod: "my-instance-abc", Valid: true}, nil
+				},
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			err := NewApp(stdout, stderr, fakeClient).Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+		})
+	}
+}
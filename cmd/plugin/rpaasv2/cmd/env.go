@@ -0,0 +1,95 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+func expandEnvFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "expand-env",
+			Usage: "replaces the ${VAR} placeholders of the files with the environment variables, failing when any of them is unset (write $$ for a literal $, e.g. $${host} for the NGINX variable)",
+		},
+		&cli.BoolFlag{
+			Name:  "allow-empty-env",
+			Usage: "replaces the placeholders of unset environment variables with empty strings instead of failing (requires --expand-env)",
+		},
+	}
+}
+
+// expandEnvFromFlags expands the environment variables in content when
+// --expand-env is set.
+func expandEnvFromFlags(c *cli.Context, content []byte) ([]byte, error) {
+	if !c.Bool("expand-env") {
+		if c.Bool("allow-empty-env") {
+			return nil, fmt.Errorf("--allow-empty-env can only be used along with --expand-env")
+		}
+
+		return content, nil
+	}
+
+	return expandEnv(content, os.LookupEnv, c.Bool("allow-empty-env"))
+}
+
+// expandEnv replaces every ${VAR} in content with the value of VAR, as well as
+// every $$ with a literal $. Any other $ is kept as is, since NGINX uses them
+// for its own variables (e.g. $host). Unset variables are an error unless
+// allowEmpty is set, which replaces them with empty strings.
+func expandEnv(content []byte, lookup func(string) (string, bool), allowEmpty bool) ([]byte, error) {
+	var out bytes.Buffer
+	var missing []string
+	for i := 0; i < len(content); i++ {
+		if content[i] != '$' || i+1 == len(content) {
+			out.WriteByte(content[i])
+			continue
+		}
+
+		switch content[i+1] {
+		case '$':
+			out.WriteByte('$')
+			i++
+
+		case '{':
+			end := bytes.IndexByte(content[i+2:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated environment variable placeholder at line %d", lineNumber(content, i))
+			}
+
+			name := string(content[i+2 : i+2+end])
+			if !envVarNameRegexp.MatchString(name) {
+				return nil, fmt.Errorf("invalid environment variable name %q at line %d", name, lineNumber(content, i))
+			}
+
+			value, found := lookup(name)
+			if !found && !allowEmpty && !slices.Contains(missing, name) {
+				missing = append(missing, name)
+			}
+
+			out.WriteString(value)
+			i += 2 + end
+
+		default:
+			out.WriteByte(content[i])
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("environment variables not set: %s (use --allow-empty-env to replace them with empty strings)", strings.Join(missing, ", "))
+	}
+
+	return out.Bytes(), nil
+}
+
+func lineNumber(content []byte, offset int) int {
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
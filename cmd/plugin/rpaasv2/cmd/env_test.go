@@ -0,0 +1,77 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{"BACKEND_HOST": "app.tsuru.example.com", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, found := env[name]
+		return value, found
+	}
+
+	tests := []struct {
+		name          string
+		content       string
+		allowEmpty    bool
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "without placeholders",
+			content:  "proxy_set_header Host $host;\n",
+			expected: "proxy_set_header Host $host;\n",
+		},
+		{
+			name:     "with placeholders",
+			content:  "proxy_pass http://${BACKEND_HOST};\nadd_header X-Empty \"${EMPTY}\";\n",
+			expected: "proxy_pass http://app.tsuru.example.com;\nadd_header X-Empty \"\";\n",
+		},
+		{
+			name:     "with escaped dollar signs",
+			content:  "return 200 \"$${BACKEND_HOST} costs $$5 at $${host}\";$",
+			expected: "return 200 \"${BACKEND_HOST} costs $5 at ${host}\";$",
+		},
+		{
+			name:          "with unset variables",
+			content:       "${MISSING} ${BACKEND_HOST} ${OTHER} ${MISSING}",
+			expectedError: "environment variables not set: MISSING, OTHER (use --allow-empty-env to replace them with empty strings)",
+		},
+		{
+			name:       "with unset variables allowing empty ones",
+			content:    "proxy_pass http://${MISSING}backend;",
+			allowEmpty: true,
+			expected:   "proxy_pass http://backend;",
+		},
+		{
+			name:          "with an unterminated placeholder",
+			content:       "# first line\nproxy_pass http://${BACKEND_HOST;\n",
+			expectedError: "unterminated environment variable placeholder at line 2",
+		},
+		{
+			name:          "with an invalid variable name",
+			content:       "${1HOST}",
+			expectedError: `invalid environment variable name "1HOST" at line 1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandEnv([]byte(tt.content), lookup, tt.allowEmpty)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, string(got))
+		})
+	}
+}
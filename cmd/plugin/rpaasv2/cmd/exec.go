@@ -5,21 +5,28 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/websocket"
 	"github.com/urfave/cli/v2"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/kubectl/pkg/util/term"
 
 	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func NewCmdExec() *cli.Command {
 	return &cli.Command{
 		Name:      "exec",
 		Usage:     "Run a command in an instance",
-		ArgsUsage: "[-p POD] [-c CONTAINER] [--] COMMAND [args...]",
+		ArgsUsage: "[-p POD | --pod-selector SELECTOR [--first]] [-c CONTAINER] [-e KEY=VALUE...] [--] COMMAND [args...] | --file SOURCE --destination PATH",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "service",
@@ -37,6 +44,14 @@ func NewCmdExec() *cli.Command {
 				Aliases: []string{"p"},
 				Usage:   "pod name - if omitted, the first pod will be chosen",
 			},
+			&cli.StringFlag{
+				Name:  "pod-selector",
+				Usage: "label selector (e.g. key=value) matching the pod, instead of its name on --pod",
+			},
+			&cli.BoolFlag{
+				Name:  "first",
+				Usage: "picks the first pod (by name) when more than one pod matches --pod-selector",
+			},
 			&cli.StringFlag{
 				Name:    "container",
 				Aliases: []string{"c"},
@@ -52,6 +67,29 @@ func NewCmdExec() *cli.Command {
 				Aliases: []string{"t"},
 				Usage:   "allocate a pseudo-TTY",
 			},
+			&cli.StringSliceFlag{
+				Name:    "env",
+				Aliases: []string{"e"},
+				Usage:   "environment variable in the KEY=VALUE format set on the command (can be used multiple times)",
+			},
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Usage:   "local file to be copied into the pod (use \"-\" to read from STDIN) instead of running a command",
+			},
+			&cli.StringFlag{
+				Name:    "destination",
+				Aliases: []string{"dest", "d"},
+				Usage:   "path in the pod where the file is going to be written (requires --file)",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "overwrite the destination path if it already exists (requires --file)",
+			},
+			&cli.PathFlag{
+				Name:  "record",
+				Usage: "writes a transcript of the session output (the typed input is left out) into this file in the asciicast v2 format, which can be replayed with asciinema",
+			},
 		},
 		Before: setupClient,
 		Action: runExec,
@@ -64,15 +102,37 @@ func runExec(c *cli.Context) error {
 		return err
 	}
 
+	pod, err := podFromFlags(c, client)
+	if err != nil {
+		return err
+	}
+
+	if c.IsSet("file") {
+		if c.IsSet("record") {
+			return fmt.Errorf("--record cannot be used along with --file")
+		}
+
+		if c.IsSet("env") {
+			return fmt.Errorf("--env cannot be used along with --file")
+		}
+
+		return runCopyFile(c, client, pod)
+	}
+
 	var width, height uint16
 	if ts := term.GetSize(os.Stdin.Fd()); ts != nil {
 		width, height = ts.Width, ts.Height
 	}
 
+	command, err := commandWithEnv(c.StringSlice("env"), c.Args().Slice())
+	if err != nil {
+		return err
+	}
+
 	args := rpaasclient.ExecArgs{
-		Command:        c.Args().Slice(),
+		Command:        command,
 		Instance:       c.String("instance"),
-		Pod:            c.String("pod"),
+		Pod:            pod,
 		Container:      c.String("container"),
 		Interactive:    c.Bool("interactive"),
 		TTY:            c.Bool("tty"),
@@ -89,6 +149,52 @@ func runExec(c *cli.Context) error {
 		Out: c.App.Writer,
 		Raw: args.TTY,
 	}
+
+	return runRecordedExec(c, client, tty, args)
+}
+
+var envVarNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// commandWithEnv prefixes command with "env KEY=VALUE..." since the exec API
+// has no means to set environment variables. Each pair is sent as a single
+// argument, so values containing spaces or quotes need no further escaping.
+func commandWithEnv(pairs, command []string) ([]string, error) {
+	if len(pairs) == 0 {
+		return command, nil
+	}
+
+	if len(command) == 0 {
+		return nil, fmt.Errorf("--env requires a command to run")
+	}
+
+	for _, pair := range pairs {
+		key, _, found := strings.Cut(pair, "=")
+		if !found || !envVarNameRegexp.MatchString(key) {
+			return nil, fmt.Errorf("invalid environment variable %q: must be in the KEY=VALUE format, KEY matching %s", pair, envVarNameRegexp)
+		}
+	}
+
+	return append(append([]string{"env"}, pairs...), command...), nil
+}
+
+// runRecordedExec runs the command described by args within tty, recording
+// the session when asked to.
+func runRecordedExec(c *cli.Context, client rpaasclient.Client, tty *term.TTY, args rpaasclient.ExecArgs) (err error) {
+	out, rec, err := recordSession(c, &args, c.App.Writer)
+	if err != nil {
+		return err
+	}
+
+	errOut := c.App.ErrWriter
+	if rec != nil {
+		errOut = io.MultiWriter(errOut, rec.Output())
+		defer func() {
+			if rerr := rec.Close(); err == nil {
+				err = rerr
+			}
+		}()
+	}
+
 	return tty.Safe(func() error {
 		conn, err := client.Exec(c.Context, args)
 		if err != nil {
@@ -96,37 +202,192 @@ func runExec(c *cli.Context) error {
 		}
 		defer conn.Close()
 
-		done := make(chan error, 1)
-		go func() {
-			defer close(done)
-			for {
-				mtype, message, nerr := conn.ReadMessage()
-				if nerr != nil {
-					closeErr, ok := nerr.(*websocket.CloseError)
-					if !ok {
-						done <- fmt.Errorf("ERROR: received an unexpected error while reading messages: %w", err)
-						return
-					}
+		return readExecOutput(conn, out, errOut)
+	})
+}
 
-					switch closeErr.Code {
-					case websocket.CloseNormalClosure:
-					case websocket.CloseInternalServerErr:
-						done <- fmt.Errorf("ERROR: the command may not be executed as expected - reason: %s", closeErr.Text)
-					default:
-						done <- fmt.Errorf("ERROR: unexpected close error: %s", closeErr.Error())
-					}
+// ExitError is returned when the remote command exits with a non-zero status,
+// which should become the exit status of the CLI as well.
+type ExitError struct {
+	Status int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command terminated with exit code %d", e.Status)
+}
+
+// readExecOutput copies the messages received from the remote command to
+// stdout and stderr until the connection is closed, returning an error when
+// the command did not finish properly. When the server speaks the
+// ExecChannelProtocol, a non-zero exit status is returned as an *ExitError;
+// otherwise (i.e. older servers) both outputs are written to stdout.
+func readExecOutput(conn *websocket.Conn, stdout, stderr io.Writer) error {
+	channels := conn.Subprotocol() == clientTypes.ExecChannelProtocol
 
+	done := make(chan error, 1)
+	go func() {
+		defer close(done)
+		status := -1
+		for {
+			mtype, message, err := conn.ReadMessage()
+			if err != nil {
+				closeErr, ok := err.(*websocket.CloseError)
+				if !ok {
+					done <- fmt.Errorf("ERROR: received an unexpected error while reading messages: %w", err)
 					return
 				}
 
-				switch mtype {
-				case websocket.TextMessage, websocket.BinaryMessage:
-					c.App.Writer.Write(message)
+				switch closeErr.Code {
+				case websocket.CloseNormalClosure:
+					if status > 0 {
+						done <- &ExitError{Status: status}
+					}
+				case websocket.CloseInternalServerErr:
+					done <- fmt.Errorf("ERROR: the command may not be executed as expected - reason: %s", closeErr.Text)
+				default:
+					done <- fmt.Errorf("ERROR: unexpected close error: %s", closeErr.Error())
 				}
+
+				return
 			}
-		}()
-		err = <-done
-		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+
+			if mtype != websocket.TextMessage && mtype != websocket.BinaryMessage {
+				continue
+			}
+
+			if !channels {
+				stdout.Write(message)
+				continue
+			}
+
+			if len(message) == 0 {
+				continue
+			}
+
+			switch message[0] {
+			case clientTypes.ExecStdoutChannel:
+				stdout.Write(message[1:])
+			case clientTypes.ExecStderrChannel:
+				stderr.Write(message[1:])
+			case clientTypes.ExecStatusChannel:
+				if status, err = strconv.Atoi(string(message[1:])); err != nil {
+					done <- fmt.Errorf("ERROR: received an invalid exit status %q", message[1:])
+					return
+				}
+			}
+		}
+	}()
+	err := <-done
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return err
+}
+
+// copyFileScript writes exactly the number of bytes announced to the
+// destination and prints the resulting file size. Reading a fixed amount of
+// bytes (rather than waiting for EOF as "cat" does) is required since the
+// exec channel has no way to close the remote STDIN without closing the
+// whole connection.
+const copyFileScript = `if [ -e "$1" ] && [ "$2" != "true" ]; then echo "$1 already exists, use --force to overwrite it" >&2; exit 1; fi; head -c "$3" > "$1" && wc -c < "$1"`
+
+func runCopyFile(c *cli.Context, client rpaasclient.Client, pod string) error {
+	source, destination := c.String("file"), c.String("destination")
+	if destination == "" {
+		return fmt.Errorf("destination path is required when copying a file (see --destination)")
+	}
+
+	if c.Args().Present() {
+		return fmt.Errorf("cannot run a command while copying a file")
+	}
+
+	var content []byte
+	var err error
+	if source == "-" {
+		content, err = io.ReadAll(c.App.Reader)
+	} else {
+		content, err = os.ReadFile(source)
+	}
+	if err != nil {
 		return err
+	}
+
+	conn, err := client.Exec(c.Context, rpaasclient.ExecArgs{
+		In:          bytes.NewReader(content),
+		Command:     []string{"sh", "-c", copyFileScript, "sh", destination, strconv.FormatBool(c.Bool("force")), strconv.Itoa(len(content))},
+		Instance:    c.String("instance"),
+		Pod:         pod,
+		Container:   c.String("container"),
+		Interactive: true,
 	})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var output bytes.Buffer
+	err = readExecOutput(conn, &output, &output)
+	if err != nil {
+		if out := strings.TrimSpace(output.String()); out != "" {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+
+		return err
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	written, err := strconv.Atoi(strings.TrimSpace(lines[len(lines)-1]))
+	if err != nil {
+		return fmt.Errorf("could not verify the copied file: unexpected output %q", output.String())
+	}
+
+	if written != len(content) {
+		return fmt.Errorf("file copy incomplete: %d of %d bytes written to %s", written, len(content), destination)
+	}
+
+	fmt.Fprintf(c.App.Writer, "Copied %d bytes to %s on %s\n", written, destination, formatInstanceName(c))
+	return nil
+}
+
+// podFromFlags returns the pod name set on --pod or, when --pod-selector is
+// set, the name of the running pod matching that label selector.
+func podFromFlags(c *cli.Context, client rpaasclient.Client) (string, error) {
+	selector := c.String("pod-selector")
+	if selector == "" {
+		if c.Bool("first") {
+			return "", fmt.Errorf("--first can only be used along with --pod-selector")
+		}
+
+		return c.String("pod"), nil
+	}
+
+	if c.IsSet("pod") {
+		return "", fmt.Errorf("--pod cannot be used along with --pod-selector")
+	}
+
+	if _, err := labels.Parse(selector); err != nil {
+		return "", fmt.Errorf("invalid --pod-selector %q: %w", selector, err)
+	}
+
+	pods, err := client.ListPods(c.Context, rpaasclient.ListPodsArgs{Instance: c.String("instance"), Selector: selector})
+	if err != nil {
+		return "", err
+	}
+
+	if len(pods) == 0 {
+		return "", fmt.Errorf("no running pods of %s match the selector %q", formatInstanceName(c), selector)
+	}
+
+	if len(pods) > 1 {
+		var names []string
+		for _, p := range pods {
+			names = append(names, p.Name)
+		}
+
+		if !c.Bool("first") {
+			return "", fmt.Errorf("%d pods of %s match the selector %q (%s), use --first to pick the first one", len(pods), formatInstanceName(c), selector, strings.Join(names, ", "))
+		}
+
+		fmt.Fprintf(c.App.ErrWriter, "Picked the pod %s out of %d pods matching the selector %q\n", pods[0].Name, len(pods), selector)
+	}
+
+	return pods[0].Name, nil
 }
@@ -8,7 +8,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/websocket"
@@ -17,6 +22,7 @@ import (
 
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func TestExec(t *testing.T) {
@@ -69,6 +75,42 @@ func TestExec(t *testing.T) {
 			expectedCalled: true,
 			expectedError:  "another error",
 		},
+		{
+			name: "with environment variables",
+			args: []string{"rpaasv2", "exec", "-s", "rpaasv2", "-i", "my-instance", "--tty", "--env", "DEBUG=1", "--env", "GREETING=hello, \"world\"", "--env", "EMPTY=", "--", "sh", "-c", "echo $GREETING"},
+			client: &fake.FakeClient{
+				FakeExec: func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+					called = true
+					expected := client.ExecArgs{
+						Command:  []string{"env", "DEBUG=1", "GREETING=hello, \"world\"", "EMPTY=", "sh", "-c", "echo $GREETING"},
+						Instance: "my-instance",
+						TTY:      true,
+					}
+					assert.Equal(t, expected, args)
+					return nil, fmt.Errorf("some error")
+				},
+			},
+			expectedCalled: true,
+			expectedError:  "some error",
+		},
+		{
+			name:          "with an invalid environment variable name",
+			args:          []string{"rpaasv2", "exec", "-s", "rpaasv2", "-i", "my-instance", "--env", "1DEBUG=1", "--", "my-command"},
+			client:        &fake.FakeClient{},
+			expectedError: `invalid environment variable "1DEBUG=1": must be in the KEY=VALUE format, KEY matching ^[A-Za-z_][A-Za-z0-9_]*$`,
+		},
+		{
+			name:          "with an environment variable missing its value",
+			args:          []string{"rpaasv2", "exec", "-s", "rpaasv2", "-i", "my-instance", "--env", "DEBUG", "--", "my-command"},
+			client:        &fake.FakeClient{},
+			expectedError: `invalid environment variable "DEBUG": must be in the KEY=VALUE format, KEY matching ^[A-Za-z_][A-Za-z0-9_]*$`,
+		},
+		{
+			name:          "with environment variables but no command",
+			args:          []string{"rpaasv2", "exec", "-s", "rpaasv2", "-i", "my-instance", "--env", "DEBUG=1"},
+			client:        &fake.FakeClient{},
+			expectedError: "--env requires a command to run",
+		},
 	}
 
 	for _, tt := range tests {
@@ -89,3 +131,316 @@ func TestExec(t *testing.T) {
 		})
 	}
 }
+
+func TestExecExitStatus(t *testing.T) {
+	// newFakeExec returns a FakeExec func which dials against a WebSocket
+	// server running a fake command: it replies each message (prefixed by
+	// its channel when the server speaks the channel protocol) and then
+	// closes the connection with the given code.
+	newFakeExec := func(t *testing.T, subprotocols []string, messages [][]byte, code int, reason string) func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+		return func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+			assert.Equal(t, []string{"cat", "/missing"}, args.Command)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upgrader := websocket.Upgrader{Subprotocols: subprotocols}
+				conn, err := upgrader.Upgrade(w, r, nil)
+				require.NoError(t, err)
+				defer conn.Close()
+				for _, m := range messages {
+					conn.WriteMessage(websocket.BinaryMessage, m)
+				}
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+				conn.ReadMessage()
+			}))
+			t.Cleanup(server.Close)
+
+			dialer := websocket.Dialer{Subprotocols: []string{clientTypes.ExecChannelProtocol}}
+			conn, _, err := dialer.DialContext(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+			return conn, err
+		}
+	}
+
+	channels := []string{clientTypes.ExecChannelProtocol}
+	stdoutMessage := append([]byte{clientTypes.ExecStdoutChannel}, "some output\n"...)
+	stderrMessage := func(s string) []byte { return append([]byte{clientTypes.ExecStderrChannel}, s...) }
+	statusMessage := func(s string) []byte { return append([]byte{clientTypes.ExecStatusChannel}, s...) }
+
+	tests := []struct {
+		name           string
+		fakeExec       func(t *testing.T) func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error)
+		expectedStdout string
+		expectedStderr string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name: "when the command succeeds",
+			fakeExec: func(t *testing.T) func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+				return newFakeExec(t, channels, [][]byte{stdoutMessage, statusMessage("0")}, websocket.CloseNormalClosure, "")
+			},
+			expectedStdout: "some output\n",
+		},
+		{
+			name: "when the command fails",
+			fakeExec: func(t *testing.T) func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+				return newFakeExec(t, channels, [][]byte{stdoutMessage, stderrMessage("cat: can't open '/missing': No such file or directory\n"), statusMessage("1")}, websocket.CloseNormalClosure, "")
+			},
+			expectedStdout: "some output\n",
+			expectedStderr: "cat: can't open '/missing': No such file or directory\n",
+			expectedStatus: 1,
+		},
+		{
+			name: "when the command is not found",
+			fakeExec: func(t *testing.T) func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+				return newFakeExec(t, channels, [][]byte{stderrMessage("exec: \"cat\": executable file not found in $PATH\n"), statusMessage("127")}, websocket.CloseNormalClosure, "")
+			},
+			expectedStderr: "exec: \"cat\": executable file not found in $PATH\n",
+			expectedStatus: 127,
+		},
+		{
+			name: "when the command is killed by a signal",
+			fakeExec: func(t *testing.T) func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+				return newFakeExec(t, channels, [][]byte{stdoutMessage, statusMessage("137")}, websocket.CloseNormalClosure, "")
+			},
+			expectedStdout: "some output\n",
+			expectedStatus: 137,
+		},
+		{
+			name: "when the exit status is invalid",
+			fakeExec: func(t *testing.T) func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+				return newFakeExec(t, channels, [][]byte{statusMessage("killed")}, websocket.CloseNormalClosure, "")
+			},
+			expectedError: `ERROR: received an invalid exit status "killed"`,
+		},
+		{
+			name: "when the command could not be run",
+			fakeExec: func(t *testing.T) func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+				return newFakeExec(t, channels, nil, websocket.CloseInternalServerErr, "pod not found")
+			},
+			expectedError: "ERROR: the command may not be executed as expected - reason: pod not found",
+		},
+		{
+			name: "when the server does not support the channel protocol",
+			fakeExec: func(t *testing.T) func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+				return newFakeExec(t, nil, [][]byte{[]byte("some output\n"), []byte("cat: can't open '/missing'\n")}, websocket.CloseInternalServerErr, "command terminated with exit code 1")
+			},
+			expectedStdout: "some output\ncat: can't open '/missing'\n",
+			expectedError:  "ERROR: the command may not be executed as expected - reason: command terminated with exit code 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, &fake.FakeClient{FakeExec: tt.fakeExec(t)})
+			err := app.Run([]string{"rpaasv2", "exec", "-i", "my-instance", "--", "cat", "/missing"})
+			assert.Equal(t, tt.expectedStdout, stdout.String())
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+
+			switch {
+			case tt.expectedError != "":
+				assert.EqualError(t, err, tt.expectedError)
+
+			case tt.expectedStatus != 0:
+				var exitErr *ExitError
+				require.ErrorAs(t, err, &exitErr)
+				assert.Equal(t, tt.expectedStatus, exitErr.Status)
+				assert.EqualError(t, err, fmt.Sprintf("command terminated with exit code %d", tt.expectedStatus))
+
+			default:
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestExecPodSelector(t *testing.T) {
+	pods := []clientTypes.Pod{{Name: "my-instance-abc"}, {Name: "my-instance-def"}}
+
+	tests := []struct {
+		name           string
+		args           []string
+		pods           []clientTypes.Pod
+		expectedPod    string
+		expectedStderr string
+		expectedError  string
+	}{
+		{
+			name:        "when a single pod matches",
+			args:        []string{"--pod-selector", "version=v2"},
+			pods:        pods[1:],
+			expectedPod: "my-instance-def",
+		},
+		{
+			name:          "when no pod matches",
+			args:          []string{"--pod-selector", "version=v2"},
+			expectedError: `no running pods of rpaasv2/my-instance match the selector "version=v2"`,
+		},
+		{
+			name:          "when many pods match",
+			args:          []string{"--pod-selector", "version=v2"},
+			pods:          pods,
+			expectedError: `2 pods of rpaasv2/my-instance match the selector "version=v2" (my-instance-abc, my-instance-def), use --first to pick the first one`,
+		},
+		{
+			name:           "when many pods match picking the first one",
+			args:           []string{"--pod-selector", "version=v2", "--first"},
+			pods:           pods,
+			expectedPod:    "my-instance-abc",
+			expectedStderr: "Picked the pod my-instance-abc out of 2 pods matching the selector \"version=v2\"\n",
+		},
+		{
+			name:          "with an invalid selector",
+			args:          []string{"--pod-selector", "version in (v1"},
+			expectedError: `invalid --pod-selector "version in (v1": unable to parse requirement: found '', expected: ',' or ')'`,
+		},
+		{
+			name:          "along with --pod",
+			args:          []string{"--pod-selector", "version=v2", "-p", "my-instance-abc"},
+			expectedError: "--pod cannot be used along with --pod-selector",
+		},
+		{
+			name:          "with --first but no selector",
+			args:          []string{"--first"},
+			expectedError: "--first can only be used along with --pod-selector",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := &fake.FakeClient{
+				FakeListPods: func(args client.ListPodsArgs) ([]clientTypes.Pod, error) {
+					assert.Equal(t, client.ListPodsArgs{Instance: "my-instance", Selector: "version=v2"}, args)
+					return tt.pods, nil
+				},
+				FakeExec: func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+					assert.Equal(t, tt.expectedPod, args.Pod)
+					return nil, fmt.Errorf("some error")
+				},
+			}
+
+			stderr := &bytes.Buffer{}
+			args := append([]string{"rpaasv2", "exec", "-s", "rpaasv2", "-i", "my-instance"}, tt.args...)
+			err := NewApp(&bytes.Buffer{}, stderr, fakeClient).Run(append(args, "--", "nginx", "-t"))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			assert.EqualError(t, err, "some error")
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+		})
+	}
+}
+
+func TestExecCopyFile(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "script.sh")
+	require.NoError(t, os.WriteFile(source, []byte("echo hello\n"), 0644))
+
+	// newFakeExec returns a FakeExec func which dials against a WebSocket
+	// server replying the remote command's output and closing the
+	// connection with the given code.
+	newFakeExec := func(t *testing.T, expectedForce string, output string, code int) func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+		return func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+			assert.Equal(t, "my-instance", args.Instance)
+			assert.True(t, args.Interactive)
+			require.Len(t, args.Command, 7)
+			assert.Equal(t, []string{"sh", "-c"}, args.Command[:2])
+			assert.Equal(t, []string{"sh", "/tmp/script.sh", expectedForce, "11"}, args.Command[3:])
+
+			content, err := io.ReadAll(args.In)
+			require.NoError(t, err)
+			assert.Equal(t, "echo hello\n", string(content))
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				upgrader := websocket.Upgrader{}
+				conn, err := upgrader.Upgrade(w, r, nil)
+				require.NoError(t, err)
+				defer conn.Close()
+				if output != "" {
+					conn.WriteMessage(websocket.TextMessage, []byte(output))
+				}
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, "command terminated with exit code 1"))
+				conn.ReadMessage()
+			}))
+			t.Cleanup(server.Close)
+
+			conn, _, err := websocket.DefaultDialer.DialContext(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+			return conn, err
+		}
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		stdin         string
+		expected      string
+		expectedError string
+		client        func(t *testing.T) client.Client
+	}{
+		{
+			name:          "without destination",
+			args:          []string{"rpaasv2", "exec", "-i", "my-instance", "--file", source},
+			expectedError: "destination path is required when copying a file (see --destination)",
+			client:        func(t *testing.T) client.Client { return &fake.FakeClient{} },
+		},
+		{
+			name:          "with command and file at once",
+			args:          []string{"rpaasv2", "exec", "-i", "my-instance", "--file", source, "--dest", "/tmp/script.sh", "--", "ls"},
+			expectedError: "cannot run a command while copying a file",
+			client:        func(t *testing.T) client.Client { return &fake.FakeClient{} },
+		},
+		{
+			name:     "copying a local file",
+			args:     []string{"rpaasv2", "exec", "-i", "my-instance", "--file", source, "--destination", "/tmp/script.sh"},
+			expected: "Copied 11 bytes to /tmp/script.sh on my-instance\n",
+			client: func(t *testing.T) client.Client {
+				return &fake.FakeClient{FakeExec: newFakeExec(t, "false", "11\n", websocket.CloseNormalClosure)}
+			},
+		},
+		{
+			name:     "copying from stdin overwriting the destination",
+			args:     []string{"rpaasv2", "exec", "-i", "my-instance", "-f", "-", "-d", "/tmp/script.sh", "--force"},
+			stdin:    "echo hello\n",
+			expected: "Copied 11 bytes to /tmp/script.sh on my-instance\n",
+			client: func(t *testing.T) client.Client {
+				return &fake.FakeClient{FakeExec: newFakeExec(t, "true", "11\n", websocket.CloseNormalClosure)}
+			},
+		},
+		{
+			name:          "when destination already exists",
+			args:          []string{"rpaasv2", "exec", "-i", "my-instance", "--file", source, "--destination", "/tmp/script.sh"},
+			expectedError: "ERROR: the command may not be executed as expected - reason: command terminated with exit code 1: /tmp/script.sh already exists, use --force to overwrite it",
+			client: func(t *testing.T) client.Client {
+				return &fake.FakeClient{FakeExec: newFakeExec(t, "false", "/tmp/script.sh already exists, use --force to overwrite it\n", websocket.CloseInternalServerErr)}
+			},
+		},
+		{
+			name:          "when written size does not match",
+			args:          []string{"rpaasv2", "exec", "-i", "my-instance", "--file", source, "--destination", "/tmp/script.sh"},
+			expectedError: "file copy incomplete: 4 of 11 bytes written to /tmp/script.sh",
+			client: func(t *testing.T) client.Client {
+				return &fake.FakeClient{FakeExec: newFakeExec(t, "false", "4\n", websocket.CloseNormalClosure)}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client(t))
+			app.Reader = strings.NewReader(tt.stdin)
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
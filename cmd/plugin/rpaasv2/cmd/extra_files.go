@@ -24,9 +24,9 @@ func NewCmdExtraFiles() *cli.Command {
 		Aliases: []string{"files"},
 		Usage:   "Manages persistent files in the instance filesystem",
 		Subcommands: []*cli.Command{
-			NewCmdAddExtraFiles(),
-			NewCmdUpdateExtraFiles(),
-			NewCmdDeleteExtraFiles(),
+			withInstanceFile(NewCmdAddExtraFiles()),
+			withInstanceFile(NewCmdUpdateExtraFiles()),
+			withInstanceFile(NewCmdDeleteExtraFiles()),
 			NewCmdListExtraFiles(),
 			NewCmdGetExtraFile(),
 		},
@@ -0,0 +1,173 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func NewCmdFlavors() *cli.Command {
+	return &cli.Command{
+		Name:    "flavors",
+		Aliases: []string{"flavor"},
+		Usage:   "Shows the flavors available to rpaas instances",
+		Subcommands: []*cli.Command{
+			NewCmdListFlavors(),
+			NewCmdGetFlavor(),
+		},
+	}
+}
+
+func flavorFlags(formats ...string) []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "service",
+			Aliases: []string{"tsuru-service", "s"},
+			Usage:   "the Tsuru service name",
+		},
+		&cli.StringFlag{
+			Name:    "instance",
+			Aliases: []string{"tsuru-service-instance", "i"},
+			Usage:   "the reverse proxy instance name (required when going through Tsuru)",
+		},
+		outputFlag(formats...),
+	}, outputFieldFlags()...)
+}
+
+func outputFlag(formats ...string) *cli.StringFlag {
+	return &cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Usage:   fmt.Sprintf("the output format (one of: %s)", strings.Join(formats, ", ")),
+		Value:   "table",
+	}
+}
+
+func NewCmdListFlavors() *cli.Command {
+	return &cli.Command{
+		Name:    "list",
+		Aliases: []string{"ls"},
+		Usage:   "Lists the available flavors",
+		Flags:   flavorFlags("table", "json", "yaml", "csv", "jsonl"),
+		Before:  setupClient,
+		Action:  runListFlavors,
+	}
+}
+
+func NewCmdGetFlavor() *cli.Command {
+	return &cli.Command{
+		Name:      "info",
+		Usage:     "Shows the details of a flavor",
+		ArgsUsage: "FLAVOR",
+		Flags:     flavorFlags("table", "json", "yaml"),
+		Before:    setupClient,
+		Action:    runGetFlavor,
+	}
+}
+
+func runListFlavors(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	flavors, err := client.ListFlavors(c.Context, rpaasclient.ListFlavorsArgs{Instance: c.String("instance")})
+	if err != nil {
+		return err
+	}
+
+	return writeListOutput(c, c.String("output"), flavors, flavorsRecords(flavors), func(w io.Writer) error {
+		writeFlavorsOnTableFormat(w, flavors)
+		return nil
+	})
+}
+
+func runGetFlavor(c *cli.Context) error {
+	if c.Args().Len() != 1 {
+		return fmt.Errorf("flavor name is required")
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	flavor, err := client.GetFlavor(c.Context, rpaasclient.GetFlavorArgs{
+		Instance: c.String("instance"),
+		Name:     c.Args().First(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(c, c.String("output"), flavor, func(w io.Writer) error {
+		return writeFlavorInfo(w, flavor)
+	})
+}
+
+func writeFlavorsOnTableFormat(w io.Writer, flavors []clientTypes.Flavor) {
+	if len(flavors) == 0 {
+		return
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Name", "Description"})
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(true)
+	table.SetRowLine(false)
+
+	for _, flavor := range flavors {
+		table.Append([]string{flavor.Name, flavor.Description})
+	}
+
+	table.Render()
+}
+
+func flavorsRecords(flavors []clientTypes.Flavor) records {
+	rec := records{Header: []string{"Name", "Description"}}
+	for _, flavor := range flavors {
+		rec.Rows = append(rec.Rows, []string{flavor.Name, flavor.Description})
+	}
+
+	return rec
+}
+
+func writeFlavorInfo(w io.Writer, flavor *clientTypes.FlavorInfo) error {
+	if flavor == nil {
+		return nil
+	}
+
+	fmt.Fprintf(w, "Name: %s\n", flavor.Name)
+	fmt.Fprintf(w, "Description: %s\n", flavor.Description)
+	fmt.Fprintf(w, "Default: %t\n", flavor.Default)
+	fmt.Fprintf(w, "Creation only: %t\n", flavor.CreationOnly)
+	fmt.Fprintf(w, "Incompatible flavors: %s\n", strings.Join(flavor.IncompatibleFlavors, ", "))
+
+	if flavor.InstanceTemplate == nil {
+		return nil
+	}
+
+	data, err := yaml.Marshal(flavor.InstanceTemplate)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nInstance template:")
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	nginxv1alpha1 "github.com/tsuru/nginx-operator/api/v1alpha1"
+
+	"github.com/tsuru/rpaas-operator/api/v1alpha1"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestListFlavors(t *testing.T) {
+	flavors := []types.Flavor{
+		{Name: "mango", Description: "Mango flavor"},
+		{Name: "mint", Description: "Mint flavor"},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        client.Client
+	}{
+		{
+			name:          "when ListFlavors returns an error",
+			args:          []string{"./rpaasv2", "flavors", "list", "-i", "my-instance"},
+			expectedError: "some error",
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					return nil, fmt.Errorf("some error")
+				},
+			},
+		},
+		{
+			name: "listing flavors on table format",
+			args: []string{"./rpaasv2", "flavor", "list", "-i", "my-instance"},
+			expected: `+-------+--------------+
+| Name  | Description  |
++-------+--------------+
+| mango | Mango flavor |
+| mint  | Mint flavor  |
++-------+--------------+
+`,
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					assert.Equal(t, client.ListFlavorsArgs{Instance: "my-instance"}, args)
+					return flavors, nil
+				},
+			},
+		},
+		{
+			name: "listing flavors as JSON",
+			args: []string{"./rpaasv2", "flavors", "list", "-i", "my-instance", "-o", "json"},
+			expected: `[
+	{
+		"name": "mango",
+		"description": "Mango flavor"
+	},
+	{
+		"name": "mint",
+		"description": "Mint flavor"
+	}
+]
+`,
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					return flavors, nil
+				},
+			},
+		},
+		{
+			name: "listing flavors as YAML",
+			args: []string{"./rpaasv2", "flavors", "list", "-i", "my-instance", "--output", "yaml"},
+			expected: `- description: Mango flavor
+  name: mango
+- description: Mint flavor
+  name: mint
+`,
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					return flavors, nil
+				},
+			},
+		},
+		{
+			name:     "projecting a field of the flavors",
+			args:     []string{"./rpaasv2", "flavors", "list", "-i", "my-instance", "-o", "json", "--field", "[1].name"},
+			expected: "mint\n",
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					return flavors, nil
+				},
+			},
+		},
+		{
+			name: "projecting an object of the flavors",
+			args: []string{"./rpaasv2", "flavors", "list", "-i", "my-instance", "-o", "json", "--field", "$[0]"},
+			expected: `{
+	"description": "Mango flavor",
+	"name": "mango"
+}
+`,
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					return flavors, nil
+				},
+			},
+		},
+		{
+			name:          "projecting a field on table format",
+			args:          []string{"./rpaasv2", "flavors", "list", "-i", "my-instance", "--field", "[1].name"},
+			expectedError: "--field and --allow-missing can only be used along with --output json",
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					return flavors, nil
+				},
+			},
+		},
+		{
+			name: "listing flavors as CSV",
+			args: []string{"./rpaasv2", "flavors", "list", "-i", "my-instance", "-o", "csv"},
+			expected: "Name,Description\r\n" +
+				"mango,Mango flavor\r\n" +
+				"mint,Mint flavor\r\n",
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					return flavors, nil
+				},
+			},
+		},
+		{
+			name: "listing flavors as JSON Lines",
+			args: []string{"./rpaasv2", "flavors", "list", "-i", "my-instance", "-o", "jsonl"},
+			expected: `{"name":"mango","description":"Mango flavor"}
+{"name":"mint","description":"Mint flavor"}
+`,
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					return flavors, nil
+				},
+			},
+		},
+		{
+			name:          "with an unknown output format",
+			args:          []string{"./rpaasv2", "flavors", "list", "-i", "my-instance", "-o", "xml"},
+			expectedError: `unsupported output format "xml" (one of: table, json, yaml, csv, jsonl)`,
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					return flavors, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client)
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
+
+func TestGetFlavor(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        client.Client
+	}{
+		{
+			name:          "without flavor name",
+			args:          []string{"./rpaasv2", "flavors", "info", "-i", "my-instance"},
+			expectedError: "flavor name is required",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when GetFlavor returns an error",
+			args:          []string{"./rpaasv2", "flavors", "info", "-i", "my-instance", "mint"},
+			expectedError: "some error",
+			client: &fake.FakeClient{
+				FakeGetFlavor: func(args client.GetFlavorArgs) (*types.FlavorInfo, error) {
+					return nil, fmt.Errorf("some error")
+				},
+			},
+		},
+		{
+			name: "showing the flavor details",
+			args: []string{"./rpaasv2", "flavors", "info", "-i", "my-instance", "mint"},
+			expected: `Name: mint
+Description: Mint flavor
+Default: false
+Creation only: true
+Incompatible flavors: mango, orange
+
+Instance template:
+  podTemplate: {}
+  service:
+    annotations:
+      mint: "true"
+`,
+			client: &fake.FakeClient{
+				FakeGetFlavor: func(args client.GetFlavorArgs) (*types.FlavorInfo, error) {
+					assert.Equal(t, client.GetFlavorArgs{Instance: "my-instance", Name: "mint"}, args)
+					return &types.FlavorInfo{
+						Name:                "mint",
+						Description:         "Mint flavor",
+						CreationOnly:        true,
+						IncompatibleFlavors: []string{"mango", "orange"},
+						InstanceTemplate: &v1alpha1.RpaasInstanceSpec{
+							Service: &nginxv1alpha1.NginxService{
+								Annotations: map[string]string{"mint": "true"},
+							},
+						},
+					}, nil
+				},
+			},
+		},
+		{
+			name: "showing the flavor details as JSON",
+			args: []string{"./rpaasv2", "flavors", "info", "-i", "my-instance", "-o", "json", "mint"},
+			expected: `{
+	"name": "mint",
+	"description": "Mint flavor"
+}
+`,
+			client: &fake.FakeClient{
+				FakeGetFlavor: func(args client.GetFlavorArgs) (*types.FlavorInfo, error) {
+					return &types.FlavorInfo{Name: "mint", Description: "Mint flavor"}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client)
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func NewCmdHistory() *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "Shows the recent changes of an instance (scale, autoscale, certificates, blocks, routes, etc), from the oldest one",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.DurationFlag{
+				Name:  "since",
+				Usage: "shows only the changes made within this duration (e.g. 24h)",
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "shows only this number of the most recent changes (0 means no limit)",
+			},
+			outputFlag("table", "json", "yaml", "csv", "jsonl"),
+		}, outputFieldFlags()...),
+		Before: setupClient,
+		Action: runHistory,
+	}
+}
+
+func runHistory(c *cli.Context) error {
+	since := c.Duration("since")
+	if c.IsSet("since") && since <= 0 {
+		return fmt.Errorf("--since must be a positive duration")
+	}
+
+	limit := c.Int("limit")
+	if limit < 0 {
+		return fmt.Errorf("--limit must not be a negative number")
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	history, err := client.GetHistory(c.Context, rpaasclient.HistoryArgs{Instance: c.String("instance")})
+	if err != nil {
+		return err
+	}
+
+	history = filterHistory(history, since, limit)
+
+	return writeListOutput(c, c.String("output"), history, historyRecords(history), func(w io.Writer) error {
+		if len(history) == 0 {
+			fmt.Fprintf(w, "No changes found in %s\n", formatInstanceName(c))
+			return nil
+		}
+
+		writeHistoryOnTableFormat(w, history)
+		return nil
+	})
+}
+
+// filterHistory keeps the entries made within since (when it's set), then the
+// limit most recent of them (when it's set), still from the oldest one.
+func filterHistory(history []clientTypes.HistoryEntry, since time.Duration, limit int) []clientTypes.HistoryEntry {
+	cutoff := timeNow().Add(-since)
+
+	filtered := []clientTypes.HistoryEntry{}
+	for _, h := range history {
+		if since > 0 && h.Time.Before(cutoff) {
+			continue
+		}
+
+		filtered = append(filtered, h)
+	}
+
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[len(filtered)-limit:]
+	}
+
+	return filtered
+}
+
+func historyRecords(history []clientTypes.HistoryEntry) records {
+	rec := records{Header: []string{"Time", "Kind", "Actor", "Action", "Message"}}
+	for _, h := range history {
+		rec.Rows = append(rec.Rows, []string{formatTime(h.Time), h.Kind, h.Actor, h.Action, h.Message})
+	}
+
+	return rec
+}
+
+func writeHistoryOnTableFormat(w io.Writer, history []clientTypes.HistoryEntry) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Time", "Kind", "Actor", "Action", "Message"})
+	table.SetRowLine(true)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(false)
+	table.AppendBulk(historyRecords(history).Rows)
+	table.Render()
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestHistory(t *testing.T) {
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC) }
+
+	history := []types.HistoryEntry{
+		{Time: time.Date(2023, time.March, 1, 10, 0, 0, 0, time.UTC), Kind: "block", Actor: "rpaas-api", Action: "Update", Message: "set blocks (http, server)"},
+		{Time: time.Date(2023, time.March, 15, 9, 0, 0, 0, time.UTC), Kind: "scale", Actor: "rpaas-api", Action: "Update", Message: "set replicas"},
+		{Time: time.Date(2023, time.March, 15, 11, 0, 0, 0, time.UTC), Kind: "event", Actor: "rpaas-operator", Action: "RpaasInstanceUpdated", Message: "scaled to 3 replicas"},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		history       []types.HistoryEntry
+		expected      string
+		expectedError string
+	}{
+		{
+			name:    "with the whole history",
+			args:    []string{"./rpaasv2", "history", "-i", "my-instance"},
+			history: history,
+			expected: `+----------------------+-------+----------------+----------------------+---------------------------+
+| Time                 | Kind  | Actor          | Action               | Message                   |
++----------------------+-------+----------------+----------------------+---------------------------+
+| 2023-03-01T10:00:00Z | block | rpaas-api      | Update               | set blocks (http, server) |
++----------------------+-------+----------------+----------------------+---------------------------+
+| 2023-03-15T09:00:00Z | scale | rpaas-api      | Update               | set replicas              |
++----------------------+-------+----------------+----------------------+---------------------------+
+| 2023-03-15T11:00:00Z | event | rpaas-operator | RpaasInstanceUpdated | scaled to 3 replicas      |
++----------------------+-------+----------------+----------------------+---------------------------+
+`,
+		},
+		{
+			name:     "with the changes since a day ago as CSV",
+			args:     []string{"./rpaasv2", "history", "-i", "my-instance", "--since", "24h", "-o", "csv"},
+			history:  history,
+			expected: "Time,Kind,Actor,Action,Message\r\n2023-03-15T09:00:00Z,scale,rpaas-api,Update,set replicas\r\n2023-03-15T11:00:00Z,event,rpaas-operator,RpaasInstanceUpdated,scaled to 3 replicas\r\n",
+		},
+		{
+			name:     "with the most recent change as JSON",
+			args:     []string{"./rpaasv2", "history", "-i", "my-instance", "--limit", "1", "-o", "json"},
+			history:  history,
+			expected: "[\n\t{\n\t\t\"time\": \"2023-03-15T11:00:00Z\",\n\t\t\"kind\": \"event\",\n\t\t\"actor\": \"rpaas-operator\",\n\t\t\"action\": \"RpaasInstanceUpdated\",\n\t\t\"message\": \"scaled to 3 replicas\"\n\t}\n]\n",
+		},
+		{
+			name:     "without changes within the period",
+			args:     []string{"./rpaasv2", "history", "-i", "my-instance", "--since", "10m"},
+			history:  history,
+			expected: "No changes found in my-instance\n",
+		},
+		{
+			name:     "without changes as JSON",
+			args:     []string{"./rpaasv2", "history", "-i", "my-instance", "-o", "json"},
+			expected: "[]\n",
+		},
+		{
+			name:          "with a negative limit",
+			args:          []string{"./rpaasv2", "history", "-i", "my-instance", "--limit", "-1"},
+			expectedError: "--limit must not be a negative number",
+		},
+		{
+			name:          "with a non-positive since",
+			args:          []string{"./rpaasv2", "history", "-i", "my-instance", "--since", "0s"},
+			expectedError: "--since must be a positive duration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := &fake.FakeClient{
+				FakeGetHistory: func(args client.HistoryArgs) ([]types.HistoryEntry, error) {
+					assert.Equal(t, "my-instance", args.Instance)
+					return tt.history, nil
+				},
+			}
+
+			stdout := &bytes.Buffer{}
+			err := NewApp(stdout, &bytes.Buffer{}, fc).Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
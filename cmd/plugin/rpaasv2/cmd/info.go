@@ -9,6 +9,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -29,7 +31,23 @@ func NewCmdInfo() *cli.Command {
 	return &cli.Command{
 		Name:  "info",
 		Usage: "Shows an information summary about an instance",
-		Flags: []cli.Flag{
+		Description: `The "template" output format renders a Go template (with Sprig functions)
+against the same summary printed by the "json" one, whose fields are:
+
+  .Name, .Service, .Plan                string
+  .Flavors, .BoundApps                  []string
+  .Replicas.Desired                     int32
+  .Replicas.Ready, .Replicas.Available  int32
+  .Autoscale                            nil when the instance has no autoscale
+  .Autoscale.MinReplicas, .MaxReplicas  int32
+  .Autoscale.CPU, .Memory, .RPS         *int32 (nil when unset)
+  .Autoscale.Schedules                  int
+  .Certificates                         list of .Name, .DNSNames, .ExpiresAt and .Expired
+
+For instance:
+
+  rpaasv2 info -i my-instance -o template --template '{{ .Replicas.Ready }}/{{ .Replicas.Desired }}'`,
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "service",
 				Aliases: []string{"tsuru-service", "s"},
@@ -44,10 +62,19 @@ func NewCmdInfo() *cli.Command {
 			&cli.BoolFlag{
 				Name:    "raw-output",
 				Aliases: []string{"r", "raw"},
-				Usage:   "show as JSON instead of the predefined format",
+				Usage:   "show the raw API response as JSON instead of the predefined format",
 				Value:   false,
 			},
-		},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "the output format (one of: json, template, wide), where json and template have a stable schema meant for scripts",
+			},
+			&cli.BoolFlag{
+				Name:  "stats",
+				Usage: "show the current NGINX connections of each pod",
+			},
+		}, append(outputTemplateFlags(), outputFieldFlags()...)...),
 		Before: setupClient,
 		Action: runInfo,
 	}
@@ -59,8 +86,11 @@ func newFuncMap() template.FuncMap {
 		"formatRoutes":       writeInfoRoutesOnTableFormat,
 		"formatAddresses":    writeAddressesOnTableFormat,
 		"formatBinds":        writeBindsOnTableFormat,
+		"formatBindsStatus":  writeBindsStatusOnTableFormat,
 		"formatAutoscale":    writeAutoscaleOnTableFormat,
 		"formatPods":         writePodsOnTableFormat,
+		"formatPlacement":    writePodsPlacementOnTableFormat,
+		"formatConnections":  writeConnectionStatsOnTableFormat,
 		"formatPodErrors":    writePodErrorsOnTableFormat,
 		"formatCertificates": writeCertificatesOnTableFormat,
 		"formatEvents":       writeEventsOnTableFormat,
@@ -100,12 +130,22 @@ Plan overrides:
 {{ mustToPrettyJson . }}
 {{- end }}
 
-Pods: (current: {{ len .Pods }}{{ if not .Autoscale }} / desired: {{ .Replicas }}{{ end }})
+Pods: (current: {{ len .Pods }}{{ if not .Autoscale }} / desired: {{ .Summary.Replicas.Desired }}{{ end }})
 {{- with .Pods }}
 {{ formatPods . }}
 {{ formatPodErrors . }}
 {{- end }}
 
+{{- if and .Wide .Pods }}
+Placement:
+{{ formatPlacement .Pods }}
+{{- end }}
+
+{{- with .Stats }}
+Connections:
+{{ formatConnections . }}
+{{- end }}
+
 {{- with .Autoscale }}
 Autoscale:
 {{ formatAutoscale . }}
@@ -116,9 +156,12 @@ ACLs:
 {{ formatACLs . }}
 {{- end }}
 
-{{- with .Binds }}
+{{- if .BindsStatus }}
 Binds:
-{{ formatBinds . }}
+{{ formatBindsStatus .BindsStatus }}
+{{- else if .Binds }}
+Binds:
+{{ formatBinds .Binds }}
 {{- end }}
 
 {{- with .Addresses }}
@@ -153,6 +196,102 @@ Events:
 {{- /* end template */ -}}
 `))
 
+// instanceInfoView is the data rendered by instanceInfoTemplate.
+type instanceInfoView struct {
+	*clientTypes.InstanceInfo
+
+	// Wide indicates whether the pods placement should be shown.
+	Wide bool
+
+	// Stats holds the NGINX connections of each pod, if requested.
+	Stats []clientTypes.PodConnectionStats
+
+	// BindsStatus holds the health of the bound apps, when available.
+	BindsStatus []clientTypes.BindStatus
+
+	// Summary is the same summary shown by --output json.
+	Summary *rpaasclient.InstanceInfo
+}
+
+func writeConnectionStatsOnTableFormat(stats []clientTypes.PodConnectionStats) string {
+	var total clientTypes.ConnectionStats
+	var data [][]string
+	for _, s := range stats {
+		if s.Stats == nil {
+			data = append(data, []string{s.Pod, "unknown", "unknown", "unknown", "unknown"})
+			continue
+		}
+
+		total.Active += s.Stats.Active
+		total.Reading += s.Stats.Reading
+		total.Writing += s.Stats.Writing
+		total.Waiting += s.Stats.Waiting
+
+		data = append(data, []string{
+			s.Pod,
+			strconv.FormatInt(s.Stats.Active, 10),
+			strconv.FormatInt(s.Stats.Reading, 10),
+			strconv.FormatInt(s.Stats.Writing, 10),
+			strconv.FormatInt(s.Stats.Waiting, 10),
+		})
+	}
+
+	var buffer bytes.Buffer
+	table := tablewriter.NewWriter(&buffer)
+	table.SetHeader([]string{"Pod", "Active", "Reading", "Writing", "Waiting"})
+	table.SetFooter([]string{
+		"Total",
+		strconv.FormatInt(total.Active, 10),
+		strconv.FormatInt(total.Reading, 10),
+		strconv.FormatInt(total.Writing, 10),
+		strconv.FormatInt(total.Waiting, 10),
+	})
+	table.SetAutoWrapText(true)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetFooterAlignment(tablewriter.ALIGN_LEFT)
+	table.AppendBulk(data)
+	table.Render()
+
+	return buffer.String()
+}
+
+func writePodsPlacementOnTableFormat(pods []clientTypes.Pod) string {
+	pods = append([]clientTypes.Pod{}, pods...)
+	sort.SliceStable(pods, func(i, j int) bool {
+		if pods[i].Zone != pods[j].Zone {
+			return pods[i].Zone < pods[j].Zone
+		}
+
+		return pods[i].NodeName < pods[j].NodeName
+	})
+
+	var data [][]string
+	for _, pod := range pods {
+		node, zone := pod.NodeName, pod.Zone
+		if node == "" {
+			node = "<unscheduled>"
+		}
+
+		if zone == "" {
+			zone = "<unknown>"
+		}
+
+		data = append(data, []string{zone, node, pod.Name, pod.IP})
+	}
+
+	var buffer bytes.Buffer
+	table := tablewriter.NewWriter(&buffer)
+	table.SetHeader([]string{"Zone", "Node", "Pod", "IP"})
+	table.SetAutoWrapText(true)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.AppendBulk(data)
+	table.Render()
+
+	return buffer.String()
+}
+
 func writePodsOnTableFormat(pods []clientTypes.Pod) string {
 	if len(pods) == 0 {
 		return ""
@@ -267,7 +406,7 @@ func writePodErrorsOnTableFormat(pods []clientTypes.Pod) string {
 
 func writeAutoscaleOnTableFormat(a *autogenerated.Autoscale) string {
 	var buffer bytes.Buffer
-	writeAutoscale(&buffer, a)
+	writeAutoscale(&buffer, a, 0)
 	return buffer.String()
 }
 
@@ -397,6 +536,20 @@ func runInfo(c *cli.Context) error {
 		return err
 	}
 
+	output := c.String("output")
+	if output != "" && output != "json" && output != "template" && output != "wide" {
+		return fmt.Errorf("unsupported output format %q (one of: json, template, wide)", output)
+	}
+
+	tmpl, err := parseOutputTemplate(c)
+	if err != nil {
+		return err
+	}
+
+	if err = checkOutputField(c, output); err != nil {
+		return err
+	}
+
 	info := rpaasclient.InfoArgs{
 		Instance: c.String("instance"),
 		Raw:      c.Bool("raw-output"),
@@ -411,9 +564,35 @@ func runInfo(c *cli.Context) error {
 		return writeInfoOnJSONFormat(c.App.Writer, infoPayload)
 	}
 
+	summary := rpaasclient.NewInstanceInfo(infoPayload, timeNow())
+	if output == "json" {
+		return writeJSONOutput(c, summary)
+	}
+
+	if tmpl != nil {
+		return writeOutputTemplate(c.App.Writer, tmpl, summary)
+	}
+
+	view := instanceInfoView{InstanceInfo: infoPayload, Wide: output == "wide", Summary: summary}
+	if c.Bool("stats") {
+		view.Stats, err = client.GetConnectionStats(c.Context, rpaasclient.ConnectionStatsArgs{Instance: info.Instance})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(infoPayload.Binds) > 0 {
+		// NOTE: the binds are still shown (without their health) when it
+		// cannot be checked, e.g. by older API servers.
+		view.BindsStatus, err = client.ListBinds(c.Context, rpaasclient.ListBindsArgs{Instance: info.Instance})
+		if err != nil {
+			fmt.Fprintf(c.App.ErrWriter, "WARNING: could not check the health of the bound apps: %v\n", err)
+		}
+	}
+
 	writer := newPagerWriter(c.App.Writer)
 
-	err = instanceInfoTemplate.Execute(writer, infoPayload)
+	err = instanceInfoTemplate.Execute(writer, view)
 	if err != nil {
 		return err
 	}
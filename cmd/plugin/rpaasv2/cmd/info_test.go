@@ -7,6 +7,8 @@ package cmd
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -690,6 +692,144 @@ Pods: (current: 2 / desired: 3)
 | my-instance-75c8bdc6b9-bcdef | 169.254.1.101 | Terminating |        0 | 12h |
 +------------------------------+---------------+-------------+----------+-----+
 
+`,
+		},
+
+		{
+			name: "when info route is successful and on wide format",
+			args: []string{"./rpaasv2", "info", "-i", "my-instance", "-o", "wide"},
+			client: &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*clientTypes.InstanceInfo, error) {
+					return &clientTypes.InstanceInfo{
+						Name:     "my-instance",
+						Plan:     "basic",
+						Replicas: autogenerated.PtrInt32(3),
+						Pods: []clientTypes.Pod{
+							{Name: "my-instance-abc", IP: "10.0.0.1", HostIP: "169.254.1.1", NodeName: "node-b", Zone: "zone-b", Ready: true, CreatedAt: time.Now().In(time.UTC).Add(-12 * time.Hour)},
+							{Name: "my-instance-def", IP: "10.0.0.2", HostIP: "169.254.1.2", NodeName: "node-a", Zone: "zone-a", Ready: true, CreatedAt: time.Now().In(time.UTC).Add(-12 * time.Hour)},
+							{Name: "my-instance-ghi", Status: "Pending", CreatedAt: time.Now().In(time.UTC).Add(-12 * time.Hour)},
+						},
+					}, nil
+				},
+			},
+			expected: `Name: my-instance
+Description: 
+Tags: 
+Team owner: 
+Plan: basic
+Flavors: 
+
+Pods: (current: 3 / desired: 3)
++-----------------+-------------+---------+----------+-----+
+| Name            | Host        | Status  | Restarts | Age |
++-----------------+-------------+---------+----------+-----+
+| my-instance-abc | 169.254.1.1 | Ready   |        0 | 12h |
+| my-instance-def | 169.254.1.2 | Ready   |        0 | 12h |
+| my-instance-ghi |             | Pending |        0 | 12h |
++-----------------+-------------+---------+----------+-----+
+
+
+Placement:
++-----------+---------------+-----------------+----------+
+| Zone      | Node          | Pod             | IP       |
++-----------+---------------+-----------------+----------+
+| <unknown> | <unscheduled> | my-instance-ghi |          |
+| zone-a    | node-a        | my-instance-def | 10.0.0.2 |
+| zone-b    | node-b        | my-instance-abc | 10.0.0.1 |
++-----------+---------------+-----------------+----------+
+`,
+		},
+		{
+			name: "when info route is successful and connection stats are requested",
+			args: []string{"./rpaasv2", "info", "-i", "my-instance", "--stats"},
+			client: &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*clientTypes.InstanceInfo, error) {
+					return &clientTypes.InstanceInfo{
+						Name:     "my-instance",
+						Plan:     "basic",
+						Replicas: autogenerated.PtrInt32(3),
+						Pods: []clientTypes.Pod{
+							{Name: "my-instance-abc", HostIP: "169.254.1.1", Ready: true, CreatedAt: time.Now().In(time.UTC).Add(-12 * time.Hour)},
+							{Name: "my-instance-def", HostIP: "169.254.1.2", Ready: true, CreatedAt: time.Now().In(time.UTC).Add(-12 * time.Hour)},
+							{Name: "my-instance-ghi", HostIP: "169.254.1.3", Ready: true, CreatedAt: time.Now().In(time.UTC).Add(-12 * time.Hour)},
+						},
+					}, nil
+				},
+				FakeGetConnectionStats: func(args client.ConnectionStatsArgs) ([]clientTypes.PodConnectionStats, error) {
+					require.Equal(t, "my-instance", args.Instance)
+					return []clientTypes.PodConnectionStats{
+						{Pod: "my-instance-abc", Stats: &clientTypes.ConnectionStats{Active: 10, Reading: 1, Writing: 3, Waiting: 6}},
+						{Pod: "my-instance-def", Stats: &clientTypes.ConnectionStats{Active: 5, Reading: 0, Writing: 1, Waiting: 4}},
+						{Pod: "my-instance-ghi", Error: "connection refused"},
+					}, nil
+				},
+			},
+			expected: `Name: my-instance
+Description: 
+Tags: 
+Team owner: 
+Plan: basic
+Flavors: 
+
+Pods: (current: 3 / desired: 3)
++-----------------+-------------+--------+----------+-----+
+| Name            | Host        | Status | Restarts | Age |
++-----------------+-------------+--------+----------+-----+
+| my-instance-abc | 169.254.1.1 | Ready  |        0 | 12h |
+| my-instance-def | 169.254.1.2 | Ready  |        0 | 12h |
+| my-instance-ghi | 169.254.1.3 | Ready  |        0 | 12h |
++-----------------+-------------+--------+----------+-----+
+
+
+Connections:
++-----------------+---------+---------+---------+---------+
+| Pod             | Active  | Reading | Writing | Waiting |
++-----------------+---------+---------+---------+---------+
+| my-instance-abc |      10 |       1 |       3 |       6 |
+| my-instance-def |       5 |       0 |       1 |       4 |
+| my-instance-ghi | unknown | unknown | unknown | unknown |
++-----------------+---------+---------+---------+---------+
+| Total           | 15      | 1       | 4       | 10      |
++-----------------+---------+---------+---------+---------+
+`,
+		},
+		{
+			name:          "with an unsupported output format",
+			args:          []string{"./rpaasv2", "info", "-i", "my-instance", "-o", "xml"},
+			expectedError: `unsupported output format "xml" (one of: json, template, wide)`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name: "with the stable json output",
+			args: []string{"./rpaasv2", "info", "-s", "my-service", "-i", "my-instance", "-o", "json"},
+			client: &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*clientTypes.InstanceInfo, error) {
+					return &clientTypes.InstanceInfo{
+						Name:     "my-instance",
+						Service:  "my-service",
+						Plan:     "basic",
+						Replicas: autogenerated.PtrInt32(1),
+						Pods:     []clientTypes.Pod{{Name: "my-instance-abc", Status: "Running", Ready: true}},
+						Binds:    []v1alpha1.Bind{{Name: "some-app", Host: "some-app.example.com"}},
+					}, nil
+				},
+			},
+			expected: `{
+	"name": "my-instance",
+	"service": "my-service",
+	"plan": "basic",
+	"flavors": [],
+	"replicas": {
+		"desired": 1,
+		"ready": 1,
+		"available": 1
+	},
+	"autoscale": null,
+	"certificates": [],
+	"boundApps": [
+		"some-app"
+	]
+}
 `,
 		},
 
@@ -760,3 +900,148 @@ Pods: (current: 2 / desired: 3)
 		})
 	}
 }
+
+func TestInfoTemplate(t *testing.T) {
+	templateFile := filepath.Join(t.TempDir(), "info.tmpl")
+	require.NoError(t, os.WriteFile(templateFile, []byte("{{ .Name }}: {{ join \",\" .BoundApps }}\n"), 0644))
+
+	fakeClient := &fake.FakeClient{
+		FakeInfo: func(args client.InfoArgs) (*clientTypes.InstanceInfo, error) {
+			return &clientTypes.InstanceInfo{
+				Name:     args.Instance,
+				Replicas: autogenerated.PtrInt32(3),
+				Pods: []clientTypes.Pod{
+					{Name: "my-instance-abc", Status: "Running", Ready: true},
+					{Name: "my-instance-def", Status: "Running", Ready: true},
+					{Name: "my-instance-ghi", Status: "Pending"},
+				},
+				Binds: []v1alpha1.Bind{{Name: "app1"}, {Name: "app2"}},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "with an inline template",
+			args:     []string{"./rpaasv2", "info", "-i", "my-instance", "-o", "template", "--template", "{{ .Replicas.Ready }}/{{ .Replicas.Desired }}"},
+			expected: "2/3\n",
+		},
+		{
+			name:     "with a template file",
+			args:     []string{"./rpaasv2", "info", "-i", "my-instance", "-o", "template", "--template-file", templateFile},
+			expected: "my-instance: app1,app2\n",
+		},
+		{
+			name:          "without any template",
+			args:          []string{"./rpaasv2", "info", "-i", "my-instance", "-o", "template"},
+			expectedError: "--output template requires either --template or --template-file",
+		},
+		{
+			name:          "with both template and template file",
+			args:          []string{"./rpaasv2", "info", "-i", "my-instance", "-o", "template", "--template", "{{ .Name }}", "--template-file", templateFile},
+			expectedError: "--template cannot be used along with --template-file",
+		},
+		{
+			name:          "with template but another output format",
+			args:          []string{"./rpaasv2", "info", "-i", "my-instance", "--template", "{{ .Name }}"},
+			expectedError: "--template and --template-file can only be used along with --output template",
+		},
+		{
+			name:          "with a template that cannot be parsed",
+			args:          []string{"./rpaasv2", "info", "-i", "my-instance", "-o", "template", "--template", "{{ .Name "},
+			expectedError: "could not parse the output template: template: --template:1: unclosed action",
+		},
+		{
+			name:          "with a template referring to an unknown field",
+			args:          []string{"./rpaasv2", "info", "-i", "my-instance", "-o", "template", "--template", "{{ .ReadyReplicas }}"},
+			expectedError: `could not execute the output template: template: --template:1:3: executing "--template" at <.ReadyReplicas>: can't evaluate field ReadyReplicas in type *client.InstanceInfo`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			err := NewApp(stdout, &bytes.Buffer{}, fakeClient).Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
+
+func TestInfoBindsStatus(t *testing.T) {
+	newFakeClient := func(listBinds func(args client.ListBindsArgs) ([]clientTypes.BindStatus, error)) *fake.FakeClient {
+		return &fake.FakeClient{
+			FakeInfo: func(args client.InfoArgs) (*clientTypes.InstanceInfo, error) {
+				return &clientTypes.InstanceInfo{
+					Name:  "my-instance",
+					Plan:  "basic",
+					Binds: []v1alpha1.Bind{{Name: "app1", Host: "app1.tsuru.example.com"}, {Name: "app2", Host: "app2.tsuru.example.com"}},
+				}, nil
+			},
+			FakeListBinds: listBinds,
+		}
+	}
+
+	t.Run("showing the health of the bound apps", func(t *testing.T) {
+		fakeClient := newFakeClient(func(args client.ListBindsArgs) ([]clientTypes.BindStatus, error) {
+			assert.Equal(t, "my-instance", args.Instance)
+			return []clientTypes.BindStatus{
+				{App: "app1", Address: "app1.tsuru.example.com", Healthy: true},
+				{App: "app2", Address: "app2.tsuru.example.com"},
+			}, nil
+		})
+
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		err := NewApp(stdout, stderr, fakeClient).Run([]string{"./rpaasv2", "info", "-i", "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, `Name: my-instance
+Description: 
+Tags: 
+Team owner: 
+Plan: basic
+Flavors: 
+
+Pods: (current: 0 / desired: 0)
+Binds:
++------+------------------------+-----------+
+| App  | Address                | Health    |
++------+------------------------+-----------+
+| app1 | app1.tsuru.example.com | healthy   |
++------+------------------------+-----------+
+| app2 | app2.tsuru.example.com | unhealthy |
++------+------------------------+-----------+
+WARNING: 1 of 2 bound app(s) unhealthy
+`, stdout.String())
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("when the health cannot be checked", func(t *testing.T) {
+		fakeClient := newFakeClient(func(args client.ListBindsArgs) ([]clientTypes.BindStatus, error) {
+			return nil, fmt.Errorf("some error")
+		})
+
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		err := NewApp(stdout, stderr, fakeClient).Run([]string{"./rpaasv2", "info", "-i", "my-instance"})
+		require.NoError(t, err)
+		assert.Contains(t, stdout.String(), `Binds:
++------+------------------------+
+| App  | Address                |
++------+------------------------+
+| app1 | app1.tsuru.example.com |
++------+------------------------+
+| app2 | app2.tsuru.example.com |
++------+------------------------+
+`)
+		assert.Equal(t, "WARNING: could not check the health of the bound apps: some error\n", stderr.String())
+	})
+}
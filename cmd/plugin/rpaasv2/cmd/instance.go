@@ -0,0 +1,297 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+)
+
+func NewCmdInstance() *cli.Command {
+	return &cli.Command{
+		Name:    "instance",
+		Aliases: []string{"instances"},
+		Usage:   "Manages instances straight on the RPaaS API (i.e. for deployments without Tsuru)",
+		Subcommands: []*cli.Command{
+			NewCmdCreateInstance(),
+			NewCmdDeleteInstance(),
+		},
+	}
+}
+
+func NewCmdCreateInstance() *cli.Command {
+	return &cli.Command{
+		Name:  "create",
+		Usage: "Creates an instance",
+		Description: `
+# Create an instance using the default plan, waiting until it's ready:
+rpaasv2 --rpaas-url https://rpaas.example.com instance create -i my-instance --team my-team --wait
+
+# Create an instance with a plan and flavors:
+rpaasv2 --rpaas-url https://rpaas.example.com instance create -i my-instance --team my-team --plan small --flavor strawberry --flavor chocolate
+`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "team",
+				Aliases:  []string{"t"},
+				Usage:    "the team which owns the instance",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "plan",
+				Aliases: []string{"p"},
+				Usage:   "the plan of the instance (default: the default plan of the API)",
+			},
+			&cli.StringSliceFlag{
+				Name:    "flavor",
+				Aliases: []string{"f"},
+				Usage:   "flavor applied to the instance (can be used multiple times)",
+			},
+			&cli.StringFlag{
+				Name:    "description",
+				Aliases: []string{"d"},
+				Usage:   "a description of the instance",
+			},
+			&cli.StringSliceFlag{
+				Name:  "tag",
+				Usage: "tag of the instance (can be used multiple times)",
+			},
+			&cli.BoolFlag{
+				Name:  "wait",
+				Usage: "whether should wait until the instance replicas are ready",
+			},
+			&cli.DurationFlag{
+				Name:  "wait-timeout",
+				Usage: "time limit to wait for the instance to become ready (requires --wait)",
+			},
+		},
+		Before: requireDirectAPI,
+		Action: runCreateInstance,
+	}
+}
+
+// requireDirectAPI refuses to run commands which are only supported when
+// talking to the RPaaS API straight, since Tsuru manages the instances on its
+// own.
+func requireDirectAPI(c *cli.Context) error {
+	if c.String("rpaas-url") == "" {
+		return fmt.Errorf("instances can only be managed straight on the RPaaS API (see --rpaas-url), use the Tsuru service instance commands otherwise")
+	}
+
+	return setupClient(c)
+}
+
+func runCreateInstance(c *cli.Context) error {
+	if c.IsSet("wait-timeout") && !c.Bool("wait") {
+		return fmt.Errorf("--wait-timeout can only be used along with --wait")
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	args := rpaasclient.CreateInstanceArgs{
+		Instance:    c.String("instance"),
+		Team:        c.String("team"),
+		Plan:        c.String("plan"),
+		Description: c.String("description"),
+		Tags:        c.StringSlice("tag"),
+		Flavors:     c.StringSlice("flavor"),
+	}
+
+	if err = validatePlanAndFlavors(c, client, args.Plan, args.Flavors); err != nil {
+		return err
+	}
+
+	if err = client.CreateInstance(c.Context, args); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Instance %q created\n", args.Instance)
+
+	if !c.Bool("wait") {
+		return nil
+	}
+
+	return waitForInstanceReady(c.Context, client, c.App.Writer, args.Instance, c.Duration("wait-timeout"))
+}
+
+// validatePlanAndFlavors checks the plan and flavors exist on the API before
+// creating the instance, listing the available ones otherwise.
+func validatePlanAndFlavors(c *cli.Context, client rpaasclient.Client, plan string, flavors []string) error {
+	if plan != "" {
+		plans, err := client.GetPlans(c.Context, "")
+		if err != nil {
+			return err
+		}
+
+		var names []string
+		for _, p := range plans {
+			names = append(names, p.Name)
+		}
+
+		if !slices.Contains(names, plan) {
+			return fmt.Errorf("plan %q not found (available: %s)", plan, formatAvailable(names))
+		}
+	}
+
+	if len(flavors) == 0 {
+		return nil
+	}
+
+	available, err := client.ListFlavors(c.Context, rpaasclient.ListFlavorsArgs{})
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, f := range available {
+		names = append(names, f.Name)
+	}
+
+	for _, f := range flavors {
+		if !slices.Contains(names, f) {
+			return fmt.Errorf("flavor %q not found (available: %s)", f, formatAvailable(names))
+		}
+	}
+
+	return nil
+}
+
+func formatAvailable(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+
+	sorted := slices.Clone(names)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+// waitForInstanceReady polls the instance info until every desired replica
+// of the new instance is ready. The instance may not show up right after
+// being created, so not found errors are handled as not ready yet.
+func waitForInstanceReady(ctx context.Context, client rpaasclient.Client, w io.Writer, instance string, timeout time.Duration) error {
+	var ready, replicas int32
+	lastReady := int32(-1)
+	err := pollUntil(ctx, timeout, func(ctx context.Context) (bool, error) {
+		info, err := client.Info(ctx, rpaasclient.InfoArgs{Instance: instance})
+		if rpaasclient.IsNotFoundError(err) {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		if info.Replicas == nil {
+			return false, nil
+		}
+
+		var total int32
+		replicas = *info.Replicas
+		ready, total = countReplicas(info.Pods, nil)
+		if ready != lastReady {
+			fmt.Fprintf(w, "Waiting for replicas: %d of %d ready\n", ready, replicas)
+			lastReady = ready
+		}
+
+		return ready == replicas && total == replicas, nil
+	})
+	if errors.Is(err, errPollTimeout) {
+		return fmt.Errorf("timed out waiting for the instance to be ready: %d of %d replica(s) ready", ready, replicas)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Instance %q is ready\n", instance)
+	return nil
+}
+
+func NewCmdDeleteInstance() *cli.Command {
+	return &cli.Command{
+		Name:    "delete",
+		Aliases: []string{"remove"},
+		Usage:   "Deletes an instance",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "deletes the instance without asking for confirmation",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "deletes the instance even if there are apps bound to it",
+			},
+		},
+		Before: requireDirectAPI,
+		Action: runDeleteInstance,
+	}
+}
+
+func runDeleteInstance(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	instance := c.String("instance")
+	info, err := client.Info(c.Context, rpaasclient.InfoArgs{Instance: instance})
+	if err != nil {
+		return err
+	}
+
+	if len(info.Binds) > 0 {
+		var apps []string
+		for _, b := range info.Binds {
+			apps = append(apps, b.Name)
+		}
+
+		fmt.Fprintf(c.App.ErrWriter, "WARNING: instance %q is bound to the following app(s): %s\n", instance, strings.Join(apps, ", "))
+
+		if !c.Bool("force") {
+			return fmt.Errorf("refusing to delete an instance with bound apps, unbind them before or use --force")
+		}
+	}
+
+	if !c.Bool("yes") {
+		confirmed, err := askForConfirmation(c, fmt.Sprintf("Are you sure you want to delete the instance %q?", instance))
+		if err != nil || !confirmed {
+			return err
+		}
+	}
+
+	if err = client.DeleteInstance(c.Context, rpaasclient.DeleteInstanceArgs{Instance: instance}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Instance %q deleted\n", instance)
+	return nil
+}
@@ -0,0 +1,205 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/api/v1alpha1"
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestCreateInstance(t *testing.T) {
+	defer func(d time.Duration) { waitPollInterval = d }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
+	plans := func(instance string) ([]types.Plan, error) {
+		return []types.Plan{{Name: "small"}, {Name: "large"}}, nil
+	}
+
+	flavors := func(args rpaasclient.ListFlavorsArgs) ([]types.Flavor, error) {
+		return []types.Flavor{{Name: "strawberry"}, {Name: "chocolate"}}, nil
+	}
+
+	int32Ptr := func(n int32) *int32 { return &n }
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        rpaasclient.Client
+	}{
+		{
+			name:          "when not talking to the RPaaS API directly",
+			args:          []string{"./rpaasv2", "instance", "create", "-i", "my-instance", "--team", "my-team"},
+			expectedError: "instances can only be managed straight on the RPaaS API (see --rpaas-url), use the Tsuru service instance commands otherwise",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when plan does not exist",
+			args:          []string{"./rpaasv2", "--rpaas-url", "http://rpaas.example.com", "instance", "create", "-i", "my-instance", "--team", "my-team", "--plan", "medium"},
+			expectedError: `plan "medium" not found (available: large, small)`,
+			client:        &fake.FakeClient{FakeGetPlans: plans},
+		},
+		{
+			name:          "when flavor does not exist",
+			args:          []string{"./rpaasv2", "--rpaas-url", "http://rpaas.example.com", "instance", "create", "-i", "my-instance", "--team", "my-team", "--flavor", "vanilla"},
+			expectedError: `flavor "vanilla" not found (available: chocolate, strawberry)`,
+			client:        &fake.FakeClient{FakeListFlavors: flavors},
+		},
+		{
+			name:     "when creating an instance",
+			args:     []string{"./rpaasv2", "--rpaas-url", "http://rpaas.example.com", "instance", "create", "-i", "my-instance", "--team", "my-team", "--plan", "small", "--flavor", "strawberry", "--tag", "a=b", "--description", "My instance"},
+			expected: "Instance \"my-instance\" created\n",
+			client: &fake.FakeClient{
+				FakeGetPlans:    plans,
+				FakeListFlavors: flavors,
+				FakeCreateInstance: func(args rpaasclient.CreateInstanceArgs) error {
+					assert.Equal(t, rpaasclient.CreateInstanceArgs{
+						Instance:    "my-instance",
+						Team:        "my-team",
+						Plan:        "small",
+						Description: "My instance",
+						Tags:        []string{"a=b"},
+						Flavors:     []string{"strawberry"},
+					}, args)
+					return nil
+				},
+			},
+		},
+		{
+			name:     "when waiting for the instance to be ready",
+			args:     []string{"./rpaasv2", "--rpaas-url", "http://rpaas.example.com", "instance", "create", "-i", "my-instance", "--team", "my-team", "--wait"},
+			expected: "Instance \"my-instance\" created\nWaiting for replicas: 0 of 2 ready\nWaiting for replicas: 2 of 2 ready\nInstance \"my-instance\" is ready\n",
+			client: func() rpaasclient.Client {
+				var calls int
+				return &fake.FakeClient{
+					FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+						calls++
+						switch calls {
+						case 1:
+							return nil, &rpaasclient.ErrUnexpectedStatusCode{Status: 404}
+						case 2:
+							return &types.InstanceInfo{Replicas: int32Ptr(2)}, nil
+						default:
+							return &types.InstanceInfo{Replicas: int32Ptr(2), Pods: []types.Pod{{Name: "pod-1", Ready: true}, {Name: "pod-2", Ready: true}}}, nil
+						}
+					},
+				}
+			}(),
+		},
+		{
+			name:          "when waiting times out",
+			args:          []string{"./rpaasv2", "--rpaas-url", "http://rpaas.example.com", "instance", "create", "-i", "my-instance", "--team", "my-team", "--wait", "--wait-timeout", "10ms"},
+			expectedError: "timed out waiting for the instance to be ready: 0 of 1 replica(s) ready",
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{Replicas: int32Ptr(1), Pods: []types.Pod{{Name: "pod-1"}}}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			app := NewApp(stdout, &bytes.Buffer{}, tt.client)
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
+
+func TestDeleteInstance(t *testing.T) {
+	bound := func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+		return &types.InstanceInfo{Binds: []v1alpha1.Bind{{Name: "app1", Host: "app1.example.com"}}}, nil
+	}
+
+	tests := []struct {
+		name           string
+		args           []string
+		stdin          string
+		expected       string
+		expectedStderr string
+		expectedError  string
+		deleted        bool
+		info           func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error)
+	}{
+		{
+			name:     "when confirmed",
+			args:     []string{"./rpaasv2", "--rpaas-url", "http://rpaas.example.com", "instance", "delete", "-i", "my-instance"},
+			stdin:    "y\n",
+			expected: "Are you sure you want to delete the instance \"my-instance\"? (y/N) Instance \"my-instance\" deleted\n",
+			deleted:  true,
+		},
+		{
+			name:     "when not confirmed",
+			args:     []string{"./rpaasv2", "--rpaas-url", "http://rpaas.example.com", "instance", "delete", "-i", "my-instance"},
+			stdin:    "\n",
+			expected: "Are you sure you want to delete the instance \"my-instance\"? (y/N) Aborted.\n",
+		},
+		{
+			name:           "when there are bound apps",
+			args:           []string{"./rpaasv2", "--rpaas-url", "http://rpaas.example.com", "instance", "delete", "-i", "my-instance", "-y"},
+			expectedStderr: "WARNING: instance \"my-instance\" is bound to the following app(s): app1\n",
+			expectedError:  "refusing to delete an instance with bound apps, unbind them before or use --force",
+			info:           bound,
+		},
+		{
+			name:           "when there are bound apps and it is forced",
+			args:           []string{"./rpaasv2", "--rpaas-url", "http://rpaas.example.com", "instance", "delete", "-i", "my-instance", "-y", "--force"},
+			expected:       "Instance \"my-instance\" deleted\n",
+			expectedStderr: "WARNING: instance \"my-instance\" is bound to the following app(s): app1\n",
+			deleted:        true,
+			info:           bound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deleted bool
+			client := &fake.FakeClient{
+				FakeInfo: tt.info,
+				FakeDeleteInstance: func(args rpaasclient.DeleteInstanceArgs) error {
+					assert.Equal(t, rpaasclient.DeleteInstanceArgs{Instance: "my-instance"}, args)
+					deleted = true
+					return nil
+				},
+			}
+			if client.FakeInfo == nil {
+				client.FakeInfo = func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{}, nil
+				}
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			app := NewApp(stdout, stderr, client)
+			app.Reader = strings.NewReader(tt.stdin)
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+			assert.Equal(t, tt.deleted, deleted)
+		})
+	}
+}
@@ -5,11 +5,40 @@
 package cmd
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/hashicorp/go-multierror"
 	"github.com/urfave/cli/v2"
 
+	"github.com/tsuru/rpaas-operator/cmd/plugin/rpaasv2/theme"
 	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/util"
 )
 
+// notifyLogSignals returns a copy of ctx canceled once the user interrupts
+// the logs, e.g. by hitting Ctrl-C.
+var notifyLogSignals = func(ctx context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+}
+
 func NewCmdLogs() *cli.Command {
 	return &cli.Command{
 		Name:    "logs",
@@ -31,19 +60,35 @@ func NewCmdLogs() *cli.Command {
 				Aliases: []string{"p"},
 				Usage:   "specific pod to log from (default: all pods from instance)",
 			},
+			&cli.BoolFlag{
+				Name:  "running-only",
+				Usage: "only shows logs from pods in the Running phase, newly running pods are picked up while following (overridden by --pod)",
+			},
 			&cli.PathFlag{
 				Name:    "container",
 				Aliases: []string{"c"},
-				Usage:   "specific container to log from (default: all containers from pods)",
+				Usage:   "specific container to log from, it also accepts glob patterns like \"nginx*\" (default: all containers from pods)",
+			},
+			&cli.BoolFlag{
+				Name:  "container-regexp",
+				Usage: "whether the container name should be treated as a regular expression",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-pod",
+				Usage: "pod left out of the logs, it also accepts glob patterns like \"*-canary-*\" (overridden by --pod, can be used multiple times); pods started afterwards are not picked up while following",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-container",
+				Usage: "container left out of the logs, it also accepts glob patterns like \"*-sidecar\" (overridden by a --container without patterns, can be used multiple times)",
 			},
 			&cli.IntFlag{
 				Name:    "lines",
 				Aliases: []string{"l"},
-				Usage:   "number of earlier log lines to show",
+				Usage:   "number of earlier log lines to show, where 0 means no limit (ignored when --since is set)",
 			},
 			&cli.DurationFlag{
 				Name:  "since",
-				Usage: "only return logs newer than a relative duration like 5s, 2m, or 3h",
+				Usage: "only return logs newer than a relative duration like 5s, 2m, or 3h (takes precedence over --lines)",
 			},
 			&cli.BoolFlag{
 				Name:    "follow",
@@ -55,6 +100,24 @@ func NewCmdLogs() *cli.Command {
 				Aliases: []string{"no-color"},
 				Usage:   "defines whether or not to display colorful output.",
 			},
+			&cli.StringFlag{
+				Name:  "prefix",
+				Usage: "Go template rendering the prefix of every line, where .Time, .Pod and .Container are available (e.g. \"{{.Pod}}/{{.Container}}\"); prefixes are aligned and colored by pod, printing a legend of colors beforehand",
+			},
+			&cli.BoolFlag{
+				Name:  "merge",
+				Usage: fmt.Sprintf("prints the log lines from all pods and containers sorted by their timestamps rather than pod by pod, buffering up to %d lines at once (cannot be used along with --follow)", maxMergedLogLines),
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "the output format (one of: text, jsonl), where jsonl writes every log line as a JSON object with its time, pod, container and message (implies --without-color, cannot be used along with --prefix)",
+				Value:   "text",
+			},
+			&cli.PathFlag{
+				Name:  "export",
+				Usage: "writes the raw log lines into this file instead of the standard output, gzipping them if the file name ends with \".gz\" (implies --without-color, cannot be used along with --follow)",
+			},
 		},
 		Before: setupClient,
 		Action: runLogRpaas,
@@ -67,14 +130,720 @@ func runLogRpaas(c *cli.Context) error {
 		return err
 	}
 
-	return client.Log(c.Context, rpaasclient.LogArgs{
-		Out:       c.App.Writer,
-		Instance:  c.String("instance"),
-		Lines:     c.Int("lines"),
-		Since:     c.Duration("since"),
-		Follow:    c.Bool("follow"),
-		Pod:       c.String("pod"),
-		Container: c.String("container"),
-		Color:     !c.Bool("without-color"),
+	lines, since, err := logWindowFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	args := rpaasclient.LogArgs{
+		Out:         c.App.Writer,
+		Instance:    c.String("instance"),
+		Lines:       lines,
+		Since:       since,
+		Follow:      c.Bool("follow"),
+		Pod:         c.String("pod"),
+		Container:   c.String("container"),
+		Color:       themeFromFlags(c).Enabled(),
+		RunningOnly: c.Bool("running-only"),
+	}
+
+	if c.Bool("merge") && args.Follow {
+		return fmt.Errorf("--merge cannot be used along with --follow")
+	}
+
+	switch output := c.String("output"); output {
+	case "text":
+	case "jsonl":
+		if c.IsSet("prefix") {
+			return fmt.Errorf("--output jsonl cannot be used along with --prefix")
+		}
+
+		args.Color = false
+	default:
+		return fmt.Errorf("unsupported output format %q (one of: text, jsonl)", output)
+	}
+
+	if path := c.Path("export"); path != "" {
+		return exportLogs(c, client, args, path)
+	}
+
+	// NOTE: interrupting the logs cancels the requests so that their
+	// connections are closed right away, while the lines received so far
+	// still get written, leaving the terminal in a clean state.
+	parent := c.Context
+	ctx, stop := notifyLogSignals(parent)
+	defer stop()
+
+	out := util.NewLineForwarder(c.App.Writer, nil, "")
+	args.Out, c.Context = out, ctx
+	err = logRpaas(c, client, args)
+	c.Context = parent
+
+	// NOTE: the logs cut by an interruption are finished rather than
+	// failed, resetting the colors a line cut in half could leave set.
+	interrupted := ctx.Err() != nil && parent.Err() == nil
+
+	var end []byte
+	if interrupted && args.Color && !color.NoColor {
+		end = []byte("\x1b[0m")
+	}
+
+	if ferr := out.Finish(end); err == nil || interrupted {
+		err = ferr
+	}
+
+	return err
+}
+
+// logWindowFromFlags returns the number of lines and the relative time which
+// the logs should start from. Just like kubectl, --since takes precedence over
+// --lines when both are set, and --lines 0 means no line limit at all.
+func logWindowFromFlags(c *cli.Context) (int, time.Duration, error) {
+	lines, since := c.Int("lines"), c.Duration("since")
+	if lines < 0 {
+		return 0, 0, fmt.Errorf("--lines must not be negative")
+	}
+
+	if !c.IsSet("since") {
+		return lines, 0, nil
+	}
+
+	if since <= 0 {
+		return 0, 0, fmt.Errorf("--since must be a positive duration")
+	}
+
+	if c.IsSet("lines") {
+		fmt.Fprintf(c.App.ErrWriter, "WARNING: --lines is ignored since --since takes precedence over it\n")
+	}
+
+	return 0, since, nil
+}
+
+func logRpaas(c *cli.Context, client rpaasclient.Client, args rpaasclient.LogArgs) error {
+	pods, err := expandLogPods(c, client, args)
+	if err != nil {
+		return err
+	}
+
+	containers, err := expandLogContainers(c, client, args, pods)
+	if err != nil {
+		return err
+	}
+
+	if len(containers) == 1 {
+		args.Container = containers[0]
+	}
+
+	if prefix := c.String("prefix"); prefix != "" {
+		f, err := newPodLogFormatter(c.Context, client, args, pods, containers, prefix, themeFromFlags(c))
+		if err != nil {
+			return err
+		}
+
+		defer f.Flush()
+		args.Out, args.Color = f, false
+	}
+
+	if c.String("output") == "jsonl" {
+		j := newLogJSONLinesWriter(args.Out)
+		defer j.Flush()
+		args.Out = j
+	}
+
+	if c.Bool("merge") {
+		m := newLogMergeWriter(args.Out, c.App.ErrWriter)
+		defer m.Flush()
+		args.Out = m
+	}
+
+	// NOTE: the custom prefix (or the JSON object) already tells the
+	// containers apart.
+	prefixContainers := c.String("prefix") == "" && c.String("output") != "jsonl"
+
+	if len(pods) > 0 {
+		return logFromPods(c.Context, client, args, pods, containers, prefixContainers)
+	}
+
+	if len(containers) < 2 {
+		return client.Log(c.Context, args)
+	}
+
+	return logFromContainers(c.Context, client, args, containers, prefixContainers)
+}
+
+func exportLogs(c *cli.Context, client rpaasclient.Client, args rpaasclient.LogArgs, path string) error {
+	if args.Follow {
+		return fmt.Errorf("--export cannot be used along with --follow")
+	}
+
+	// NOTE: logs are written to a temporary file on the same directory, which
+	// replaces the target only once the export succeeds, so that a failed
+	// export never truncates or removes a pre-existing file.
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	cw, err := writeExportedLogs(c, client, args, f, strings.HasSuffix(path, ".gz"))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Exported %d line(s) (%d bytes) to %s\n", cw.lines, cw.bytes, path)
+	return nil
+}
+
+func writeExportedLogs(c *cli.Context, client rpaasclient.Client, args rpaasclient.LogArgs, f *os.File, gzipped bool) (*countingWriter, error) {
+	if err := f.Chmod(0644); err != nil {
+		return nil, err
+	}
+
+	var w io.Writer = f
+	var gw *gzip.Writer
+	if gzipped {
+		gw = gzip.NewWriter(f)
+		w = gw
+	}
+
+	cw := &countingWriter{w: w}
+	args.Out, args.Color = cw, false
+
+	if err := logRpaas(c, client, args); err != nil {
+		return nil, err
+	}
+
+	if gw != nil {
+		if err := gw.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	return cw, nil
+}
+
+// countingWriter counts the bytes and lines written through it.
+type countingWriter struct {
+	w     io.Writer
+	bytes int64
+	lines int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.bytes += int64(n)
+	cw.lines += int64(bytes.Count(p[:n], []byte{'\n'}))
+	return n, err
+}
+
+// expandLogPods returns the pods left by --exclude-pod, which is nil when
+// there's nothing to exclude or when a single pod is set on --pod.
+func expandLogPods(c *cli.Context, client rpaasclient.Client, args rpaasclient.LogArgs) ([]string, error) {
+	excluded := c.StringSlice("exclude-pod")
+	if err := validateLogPatterns("pod", excluded); err != nil {
+		return nil, err
+	}
+
+	if len(excluded) == 0 || args.Pod != "" {
+		return nil, nil
+	}
+
+	info, err := client.Info(c.Context, rpaasclient.InfoArgs{Instance: args.Instance})
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []string
+	for _, pod := range info.Pods {
+		if (args.RunningOnly && pod.Status != "Running") || matchesAnyLogPattern(pod.Name, excluded) {
+			continue
+		}
+
+		pods = append(pods, pod.Name)
+	}
+
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no pods left after excluding %s", strings.Join(excluded, ", "))
+	}
+
+	sort.Strings(pods)
+	return pods, nil
+}
+
+// expandLogContainers returns the containers matching --container and not
+// excluded by --exclude-container within the given pods (or all of them, when
+// nil). It's nil when every container should be logged.
+func expandLogContainers(c *cli.Context, client rpaasclient.Client, args rpaasclient.LogArgs, pods []string) ([]string, error) {
+	excluded := c.StringSlice("exclude-container")
+	if err := validateLogPatterns("container", excluded); err != nil {
+		return nil, err
+	}
+
+	pattern := args.Container
+	if pattern == "" && len(excluded) == 0 {
+		return nil, nil
+	}
+
+	isRegexp := c.Bool("container-regexp")
+	if pattern != "" && !isRegexp && !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+
+	match := func(name string) bool {
+		matched, _ := filepath.Match(pattern, name)
+		return pattern == "" || matched
+	}
+
+	if isRegexp {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid container regular expression %q: %w", pattern, err)
+		}
+
+		match = re.MatchString
+	} else if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid container pattern %q: %w", pattern, err)
+	}
+
+	info, err := client.Info(c.Context, rpaasclient.InfoArgs{Instance: args.Instance})
+	if err != nil {
+		return nil, err
+	}
+
+	var matched, excludedAny bool
+	found := make(map[string]bool)
+	for _, pod := range info.Pods {
+		if (args.Pod != "" && args.Pod != pod.Name) || (pods != nil && !slices.Contains(pods, pod.Name)) {
+			continue
+		}
+
+		for _, container := range pod.Containers {
+			if !match(container) {
+				continue
+			}
+
+			matched = true
+			if matchesAnyLogPattern(container, excluded) {
+				excludedAny = true
+				continue
+			}
+
+			found[container] = true
+		}
+	}
+
+	if !matched && pattern != "" {
+		return nil, fmt.Errorf("no container matches %q", pattern)
+	}
+
+	if pattern == "" && !excludedAny {
+		return nil, nil
+	}
+
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no containers left after excluding %s", strings.Join(excluded, ", "))
+	}
+
+	var containers []string
+	for name := range found {
+		containers = append(containers, name)
+	}
+
+	sort.Strings(containers)
+	return containers, nil
+}
+
+func validateLogPatterns(kind string, patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid %s pattern %q: %w", kind, pattern, err)
+		}
+	}
+
+	return nil
+}
+
+func matchesAnyLogPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// logFromPods streams the logs of each pod, which the API already tells
+// apart on every line, as well as of each container when there are many.
+func logFromPods(ctx context.Context, client rpaasclient.Client, args rpaasclient.LogArgs, pods, containers []string, prefixContainers bool) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs *multierror.Error
+
+	run := func(pod string) {
+		w := util.NewLineForwarder(args.Out, &mu, "")
+		defer w.Flush()
+
+		pargs := args
+		pargs.Pod, pargs.Out = pod, w
+
+		var err error
+		if len(containers) < 2 {
+			err = client.Log(ctx, pargs)
+		} else {
+			err = logFromContainers(ctx, client, pargs, containers, prefixContainers)
+		}
+
+		if err != nil {
+			mu.Lock()
+			errs = multierror.Append(errs, fmt.Errorf("pod %s: %w", pod, err))
+			mu.Unlock()
+		}
+	}
+
+	for _, pod := range pods {
+		// NOTE: follow mode never returns until the user interrupts it, so
+		// each pod must be streamed concurrently.
+		if !args.Follow {
+			run(pod)
+			continue
+		}
+
+		wg.Add(1)
+		go func(pod string) {
+			defer wg.Done()
+			run(pod)
+		}(pod)
+	}
+
+	wg.Wait()
+	return errs.ErrorOrNil()
+}
+
+func logFromContainers(ctx context.Context, client rpaasclient.Client, args rpaasclient.LogArgs, containers []string, prefixContainers bool) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs *multierror.Error
+
+	run := func(container string) {
+		var prefix string
+		if prefixContainers {
+			prefix = fmt.Sprintf("[%s] ", container)
+		}
+
+		w := util.NewLineForwarder(args.Out, &mu, prefix)
+		defer w.Flush()
+
+		cargs := args
+		cargs.Container, cargs.Out = container, w
+		if err := client.Log(ctx, cargs); err != nil {
+			mu.Lock()
+			errs = multierror.Append(errs, fmt.Errorf("container %s: %w", container, err))
+			mu.Unlock()
+		}
+	}
+
+	for _, container := range containers {
+		// NOTE: follow mode never returns until the user interrupts it, so
+		// each container must be streamed concurrently.
+		if !args.Follow {
+			run(container)
+			continue
+		}
+
+		wg.Add(1)
+		go func(container string) {
+			defer wg.Done()
+			run(container)
+		}(container)
+	}
+
+	wg.Wait()
+	return errs.ErrorOrNil()
+}
+
+// maxMergedLogLines is the most log lines buffered by --merge, so that the
+// memory usage stays bounded no matter how many lines were asked for.
+var maxMergedLogLines = 100000
+
+var (
+	// logTimeRegexp matches the time of the log lines as formatted by the API
+	// (see logLineRegexp), even if prefixed (e.g. by the container name).
+	logTimeRegexp = regexp.MustCompile(`(?:^|\s)(\d{4}-\d{2}-\d{2}T[0-9:.]+(?:Z|[+-]\d{2}:\d{2})) \[`)
+
+	// ansiEscapeRegexp matches the escape sequences coloring the log lines.
+	ansiEscapeRegexp = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+)
+
+// logMergeWriter buffers the log lines written into it and, once flushed,
+// writes them into w sorted by their timestamps. Lines without a timestamp
+// are kept along with the line before them, e.g. multi-line messages. When
+// there are more than maxMergedLogLines lines, they're sorted in batches of
+// that size, warning about it.
+type logMergeWriter struct {
+	*util.LineWriter
+
+	w    io.Writer
+	warn io.Writer
+
+	mu      sync.Mutex
+	entries []logMergeEntry
+	lines   int
+	warned  bool
+}
+
+type logMergeEntry struct {
+	time  time.Time
+	lines []byte
+}
+
+func newLogMergeWriter(w, warn io.Writer) *logMergeWriter {
+	m := &logMergeWriter{w: w, warn: warn}
+	m.LineWriter = util.NewLineWriter(&m.mu, m.add)
+	return m
+}
+
+// Flush writes every line buffered so far, the partial one included.
+func (m *logMergeWriter) Flush() error {
+	if err := m.LineWriter.Flush(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.flush()
+}
+
+func (m *logMergeWriter) add(line []byte) error {
+	if m.lines >= maxMergedLogLines {
+		if !m.warned {
+			m.warned = true
+			fmt.Fprintf(m.warn, "WARNING: there are more than %d log lines to merge, they're sorted in batches of that size (narrow them down with --lines or --since)\n", maxMergedLogLines)
+		}
+
+		if err := m.flush(); err != nil {
+			return err
+		}
+	}
+
+	m.lines++
+
+	matches := logTimeRegexp.FindSubmatch(ansiEscapeRegexp.ReplaceAll(line, nil))
+	if matches == nil && len(m.entries) > 0 {
+		last := &m.entries[len(m.entries)-1]
+		last.lines = append(last.lines, line...)
+		return nil
+	}
+
+	var t time.Time
+	if matches != nil {
+		t, _ = time.Parse(time.RFC3339Nano, string(matches[1]))
+	}
+
+	m.entries = append(m.entries, logMergeEntry{time: t, lines: append([]byte(nil), line...)})
+	return nil
+}
+
+func (m *logMergeWriter) flush() error {
+	// NOTE: the sort is stable, so lines logged at the same time keep the
+	// order of their pods.
+	sort.SliceStable(m.entries, func(i, j int) bool { return m.entries[i].time.Before(m.entries[j].time) })
+
+	for _, e := range m.entries {
+		if _, err := m.w.Write(e.lines); err != nil {
+			return err
+		}
+	}
+
+	m.entries, m.lines = nil, 0
+	return nil
+}
+
+var (
+	// logLineRegexp matches the log lines as formatted by the API, that is,
+	// "<time> [<pod>][<container>]: <message>".
+	logLineRegexp = regexp.MustCompile(`^(\S+) \[([^\]]*)\]\[([^\]]*)\]: (.*)$`)
+)
+
+// logPrefixData holds the fields available on --prefix templates.
+type logPrefixData struct {
+	Time      string
+	Pod       string
+	Container string
+}
+
+// podLogFormatter rewrites the log lines coming from the API using a custom
+// prefix, padding it to the widest prefix seen so far and painting it with a
+// color stable per pod. Lines not following the API format are written as is.
+type podLogFormatter struct {
+	*util.LineWriter
+
+	w        io.Writer
+	tmpl     *template.Template
+	colorize bool
+	theme    theme.Theme
+
+	width  int
+	colors map[string]*color.Color
+}
+
+func newPodLogFormatter(ctx context.Context, client rpaasclient.Client, args rpaasclient.LogArgs, pods, containers []string, prefix string, th theme.Theme) (*podLogFormatter, error) {
+	tmpl, err := template.New("prefix").Parse(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --prefix template: %w", err)
+	}
+
+	f := &podLogFormatter{w: args.Out, tmpl: tmpl, colorize: args.Color, theme: th, colors: make(map[string]*color.Color)}
+	f.LineWriter = util.NewLineWriter(nil, f.writeLine)
+
+	// NOTE: stern writes timestamps in RFC 3339 with nanoseconds, trimming
+	// the trailing zeros, so the longest one is taken to size the prefixes.
+	if _, err = f.render(logPrefixData{Time: "2006-01-02T15:04:05.999999999Z"}); err != nil {
+		return nil, fmt.Errorf("invalid --prefix template: %w", err)
+	}
+
+	info, err := client.Info(ctx, rpaasclient.InfoArgs{Instance: args.Instance})
+	if err != nil {
+		return nil, err
+	}
+
+	all := info.Pods
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+
+	var known []string
+	for _, pod := range all {
+		if (args.Pod != "" && args.Pod != pod.Name) || (args.RunningOnly && args.Pod == "" && pod.Status != "Running") || (pods != nil && !slices.Contains(pods, pod.Name)) {
+			continue
+		}
+
+		known = append(known, pod.Name)
+		f.podColor(pod.Name)
+
+		for _, container := range pod.Containers {
+			if !logContainerSelected(container, args.Container, containers) {
+				continue
+			}
+
+			p, _ := f.render(logPrefixData{Time: "2006-01-02T15:04:05.999999999Z", Pod: pod.Name, Container: container})
+			if n := utf8.RuneCountInString(p); n > f.width {
+				f.width = n
+			}
+		}
+	}
+
+	if f.colorize && len(known) > 0 {
+		legend := make([]string, 0, len(known))
+		for i, pod := range known {
+			legend = append(legend, f.colors[pod].Sprintf("%s=%s", f.theme.PodColor(i).Name, pod))
+		}
+
+		if _, err = fmt.Fprintf(f.w, "Colors: %s\n", strings.Join(legend, ", ")); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func logContainerSelected(container, selected string, containers []string) bool {
+	if len(containers) > 1 {
+		for _, c := range containers {
+			if c == container {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return selected == "" || selected == container
+}
+
+func (f *podLogFormatter) writeLine(line []byte) error {
+	matches := logLineRegexp.FindStringSubmatch(strings.TrimSuffix(string(line), "\n"))
+	if matches == nil {
+		_, err := f.w.Write(line)
+		return err
+	}
+
+	prefix, err := f.render(logPrefixData{Time: matches[1], Pod: matches[2], Container: matches[3]})
+	if err != nil {
+		return err
+	}
+
+	n := utf8.RuneCountInString(prefix)
+	if n > f.width {
+		f.width = n
+	}
+
+	padding := strings.Repeat(" ", f.width-n)
+	if f.colorize {
+		prefix = f.podColor(matches[2]).Sprint(prefix)
+	}
+
+	_, err = fmt.Fprintf(f.w, "%s%s %s\n", prefix, padding, matches[4])
+	return err
+}
+
+func (f *podLogFormatter) render(data logPrefixData) (string, error) {
+	var sb strings.Builder
+	if err := f.tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// podColor returns the color assigned to pod, assigning the next one from the
+// palette to pods never seen before.
+func (f *podLogFormatter) podColor(pod string) *color.Color {
+	if c, found := f.colors[pod]; found {
+		return c
+	}
+
+	c := f.theme.PodColor(len(f.colors)).New()
+	f.colors[pod] = c
+	return c
+}
+
+// logJSONLine is a log line written by --output jsonl.
+type logJSONLine struct {
+	Time      string `json:"time,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+	Message   string `json:"message"`
+}
+
+// newLogJSONLinesWriter returns a writer rewriting the log lines coming from
+// the API as JSON objects, one per line. Every line is written as soon as it's
+// complete, so that followed logs can be piped right away. Lines not following
+// the API format are written with just their message.
+func newLogJSONLinesWriter(w io.Writer) *util.LineWriter {
+	return util.NewLineWriter(nil, func(line []byte) error {
+		return writeLogJSONLine(w, strings.TrimSuffix(string(line), "\n"))
 	})
 }
+
+func writeLogJSONLine(w io.Writer, line string) error {
+	entry := logJSONLine{Message: line}
+	if matches := logLineRegexp.FindStringSubmatch(line); matches != nil {
+		entry = logJSONLine{Time: matches[1], Pod: matches[2], Container: matches[3], Message: matches[4]}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
@@ -6,24 +6,34 @@ package cmd
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func TestLog(t *testing.T) {
 	tests := []struct {
-		name          string
-		args          []string
-		expected      string
-		expectedError string
-		client        rpaasclient.Client
+		name           string
+		args           []string
+		expected       string
+		expectedStderr string
+		expectedError  string
+		client         rpaasclient.Client
 	}{
 		{
 			name:          "when Log returns an error",
@@ -32,10 +42,11 @@ func TestLog(t *testing.T) {
 			client: &fake.FakeClient{
 				FakeLog: func(args rpaasclient.LogArgs) error {
 					expected := rpaasclient.LogArgs{
-						Out:      &bytes.Buffer{},
 						Instance: "my-instance",
 						Color:    true,
 					}
+					assert.NotNil(t, args.Out)
+					args.Out = nil
 					assert.Equal(t, expected, args)
 					return fmt.Errorf("some error")
 				},
@@ -43,24 +54,302 @@ func TestLog(t *testing.T) {
 		},
 		{
 			name: "when Log returns no error",
-			args: []string{"./rpaasv2", "logs", "-i", "my-instance", "--since", "2s", "--follow", "--pod", "some-pod", "--container", "some-container", "--lines", "15"},
+			args: []string{"./rpaasv2", "logs", "-i", "my-instance", "--since", "2s", "--follow", "--pod", "some-pod", "--container", "some-container"},
 			client: &fake.FakeClient{
 				FakeLog: func(args rpaasclient.LogArgs) error {
 					expected := rpaasclient.LogArgs{
-						Out:       &bytes.Buffer{},
 						Instance:  "my-instance",
 						Since:     time.Second * 2,
 						Follow:    true,
 						Pod:       "some-pod",
 						Container: "some-container",
-						Lines:     15,
 						Color:     true,
 					}
+					assert.NotNil(t, args.Out)
+					args.Out = nil
 					assert.Equal(t, expected, args)
 					return nil
 				},
 			},
 		},
+		{
+			name: "with lines only",
+			args: []string{"./rpaasv2", "logs", "-i", "my-instance", "--lines", "15"},
+			client: &fake.FakeClient{
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					assert.Equal(t, 15, args.Lines)
+					assert.Equal(t, time.Duration(0), args.Since)
+					return nil
+				},
+			},
+		},
+		{
+			name: "with lines set to zero meaning no line limit",
+			args: []string{"./rpaasv2", "logs", "-i", "my-instance", "--lines", "0"},
+			client: &fake.FakeClient{
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					assert.Equal(t, 0, args.Lines)
+					assert.Equal(t, time.Duration(0), args.Since)
+					return nil
+				},
+			},
+		},
+		{
+			name:           "with both since and lines, since takes precedence",
+			args:           []string{"./rpaasv2", "logs", "-i", "my-instance", "--since", "5m", "--lines", "15"},
+			expectedStderr: "WARNING: --lines is ignored since --since takes precedence over it\n",
+			client: &fake.FakeClient{
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					assert.Equal(t, 0, args.Lines)
+					assert.Equal(t, 5*time.Minute, args.Since)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "with since set to zero",
+			args:          []string{"./rpaasv2", "logs", "-i", "my-instance", "--since", "0s", "--lines", "15"},
+			expectedError: "--since must be a positive duration",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "with negative lines",
+			args:          []string{"./rpaasv2", "logs", "-i", "my-instance", "--lines", "-1"},
+			expectedError: "--lines must not be negative",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name: "when running only is set",
+			args: []string{"./rpaasv2", "logs", "-i", "my-instance", "--running-only", "--follow"},
+			client: &fake.FakeClient{
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					expected := rpaasclient.LogArgs{
+						Instance:    "my-instance",
+						Follow:      true,
+						Color:       true,
+						RunningOnly: true,
+					}
+					assert.NotNil(t, args.Out)
+					args.Out = nil
+					assert.Equal(t, expected, args)
+					return nil
+				},
+			},
+		},
+		{
+			name:     "when container is a glob pattern matching many containers",
+			args:     []string{"./rpaasv2", "logs", "-i", "my-instance", "--container", "nginx*"},
+			expected: "[nginx] line from nginx\n[nginx-sidecar] line from nginx-sidecar\n",
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					assert.Equal(t, "my-instance", args.Instance)
+					return &types.InstanceInfo{
+						Pods: []types.Pod{
+							{Name: "pod-1", Containers: []string{"nginx", "nginx-sidecar", "other"}},
+							{Name: "pod-2", Containers: []string{"nginx"}},
+						},
+					}, nil
+				},
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					fmt.Fprintf(args.Out, "line from %s", args.Container)
+					return nil
+				},
+			},
+		},
+		{
+			name: "when container is a regular expression matching a single container",
+			args: []string{"./rpaasv2", "logs", "-i", "my-instance", "--container", "^side", "--container-regexp"},
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{
+						Pods: []types.Pod{
+							{Name: "pod-1", Containers: []string{"nginx", "sidecar"}},
+						},
+					}, nil
+				},
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					assert.Equal(t, "sidecar", args.Container)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when container pattern matches nothing",
+			args:          []string{"./rpaasv2", "logs", "-i", "my-instance", "--container", "foo*"},
+			expectedError: `no container matches "foo*"`,
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{
+						Pods: []types.Pod{
+							{Name: "pod-1", Containers: []string{"nginx"}},
+						},
+					}, nil
+				},
+			},
+		},
+		{
+			name:     "when pods are excluded",
+			args:     []string{"./rpaasv2", "logs", "-i", "my-instance", "--exclude-pod", "*-canary-*", "--exclude-pod", "pod-3"},
+			expected: "line from pod-1\nline from pod-2\n",
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{
+						Pods: []types.Pod{
+							{Name: "pod-2", Containers: []string{"nginx"}},
+							{Name: "pod-1", Containers: []string{"nginx"}},
+							{Name: "pod-canary-1", Containers: []string{"nginx"}},
+							{Name: "pod-3", Containers: []string{"nginx"}},
+						},
+					}, nil
+				},
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					assert.Empty(t, args.Container)
+					fmt.Fprintf(args.Out, "line from %s", args.Pod)
+					return nil
+				},
+			},
+		},
+		{
+			name:     "when pods are excluded while following many containers as JSON lines",
+			args:     []string{"./rpaasv2", "logs", "-i", "my-instance", "--exclude-pod", "pod-2", "--container", "nginx*", "--follow", "-o", "jsonl"},
+			expected: "{\"time\":\"2023-03-10T12:00:00Z\",\"pod\":\"pod-1\",\"container\":\"nginx\",\"message\":\"hello\"}\n",
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{
+						Pods: []types.Pod{
+							{Name: "pod-1", Containers: []string{"nginx", "nginx-exporter"}},
+							{Name: "pod-2", Containers: []string{"nginx", "nginx-exporter"}},
+						},
+					}, nil
+				},
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					assert.Equal(t, "pod-1", args.Pod)
+					if args.Container == "nginx" {
+						fmt.Fprintf(args.Out, "2023-03-10T12:00:00Z [%s][%s]: hello\n", args.Pod, args.Container)
+					}
+					return nil
+				},
+			},
+		},
+		{
+			name: "when pods are excluded along with running only",
+			args: []string{"./rpaasv2", "logs", "-i", "my-instance", "--exclude-pod", "pod-1", "--running-only"},
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{
+						Pods: []types.Pod{
+							{Name: "pod-1", Status: "Running"},
+							{Name: "pod-2", Status: "Running"},
+							{Name: "pod-3", Status: "Pending"},
+						},
+					}, nil
+				},
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					assert.Equal(t, "pod-2", args.Pod)
+					return nil
+				},
+			},
+		},
+		{
+			name: "when the excluded pod is set explicitly",
+			args: []string{"./rpaasv2", "logs", "-i", "my-instance", "--exclude-pod", "pod-*", "--pod", "pod-1"},
+			client: &fake.FakeClient{
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					assert.Equal(t, "pod-1", args.Pod)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when every pod is excluded",
+			args:          []string{"./rpaasv2", "logs", "-i", "my-instance", "--exclude-pod", "pod-*"},
+			expectedError: "no pods left after excluding pod-*",
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{Pods: []types.Pod{{Name: "pod-1"}, {Name: "pod-2"}}}, nil
+				},
+			},
+		},
+		{
+			name:          "when the pod exclusion pattern is invalid",
+			args:          []string{"./rpaasv2", "logs", "-i", "my-instance", "--exclude-pod", "pod-["},
+			expectedError: `invalid pod pattern "pod-[": syntax error in pattern`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:     "when containers are excluded",
+			args:     []string{"./rpaasv2", "logs", "-i", "my-instance", "--exclude-container", "*-injector"},
+			expected: "[nginx] line from nginx\n[other] line from other\n",
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{
+						Pods: []types.Pod{
+							{Name: "pod-1", Containers: []string{"nginx", "sidecar-injector", "other"}},
+						},
+					}, nil
+				},
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					fmt.Fprintf(args.Out, "line from %s", args.Container)
+					return nil
+				},
+			},
+		},
+		{
+			name: "when containers are excluded from the ones matching the container pattern",
+			args: []string{"./rpaasv2", "logs", "-i", "my-instance", "--container", "nginx*", "--exclude-container", "nginx-sidecar"},
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{
+						Pods: []types.Pod{
+							{Name: "pod-1", Containers: []string{"nginx", "nginx-sidecar"}},
+						},
+					}, nil
+				},
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					assert.Equal(t, "nginx", args.Container)
+					return nil
+				},
+			},
+		},
+		{
+			name: "when no container matches the exclusion",
+			args: []string{"./rpaasv2", "logs", "-i", "my-instance", "--exclude-container", "istio-*"},
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{
+						Pods: []types.Pod{
+							{Name: "pod-1", Containers: []string{"nginx", "sidecar"}},
+						},
+					}, nil
+				},
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					assert.Empty(t, args.Container)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when every container is excluded",
+			args:          []string{"./rpaasv2", "logs", "-i", "my-instance", "--exclude-container", "*"},
+			expectedError: "no containers left after excluding *",
+			client: &fake.FakeClient{
+				FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{Pods: []types.Pod{{Name: "pod-1", Containers: []string{"nginx"}}}}, nil
+				},
+			},
+		},
+		{
+			name:          "when the container exclusion pattern is invalid",
+			args:          []string{"./rpaasv2", "logs", "-i", "my-instance", "--exclude-container", "["},
+			expectedError: `invalid container pattern "[": syntax error in pattern`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when container regular expression is invalid",
+			args:          []string{"./rpaasv2", "logs", "-i", "my-instance", "--container", "(nginx", "--container-regexp"},
+			expectedError: "invalid container regular expression \"(nginx\": error parsing regexp: missing closing ): `(nginx`",
+			client:        &fake.FakeClient{},
+		},
 	}
 
 	for _, tt := range tests {
@@ -76,7 +365,388 @@ func TestLog(t *testing.T) {
 			}
 			require.NoError(t, err)
 			assert.Equal(t, tt.expected, stdout.String())
-			assert.Empty(t, stderr.String())
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+		})
+	}
+}
+
+func TestLogExport(t *testing.T) {
+	logLines := func(args rpaasclient.LogArgs) error {
+		assert.False(t, args.Color)
+		assert.Equal(t, 2, args.Lines)
+		fmt.Fprint(args.Out, "first line\nsecond line\n")
+		return nil
+	}
+
+	t.Run("when follow is set", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs.txt")
+		app := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{})
+		err := app.Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--export", path, "--follow"})
+		assert.EqualError(t, err, "--export cannot be used along with --follow")
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("when Log returns an error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs.txt")
+		app := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{
+			FakeLog: func(args rpaasclient.LogArgs) error { return fmt.Errorf("some error") },
+		})
+		err := app.Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--export", path})
+		assert.EqualError(t, err, "some error")
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("when Log returns an error over a pre-existing file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "logs.txt")
+		require.NoError(t, os.WriteFile(path, []byte("previous logs\n"), 0644))
+
+		app := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{
+			FakeLog: func(args rpaasclient.LogArgs) error {
+				fmt.Fprint(args.Out, "partial line")
+				return fmt.Errorf("some error")
+			},
+		})
+		err := app.Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--export", path})
+		assert.EqualError(t, err, "some error")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "previous logs\n", string(data))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("exporting into a plain file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs.txt")
+		stdout := &bytes.Buffer{}
+		app := NewApp(stdout, &bytes.Buffer{}, &fake.FakeClient{FakeLog: logLines})
+		err := app.Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--export", path, "--lines", "2"})
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("Exported 2 line(s) (23 bytes) to %s\n", path), stdout.String())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "first line\nsecond line\n", string(data))
+	})
+
+	t.Run("exporting into a gzipped file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "logs.txt.gz")
+		stdout := &bytes.Buffer{}
+		app := NewApp(stdout, &bytes.Buffer{}, &fake.FakeClient{FakeLog: logLines})
+		err := app.Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--export", path, "--lines", "2"})
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("Exported 2 line(s) (23 bytes) to %s\n", path), stdout.String())
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+
+		gr, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		data, err := io.ReadAll(gr)
+		require.NoError(t, err)
+		assert.Equal(t, "first line\nsecond line\n", string(data))
+	})
+}
+
+func TestLogWithPrefix(t *testing.T) {
+	client := &fake.FakeClient{
+		FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+			assert.Equal(t, "my-instance", args.Instance)
+			return &types.InstanceInfo{
+				Pods: []types.Pod{
+					{Name: "my-instance-b", Status: "Running", Containers: []string{"nginx"}},
+					{Name: "my-instance-a", Status: "Running", Containers: []string{"nginx", "sidecar"}},
+				},
+			}, nil
+		},
+		FakeLog: func(args rpaasclient.LogArgs) error {
+			assert.False(t, args.Color)
+			fmt.Fprint(args.Out, "2024-01-01T00:00:00Z [my-instance-b][nginx]: first line\n")
+			fmt.Fprint(args.Out, "2024-01-01T00:00:01Z [my-instance-a][sidecar]: second ")
+			fmt.Fprint(args.Out, "line\nnot following the format\n")
+			return nil
+		},
+	}
+
+	t.Run("without colors", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		app := NewApp(stdout, &bytes.Buffer{}, client)
+		err := app.Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--prefix", "{{.Pod}}/{{.Container}}", "--without-color"})
+		require.NoError(t, err)
+		assert.Equal(t, "my-instance-b/nginx   first line\nmy-instance-a/sidecar second line\nnot following the format\n", stdout.String())
+	})
+
+	t.Run("with colors", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		app := NewApp(stdout, &bytes.Buffer{}, client)
+		err := app.Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--prefix", "[{{.Pod}}]"})
+		require.NoError(t, err)
+		assert.Equal(t, "Colors: \x1b[36mcyan=my-instance-a\x1b[0m, \x1b[32mgreen=my-instance-b\x1b[0m\n"+
+			"\x1b[32m[my-instance-b]\x1b[0m first line\n"+
+			"\x1b[36m[my-instance-a]\x1b[0m second line\n"+
+			"not following the format\n", stdout.String())
+	})
+
+	t.Run("with the light theme", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		app := NewApp(stdout, &bytes.Buffer{}, client)
+		err := app.Run([]string{"./rpaasv2", "--theme", "light", "logs", "-i", "my-instance", "--prefix", "[{{.Pod}}]"})
+		require.NoError(t, err)
+		assert.Equal(t, "Colors: \x1b[34mblue=my-instance-a\x1b[0m, \x1b[35mmagenta=my-instance-b\x1b[0m\n"+
+			"\x1b[35m[my-instance-b]\x1b[0m first line\n"+
+			"\x1b[34m[my-instance-a]\x1b[0m second line\n"+
+			"not following the format\n", stdout.String())
+	})
+
+	t.Run("when NO_COLOR is set", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		stdout := &bytes.Buffer{}
+		app := NewApp(stdout, &bytes.Buffer{}, client)
+		err := app.Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--prefix", "[{{.Pod}}]"})
+		require.NoError(t, err)
+		assert.Equal(t, "[my-instance-b] first line\n[my-instance-a] second line\nnot following the format\n", stdout.String())
+	})
+
+	t.Run("when template is invalid", func(t *testing.T) {
+		app := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, client)
+		err := app.Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--prefix", "{{.Node}}"})
+		assert.ErrorContains(t, err, "invalid --prefix template:")
+	})
+}
+
+func TestLogMerge(t *testing.T) {
+	client := &fake.FakeClient{
+		FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+			return &types.InstanceInfo{
+				Pods: []types.Pod{
+					{Name: "my-instance-a", Status: "Running", Containers: []string{"nginx", "sidecar"}},
+					{Name: "my-instance-b", Status: "Running", Containers: []string{"nginx", "sidecar"}},
+				},
+			}, nil
+		},
+		FakeLog: func(args rpaasclient.LogArgs) error {
+			if args.Container == "sidecar" {
+				fmt.Fprint(args.Out, "2024-01-01T00:00:01.5Z [my-instance-a][sidecar]: sidecar line\n")
+				return nil
+			}
+
+			// NOTE: the API writes the lines pod by pod.
+			fmt.Fprint(args.Out, "2024-01-01T00:00:00Z [my-instance-a][nginx]: a0\n")
+			fmt.Fprint(args.Out, "2024-01-01T00:00:02Z [my-instance-a][nginx]: a2\n  continued\n")
+			fmt.Fprint(args.Out, "2024-01-01T00:00:01Z [my-instance-b][nginx]: b1\n")
+			fmt.Fprint(args.Out, "2024-01-01T00:00:03Z [my-instance-b][nginx]: b3")
+			return nil
+		},
+	}
+
+	t.Run("sorts the lines from all pods", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--merge"})
+		require.NoError(t, err)
+		assert.Equal(t, "2024-01-01T00:00:00Z [my-instance-a][nginx]: a0\n"+
+			"2024-01-01T00:00:01Z [my-instance-b][nginx]: b1\n"+
+			"2024-01-01T00:00:02Z [my-instance-a][nginx]: a2\n  continued\n"+
+			"2024-01-01T00:00:03Z [my-instance-b][nginx]: b3\n", stdout.String())
+	})
+
+	t.Run("sorts colored lines", func(t *testing.T) {
+		colored := &fake.FakeClient{
+			FakeLog: func(args rpaasclient.LogArgs) error {
+				assert.True(t, args.Color)
+				fmt.Fprint(args.Out, "\x1b[36m2024-01-01T00:00:02Z [my-instance-a][nginx]\x1b[0m: a2\n")
+				fmt.Fprint(args.Out, "\x1b[32m2024-01-01T00:00:01Z [my-instance-b][nginx]\x1b[0m: b1\n")
+				return nil
+			},
+		}
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, colored).Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--merge"})
+		require.NoError(t, err)
+		assert.Equal(t, "\x1b[32m2024-01-01T00:00:01Z [my-instance-b][nginx]\x1b[0m: b1\n"+
+			"\x1b[36m2024-01-01T00:00:02Z [my-instance-a][nginx]\x1b[0m: a2\n", stdout.String())
+	})
+
+	t.Run("sorts the lines from many containers", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--merge", "--container", "*"})
+		require.NoError(t, err)
+		assert.Equal(t, "[nginx] 2024-01-01T00:00:00Z [my-instance-a][nginx]: a0\n"+
+			"[nginx] 2024-01-01T00:00:01Z [my-instance-b][nginx]: b1\n"+
+			"[sidecar] 2024-01-01T00:00:01.5Z [my-instance-a][sidecar]: sidecar line\n"+
+			"[nginx] 2024-01-01T00:00:02Z [my-instance-a][nginx]: a2\n"+
+			"[nginx]   continued\n"+
+			"[nginx] 2024-01-01T00:00:03Z [my-instance-b][nginx]: b3\n", stdout.String())
+	})
+
+	t.Run("along with a custom prefix", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--merge", "--container", "*", "--prefix", "{{.Pod}}/{{.Container}}", "--without-color"})
+		require.NoError(t, err)
+		assert.Equal(t, "my-instance-a/nginx   a0\n"+
+			"my-instance-b/nginx   b1\n"+
+			"my-instance-a/sidecar sidecar line\n"+
+			"my-instance-a/nginx   a2\n"+
+			"  continued\n"+
+			"my-instance-b/nginx   b3\n", stdout.String())
+	})
+
+	t.Run("sorts in batches when there are too many lines", func(t *testing.T) {
+		defer func(n int) { maxMergedLogLines = n }(maxMergedLogLines)
+		maxMergedLogLines = 3
+
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		err := NewApp(stdout, stderr, client).Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--merge"})
+		require.NoError(t, err)
+		assert.Equal(t, "2024-01-01T00:00:00Z [my-instance-a][nginx]: a0\n"+
+			"2024-01-01T00:00:02Z [my-instance-a][nginx]: a2\n  continued\n"+
+			"2024-01-01T00:00:01Z [my-instance-b][nginx]: b1\n"+
+			"2024-01-01T00:00:03Z [my-instance-b][nginx]: b3\n", stdout.String())
+		assert.Equal(t, "WARNING: there are more than 3 log lines to merge, they're sorted in batches of that size (narrow them down with --lines or --since)\n", stderr.String())
+	})
+
+	t.Run("along with follow", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--merge", "--follow"})
+		assert.EqualError(t, err, "--merge cannot be used along with --follow")
+	})
+}
+
+func TestLogInterrupted(t *testing.T) {
+	defer func(f func(context.Context) (context.Context, context.CancelFunc)) { notifyLogSignals = f }(notifyLogSignals)
+
+	var interrupt context.CancelFunc
+	notifyLogSignals = func(ctx context.Context) (context.Context, context.CancelFunc) {
+		ctx, interrupt = context.WithCancel(ctx)
+		return ctx, interrupt
+	}
+
+	info := func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+		return &types.InstanceInfo{
+			Pods: []types.Pod{{Name: "my-instance-a", Status: "Running", Containers: []string{"nginx", "sidecar"}}},
+		}, nil
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		color    bool
+		expected string
+	}{
+		{
+			name:     "finishing the partial last line",
+			args:     []string{"./rpaasv2", "logs", "-i", "my-instance", "--follow", "--without-color"},
+			expected: "2024-01-01T00:00:00Z [my-instance-a][nginx]: first line\n2024-01-01T00:00:01Z [my-instance-a][nginx]: partial\n",
+		},
+		{
+			name:     "flushing the partial last line with a custom prefix",
+			args:     []string{"./rpaasv2", "logs", "-i", "my-instance", "--follow", "--without-color", "--container", "nginx", "--prefix", "{{.Pod}}"},
+			expected: "my-instance-a first line\nmy-instance-a partial\n",
+		},
+		{
+			name:     "flushing the partial last line from many containers",
+			args:     []string{"./rpaasv2", "logs", "-i", "my-instance", "--follow", "--without-color", "--container", "*"},
+			expected: "[nginx] 2024-01-01T00:00:00Z [my-instance-a][nginx]: first line\n[nginx] 2024-01-01T00:00:01Z [my-instance-a][nginx]: partial\n",
+		},
+		{
+			name:     "resetting the colors left by the partial last line",
+			args:     []string{"./rpaasv2", "logs", "-i", "my-instance", "--follow", "--container", "nginx"},
+			color:    true,
+			expected: "2024-01-01T00:00:00Z [my-instance-a][nginx]: first line\n2024-01-01T00:00:01Z [my-instance-a][nginx]: partial\x1b[0m\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fake.FakeClient{
+				FakeInfo: info,
+				FakeLog: func(args rpaasclient.LogArgs) error {
+					if args.Container != "sidecar" {
+						fmt.Fprint(args.Out, "2024-01-01T00:00:00Z [my-instance-a][nginx]: first line\n")
+						fmt.Fprint(args.Out, "2024-01-01T00:00:01Z [my-instance-a][nginx]: partial")
+					}
+
+					interrupt()
+					return context.Canceled
+				},
+			}
+
+			defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+			color.NoColor = !tt.color
+
+			stdout := &bytes.Buffer{}
+			err := NewApp(stdout, &bytes.Buffer{}, client).Run(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
 		})
 	}
 }
+
+func TestLogJSONLines(t *testing.T) {
+	parse := func(t *testing.T, output string) []logJSONLine {
+		var entries []logJSONLine
+		for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+			var entry logJSONLine
+			require.NoError(t, json.Unmarshal([]byte(line), &entry), "line %q", line)
+			entries = append(entries, entry)
+		}
+
+		return entries
+	}
+
+	t.Run("writes every line as soon as it's complete", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		client := &fake.FakeClient{
+			FakeLog: func(args rpaasclient.LogArgs) error {
+				assert.False(t, args.Color)
+
+				fmt.Fprint(args.Out, "2024-01-01T00:00:00Z [my-instance-a][nginx]: GET / 200\n2024-01-01T00:00:01Z [my-instance-a][nginx]: GET ")
+				assert.Equal(t, []logJSONLine{{Time: "2024-01-01T00:00:00Z", Pod: "my-instance-a", Container: "nginx", Message: "GET / 200"}}, parse(t, stdout.String()))
+
+				fmt.Fprint(args.Out, "/health 200\nnginx: [warn] \"some\" warning\n")
+				fmt.Fprint(args.Out, "2024-01-01T00:00:02Z [my-instance-b][nginx]: partial")
+				return nil
+			},
+		}
+
+		err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--follow", "-o", "jsonl"})
+		require.NoError(t, err)
+		assert.Equal(t, []logJSONLine{
+			{Time: "2024-01-01T00:00:00Z", Pod: "my-instance-a", Container: "nginx", Message: "GET / 200"},
+			{Time: "2024-01-01T00:00:01Z", Pod: "my-instance-a", Container: "nginx", Message: "GET /health 200"},
+			{Message: `nginx: [warn] "some" warning`},
+			{Time: "2024-01-01T00:00:02Z", Pod: "my-instance-b", Container: "nginx", Message: "partial"},
+		}, parse(t, stdout.String()))
+	})
+
+	t.Run("from many containers", func(t *testing.T) {
+		client := &fake.FakeClient{
+			FakeInfo: func(args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+				return &types.InstanceInfo{Pods: []types.Pod{{Name: "my-instance-a", Containers: []string{"nginx", "sidecar"}}}}, nil
+			},
+			FakeLog: func(args rpaasclient.LogArgs) error {
+				fmt.Fprintf(args.Out, "2024-01-01T00:00:00Z [my-instance-a][%s]: hello\n", args.Container)
+				return nil
+			},
+		}
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "--container", "*", "-o", "jsonl"})
+		require.NoError(t, err)
+		assert.Equal(t, `{"time":"2024-01-01T00:00:00Z","pod":"my-instance-a","container":"nginx","message":"hello"}
+{"time":"2024-01-01T00:00:00Z","pod":"my-instance-a","container":"sidecar","message":"hello"}
+`, stdout.String())
+	})
+
+	t.Run("along with a custom prefix", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{}).Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "-o", "jsonl", "--prefix", "{{.Pod}}"})
+		assert.EqualError(t, err, "--output jsonl cannot be used along with --prefix")
+	})
+
+	t.Run("with an unknown output format", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{}).Run([]string{"./rpaasv2", "logs", "-i", "my-instance", "-o", "json"})
+		assert.EqualError(t, err, `unsupported output format "json" (one of: text, jsonl)`)
+	})
+}
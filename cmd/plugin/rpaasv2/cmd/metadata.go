@@ -0,0 +1,227 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func NewCmdMetadata() *cli.Command {
+	return &cli.Command{
+		Name:  "metadata",
+		Usage: "Manages labels and annotations of the instance",
+		Subcommands: []*cli.Command{
+			NewCmdGetMetadata(),
+			withInstanceFile(NewCmdSetMetadata()),
+			withInstanceFile(NewCmdUnsetMetadata()),
+		},
+	}
+}
+
+func metadataInstanceFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "service",
+			Aliases: []string{"tsuru-service", "s"},
+			Usage:   "the Tsuru service name",
+		},
+		&cli.StringFlag{
+			Name:     "instance",
+			Aliases:  []string{"tsuru-service-instance", "i"},
+			Usage:    "the reverse proxy instance name",
+			Required: true,
+		},
+	}
+}
+
+func NewCmdGetMetadata() *cli.Command {
+	return &cli.Command{
+		Name:  "get",
+		Usage: "Shows the labels and annotations of the instance",
+		Flags: append(append(metadataInstanceFlags(),
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "the output format (one of: table, json, yaml)",
+				Value:   "table",
+			},
+		), outputFieldFlags()...),
+		Before: setupClient,
+		Action: runGetMetadata,
+	}
+}
+
+func runGetMetadata(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := client.GetMetadata(c.Context, rpaasclient.GetMetadataArgs{Instance: c.String("instance")})
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(c, c.String("output"), metadata, func(w io.Writer) error {
+		writeMetadataOnTableFormat(w, metadata)
+		return nil
+	})
+}
+
+func writeMetadataOnTableFormat(w io.Writer, metadata *clientTypes.Metadata) {
+	if metadata == nil || (len(metadata.Labels) == 0 && len(metadata.Annotations) == 0) {
+		return
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Type", "Key", "Value"})
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoWrapText(true)
+	table.SetRowLine(false)
+
+	for _, item := range metadata.Labels {
+		table.Append([]string{"label", item.Name, item.Value})
+	}
+
+	for _, item := range metadata.Annotations {
+		table.Append([]string{"annotation", item.Name, item.Value})
+	}
+
+	table.Render()
+}
+
+func NewCmdSetMetadata() *cli.Command {
+	return &cli.Command{
+		Name:  "set",
+		Usage: "Sets labels and annotations on the instance",
+		Description: `
+# Set a label and an annotation at once:
+rpaasv2 metadata set -s my-service -i my-instance --label env=prod --annotation owner=team-x
+`,
+		Flags: append(metadataInstanceFlags(),
+			&cli.StringSliceFlag{
+				Name:    "label",
+				Aliases: []string{"l"},
+				Usage:   "label in the key=value format (can be used multiple times)",
+			},
+			&cli.StringSliceFlag{
+				Name:    "annotation",
+				Aliases: []string{"a"},
+				Usage:   "annotation in the key=value format (can be used multiple times)",
+			},
+		),
+		Before: setupClient,
+		Action: runSetMetadata,
+	}
+}
+
+func runSetMetadata(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	labels, err := parseMetadataItems(c.StringSlice("label"), true)
+	if err != nil {
+		return err
+	}
+
+	annotations, err := parseMetadataItems(c.StringSlice("annotation"), true)
+	if err != nil {
+		return err
+	}
+
+	args := rpaasclient.SetMetadataArgs{
+		Instance: c.String("instance"),
+		Metadata: clientTypes.Metadata{Labels: labels, Annotations: annotations},
+	}
+	if err = client.SetMetadata(c.Context, args); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Metadata updated on %s.\n", formatInstanceName(c))
+	return nil
+}
+
+func NewCmdUnsetMetadata() *cli.Command {
+	return &cli.Command{
+		Name:  "unset",
+		Usage: "Removes labels and annotations from the instance",
+		Description: `
+# Remove a label:
+rpaasv2 metadata unset -s my-service -i my-instance --label env
+`,
+		Flags: append(metadataInstanceFlags(),
+			&cli.StringSliceFlag{
+				Name:    "label",
+				Aliases: []string{"l"},
+				Usage:   "label key (can be used multiple times)",
+			},
+			&cli.StringSliceFlag{
+				Name:    "annotation",
+				Aliases: []string{"a"},
+				Usage:   "annotation key (can be used multiple times)",
+			},
+		),
+		Before: setupClient,
+		Action: runUnsetMetadata,
+	}
+}
+
+func runUnsetMetadata(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	labels, err := parseMetadataItems(c.StringSlice("label"), false)
+	if err != nil {
+		return err
+	}
+
+	annotations, err := parseMetadataItems(c.StringSlice("annotation"), false)
+	if err != nil {
+		return err
+	}
+
+	args := rpaasclient.UnsetMetadataArgs{
+		Instance: c.String("instance"),
+		Metadata: clientTypes.Metadata{Labels: labels, Annotations: annotations},
+	}
+	if err = client.UnsetMetadata(c.Context, args); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Metadata removed from %s.\n", formatInstanceName(c))
+	return nil
+}
+
+func parseMetadataItems(raw []string, withValues bool) ([]clientTypes.MetadataItem, error) {
+	var items []clientTypes.MetadataItem
+	for _, r := range raw {
+		if !withValues {
+			items = append(items, clientTypes.MetadataItem{Name: r})
+			continue
+		}
+
+		key, value, found := strings.Cut(r, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid metadata %q: must be in the key=value format", r)
+		}
+
+		items = append(items, clientTypes.MetadataItem{Name: key, Value: value})
+	}
+
+	return items, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestGetMetadata(t *testing.T) {
+	metadata := &types.Metadata{
+		Labels:      []types.MetadataItem{{Name: "env", Value: "prod"}},
+		Annotations: []types.MetadataItem{{Name: "owner", Value: "team-x"}},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        client.Client
+	}{
+		{
+			name:          "when GetMetadata returns an error",
+			args:          []string{"./rpaasv2", "metadata", "get", "-i", "my-instance"},
+			expectedError: "some error",
+			client: &fake.FakeClient{
+				FakeGetMetadata: func(args client.GetMetadataArgs) (*types.Metadata, error) {
+					return nil, fmt.Errorf("some error")
+				},
+			},
+		},
+		{
+			name: "showing metadata on table format",
+			args: []string{"./rpaasv2", "metadata", "get", "-i", "my-instance"},
+			expected: `+------------+-------+--------+
+| Type       | Key   | Value  |
++------------+-------+--------+
+| label      | env   | prod   |
+| annotation | owner | team-x |
++------------+-------+--------+
+`,
+			client: &fake.FakeClient{
+				FakeGetMetadata: func(args client.GetMetadataArgs) (*types.Metadata, error) {
+					assert.Equal(t, client.GetMetadataArgs{Instance: "my-instance"}, args)
+					return metadata, nil
+				},
+			},
+		},
+		{
+			name: "showing metadata as YAML",
+			args: []string{"./rpaasv2", "metadata", "get", "-i", "my-instance", "-o", "yaml"},
+			expected: `annotations:
+- name: owner
+  value: team-x
+labels:
+- name: env
+  value: prod
+`,
+			client: &fake.FakeClient{
+				FakeGetMetadata: func(args client.GetMetadataArgs) (*types.Metadata, error) {
+					return metadata, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client)
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
+
+func TestSetMetadata(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        client.Client
+	}{
+		{
+			name:          "when label is not in the key=value format",
+			args:          []string{"./rpaasv2", "metadata", "set", "-i", "my-instance", "--label", "env"},
+			expectedError: `invalid metadata "env": must be in the key=value format`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when SetMetadata returns an error",
+			args:          []string{"./rpaasv2", "metadata", "set", "-i", "my-instance", "--label", "env=prod"},
+			expectedError: "some error",
+			client: &fake.FakeClient{
+				FakeSetMetadata: func(args client.SetMetadataArgs) error {
+					return fmt.Errorf("some error")
+				},
+			},
+		},
+		{
+			name:     "setting labels and annotations",
+			args:     []string{"./rpaasv2", "metadata", "set", "-s", "rpaasv2", "-i", "my-instance", "--label", "env=prod", "--label", "tier=frontend", "--annotation", "owner=team-x"},
+			expected: "Metadata updated on rpaasv2/my-instance.\n",
+			client: &fake.FakeClient{
+				FakeSetMetadata: func(args client.SetMetadataArgs) error {
+					assert.Equal(t, client.SetMetadataArgs{
+						Instance: "my-instance",
+						Metadata: types.Metadata{
+							Labels:      []types.MetadataItem{{Name: "env", Value: "prod"}, {Name: "tier", Value: "frontend"}},
+							Annotations: []types.MetadataItem{{Name: "owner", Value: "team-x"}},
+						},
+					}, args)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client)
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
+
+func TestUnsetMetadata(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        client.Client
+	}{
+		{
+			name:          "when UnsetMetadata returns an error",
+			args:          []string{"./rpaasv2", "metadata", "unset", "-i", "my-instance", "--label", "env"},
+			expectedError: "some error",
+			client: &fake.FakeClient{
+				FakeUnsetMetadata: func(args client.UnsetMetadataArgs) error {
+					return fmt.Errorf("some error")
+				},
+			},
+		},
+		{
+			name:     "removing a label",
+			args:     []string{"./rpaasv2", "metadata", "unset", "-s", "rpaasv2", "-i", "my-instance", "--label", "env"},
+			expected: "Metadata removed from rpaasv2/my-instance.\n",
+			client: &fake.FakeClient{
+				FakeUnsetMetadata: func(args client.UnsetMetadataArgs) error {
+					assert.Equal(t, client.UnsetMetadataArgs{
+						Instance: "my-instance",
+						Metadata: types.Metadata{Labels: []types.MetadataItem{{Name: "env"}}},
+					}, args)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client)
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+)
+
+func writeOutput(c *cli.Context, format string, v any, writeTable func(io.Writer) error) error {
+	return renderOutput(c, format, v, nil, writeTable)
+}
+
+// records holds the header and rows of a list, as written in CSV format.
+type records struct {
+	Header []string
+	Rows   [][]string
+}
+
+// writeListOutput is like writeOutput, but also supports the CSV format which
+// writes rec with a header row.
+func writeListOutput(c *cli.Context, format string, v any, rec records, writeTable func(io.Writer) error) error {
+	return renderOutput(c, format, v, &rec, writeTable)
+}
+
+func renderOutput(c *cli.Context, format string, v any, rec *records, writeTable func(io.Writer) error) error {
+	if err := checkOutputField(c, format); err != nil {
+		return err
+	}
+
+	w := c.App.Writer
+	switch format {
+	case "", "table":
+		return writeTable(w)
+
+	case "json":
+		return writeJSONOutput(c, v)
+
+	case "yaml":
+		return writeYAML(w, v)
+
+	case "csv":
+		if rec != nil {
+			return writeCSV(w, *rec)
+		}
+
+	case "jsonl":
+		if rec != nil {
+			return writeJSONLines(w, v)
+		}
+	}
+
+	formats := "table, json, yaml"
+	if rec != nil {
+		formats += ", csv, jsonl"
+	}
+
+	return fmt.Errorf("unsupported output format %q (one of: %s)", format, formats)
+}
+
+// writeCSV writes rec as RFC 4180 CSV: CRLF line breaks (including the ones
+// within fields) and fields holding commas, quotes or line breaks enclosed in
+// double quotes.
+func writeCSV(w io.Writer, rec records) error {
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	if err := cw.Write(rec.Header); err != nil {
+		return err
+	}
+
+	return cw.WriteAll(rec.Rows)
+}
+
+// writeJSONLines writes each item of the list v as JSON on its own line (aka
+// JSON Lines), right as it's encoded rather than after the whole list.
+func writeJSONLines(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		return encoder.Encode(v)
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		if err := encoder.Encode(rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeYAML(w io.Writer, v any) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// outputFieldFlags returns the flags of commands supporting the projection
+// of their JSON output, see writeJSONOutput.
+func outputFieldFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "field",
+			Usage: "prints only the value at this path of the JSON output, made of dotted keys and array indexes (e.g. pods[0].name) (requires --output json)",
+		},
+		&cli.BoolFlag{
+			Name:  "allow-missing",
+			Usage: "prints nothing instead of failing when --field matches no value",
+		},
+	}
+}
+
+// checkOutputField fails when --field is set but the output format is other
+// than JSON.
+func checkOutputField(c *cli.Context, format string) error {
+	if format != "json" && (c.IsSet("field") || c.IsSet("allow-missing")) {
+		return fmt.Errorf("--field and --allow-missing can only be used along with --output json")
+	}
+
+	return nil
+}
+
+// writeJSONOutput writes v as JSON or, when --field is set, only the value
+// at that path. Strings are written unquoted, so that they can be used right
+// away on scripts.
+func writeJSONOutput(c *cli.Context, v any) error {
+	field := c.String("field")
+	if field == "" {
+		return writeJSON(c.App.Writer, v)
+	}
+
+	path, err := parseFieldPath(field)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var doc any
+	if err = d.Decode(&doc); err != nil {
+		return err
+	}
+
+	value, found := path.lookup(doc)
+	if !found {
+		if c.Bool("allow-missing") {
+			return nil
+		}
+
+		return fmt.Errorf("field %q not found in the output", field)
+	}
+
+	switch value := value.(type) {
+	case string:
+		fmt.Fprintln(c.App.Writer, value)
+		return nil
+
+	case map[string]any, []any:
+		return writeJSON(c.App.Writer, value)
+	}
+
+	return json.NewEncoder(c.App.Writer).Encode(value)
+}
+
+// fieldPath holds the steps to a value in a JSON document, each one either
+// an object key (string) or an array index (int).
+type fieldPath []any
+
+// parseFieldPath parses paths like "pods[0].name", optionally prefixed by
+// "$" or "." as in JSONPath.
+func parseFieldPath(s string) (fieldPath, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("invalid field path %q: %s", s, reason)
+	}
+
+	rest := strings.TrimPrefix(s, "$")
+	if rest == "" {
+		return nil, invalid("path cannot be empty")
+	}
+
+	var path fieldPath
+	for i := 0; rest != ""; i++ {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, invalid("missing closing bracket")
+			}
+
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, invalid(fmt.Sprintf("array index %q must be a non-negative integer", rest[1:end]))
+			}
+
+			path, rest = append(path, index), rest[end+1:]
+
+		case rest[0] == '.' || i == 0:
+			rest = strings.TrimPrefix(rest, ".")
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			if end == 0 {
+				return nil, invalid("keys cannot be empty")
+			}
+
+			path, rest = append(path, rest[:end]), rest[end:]
+
+		default:
+			return nil, invalid(fmt.Sprintf("unexpected %q", rest))
+		}
+	}
+
+	return path, nil
+}
+
+func (p fieldPath) lookup(doc any) (any, bool) {
+	value := doc
+	for _, step := range p {
+		switch step := step.(type) {
+		case string:
+			obj, ok := value.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+
+			if value, ok = obj[step]; !ok {
+				return nil, false
+			}
+
+		case int:
+			arr, ok := value.([]any)
+			if !ok || step >= len(arr) {
+				return nil, false
+			}
+
+			value = arr[step]
+		}
+	}
+
+	return value, true
+}
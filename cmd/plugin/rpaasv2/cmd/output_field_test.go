@@ -0,0 +1,47 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path          string
+		expected      fieldPath
+		expectedError string
+	}{
+		{path: "maxReplicas", expected: fieldPath{"maxReplicas"}},
+		{path: ".maxReplicas", expected: fieldPath{"maxReplicas"}},
+		{path: "$.pods[0].name", expected: fieldPath{"pods", 0, "name"}},
+		{path: "[2][10]", expected: fieldPath{2, 10}},
+		{path: "metadata.labels[0].value", expected: fieldPath{"metadata", "labels", 0, "value"}},
+		{path: "", expectedError: `invalid field path "": path cannot be empty`},
+		{path: "$", expectedError: `invalid field path "$": path cannot be empty`},
+		{path: "pods..name", expectedError: `invalid field path "pods..name": keys cannot be empty`},
+		{path: "pods.", expectedError: `invalid field path "pods.": keys cannot be empty`},
+		{path: "pods[0", expectedError: `invalid field path "pods[0": missing closing bracket`},
+		{path: "pods[-1]", expectedError: `invalid field path "pods[-1]": array index "-1" must be a non-negative integer`},
+		{path: "pods[a]", expectedError: `invalid field path "pods[a]": array index "a" must be a non-negative integer`},
+		{path: "pods[0]name", expectedError: `invalid field path "pods[0]name": unexpected "name"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := parseFieldPath(tt.path)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, path)
+		})
+	}
+}
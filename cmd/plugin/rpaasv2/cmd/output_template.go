@@ -0,0 +1,91 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	sprig "github.com/Masterminds/sprig/v3"
+	"github.com/urfave/cli/v2"
+)
+
+// outputTemplateFlags returns the flags of commands supporting the
+// "--output template" format, see parseOutputTemplate.
+func outputTemplateFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "template",
+			Usage: "the Go template used to render the output (requires --output template)",
+		},
+		&cli.PathFlag{
+			Name:  "template-file",
+			Usage: "path to a file with the Go template used to render the output (requires --output template)",
+		},
+	}
+}
+
+// parseOutputTemplate parses the template given either by --template or
+// --template-file, which must be set only when the output format is
+// "template". The template has every function from Sprig available.
+func parseOutputTemplate(c *cli.Context) (*template.Template, error) {
+	text, file := c.String("template"), c.Path("template-file")
+
+	if c.String("output") != "template" {
+		if c.IsSet("template") || c.IsSet("template-file") {
+			return nil, fmt.Errorf("--template and --template-file can only be used along with --output template")
+		}
+
+		return nil, nil
+	}
+
+	if text != "" && file != "" {
+		return nil, fmt.Errorf("--template cannot be used along with --template-file")
+	}
+
+	name := "--template"
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the template file: %w", err)
+		}
+
+		text, name = string(data), file
+	}
+
+	if text == "" {
+		return nil, fmt.Errorf("--output template requires either --template or --template-file")
+	}
+
+	tmpl, err := template.New(name).
+		Funcs(sprig.TxtFuncMap()).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the output template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// writeOutputTemplate executes tmpl against data, ending the output with a
+// new line unless the template already does.
+func writeOutputTemplate(w io.Writer, tmpl *template.Template, data any) error {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return fmt.Errorf("could not execute the output template: %w", err)
+	}
+
+	out := sb.String()
+	if out == "" || out[len(out)-1] != '\n' {
+		out += "\n"
+	}
+
+	_, err := io.WriteString(w, out)
+	return err
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	err := writeCSV(&b, records{
+		Header: []string{"Name", "Description"},
+		Rows: [][]string{
+			{"mango", "Mango flavor"},
+			{"mint", "Mint flavor, with \"fresh\" leaves\nand more"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Name,Description\r\n"+
+		"mango,Mango flavor\r\n"+
+		"mint,\"Mint flavor, with \"\"fresh\"\" leaves\r\nand more\"\r\n", b.String())
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	type item struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	tests := []struct {
+		name     string
+		v        any
+		expected string
+	}{
+		{
+			name: "with a list",
+			v:    []item{{Name: "mango", Description: "Mango flavor"}, {Name: "mint", Description: "Mint flavor,\nwith leaves"}},
+			expected: `{"name":"mango","description":"Mango flavor"}
+{"name":"mint","description":"Mint flavor,\nwith leaves"}
+`,
+		},
+		{
+			name:     "with an empty list",
+			v:        []item(nil),
+			expected: "",
+		},
+		{
+			name:     "with a single item",
+			v:        item{Name: "mango"},
+			expected: "{\"name\":\"mango\",\"description\":\"\"}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			require.NoError(t, writeJSONLines(&b, tt.v))
+			assert.Equal(t, tt.expected, b.String())
+		})
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, writeYAML(&b, []map[string]string{{"name": "mango", "description": "Mango flavor"}}))
+	assert.Equal(t, "- description: Mango flavor\n  name: mango\n", b.String())
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func NewCmdPurge() *cli.Command {
+	return &cli.Command{
+		Name:  "purge",
+		Usage: "Removes objects from the cache of an instance",
+		Description: `Purges the cached responses of the given paths on every running pod of the
+instance, since each pod keeps its own cache. Use --path once per path.
+
+With --path-regexp, paths are taken as POSIX extended regular expressions
+matched against the paths of the cached objects, where a leading ^ anchors
+them at the start of the path, e.g.:
+
+rpaasv2 purge -s my-service -i my-instance --path-regexp -p '^/static/.*\.css$'
+`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:     "path",
+				Aliases:  []string{"p"},
+				Usage:    "path of the objects to purge (may be repeated)",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "preserve-path",
+				Usage: "whether the path must be purged as is, without the query string variations",
+			},
+			&cli.BoolFlag{
+				Name:  "path-regexp",
+				Usage: "whether paths are regular expressions matched against the cache keys",
+			},
+		},
+		Before: setupClient,
+		Action: runPurge,
+	}
+}
+
+func runPurge(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	results, err := client.PurgeCache(c.Context, rpaasclient.PurgeCacheArgs{
+		Instance:     c.String("instance"),
+		Paths:        c.StringSlice("path"),
+		PreservePath: c.Bool("preserve-path"),
+		PathRegexp:   c.Bool("path-regexp"),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = commonPurgeError(results); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Purge of cache in %s:\n", formatInstanceName(c))
+
+	var failed, unmatched int
+	for _, r := range results {
+		writePurgeResult(c.App.Writer, r)
+		if r.Error != "" {
+			failed++
+		} else if isPurgeUnmatched(r) {
+			unmatched++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to purge %d of %d path(s)", failed, len(results))
+	}
+
+	if unmatched > 0 && unmatched == len(results) {
+		fmt.Fprintln(c.App.Writer, "Nothing was purged: no cached object matched on any pod.")
+	}
+
+	return nil
+}
+
+// isPurgeUnmatched tells whether the path has no cached object on any pod.
+func isPurgeUnmatched(r types.PurgeCacheResult) bool {
+	return r.Error == "" && len(r.Pods) > 0 && r.InstancesPurged == 0
+}
+
+// commonPurgeError returns the error shared by all paths when none of them
+// reached any pod, e.g. when the cache is not enabled on the instance.
+func commonPurgeError(results []types.PurgeCacheResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	for _, r := range results {
+		if r.Error == "" || len(r.Pods) > 0 || r.Error != results[0].Error {
+			return nil
+		}
+	}
+
+	return errors.New(results[0].Error)
+}
+
+func writePurgeResult(w io.Writer, r types.PurgeCacheResult) {
+	if len(r.Pods) == 0 && r.Error != "" {
+		fmt.Fprintf(w, "  %s: %s\n", r.Path, r.Error)
+		return
+	}
+
+	if isPurgeUnmatched(r) {
+		fmt.Fprintf(w, "  %s: not cached on any of %d pod(s)\n", r.Path, len(r.Pods))
+		return
+	}
+
+	fmt.Fprintf(w, "  %s: purged on %d of %d pod(s)\n", r.Path, r.InstancesPurged, len(r.Pods))
+	for _, pod := range r.Pods {
+		var status string
+		switch {
+		case pod.Error != "":
+			status = "failed: " + pod.Error
+		case pod.Purged && pod.Objects > 0:
+			status = fmt.Sprintf("purged %d object(s)", pod.Objects)
+		case pod.Purged:
+			status = "purged"
+		default:
+			status = "not cached"
+		}
+
+		fmt.Fprintf(w, "    %s: %s\n", pod.Pod, status)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestPurge(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        client.Client
+	}{
+		{
+			name:          "when PurgeCache method returns an error",
+			args:          []string{"./rpaasv2", "purge", "-i", "my-instance", "-p", "/index.html"},
+			expectedError: "some error",
+			client: &fake.FakeClient{
+				FakePurgeCache: func(args client.PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+					return nil, fmt.Errorf("some error")
+				},
+			},
+		},
+		{
+			name:          "when cache is not enabled on the instance",
+			args:          []string{"./rpaasv2", "purge", "-i", "my-instance", "-p", "/index.html", "-p", "/about.html"},
+			expectedError: `cache is not enabled on instance "my-instance"`,
+			client: &fake.FakeClient{
+				FakePurgeCache: func(args client.PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+					return []types.PurgeCacheResult{
+						{Path: "/index.html", Error: `cache is not enabled on instance "my-instance"`},
+						{Path: "/about.html", Error: `cache is not enabled on instance "my-instance"`},
+					}, nil
+				},
+			},
+		},
+		{
+			name: "purging several paths",
+			args: []string{"./rpaasv2", "purge", "-s", "some-service", "-i", "my-instance", "--path", "/index.html", "--path", "/about.html", "--preserve-path"},
+			expected: `Purge of cache in some-service/my-instance:
+  /index.html: purged on 2 of 2 pod(s)
+    my-instance-1: purged
+    my-instance-2: purged
+  /about.html: purged on 1 of 2 pod(s)
+    my-instance-1: purged
+    my-instance-2: not cached
+`,
+			client: &fake.FakeClient{
+				FakePurgeCache: func(args client.PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+					assert.Equal(t, client.PurgeCacheArgs{Instance: "my-instance", Paths: []string{"/index.html", "/about.html"}, PreservePath: true}, args)
+					return []types.PurgeCacheResult{
+						{Path: "/index.html", InstancesPurged: 2, Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true}, {Pod: "my-instance-2", Purged: true}}},
+						{Path: "/about.html", InstancesPurged: 1, Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true}, {Pod: "my-instance-2"}}},
+					}, nil
+				},
+			},
+		},
+		{
+			name: "purging by regular expression",
+			args: []string{"./rpaasv2", "purge", "-i", "my-instance", "-p", `/static/.*\.css$`, "--path-regexp"},
+			expected: `Purge of cache in my-instance:
+  /static/.*\.css$: purged on 1 of 2 pod(s)
+    my-instance-1: purged 12 object(s)
+    my-instance-2: not cached
+`,
+			client: &fake.FakeClient{
+				FakePurgeCache: func(args client.PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+					assert.Equal(t, client.PurgeCacheArgs{Instance: "my-instance", Paths: []string{`/static/.*\.css$`}, PathRegexp: true}, args)
+					return []types.PurgeCacheResult{
+						{Path: `/static/.*\.css$`, InstancesPurged: 1, Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true, Objects: 12}, {Pod: "my-instance-2"}}},
+					}, nil
+				},
+			},
+		},
+		{
+			name: "purging regular expressions",
+			args: []string{"./rpaasv2", "purge", "-i", "my-instance", "--path-regexp", "-p", "^/index\\.html$", "-p", "^/static/.*"},
+			expected: `Purge of cache in my-instance:
+  ^/index\.html$: not cached on any of 2 pod(s)
+  ^/static/.*: purged on 2 of 2 pod(s)
+    my-instance-1: purged 12 object(s)
+    my-instance-2: purged 3 object(s)
+`,
+			client: &fake.FakeClient{
+				FakePurgeCache: func(args client.PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+					assert.Equal(t, client.PurgeCacheArgs{Instance: "my-instance", Paths: []string{"^/index\\.html$", "^/static/.*"}, PathRegexp: true}, args)
+					return []types.PurgeCacheResult{
+						{Path: "^/index\\.html$", Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1"}, {Pod: "my-instance-2"}}},
+						{Path: "^/static/.*", InstancesPurged: 2, Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true, Objects: 12}, {Pod: "my-instance-2", Purged: true, Objects: 3}}},
+					}, nil
+				},
+			},
+		},
+		{
+			name: "when nothing matches on any pod",
+			args: []string{"./rpaasv2", "purge", "-i", "my-instance", "--path-regexp", "-p", "^/static/.*", "-p", "^/assets/.*"},
+			expected: `Purge of cache in my-instance:
+  ^/static/.*: not cached on any of 2 pod(s)
+  ^/assets/.*: not cached on any of 2 pod(s)
+Nothing was purged: no cached object matched on any pod.
+`,
+			client: &fake.FakeClient{
+				FakePurgeCache: func(args client.PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+					return []types.PurgeCacheResult{
+						{Path: "^/static/.*", Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1"}, {Pod: "my-instance-2"}}},
+						{Path: "^/assets/.*", Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1"}, {Pod: "my-instance-2"}}},
+					}, nil
+				},
+			},
+		},
+		{
+			name: "when purge fails on some pod",
+			args: []string{"./rpaasv2", "purge", "-i", "my-instance", "-p", "/index.html", "-p", "/about.html"},
+			expected: `Purge of cache in my-instance:
+  /index.html: purged on 1 of 2 pod(s)
+    my-instance-1: purged
+    my-instance-2: failed: connection refused
+  /about.html: purged on 2 of 2 pod(s)
+    my-instance-1: purged
+    my-instance-2: purged
+`,
+			expectedError: "failed to purge 1 of 2 path(s)",
+			client: &fake.FakeClient{
+				FakePurgeCache: func(args client.PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+					return []types.PurgeCacheResult{
+						{Path: "/index.html", InstancesPurged: 1, Error: "purge failed on pod(s): my-instance-2", Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true}, {Pod: "my-instance-2", Error: "connection refused"}}},
+						{Path: "/about.html", InstancesPurged: 2, Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true}, {Pod: "my-instance-2", Purged: true}}},
+					}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client)
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+)
+
+const (
+	asciicastDefaultWidth  = 80
+	asciicastDefaultHeight = 24
+)
+
+// currentUser returns the name of the local user running the command.
+var currentUser = func() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+
+	return os.Getenv("USER")
+}
+
+// recordSession starts recording the session described by args when the
+// --record flag is set, returning the writer where the session output must be
+// written to. The recorder is nil when there's nothing to record.
+func recordSession(c *cli.Context, args *rpaasclient.ExecArgs, out io.Writer) (io.Writer, *sessionRecorder, error) {
+	path := c.Path("record")
+	if path == "" {
+		return out, nil, nil
+	}
+
+	target := formatInstanceName(c)
+	if args.Pod != "" {
+		target = fmt.Sprintf("%s (pod %s)", target, args.Pod)
+	}
+
+	username := currentUser()
+	rec, err := newSessionRecorder(path, asciicastHeader{
+		Width:   args.TerminalWidth,
+		Height:  args.TerminalHeight,
+		Command: strings.Join(args.Command, " "),
+		Title:   fmt.Sprintf("Session on %s by %s", target, username),
+		Env:     map[string]string{"TERM": os.Getenv("TERM"), "USER": username},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// NOTE: only the output is recorded, as the input may carry secrets
+	// typed without echo (e.g. passwords).
+	return io.MultiWriter(out, rec.Output()), rec, nil
+}
+
+// asciicastHeader is the first line of a recording in the asciicast v2
+// format, see https://docs.asciinema.org/manual/asciicast/v2/.
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Command   string            `json:"command,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// sessionRecorder writes the output of a remote session into a file in the
+// asciicast v2 format, so it can be replayed later with asciinema.
+//
+// Failing to record must never disturb the session itself, so the writers
+// returned by Output always succeed: the first error found is returned by
+// Close.
+type sessionRecorder struct {
+	mu    sync.Mutex
+	f     *os.File
+	start time.Time
+	err   error
+}
+
+func newSessionRecorder(path string, header asciicastHeader) (*sessionRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the session recording: %w", err)
+	}
+
+	start := timeNow()
+	header.Version, header.Timestamp = 2, start.Unix()
+	if header.Width == 0 || header.Height == 0 {
+		header.Width, header.Height = asciicastDefaultWidth, asciicastDefaultHeight
+	}
+
+	data, err := json.Marshal(header)
+	if err == nil {
+		_, err = fmt.Fprintf(f, "%s\n", data)
+	}
+
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("could not write the session recording: %w", err)
+	}
+
+	return &sessionRecorder{f: f, start: start}, nil
+}
+
+// Output returns a writer recording the data written into it as output.
+func (r *sessionRecorder) Output() io.Writer {
+	return &recorderWriter{r: r, code: "o"}
+}
+
+// Close writes down any pending data and closes the recording file.
+func (r *sessionRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.f.Close(); r.err == nil && err != nil {
+		r.err = err
+	}
+
+	if r.err != nil {
+		return fmt.Errorf("could not write the session recording: %w", r.err)
+	}
+
+	return nil
+}
+
+func (r *sessionRecorder) record(code string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	elapsed := timeNow().Sub(r.start).Seconds()
+	event, err := json.Marshal([]interface{}{json.Number(fmt.Sprintf("%.6f", elapsed)), code, string(data)})
+	if err == nil {
+		// NOTE: every event is written straight into the file, so the
+		// recording is complete up to the moment the session is cut off.
+		_, err = fmt.Fprintf(r.f, "%s\n", event)
+	}
+
+	r.err = err
+}
+
+type recorderWriter struct {
+	r    *sessionRecorder
+	code string
+
+	// pending holds the bytes of an incomplete UTF-8 character until the
+	// rest of it arrives, since events must carry valid strings.
+	pending []byte
+}
+
+func (w *recorderWriter) Write(p []byte) (int, error) {
+	data := append(w.pending, p...)
+
+	n := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				n = i
+			}
+			break
+		}
+	}
+
+	w.pending = append([]byte{}, data[n:]...)
+	if n > 0 {
+		w.r.record(w.code, data[:n])
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+)
+
+// fakeClock makes timeNow advance step on every call until the test ends.
+func fakeClock(t *testing.T, step time.Duration) {
+	now := time.Date(2023, time.May, 1, 12, 0, 0, 0, time.UTC)
+	previous := timeNow
+	t.Cleanup(func() { timeNow = previous })
+
+	timeNow = func() time.Time {
+		current := now
+		now = now.Add(step)
+		return current
+	}
+}
+
+func TestExecRecord(t *testing.T) {
+	fakeClock(t, 500*time.Millisecond)
+	defer func(f func() string) { currentUser = f }(currentUser)
+	currentUser = func() string { return "alice" }
+	t.Setenv("TERM", "xterm-256color")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// NOTE: the "ö" character is split between two messages.
+		conn.WriteMessage(websocket.TextMessage, []byte("hello w\xc3"))
+		conn.WriteMessage(websocket.TextMessage, []byte("\xb6rld\n"))
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	fakeClient := &fake.FakeClient{
+		FakeExec: func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+			conn, _, err := websocket.DefaultDialer.DialContext(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+			return conn, err
+		},
+	}
+
+	record := filepath.Join(t.TempDir(), "session.cast")
+
+	stdout := &bytes.Buffer{}
+	err := NewApp(stdout, &bytes.Buffer{}, fakeClient).Run([]string{"rpaasv2", "exec", "-s", "rpaasv2", "-i", "my-instance", "-p", "my-instance-abc", "--record", record, "--", "echo", "hello wörld"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello wörld\n", stdout.String())
+
+	data, err := os.ReadFile(record)
+	require.NoError(t, err)
+	assert.Equal(t, `{"version":2,"width":80,"height":24,"timestamp":1682942400,"command":"echo hello wörld","title":"Session on rpaasv2/my-instance (pod my-instance-abc) by alice","env":{"TERM":"xterm-256color","USER":"alice"}}
+[0.500000,"o","hello w"]
+[1.000000,"o","örld\n"]
+`, string(data))
+
+	t.Run("along with --file", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{}).Run([]string{"rpaasv2", "exec", "-i", "my-instance", "--file", "-", "--destination", "/tmp/foo", "--record", record})
+		assert.EqualError(t, err, "--record cannot be used along with --file")
+	})
+}
+
+func TestSessionRecorder(t *testing.T) {
+	fakeClock(t, time.Second)
+
+	path := filepath.Join(t.TempDir(), "session.cast")
+	rec, err := newSessionRecorder(path, asciicastHeader{Width: 120, Height: 40, Command: "bash"})
+	require.NoError(t, err)
+
+	rec.Output().Write([]byte("$ "))
+	rec.Output().Write([]byte("ls\r\nnginx.conf\r\n$ "))
+	require.NoError(t, rec.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"version":2,"width":120,"height":40,"timestamp":1682942400,"command":"bash"}
+[1.000000,"o","$ "]
+[2.000000,"o","ls\r\nnginx.conf\r\n$ "]
+`, string(data))
+}
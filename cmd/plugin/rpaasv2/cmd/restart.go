@@ -0,0 +1,107 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+)
+
+func NewCmdRestart() *cli.Command {
+	return &cli.Command{
+		Name:  "restart",
+		Usage: "Restarts the pods of an instance",
+		Description: `Performs a rolling restart of the instance pods by default, i.e. new pods
+are started before the older ones are terminated. Use --immediate to delete
+all pods at once (it may cause downtime, use it in emergencies only).
+`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "immediate",
+				Usage: "whether should delete all pods at once instead of performing a rolling restart",
+			},
+			&cli.BoolFlag{
+				Name:  "wait",
+				Usage: "whether should wait until the new pods are ready",
+			},
+			&cli.DurationFlag{
+				Name:  "wait-timeout",
+				Usage: "time limit to wait for the new pods to become ready (requires --wait)",
+				Value: 5 * time.Minute,
+			},
+		},
+		Before: setupClient,
+		Action: runRestart,
+	}
+}
+
+func runRestart(c *cli.Context) error {
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	args := rpaasclient.RestartArgs{
+		Instance:  c.String("instance"),
+		Immediate: c.Bool("immediate"),
+	}
+
+	var replicas int32
+	if c.Bool("wait") {
+		info, err := client.Info(c.Context, rpaasclient.InfoArgs{Instance: args.Instance})
+		if err != nil {
+			return err
+		}
+
+		if info.Replicas != nil {
+			replicas = *info.Replicas
+		}
+	}
+
+	pods, err := client.Restart(c.Context, args)
+	if err != nil {
+		return err
+	}
+
+	mode := "Rolling restart"
+	if args.Immediate {
+		mode = "Immediate restart"
+	}
+
+	fmt.Fprintf(c.App.Writer, "%s of %s triggered, cycled pods:\n", mode, formatInstanceName(c))
+	for _, pod := range pods {
+		fmt.Fprintf(c.App.Writer, "  %s\n", pod)
+	}
+
+	if !c.Bool("wait") {
+		return nil
+	}
+
+	if replicas == 0 {
+		replicas = int32(len(pods))
+	}
+
+	return waitForReadyReplicas(c.Context, client, c.App.Writer, waitReadyReplicasArgs{
+		Instance: args.Instance,
+		Replicas: replicas,
+		Timeout:  c.Duration("wait-timeout"),
+		Ignored:  pods,
+	})
+}
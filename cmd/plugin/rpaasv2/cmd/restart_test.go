@@ -0,0 +1,120 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestRestart(t *testing.T) {
+	defer func(d time.Duration) { waitPollInterval = d }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        client.Client
+	}{
+		{
+			name:          "when Restart method returns an error",
+			args:          []string{"./rpaasv2", "restart", "-i", "my-instance"},
+			expectedError: "some error",
+			client: &fake.FakeClient{
+				FakeRestart: func(args client.RestartArgs) ([]string, error) {
+					return nil, fmt.Errorf("some error")
+				},
+			},
+		},
+		{
+			name:     "rolling restart",
+			args:     []string{"./rpaasv2", "restart", "-s", "some-service", "-i", "my-instance"},
+			expected: "Rolling restart of some-service/my-instance triggered, cycled pods:\n  my-instance-abc\n  my-instance-def\n",
+			client: &fake.FakeClient{
+				FakeRestart: func(args client.RestartArgs) ([]string, error) {
+					assert.Equal(t, client.RestartArgs{Instance: "my-instance"}, args)
+					return []string{"my-instance-abc", "my-instance-def"}, nil
+				},
+			},
+		},
+		{
+			name:     "immediate restart",
+			args:     []string{"./rpaasv2", "restart", "-i", "my-instance", "--immediate"},
+			expected: "Immediate restart of my-instance triggered, cycled pods:\n  my-instance-abc\n",
+			client: &fake.FakeClient{
+				FakeRestart: func(args client.RestartArgs) ([]string, error) {
+					assert.Equal(t, client.RestartArgs{Instance: "my-instance", Immediate: true}, args)
+					return []string{"my-instance-abc"}, nil
+				},
+			},
+		},
+		{
+			name:     "waiting new pods to be ready",
+			args:     []string{"./rpaasv2", "restart", "-i", "my-instance", "--wait"},
+			expected: "Rolling restart of my-instance triggered, cycled pods:\n  my-instance-abc\n  my-instance-def\nWaiting for replicas: 0 of 2 ready\nWaiting for replicas: 1 of 2 ready\nWaiting for replicas: 2 of 2 ready\nAll 2 replica(s) are ready\n",
+			client: func() client.Client {
+				calls := 0
+				replicas := int32(2)
+				return &fake.FakeClient{
+					FakeRestart: func(args client.RestartArgs) ([]string, error) {
+						return []string{"my-instance-abc", "my-instance-def"}, nil
+					},
+					FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+						calls++
+						pods := []types.Pod{{Name: "my-instance-abc", Ready: true}, {Name: "my-instance-def", Ready: true}}
+						switch calls {
+						case 1, 2:
+						case 3:
+							pods = append(pods[1:], types.Pod{Name: "my-instance-ghi", Ready: true}, types.Pod{Name: "my-instance-jkl"})
+						default:
+							pods = []types.Pod{{Name: "my-instance-ghi", Ready: true}, {Name: "my-instance-jkl", Ready: true}}
+						}
+						return &types.InstanceInfo{Replicas: &replicas, Pods: pods}, nil
+					},
+				}
+			}(),
+		},
+		{
+			name:          "when new pods do not become ready in time",
+			args:          []string{"./rpaasv2", "restart", "-i", "my-instance", "--wait", "--wait-timeout", "10ms"},
+			expectedError: "timed out waiting for replicas to be ready: 0 of 1 ready",
+			client: &fake.FakeClient{
+				FakeRestart: func(args client.RestartArgs) ([]string, error) {
+					return []string{"my-instance-abc"}, nil
+				},
+				FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{Pods: []types.Pod{{Name: "my-instance-abc", Ready: true}}}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client)
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
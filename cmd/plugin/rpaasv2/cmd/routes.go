@@ -5,11 +5,21 @@
 package cmd
 
 import (
-	"encoding/json"
+	"bytes"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"math"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/urfave/cli/v2"
@@ -23,9 +33,9 @@ func NewCmdRoutes() *cli.Command {
 		Name:  "routes",
 		Usage: "Manages application-layer routing (NGINX locations)",
 		Subcommands: []*cli.Command{
-			NewCmdDeleteRoute(),
+			withInstanceFile(NewCmdDeleteRoute()),
 			NewCmdListRoutes(),
-			NewCmdUpdateRoute(),
+			withInstanceFile(NewCmdUpdateRoute()),
 		},
 	}
 }
@@ -35,6 +45,13 @@ func NewCmdDeleteRoute() *cli.Command {
 		Name:    "delete",
 		Aliases: []string{"remove"},
 		Usage:   "Removes a route from a path",
+		Description: `
+# Remove the route on a single path:
+rpaasv2 routes delete -s my-service -i my-instance -p /api/v1
+
+# Remove every route under a path prefix, confirming before:
+rpaasv2 routes delete -s my-service -i my-instance --path-prefix /api/
+`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "service",
@@ -48,10 +65,22 @@ func NewCmdDeleteRoute() *cli.Command {
 				Required: true,
 			},
 			&cli.StringFlag{
-				Name:     "path",
-				Aliases:  []string{"p"},
-				Usage:    "path name",
-				Required: true,
+				Name:    "path",
+				Aliases: []string{"p"},
+				Usage:   "path name",
+			},
+			&cli.StringFlag{
+				Name:  "path-prefix",
+				Usage: "removes every route whose path starts with this prefix (should not be combined with path)",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "only shows the routes matching the path prefix without removing them",
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "removes the routes matching the path prefix without asking for confirmation",
 			},
 		},
 		Before: setupClient,
@@ -65,6 +94,14 @@ func runDeleteRoute(c *cli.Context) error {
 		return err
 	}
 
+	if c.IsSet("path") == c.IsSet("path-prefix") {
+		return fmt.Errorf("either --path or --path-prefix must be provided")
+	}
+
+	if c.IsSet("path-prefix") {
+		return deleteRoutesByPrefix(c, client)
+	}
+
 	args := rpaasclient.DeleteRouteArgs{
 		Instance: c.String("instance"),
 		Path:     c.String("path"),
@@ -75,6 +112,59 @@ func runDeleteRoute(c *cli.Context) error {
 	}
 
 	fmt.Fprintf(c.App.Writer, "Route %q deleted.\n", args.Path)
+
+	// NOTE: the zone must outlive the route using it, otherwise NGINX would
+	// refuse the configuration in between.
+	return removeRateLimitZone(c, client, rateLimitZoneName(args.Path))
+}
+
+func deleteRoutesByPrefix(c *cli.Context, client rpaasclient.Client) error {
+	instance, prefix := c.String("instance"), c.String("path-prefix")
+	routes, err := client.ListRoutes(c.Context, rpaasclient.ListRoutesArgs{Instance: instance})
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, r := range routes {
+		if strings.HasPrefix(r.Path, prefix) {
+			paths = append(paths, r.Path)
+		}
+	}
+
+	if len(paths) == 0 {
+		fmt.Fprintf(c.App.Writer, "No routes matched the path prefix %q.\n", prefix)
+		return nil
+	}
+
+	fmt.Fprintf(c.App.Writer, "Routes matching the path prefix %q:\n", prefix)
+	for _, path := range paths {
+		fmt.Fprintf(c.App.Writer, "  %s\n", path)
+	}
+
+	if c.Bool("dry-run") {
+		return nil
+	}
+
+	if !c.Bool("yes") {
+		confirmed, err := askForConfirmation(c, fmt.Sprintf("Are you sure you want to remove %d route(s) from %s?", len(paths), formatInstanceName(c)))
+		if err != nil || !confirmed {
+			return err
+		}
+	}
+
+	for _, path := range paths {
+		if err = client.DeleteRoute(c.Context, rpaasclient.DeleteRouteArgs{Instance: instance, Path: path}); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "Route %q deleted.\n", path)
+
+		if err = removeRateLimitZone(c, client, rateLimitZoneName(path)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -82,7 +172,7 @@ func NewCmdListRoutes() *cli.Command {
 	return &cli.Command{
 		Name:  "list",
 		Usage: "Shows the routes on the instance",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "service",
 				Aliases: []string{"tsuru-service", "s"},
@@ -97,10 +187,11 @@ func NewCmdListRoutes() *cli.Command {
 			&cli.BoolFlag{
 				Name:    "raw-output",
 				Aliases: []string{"r"},
-				Usage:   "show as JSON instead of table format",
+				Usage:   "show as JSON instead of table format (same as --output json)",
 				Value:   false,
 			},
-		},
+			outputFlag("table", "json", "yaml", "csv", "jsonl"),
+		}, append(sortFlags("path", "destination"), outputFieldFlags()...)...),
 		Before: setupClient,
 		Action: runListRoutes,
 	}
@@ -118,18 +209,80 @@ func runListRoutes(c *cli.Context) error {
 		return err
 	}
 
+	err = sortList(c, routes, map[string]func(a, b clientTypes.Route) int{
+		"path": func(a, b clientTypes.Route) int { return strings.Compare(a.Path, b.Path) },
+		"destination": func(a, b clientTypes.Route) int {
+			return strings.Compare(routeDestination(a), routeDestination(b))
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	format := c.String("output")
 	if c.Bool("raw-output") {
-		return writeRoutesOnJSONFormat(c.App.Writer, routes)
+		format = "json"
 	}
 
-	writeRoutesOnTableFormat(c.App.Writer, routes)
-	return nil
+	return writeListOutput(c, format, routesWithHeaders(routes), routesRecords(routes), func(w io.Writer) error {
+		writeRoutesOnTableFormat(w, routes)
+		return nil
+	})
 }
 
 func writeRoutesOnTableFormat(w io.Writer, routes []clientTypes.Route) {
 	data := [][]string{}
 	for _, r := range routes {
-		data = append(data, []string{r.Path, r.Destination, checkedChar(r.HTTPSOnly), r.Content})
+		destination, content := r.Destination, r.Content
+		if len(r.Destinations) > 0 {
+			destination = formatWeightedDestinations(r.Destinations)
+		}
+
+		var headers string
+		if h, rest, ok := parseHeadersRoute(content); ok {
+			headers, content = "headers: "+formatRouteHeaders(h), rest
+		}
+
+		var timeouts string
+		if t, rest, ok := parseProxyTimeoutsRoute(content); ok {
+			timeouts, content = "proxy timeouts: "+formatProxyTimeouts(t), rest
+		}
+
+		var auth string
+		if realm, rest, ok := parseBasicAuthRoute(content); ok {
+			auth, content = fmt.Sprintf("basic auth (realm %q)", realm), rest
+		}
+
+		var limit string
+		if rate, burst, rest, ok := parseRateLimitRoute(content); ok {
+			limit, content = fmt.Sprintf("rate limited to %s (burst %d)", rate, burst), rest
+		}
+
+		if code, target, ok := parseRedirectRoute(content); ok {
+			destination, content = fmt.Sprintf("redirect (%d) to %s", code, target), ""
+		}
+
+		if origins, rest, ok := parseCORSRoute(content); ok {
+			destination, content = fmt.Sprintf("CORS from %s", strings.Join(origins, ", ")), rest
+		}
+
+		if limit != "" {
+			destination = strings.TrimPrefix(destination+"\n"+limit, "\n")
+		}
+
+		if auth != "" {
+			destination = strings.TrimPrefix(destination+"\n"+auth, "\n")
+		}
+
+		if timeouts != "" {
+			destination = strings.TrimPrefix(destination+"\n"+timeouts, "\n")
+		}
+
+		if headers != "" {
+			destination = strings.TrimPrefix(destination+"\n"+headers, "\n")
+		}
+
+		data = append(data, []string{r.Path, destination, checkedChar(r.HTTPSOnly), content})
 	}
 
 	table := tablewriter.NewWriter(w)
@@ -142,14 +295,23 @@ func writeRoutesOnTableFormat(w io.Writer, routes []clientTypes.Route) {
 	table.Render()
 }
 
-func writeRoutesOnJSONFormat(w io.Writer, routes []clientTypes.Route) error {
-	message, err := json.MarshalIndent(routes, "", "\t")
-	if err != nil {
-		return err
+func routesRecords(routes []clientTypes.Route) records {
+	rec := records{Header: []string{"Path", "Destination", "Force HTTPS?", "Configuration"}}
+	for _, r := range routes {
+		rec.Rows = append(rec.Rows, []string{r.Path, routeDestination(r), strconv.FormatBool(r.HTTPSOnly), r.Content})
 	}
 
-	fmt.Fprintln(w, string(message))
-	return nil
+	return rec
+}
+
+// routeDestination returns the destination of r as shown on lists, i.e. its
+// weighted destinations when any.
+func routeDestination(r clientTypes.Route) string {
+	if len(r.Destinations) > 0 {
+		return formatWeightedDestinations(r.Destinations)
+	}
+
+	return r.Destination
 }
 
 func checkedChar(b bool) string {
@@ -165,7 +327,62 @@ func NewCmdUpdateRoute() *cli.Command {
 		Name:    "update",
 		Aliases: []string{"add"},
 		Usage:   "Inserts a NGINX location on a path",
-		Flags: []cli.Flag{
+		Description: `
+# Forward the requests on a path to an application:
+rpaasv2 routes update -s my-service -i my-instance -p /api -d app.tsuru.example.com
+
+# Split the requests on a path between two applications (e.g. a canary release),
+# sending 90% of them to the first one and 10% to the second:
+rpaasv2 routes update -s my-service -i my-instance -p /api -d app.tsuru.example.com --weight 9 -d app-canary.tsuru.example.com --weight 1
+
+# Permanently redirect the requests on a path to another site, keeping the request URI:
+rpaasv2 routes update -s my-service -i my-instance -p /old --redirect 'https://new.example.com$request_uri'
+
+# Temporarily redirect the requests on a path:
+rpaasv2 routes update -s my-service -i my-instance -p /promo --redirect https://promo.example.com --redirect-code 302
+
+# Allow cross-origin requests from two sites, answering the CORS preflight
+# requests and proxying the other ones as set on the content file:
+rpaasv2 routes update -s my-service -i my-instance -p /api --content-file ./routes/api.conf \
+  --cors-origin https://app.example.com --cors-origin https://admin.example.com \
+  --cors-methods GET --cors-methods POST --cors-headers Authorization --cors-credentials
+
+# Limit the requests on a path to 10 per second from each client address, allowing
+# bursts of up to 20 requests and rejecting the exceeding ones with 429 Too Many Requests:
+rpaasv2 routes update -s my-service -i my-instance -p /api --content-file ./routes/api.conf --rate-limit 10r/s --rate-limit-burst 20
+
+# Wait up to 5 minutes for the responses of a slow backend (NGINX waits 60s by default):
+rpaasv2 routes update -s my-service -i my-instance -p /reports --content-file ./routes/reports.conf --proxy-read-timeout 5m
+
+# Add security headers to the responses on a path, hiding the NGINX version
+# from them and telling the backend which site the requests came through:
+rpaasv2 routes update -s my-service -i my-instance -p /app --content-file ./routes/app.conf \
+  --add-response-header 'X-Frame-Options: DENY' --add-response-header 'Strict-Transport-Security: max-age=31536000' \
+  --remove-header Server --add-request-header 'X-Forwarded-Site: www.example.com'
+
+# Require HTTP basic auth on a path, reading the password from the standard input
+# so that it doesn't end up in the shell history (it's hashed with bcrypt before
+# being sent, along with the user, as an extra file of the instance):
+rpaasv2 routes update -s my-service -i my-instance -p /admin --content-file ./routes/admin.conf --basic-auth-user admin < password.txt
+
+# Require HTTP basic auth from the users of an htpasswd file (e.g. made with
+# "htpasswd -B -c admins.htpasswd alice"), replacing the previous users:
+rpaasv2 routes update -s my-service -i my-instance -p /admin --content-file ./routes/admin.conf --htpasswd-file admins.htpasswd
+
+# The rate limit sets a shared memory zone named after the path on the http block,
+# taking 10 MB of memory from each NGINX pod (enough to track about 160 thousand
+# client addresses) for every rate-limited route. The zone is removed from the http
+# block along with its route by "rpaasv2 routes delete".
+
+# Use a custom NGINX configuration, inlining the shared snippets it includes
+# (e.g. "include snippets/cors.conf;"), resolved from the directory of the file:
+rpaasv2 routes update -s my-service -i my-instance -p /api --content-file ./routes/api.conf --expand-includes
+
+# Fill the ${VAR} placeholders of the content file (e.g. "proxy_pass http://${BACKEND_HOST};")
+# from the environment, writing $$ wherever a literal $ is meant:
+BACKEND_HOST=api.internal rpaasv2 routes update -s my-service -i my-instance -p /api --content-file ./routes/api.conf --expand-env
+`,
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "service",
 				Aliases: []string{"tsuru-service", "s"},
@@ -183,21 +400,119 @@ func NewCmdUpdateRoute() *cli.Command {
 				Usage:    "path name",
 				Required: true,
 			},
-			&cli.StringFlag{
+			&cli.StringSliceFlag{
 				Name:    "destination",
 				Aliases: []string{"d"},
-				Usage:   "host address that all request will be forwarded for",
+				Usage:   "host address that all request will be forwarded for (can be used multiple times along with --weight)",
+			},
+			&cli.IntSliceFlag{
+				Name:  "weight",
+				Usage: "positive integer weight of each destination, in the order they were set, splitting the requests among them accordingly (can be used multiple times)",
 			},
 			&cli.BoolFlag{
-				Name:  "https-only",
-				Usage: "indicates whether should only be accessed over TLS (requires that destination be set)",
+				Name:    "https-only",
+				Aliases: []string{"force-https"},
+				Usage:   "indicates whether should only be accessed over TLS (requires that destination be set). When omitted, the current setting of the route is kept, so unset it with --https-only=false",
 			},
 			&cli.PathFlag{
 				Name:    "content",
 				Aliases: []string{"content-file", "c"},
 				Usage:   "path in the system to the NGINX configuration (should not be combined with destination)",
 			},
-		},
+			&cli.BoolFlag{
+				Name:  "expand-includes",
+				Usage: "inlines the include directives of the NGINX configuration set on --content, which must point to relative paths within the directory of that file",
+			},
+			&cli.IntFlag{
+				Name:  "max-include-depth",
+				Usage: "the maximum nesting level of the includes expanded by --expand-includes",
+				Value: 10,
+			},
+			&cli.StringFlag{
+				Name:  "redirect",
+				Usage: "URL where the requests on the path are redirected to (should not be combined with destination nor content)",
+			},
+			&cli.IntFlag{
+				Name:  "redirect-code",
+				Usage: fmt.Sprintf("HTTP status code of the redirect (one of: %s)", strings.Join(redirectCodes(), ", ")),
+				Value: 301,
+			},
+			&cli.StringSliceFlag{
+				Name:  "cors-origin",
+				Usage: "origin (e.g. https://app.example.com) or \"*\" allowed to make cross-origin requests, generating the CORS headers and preflight handling ahead of the content (should not be combined with destination nor redirect, can be used multiple times)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "cors-methods",
+				Usage: fmt.Sprintf("HTTP method allowed on cross-origin requests (requires --cors-origin, can be used multiple times) (default: %s)", strings.Join(defaultCORSMethods, ", ")),
+			},
+			&cli.StringSliceFlag{
+				Name:  "cors-headers",
+				Usage: "request header allowed on cross-origin requests (requires --cors-origin, can be used multiple times)",
+			},
+			&cli.BoolFlag{
+				Name:  "cors-credentials",
+				Usage: "allow cross-origin requests with credentials, e.g. cookies (requires --cors-origin, cannot be used along with \"*\")",
+			},
+			&cli.StringFlag{
+				Name:  "rate-limit",
+				Usage: "maximum rate of requests from each client address on the path, per second or minute (e.g. 10r/s or 600r/m), rejecting the exceeding ones with 429 Too Many Requests (should not be combined with destination nor redirect)",
+			},
+			&cli.IntFlag{
+				Name:  "rate-limit-burst",
+				Usage: "number of requests from a client address accepted at once beyond --rate-limit (requires --rate-limit)",
+			},
+			&cli.DurationFlag{
+				Name:  "proxy-read-timeout",
+				Usage: "time limit between two reads of the response from the backend, after which NGINX answers with 504 Gateway Timeout (e.g. 2m, should not be combined with destination nor redirect)",
+			},
+			&cli.DurationFlag{
+				Name:  "proxy-connect-timeout",
+				Usage: "time limit to connect to the backend, which usually cannot exceed 75s (e.g. 5s, should not be combined with destination nor redirect)",
+			},
+			&cli.DurationFlag{
+				Name:  "proxy-send-timeout",
+				Usage: "time limit between two writes of the request to the backend (e.g. 2m, should not be combined with destination nor redirect)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "add-response-header",
+				Usage: "header like \"Name: value\" added to the responses on the path, including the error ones (should not be combined with destination nor redirect, can be used multiple times)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "add-request-header",
+				Usage: "header like \"Name: value\" set on the requests proxied to the backend (should not be combined with destination nor redirect, can be used multiple times)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "remove-header",
+				Usage: "name of a header removed from the responses on the path, e.g. Server (should not be combined with destination nor redirect, can be used multiple times)",
+			},
+			&cli.StringFlag{
+				Name:  "basic-auth-user",
+				Usage: "user allowed on the path through HTTP basic auth, whose password is hashed before being sent (should not be combined with destination nor redirect)",
+			},
+			&cli.StringFlag{
+				Name:        "basic-auth-password",
+				Usage:       "password of --basic-auth-user (requires --basic-auth-user)",
+				DefaultText: "read from the standard input",
+			},
+			&cli.StringFlag{
+				Name:  "basic-auth-hash",
+				Usage: fmt.Sprintf("hash function of --basic-auth-password (one of: %s), where apr1 is meant for NGINX builds whose crypt() lacks bcrypt (requires --basic-auth-user)", strings.Join(basicAuthHashes, ", ")),
+				Value: "bcrypt",
+			},
+			&cli.PathFlag{
+				Name:  "htpasswd-file",
+				Usage: "path in the system to an htpasswd file with the users allowed on the path through HTTP basic auth, holding hashed passwords only, e.g. made with \"htpasswd -B\" (should not be combined with --basic-auth-user)",
+			},
+			&cli.StringFlag{
+				Name:  "basic-auth-realm",
+				Usage: "realm of the HTTP basic auth, shown by browsers when asking for the credentials (requires --basic-auth-user or --htpasswd-file)",
+				Value: "Restricted",
+			},
+			&cli.BoolFlag{
+				Name:  "if-changed",
+				Usage: "skip the update when the route is already set as given",
+			},
+		}, expandEnvFlags()...),
 		Before: setupClient,
 		Action: runUpdateRoute,
 	}
@@ -214,32 +529,883 @@ func runUpdateRoute(c *cli.Context) error {
 		return err
 	}
 
+	var included []string
+	if c.Bool("expand-includes") {
+		content, included, err = expandContentIncludes(c, content)
+		if err != nil {
+			return err
+		}
+	} else if c.IsSet("max-include-depth") {
+		return fmt.Errorf("--max-include-depth can only be used along with --expand-includes")
+	}
+
+	content, err = expandEnvFromFlags(c, content)
+	if err != nil {
+		return err
+	}
+
+	if c.IsSet("redirect") {
+		if c.IsSet("destination") || c.IsSet("content") {
+			return fmt.Errorf("--redirect cannot be used along with --destination or --content")
+		}
+
+		content, err = redirectRouteContent(c.String("redirect"), c.Int("redirect-code"))
+		if err != nil {
+			return err
+		}
+	} else if c.IsSet("redirect-code") {
+		return fmt.Errorf("--redirect-code can only be used along with --redirect")
+	}
+
+	if c.IsSet("cors-origin") {
+		if c.IsSet("destination") || c.IsSet("redirect") {
+			return fmt.Errorf("--cors-origin cannot be used along with --destination or --redirect, set the proxy_pass on --content instead")
+		}
+
+		var cors []byte
+		cors, err = corsRouteContent(c.StringSlice("cors-origin"), c.StringSlice("cors-methods"), c.StringSlice("cors-headers"), c.Bool("cors-credentials"))
+		if err != nil {
+			return err
+		}
+
+		content = append(cors, content...)
+	} else if c.IsSet("cors-methods") || c.IsSet("cors-headers") || c.IsSet("cors-credentials") {
+		return fmt.Errorf("--cors-methods, --cors-headers and --cors-credentials can only be used along with --cors-origin")
+	}
+
+	var zone string
+	if c.IsSet("rate-limit") {
+		if c.IsSet("destination") || c.IsSet("redirect") {
+			return fmt.Errorf("--rate-limit cannot be used along with --destination or --redirect, set the proxy_pass on --content instead")
+		}
+
+		zone = rateLimitZoneName(c.String("path"))
+
+		var limit []byte
+		limit, err = rateLimitRouteContent(zone, c.String("rate-limit"), c.Int("rate-limit-burst"))
+		if err != nil {
+			return err
+		}
+
+		content = append(limit, content...)
+	} else if c.IsSet("rate-limit-burst") {
+		return fmt.Errorf("--rate-limit-burst can only be used along with --rate-limit")
+	}
+
+	var htpasswdFile string
+	if c.IsSet("basic-auth-user") || c.IsSet("htpasswd-file") {
+		if c.IsSet("destination") || c.IsSet("redirect") {
+			return fmt.Errorf("--basic-auth-user and --htpasswd-file cannot be used along with --destination or --redirect, set the proxy_pass on --content instead")
+		}
+
+		htpasswdFile = basicAuthFileName(c.String("path"))
+
+		var auth []byte
+		auth, err = basicAuthRouteContent(htpasswdFile, c.String("basic-auth-realm"))
+		if err != nil {
+			return err
+		}
+
+		content = append(auth, content...)
+	}
+
+	if timeouts := proxyTimeoutsFromFlags(c); len(timeouts) > 0 {
+		if c.IsSet("destination") || c.IsSet("redirect") {
+			return fmt.Errorf("--proxy-read-timeout, --proxy-connect-timeout and --proxy-send-timeout cannot be used along with --destination or --redirect, set the proxy_pass on --content instead")
+		}
+
+		var directives []byte
+		directives, err = proxyTimeoutsRouteContent(timeouts)
+		if err != nil {
+			return err
+		}
+
+		content = append(directives, content...)
+	}
+
+	headers, err := routeHeadersFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	if headers != nil {
+		if c.IsSet("destination") || c.IsSet("redirect") {
+			return fmt.Errorf("--add-response-header, --add-request-header and --remove-header cannot be used along with --destination or --redirect, set the proxy_pass on --content instead")
+		}
+
+		content = append(headersRouteContent(headers), content...)
+	}
+
+	htpasswd, err := htpasswdFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	destination, destinations, err := routeDestinationsFromFlags(c)
+	if err != nil {
+		return err
+	}
+
 	args := rpaasclient.UpdateRouteArgs{
-		Instance:    c.String("instance"),
-		Path:        c.String("path"),
-		Destination: c.String("destination"),
-		HTTPSOnly:   c.Bool("https-only"),
-		Content:     string(content),
+		Instance:     c.String("instance"),
+		Path:         c.String("path"),
+		Destination:  destination,
+		Destinations: destinations,
+		HTTPSOnly:    c.Bool("https-only"),
+		Content:      string(content),
+	}
+
+	if !c.IsSet("https-only") && (args.Destination != "" || len(args.Destinations) > 0) {
+		args.HTTPSOnly, err = isRouteHTTPSOnly(c, client, args.Path)
+		if err != nil {
+			return err
+		}
+	}
+
+	// NOTE: the users are uploaded even when the route is unchanged, as
+	// their passwords may have changed, and before the route which requires
+	// the file to be there.
+	if htpasswd != nil {
+		if err = setBasicAuthFile(c, client, htpasswdFile, htpasswd); err != nil {
+			return err
+		}
+	}
+
+	if c.Bool("if-changed") {
+		var unchanged bool
+		unchanged, err = isRouteUnchanged(c, client, args)
+		if err != nil {
+			return err
+		}
+
+		if unchanged {
+			fmt.Fprintf(c.App.Writer, "Route %q unchanged.\n", args.Path)
+			return nil
+		}
+	}
+
+	// NOTE: the zone must be there before the route using it, otherwise
+	// NGINX would refuse the configuration in between.
+	if zone != "" {
+		if err = setRateLimitZone(c, client, zone, c.String("rate-limit")); err != nil {
+			return err
+		}
 	}
+
 	err = client.UpdateRoute(c.Context, args)
 	if err != nil {
 		return err
 	}
 
 	fmt.Fprintf(c.App.Writer, "Route %q updated.\n", args.Path)
+
+	for _, f := range included {
+		fmt.Fprintf(c.App.Writer, "Inlined %s\n", f)
+	}
+
+	if args.HTTPSOnly {
+		return warnIfNoCertificates(c, client, args.Path)
+	}
+
 	return nil
 }
 
+// routeDestinationsFromFlags returns either the single destination of the
+// route or its weighted destinations, whose weights are reduced by their
+// greatest common divisor (e.g. 90 and 10 become 9 and 1).
+func routeDestinationsFromFlags(c *cli.Context) (string, []clientTypes.WeightedDestination, error) {
+	destinations, weights := c.StringSlice("destination"), c.IntSlice("weight")
+	if len(weights) == 0 {
+		if len(destinations) > 1 {
+			return "", nil, fmt.Errorf("--weight must be set for each --destination when there are many of them")
+		}
+
+		if len(destinations) == 0 {
+			return "", nil, nil
+		}
+
+		return destinations[0], nil, nil
+	}
+
+	if len(weights) != len(destinations) {
+		return "", nil, fmt.Errorf("--weight must be set for each --destination (got %d weight(s) for %d destination(s))", len(weights), len(destinations))
+	}
+
+	if len(destinations) < 2 {
+		return "", nil, fmt.Errorf("--weight requires at least two destinations")
+	}
+
+	divisor := 0
+	seen := make(map[string]bool)
+	for i, d := range destinations {
+		if weights[i] <= 0 || weights[i] > math.MaxInt32 {
+			return "", nil, fmt.Errorf("invalid weight %d of destination %q: must be a positive integer", weights[i], d)
+		}
+
+		if seen[d] {
+			return "", nil, fmt.Errorf("destination %q is set more than once", d)
+		}
+
+		seen[d] = true
+		divisor = gcd(divisor, weights[i])
+	}
+
+	var weighted []clientTypes.WeightedDestination
+	for i, d := range destinations {
+		weighted = append(weighted, clientTypes.WeightedDestination{Destination: d, Weight: int32(weights[i] / divisor)})
+	}
+
+	return "", weighted, nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
+
+// formatWeightedDestinations returns the destinations of the route, one per
+// line, along with their weight and share of the requests.
+func formatWeightedDestinations(destinations []clientTypes.WeightedDestination) string {
+	var total int64
+	for _, d := range destinations {
+		total += int64(d.Weight)
+	}
+
+	var lines []string
+	for _, d := range destinations {
+		share := math.Round(float64(d.Weight)*1000/float64(total)) / 10
+		lines = append(lines, fmt.Sprintf("%s (weight %d, %s%%)", d.Destination, d.Weight, strconv.FormatFloat(share, 'f', -1, 64)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// isRouteHTTPSOnly returns the HTTPS-only setting of the route on path, so
+// that updating it does not unset that by accident.
+func isRouteHTTPSOnly(c *cli.Context, client rpaasclient.Client, path string) (bool, error) {
+	routes, err := client.ListRoutes(c.Context, rpaasclient.ListRoutesArgs{Instance: c.String("instance")})
+	if err != nil {
+		return false, err
+	}
+
+	for _, r := range routes {
+		if r.Path == path {
+			return r.HTTPSOnly, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isRouteUnchanged tells whether the route on args is already set on the
+// instance, disregarding the trailing whitespace of its content.
+func isRouteUnchanged(c *cli.Context, client rpaasclient.Client, args rpaasclient.UpdateRouteArgs) (bool, error) {
+	routes, err := client.ListRoutes(c.Context, rpaasclient.ListRoutesArgs{Instance: args.Instance})
+	if err != nil {
+		return false, err
+	}
+
+	for _, r := range routes {
+		if r.Path != args.Path {
+			continue
+		}
+
+		return r.Destination == args.Destination &&
+			slices.Equal(r.Destinations, args.Destinations) &&
+			r.HTTPSOnly == args.HTTPSOnly &&
+			normalizeRouteContent(r.Content) == normalizeRouteContent(args.Content), nil
+	}
+
+	return false, nil
+}
+
+func normalizeRouteContent(content string) string {
+	lines := strings.Split(content, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRightFunc(lines[i], unicode.IsSpace)
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func warnIfNoCertificates(c *cli.Context, client rpaasclient.Client, path string) error {
+	certs, err := client.ListCertificates(c.Context, rpaasclient.ListCertificatesArgs{Instance: c.String("instance")})
+	if err != nil {
+		return err
+	}
+
+	if len(certs) == 0 {
+		fmt.Fprintf(c.App.ErrWriter, "WARNING: %s has no TLS certificate, so route %q cannot be reached as it only accepts HTTPS!\n", formatInstanceName(c), path)
+	}
+
+	return nil
+}
+
+var (
+	redirectStatusCodes = []int{301, 302, 303, 307, 308}
+
+	redirectRouteRegexp = regexp.MustCompile(`^return (30[12378]) (\S+);\n?$`)
+)
+
+func redirectCodes() []string {
+	var codes []string
+	for _, code := range redirectStatusCodes {
+		codes = append(codes, strconv.Itoa(code))
+	}
+
+	return codes
+}
+
+// redirectRouteContent returns the NGINX configuration redirecting the
+// requests to target with the given status code.
+func redirectRouteContent(target string, code int) ([]byte, error) {
+	valid := false
+	for _, c := range redirectStatusCodes {
+		valid = valid || c == code
+	}
+
+	if !valid {
+		return nil, fmt.Errorf("invalid redirect code %d (one of: %s)", code, strings.Join(redirectCodes(), ", "))
+	}
+
+	// NOTE: the URL is written as is into the NGINX configuration, so any
+	// char which could end the directive is forbidden.
+	if strings.ContainsAny(target, " \t\r\n;'\"{}") {
+		return nil, fmt.Errorf("invalid redirect URL %q: must not contain whitespaces, quotes, braces nor semicolons", target)
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect URL %q: %w", target, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid redirect URL %q: must be an absolute HTTP(S) URL", target)
+	}
+
+	return []byte(fmt.Sprintf("return %d %s;\n", code, target)), nil
+}
+
+// parseRedirectRoute returns the status code and the target of a route
+// created with --redirect.
+func parseRedirectRoute(content string) (int, string, bool) {
+	matches := redirectRouteRegexp.FindStringSubmatch(content)
+	if matches == nil {
+		return 0, "", false
+	}
+
+	code, _ := strconv.Atoi(matches[1])
+	return code, matches[2], true
+}
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "OPTIONS"}
+
+	corsMethodRegexp = regexp.MustCompile(`^[A-Z]+$`)
+	corsHeaderRegexp = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+	corsRouteRegexp = regexp.MustCompile(`(?s)^# BEGIN CORS origins=(\S+)\n.*?# END CORS\n(.*)$`)
+)
+
+// corsRouteContent returns the NGINX configuration adding the CORS headers to
+// the responses to the allowed origins and answering the preflight requests.
+// The header values are only set for the allowed origins, the others getting
+// no CORS headers at all. It's delimited by comments, so that parseCORSRoute
+// tells these routes apart.
+func corsRouteContent(origins, methods, headers []string, credentials bool) ([]byte, error) {
+	for _, origin := range origins {
+		if origin == "*" {
+			if len(origins) > 1 {
+				return nil, fmt.Errorf("--cors-origin \"*\" cannot be used along with other origins")
+			}
+
+			if credentials {
+				return nil, fmt.Errorf("--cors-origin \"*\" cannot be used along with --cors-credentials, as browsers reject credentials on wildcard origins")
+			}
+
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil || strings.TrimSuffix(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" || strings.ContainsAny(origin, " \t\r\n;'\"{}") {
+			return nil, fmt.Errorf("invalid CORS origin %q: must be \"*\" or an URL made of scheme, host and optional port (e.g. https://app.example.com)", origin)
+		}
+	}
+
+	methods = slices.Clone(methods)
+	if len(methods) == 0 {
+		methods = slices.Clone(defaultCORSMethods)
+	}
+
+	for i, method := range methods {
+		methods[i] = strings.ToUpper(method)
+		if !corsMethodRegexp.MatchString(methods[i]) {
+			return nil, fmt.Errorf("invalid CORS method %q", method)
+		}
+	}
+
+	for _, header := range headers {
+		if !corsHeaderRegexp.MatchString(header) {
+			return nil, fmt.Errorf("invalid CORS header %q: must be made of letters, digits and dashes", header)
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# BEGIN CORS origins=%s\n", strings.Join(origins, ","))
+
+	allowOrigin := "*"
+	if origins[0] != "*" {
+		allowOrigin = "$cors_origin"
+
+		sb.WriteString("set $cors_origin \"\";\n")
+		for _, origin := range origins {
+			fmt.Fprintf(&sb, "if ($http_origin = \"%s\") {\n    set $cors_origin $http_origin;\n}\n", strings.TrimSuffix(origin, "/"))
+		}
+	}
+
+	// NOTE: add_header directives are not inherited by the if blocks having
+	// their own ones, so the response headers are repeated on preflights.
+	responseHeaders := []string{fmt.Sprintf("Access-Control-Allow-Origin %s", allowOrigin)}
+	if credentials {
+		responseHeaders = append(responseHeaders, "Access-Control-Allow-Credentials true")
+	}
+
+	if allowOrigin != "*" {
+		responseHeaders = append(responseHeaders, "Vary Origin")
+	}
+
+	sb.WriteString("if ($request_method = OPTIONS) {\n")
+	for _, h := range responseHeaders {
+		fmt.Fprintf(&sb, "    add_header %s always;\n", h)
+	}
+
+	fmt.Fprintf(&sb, "    add_header Access-Control-Allow-Methods \"%s\" always;\n", strings.Join(methods, ", "))
+	if len(headers) > 0 {
+		fmt.Fprintf(&sb, "    add_header Access-Control-Allow-Headers \"%s\" always;\n", strings.Join(headers, ", "))
+	}
+
+	sb.WriteString("    add_header Access-Control-Max-Age 86400 always;\n    return 204;\n}\n")
+	for _, h := range responseHeaders {
+		fmt.Fprintf(&sb, "add_header %s always;\n", h)
+	}
+
+	sb.WriteString("# END CORS\n")
+	return []byte(sb.String()), nil
+}
+
+// parseCORSRoute returns the allowed origins of a route created with
+// --cors-origin along with the rest of its content.
+func parseCORSRoute(content string) ([]string, string, bool) {
+	matches := corsRouteRegexp.FindStringSubmatch(content)
+	if matches == nil {
+		return nil, "", false
+	}
+
+	return strings.Split(matches[1], ","), matches[2], true
+}
+
+// rateLimitZoneSize is the size of the shared memory zone of each rate-limited
+// route, where 1 MB keeps the state of about 16 thousand client addresses.
+const rateLimitZoneSize = "10m"
+
+var (
+	rateLimitRegexp         = regexp.MustCompile(`^[1-9][0-9]*r/[sm]$`)
+	rateLimitZoneNameRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+	rateLimitRouteRegexp = regexp.MustCompile(`(?s)^# BEGIN rate limit zone=\S+ rate=(\S+) burst=([0-9]+)\n.*?# END rate limit\n(.*)$`)
+)
+
+// rateLimitZoneName derives the name of the zone of the route on path from it,
+// see routeKey.
+func rateLimitZoneName(path string) string {
+	return "rate_limit_" + routeKey(path)
+}
+
+// routeKey derives a name from path made of its letters and digits, along
+// with a hash of the path so that paths differing just on punctuation (e.g.
+// /a-b and /a_b) don't share a name.
+func routeKey(path string) string {
+	h := fnv.New32a()
+	h.Write([]byte(path))
+
+	name := strings.Trim(rateLimitZoneNameRegexp.ReplaceAllString(path, "_"), "_")
+	if len(name) > 32 {
+		name = name[:32]
+	}
+
+	if name == "" {
+		return fmt.Sprintf("%08x", h.Sum32())
+	}
+
+	return fmt.Sprintf("%s_%08x", name, h.Sum32())
+}
+
+// rateLimitRouteContent returns the NGINX configuration limiting the requests
+// through zone, which is delimited by comments so that parseRateLimitRoute
+// tells these routes apart. The zone itself is set on the http block (see
+// setRateLimitZone), as NGINX only allows it there.
+func rateLimitRouteContent(zone, rate string, burst int) ([]byte, error) {
+	if !rateLimitRegexp.MatchString(rate) {
+		return nil, fmt.Errorf("invalid rate limit %q: must be a positive number of requests per second or minute (e.g. 10r/s or 600r/m)", rate)
+	}
+
+	if burst < 0 {
+		return nil, fmt.Errorf("invalid rate limit burst %d: must not be negative", burst)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# BEGIN rate limit zone=%s rate=%s burst=%d\n", zone, rate, burst)
+	if burst > 0 {
+		fmt.Fprintf(&sb, "limit_req zone=%s burst=%d nodelay;\n", zone, burst)
+	} else {
+		fmt.Fprintf(&sb, "limit_req zone=%s;\n", zone)
+	}
+
+	sb.WriteString("limit_req_status 429;\n# END rate limit\n")
+	return []byte(sb.String()), nil
+}
+
+// parseRateLimitRoute returns the rate and burst of a route created with
+// --rate-limit along with the rest of its content.
+func parseRateLimitRoute(content string) (string, int, string, bool) {
+	matches := rateLimitRouteRegexp.FindStringSubmatch(content)
+	if matches == nil {
+		return "", 0, "", false
+	}
+
+	burst, _ := strconv.Atoi(matches[2])
+	return matches[1], burst, matches[3], true
+}
+
+// setRateLimitZone adds the zone to the http block, or updates its rate when
+// it's already there, retrying when the blocks are changed meanwhile.
+func setRateLimitZone(c *cli.Context, client rpaasclient.Client, zone, rate string) error {
+	return editHTTPBlock(c, client, fmt.Sprintf("Rate limit zone %q set on the http block.\n", zone), func(content string) string {
+		return rateLimitZoneBlock(content, zone, rate)
+	})
+}
+
+// removeRateLimitZone removes the zone from the http block when it's there,
+// e.g. once its route is deleted, so that its memory is released.
+func removeRateLimitZone(c *cli.Context, client rpaasclient.Client, zone string) error {
+	return editHTTPBlock(c, client, fmt.Sprintf("Rate limit zone %q removed from the http block.\n", zone), func(content string) string {
+		return withoutRateLimitZone(content, zone)
+	})
+}
+
+// editHTTPBlock replaces the http block by the result of edit on its current
+// content, retrying when the blocks are changed meanwhile. The notice is
+// written once the block is changed.
+func editHTTPBlock(c *cli.Context, client rpaasclient.Client, notice string, edit func(content string) string) error {
+	instance := c.String("instance")
+	for retries := 0; ; retries++ {
+		blocks, version, err := client.ListBlocksWithVersion(c.Context, rpaasclient.ListBlocksArgs{Instance: instance})
+		if err != nil {
+			return err
+		}
+
+		current, _ := blockContent(blocks, "http")
+		updated := edit(current)
+		if updated == current {
+			return nil
+		}
+
+		if strings.TrimSpace(updated) == "" {
+			// NOTE: blocks cannot be left empty, see UpdateBlockArgs.
+			err = client.DeleteBlock(c.Context, rpaasclient.DeleteBlockArgs{Instance: instance, Name: "http"})
+		} else {
+			err = client.UpdateBlock(c.Context, rpaasclient.UpdateBlockArgs{Instance: instance, Name: "http", Content: updated, Version: version})
+		}
+
+		if errors.Is(err, rpaasclient.ErrBlocksConflict) {
+			if retries == maxBlockConflictRetries {
+				return fmt.Errorf("giving up after %d retries: %w", retries, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(c.App.Writer, notice)
+		return nil
+	}
+}
+
+// rateLimitZoneBlock returns the content of the http block declaring zone,
+// delimited by comments so that it can be updated later.
+func rateLimitZoneBlock(content, zone, rate string) string {
+	begin, end := rateLimitZoneMarkers(zone)
+	section := fmt.Sprintf("%slimit_req_zone $binary_remote_addr zone=%s:%s rate=%s;\n%s", begin, zone, rateLimitZoneSize, rate, end)
+
+	if i, j, ok := rateLimitZoneSection(content, zone); ok {
+		return content[:i] + section + content[j:]
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	return content + section
+}
+
+// withoutRateLimitZone returns the content of the http block without the
+// section declaring zone, see rateLimitZoneBlock.
+func withoutRateLimitZone(content, zone string) string {
+	if i, j, ok := rateLimitZoneSection(content, zone); ok {
+		return content[:i] + content[j:]
+	}
+
+	return content
+}
+
+func rateLimitZoneMarkers(zone string) (string, string) {
+	return fmt.Sprintf("# BEGIN rate limit zone=%s\n", zone), fmt.Sprintf("# END rate limit zone=%s\n", zone)
+}
+
+// rateLimitZoneSection returns where the section declaring zone starts and
+// ends within the content of the http block.
+func rateLimitZoneSection(content, zone string) (int, int, bool) {
+	begin, end := rateLimitZoneMarkers(zone)
+
+	i := strings.Index(content, begin)
+	if i < 0 {
+		return 0, 0, false
+	}
+
+	j := strings.Index(content[i:], end)
+	if j < 0 {
+		return 0, 0, false
+	}
+
+	return i, i + j + len(end), true
+}
+
+// proxyTimeout is a proxy_*_timeout directive of a route, see
+// proxyTimeoutsRouteContent.
+type proxyTimeout struct {
+	Name  string
+	Value time.Duration
+}
+
+// proxyTimeoutNames are the timeouts set by the --proxy-*-timeout flags, in
+// the order they're written into the route.
+var proxyTimeoutNames = []string{"read", "connect", "send"}
+
+var proxyTimeoutsRouteRegexp = regexp.MustCompile(`(?s)^# BEGIN proxy timeouts ([a-z]+=[0-9]+[a-z]+(?: [a-z]+=[0-9]+[a-z]+)*)\n.*?# END proxy timeouts\n(.*)$`)
+
+func proxyTimeoutsFromFlags(c *cli.Context) []proxyTimeout {
+	var timeouts []proxyTimeout
+	for _, name := range proxyTimeoutNames {
+		if flag := fmt.Sprintf("proxy-%s-timeout", name); c.IsSet(flag) {
+			timeouts = append(timeouts, proxyTimeout{Name: name, Value: c.Duration(flag)})
+		}
+	}
+
+	return timeouts
+}
+
+// proxyTimeoutsRouteContent returns the NGINX configuration overriding the
+// proxy timeouts of the route, which is delimited by comments so that
+// parseProxyTimeoutsRoute tells these routes apart.
+func proxyTimeoutsRouteContent(timeouts []proxyTimeout) ([]byte, error) {
+	var values []string
+	for _, t := range timeouts {
+		value, err := nginxDuration(t.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --proxy-%s-timeout %s: %w", t.Name, t.Value, err)
+		}
+
+		values = append(values, fmt.Sprintf("%s=%s", t.Name, value))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# BEGIN proxy timeouts %s\n", strings.Join(values, " "))
+	for _, v := range values {
+		name, value, _ := strings.Cut(v, "=")
+		fmt.Fprintf(&sb, "proxy_%s_timeout %s;\n", name, value)
+	}
+
+	sb.WriteString("# END proxy timeouts\n")
+	return []byte(sb.String()), nil
+}
+
+// nginxDuration writes d in the largest NGINX time unit that holds it
+// exactly (e.g. 30s, 2m or 1500ms).
+func nginxDuration(d time.Duration) (string, error) {
+	if d <= 0 {
+		return "", fmt.Errorf("must be greater than zero")
+	}
+
+	if d%time.Millisecond != 0 {
+		return "", fmt.Errorf("must be a whole number of milliseconds")
+	}
+
+	for _, unit := range []struct {
+		suffix   string
+		duration time.Duration
+	}{{"h", time.Hour}, {"m", time.Minute}, {"s", time.Second}} {
+		if d%unit.duration == 0 {
+			return fmt.Sprintf("%d%s", d/unit.duration, unit.suffix), nil
+		}
+	}
+
+	return fmt.Sprintf("%dms", d/time.Millisecond), nil
+}
+
+// parseProxyTimeoutsRoute returns the timeouts of a route created with the
+// --proxy-*-timeout flags (as written in NGINX units, e.g. read=30s) along
+// with the rest of its content.
+func parseProxyTimeoutsRoute(content string) ([]string, string, bool) {
+	matches := proxyTimeoutsRouteRegexp.FindStringSubmatch(content)
+	if matches == nil {
+		return nil, "", false
+	}
+
+	return strings.Split(matches[1], " "), matches[2], true
+}
+
+// formatProxyTimeouts turns timeouts like read=30s into "read 30s".
+func formatProxyTimeouts(timeouts []string) string {
+	formatted := make([]string, 0, len(timeouts))
+	for _, t := range timeouts {
+		formatted = append(formatted, strings.Replace(t, "=", " ", 1))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
 func fetchContentFile(c *cli.Context) ([]byte, error) {
-	contentFile := c.Path("content")
+	contentFile := contentFilePath(c)
 	if contentFile == "" {
 		return nil, nil
 	}
-	content, err := os.ReadFile(contentFile)
-	if os.IsNotExist(err) &&
-		strings.HasPrefix(contentFile, "@") {
-		return os.ReadFile(strings.TrimPrefix(contentFile, "@"))
+
+	return os.ReadFile(contentFile)
+}
+
+// contentFilePath returns the path set on --content, which may be prefixed
+// with "@" as in curl.
+func contentFilePath(c *cli.Context) string {
+	contentFile := c.Path("content")
+	if _, err := os.Stat(contentFile); os.IsNotExist(err) && strings.HasPrefix(contentFile, "@") {
+		return strings.TrimPrefix(contentFile, "@")
+	}
+
+	return contentFile
+}
+
+var includeDirectiveRegexp = regexp.MustCompile(`(?m)^[ \t]*include[ \t]+(?:"([^"]*)"|'([^']*)'|([^\s"';]+))[ \t]*;[ \t]*$`)
+
+// expandContentIncludes replaces the include directives of the content file
+// with the files they point to, recursively, returning the files inlined in
+// the order they were first found. Includes are resolved from the directory
+// of the content file, just like NGINX resolves them from its prefix path.
+func expandContentIncludes(c *cli.Context, content []byte) ([]byte, []string, error) {
+	root := contentFilePath(c)
+	if root == "" {
+		return nil, nil, fmt.Errorf("--expand-includes requires --content")
+	}
+
+	maxDepth := c.Int("max-include-depth")
+	if maxDepth <= 0 {
+		return nil, nil, fmt.Errorf("--max-include-depth must be greater than zero")
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(root))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e := &includeExpander{dir: dir, maxDepth: maxDepth, seen: map[string]bool{}}
+	content, err = e.expand(content, []string{filepath.Base(root)})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return content, e.included, nil
+}
+
+type includeExpander struct {
+	dir      string
+	maxDepth int
+	included []string
+	seen     map[string]bool
+}
+
+// expand inlines the includes of content, where stack holds the files being
+// expanded (relative to the root directory) so that cycles are caught.
+func (e *includeExpander) expand(content []byte, stack []string) ([]byte, error) {
+	var err error
+	expanded := includeDirectiveRegexp.ReplaceAllFunc(content, func(directive []byte) []byte {
+		if err != nil {
+			return directive
+		}
+
+		var included []byte
+		included, err = e.include(includeDirectiveRegexp.FindSubmatch(directive), stack)
+		return included
+	})
+
+	return expanded, err
+}
+
+func (e *includeExpander) include(matches [][]byte, stack []string) ([]byte, error) {
+	path := string(bytes.Join(matches[1:], nil))
+
+	file, err := e.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, f := range stack {
+		if f == file {
+			return nil, fmt.Errorf("include cycle detected: %s", strings.Join(append(stack[i:], file), " -> "))
+		}
+	}
+
+	if len(stack) > e.maxDepth {
+		return nil, fmt.Errorf("cannot include %s: includes are nested deeper than %d level(s) (see --max-include-depth)", file, e.maxDepth)
+	}
+
+	content, err := os.ReadFile(filepath.Join(e.dir, file))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the included file: %w", err)
+	}
+
+	if !e.seen[file] {
+		e.seen[file] = true
+		e.included = append(e.included, file)
+	}
+
+	content, err = e.expand(content, append(stack[:len(stack):len(stack)], file))
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(content, "\n"), nil
+}
+
+// resolve returns the included path relative to the root directory, refusing
+// absolute paths, globs and paths out of the root directory.
+func (e *includeExpander) resolve(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return "", fmt.Errorf("cannot include %q: only relative paths are allowed", path)
+	}
+
+	if strings.ContainsAny(path, "*?[") {
+		return "", fmt.Errorf("cannot include %q: glob patterns are not supported", path)
+	}
+
+	file := filepath.Clean(path)
+	if file == ".." || strings.HasPrefix(file, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("cannot include %q: it points out of the directory of the content file", path)
 	}
 
-	return content, err
+	return file, nil
 }
@@ -0,0 +1,299 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/bcrypt"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+// extraFilesDir is where NGINX finds the extra files of the instance.
+const extraFilesDir = "/etc/nginx/extra_files"
+
+var (
+	basicAuthHashes = []string{"bcrypt", "apr1"}
+
+	// htpasswdHashPrefixes are the prefixes of the hashes NGINX supports,
+	// leaving out {PLAIN} on purpose.
+	htpasswdHashPrefixes = []string{"$apr1$", "$2a$", "$2b$", "$2y$", "$1$", "$5$", "$6$", "{SHA}", "{SSHA}"}
+
+	// htpasswdDESHashRegexp matches the traditional crypt() hashes.
+	htpasswdDESHashRegexp = regexp.MustCompile(`^[./0-9A-Za-z]{13}$`)
+
+	basicAuthRouteRegexp = regexp.MustCompile(`(?s)^# BEGIN basic auth file=\S+\nauth_basic "([^"]*)";\n.*?# END basic auth\n(.*)$`)
+)
+
+// basicAuthFileName returns the name of the extra file holding the users of
+// the route on path.
+func basicAuthFileName(path string) string {
+	return "htpasswd_" + routeKey(path)
+}
+
+// htpasswdFromFlags returns the htpasswd content with the users allowed on
+// the route, either from --htpasswd-file or from --basic-auth-user, whose
+// password is hashed right away so that it's never sent in plain text. It
+// returns nil when no basic auth is set.
+func htpasswdFromFlags(c *cli.Context) ([]byte, error) {
+	if c.IsSet("htpasswd-file") {
+		if c.IsSet("basic-auth-user") || c.IsSet("basic-auth-password") || c.IsSet("basic-auth-hash") {
+			return nil, fmt.Errorf("--htpasswd-file cannot be used along with --basic-auth-user, --basic-auth-password or --basic-auth-hash")
+		}
+
+		content, err := os.ReadFile(c.Path("htpasswd-file"))
+		if err != nil {
+			return nil, err
+		}
+
+		if err = validateHTPasswd(content); err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Path("htpasswd-file"), err)
+		}
+
+		return content, nil
+	}
+
+	user := c.String("basic-auth-user")
+	if user == "" {
+		if c.IsSet("basic-auth-password") || c.IsSet("basic-auth-hash") || c.IsSet("basic-auth-realm") {
+			return nil, fmt.Errorf("--basic-auth-password, --basic-auth-hash and --basic-auth-realm can only be used along with --basic-auth-user or --htpasswd-file")
+		}
+
+		return nil, nil
+	}
+
+	if strings.ContainsAny(user, ":\r\n") {
+		return nil, fmt.Errorf("invalid basic auth user %q: must not contain colons nor line breaks", user)
+	}
+
+	password := c.String("basic-auth-password")
+	if !c.IsSet("basic-auth-password") {
+		line, err := bufio.NewReader(inputReader(c)).ReadString('\n')
+		if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+			return nil, fmt.Errorf("could not read the password of --basic-auth-user from the standard input: %w", err)
+		}
+
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	if password == "" {
+		return nil, fmt.Errorf("the password of --basic-auth-user cannot be empty")
+	}
+
+	hash, err := hashBasicAuthPassword(password, c.String("basic-auth-hash"))
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("%s:%s\n", user, hash)), nil
+}
+
+func hashBasicAuthPassword(password, function string) (string, error) {
+	switch function {
+	case "bcrypt":
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+
+		return string(hash), nil
+
+	case "apr1":
+		salt, err := newAPR1Salt()
+		if err != nil {
+			return "", err
+		}
+
+		return apr1Hash(password, salt), nil
+	}
+
+	return "", fmt.Errorf("invalid basic auth hash %q (one of: %s)", function, strings.Join(basicAuthHashes, ", "))
+}
+
+// validateHTPasswd checks that every entry of content is made of a user and a
+// password hash, refusing plain text passwords.
+func validateHTPasswd(content []byte) error {
+	var entries int
+	for n, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		user, hash, found := strings.Cut(line, ":")
+		if !found || user == "" {
+			return fmt.Errorf("line %d: must be in the user:hash format", n+1)
+		}
+
+		if !isHTPasswdHash(hash) {
+			return fmt.Errorf("line %d: the password of user %q is not hashed (or uses an unsupported hash), plain text passwords are not allowed (hash them e.g. with \"htpasswd -B\")", n+1, user)
+		}
+
+		entries++
+	}
+
+	if entries == 0 {
+		return fmt.Errorf("no users found")
+	}
+
+	return nil
+}
+
+func isHTPasswdHash(hash string) bool {
+	for _, prefix := range htpasswdHashPrefixes {
+		if strings.HasPrefix(hash, prefix) && len(hash) > len(prefix) {
+			return true
+		}
+	}
+
+	return htpasswdDESHashRegexp.MatchString(hash)
+}
+
+const apr1Alphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+func newAPR1Salt() (string, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	for i := range salt {
+		salt[i] = apr1Alphabet[int(salt[i])%len(apr1Alphabet)]
+	}
+
+	return string(salt), nil
+}
+
+// apr1Hash returns the Apache variant of the MD5-based crypt() of password,
+// which NGINX supports regardless of the crypt() of its system. It's weaker
+// than bcrypt, so it's only meant for NGINX builds lacking bcrypt.
+func apr1Hash(password, salt string) string {
+	const magic = "$apr1$"
+	pw := []byte(password)
+
+	alternate := md5.Sum([]byte(password + salt + password))
+
+	h := md5.New()
+	h.Write([]byte(password + magic + salt))
+	for i := len(pw); i > 0; i -= 16 {
+		h.Write(alternate[:min(i, 16)])
+	}
+
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(pw[:1])
+		}
+	}
+
+	sum := h.Sum(nil)
+	for i := 0; i < 1000; i++ {
+		h = md5.New()
+		if i&1 != 0 {
+			h.Write(pw)
+		} else {
+			h.Write(sum)
+		}
+
+		if i%3 != 0 {
+			h.Write([]byte(salt))
+		}
+
+		if i%7 != 0 {
+			h.Write(pw)
+		}
+
+		if i&1 != 0 {
+			h.Write(sum)
+		} else {
+			h.Write(pw)
+		}
+
+		sum = h.Sum(nil)
+	}
+
+	var encoded []byte
+	encode := func(v uint, n int) {
+		for ; n > 0; n-- {
+			encoded = append(encoded, apr1Alphabet[v&0x3f])
+			v >>= 6
+		}
+	}
+
+	for _, i := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		encode(uint(sum[i[0]])<<16|uint(sum[i[1]])<<8|uint(sum[i[2]]), 4)
+	}
+	encode(uint(sum[11]), 2)
+
+	return magic + salt + "$" + string(encoded)
+}
+
+// basicAuthRouteContent returns the NGINX configuration requiring the users
+// of the extra file to authenticate, which is delimited by comments so that
+// parseBasicAuthRoute tells these routes apart.
+func basicAuthRouteContent(file, realm string) ([]byte, error) {
+	if realm == "" || strings.ContainsAny(realm, "\"\\\r\n") {
+		return nil, fmt.Errorf("invalid basic auth realm %q: must not be empty nor contain quotes, backslashes or line breaks", realm)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# BEGIN basic auth file=%s\n", file)
+	fmt.Fprintf(&sb, "auth_basic \"%s\";\n", realm)
+	fmt.Fprintf(&sb, "auth_basic_user_file %s/%s;\n", extraFilesDir, file)
+	sb.WriteString("# END basic auth\n")
+	return []byte(sb.String()), nil
+}
+
+// parseBasicAuthRoute returns the realm of a route created with basic auth
+// along with the rest of its content.
+func parseBasicAuthRoute(content string) (string, string, bool) {
+	matches := basicAuthRouteRegexp.FindStringSubmatch(content)
+	if matches == nil {
+		return "", "", false
+	}
+
+	return matches[1], matches[2], true
+}
+
+// setBasicAuthFile uploads the htpasswd content as an extra file of the
+// instance, replacing the previous users of the route if any.
+func setBasicAuthFile(c *cli.Context, client rpaasclient.Client, name string, content []byte) error {
+	args := rpaasclient.ExtraFilesArgs{
+		Instance: c.String("instance"),
+		Files:    []types.RpaasFile{{Name: name, Content: content}},
+	}
+
+	err := client.AddExtraFiles(c.Context, args)
+
+	var unexpected *rpaasclient.ErrUnexpectedStatusCode
+	if errors.As(err, &unexpected) && unexpected.Status == http.StatusConflict {
+		err = client.UpdateExtraFiles(c.Context, args)
+
+		// NOTE: the API answers with no content when the file is unchanged.
+		if errors.As(err, &unexpected) && unexpected.Status == http.StatusNoContent {
+			err = nil
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not upload the basic auth users: %w", err)
+	}
+
+	fmt.Fprintf(c.App.Writer, "Basic auth users uploaded to the %q extra file.\n", name)
+	return nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestAPR1Hash(t *testing.T) {
+	// NOTE: expected hashes were generated by "openssl passwd -apr1 -salt".
+	assert.Equal(t, "$apr1$abcdefgh$FTOHHCw3jpYCwZEdHLbXD.", apr1Hash("s3cr3t!", "abcdefgh"))
+	assert.Equal(t, "$apr1$Zx9.yW/2$47Z6AIWHPkBJi5sW4uPf.0", apr1Hash("correct horse", "Zx9.yW/2"))
+}
+
+func TestUpdateRouteWithBasicAuth(t *testing.T) {
+	const routeContent = "proxy_pass http://admin.internal;\n"
+	authFile := basicAuthFileName("/admin")
+
+	dir := t.TempDir()
+	contentFile := filepath.Join(dir, "admin.conf")
+	require.NoError(t, os.WriteFile(contentFile, []byte(routeContent), 0644))
+
+	htpasswdFile := filepath.Join(dir, "admins.htpasswd")
+	require.NoError(t, os.WriteFile(htpasswdFile, []byte("# admins\nalice:$apr1$abcdefgh$FTOHHCw3jpYCwZEdHLbXD.\nbob:$2y$05$c4WoMPo3SXsafkva.HHa6uXQZWr7oboPiC2bT/r7q1BB8I2s0BRqC\n"), 0644))
+
+	plainFile := filepath.Join(dir, "plain.htpasswd")
+	require.NoError(t, os.WriteFile(plainFile, []byte("alice:$apr1$abcdefgh$FTOHHCw3jpYCwZEdHLbXD.\nbob:{PLAIN}s3cr3t\n"), 0644))
+
+	type result struct {
+		files   []clientTypes.RpaasFile
+		route   rpaasclient.UpdateRouteArgs
+		updated bool
+	}
+
+	newClient := func(r *result, existing bool) *fake.FakeClient {
+		return &fake.FakeClient{
+			FakeAddExtraFiles: func(args rpaasclient.ExtraFilesArgs) error {
+				assert.Equal(t, "my-instance", args.Instance)
+				if existing {
+					return &rpaasclient.ErrUnexpectedStatusCode{Status: http.StatusConflict}
+				}
+
+				r.files = args.Files
+				return nil
+			},
+			FakeUpdateExtraFiles: func(args rpaasclient.ExtraFilesArgs) error {
+				assert.True(t, existing, "the file should only be updated when it already exists")
+				r.files, r.updated = args.Files, true
+				return nil
+			},
+			FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+				assert.NotNil(t, r.files, "the users must be uploaded before the route")
+				r.route = args
+				return nil
+			},
+		}
+	}
+
+	args := []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/admin", "--content", contentFile}
+
+	t.Run("with a user whose password is read from the standard input", func(t *testing.T) {
+		var r result
+		stdout := &bytes.Buffer{}
+		app := NewApp(stdout, &bytes.Buffer{}, newClient(&r, false))
+		app.Reader = strings.NewReader("s3cr3t!\n")
+		err := app.Run(append(args, "--basic-auth-user", "alice"))
+		require.NoError(t, err)
+
+		require.Len(t, r.files, 1)
+		assert.Equal(t, authFile, r.files[0].Name)
+		assert.NotContains(t, string(r.files[0].Content), "s3cr3t!")
+
+		user, hash, found := strings.Cut(strings.TrimSuffix(string(r.files[0].Content), "\n"), ":")
+		require.True(t, found)
+		assert.Equal(t, "alice", user)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("s3cr3t!")))
+
+		assert.Equal(t, `# BEGIN basic auth file=`+authFile+`
+auth_basic "Restricted";
+auth_basic_user_file /etc/nginx/extra_files/`+authFile+`;
+# END basic auth
+`+routeContent, r.route.Content)
+		assert.Equal(t, "Basic auth users uploaded to the \""+authFile+"\" extra file.\nRoute \"/admin\" updated.\n", stdout.String())
+	})
+
+	t.Run("with an apr1 password replacing the previous users", func(t *testing.T) {
+		var r result
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, newClient(&r, true)).Run(append(args, "--basic-auth-user", "alice", "--basic-auth-password", "s3cr3t!", "--basic-auth-hash", "apr1", "--basic-auth-realm", "Admins only"))
+		require.NoError(t, err)
+
+		assert.True(t, r.updated)
+		require.Len(t, r.files, 1)
+
+		_, hash, _ := strings.Cut(strings.TrimSuffix(string(r.files[0].Content), "\n"), ":")
+		require.True(t, strings.HasPrefix(hash, "$apr1$"), hash)
+		assert.Equal(t, hash, apr1Hash("s3cr3t!", strings.Split(hash, "$")[2]))
+		assert.Contains(t, r.route.Content, "auth_basic \"Admins only\";\n")
+	})
+
+	t.Run("with an htpasswd file", func(t *testing.T) {
+		var r result
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, newClient(&r, false)).Run(append(args, "--htpasswd-file", htpasswdFile))
+		require.NoError(t, err)
+
+		expected, err := os.ReadFile(htpasswdFile)
+		require.NoError(t, err)
+		require.Len(t, r.files, 1)
+		assert.Equal(t, expected, r.files[0].Content)
+		assert.True(t, strings.HasPrefix(r.route.Content, "# BEGIN basic auth file="+authFile+"\n"))
+	})
+
+	tests := []struct {
+		name          string
+		args          []string
+		stdin         string
+		expectedError string
+	}{
+		{
+			name:          "htpasswd file with plain text passwords",
+			args:          []string{"--htpasswd-file", plainFile},
+			expectedError: plainFile + `: line 2: the password of user "bob" is not hashed (or uses an unsupported hash), plain text passwords are not allowed (hash them e.g. with "htpasswd -B")`,
+		},
+		{
+			name:          "htpasswd file along with a user",
+			args:          []string{"--htpasswd-file", htpasswdFile, "--basic-auth-user", "alice"},
+			expectedError: "--htpasswd-file cannot be used along with --basic-auth-user, --basic-auth-password or --basic-auth-hash",
+		},
+		{
+			name:          "password without a user",
+			args:          []string{"--basic-auth-password", "s3cr3t!"},
+			expectedError: "--basic-auth-password, --basic-auth-hash and --basic-auth-realm can only be used along with --basic-auth-user or --htpasswd-file",
+		},
+		{
+			name:          "empty password",
+			args:          []string{"--basic-auth-user", "alice"},
+			stdin:         "\n",
+			expectedError: "the password of --basic-auth-user cannot be empty",
+		},
+		{
+			name:          "user with a colon",
+			args:          []string{"--basic-auth-user", "alice:admin", "--basic-auth-password", "s3cr3t!"},
+			expectedError: `invalid basic auth user "alice:admin": must not contain colons nor line breaks`,
+		},
+		{
+			name:          "unknown hash",
+			args:          []string{"--basic-auth-user", "alice", "--basic-auth-password", "s3cr3t!", "--basic-auth-hash", "md5"},
+			expectedError: `invalid basic auth hash "md5" (one of: bcrypt, apr1)`,
+		},
+		{
+			name:          "realm with quotes",
+			args:          []string{"--basic-auth-user", "alice", "--basic-auth-password", "s3cr3t!", "--basic-auth-realm", `"admins"`},
+			expectedError: `invalid basic auth realm "\"admins\"": must not be empty nor contain quotes, backslashes or line breaks`,
+		},
+		{
+			name:          "along with destination",
+			args:          []string{"--basic-auth-user", "alice", "--basic-auth-password", "s3cr3t!", "--destination", "app.tsuru.example.com"},
+			expectedError: "--basic-auth-user and --htpasswd-file cannot be used along with --destination or --redirect, set the proxy_pass on --content instead",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{})
+			app.Reader = strings.NewReader(tt.stdin)
+			err := app.Run(append(args, tt.args...))
+			assert.EqualError(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestListRoutesWithBasicAuth(t *testing.T) {
+	auth, err := basicAuthRouteContent(basicAuthFileName("/admin"), "Admins only")
+	require.NoError(t, err)
+
+	client := &fake.FakeClient{
+		FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+			return []clientTypes.Route{
+				{Path: "/admin", Content: string(auth) + "proxy_pass http://admin.internal;\n"},
+			}, nil
+		},
+	}
+
+	stdout := &bytes.Buffer{}
+	err = NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "routes", "list", "-i", "my-instance"})
+	require.NoError(t, err)
+	assert.Equal(t, `+--------+----------------------------------+--------------+-----------------------------------+
+| Path   | Destination                      | Force HTTPS? | Configuration                     |
++--------+----------------------------------+--------------+-----------------------------------+
+| /admin | basic auth (realm "Admins only") |              | proxy_pass http://admin.internal; |
+|        |                                  |              |                                   |
++--------+----------------------------------+--------------+-----------------------------------+
+`, stdout.String())
+}
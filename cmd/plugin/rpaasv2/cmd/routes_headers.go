@@ -0,0 +1,187 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+var (
+	// headerNameRegexp matches the header names, which are tokens as
+	// defined on RFC 9110 (section 5.6.2).
+	headerNameRegexp = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+	headersRouteRegexp = regexp.MustCompile(`(?s)^# BEGIN headers\n(.*?)# END headers\n(.*)$`)
+
+	addResponseHeaderRegexp = regexp.MustCompile(`^add_header (\S+) "((?:[^"\\]|\\.)*)" always;$`)
+	addRequestHeaderRegexp  = regexp.MustCompile(`^proxy_set_header (\S+) "((?:[^"\\]|\\.)*)";$`)
+	removeHeaderRegexp      = regexp.MustCompile(`^more_clear_headers "(\S+)";$`)
+
+	unquoteRegexp = regexp.MustCompile(`\\(.)`)
+)
+
+// routeHeaders are the header modifications of a route set by the
+// --add-response-header, --add-request-header and --remove-header flags.
+type routeHeaders struct {
+	AddResponse []routeHeader `json:"add_response,omitempty"`
+	AddRequest  []routeHeader `json:"add_request,omitempty"`
+	Remove      []string      `json:"remove,omitempty"`
+}
+
+type routeHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// routeWithHeaders is a route as shown on "routes list --output json", along
+// with the header modifications found on its content.
+type routeWithHeaders struct {
+	clientTypes.Route
+	Headers *routeHeaders `json:"headers,omitempty"`
+}
+
+func routeHeadersFromFlags(c *cli.Context) (*routeHeaders, error) {
+	if !c.IsSet("add-response-header") && !c.IsSet("add-request-header") && !c.IsSet("remove-header") {
+		return nil, nil
+	}
+
+	var headers routeHeaders
+	for _, flag := range []struct {
+		name string
+		dst  *[]routeHeader
+	}{{"add-response-header", &headers.AddResponse}, {"add-request-header", &headers.AddRequest}} {
+		for _, h := range c.StringSlice(flag.name) {
+			header, err := parseRouteHeader(h)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --%s %q: %w", flag.name, h, err)
+			}
+
+			*flag.dst = append(*flag.dst, header)
+		}
+	}
+
+	for _, name := range c.StringSlice("remove-header") {
+		if !headerNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("invalid --remove-header %q: header names must be tokens (letters, digits and !#$%%&'*+-.^_`|~)", name)
+		}
+
+		headers.Remove = append(headers.Remove, name)
+	}
+
+	return &headers, nil
+}
+
+// parseRouteHeader parses a header like "X-Frame-Options: DENY".
+func parseRouteHeader(s string) (routeHeader, error) {
+	name, value, found := strings.Cut(s, ":")
+	if !found {
+		return routeHeader{}, fmt.Errorf("must be like \"Name: value\"")
+	}
+
+	if !headerNameRegexp.MatchString(name) {
+		return routeHeader{}, fmt.Errorf("header names must be tokens (letters, digits and !#$%%&'*+-.^_`|~)")
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return routeHeader{}, fmt.Errorf("header values must not be empty")
+	}
+
+	if strings.ContainsFunc(value, func(r rune) bool { return (r < ' ' && r != '\t') || r == 0x7f }) {
+		return routeHeader{}, fmt.Errorf("header values must not contain control characters")
+	}
+
+	return routeHeader{Name: name, Value: value}, nil
+}
+
+// headersRouteContent returns the NGINX configuration modifying the headers
+// of the route, which is delimited by comments so that parseHeadersRoute
+// tells these routes apart. Response headers are removed with the
+// headers-more module, which is part of the NGINX image.
+func headersRouteContent(headers *routeHeaders) []byte {
+	var sb strings.Builder
+	sb.WriteString("# BEGIN headers\n")
+	for _, h := range headers.AddResponse {
+		fmt.Fprintf(&sb, "add_header %s %s always;\n", h.Name, quoteNginxString(h.Value))
+	}
+
+	for _, h := range headers.AddRequest {
+		fmt.Fprintf(&sb, "proxy_set_header %s %s;\n", h.Name, quoteNginxString(h.Value))
+	}
+
+	for _, name := range headers.Remove {
+		fmt.Fprintf(&sb, "more_clear_headers %s;\n", quoteNginxString(name))
+	}
+
+	sb.WriteString("# END headers\n")
+	return []byte(sb.String())
+}
+
+// quoteNginxString double-quotes s, escaping the quotes and backslashes so
+// that NGINX reads it as a single argument. Variables (e.g. $host) are still
+// expanded.
+func quoteNginxString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// parseHeadersRoute returns the header modifications of a route created
+// with the --add-response-header, --add-request-header and --remove-header
+// flags along with the rest of its content.
+func parseHeadersRoute(content string) (*routeHeaders, string, bool) {
+	matches := headersRouteRegexp.FindStringSubmatch(content)
+	if matches == nil {
+		return nil, "", false
+	}
+
+	var headers routeHeaders
+	for _, line := range strings.Split(strings.TrimSuffix(matches[1], "\n"), "\n") {
+		if m := addResponseHeaderRegexp.FindStringSubmatch(line); m != nil {
+			headers.AddResponse = append(headers.AddResponse, routeHeader{Name: m[1], Value: unquoteRegexp.ReplaceAllString(m[2], "$1")})
+		} else if m := addRequestHeaderRegexp.FindStringSubmatch(line); m != nil {
+			headers.AddRequest = append(headers.AddRequest, routeHeader{Name: m[1], Value: unquoteRegexp.ReplaceAllString(m[2], "$1")})
+		} else if m := removeHeaderRegexp.FindStringSubmatch(line); m != nil {
+			headers.Remove = append(headers.Remove, m[1])
+		}
+	}
+
+	return &headers, matches[2], true
+}
+
+// formatRouteHeaders describes the header modifications on tables, e.g.
+// "+X-Frame-Options (response), -Server".
+func formatRouteHeaders(headers *routeHeaders) string {
+	var parts []string
+	for _, h := range headers.AddResponse {
+		parts = append(parts, fmt.Sprintf("+%s (response)", h.Name))
+	}
+
+	for _, h := range headers.AddRequest {
+		parts = append(parts, fmt.Sprintf("+%s (request)", h.Name))
+	}
+
+	for _, name := range headers.Remove {
+		parts = append(parts, "-"+name)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// routesWithHeaders returns the routes along with their header
+// modifications, if any.
+func routesWithHeaders(routes []clientTypes.Route) []routeWithHeaders {
+	result := make([]routeWithHeaders, 0, len(routes))
+	for _, r := range routes {
+		headers, _, _ := parseHeadersRoute(r.Content)
+		result = append(result, routeWithHeaders{Route: r, Headers: headers})
+	}
+
+	return result
+}
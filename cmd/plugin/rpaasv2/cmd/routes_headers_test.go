@@ -0,0 +1,158 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestUpdateRouteWithHeaders(t *testing.T) {
+	contentFile := filepath.Join(t.TempDir(), "app.conf")
+	require.NoError(t, os.WriteFile(contentFile, []byte("proxy_pass http://app.internal;\n"), 0644))
+
+	tests := []struct {
+		name            string
+		args            []string
+		expectedContent string
+		expectedError   string
+	}{
+		{
+			name: "adding and removing headers",
+			args: []string{"--add-response-header", "X-Frame-Options: DENY", "--add-response-header", `Content-Security-Policy:default-src 'self'; img-src "data:"`, "--add-request-header", "X-Forwarded-Site: $host", "--remove-header", "Server", "--remove-header", "X-Powered-By"},
+			expectedContent: `# BEGIN headers
+add_header X-Frame-Options "DENY" always;
+add_header Content-Security-Policy "default-src 'self'; img-src \"data:\"" always;
+proxy_set_header X-Forwarded-Site "$host";
+more_clear_headers "Server";
+more_clear_headers "X-Powered-By";
+# END headers
+proxy_pass http://app.internal;
+`,
+		},
+		{
+			name: "along with proxy timeouts",
+			args: []string{"--remove-header", "Server", "--proxy-read-timeout", "5m"},
+			expectedContent: `# BEGIN headers
+more_clear_headers "Server";
+# END headers
+# BEGIN proxy timeouts read=5m
+proxy_read_timeout 5m;
+# END proxy timeouts
+proxy_pass http://app.internal;
+`,
+		},
+		{
+			name:          "with a header missing its value",
+			args:          []string{"--add-response-header", "X-Frame-Options"},
+			expectedError: `invalid --add-response-header "X-Frame-Options": must be like "Name: value"`,
+		},
+		{
+			name:          "with an empty header value",
+			args:          []string{"--add-request-header", "X-Forwarded-Site: "},
+			expectedError: `invalid --add-request-header "X-Forwarded-Site: ": header values must not be empty`,
+		},
+		{
+			name:          "with an invalid header name",
+			args:          []string{"--add-response-header", "X Frame Options: DENY"},
+			expectedError: "invalid --add-response-header \"X Frame Options: DENY\": header names must be tokens (letters, digits and !#$%&'*+-.^_`|~)",
+		},
+		{
+			name:          "with an invalid header name to remove",
+			args:          []string{"--remove-header", "Server;"},
+			expectedError: "invalid --remove-header \"Server;\": header names must be tokens (letters, digits and !#$%&'*+-.^_`|~)",
+		},
+		{
+			name:          "with a header value holding a line break",
+			args:          []string{"--add-response-header", "X-Frame-Options: DENY\nmore_clear_headers *"},
+			expectedError: "invalid --add-response-header \"X-Frame-Options: DENY\\nmore_clear_headers *\": header values must not contain control characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var route rpaasclient.UpdateRouteArgs
+			client := &fake.FakeClient{
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					route = args
+					return nil
+				},
+			}
+
+			args := append([]string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "--content", contentFile}, tt.args...)
+			err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, client).Run(args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedContent, route.Content)
+		})
+	}
+
+	t.Run("along with a destination", func(t *testing.T) {
+		err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, &fake.FakeClient{}).Run([]string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app.tsuru.example.com", "--remove-header", "Server"})
+		assert.EqualError(t, err, "--add-response-header, --add-request-header and --remove-header cannot be used along with --destination or --redirect, set the proxy_pass on --content instead")
+	})
+}
+
+func TestListRoutesWithHeaders(t *testing.T) {
+	headers := headersRouteContent(&routeHeaders{
+		AddResponse: []routeHeader{{Name: "X-Frame-Options", Value: "DENY"}, {Name: "X-Note", Value: `say "hi" \o/`}},
+		AddRequest:  []routeHeader{{Name: "X-Forwarded-Site", Value: "$host"}},
+		Remove:      []string{"Server"},
+	})
+
+	client := &fake.FakeClient{
+		FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+			return []clientTypes.Route{
+				{Path: "/app", Content: string(headers) + "proxy_pass http://app.internal;\n"},
+				{Path: "/static", Destination: "static.tsuru.example.com"},
+			}, nil
+		},
+	}
+
+	t.Run("on table format", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "routes", "list", "-i", "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, `+---------+------------------------------------------------------------------------------------------------+--------------+---------------------------------+
+| Path    | Destination                                                                                    | Force HTTPS? | Configuration                   |
++---------+------------------------------------------------------------------------------------------------+--------------+---------------------------------+
+| /app    | headers: +X-Frame-Options (response), +X-Note (response), +X-Forwarded-Site (request), -Server |              | proxy_pass http://app.internal; |
+|         |                                                                                                |              |                                 |
+| /static | static.tsuru.example.com                                                                       |              |                                 |
++---------+------------------------------------------------------------------------------------------------+--------------+---------------------------------+
+`, stdout.String())
+	})
+
+	t.Run("on JSON format", func(t *testing.T) {
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "routes", "list", "-i", "my-instance", "-o", "json"})
+		require.NoError(t, err)
+		assert.JSONEq(t, `[
+			{
+				"path": "/app",
+				"content": "# BEGIN headers\nadd_header X-Frame-Options \"DENY\" always;\nadd_header X-Note \"say \\\"hi\\\" \\\\o/\" always;\nproxy_set_header X-Forwarded-Site \"$host\";\nmore_clear_headers \"Server\";\n# END headers\nproxy_pass http://app.internal;\n",
+				"headers": {
+					"add_response": [{"name": "X-Frame-Options", "value": "DENY"}, {"name": "X-Note", "value": "say \"hi\" \\o/"}],
+					"add_request": [{"name": "X-Forwarded-Site", "value": "$host"}],
+					"remove": ["Server"]
+				}
+			},
+			{"path": "/static", "destination": "static.tsuru.example.com"}
+		]`, stdout.String())
+	})
+}
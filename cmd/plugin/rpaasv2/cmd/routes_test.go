@@ -7,7 +7,11 @@ package cmd
 import (
 	"bytes"
 	"fmt"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,6 +28,7 @@ func TestDeleteRoute(t *testing.T) {
 		args          []string
 		expected      string
 		expectedError string
+		stdin         string
 		client        rpaasclient.Client
 	}{
 		{
@@ -56,6 +61,152 @@ func TestDeleteRoute(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "when the route is rate-limited",
+			args:     []string{"./rpaasv2", "routes", "delete", "-i", "my-instance", "-p", "/api"},
+			expected: fmt.Sprintf("Route \"/api\" deleted.\nRate limit zone %q removed from the http block.\n", rateLimitZoneName("/api")),
+			client: &fake.FakeClient{
+				FakeListBlocksWithVersion: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, string, error) {
+					content := rateLimitZoneBlock("client_max_body_size 8m;\n", rateLimitZoneName("/api"), "10r/s")
+					content = rateLimitZoneBlock(content, rateLimitZoneName("/admin"), "1r/s")
+					return []clientTypes.Block{{Name: "http", Content: content}}, "v1", nil
+				},
+				FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+					assert.Equal(t, rpaasclient.UpdateBlockArgs{
+						Instance: "my-instance",
+						Name:     "http",
+						Content:  rateLimitZoneBlock("client_max_body_size 8m;\n", rateLimitZoneName("/admin"), "1r/s"),
+						Version:  "v1",
+					}, args)
+					return nil
+				},
+			},
+		},
+		{
+			name:     "when the rate limit zone is all the http block has",
+			args:     []string{"./rpaasv2", "routes", "delete", "-i", "my-instance", "-p", "/api"},
+			expected: fmt.Sprintf("Route \"/api\" deleted.\nRate limit zone %q removed from the http block.\n", rateLimitZoneName("/api")),
+			client: &fake.FakeClient{
+				FakeListBlocks: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, error) {
+					return []clientTypes.Block{{Name: "http", Content: rateLimitZoneBlock("", rateLimitZoneName("/api"), "10r/s")}}, nil
+				},
+				FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+					assert.Fail(t, "the http block should be deleted rather than left empty")
+					return nil
+				},
+				FakeDeleteBlock: func(args rpaasclient.DeleteBlockArgs) error {
+					assert.Equal(t, rpaasclient.DeleteBlockArgs{Instance: "my-instance", Name: "http"}, args)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when neither path nor path prefix are provided",
+			args:          []string{"./rpaasv2", "routes", "delete", "-i", "my-instance"},
+			expectedError: "either --path or --path-prefix must be provided",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when both path and path prefix are provided",
+			args:          []string{"./rpaasv2", "routes", "delete", "-i", "my-instance", "-p", "/api/v1", "--path-prefix", "/api"},
+			expectedError: "either --path or --path-prefix must be provided",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name: "when no route matches the path prefix",
+			args: []string{"./rpaasv2", "routes", "delete", "-i", "my-instance", "--path-prefix", "/admin"},
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return []clientTypes.Route{
+						{Path: "/"},
+						{Path: "/api/v1", Destination: "v1.example.com"},
+						{Path: "/api/v2", Destination: "v2.example.com"},
+						{Path: "/static"},
+					}, nil
+				},
+			},
+			expected: "No routes matched the path prefix \"/admin\".\n",
+		},
+		{
+			name: "removing routes by path prefix on dry-run mode",
+			args: []string{"./rpaasv2", "routes", "delete", "-i", "my-instance", "--path-prefix", "/api/", "--dry-run"},
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return []clientTypes.Route{
+						{Path: "/"},
+						{Path: "/api/v1", Destination: "v1.example.com"},
+						{Path: "/api/v2", Destination: "v2.example.com"},
+						{Path: "/static"},
+					}, nil
+				},
+				FakeDeleteRoute: func(args rpaasclient.DeleteRouteArgs) error {
+					require.FailNow(t, "should not invoke this method")
+					return nil
+				},
+			},
+			expected: "Routes matching the path prefix \"/api/\":\n  /api/v1\n  /api/v2\n",
+		},
+		{
+			name: "removing routes by path prefix without confirmation",
+			args: []string{"./rpaasv2", "routes", "delete", "-i", "my-instance", "--path-prefix", "/api/", "--yes"},
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					assert.Equal(t, rpaasclient.ListRoutesArgs{Instance: "my-instance"}, args)
+					return []clientTypes.Route{
+						{Path: "/"},
+						{Path: "/api/v1", Destination: "v1.example.com"},
+						{Path: "/api/v2", Destination: "v2.example.com"},
+						{Path: "/static"},
+					}, nil
+				},
+				FakeDeleteRoute: func(args rpaasclient.DeleteRouteArgs) error {
+					assert.Equal(t, "my-instance", args.Instance)
+					assert.Contains(t, []string{"/api/v1", "/api/v2"}, args.Path)
+					return nil
+				},
+			},
+			expected: "Routes matching the path prefix \"/api/\":\n  /api/v1\n  /api/v2\nRoute \"/api/v1\" deleted.\nRoute \"/api/v2\" deleted.\n",
+		},
+		{
+			name:  "removing routes by path prefix after confirming",
+			args:  []string{"./rpaasv2", "routes", "delete", "-i", "my-instance", "--path-prefix", "/api/v2"},
+			stdin: "y\n",
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return []clientTypes.Route{
+						{Path: "/"},
+						{Path: "/api/v1", Destination: "v1.example.com"},
+						{Path: "/api/v2", Destination: "v2.example.com"},
+						{Path: "/static"},
+					}, nil
+				},
+				FakeDeleteRoute: func(args rpaasclient.DeleteRouteArgs) error {
+					assert.Equal(t, rpaasclient.DeleteRouteArgs{Instance: "my-instance", Path: "/api/v2"}, args)
+					return nil
+				},
+			},
+			expected: "Routes matching the path prefix \"/api/v2\":\n  /api/v2\nAre you sure you want to remove 1 route(s) from my-instance? (y/N) Route \"/api/v2\" deleted.\n",
+		},
+		{
+			name:  "removing routes by path prefix without confirming",
+			args:  []string{"./rpaasv2", "routes", "delete", "-i", "my-instance", "--path-prefix", "/"},
+			stdin: "\n",
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return []clientTypes.Route{
+						{Path: "/"},
+						{Path: "/api/v1", Destination: "v1.example.com"},
+						{Path: "/api/v2", Destination: "v2.example.com"},
+						{Path: "/static"},
+					}, nil
+				},
+				FakeDeleteRoute: func(args rpaasclient.DeleteRouteArgs) error {
+					require.FailNow(t, "should not invoke this method")
+					return nil
+				},
+			},
+			expected: "Routes matching the path prefix \"/\":\n  /\n  /api/v1\n  /api/v2\n  /static\nAre you sure you want to remove 4 route(s) from my-instance? (y/N) Aborted.\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -63,6 +214,7 @@ func TestDeleteRoute(t *testing.T) {
 			stdout := &bytes.Buffer{}
 			stderr := &bytes.Buffer{}
 			app := NewApp(stdout, stderr, tt.client)
+			app.Reader = strings.NewReader(tt.stdin)
 			err := app.Run(tt.args)
 			if tt.expectedError != "" {
 				assert.Error(t, err)
@@ -99,13 +251,18 @@ func TestListRoutes(t *testing.T) {
 		{
 			name: "when listing routes on table format",
 			args: []string{"./rpaasv2", "routes", "list", "-i", "my-instance"},
-			expected: `+--------------+-------------------------------+--------------+-------------------+
-| Path         | Destination                   | Force HTTPS? | Configuration     |
-+--------------+-------------------------------+--------------+-------------------+
-| /static      | static.apps.tsuru.example.com |              |                   |
-| /login       | login.apps.tsuru.example.com  |      ✓       |                   |
-| /custom/path |                               |              | # My NGINX config |
-+--------------+-------------------------------+--------------+-------------------+
+			expected: `+--------------+--------------------------------------------------------------+--------------+---------------------------------------------------+
+| Path         | Destination                                                  | Force HTTPS? | Configuration                                     |
++--------------+--------------------------------------------------------------+--------------+---------------------------------------------------+
+| /static      | static.apps.tsuru.example.com                                |              |                                                   |
+| /login       | login.apps.tsuru.example.com                                 |      ✓       |                                                   |
+| /custom/path |                                                              |              | # My NGINX config                                 |
+| /old         | redirect (301) to https://new.example.com$request_uri        |              |                                                   |
+| /api         | api.apps.tsuru.example.com (weight 2, 66.7%)                 |              |                                                   |
+|              | api-canary.apps.tsuru.example.com (weight 1, 33.3%)          |              |                                                   |
+| /cors        | CORS from https://app.example.com, https://admin.example.com |              | proxy_pass http://cors.apps.tsuru.example.com;    |
+| /limited     | rate limited to 10r/s (burst 20)                             |              | proxy_pass http://limited.apps.tsuru.example.com; |
++--------------+--------------------------------------------------------------+--------------+---------------------------------------------------+
 `,
 			client: &fake.FakeClient{
 				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
@@ -125,6 +282,55 @@ func TestListRoutes(t *testing.T) {
 							Path:    "/custom/path",
 							Content: "# My NGINX config",
 						},
+						{
+							Path:    "/old",
+							Content: "return 301 https://new.example.com$request_uri;\n",
+						},
+						{
+							Path: "/api",
+							Destinations: []clientTypes.WeightedDestination{
+								{Destination: "api.apps.tsuru.example.com", Weight: 2},
+								{Destination: "api-canary.apps.tsuru.example.com", Weight: 1},
+							},
+						},
+						{
+							Path:    "/cors",
+							Content: "# BEGIN CORS origins=https://app.example.com,https://admin.example.com\nadd_header Vary Origin always;\n# END CORS\nproxy_pass http://cors.apps.tsuru.example.com;",
+						},
+						{
+							Path:    "/limited",
+							Content: "# BEGIN rate limit zone=rate_limit_limited_4b7f0a3e rate=10r/s burst=20\nlimit_req zone=rate_limit_limited_4b7f0a3e burst=20 nodelay;\nlimit_req_status 429;\n# END rate limit\nproxy_pass http://limited.apps.tsuru.example.com;",
+						},
+					}, nil
+				},
+			},
+		},
+		{
+			name: "when listing routes on CSV format",
+			args: []string{"./rpaasv2", "routes", "list", "-i", "my-instance", "-o", "csv"},
+			expected: "Path,Destination,Force HTTPS?,Configuration\r\n" +
+				"/login,login.apps.tsuru.example.com,true,\r\n" +
+				"\"/a,b\",,false,\"add_header X-Quote \"\"a, b\"\";\r\nreturn 204;\"\r\n" +
+				"/api,\"api.apps.tsuru.example.com (weight 2, 66.7%)\r\napi-canary.apps.tsuru.example.com (weight 1, 33.3%)\",false,\r\n",
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return []clientTypes.Route{
+						{
+							Path:        "/login",
+							Destination: "login.apps.tsuru.example.com",
+							HTTPSOnly:   true,
+						},
+						{
+							Path:    "/a,b",
+							Content: "add_header X-Quote \"a, b\";\nreturn 204;",
+						},
+						{
+							Path: "/api",
+							Destinations: []clientTypes.WeightedDestination{
+								{Destination: "api.apps.tsuru.example.com", Weight: 2},
+								{Destination: "api-canary.apps.tsuru.example.com", Weight: 1},
+							},
+						},
 					}, nil
 				},
 			},
@@ -170,6 +376,45 @@ func TestListRoutes(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "when sorting routes by destination in reverse order",
+			args: []string{"./rpaasv2", "routes", "list", "-i", "my-instance", "-o", "csv", "--sort", "destination", "--reverse"},
+			expected: "Path,Destination,Force HTTPS?,Configuration\r\n" +
+				"/login,login.apps.tsuru.example.com,false,\r\n" +
+				"/static,app.apps.tsuru.example.com,false,\r\n" +
+				"/,app.apps.tsuru.example.com,false,\r\n" +
+				"/custom,,false,# My NGINX config\r\n",
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return []clientTypes.Route{
+						{Path: "/static", Destination: "app.apps.tsuru.example.com"},
+						{Path: "/custom", Content: "# My NGINX config"},
+						{Path: "/login", Destination: "login.apps.tsuru.example.com"},
+						{Path: "/", Destination: "app.apps.tsuru.example.com"},
+					}, nil
+				},
+			},
+		},
+		{
+			name:          "when sorting routes by an unknown key",
+			args:          []string{"./rpaasv2", "routes", "list", "-i", "my-instance", "--sort", "size"},
+			expectedError: `unsupported sort key "size" (one of: destination, path)`,
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return nil, nil
+				},
+			},
+		},
+		{
+			name:          "when reversing routes without sorting them",
+			args:          []string{"./rpaasv2", "routes", "list", "-i", "my-instance", "--reverse"},
+			expectedError: "--reverse can only be used along with --sort",
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return nil, nil
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -190,6 +435,60 @@ func TestListRoutes(t *testing.T) {
 	}
 }
 
+func TestListRoutesWithProxyTimeouts(t *testing.T) {
+	client := &fake.FakeClient{
+		FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+			return []clientTypes.Route{
+				{Path: "/reports", Content: "# BEGIN proxy timeouts read=5m send=90s\nproxy_read_timeout 5m;\nproxy_send_timeout 90s;\n# END proxy timeouts\nproxy_pass http://reports.internal;\n"},
+			}, nil
+		},
+	}
+
+	stdout := &bytes.Buffer{}
+	err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "routes", "list", "-i", "my-instance"})
+	require.NoError(t, err)
+	assert.Equal(t, `+----------+-----------------------------------+--------------+-------------------------------------+
+| Path     | Destination                       | Force HTTPS? | Configuration                       |
++----------+-----------------------------------+--------------+-------------------------------------+
+| /reports | proxy timeouts: read 5m, send 90s |              | proxy_pass http://reports.internal; |
+|          |                                   |              |                                     |
++----------+-----------------------------------+--------------+-------------------------------------+
+`, stdout.String())
+}
+
+func TestListRoutesSortedByPath(t *testing.T) {
+	routes := []clientTypes.Route{
+		{Path: "/static", Destination: "static.apps.tsuru.example.com"},
+		{Path: "/", Destination: "app.apps.tsuru.example.com"},
+		{Path: "/login", Destination: "login.apps.tsuru.example.com", HTTPSOnly: true},
+		{Path: "/api", Destination: "api.apps.tsuru.example.com"},
+		{Path: "/api/v2", Destination: "api-v2.apps.tsuru.example.com"},
+	}
+
+	expected := "Path,Destination,Force HTTPS?,Configuration\r\n" +
+		"/,app.apps.tsuru.example.com,false,\r\n" +
+		"/api,api.apps.tsuru.example.com,false,\r\n" +
+		"/api/v2,api-v2.apps.tsuru.example.com,false,\r\n" +
+		"/login,login.apps.tsuru.example.com,true,\r\n" +
+		"/static,static.apps.tsuru.example.com,false,\r\n"
+
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 10; i++ {
+		client := &fake.FakeClient{
+			FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+				shuffled := slices.Clone(routes)
+				r.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+				return shuffled, nil
+			},
+		}
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "routes", "list", "-i", "my-instance", "-o", "csv", "--sort", "path"})
+		require.NoError(t, err)
+		assert.Equal(t, expected, stdout.String())
+	}
+}
+
 func TestUpdateRoute(t *testing.T) {
 	nginxConfig := `# My custom NGINX configuration`
 	configFile, err := os.CreateTemp("", "nginx.*.cfg")
@@ -199,6 +498,10 @@ func TestUpdateRoute(t *testing.T) {
 	require.NoError(t, configFile.Close())
 	defer os.Remove(configFile.Name())
 
+	envConfigFile := filepath.Join(t.TempDir(), "api.conf")
+	require.NoError(t, os.WriteFile(envConfigFile, []byte("proxy_pass http://${BACKEND_HOST}$${request_uri};\n"), 0644))
+	t.Setenv("BACKEND_HOST", "api.tsuru.example.com")
+
 	tests := []struct {
 		name          string
 		args          []string
@@ -237,6 +540,9 @@ func TestUpdateRoute(t *testing.T) {
 					assert.Equal(t, expected, args)
 					return nil
 				},
+				FakeListCertificates: func(args rpaasclient.ListCertificatesArgs) ([]clientTypes.Certificate, error) {
+					return []clientTypes.Certificate{{Name: "default"}}, nil
+				},
 			},
 		},
 		{
@@ -255,6 +561,390 @@ func TestUpdateRoute(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "when expanding environment variables on a custom NGINX config",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "-c", envConfigFile, "--expand-env"},
+			expected: "Route \"/api\" updated.\n",
+			client: &fake.FakeClient{
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					assert.Equal(t, "proxy_pass http://api.tsuru.example.com${request_uri};\n", args.Content)
+					return nil
+				},
+			},
+		},
+		{
+			name:     "when redirecting to another URL",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/old", "--redirect", "https://new.example.com$request_uri"},
+			expected: "Route \"/old\" updated.\n",
+			client: &fake.FakeClient{
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					expected := rpaasclient.UpdateRouteArgs{
+						Instance: "my-instance",
+						Path:     "/old",
+						Content:  "return 301 https://new.example.com$request_uri;\n",
+					}
+					assert.Equal(t, expected, args)
+					return nil
+				},
+			},
+		},
+		{
+			name:     "when redirecting temporarily",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/promo", "--redirect", "http://promo.example.com/", "--redirect-code", "302"},
+			expected: "Route \"/promo\" updated.\n",
+			client: &fake.FakeClient{
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					assert.Equal(t, "return 302 http://promo.example.com/;\n", args.Content)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when redirect URL is relative",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/old", "--redirect", "/new"},
+			expectedError: `invalid redirect URL "/new": must be an absolute HTTP(S) URL`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when redirect URL would break the NGINX configuration",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/old", "--redirect", "https://new.example.com; return 200"},
+			expectedError: `invalid redirect URL "https://new.example.com; return 200": must not contain whitespaces, quotes, braces nor semicolons`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when redirect code is not a redirection",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/old", "--redirect", "https://new.example.com", "--redirect-code", "200"},
+			expectedError: "invalid redirect code 200 (one of: 301, 302, 303, 307, 308)",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when redirect is combined with destination",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/old", "--redirect", "https://new.example.com", "-d", "app.tsuru.example.com"},
+			expectedError: "--redirect cannot be used along with --destination or --content",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when redirect code is set without redirect",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/old", "-d", "app.tsuru.example.com", "--redirect-code", "302"},
+			expectedError: "--redirect-code can only be used along with --redirect",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:     "when allowing cross-origin requests from some origins",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "-c", configFile.Name(), "--cors-origin", "https://app.example.com", "--cors-origin", "http://localhost:8080", "--cors-methods", "get", "--cors-methods", "PUT", "--cors-headers", "Authorization", "--cors-headers", "X-Request-Id", "--cors-credentials"},
+			expected: "Route \"/api\" updated.\n",
+			client: &fake.FakeClient{
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					expected := rpaasclient.UpdateRouteArgs{
+						Instance: "my-instance",
+						Path:     "/api",
+						Content: `# BEGIN CORS origins=https://app.example.com,http://localhost:8080
+set $cors_origin "";
+if ($http_origin = "https://app.example.com") {
+    set $cors_origin $http_origin;
+}
+if ($http_origin = "http://localhost:8080") {
+    set $cors_origin $http_origin;
+}
+if ($request_method = OPTIONS) {
+    add_header Access-Control-Allow-Origin $cors_origin always;
+    add_header Access-Control-Allow-Credentials true always;
+    add_header Vary Origin always;
+    add_header Access-Control-Allow-Methods "GET, PUT" always;
+    add_header Access-Control-Allow-Headers "Authorization, X-Request-Id" always;
+    add_header Access-Control-Max-Age 86400 always;
+    return 204;
+}
+add_header Access-Control-Allow-Origin $cors_origin always;
+add_header Access-Control-Allow-Credentials true always;
+add_header Vary Origin always;
+# END CORS
+` + nginxConfig,
+					}
+					assert.Equal(t, expected, args)
+					return nil
+				},
+			},
+		},
+		{
+			name:     "when allowing cross-origin requests from any origin",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/public", "--cors-origin", "*"},
+			expected: "Route \"/public\" updated.\n",
+			client: &fake.FakeClient{
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					assert.Equal(t, `# BEGIN CORS origins=*
+if ($request_method = OPTIONS) {
+    add_header Access-Control-Allow-Origin * always;
+    add_header Access-Control-Allow-Methods "GET, POST, OPTIONS" always;
+    add_header Access-Control-Max-Age 86400 always;
+    return 204;
+}
+add_header Access-Control-Allow-Origin * always;
+# END CORS
+`, args.Content)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when allowing credentials from any origin",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/public", "--cors-origin", "*", "--cors-credentials"},
+			expectedError: `--cors-origin "*" cannot be used along with --cors-credentials, as browsers reject credentials on wildcard origins`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when any origin is mixed with other ones",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/public", "--cors-origin", "*", "--cors-origin", "https://app.example.com"},
+			expectedError: `--cors-origin "*" cannot be used along with other origins`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when the CORS origin is not an URL",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "--cors-origin", "app.example.com/login"},
+			expectedError: `invalid CORS origin "app.example.com/login": must be "*" or an URL made of scheme, host and optional port (e.g. https://app.example.com)`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when the CORS header is invalid",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "--cors-origin", "https://app.example.com", "--cors-headers", "X-Foo;"},
+			expectedError: `invalid CORS header "X-Foo;": must be made of letters, digits and dashes`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when CORS is set along with a destination",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "-d", "api.tsuru.example.com", "--cors-origin", "https://app.example.com"},
+			expectedError: "--cors-origin cannot be used along with --destination or --redirect, set the proxy_pass on --content instead",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when CORS methods are set without origins",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "-d", "api.tsuru.example.com", "--cors-methods", "GET"},
+			expectedError: "--cors-methods, --cors-headers and --cors-credentials can only be used along with --cors-origin",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:     "when splitting the requests among weighted destinations",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app.tsuru.example.com", "--weight", "90", "-d", "app-canary.tsuru.example.com", "--weight", "10"},
+			expected: "Route \"/app\" updated.\n",
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return nil, nil
+				},
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					expected := rpaasclient.UpdateRouteArgs{
+						Instance: "my-instance",
+						Path:     "/app",
+						Destinations: []clientTypes.WeightedDestination{
+							{Destination: "app.tsuru.example.com", Weight: 9},
+							{Destination: "app-canary.tsuru.example.com", Weight: 1},
+						},
+					}
+					assert.Equal(t, expected, args)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when many destinations are set without weights",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app.tsuru.example.com", "-d", "app-canary.tsuru.example.com"},
+			expectedError: "--weight must be set for each --destination when there are many of them",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when weights do not pair with destinations",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app.tsuru.example.com", "--weight", "1", "-d", "app-canary.tsuru.example.com"},
+			expectedError: "--weight must be set for each --destination (got 1 weight(s) for 2 destination(s))",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when a weight is not positive",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app.tsuru.example.com", "--weight", "1", "-d", "app-canary.tsuru.example.com", "--weight", "0"},
+			expectedError: `invalid weight 0 of destination "app-canary.tsuru.example.com": must be a positive integer`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when a weighted destination is repeated",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app.tsuru.example.com", "--weight", "1", "-d", "app.tsuru.example.com", "--weight", "2"},
+			expectedError: `destination "app.tsuru.example.com" is set more than once`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when weight is set on a single destination",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app.tsuru.example.com", "--weight", "1"},
+			expectedError: "--weight requires at least two destinations",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:     "when rate limiting the requests",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "-c", configFile.Name(), "--rate-limit", "10r/s", "--rate-limit-burst", "20"},
+			expected: "Rate limit zone \"rate_limit_api_0b81c40a\" set on the http block.\nRoute \"/api\" updated.\n",
+			client: &fake.FakeClient{
+				FakeListBlocksWithVersion: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, string, error) {
+					assert.Equal(t, rpaasclient.ListBlocksArgs{Instance: "my-instance"}, args)
+					return []clientTypes.Block{{Name: "http", Content: "gzip on;"}}, "v1", nil
+				},
+				FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+					assert.Equal(t, rpaasclient.UpdateBlockArgs{
+						Instance: "my-instance",
+						Name:     "http",
+						Content: `gzip on;
+# BEGIN rate limit zone=rate_limit_api_0b81c40a
+limit_req_zone $binary_remote_addr zone=rate_limit_api_0b81c40a:10m rate=10r/s;
+# END rate limit zone=rate_limit_api_0b81c40a
+`,
+						Version: "v1",
+					}, args)
+					return nil
+				},
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					expected := rpaasclient.UpdateRouteArgs{
+						Instance: "my-instance",
+						Path:     "/api",
+						Content: `# BEGIN rate limit zone=rate_limit_api_0b81c40a rate=10r/s burst=20
+limit_req zone=rate_limit_api_0b81c40a burst=20 nodelay;
+limit_req_status 429;
+# END rate limit
+` + nginxConfig,
+					}
+					assert.Equal(t, expected, args)
+					return nil
+				},
+			},
+		},
+		{
+			name:     "when rate limiting the requests with the zone already set",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/", "--rate-limit", "600r/m"},
+			expected: "Route \"/\" updated.\n",
+			client: &fake.FakeClient{
+				FakeListBlocksWithVersion: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, string, error) {
+					return []clientTypes.Block{{Name: "http", Content: "# BEGIN rate limit zone=rate_limit_2a0c975e\nlimit_req_zone $binary_remote_addr zone=rate_limit_2a0c975e:10m rate=600r/m;\n# END rate limit zone=rate_limit_2a0c975e\n"}}, "v1", nil
+				},
+				FakeUpdateBlock: func(args rpaasclient.UpdateBlockArgs) error {
+					assert.Fail(t, "the http block should not be updated")
+					return nil
+				},
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					assert.Equal(t, "# BEGIN rate limit zone=rate_limit_2a0c975e rate=600r/m burst=0\nlimit_req zone=rate_limit_2a0c975e;\nlimit_req_status 429;\n# END rate limit\n", args.Content)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when the rate limit is invalid",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "--rate-limit", "10/s"},
+			expectedError: `invalid rate limit "10/s": must be a positive number of requests per second or minute (e.g. 10r/s or 600r/m)`,
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when the rate limit burst is negative",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "--rate-limit", "10r/s", "--rate-limit-burst", "-1"},
+			expectedError: "invalid rate limit burst -1: must not be negative",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when rate limit is set along with a destination",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "-d", "app.tsuru.example.com", "--rate-limit", "10r/s"},
+			expectedError: "--rate-limit cannot be used along with --destination or --redirect, set the proxy_pass on --content instead",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when rate limit burst is set without rate limit",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "--rate-limit-burst", "10"},
+			expectedError: "--rate-limit-burst can only be used along with --rate-limit",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:     "when setting proxy timeouts along with a rate limit",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/", "-c", configFile.Name(), "--rate-limit", "600r/m", "--proxy-read-timeout", "5m", "--proxy-connect-timeout", "1500ms", "--proxy-send-timeout", "90s"},
+			expected: "Route \"/\" updated.\n",
+			client: &fake.FakeClient{
+				FakeListBlocksWithVersion: func(args rpaasclient.ListBlocksArgs) ([]clientTypes.Block, string, error) {
+					return []clientTypes.Block{{Name: "http", Content: "# BEGIN rate limit zone=rate_limit_2a0c975e\nlimit_req_zone $binary_remote_addr zone=rate_limit_2a0c975e:10m rate=600r/m;\n# END rate limit zone=rate_limit_2a0c975e\n"}}, "v1", nil
+				},
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					assert.Equal(t, `# BEGIN proxy timeouts read=5m connect=1500ms send=90s
+proxy_read_timeout 5m;
+proxy_connect_timeout 1500ms;
+proxy_send_timeout 90s;
+# END proxy timeouts
+# BEGIN rate limit zone=rate_limit_2a0c975e rate=600r/m burst=0
+limit_req zone=rate_limit_2a0c975e;
+limit_req_status 429;
+# END rate limit
+`+nginxConfig, args.Content)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when a proxy timeout is not positive",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "-c", configFile.Name(), "--proxy-read-timeout", "0s"},
+			expectedError: "invalid --proxy-read-timeout 0s: must be greater than zero",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when a proxy timeout has less than a millisecond",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "-c", configFile.Name(), "--proxy-send-timeout", "1.0005s"},
+			expectedError: "invalid --proxy-send-timeout 1.0005s: must be a whole number of milliseconds",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:          "when a proxy timeout is set along with a destination",
+			args:          []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api", "-d", "app.tsuru.example.com", "--proxy-read-timeout", "30s"},
+			expectedError: "--proxy-read-timeout, --proxy-connect-timeout and --proxy-send-timeout cannot be used along with --destination or --redirect, set the proxy_pass on --content instead",
+			client:        &fake.FakeClient{},
+		},
+		{
+			name:     "when --if-changed is set and the route is the same",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/custom/path", "-c", configFile.Name(), "--if-changed"},
+			expected: "Route \"/custom/path\" unchanged.\n",
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					assert.Equal(t, rpaasclient.ListRoutesArgs{Instance: "my-instance"}, args)
+					return []clientTypes.Route{
+						{Path: "/app", Destination: "app.tsuru.example.com"},
+						{Path: "/custom/path", Content: nginxConfig + "  \r\n\n"},
+					}, nil
+				},
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					require.FailNow(t, "should not invoke this method")
+					return nil
+				},
+			},
+		},
+		{
+			name:     "when --if-changed is set and the route differs",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app.tsuru.example.com", "--https-only", "--if-changed"},
+			expected: "Route \"/app\" updated.\n",
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return []clientTypes.Route{
+						{Path: "/app", Destination: "app.tsuru.example.com"},
+					}, nil
+				},
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					assert.Equal(t, rpaasclient.UpdateRouteArgs{Instance: "my-instance", Path: "/app", Destination: "app.tsuru.example.com", HTTPSOnly: true}, args)
+					return nil
+				},
+				FakeListCertificates: func(args rpaasclient.ListCertificatesArgs) ([]clientTypes.Certificate, error) {
+					return []clientTypes.Certificate{{Name: "default"}}, nil
+				},
+			},
+		},
+		{
+			name:     "when --if-changed is set and the route does not exist",
+			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/custom/path", "-c", configFile.Name(), "--if-changed"},
+			expected: "Route \"/custom/path\" updated.\n",
+			client: &fake.FakeClient{
+				FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+					return nil, nil
+				},
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					assert.Equal(t, nginxConfig, args.Content)
+					return nil
+				},
+			},
+		},
 		{
 			name:     "when using a custom NGINX config with @ prefixed file path",
 			args:     []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/custom/path", "-c", "@" + configFile.Name()},
@@ -290,3 +980,189 @@ func TestUpdateRoute(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateRouteExpandingIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"api.conf":              "include snippets/cors.conf;\nproxy_pass http://app.tsuru.example.com;\n",
+		"snippets/cors.conf":    "add_header Access-Control-Allow-Origin *;\n  include \"snippets/headers.conf\";\n",
+		"snippets/headers.conf": "add_header X-Frame-Options DENY;\n",
+		"twice.conf":            "include snippets/headers.conf;\ninclude snippets/headers.conf;\n",
+		"cycle.conf":            "include cycle/a.conf;\n",
+		"cycle/a.conf":          "include cycle/b.conf;\n",
+		"cycle/b.conf":          "include cycle/a.conf;\n",
+		"absolute.conf":         "include /etc/passwd;\n",
+		"outside.conf":          "include ../outside.conf;\n",
+		"glob.conf":             "include snippets/*.conf;\n",
+		"missing.conf":          "include snippets/missing.conf;\n",
+		"nginx-directives.conf": "# include snippets/headers.conf;\nssi_include_timeout 1s;\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	tests := []struct {
+		name            string
+		args            []string
+		expected        string
+		expectedContent string
+		expectedError   string
+	}{
+		{
+			name:            "inlining nested includes",
+			args:            []string{"--content-file", filepath.Join(dir, "api.conf"), "--expand-includes"},
+			expectedContent: "add_header Access-Control-Allow-Origin *;\nadd_header X-Frame-Options DENY;\nproxy_pass http://app.tsuru.example.com;\n",
+			expected:        "Route \"/api\" updated.\nInlined snippets/cors.conf\nInlined snippets/headers.conf\n",
+		},
+		{
+			name:            "reporting files included more than once only once",
+			args:            []string{"--content-file", filepath.Join(dir, "twice.conf"), "--expand-includes"},
+			expectedContent: "add_header X-Frame-Options DENY;\nadd_header X-Frame-Options DENY;\n",
+			expected:        "Route \"/api\" updated.\nInlined snippets/headers.conf\n",
+		},
+		{
+			name:            "without expanding includes",
+			args:            []string{"--content-file", filepath.Join(dir, "api.conf")},
+			expectedContent: files["api.conf"],
+			expected:        "Route \"/api\" updated.\n",
+		},
+		{
+			name:            "ignoring comments and other directives",
+			args:            []string{"--content-file", filepath.Join(dir, "nginx-directives.conf"), "--expand-includes"},
+			expectedContent: files["nginx-directives.conf"],
+			expected:        "Route \"/api\" updated.\n",
+		},
+		{
+			name:          "when includes are nested deeper than the max depth",
+			args:          []string{"--content-file", filepath.Join(dir, "api.conf"), "--expand-includes", "--max-include-depth", "1"},
+			expectedError: "cannot include snippets/headers.conf: includes are nested deeper than 1 level(s) (see --max-include-depth)",
+		},
+		{
+			name:          "when there's an include cycle",
+			args:          []string{"--content-file", filepath.Join(dir, "cycle.conf"), "--expand-includes"},
+			expectedError: "include cycle detected: cycle/a.conf -> cycle/b.conf -> cycle/a.conf",
+		},
+		{
+			name:          "when including an absolute path",
+			args:          []string{"--content-file", filepath.Join(dir, "absolute.conf"), "--expand-includes"},
+			expectedError: `cannot include "/etc/passwd": only relative paths are allowed`,
+		},
+		{
+			name:          "when including a path out of the content file directory",
+			args:          []string{"--content-file", filepath.Join(dir, "outside.conf"), "--expand-includes"},
+			expectedError: `cannot include "../outside.conf": it points out of the directory of the content file`,
+		},
+		{
+			name:          "when including a glob pattern",
+			args:          []string{"--content-file", filepath.Join(dir, "glob.conf"), "--expand-includes"},
+			expectedError: `cannot include "snippets/*.conf": glob patterns are not supported`,
+		},
+		{
+			name:          "when the included file does not exist",
+			args:          []string{"--content-file", filepath.Join(dir, "missing.conf"), "--expand-includes"},
+			expectedError: fmt.Sprintf("could not read the included file: open %s: no such file or directory", filepath.Join(dir, "snippets/missing.conf")),
+		},
+		{
+			name:          "when expanding includes without content",
+			args:          []string{"-d", "app.tsuru.example.com", "--expand-includes"},
+			expectedError: "--expand-includes requires --content",
+		},
+		{
+			name:          "when setting max depth without expanding includes",
+			args:          []string{"--content-file", filepath.Join(dir, "api.conf"), "--max-include-depth", "2"},
+			expectedError: "--max-include-depth can only be used along with --expand-includes",
+		},
+		{
+			name:          "when max depth is not positive",
+			args:          []string{"--content-file", filepath.Join(dir, "api.conf"), "--expand-includes", "--max-include-depth", "0"},
+			expectedError: "--max-include-depth must be greater than zero",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fake.FakeClient{
+				FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+					assert.Equal(t, tt.expectedContent, args.Content)
+					return nil
+				},
+			}
+
+			stdout := &bytes.Buffer{}
+			args := append([]string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/api"}, tt.args...)
+			err := NewApp(stdout, &bytes.Buffer{}, client).Run(args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
+
+func TestUpdateRouteHTTPSOnly(t *testing.T) {
+	newClient := func(currentHTTPSOnly bool, certs []clientTypes.Certificate, updated *rpaasclient.UpdateRouteArgs) *fake.FakeClient {
+		return &fake.FakeClient{
+			FakeListRoutes: func(args rpaasclient.ListRoutesArgs) ([]clientTypes.Route, error) {
+				assert.Equal(t, rpaasclient.ListRoutesArgs{Instance: "my-instance"}, args)
+				return []clientTypes.Route{
+					{Path: "/", Destination: "app1.tsuru.example.com"},
+					{Path: "/app", Destination: "app2.tsuru.example.com", HTTPSOnly: currentHTTPSOnly},
+				}, nil
+			},
+			FakeListCertificates: func(args rpaasclient.ListCertificatesArgs) ([]clientTypes.Certificate, error) {
+				return certs, nil
+			},
+			FakeUpdateRoute: func(args rpaasclient.UpdateRouteArgs) error {
+				*updated = args
+				return nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		args              []string
+		currentHTTPSOnly  bool
+		certificates      []clientTypes.Certificate
+		expectedHTTPSOnly bool
+		expectedStderr    string
+	}{
+		{
+			name:              "keeps the current setting when the flag is omitted",
+			args:              []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app3.tsuru.example.com"},
+			currentHTTPSOnly:  true,
+			certificates:      []clientTypes.Certificate{{Name: "default"}},
+			expectedHTTPSOnly: true,
+		},
+		{
+			name:             "unsets it when explicitly asked to",
+			args:             []string{"./rpaasv2", "routes", "update", "-i", "my-instance", "-p", "/app", "-d", "app3.tsuru.example.com", "--https-only=false"},
+			currentHTTPSOnly: true,
+		},
+		{
+			name:              "warns when the instance has no certificates",
+			args:              []string{"./rpaasv2", "routes", "update", "-s", "rpaasv2", "-i", "my-instance", "-p", "/app", "-d", "app3.tsuru.example.com", "--force-https"},
+			expectedHTTPSOnly: true,
+			expectedStderr:    "WARNING: rpaasv2/my-instance has no TLS certificate, so route \"/app\" cannot be reached as it only accepts HTTPS!\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated rpaasclient.UpdateRouteArgs
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, newClient(tt.currentHTTPSOnly, tt.certificates, &updated))
+			err := app.Run(tt.args)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedHTTPSOnly, updated.HTTPSOnly)
+			assert.Equal(t, "Route \"/app\" updated.\n", stdout.String())
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+		})
+	}
+}
@@ -5,17 +5,45 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
 
 	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
+var waitPollInterval = 2 * time.Second
+
 func NewCmdScale() *cli.Command {
 	return &cli.Command{
 		Name:  "scale",
 		Usage: "Sets the number of replicas for an instance",
+		Description: `
+# Set the number of replicas of an instance without autoscale:
+rpaasv2 scale -s my-service -i my-instance -q 3
+
+# Add two replicas to an instance, whatever the number of replicas it has:
+rpaasv2 scale -s my-service -i my-instance -q +2 --relative
+
+# Scale an instance and wait until its new replicas are serving requests:
+rpaasv2 scale -s my-service -i my-instance -q 3 --wait-healthy
+
+# Adjust the autoscale bounds of an instance:
+rpaasv2 scale -s my-service -i my-instance --min 3 --max 10
+
+# Keep at least 10 replicas every weekday from 8 AM until 8 PM (UTC), and 4 replicas on weekends:
+rpaasv2 scale -s my-service -i my-instance --schedule 'weekdays 08:00-20:00 replicas=10' --schedule 'weekends 10:00-18:00 replicas=4'
+`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "service",
@@ -29,11 +57,53 @@ func NewCmdScale() *cli.Command {
 				Required: true,
 			},
 			&cli.IntFlag{
-				Name:     "replicas",
-				Aliases:  []string{"quantity", "q"},
-				Usage:    "the desired replicas number",
-				Value:    -1,
-				Required: true,
+				Name:    "replicas",
+				Aliases: []string{"quantity", "q"},
+				Usage:   "the desired replicas number",
+				Value:   -1,
+			},
+			&cli.BoolFlag{
+				Name:  "relative",
+				Usage: "treats the replicas as a change to the current number of replicas, e.g. +2 or -1",
+			},
+			&cli.IntFlag{
+				Name:    "max-replicas",
+				Usage:   "the maximum number of replicas that --relative can scale the instance to",
+				EnvVars: []string{"RPAASV2_MAX_REPLICAS"},
+				Value:   100,
+			},
+			&cli.IntFlag{
+				Name:  "min",
+				Usage: "the minimum number of replicas of the autoscale (should not be combined with replicas)",
+			},
+			&cli.IntFlag{
+				Name:  "max",
+				Usage: "the maximum number of replicas of the autoscale (should not be combined with replicas)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "schedule",
+				Usage: "replaces the autoscale scheduled windows by ones like \"weekdays 08:00-20:00 replicas=10\" (days: daily, weekdays or weekends; times in UTC)",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "scales the instance manually even though autoscale is enabled (autoscale will revert it eventually)",
+			},
+			&cli.BoolFlag{
+				Name:  "wait",
+				Usage: "whether should wait until the desired replicas are ready",
+			},
+			&cli.BoolFlag{
+				Name:  "wait-healthy",
+				Usage: "like --wait, but also waits until the NGINX of every ready replica answers its health check",
+			},
+			&cli.DurationFlag{
+				Name:  "wait-timeout",
+				Usage: "time limit to wait for the replicas to become ready (requires --wait or --wait-healthy)",
+				Value: 5 * time.Minute,
+			},
+			&cli.BoolFlag{
+				Name:  "watch-events",
+				Usage: "shows the warning events of the instance while waiting, e.g. pods failing to be scheduled (requires --wait or --wait-healthy)",
 			},
 		},
 		Before: setupClient,
@@ -42,6 +112,41 @@ func NewCmdScale() *cli.Command {
 }
 
 func runScale(c *cli.Context) error {
+	isAutoscaleBounds := c.IsSet("min") || c.IsSet("max") || c.IsSet("schedule")
+	if c.IsSet("replicas") == isAutoscaleBounds {
+		return fmt.Errorf("either --replicas or --min/--max/--schedule must be provided")
+	}
+
+	if c.Bool("relative") && !c.IsSet("replicas") {
+		return fmt.Errorf("--relative can only be used along with --replicas")
+	}
+
+	if c.Bool("watch-events") && !c.Bool("wait") && !c.Bool("wait-healthy") {
+		return fmt.Errorf("--watch-events can only be used along with --wait or --wait-healthy")
+	}
+
+	schedules, err := parseScaleSchedules(c.StringSlice("schedule"))
+	if err != nil {
+		return err
+	}
+
+	autoscale, err := getActiveAutoscale(c)
+	if err != nil {
+		return err
+	}
+
+	if isAutoscaleBounds {
+		return updateAutoscaleBounds(c, autoscale, schedules)
+	}
+
+	if autoscale != nil {
+		if !c.Bool("force") {
+			return fmt.Errorf("%s has autoscale enabled (min: %d, max: %d replicas) which would revert a manual scale, adjust its bounds with --min/--max or use --force to scale it anyway", formatInstanceName(c), autoscale.MinReplicas, autoscale.MaxReplicas)
+		}
+
+		fmt.Fprintf(c.App.ErrWriter, "WARNING: %s has autoscale enabled, the number of replicas will be reverted by it!\n", formatInstanceName(c))
+	}
+
 	client, err := getClient(c)
 	if err != nil {
 		return err
@@ -51,15 +156,585 @@ func runScale(c *cli.Context) error {
 		Instance: c.String("instance"),
 		Replicas: int32(c.Int("replicas")),
 	}
+
+	var previous int32
+	if c.Bool("relative") {
+		previous, scale.Replicas, err = relativeReplicas(c, client, scale.Replicas)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = client.Scale(c.Context, scale)
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintf(c.App.Writer, "%s scaled to %d replica(s)\n", formatInstanceName(c), scale.Replicas)
+	if c.Bool("relative") {
+		fmt.Fprintf(c.App.Writer, "%s scaled from %d to %d replica(s)\n", formatInstanceName(c), previous, scale.Replicas)
+	} else {
+		fmt.Fprintf(c.App.Writer, "%s scaled to %d replica(s)\n", formatInstanceName(c), scale.Replicas)
+	}
+
+	if !c.Bool("wait") && !c.Bool("wait-healthy") {
+		return nil
+	}
+
+	return waitForReadyReplicas(c.Context, client, c.App.Writer, waitReadyReplicasArgs{
+		Instance:    scale.Instance,
+		Replicas:    scale.Replicas,
+		Timeout:     c.Duration("wait-timeout"),
+		Healthy:     c.Bool("wait-healthy"),
+		WatchEvents: c.Bool("watch-events"),
+	})
+}
+
+// relativeReplicas returns the current number of replicas of the instance
+// along with the one resulting from adding delta to it, which must be
+// within zero and --max-replicas.
+func relativeReplicas(c *cli.Context, client rpaasclient.Client, delta int32) (int32, int32, error) {
+	info, err := client.Info(c.Context, rpaasclient.InfoArgs{Instance: c.String("instance")})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if info.Replicas == nil {
+		return 0, 0, fmt.Errorf("could not get the current number of replicas of %s", formatInstanceName(c))
+	}
+
+	current := *info.Replicas
+	replicas := current + delta
+	if replicas < 0 {
+		return 0, 0, fmt.Errorf("cannot scale %s by %+d replica(s) as it has only %d replica(s)", formatInstanceName(c), delta, current)
+	}
+
+	if limit := int32(c.Int("max-replicas")); replicas > limit {
+		return 0, 0, fmt.Errorf("cannot scale %s by %+d replica(s) to %d replica(s) as it exceeds the maximum of %d (see --max-replicas)", formatInstanceName(c), delta, replicas, limit)
+	}
+
+	return current, replicas, nil
+}
+
+// getActiveAutoscale returns the autoscale settings of the instance, or nil
+// when it has none.
+func getActiveAutoscale(c *cli.Context) (*autogenerated.Autoscale, error) {
+	client, err := NewAutogeneratedClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	autoscale, _, err := client.RpaasApi.GetAutoscale(c.Context, c.String("instance")).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("could not get autoscale from RPaaS API: %w", err)
+	}
+
+	if autoscale == nil || autoscale.MaxReplicas == 0 {
+		return nil, nil
+	}
+
+	return autoscale, nil
+}
+
+func updateAutoscaleBounds(c *cli.Context, autoscale *autogenerated.Autoscale, schedules []scaleSchedule) error {
+	if autoscale == nil {
+		return fmt.Errorf("%s has no autoscale configured, use \"autoscale update\" to set it up", formatInstanceName(c))
+	}
+
+	if c.IsSet("min") {
+		autoscale.MinReplicas = int32(c.Int("min"))
+	}
+
+	if c.IsSet("max") {
+		autoscale.MaxReplicas = int32(c.Int("max"))
+	}
+
+	if autoscale.MinReplicas > autoscale.MaxReplicas {
+		return fmt.Errorf("min replicas (%d) cannot be greater than max replicas (%d)", autoscale.MinReplicas, autoscale.MaxReplicas)
+	}
+
+	if c.IsSet("schedule") {
+		autoscale.Schedules = nil
+		for _, s := range schedules {
+			if s.replicas > autoscale.MaxReplicas {
+				return fmt.Errorf("--schedule %q asks for %d replica(s), more than the max replicas (%d)", s.spec, s.replicas, autoscale.MaxReplicas)
+			}
+
+			window := s.window()
+			fmt.Fprintf(c.App.Writer, "Schedule %q: start %q, end %q (min replicas: %d)\n", s.spec, window.Start, window.End, window.MinReplicas)
+			autoscale.Schedules = append(autoscale.Schedules, window)
+		}
+	}
+
+	client, err := NewAutogeneratedClient(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.RpaasApi.UpdateAutoscale(c.Context, c.String("instance")).Autoscale(*autoscale).Execute()
+	if err != nil {
+		return fmt.Errorf("could not update the autoscale on RPaaS API: %w", err)
+	}
+
+	if c.IsSet("min") || c.IsSet("max") {
+		fmt.Fprintf(c.App.Writer, "%s autoscale bounds set to %d-%d replica(s)\n", formatInstanceName(c), autoscale.MinReplicas, autoscale.MaxReplicas)
+	}
+
+	if c.IsSet("schedule") {
+		fmt.Fprintf(c.App.Writer, "%s autoscale schedule set to %d window(s)\n", formatInstanceName(c), len(autoscale.Schedules))
+	}
+
+	return nil
+}
+
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+// scheduleDays maps the days accepted on --schedule to the days of the week
+// they stand for, numbered as cron does (Sunday is 0).
+var scheduleDays = map[string][]int{
+	"daily":    {0, 1, 2, 3, 4, 5, 6},
+	"weekdays": {1, 2, 3, 4, 5},
+	"weekends": {0, 6},
+}
+
+var scheduleTimeRangeRegexp = regexp.MustCompile(`^([0-9]{2}):([0-9]{2})-([0-9]{2}):([0-9]{2})$`)
+
+// scaleSchedule is a scheduled window written in the --schedule form, e.g.
+// "weekdays 08:00-20:00 replicas=10". Windows ending before they start
+// (e.g. 22:00-06:00) end on the next day.
+type scaleSchedule struct {
+	spec     string
+	days     []int
+	start    int // minutes since midnight
+	end      int // minutes since midnight
+	replicas int32
+}
+
+func parseScaleSchedules(specs []string) ([]scaleSchedule, error) {
+	var schedules []scaleSchedule
+	for _, spec := range specs {
+		s, err := parseScaleSchedule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --schedule %q: %w", spec, err)
+		}
+
+		for _, other := range schedules {
+			if s.overlaps(other) {
+				return nil, fmt.Errorf("--schedule %q overlaps with %q", s.spec, other.spec)
+			}
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	return schedules, nil
+}
+
+func parseScaleSchedule(spec string) (scaleSchedule, error) {
+	s := scaleSchedule{spec: spec, start: -1}
+	for _, field := range strings.Fields(spec) {
+		switch {
+		case strings.Contains(field, "="):
+			name, value, _ := strings.Cut(field, "=")
+			if name != "replicas" {
+				return s, fmt.Errorf("unknown option %q, only replicas=N is supported", name)
+			}
+
+			if s.replicas != 0 {
+				return s, errors.New("replicas is set more than once")
+			}
+
+			n, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || n <= 0 {
+				return s, fmt.Errorf("replicas must be a number greater than zero, got %q", value)
+			}
+
+			s.replicas = int32(n)
+
+		case strings.Contains(field, ":"):
+			if s.start >= 0 {
+				return s, errors.New("the time range is set more than once")
+			}
+
+			start, end, err := parseScheduleTimeRange(field)
+			if err != nil {
+				return s, err
+			}
+
+			s.start, s.end = start, end
+
+		default:
+			days, found := scheduleDays[strings.ToLower(field)]
+			if !found {
+				return s, fmt.Errorf("unknown days %q (one of: daily, weekdays, weekends)", field)
+			}
+
+			if s.days != nil {
+				return s, errors.New("the days are set more than once")
+			}
+
+			s.days = days
+		}
+	}
+
+	if s.days == nil || s.start < 0 || s.replicas == 0 {
+		return s, errors.New(`it must have the days, the time range and the replicas, e.g. "weekdays 08:00-20:00 replicas=10"`)
+	}
+
+	return s, nil
+}
+
+func parseScheduleTimeRange(field string) (int, int, error) {
+	matches := scheduleTimeRangeRegexp.FindStringSubmatch(field)
+	if matches == nil {
+		return 0, 0, fmt.Errorf("time range %q must be like HH:MM-HH:MM", field)
+	}
+
+	var minutes [2]int
+	for i := range minutes {
+		hour, _ := strconv.Atoi(matches[2*i+1])
+		minute, _ := strconv.Atoi(matches[2*i+2])
+		if hour > 23 || minute > 59 {
+			return 0, 0, fmt.Errorf("time range %q has an invalid time of day", field)
+		}
+
+		minutes[i] = hour*60 + minute
+	}
+
+	if minutes[0] == minutes[1] {
+		return 0, 0, fmt.Errorf("time range %q starts and ends at the same time", field)
+	}
+
+	return minutes[0], minutes[1], nil
+}
+
+// window returns the scheduled window with the cron expressions matching s.
+func (s scaleSchedule) window() autogenerated.ScheduledWindow {
+	endDays := s.days
+	if s.end < s.start {
+		endDays = make([]int, len(s.days))
+		for i, d := range s.days {
+			endDays[i] = (d + 1) % 7
+		}
+	}
+
+	return autogenerated.ScheduledWindow{
+		MinReplicas: s.replicas,
+		Start:       fmt.Sprintf("%02d %02d * * %s", s.start%60, s.start/60, cronDaysOfWeek(s.days)),
+		End:         fmt.Sprintf("%02d %02d * * %s", s.end%60, s.end/60, cronDaysOfWeek(endDays)),
+	}
+}
+
+// overlaps tells whether s and other are active at the same time on any
+// moment of the week.
+func (s scaleSchedule) overlaps(other scaleSchedule) bool {
+	for _, a := range s.intervals() {
+		for _, b := range other.intervals() {
+			for _, shift := range []int{-minutesPerWeek, 0, minutesPerWeek} {
+				if a[0] < b[1]+shift && b[0]+shift < a[1] {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// intervals returns the minutes of the week s is active on, one interval
+// per day, which may go past the end of the week.
+func (s scaleSchedule) intervals() [][2]int {
+	length := s.end - s.start
+	if length < 0 {
+		length += minutesPerDay
+	}
+
+	var intervals [][2]int
+	for _, d := range s.days {
+		start := d*minutesPerDay + s.start
+		intervals = append(intervals, [2]int{start, start + length})
+	}
+
+	return intervals
+}
+
+// cronDaysOfWeek returns the day of week field of a cron expression matching
+// days, e.g. "1-5" or "0,6".
+func cronDaysOfWeek(days []int) string {
+	if len(days) == 7 {
+		return "*"
+	}
+
+	sorted := append([]int(nil), days...)
+	sort.Ints(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		}
+
+		i = j + 1
+	}
+
+	return strings.Join(parts, ",")
+}
+
+type waitReadyReplicasArgs struct {
+	Instance string
+	Replicas int32
+	Timeout  time.Duration
+	// Ignored holds pods which should not be accounted as ready replicas
+	// (e.g. pods being replaced in a rolling restart).
+	Ignored []string
+	// Healthy makes it also wait for the NGINX of every ready replica to
+	// answer its health check, since being ready does not mean serving.
+	Healthy bool
+	// WatchEvents shows the warning events of the instance seen while
+	// waiting, and the most recent ones on timeout.
+	WatchEvents bool
+}
+
+// maxTimeoutWarningEvents is the number of warning events added to the
+// error when waiting times out.
+const maxTimeoutWarningEvents = 3
+
+// waitForReadyReplicas polls the instance info until the number of ready pods
+// matches the desired number of replicas, or the timeout is reached.
+func waitForReadyReplicas(ctx context.Context, client rpaasclient.Client, w io.Writer, args waitReadyReplicasArgs) error {
+	ignored := make(map[string]bool)
+	for _, name := range args.Ignored {
+		ignored[name] = true
+	}
+
+	var ready int32
+	var unhealthy []string
+	lastReady, lastUnhealthy := int32(-1), ""
+	events := newWarningEventsWatcher(timeNow())
+	err := pollUntil(ctx, args.Timeout, func(ctx context.Context) (bool, error) {
+		info, err := client.Info(ctx, rpaasclient.InfoArgs{Instance: args.Instance})
+		if err != nil {
+			return false, err
+		}
+
+		if args.WatchEvents {
+			for _, e := range events.observe(info.Events) {
+				fmt.Fprintf(w, "Warning event: %s\n", formatWarningEvent(e))
+			}
+		}
+
+		var total int32
+		ready, total = countReplicas(info.Pods, ignored)
+		if ready != lastReady {
+			fmt.Fprintf(w, "Waiting for replicas: %d of %d ready\n", ready, args.Replicas)
+			lastReady = ready
+		}
+
+		unhealthy = nil
+		if ready != args.Replicas || total != args.Replicas {
+			return false, nil
+		}
+
+		if !args.Healthy {
+			return true, nil
+		}
+
+		unhealthy, err = unhealthyReplicas(ctx, client, args.Instance, info.Pods, ignored)
+		if err != nil {
+			return false, err
+		}
+
+		if s := strings.Join(unhealthy, ", "); len(unhealthy) > 0 && s != lastUnhealthy {
+			fmt.Fprintf(w, "Ready but not healthy: %s\n", s)
+			lastUnhealthy = s
+		}
+
+		return len(unhealthy) == 0, nil
+	})
+	if errors.Is(err, errPollTimeout) {
+		var recent string
+		if args.WatchEvents {
+			recent = events.recent(maxTimeoutWarningEvents)
+		}
+
+		if len(unhealthy) > 0 {
+			return fmt.Errorf("timed out waiting for replicas to be healthy: %d of %d ready but not healthy (%s)%s", len(unhealthy), args.Replicas, strings.Join(unhealthy, ", "), recent)
+		}
+
+		return fmt.Errorf("timed out waiting for replicas to be ready: %d of %d ready%s", ready, args.Replicas, recent)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if args.Healthy {
+		fmt.Fprintf(w, "All %d replica(s) are ready and healthy\n", args.Replicas)
+		return nil
+	}
+
+	fmt.Fprintf(w, "All %d replica(s) are ready\n", args.Replicas)
 	return nil
 }
 
+// warningEventsWatcher keeps the warning events of an instance which
+// happened since the wait started, so that each one is shown only once.
+type warningEventsWatcher struct {
+	since  time.Time
+	seen   map[string]bool
+	events []clientTypes.Event
+}
+
+func newWarningEventsWatcher(since time.Time) *warningEventsWatcher {
+	return &warningEventsWatcher{since: since, seen: make(map[string]bool)}
+}
+
+// observe returns the warning events not seen before, keeping the latest
+// state (e.g. count) of the ones already seen.
+func (w *warningEventsWatcher) observe(events []clientTypes.Event) []clientTypes.Event {
+	var fresh []clientTypes.Event
+	for _, e := range events {
+		if e.Type != "Warning" || e.Last.Before(w.since) {
+			continue
+		}
+
+		key := e.Reason + "\x00" + e.Message
+		if !w.seen[key] {
+			w.seen[key] = true
+			w.events = append(w.events, e)
+			fresh = append(fresh, e)
+			continue
+		}
+
+		for i := range w.events {
+			if w.events[i].Reason == e.Reason && w.events[i].Message == e.Message {
+				w.events[i] = e
+			}
+		}
+	}
+
+	return fresh
+}
+
+// recent returns the n latest warning events formatted to be appended to
+// an error, or an empty string if there's none.
+func (w *warningEventsWatcher) recent(n int) string {
+	if len(w.events) == 0 {
+		return ""
+	}
+
+	events := append([]clientTypes.Event(nil), w.events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Last.After(events[j].Last) })
+	if len(events) > n {
+		events = events[:n]
+	}
+
+	var parts []string
+	for _, e := range events {
+		parts = append(parts, formatWarningEvent(e))
+	}
+
+	return fmt.Sprintf("; recent warning events: %s", strings.Join(parts, "; "))
+}
+
+func formatWarningEvent(e clientTypes.Event) string {
+	if e.Count > 1 {
+		return fmt.Sprintf("%s: %s (x%d)", e.Reason, e.Message, e.Count)
+	}
+
+	return fmt.Sprintf("%s: %s", e.Reason, e.Message)
+}
+
+// unhealthyReplicas returns the ready pods whose NGINX does not answer the
+// health check, along with the reason when known.
+func unhealthyReplicas(ctx context.Context, client rpaasclient.Client, instance string, pods []clientTypes.Pod, ignored map[string]bool) ([]string, error) {
+	health, err := client.GetPodsHealth(ctx, rpaasclient.PodsHealthArgs{Instance: instance})
+	if err != nil {
+		return nil, err
+	}
+
+	byPod := make(map[string]clientTypes.PodHealth)
+	for _, h := range health {
+		byPod[h.Pod] = h
+	}
+
+	var unhealthy []string
+	for _, pod := range pods {
+		if ignored[pod.Name] || pod.Status == "Terminating" || !pod.Ready {
+			continue
+		}
+
+		h, found := byPod[pod.Name]
+		switch {
+		case !found:
+			unhealthy = append(unhealthy, fmt.Sprintf("%s (not checked yet)", pod.Name))
+		case !h.Healthy && h.Error != "":
+			unhealthy = append(unhealthy, fmt.Sprintf("%s (%s)", pod.Name, h.Error))
+		case !h.Healthy:
+			unhealthy = append(unhealthy, pod.Name)
+		}
+	}
+
+	return unhealthy, nil
+}
+
+var errPollTimeout = errors.New("timed out")
+
+// pollUntil calls check every waitPollInterval until it reports done. Errors
+// from check are returned right away, unless they're caused by the timeout
+// (if any) being reached, in which case errPollTimeout is returned.
+func pollUntil(ctx context.Context, timeout time.Duration, check func(ctx context.Context) (bool, error)) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := check(ctx)
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		if err == nil && done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errPollTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+func countReplicas(pods []clientTypes.Pod, ignored map[string]bool) (ready, total int32) {
+	for _, pod := range pods {
+		if ignored[pod.Name] || pod.Status == "Terminating" {
+			continue
+		}
+
+		total++
+		if pod.Ready {
+			ready++
+		}
+	}
+
+	return
+}
+
 func formatInstanceName(c *cli.Context) string {
 	var prefix string
 	if service := c.String("service"); service != "" {
@@ -6,20 +6,30 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func TestScale(t *testing.T) {
+	defer func(d time.Duration) { waitPollInterval = d }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
 	tests := []struct {
 		name          string
 		args          []string
+		autoscale     *autogenerated.Autoscale
 		expected      string
 		expectedError string
 		client        client.Client
@@ -48,14 +58,161 @@ func TestScale(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "scaling and waiting the replicas to be ready",
+			args:     []string{"./rpaasv2", "scale", "-i", "my-instance", "-q", "2", "--wait"},
+			expected: "my-instance scaled to 2 replica(s)\nWaiting for replicas: 1 of 2 ready\nWaiting for replicas: 2 of 2 ready\nAll 2 replica(s) are ready\n",
+			client: func() client.Client {
+				calls := 0
+				return &fake.FakeClient{
+					FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+						calls++
+						pods := []types.Pod{{Name: "pod-1", Ready: true}, {Name: "pod-2"}}
+						if calls > 1 {
+							pods[1].Ready = true
+						}
+						return &types.InstanceInfo{Pods: pods}, nil
+					},
+				}
+			}(),
+		},
+		{
+			name:     "scaling and waiting the replicas to be healthy",
+			args:     []string{"./rpaasv2", "scale", "-i", "my-instance", "-q", "2", "--wait-healthy"},
+			expected: "my-instance scaled to 2 replica(s)\nWaiting for replicas: 2 of 2 ready\nReady but not healthy: pod-2 (cannot check health - unexpected response from nginx server: 503)\nAll 2 replica(s) are ready and healthy\n",
+			client: func() client.Client {
+				calls := 0
+				return &fake.FakeClient{
+					FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+						return &types.InstanceInfo{Pods: []types.Pod{{Name: "pod-1", Ready: true}, {Name: "pod-2", Ready: true}}}, nil
+					},
+					FakeGetPodsHealth: func(args client.PodsHealthArgs) ([]types.PodHealth, error) {
+						require.Equal(t, "my-instance", args.Instance)
+						calls++
+						if calls == 1 {
+							return []types.PodHealth{{Pod: "pod-1", Healthy: true}, {Pod: "pod-2", Error: "cannot check health - unexpected response from nginx server: 503"}}, nil
+						}
+						return []types.PodHealth{{Pod: "pod-1", Healthy: true}, {Pod: "pod-2", Healthy: true}}, nil
+					},
+				}
+			}(),
+		},
+		{
+			name:          "when the replicas are ready but not healthy until the timeout",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "-q", "2", "--wait-healthy", "--wait-timeout", "20ms"},
+			expectedError: "timed out waiting for replicas to be healthy: 1 of 2 ready but not healthy (pod-2 (connection refused))",
+			client: &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{Pods: []types.Pod{{Name: "pod-1", Ready: true}, {Name: "pod-2", Ready: true}}}, nil
+				},
+				FakeGetPodsHealth: func(args client.PodsHealthArgs) ([]types.PodHealth, error) {
+					return []types.PodHealth{{Pod: "pod-1", Healthy: true}, {Pod: "pod-2", Error: "connection refused"}}, nil
+				},
+			},
+		},
+		{
+			name:          "when there are not enough ready replicas until the timeout",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "-q", "2", "--wait-healthy", "--wait-timeout", "20ms"},
+			expectedError: "timed out waiting for replicas to be ready: 1 of 2 ready",
+			client: &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{Pods: []types.Pod{{Name: "pod-1", Ready: true}, {Name: "pod-2"}}}, nil
+				},
+				FakeGetPodsHealth: func(args client.PodsHealthArgs) ([]types.PodHealth, error) {
+					t.Errorf("health should not be checked before all replicas are ready")
+					return nil, nil
+				},
+			},
+		},
+		{
+			name:     "scaling up relatively to the current replicas",
+			args:     []string{"./rpaasv2", "scale", "-s", "some-service", "-i", "my-instance", "-q", "+2", "--relative"},
+			expected: "some-service/my-instance scaled from 3 to 5 replica(s)\n",
+			client: &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+					require.Equal(t, "my-instance", args.Instance)
+					return &types.InstanceInfo{Replicas: autogenerated.PtrInt32(3)}, nil
+				},
+				FakeScale: func(args client.ScaleArgs) error {
+					assert.Equal(t, client.ScaleArgs{Instance: "my-instance", Replicas: 5}, args)
+					return nil
+				},
+			},
+		},
+		{
+			name:     "scaling down relatively to the current replicas",
+			args:     []string{"./rpaasv2", "scale", "-i", "my-instance", "-q", "-1", "--relative"},
+			expected: "my-instance scaled from 3 to 2 replica(s)\n",
+			client: &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{Replicas: autogenerated.PtrInt32(3)}, nil
+				},
+				FakeScale: func(args client.ScaleArgs) error {
+					assert.Equal(t, int32(2), args.Replicas)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when scaling relatively below zero replicas",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "-q", "-4", "--relative"},
+			expectedError: "cannot scale my-instance by -4 replica(s) as it has only 3 replica(s)",
+			client: &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{Replicas: autogenerated.PtrInt32(3)}, nil
+				},
+				FakeScale: func(args client.ScaleArgs) error {
+					t.Error("Scale should not be called")
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when scaling relatively above the maximum replicas",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "-q", "+3", "--relative", "--max-replicas", "5"},
+			expectedError: "cannot scale my-instance by +3 replica(s) to 6 replica(s) as it exceeds the maximum of 5 (see --max-replicas)",
+			client: &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{Replicas: autogenerated.PtrInt32(3)}, nil
+				},
+				FakeScale: func(args client.ScaleArgs) error {
+					t.Error("Scale should not be called")
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when the current replicas are unknown",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "-q", "+1", "--relative"},
+			expectedError: "could not get the current number of replicas of my-instance",
+			client: &fake.FakeClient{
+				FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+					return &types.InstanceInfo{}, nil
+				},
+			},
+		},
+		{
+			name:          "when relative is set without replicas",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "--max", "5", "--relative"},
+			expectedError: "--relative can only be used along with --replicas",
+			client:        &fake.FakeClient{},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "GET", r.Method)
+				assert.Equal(t, "/resources/my-instance/autoscale", r.URL.Path)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(tt.autoscale)
+			}))
+			defer server.Close()
+
 			stdout := &bytes.Buffer{}
 			stderr := &bytes.Buffer{}
 			app := NewApp(stdout, stderr, tt.client)
-			err := app.Run(tt.args)
+			err := app.Run(append([]string{tt.args[0], "--rpaas-url", server.URL}, tt.args[1:]...))
 			if tt.expectedError != "" {
 				assert.EqualError(t, err, tt.expectedError)
 				return
@@ -66,3 +223,235 @@ func TestScale(t *testing.T) {
 		})
 	}
 }
+
+func TestScaleWatchEvents(t *testing.T) {
+	defer func(d time.Duration) { waitPollInterval = d }(waitPollInterval)
+	waitPollInterval = time.Millisecond
+
+	now := time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, "null")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:          "without --wait",
+			args:          []string{"./rpaasv2", "--rpaas-url", server.URL, "scale", "-i", "my-instance", "-q", "3", "--watch-events"},
+			expectedError: "--watch-events can only be used along with --wait or --wait-healthy",
+		},
+		{
+			name:          "when the replicas are not ready until the timeout",
+			args:          []string{"./rpaasv2", "--rpaas-url", server.URL, "scale", "-i", "my-instance", "-q", "3", "--wait", "--watch-events", "--wait-timeout", "20ms"},
+			expected:      "my-instance scaled to 3 replica(s)\nWarning event: FailedScheduling: 0/3 nodes are available: 3 Insufficient cpu. (x4)\nWaiting for replicas: 1 of 3 ready\nWarning event: BackOff: Back-off pulling image \"tsuru/nginx:bad\"\n",
+			expectedError: "timed out waiting for replicas to be ready: 1 of 3 ready; recent warning events: BackOff: Back-off pulling image \"tsuru/nginx:bad\"; FailedScheduling: 0/3 nodes are available: 3 Insufficient cpu. (x4)",
+		},
+		{
+			name:          "when the replicas are not ready until the timeout without watching events",
+			args:          []string{"./rpaasv2", "--rpaas-url", server.URL, "scale", "-i", "my-instance", "-q", "3", "--wait", "--wait-timeout", "20ms"},
+			expected:      "my-instance scaled to 3 replica(s)\nWaiting for replicas: 1 of 3 ready\n",
+			expectedError: "timed out waiting for replicas to be ready: 1 of 3 ready",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			fakeClient := &fake.FakeClient{
+				FakeScale: func(args client.ScaleArgs) error { return nil },
+				FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+					calls++
+					events := []types.Event{
+						{Type: "Warning", Reason: "FailedScheduling", Message: "some old event", Last: now.Add(-time.Hour)},
+						{Type: "Normal", Reason: "Scheduled", Message: "Successfully assigned pod-1", Last: now},
+						{Type: "Warning", Reason: "FailedScheduling", Message: "0/3 nodes are available: 3 Insufficient cpu.", Last: now.Add(time.Second), Count: 4},
+					}
+					if calls > 1 {
+						events = append(events, types.Event{Type: "Warning", Reason: "BackOff", Message: `Back-off pulling image "tsuru/nginx:bad"`, Last: now.Add(time.Minute)})
+					}
+
+					return &types.InstanceInfo{Pods: []types.Pod{{Name: "pod-1", Ready: true}, {Name: "pod-2"}, {Name: "pod-3"}}, Events: events}, nil
+				},
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			err := NewApp(stdout, stderr, fakeClient).Run(tt.args)
+			assert.EqualError(t, err, tt.expectedError)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
+
+func TestScaleWithAutoscale(t *testing.T) {
+	tests := []struct {
+		name              string
+		args              []string
+		autoscale         *autogenerated.Autoscale
+		expected          string
+		expectedStderr    string
+		expectedError     string
+		expectedAutoscale *autogenerated.Autoscale
+		client            client.Client
+	}{
+		{
+			name:          "when neither replicas nor autoscale bounds are provided",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance"},
+			expectedError: "either --replicas or --min/--max/--schedule must be provided",
+		},
+		{
+			name:          "when replicas is combined with autoscale bounds",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "-q", "2", "--max", "5"},
+			expectedError: "either --replicas or --min/--max/--schedule must be provided",
+		},
+		{
+			name:          "when autoscale is enabled",
+			args:          []string{"./rpaasv2", "scale", "-s", "some-service", "-i", "my-instance", "-q", "2"},
+			autoscale:     &autogenerated.Autoscale{MinReplicas: 3, MaxReplicas: 10},
+			expectedError: "some-service/my-instance has autoscale enabled (min: 3, max: 10 replicas) which would revert a manual scale, adjust its bounds with --min/--max or use --force to scale it anyway",
+			client: &fake.FakeClient{
+				FakeScale: func(args client.ScaleArgs) error {
+					t.Error("Scale should not be called")
+					return nil
+				},
+			},
+		},
+		{
+			name:           "when autoscale is enabled and scaling is forced",
+			args:           []string{"./rpaasv2", "scale", "-s", "some-service", "-i", "my-instance", "-q", "2", "--force"},
+			autoscale:      &autogenerated.Autoscale{MinReplicas: 3, MaxReplicas: 10},
+			expected:       "some-service/my-instance scaled to 2 replica(s)\n",
+			expectedStderr: "WARNING: some-service/my-instance has autoscale enabled, the number of replicas will be reverted by it!\n",
+			client: &fake.FakeClient{
+				FakeScale: func(args client.ScaleArgs) error {
+					assert.Equal(t, client.ScaleArgs{Instance: "my-instance", Replicas: 2}, args)
+					return nil
+				},
+			},
+		},
+		{
+			name:          "when adjusting bounds of an instance without autoscale",
+			args:          []string{"./rpaasv2", "scale", "-s", "some-service", "-i", "my-instance", "--max", "5"},
+			expectedError: `some-service/my-instance has no autoscale configured, use "autoscale update" to set it up`,
+		},
+		{
+			name:          "when min replicas is greater than max replicas",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "--min", "20"},
+			autoscale:     &autogenerated.Autoscale{MinReplicas: 3, MaxReplicas: 10},
+			expectedError: "min replicas (20) cannot be greater than max replicas (10)",
+		},
+		{
+			name:              "adjusting the autoscale bounds",
+			args:              []string{"./rpaasv2", "scale", "-s", "some-service", "-i", "my-instance", "--min", "5", "--max", "20"},
+			autoscale:         &autogenerated.Autoscale{MinReplicas: 3, MaxReplicas: 10, Cpu: autogenerated.PtrInt32(70)},
+			expected:          "some-service/my-instance autoscale bounds set to 5-20 replica(s)\n",
+			expectedAutoscale: &autogenerated.Autoscale{MinReplicas: 5, MaxReplicas: 20, Cpu: autogenerated.PtrInt32(70)},
+		},
+		{
+			name:      "setting the autoscale schedule",
+			args:      []string{"./rpaasv2", "scale", "-s", "some-service", "-i", "my-instance", "--schedule", "weekdays 08:00-20:00 replicas=10", "--schedule", "replicas=4 Weekends 22:30-06:00"},
+			autoscale: &autogenerated.Autoscale{MinReplicas: 2, MaxReplicas: 10, Schedules: []autogenerated.ScheduledWindow{{MinReplicas: 1, Start: "00 00 * * *", End: "00 01 * * *"}}},
+			expected: `Schedule "weekdays 08:00-20:00 replicas=10": start "00 08 * * 1-5", end "00 20 * * 1-5" (min replicas: 10)
+Schedule "replicas=4 Weekends 22:30-06:00": start "30 22 * * 0,6", end "00 06 * * 0-1" (min replicas: 4)
+some-service/my-instance autoscale schedule set to 2 window(s)
+`,
+			expectedAutoscale: &autogenerated.Autoscale{
+				MinReplicas: 2,
+				MaxReplicas: 10,
+				Schedules: []autogenerated.ScheduledWindow{
+					{MinReplicas: 10, Start: "00 08 * * 1-5", End: "00 20 * * 1-5"},
+					{MinReplicas: 4, Start: "30 22 * * 0,6", End: "00 06 * * 0-1"},
+				},
+			},
+		},
+		{
+			name:      "setting the autoscale bounds and schedule together",
+			args:      []string{"./rpaasv2", "scale", "-i", "my-instance", "--max", "20", "--schedule", "daily 23:00-01:00 replicas=15"},
+			autoscale: &autogenerated.Autoscale{MinReplicas: 2, MaxReplicas: 10},
+			expected: `Schedule "daily 23:00-01:00 replicas=15": start "00 23 * * *", end "00 01 * * *" (min replicas: 15)
+my-instance autoscale bounds set to 2-20 replica(s)
+my-instance autoscale schedule set to 1 window(s)
+`,
+			expectedAutoscale: &autogenerated.Autoscale{
+				MinReplicas: 2,
+				MaxReplicas: 20,
+				Schedules:   []autogenerated.ScheduledWindow{{MinReplicas: 15, Start: "00 23 * * *", End: "00 01 * * *"}},
+			},
+		},
+		{
+			name:          "when the schedule asks for more replicas than the max",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "--schedule", "weekdays 08:00-20:00 replicas=12"},
+			autoscale:     &autogenerated.Autoscale{MinReplicas: 2, MaxReplicas: 10},
+			expectedError: `--schedule "weekdays 08:00-20:00 replicas=12" asks for 12 replica(s), more than the max replicas (10)`,
+		},
+		{
+			name:          "when the schedule windows overlap",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "--schedule", "weekends 20:00-02:00 replicas=5", "--schedule", "weekdays 01:00-08:00 replicas=3"},
+			expectedError: `--schedule "weekdays 01:00-08:00 replicas=3" overlaps with "weekends 20:00-02:00 replicas=5"`,
+		},
+		{
+			name:          "when the schedule has unknown days",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "--schedule", "mondays 08:00-20:00 replicas=10"},
+			expectedError: `invalid --schedule "mondays 08:00-20:00 replicas=10": unknown days "mondays" (one of: daily, weekdays, weekends)`,
+		},
+		{
+			name:          "when the schedule has an invalid time range",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "--schedule", "daily 8:00-24:00 replicas=10"},
+			expectedError: `invalid --schedule "daily 8:00-24:00 replicas=10": time range "8:00-24:00" must be like HH:MM-HH:MM`,
+		},
+		{
+			name:          "when the schedule starts and ends at the same time",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "--schedule", "daily 08:00-08:00 replicas=10"},
+			expectedError: `invalid --schedule "daily 08:00-08:00 replicas=10": time range "08:00-08:00" starts and ends at the same time`,
+		},
+		{
+			name:          "when the schedule misses the replicas",
+			args:          []string{"./rpaasv2", "scale", "-i", "my-instance", "--schedule", "daily 08:00-20:00"},
+			expectedError: `invalid --schedule "daily 08:00-20:00": it must have the days, the time range and the replicas, e.g. "weekdays 08:00-20:00 replicas=10"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *autogenerated.Autoscale
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/resources/my-instance/autoscale", r.URL.Path)
+				switch r.Method {
+				case "GET":
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(tt.autoscale)
+
+				case "PUT":
+					updated = &autogenerated.Autoscale{}
+					require.NoError(t, json.NewDecoder(r.Body).Decode(updated))
+					w.WriteHeader(http.StatusNoContent)
+
+				default:
+					t.Errorf("unexpected method: %s", r.Method)
+				}
+			}))
+			defer server.Close()
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client)
+			err := app.Run(append([]string{tt.args[0], "--rpaas-url", server.URL}, tt.args[1:]...))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+			assert.Equal(t, tt.expectedAutoscale, updated)
+		})
+	}
+}
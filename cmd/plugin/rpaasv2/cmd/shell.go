@@ -5,10 +5,8 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
 
-	"github.com/gorilla/websocket"
 	"github.com/urfave/cli/v2"
 	"k8s.io/kubectl/pkg/util/term"
 
@@ -19,7 +17,7 @@ func NewCmdShell() *cli.Command {
 	return &cli.Command{
 		Name:      "shell",
 		Usage:     "Opens a remote shell inside unit",
-		ArgsUsage: "[-p POD] [-c CONTAINER]",
+		ArgsUsage: "[-p POD | --pod-selector SELECTOR [--first]] [-c CONTAINER]",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "service",
@@ -37,11 +35,23 @@ func NewCmdShell() *cli.Command {
 				Aliases: []string{"p"},
 				Usage:   "pod name - if omitted, the first pod will be chosen",
 			},
+			&cli.StringFlag{
+				Name:  "pod-selector",
+				Usage: "label selector (e.g. key=value) matching the pod, instead of its name on --pod",
+			},
+			&cli.BoolFlag{
+				Name:  "first",
+				Usage: "picks the first pod (by name) when more than one pod matches --pod-selector",
+			},
 			&cli.StringFlag{
 				Name:    "container",
 				Aliases: []string{"c"},
 				Usage:   "container name - if omitted, the \"nginx\" container will be chosen",
 			},
+			&cli.PathFlag{
+				Name:  "record",
+				Usage: "writes a transcript of the session output (the typed input is left out) into this file in the asciicast v2 format, which can be replayed with asciinema",
+			},
 		},
 		Before: setupClient,
 		Action: runShell,
@@ -54,6 +64,11 @@ func runShell(c *cli.Context) error {
 		return err
 	}
 
+	pod, err := podFromFlags(c, client)
+	if err != nil {
+		return err
+	}
+
 	var width, height uint16
 	if ts := term.GetSize(os.Stdin.Fd()); ts != nil {
 		width, height = ts.Width, ts.Height
@@ -62,7 +77,7 @@ func runShell(c *cli.Context) error {
 	args := rpaasclient.ExecArgs{
 		Command:        []string{"bash"},
 		Instance:       c.String("instance"),
-		Pod:            c.String("pod"),
+		Pod:            pod,
 		Container:      c.String("container"),
 		Interactive:    true,
 		TTY:            true,
@@ -79,44 +94,6 @@ func runShell(c *cli.Context) error {
 		Out: c.App.Writer,
 		Raw: args.TTY,
 	}
-	return tty.Safe(func() error {
-		conn, err := client.Exec(c.Context, args)
-		if err != nil {
-			return err
-		}
-		defer conn.Close()
-
-		done := make(chan error, 1)
-		go func() {
-			defer close(done)
-			for {
-				mtype, message, nerr := conn.ReadMessage()
-				if nerr != nil {
-					closeErr, ok := nerr.(*websocket.CloseError)
-					if !ok {
-						done <- fmt.Errorf("ERROR: receveid an unexpected error while reading messages: %w", err)
-						return
-					}
 
-					switch closeErr.Code {
-					case websocket.CloseNormalClosure:
-					case websocket.CloseInternalServerErr:
-						done <- fmt.Errorf("ERROR: the command may not be executed as expected - reason: %s", closeErr.Text)
-					default:
-						done <- fmt.Errorf("ERROR: unexpected close error: %s", closeErr.Error())
-					}
-
-					return
-				}
-
-				switch mtype {
-				case websocket.TextMessage, websocket.BinaryMessage:
-					c.App.Writer.Write(message)
-				}
-			}
-		}()
-		err = <-done
-		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-		return err
-	})
+	return runRecordedExec(c, client, tty, args)
 }
@@ -17,6 +17,7 @@ import (
 
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func TestShell(t *testing.T) {
@@ -50,6 +51,23 @@ func TestShell(t *testing.T) {
 			expectedCalled: true,
 			expectedError:  "another error",
 		},
+		{
+			name: "with pod selector",
+			args: []string{"rpaasv2", "shell", "-s", "rpaasv2", "-i", "my-instance", "--pod-selector", "version=v2"},
+			client: &fake.FakeClient{
+				FakeListPods: func(args client.ListPodsArgs) ([]clientTypes.Pod, error) {
+					assert.Equal(t, client.ListPodsArgs{Instance: "my-instance", Selector: "version=v2"}, args)
+					return []clientTypes.Pod{{Name: "my-instance-abc"}}, nil
+				},
+				FakeExec: func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error) {
+					called = true
+					assert.Equal(t, "my-instance-abc", args.Pod)
+					return nil, fmt.Errorf("another error")
+				},
+			},
+			expectedCalled: true,
+			expectedError:  "another error",
+		},
 	}
 
 	for _, tt := range tests {
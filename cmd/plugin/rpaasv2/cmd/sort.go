@@ -0,0 +1,62 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// sortFlags returns the flags of list commands which can be ordered by any
+// of keys, see sortList.
+func sortFlags(keys ...string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "sort",
+			Usage: fmt.Sprintf("sorts the list by this key (one of: %s)", strings.Join(keys, ", ")),
+		},
+		&cli.BoolFlag{
+			Name:  "reverse",
+			Usage: "sorts the list in descending order (requires --sort)",
+		},
+	}
+}
+
+// sortList sorts items in place by the comparison function of the key named
+// on --sort, if any. The sort is stable, so items with equal keys keep the
+// order returned by the API, whether or not --reverse is set.
+func sortList[T any](c *cli.Context, items []T, keys map[string]func(a, b T) int) error {
+	key := c.String("sort")
+	if key == "" {
+		if c.Bool("reverse") {
+			return fmt.Errorf("--reverse can only be used along with --sort")
+		}
+
+		return nil
+	}
+
+	compare, found := keys[key]
+	if !found {
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return fmt.Errorf("unsupported sort key %q (one of: %s)", key, strings.Join(names, ", "))
+	}
+
+	if c.Bool("reverse") {
+		slices.SortStableFunc(items, func(a, b T) int { return compare(b, a) })
+		return nil
+	}
+
+	slices.SortStableFunc(items, compare)
+	return nil
+}
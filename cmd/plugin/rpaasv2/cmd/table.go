@@ -0,0 +1,109 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/urfave/cli/v2"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+func tableWidthFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:        "max-width",
+			Usage:       "wraps the table to fit into this number of columns",
+			DefaultText: "the terminal width",
+		},
+		&cli.BoolFlag{
+			Name:  "no-wrap",
+			Usage: "writes every field of the table in full, never wrapping them",
+		},
+	}
+}
+
+// tableWidthFromFlags returns the width tables should fit into, where zero
+// means no limit. Unless set on --max-width, it's the width of the terminal
+// the output is written to, if any.
+func tableWidthFromFlags(c *cli.Context, noWrap bool) (int, error) {
+	if c.IsSet("max-width") {
+		if noWrap {
+			return 0, fmt.Errorf("--max-width cannot be used along with --no-wrap")
+		}
+
+		if c.Int("max-width") <= 0 {
+			return 0, fmt.Errorf("--max-width must be greater than zero")
+		}
+
+		return c.Int("max-width"), nil
+	}
+
+	if noWrap {
+		return 0, nil
+	}
+
+	return terminalWidth(c.App.Writer), nil
+}
+
+func terminalWidth(w io.Writer) int {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0
+	}
+
+	if ts := term.GetSize(f.Fd()); ts != nil {
+		return int(ts.Width)
+	}
+
+	return 0
+}
+
+// wrapText wraps each line of text at spaces so that it fits into width,
+// indenting the continuation lines a bit further than the line they come
+// from. Words longer than width are kept whole rather than cut.
+func wrapText(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, wrapLine(line, width)...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func wrapLine(line string, width int) []string {
+	if utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+
+	words := strings.TrimLeft(line, " ")
+	indent := line[:len(line)-len(words)]
+
+	var lines []string
+	current := indent
+	for _, word := range strings.Fields(words) {
+		switch {
+		case strings.TrimSpace(current) == "":
+			current += word
+
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width:
+			lines = append(lines, current)
+			current = indent + "  " + word
+
+		default:
+			current += " " + word
+		}
+	}
+
+	return append(lines, current)
+}
@@ -0,0 +1,199 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+const clearScreen = "\033[H\033[2J"
+
+func NewCmdTop() *cli.Command {
+	return &cli.Command{
+		Name:  "top",
+		Usage: "Shows the CPU and memory usage of the instance pods",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "sort-by",
+				Usage: "the resource to sort the pods by, in descending order (one of: cpu, memory)",
+				Value: "cpu",
+			},
+			&cli.BoolFlag{
+				Name:    "watch",
+				Aliases: []string{"w"},
+				Usage:   "keeps refreshing the usage until interrupted",
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "time between refreshes while watching",
+				Value: 2 * time.Second,
+			},
+		},
+		Before: setupClient,
+		Action: runTop,
+	}
+}
+
+func runTop(c *cli.Context) error {
+	sortBy := c.String("sort-by")
+	if sortBy != "cpu" && sortBy != "memory" {
+		return fmt.Errorf("unknown --sort-by %q, it must be one of: cpu, memory", sortBy)
+	}
+
+	interval := c.Duration("interval")
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be a positive duration")
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	args := rpaasclient.PodsUsageArgs{Instance: c.String("instance")}
+
+	if !c.Bool("watch") {
+		usage, err := client.GetPodsUsage(c.Context, args)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(c.App.Writer, writePodsUsageOnTableFormat(usage, sortBy))
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		usage, err := client.GetPodsUsage(c.Context, args)
+		if err != nil {
+			if c.Context.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		fmt.Fprint(c.App.Writer, clearScreen)
+		fmt.Fprintf(c.App.Writer, "Every %s: %s\t%s\n\n", interval, formatInstanceName(c), timeNow().Format(time.RFC1123))
+		fmt.Fprint(c.App.Writer, writePodsUsageOnTableFormat(usage, sortBy))
+
+		select {
+		case <-c.Context.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// podUsage holds the parsed usage of a pod, where pods whose metrics aren't
+// available (or cannot be parsed) have a nil cpu and memory.
+type podUsage struct {
+	pod    string
+	cpu    *resource.Quantity
+	memory *resource.Quantity
+}
+
+func parsePodsUsage(usage []clientTypes.PodUsage) []podUsage {
+	var pods []podUsage
+	for _, u := range usage {
+		p := podUsage{pod: u.Pod}
+		if u.Metrics != nil {
+			cpu, cerr := resource.ParseQuantity(u.Metrics.CPU)
+			memory, merr := resource.ParseQuantity(u.Metrics.Memory)
+			if cerr == nil && merr == nil {
+				p.cpu, p.memory = &cpu, &memory
+			}
+		}
+
+		pods = append(pods, p)
+	}
+
+	return pods
+}
+
+// sortPodsUsage sorts the pods by the given resource in descending order,
+// leaving the pods without metrics at the bottom.
+func sortPodsUsage(pods []podUsage, sortBy string) {
+	sort.SliceStable(pods, func(i, j int) bool {
+		if pods[i].cpu == nil || pods[j].cpu == nil {
+			return pods[i].cpu != nil
+		}
+
+		if sortBy == "memory" {
+			return pods[i].memory.Cmp(*pods[j].memory) > 0
+		}
+
+		return pods[i].cpu.Cmp(*pods[j].cpu) > 0
+	})
+}
+
+func writePodsUsageOnTableFormat(usage []clientTypes.PodUsage, sortBy string) string {
+	pods := parsePodsUsage(usage)
+	sortPodsUsage(pods, sortBy)
+
+	var data [][]string
+	var totalCPU, totalMemory int64
+	var withMetrics int64
+	for _, p := range pods {
+		if p.cpu == nil {
+			data = append(data, []string{p.pod, "metrics unavailable", "metrics unavailable"})
+			continue
+		}
+
+		withMetrics++
+		totalCPU += p.cpu.MilliValue()
+		totalMemory += p.memory.Value()
+		data = append(data, []string{p.pod, formatCPUUsage(p.cpu.MilliValue()), formatMemoryUsage(p.memory.Value())})
+	}
+
+	if withMetrics > 0 {
+		data = append(data,
+			[]string{"Total", formatCPUUsage(totalCPU), formatMemoryUsage(totalMemory)},
+			[]string{"Average", formatCPUUsage(totalCPU / withMetrics), formatMemoryUsage(totalMemory / withMetrics)},
+		)
+	}
+
+	var buffer bytes.Buffer
+	table := tablewriter.NewWriter(&buffer)
+	table.SetHeader([]string{"Pod", "CPU", "Memory"})
+	table.SetAutoWrapText(true)
+	table.SetAutoFormatHeaders(false)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.AppendBulk(data)
+	table.Render()
+
+	return buffer.String()
+}
+
+func formatCPUUsage(millicores int64) string {
+	return fmt.Sprintf("%dm", millicores)
+}
+
+func formatMemoryUsage(b int64) string {
+	return fmt.Sprintf("%vMi", b/(1024*1024))
+}
@@ -0,0 +1,146 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestTop(t *testing.T) {
+	usage := []clientTypes.PodUsage{
+		{Pod: "my-instance-abc", Metrics: &clientTypes.PodMetrics{CPU: "150m", Memory: "256Mi"}},
+		{Pod: "my-instance-def"},
+		{Pod: "my-instance-ghi", Metrics: &clientTypes.PodMetrics{CPU: "1", Memory: "64Mi"}},
+		{Pod: "my-instance-jkl", Metrics: &clientTypes.PodMetrics{CPU: "350m", Memory: "128Mi"}},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		usage         []clientTypes.PodUsage
+		expected      string
+		expectedError string
+	}{
+		{
+			name:          "with unknown sort",
+			args:          []string{"./rpaasv2", "top", "-i", "my-instance", "--sort-by", "disk"},
+			expectedError: `unknown --sort-by "disk", it must be one of: cpu, memory`,
+		},
+		{
+			name:  "sorting by CPU by default",
+			args:  []string{"./rpaasv2", "top", "-i", "my-instance"},
+			usage: usage,
+			expected: `+-----------------+---------------------+---------------------+
+| Pod             | CPU                 | Memory              |
++-----------------+---------------------+---------------------+
+| my-instance-ghi | 1000m               | 64Mi                |
+| my-instance-jkl | 350m                | 128Mi               |
+| my-instance-abc | 150m                | 256Mi               |
+| my-instance-def | metrics unavailable | metrics unavailable |
+| Total           | 1500m               | 448Mi               |
+| Average         | 500m                | 149Mi               |
++-----------------+---------------------+---------------------+
+`,
+		},
+		{
+			name:  "sorting by memory",
+			args:  []string{"./rpaasv2", "top", "-i", "my-instance", "--sort-by", "memory"},
+			usage: usage,
+			expected: `+-----------------+---------------------+---------------------+
+| Pod             | CPU                 | Memory              |
++-----------------+---------------------+---------------------+
+| my-instance-abc | 150m                | 256Mi               |
+| my-instance-jkl | 350m                | 128Mi               |
+| my-instance-ghi | 1000m               | 64Mi                |
+| my-instance-def | metrics unavailable | metrics unavailable |
+| Total           | 1500m               | 448Mi               |
+| Average         | 500m                | 149Mi               |
++-----------------+---------------------+---------------------+
+`,
+		},
+		{
+			name:  "when metrics are not available at all",
+			args:  []string{"./rpaasv2", "top", "-i", "my-instance"},
+			usage: []clientTypes.PodUsage{{Pod: "my-instance-abc"}},
+			expected: `+-----------------+---------------------+---------------------+
+| Pod             | CPU                 | Memory              |
++-----------------+---------------------+---------------------+
+| my-instance-abc | metrics unavailable | metrics unavailable |
++-----------------+---------------------+---------------------+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fake.FakeClient{
+				FakeGetPodsUsage: func(args rpaasclient.PodsUsageArgs) ([]clientTypes.PodUsage, error) {
+					assert.Equal(t, "my-instance", args.Instance)
+					return tt.usage, nil
+				},
+			}
+
+			stdout := &bytes.Buffer{}
+			err := NewApp(stdout, &bytes.Buffer{}, client).Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+		})
+	}
+}
+
+func TestTopWatch(t *testing.T) {
+	fakeClock(t, time.Second)
+
+	var calls int
+	client := &fake.FakeClient{
+		FakeGetPodsUsage: func(args rpaasclient.PodsUsageArgs) ([]clientTypes.PodUsage, error) {
+			calls++
+			if calls > 2 {
+				return nil, fmt.Errorf("some error")
+			}
+
+			return []clientTypes.PodUsage{{Pod: "my-instance-abc", Metrics: &clientTypes.PodMetrics{CPU: fmt.Sprintf("%dm", calls*100), Memory: "64Mi"}}}, nil
+		},
+	}
+
+	stdout := &bytes.Buffer{}
+	err := NewApp(stdout, &bytes.Buffer{}, client).Run([]string{"./rpaasv2", "top", "-s", "rpaasv2", "-i", "my-instance", "--watch", "--interval", "1ms"})
+	assert.EqualError(t, err, "some error")
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, clearScreen+`Every 1ms: rpaasv2/my-instance	Mon, 01 May 2023 12:00:00 UTC
+
++-----------------+------+--------+
+| Pod             | CPU  | Memory |
++-----------------+------+--------+
+| my-instance-abc | 100m | 64Mi   |
+| Total           | 100m | 64Mi   |
+| Average         | 100m | 64Mi   |
++-----------------+------+--------+
+`+clearScreen+`Every 1ms: rpaasv2/my-instance	Mon, 01 May 2023 12:00:01 UTC
+
++-----------------+------+--------+
+| Pod             | CPU  | Memory |
++-----------------+------+--------+
+| my-instance-abc | 200m | 64Mi   |
+| Total           | 200m | 64Mi   |
+| Average         | 200m | 64Mi   |
++-----------------+------+--------+
+`, stdout.String())
+}
@@ -0,0 +1,299 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func NewCmdUpdate() *cli.Command {
+	return &cli.Command{
+		Name:  "update",
+		Usage: "Updates the settings of an instance",
+		Description: `
+Labels and annotations can be managed declaratively from a YAML (or JSON) file
+holding a map of keys to values. By default, the keys in the file are merged
+into the ones already set; with --prune, any other key is removed as well
+(except for those managed by the operator).
+
+# Make the annotations of the instance match the file exactly:
+rpaasv2 update -s my-service -i my-instance --annotations-from-file annotations.yaml --prune
+`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:     "instance",
+				Aliases:  []string{"tsuru-service-instance", "i"},
+				Usage:    "the reverse proxy instance name",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:  "add-flavor",
+				Usage: "flavor to be added to the instance (can be used multiple times)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "remove-flavor",
+				Usage: "flavor to be removed from the instance (can be used multiple times)",
+			},
+			&cli.PathFlag{
+				Name:  "labels-from-file",
+				Usage: "path to a YAML or JSON file with the labels (as a key/value map) to be applied",
+			},
+			&cli.PathFlag{
+				Name:  "annotations-from-file",
+				Usage: "path to a YAML or JSON file with the annotations (as a key/value map) to be applied",
+			},
+			&cli.BoolFlag{
+				Name:  "prune",
+				Usage: "removes the labels and annotations not present in their files (requires --labels-from-file or --annotations-from-file)",
+			},
+		},
+		Before: setupClient,
+		Action: runUpdate,
+	}
+}
+
+func runUpdate(c *cli.Context) error {
+	toAdd, toRemove := c.StringSlice("add-flavor"), c.StringSlice("remove-flavor")
+	labelsFile, annotationsFile := c.Path("labels-from-file"), c.Path("annotations-from-file")
+	if len(toAdd) == 0 && len(toRemove) == 0 && labelsFile == "" && annotationsFile == "" {
+		return fmt.Errorf("nothing to update: either --add-flavor, --remove-flavor, --labels-from-file or --annotations-from-file must be provided")
+	}
+
+	if c.Bool("prune") && labelsFile == "" && annotationsFile == "" {
+		return fmt.Errorf("--prune can only be used along with --labels-from-file or --annotations-from-file")
+	}
+
+	labels, err := readMetadataFile(labelsFile, "label")
+	if err != nil {
+		return err
+	}
+
+	annotations, err := readMetadataFile(annotationsFile, "annotation")
+	if err != nil {
+		return err
+	}
+
+	if err = validateMetadataFiles(c, labels, annotations); err != nil {
+		return err
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	if len(toAdd) > 0 || len(toRemove) > 0 {
+		if err = updateFlavors(c, client, toAdd, toRemove); err != nil {
+			return err
+		}
+	}
+
+	if labelsFile == "" && annotationsFile == "" {
+		return nil
+	}
+
+	return updateMetadataFromFiles(c, client, labels, annotations)
+}
+
+func updateFlavors(c *cli.Context, client rpaasclient.Client, toAdd, toRemove []string) error {
+	instance := c.String("instance")
+	info, err := client.Info(c.Context, rpaasclient.InfoArgs{Instance: instance})
+	if err != nil {
+		return err
+	}
+
+	var available []clientTypes.Flavor
+	if len(toAdd) > 0 {
+		available, err = client.ListFlavors(c.Context, rpaasclient.ListFlavorsArgs{Instance: instance})
+		if err != nil {
+			return err
+		}
+	}
+
+	flavors, err := applyFlavorsDelta(info.Flavors, available, toAdd, toRemove)
+	if err != nil {
+		return err
+	}
+
+	err = client.UpdateFlavors(c.Context, rpaasclient.UpdateFlavorsArgs{Instance: instance, Flavors: flavors})
+	if err != nil {
+		return err
+	}
+
+	current := "none"
+	if len(flavors) > 0 {
+		current = strings.Join(flavors, ", ")
+	}
+
+	fmt.Fprintf(c.App.Writer, "Flavors of %s successfully updated: %s\n", formatInstanceName(c), current)
+	return nil
+}
+
+func applyFlavorsDelta(current []string, available []clientTypes.Flavor, toAdd, toRemove []string) ([]string, error) {
+	for _, f := range toRemove {
+		if !slices.Contains(current, f) {
+			return nil, fmt.Errorf("flavor %q is not applied to the instance", f)
+		}
+	}
+
+	var flavors []string
+	for _, f := range current {
+		if !slices.Contains(toRemove, f) {
+			flavors = append(flavors, f)
+		}
+	}
+
+	for _, f := range toAdd {
+		if !isFlavorAvailable(available, f) {
+			return nil, fmt.Errorf("flavor %q not found", f)
+		}
+
+		if !slices.Contains(flavors, f) {
+			flavors = append(flavors, f)
+		}
+	}
+
+	return flavors, nil
+}
+
+func isFlavorAvailable(flavors []clientTypes.Flavor, name string) bool {
+	for _, f := range flavors {
+		if f.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readMetadataFile reads a key/value map from a YAML (or JSON) file. It
+// returns nil when no file is given, and an empty map when the file has no
+// entries.
+func readMetadataFile(filename, kind string) (map[string]string, error) {
+	if filename == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the %ss file: %w", kind, err)
+	}
+
+	items := map[string]string{}
+	if err = yaml.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("could not parse the %ss file %s: %w", kind, filename, err)
+	}
+
+	return items, nil
+}
+
+// validateMetadataFiles checks the labels and annotations read from their
+// files before changing anything on the instance.
+func validateMetadataFiles(c *cli.Context, labels, annotations map[string]string) error {
+	metadata := clientTypes.Metadata{
+		Labels:      metadataItemsFromMap(labels),
+		Annotations: metadataItemsFromMap(annotations),
+	}
+
+	if len(metadata.Labels) == 0 && len(metadata.Annotations) == 0 {
+		return nil
+	}
+
+	return rpaasclient.SetMetadataArgs{Instance: c.String("instance"), Metadata: metadata}.Validate()
+}
+
+// updateMetadataFromFiles sets the labels and annotations read from their
+// files (a nil map means the file wasn't given) and, when pruning, removes the
+// ones not present there.
+func updateMetadataFromFiles(c *cli.Context, client rpaasclient.Client, labels, annotations map[string]string) error {
+	instance := c.String("instance")
+
+	var toUnset clientTypes.Metadata
+	if c.Bool("prune") {
+		current, err := client.GetMetadata(c.Context, rpaasclient.GetMetadataArgs{Instance: instance})
+		if err != nil {
+			return err
+		}
+
+		if current != nil {
+			toUnset.Labels = metadataItemsToPrune(current.Labels, labels)
+			toUnset.Annotations = metadataItemsToPrune(current.Annotations, annotations)
+		}
+	}
+
+	toSet := clientTypes.Metadata{
+		Labels:      metadataItemsFromMap(labels),
+		Annotations: metadataItemsFromMap(annotations),
+	}
+
+	if len(toSet.Labels) > 0 || len(toSet.Annotations) > 0 {
+		if err := client.SetMetadata(c.Context, rpaasclient.SetMetadataArgs{Instance: instance, Metadata: toSet}); err != nil {
+			return err
+		}
+	}
+
+	if len(toUnset.Labels) > 0 || len(toUnset.Annotations) > 0 {
+		if err := client.UnsetMetadata(c.Context, rpaasclient.UnsetMetadataArgs{Instance: instance, Metadata: toUnset}); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(c.App.Writer, "Metadata of %s successfully updated: %d label(s) and %d annotation(s) set, %d label(s) and %d annotation(s) removed\n",
+		formatInstanceName(c), len(toSet.Labels), len(toSet.Annotations), len(toUnset.Labels), len(toUnset.Annotations))
+	return nil
+}
+
+func metadataItemsFromMap(items map[string]string) []clientTypes.MetadataItem {
+	var result []clientTypes.MetadataItem
+	for _, key := range sortedKeys(items) {
+		result = append(result, clientTypes.MetadataItem{Name: key, Value: items[key]})
+	}
+
+	return result
+}
+
+// metadataItemsToPrune returns the keys of current missing from desired,
+// keeping everything when desired is nil (i.e. its file wasn't given).
+func metadataItemsToPrune(current []clientTypes.MetadataItem, desired map[string]string) []clientTypes.MetadataItem {
+	if desired == nil {
+		return nil
+	}
+
+	var result []clientTypes.MetadataItem
+	for _, item := range current {
+		if _, found := desired[item.Name]; found || clientTypes.IsReservedMetadataKey(item.Name) {
+			continue
+		}
+
+		result = append(result, clientTypes.MetadataItem{Name: item.Name})
+	}
+
+	return result
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
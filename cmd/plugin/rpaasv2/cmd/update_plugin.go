@@ -0,0 +1,451 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/tsuru/rpaas-operator/version"
+)
+
+const defaultPluginManifestURL = "https://github.com/tsuru/rpaas-operator/releases/latest/download/manifest.json"
+
+var (
+	// pluginExecutable returns the path of the running plugin, which is
+	// replaced by "update-plugin".
+	pluginExecutable = os.Executable
+
+	// pluginPlatform is the key of the build of the running plugin on the
+	// release manifest.
+	pluginPlatform = runtime.GOOS + "/" + runtime.GOARCH
+
+	errReleaseFileNotFound = errors.New("release file not found")
+)
+
+func NewCmdUpdatePlugin() *cli.Command {
+	return &cli.Command{
+		Name:  "update-plugin",
+		Usage: "Updates the plugin to its latest release",
+		Description: `Looks the latest release up on the plugin manifest (the same one given to
+"tsuru plugin install"), downloads the build for this OS and architecture,
+checks it against the checksums of the release and replaces the running
+binary at once, so that it's never left half written.
+
+When the release is signed (i.e. it has a checksums.txt.sig file, as made by
+"cosign sign-blob"), the signature is checked against --public-key, which is
+then required unless --insecure-skip-signature is set.
+
+# Tell whether there's a newer release, without updating:
+rpaasv2 update-plugin --check-only
+
+# Update from a mirror of the releases, checking their signature:
+rpaasv2 update-plugin --manifest-url https://mirror.example.com/rpaasv2/manifest.json --public-key cosign.pub`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "manifest-url",
+				Usage:   "URL of the plugin manifest of the latest release",
+				EnvVars: []string{"RPAASV2_PLUGIN_MANIFEST_URL"},
+				Value:   defaultPluginManifestURL,
+			},
+			&cli.PathFlag{
+				Name:    "public-key",
+				Usage:   "path in the system to the PEM public key (ECDSA or Ed25519) which signs the releases, whose signature is then required",
+				EnvVars: []string{"RPAASV2_PLUGIN_PUBLIC_KEY"},
+			},
+			&cli.BoolFlag{
+				Name:  "insecure-skip-signature",
+				Usage: "installs signed releases without checking their signature (do not use it but for testing)",
+			},
+			&cli.BoolFlag{
+				Name:  "check-only",
+				Usage: "only tells whether there's a newer release",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "installs the latest release even when it's not newer than the running plugin (e.g. on development builds)",
+			},
+		},
+		Action: runUpdatePlugin,
+	}
+}
+
+// pluginManifest is the manifest of a release, as read by "tsuru plugin
+// install".
+type pluginManifest struct {
+	Metadata struct {
+		Name    string `json:"Name"`
+		Version string `json:"Version"`
+	} `json:"Metadata"`
+	URLPerPlatform map[string]string `json:"URLPerPlatform"`
+}
+
+func runUpdatePlugin(c *cli.Context) error {
+	if c.Bool("check-only") && c.Bool("force") {
+		return fmt.Errorf("--check-only cannot be used along with --force")
+	}
+
+	if c.IsSet("public-key") && c.Bool("insecure-skip-signature") {
+		return fmt.Errorf("--insecure-skip-signature cannot be used along with --public-key")
+	}
+
+	client := &http.Client{
+		Timeout:   c.Duration("timeout"),
+		Transport: &http.Transport{Proxy: proxyFromFlags(c)},
+	}
+
+	manifestURL := c.String("manifest-url")
+	data, err := downloadReleaseFile(c.Context, client, manifestURL)
+	if err != nil {
+		return fmt.Errorf("could not get the plugin manifest: %w", err)
+	}
+
+	var manifest pluginManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("could not parse the plugin manifest from %s: %w", manifestURL, err)
+	}
+
+	latest, err := parseBuildVersion(manifest.Metadata.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version %q on the plugin manifest", manifest.Metadata.Version)
+	}
+
+	current, err := parseBuildVersion(version.Version)
+	switch {
+	case err != nil && !c.Bool("force"):
+		if c.Bool("check-only") {
+			fmt.Fprintf(c.App.Writer, "rpaasv2 %s is the latest release (the running plugin has an unknown version %q)\n", latest, version.Version)
+			return nil
+		}
+
+		return fmt.Errorf("cannot compare the plugin version %q with the latest release (%s), use --force to install it anyway", version.Version, latest)
+
+	case err == nil && !latest.GreaterThan(current) && !c.Bool("force"):
+		fmt.Fprintf(c.App.Writer, "rpaasv2 is up to date (version %s)\n", current)
+		return nil
+
+	case c.Bool("check-only"):
+		fmt.Fprintf(c.App.Writer, "rpaasv2 %s is available (current version: %s)\n", latest, current)
+		return nil
+	}
+
+	archiveURL, found := manifest.URLPerPlatform[pluginPlatform]
+	if !found {
+		return fmt.Errorf("there's no build of rpaasv2 %s for %s", latest, pluginPlatform)
+	}
+
+	executable, err := pluginExecutablePath()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Downloading rpaasv2 %s from %s\n", latest, archiveURL)
+	archive, err := downloadReleaseFile(c.Context, client, archiveURL)
+	if err != nil {
+		return fmt.Errorf("could not download the plugin: %w", err)
+	}
+
+	// NOTE: the checksums come from the same release of the archive rather
+	// than the latest one, which may have changed in between.
+	checksumsURL := releaseFileURL(archiveURL, "checksums.txt")
+	checksums, err := downloadReleaseFile(c.Context, client, checksumsURL)
+	if err != nil {
+		return fmt.Errorf("could not get the checksums of the release: %w", err)
+	}
+
+	if err = verifyReleaseSignature(c, client, checksumsURL, checksums); err != nil {
+		return err
+	}
+
+	sum, err := verifyReleaseChecksum(checksums, path.Base(archiveURL), archive)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Checksum verified (sha256 %s)\n", sum)
+
+	binary, err := extractPluginBinary(path.Base(archiveURL), archive)
+	if err != nil {
+		return err
+	}
+
+	if err = replaceExecutable(executable, binary); err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return fmt.Errorf("could not replace %s: permission denied, run it again as a user allowed to write there or reinstall the plugin on your own plugins directory (~/.tsuru/plugins) with \"tsuru plugin install rpaasv2 %s\"", executable, manifestURL)
+		}
+
+		return fmt.Errorf("could not replace %s: %w", executable, err)
+	}
+
+	fmt.Fprintf(c.App.Writer, "rpaasv2 updated to %s at %s\n", latest, executable)
+	return nil
+}
+
+func pluginExecutablePath() (string, error) {
+	executable, err := pluginExecutable()
+	if err != nil {
+		return "", fmt.Errorf("could not find the plugin binary: %w", err)
+	}
+
+	// NOTE: the file is replaced rather than the link to it.
+	return filepath.EvalSymlinks(executable)
+}
+
+func downloadReleaseFile(ctx context.Context, client *http.Client, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", u, errReleaseFileNotFound)
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status code: %s", u, rsp.Status)
+	}
+
+	return io.ReadAll(rsp.Body)
+}
+
+// releaseFileURL returns the URL of another file of the release holding
+// the file on u.
+func releaseFileURL(u, name string) string {
+	return u[:strings.LastIndex(u, "/")+1] + name
+}
+
+// verifyReleaseChecksum checks the SHA-256 of the archive against the one
+// listed for it on the checksums file (as in "sha256sum" output).
+func verifyReleaseChecksum(checksums []byte, name string, archive []byte) (string, error) {
+	sum := sha256.Sum256(archive)
+	actual := hex.EncodeToString(sum[:])
+
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+
+		if !strings.EqualFold(fields[0], actual) {
+			return "", fmt.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", name, fields[0], actual)
+		}
+
+		return actual, nil
+	}
+
+	return "", fmt.Errorf("there's no checksum for %s on the release", name)
+}
+
+// verifyReleaseSignature checks the signature of the checksums, if any,
+// against --public-key, failing on signed releases without it unless
+// --insecure-skip-signature is set. Signatures are encoded in base64 and, on ECDSA keys,
+// made over the SHA-256 of the checksums file, as "cosign sign-blob" does.
+func verifyReleaseSignature(c *cli.Context, client *http.Client, checksumsURL string, checksums []byte) error {
+	sig, err := downloadReleaseFile(c.Context, client, checksumsURL+".sig")
+	if errors.Is(err, errReleaseFileNotFound) {
+		if c.IsSet("public-key") {
+			return fmt.Errorf("the release is not signed, so it cannot be checked against --public-key")
+		}
+
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not get the signature of the release: %w", err)
+	}
+
+	if !c.IsSet("public-key") {
+		if !c.Bool("insecure-skip-signature") {
+			return fmt.Errorf("the release is signed, but --public-key is not set to check its signature (use --insecure-skip-signature to install it unverified)")
+		}
+
+		fmt.Fprintln(c.App.ErrWriter, "WARNING: the signature of the release was not checked, since --insecure-skip-signature is set")
+		return nil
+	}
+
+	key, err := readReleasePublicKey(c.Path("public-key"))
+	if err != nil {
+		return err
+	}
+
+	sig, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("invalid signature of the release: %w", err)
+	}
+
+	digest := sha256.Sum256(checksums)
+
+	var valid bool
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(k, digest[:], sig)
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, checksums, sig)
+	}
+
+	if !valid {
+		return fmt.Errorf("the signature of the release does not match %s", c.Path("public-key"))
+	}
+
+	fmt.Fprintln(c.App.Writer, "Signature verified")
+	return nil
+}
+
+func readReleasePublicKey(name string) (any, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM public key found", name)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	switch key.(type) {
+	case *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%s: unsupported public key type %T (one of: ECDSA, Ed25519)", name, key)
+}
+
+// extractPluginBinary returns the plugin binary from the release archive,
+// either a .tar.gz or a .zip (on Windows).
+func extractPluginBinary(name string, archive []byte) ([]byte, error) {
+	isBinary := func(entry string) bool {
+		base := path.Base(entry)
+		return base == "rpaasv2" || base == "rpaasv2.exe"
+	}
+
+	if strings.HasSuffix(name, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", name, err)
+		}
+
+		for _, f := range zr.File {
+			if !isBinary(f.Name) || f.FileInfo().IsDir() {
+				continue
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+
+			return io.ReadAll(rc)
+		}
+
+		return nil, fmt.Errorf("there's no rpaasv2 binary on %s", name)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", name, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("there's no rpaasv2 binary on %s", name)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", name, err)
+		}
+
+		if hdr.Typeflag == tar.TypeReg && isBinary(hdr.Name) {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// replaceExecutable writes the binary to a temporary file next to the
+// executable and renames it over the executable, keeping its permissions.
+// Renaming within the same directory is atomic, so the plugin is never left
+// half written.
+func replaceExecutable(executable string, binary []byte) error {
+	info, err := os.Stat(executable)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(executable), ".rpaasv2-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(binary); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Chmod(info.Mode().Perm()); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		// NOTE: Windows refuses to overwrite a running binary, but allows
+		// renaming it.
+		old := executable + ".old"
+		os.Remove(old)
+		if err = os.Rename(executable, old); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(f.Name(), executable)
+}
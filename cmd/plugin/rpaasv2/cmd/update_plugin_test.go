@@ -0,0 +1,292 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/version"
+)
+
+func TestUpdatePlugin(t *testing.T) {
+	defer func(v string) { version.Version = v }(version.Version)
+	defer func(f func() (string, error), p string) { pluginExecutable, pluginPlatform = f, p }(pluginExecutable, pluginPlatform)
+	pluginPlatform = "linux/amd64"
+
+	const newBinary = "#!/bin/sh\necho rpaasv2 v0.41.0\n"
+	archive := newPluginArchive(t, "rpaasv2", newBinary)
+	sum := sha256.Sum256(archive)
+	checksums := fmt.Sprintf("0000000000000000000000000000000000000000000000000000000000000000  rpaasv2_0.41.0_Darwin_arm64.tar.gz\n%s  rpaasv2_0.41.0_Linux_x86_64.tar.gz\n", hex.EncodeToString(sum[:]))
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte(checksums))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	publicKey := writePublicKey(t, filepath.Join(dir, "cosign.pub"), &key.PublicKey)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherPublicKey := writePublicKey(t, filepath.Join(dir, "other.pub"), &otherKey.PublicKey)
+
+	tests := []struct {
+		name           string
+		version        string
+		args           []string
+		files          map[string]string
+		expected       string
+		expectedStderr string
+		expectedError  string
+		updated        bool
+	}{
+		{
+			name:     "checking only",
+			version:  "v0.40.0/1a2b3c4",
+			args:     []string{"--check-only"},
+			expected: "rpaasv2 0.41.0 is available (current version: 0.40.0)\n",
+		},
+		{
+			name:     "when it's up to date",
+			version:  "v0.41.0/1a2b3c4",
+			expected: "rpaasv2 is up to date (version 0.41.0)\n",
+		},
+		{
+			name:     "updating",
+			version:  "v0.40.0/1a2b3c4",
+			expected: "Downloading rpaasv2 0.41.0 from {{URL}}/download/v0.41.0/rpaasv2_0.41.0_Linux_x86_64.tar.gz\nChecksum verified (sha256 " + hex.EncodeToString(sum[:]) + ")\nrpaasv2 updated to 0.41.0 at {{EXECUTABLE}}\n",
+			updated:  true,
+		},
+		{
+			name:     "updating a signed release",
+			version:  "v0.40.0/1a2b3c4",
+			args:     []string{"--public-key", publicKey},
+			files:    map[string]string{"checksums.txt.sig": base64.StdEncoding.EncodeToString(sig)},
+			expected: "Downloading rpaasv2 0.41.0 from {{URL}}/download/v0.41.0/rpaasv2_0.41.0_Linux_x86_64.tar.gz\nSignature verified\nChecksum verified (sha256 " + hex.EncodeToString(sum[:]) + ")\nrpaasv2 updated to 0.41.0 at {{EXECUTABLE}}\n",
+			updated:  true,
+		},
+		{
+			name:          "updating a signed release without a public key",
+			version:       "v0.40.0/1a2b3c4",
+			files:         map[string]string{"checksums.txt.sig": base64.StdEncoding.EncodeToString(sig)},
+			expectedError: "the release is signed, but --public-key is not set to check its signature (use --insecure-skip-signature to install it unverified)",
+		},
+		{
+			name:           "updating a signed release skipping its signature",
+			version:        "v0.40.0/1a2b3c4",
+			args:           []string{"--insecure-skip-signature"},
+			files:          map[string]string{"checksums.txt.sig": base64.StdEncoding.EncodeToString(sig)},
+			expected:       "Downloading rpaasv2 0.41.0 from {{URL}}/download/v0.41.0/rpaasv2_0.41.0_Linux_x86_64.tar.gz\nChecksum verified (sha256 " + hex.EncodeToString(sum[:]) + ")\nrpaasv2 updated to 0.41.0 at {{EXECUTABLE}}\n",
+			expectedStderr: "WARNING: the signature of the release was not checked, since --insecure-skip-signature is set\n",
+			updated:        true,
+		},
+		{
+			name:          "along with --public-key and --insecure-skip-signature",
+			version:       "v0.40.0/1a2b3c4",
+			args:          []string{"--public-key", publicKey, "--insecure-skip-signature"},
+			expectedError: "--insecure-skip-signature cannot be used along with --public-key",
+		},
+		{
+			name:          "when the signature does not match the public key",
+			version:       "v0.40.0/1a2b3c4",
+			args:          []string{"--public-key", otherPublicKey},
+			files:         map[string]string{"checksums.txt.sig": base64.StdEncoding.EncodeToString(sig)},
+			expectedError: fmt.Sprintf("the signature of the release does not match %s", otherPublicKey),
+		},
+		{
+			name:          "when the release is not signed but a public key is set",
+			version:       "v0.40.0/1a2b3c4",
+			args:          []string{"--public-key", publicKey},
+			expectedError: "the release is not signed, so it cannot be checked against --public-key",
+		},
+		{
+			name:          "when the checksum does not match",
+			version:       "v0.40.0/1a2b3c4",
+			files:         map[string]string{"checksums.txt": "0000000000000000000000000000000000000000000000000000000000000000  rpaasv2_0.41.0_Linux_x86_64.tar.gz\n"},
+			expectedError: "checksum mismatch for rpaasv2_0.41.0_Linux_x86_64.tar.gz: expected sha256 0000000000000000000000000000000000000000000000000000000000000000, got " + hex.EncodeToString(sum[:]),
+		},
+		{
+			name:          "when there's no checksum for the archive",
+			version:       "v0.40.0/1a2b3c4",
+			files:         map[string]string{"checksums.txt": "0000000000000000000000000000000000000000000000000000000000000000  rpaasv2_0.41.0_Darwin_arm64.tar.gz\n"},
+			expectedError: "there's no checksum for rpaasv2_0.41.0_Linux_x86_64.tar.gz on the release",
+		},
+		{
+			name:          "with an unknown plugin version",
+			version:       "NA",
+			expectedError: `cannot compare the plugin version "NA" with the latest release (0.41.0), use --force to install it anyway`,
+		},
+		{
+			name:     "forcing the update of an unknown plugin version",
+			version:  "NA",
+			args:     []string{"--force"},
+			expected: "Downloading rpaasv2 0.41.0 from {{URL}}/download/v0.41.0/rpaasv2_0.41.0_Linux_x86_64.tar.gz\nChecksum verified (sha256 " + hex.EncodeToString(sum[:]) + ")\nrpaasv2 updated to 0.41.0 at {{EXECUTABLE}}\n",
+			updated:  true,
+		},
+		{
+			name:          "along with --check-only and --force",
+			version:       "v0.40.0/1a2b3c4",
+			args:          []string{"--check-only", "--force"},
+			expectedError: "--check-only cannot be used along with --force",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				files := map[string]string{
+					"/latest/manifest.json":                                fmt.Sprintf(`{"SchemaVersion": "1.0", "Metadata": {"Name": "rpaasv2", "Version": "0.41.0"}, "URLPerPlatform": {"linux/amd64": "%[1]s/download/v0.41.0/rpaasv2_0.41.0_Linux_x86_64.tar.gz", "darwin/arm64": "%[1]s/download/v0.41.0/rpaasv2_0.41.0_Darwin_arm64.tar.gz"}}`, server.URL),
+					"/download/v0.41.0/rpaasv2_0.41.0_Linux_x86_64.tar.gz": string(archive),
+					"/download/v0.41.0/checksums.txt":                      checksums,
+				}
+				for name, content := range tt.files {
+					files["/download/v0.41.0/"+name] = content
+				}
+
+				content, found := files[r.URL.Path]
+				if !found {
+					http.NotFound(w, r)
+					return
+				}
+
+				fmt.Fprint(w, content)
+			}))
+			defer server.Close()
+
+			executable := filepath.Join(t.TempDir(), "rpaasv2")
+			require.NoError(t, os.WriteFile(executable, []byte("old binary"), 0755))
+			pluginExecutable = func() (string, error) { return executable, nil }
+			version.Version = tt.version
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			args := append([]string{"./rpaasv2", "update-plugin", "--manifest-url", server.URL + "/latest/manifest.json"}, tt.args...)
+			err := NewApp(stdout, stderr, nil).Run(args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, strings.NewReplacer("{{URL}}", server.URL, "{{EXECUTABLE}}", executable).Replace(tt.expected), stdout.String())
+			}
+
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+
+			data, err := os.ReadFile(executable)
+			require.NoError(t, err)
+
+			info, err := os.Stat(executable)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+			entries, err := os.ReadDir(filepath.Dir(executable))
+			require.NoError(t, err)
+			assert.Len(t, entries, 1, "temporary files should not be left behind")
+
+			if tt.updated {
+				assert.Equal(t, newBinary, string(data))
+			} else {
+				assert.Equal(t, "old binary", string(data))
+			}
+		})
+	}
+}
+
+func TestUpdatePluginWithoutBuildForPlatform(t *testing.T) {
+	defer func(v string) { version.Version = v }(version.Version)
+	defer func(p string) { pluginPlatform = p }(pluginPlatform)
+	pluginPlatform = "plan9/arm"
+	version.Version = "v0.40.0/1a2b3c4"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"Metadata": {"Name": "rpaasv2", "Version": "0.41.0"}, "URLPerPlatform": {"linux/amd64": "https://example.com/rpaasv2_0.41.0_Linux_x86_64.tar.gz"}}`)
+	}))
+	defer server.Close()
+
+	err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "update-plugin", "--manifest-url", server.URL})
+	assert.EqualError(t, err, "there's no build of rpaasv2 0.41.0 for plan9/arm")
+}
+
+func TestUpdatePluginWithoutPermission(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write anywhere")
+	}
+
+	defer func(v string) { version.Version = v }(version.Version)
+	defer func(f func() (string, error), p string) { pluginExecutable, pluginPlatform = f, p }(pluginExecutable, pluginPlatform)
+	pluginPlatform = "linux/amd64"
+	version.Version = "v0.40.0/1a2b3c4"
+
+	archive := newPluginArchive(t, "rpaasv2", "new binary")
+	sum := sha256.Sum256(archive)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/manifest.json":
+			fmt.Fprintf(w, `{"Metadata": {"Name": "rpaasv2", "Version": "0.41.0"}, "URLPerPlatform": {"linux/amd64": "%s/v0.41.0/rpaasv2_0.41.0_Linux_x86_64.tar.gz"}}`, server.URL)
+		case "/v0.41.0/rpaasv2_0.41.0_Linux_x86_64.tar.gz":
+			w.Write(archive)
+		case "/v0.41.0/checksums.txt":
+			fmt.Fprintf(w, "%s  rpaasv2_0.41.0_Linux_x86_64.tar.gz\n", hex.EncodeToString(sum[:]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "rpaasv2")
+	require.NoError(t, os.WriteFile(executable, []byte("old binary"), 0755))
+	require.NoError(t, os.Chmod(dir, 0555))
+	defer os.Chmod(dir, 0755)
+	pluginExecutable = func() (string, error) { return executable, nil }
+
+	err := NewApp(&bytes.Buffer{}, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "update-plugin", "--manifest-url", server.URL + "/manifest.json"})
+	assert.EqualError(t, err, fmt.Sprintf(`could not replace %s: permission denied, run it again as a user allowed to write there or reinstall the plugin on your own plugins directory (~/.tsuru/plugins) with "tsuru plugin install rpaasv2 %s/manifest.json"`, executable, server.URL))
+}
+
+func newPluginArchive(t *testing.T, name, content string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, f := range []struct{ name, content string }{{"LICENSE", "BSD"}, {name, content}} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0755, Size: int64(len(f.content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func writePublicKey(t *testing.T, name string, key *ecdsa.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644))
+	return name
+}
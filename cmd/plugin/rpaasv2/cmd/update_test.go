@@ -0,0 +1,221 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestUpdate(t *testing.T) {
+	newClient := func(t *testing.T, expected []string) *fake.FakeClient {
+		return &fake.FakeClient{
+			FakeInfo: func(args client.InfoArgs) (*types.InstanceInfo, error) {
+				return &types.InstanceInfo{Flavors: []string{"mint", "orange"}}, nil
+			},
+			FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+				assert.Equal(t, client.ListFlavorsArgs{Instance: "my-instance"}, args)
+				return []types.Flavor{{Name: "mango"}, {Name: "mint"}, {Name: "orange"}}, nil
+			},
+			FakeUpdateFlavors: func(args client.UpdateFlavorsArgs) error {
+				assert.Equal(t, client.UpdateFlavorsArgs{Instance: "my-instance", Flavors: expected}, args)
+				return nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expected      string
+		expectedError string
+		client        func(t *testing.T) client.Client
+	}{
+		{
+			name:          "without any change",
+			args:          []string{"./rpaasv2", "update", "-i", "my-instance"},
+			expectedError: "nothing to update: either --add-flavor, --remove-flavor, --labels-from-file or --annotations-from-file must be provided",
+			client:        func(t *testing.T) client.Client { return &fake.FakeClient{} },
+		},
+		{
+			name:     "adding and removing flavors",
+			args:     []string{"./rpaasv2", "update", "-i", "my-instance", "--add-flavor", "mango", "--remove-flavor", "orange"},
+			expected: "Flavors of my-instance successfully updated: mint, mango\n",
+			client:   func(t *testing.T) client.Client { return newClient(t, []string{"mint", "mango"}) },
+		},
+		{
+			name:     "adding an already applied flavor",
+			args:     []string{"./rpaasv2", "update", "-s", "rpaasv2", "-i", "my-instance", "--add-flavor", "mint"},
+			expected: "Flavors of rpaasv2/my-instance successfully updated: mint, orange\n",
+			client:   func(t *testing.T) client.Client { return newClient(t, []string{"mint", "orange"}) },
+		},
+		{
+			name:     "removing every flavor",
+			args:     []string{"./rpaasv2", "update", "-i", "my-instance", "--remove-flavor", "mint", "--remove-flavor", "orange"},
+			expected: "Flavors of my-instance successfully updated: none\n",
+			client:   func(t *testing.T) client.Client { return newClient(t, nil) },
+		},
+		{
+			name:          "adding an unknown flavor",
+			args:          []string{"./rpaasv2", "update", "-i", "my-instance", "--add-flavor", "banana"},
+			expectedError: `flavor "banana" not found`,
+			client:        func(t *testing.T) client.Client { return newClient(t, nil) },
+		},
+		{
+			name:          "removing a flavor not applied",
+			args:          []string{"./rpaasv2", "update", "-i", "my-instance", "--remove-flavor", "mango"},
+			expectedError: `flavor "mango" is not applied to the instance`,
+			client:        func(t *testing.T) client.Client { return newClient(t, nil) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client(t))
+			err := app.Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
+
+func TestUpdateMetadataFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, []byte(content), 0644))
+		return filename
+	}
+
+	labels := writeFile("labels.json", `{"env": "prod", "team": "team-x"}`)
+	annotations := writeFile("annotations.yaml", "owner: team-x\ncost-center: \"1234\"\n")
+
+	current := &types.Metadata{
+		Labels: []types.MetadataItem{
+			{Name: "env", Value: "dev"},
+			{Name: "rpaas.extensions.tsuru.io/instance-name", Value: "my-instance"},
+		},
+		Annotations: []types.MetadataItem{
+			{Name: "owner", Value: "team-y"},
+			{Name: "slo", Value: "99.9"},
+			{Name: "rpaas_instance", Value: "my-instance"},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectedSet   *types.Metadata
+		expectedUnset *types.Metadata
+		expected      string
+		expectedError string
+	}{
+		{
+			name: "merging labels and annotations",
+			args: []string{"./rpaasv2", "update", "-i", "my-instance", "--labels-from-file", labels, "--annotations-from-file", annotations},
+			expectedSet: &types.Metadata{
+				Labels:      []types.MetadataItem{{Name: "env", Value: "prod"}, {Name: "team", Value: "team-x"}},
+				Annotations: []types.MetadataItem{{Name: "cost-center", Value: "1234"}, {Name: "owner", Value: "team-x"}},
+			},
+			expected: "Metadata of my-instance successfully updated: 2 label(s) and 2 annotation(s) set, 0 label(s) and 0 annotation(s) removed\n",
+		},
+		{
+			name: "pruning the annotations not in the file",
+			args: []string{"./rpaasv2", "update", "-i", "my-instance", "--annotations-from-file", annotations, "--prune"},
+			expectedSet: &types.Metadata{
+				Annotations: []types.MetadataItem{{Name: "cost-center", Value: "1234"}, {Name: "owner", Value: "team-x"}},
+			},
+			expectedUnset: &types.Metadata{
+				Annotations: []types.MetadataItem{{Name: "slo"}},
+			},
+			expected: "Metadata of my-instance successfully updated: 0 label(s) and 2 annotation(s) set, 0 label(s) and 1 annotation(s) removed\n",
+		},
+		{
+			name:          "pruning every label with an empty file",
+			args:          []string{"./rpaasv2", "update", "-i", "my-instance", "--labels-from-file", writeFile("empty.yaml", ""), "--prune"},
+			expectedUnset: &types.Metadata{Labels: []types.MetadataItem{{Name: "env"}}},
+			expected:      "Metadata of my-instance successfully updated: 0 label(s) and 0 annotation(s) set, 1 label(s) and 0 annotation(s) removed\n",
+		},
+		{
+			name:          "pruning without any file",
+			args:          []string{"./rpaasv2", "update", "-i", "my-instance", "--add-flavor", "mango", "--prune"},
+			expectedError: "--prune can only be used along with --labels-from-file or --annotations-from-file",
+		},
+		{
+			name:          "when the file does not exist",
+			args:          []string{"./rpaasv2", "update", "-i", "my-instance", "--labels-from-file", filepath.Join(dir, "not-found.yaml")},
+			expectedError: "could not read the labels file: open " + filepath.Join(dir, "not-found.yaml") + ": no such file or directory",
+		},
+		{
+			name:          "when the file is not a key/value map",
+			args:          []string{"./rpaasv2", "update", "-i", "my-instance", "--annotations-from-file", writeFile("list.yaml", "- owner\n")},
+			expectedError: "could not parse the annotations file " + filepath.Join(dir, "list.yaml") + ": error unmarshaling JSON: while decoding JSON: json: cannot unmarshal array into Go value of type map[string]string",
+		},
+		{
+			name:          "with an invalid key",
+			args:          []string{"./rpaasv2", "update", "-i", "my-instance", "--annotations-from-file", writeFile("invalid-key.yaml", "\"my owner\": team-x\n")},
+			expectedError: `rpaasv2: invalid annotation key "my owner": name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')`,
+		},
+		{
+			name:          "with an invalid label value",
+			args:          []string{"./rpaasv2", "update", "-i", "my-instance", "--labels-from-file", writeFile("invalid-value.yaml", "team: team x\n")},
+			expectedError: `rpaasv2: invalid value for label "team": a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+		},
+		{
+			name:          "with a key managed by the operator",
+			args:          []string{"./rpaasv2", "update", "-i", "my-instance", "--labels-from-file", writeFile("managed.yaml", "rpaas.extensions.tsuru.io/instance-name: other\n")},
+			expectedError: `rpaasv2: label "rpaas.extensions.tsuru.io/instance-name" is managed by the operator and cannot be changed`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var set, unset *types.Metadata
+			fakeClient := &fake.FakeClient{
+				FakeGetMetadata: func(args client.GetMetadataArgs) (*types.Metadata, error) {
+					assert.Equal(t, client.GetMetadataArgs{Instance: "my-instance"}, args)
+					return current, nil
+				},
+				FakeSetMetadata: func(args client.SetMetadataArgs) error {
+					assert.Equal(t, "my-instance", args.Instance)
+					set = &args.Metadata
+					return nil
+				},
+				FakeUnsetMetadata: func(args client.UnsetMetadataArgs) error {
+					assert.Equal(t, "my-instance", args.Instance)
+					unset = &args.Metadata
+					return nil
+				},
+			}
+
+			stdout := &bytes.Buffer{}
+			err := NewApp(stdout, &bytes.Buffer{}, fakeClient).Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Equal(t, tt.expectedSet, set)
+			assert.Equal(t, tt.expectedUnset, unset)
+		})
+	}
+}
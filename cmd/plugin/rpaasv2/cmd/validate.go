@@ -0,0 +1,223 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	cron "github.com/robfig/cron/v3"
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+
+	"github.com/tsuru/rpaas-operator/api/v1alpha1"
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+)
+
+var (
+	cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+	allowedBlockTypes = []string{
+		v1alpha1.BlockTypeRoot,
+		v1alpha1.BlockTypeHTTP,
+		v1alpha1.BlockTypeServer,
+		v1alpha1.BlockTypeLuaServer,
+		v1alpha1.BlockTypeLuaWorker,
+	}
+)
+
+func NewCmdValidate() *cli.Command {
+	return &cli.Command{
+		Name:  "validate",
+		Usage: "Checks an instance spec file for errors without applying it",
+		Description: `
+# Validate an instance definition (either a RpaasInstance manifest or only its spec):
+rpaasv2 validate -s my-service -i my-instance --from-file instance.yaml
+`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:    "instance",
+				Aliases: []string{"tsuru-service-instance", "i"},
+				Usage:   "the reverse proxy instance name (required when going through Tsuru)",
+			},
+			&cli.PathFlag{
+				Name:     "from-file",
+				Aliases:  []string{"f"},
+				Usage:    "path to the instance spec file (YAML or JSON)",
+				Required: true,
+			},
+		},
+		Before: setupClient,
+		Action: runValidate,
+	}
+}
+
+func runValidate(c *cli.Context) error {
+	filename := c.Path("from-file")
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	spec, err := parseInstanceSpec(data)
+	if err != nil {
+		return fmt.Errorf("could not parse %s: %w", filename, err)
+	}
+
+	problems := validateInstanceSpec(spec)
+
+	if len(spec.Flavors) > 0 {
+		flavorProblems, err := validateFlavors(c, spec.Flavors)
+		if err != nil {
+			return err
+		}
+
+		problems = append(problems, flavorProblems...)
+	}
+
+	if len(problems) == 0 {
+		fmt.Fprintf(c.App.Writer, "%s is valid.\n", filename)
+		return nil
+	}
+
+	fmt.Fprintf(c.App.Writer, "Problems found in %s:\n", filename)
+	for _, p := range problems {
+		fmt.Fprintf(c.App.Writer, "  - %s\n", p)
+	}
+
+	return fmt.Errorf("found %d problem(s) in %s", len(problems), filename)
+}
+
+// parseInstanceSpec accepts either a whole RpaasInstance manifest or only its
+// spec, rejecting unknown fields in both cases.
+func parseInstanceSpec(data []byte) (*v1alpha1.RpaasInstanceSpec, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	if _, isManifest := raw["spec"]; isManifest {
+		var instance v1alpha1.RpaasInstance
+		if err := yaml.UnmarshalStrict(data, &instance); err != nil {
+			return nil, err
+		}
+
+		return &instance.Spec, nil
+	}
+
+	var spec v1alpha1.RpaasInstanceSpec
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
+
+func validateInstanceSpec(spec *v1alpha1.RpaasInstanceSpec) []string {
+	var problems []string
+
+	if a := spec.Autoscale; a != nil {
+		if a.MaxReplicas <= 0 {
+			problems = append(problems, "autoscale: max replicas must be greater than zero")
+		}
+
+		if a.MinReplicas != nil && *a.MinReplicas > a.MaxReplicas {
+			problems = append(problems, fmt.Sprintf("autoscale: min replicas (%d) must not be greater than max replicas (%d)", *a.MinReplicas, a.MaxReplicas))
+		}
+
+		for i, s := range a.Schedules {
+			problems = append(problems, validateScheduledWindow(fmt.Sprintf("autoscale: schedule #%d", i+1), s, a.MaxReplicas)...)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, l := range spec.Locations {
+		if l.Path == "" {
+			problems = append(problems, fmt.Sprintf("routes: route #%d has no path", i+1))
+			continue
+		}
+
+		if seen[l.Path] {
+			problems = append(problems, fmt.Sprintf("routes: path %q is defined more than once", l.Path))
+		}
+
+		seen[l.Path] = true
+	}
+
+	var blocks []string
+	for bt := range spec.Blocks {
+		blocks = append(blocks, string(bt))
+	}
+
+	sort.Strings(blocks)
+	for _, name := range blocks {
+		if !slices.Contains(allowedBlockTypes, name) {
+			problems = append(problems, fmt.Sprintf("blocks: %q is not a valid block name (one of: %s)", name, strings.Join(allowedBlockTypes, ", ")))
+		}
+	}
+
+	return problems
+}
+
+func validateScheduledWindow(prefix string, s v1alpha1.ScheduledWindow, maxReplicas int32) []string {
+	var problems []string
+
+	if s.MinReplicas <= 0 || s.MinReplicas > maxReplicas {
+		problems = append(problems, fmt.Sprintf("%s: min replicas (%d) must be between 1 and max replicas (%d)", prefix, s.MinReplicas, maxReplicas))
+	}
+
+	for _, expr := range []struct{ name, value string }{{"start", s.Start}, {"end", s.End}} {
+		if _, err := cronParser.Parse(expr.value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid %s cron expression %q: %s", prefix, expr.name, expr.value, err))
+		}
+	}
+
+	if s.Start != "" && s.Start == s.End {
+		problems = append(problems, fmt.Sprintf("%s: start and end cannot have the same cron expression", prefix))
+	}
+
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: invalid timezone %q", prefix, s.Timezone))
+		}
+	}
+
+	return problems
+}
+
+func validateFlavors(c *cli.Context, flavors []string) ([]string, error) {
+	client, err := getClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := client.ListFlavors(c.Context, rpaasclient.ListFlavorsArgs{Instance: c.String("instance")})
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[string]bool)
+	for _, f := range available {
+		exists[f.Name] = true
+	}
+
+	var problems []string
+	for _, f := range flavors {
+		if !exists[f] {
+			problems = append(problems, fmt.Sprintf("flavors: %q does not exist", f))
+		}
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestValidate(t *testing.T) {
+	fakeClient := &fake.FakeClient{
+		FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+			assert.Equal(t, client.ListFlavorsArgs{Instance: "my-instance"}, args)
+			return []types.Flavor{{Name: "orange"}, {Name: "mango"}}, nil
+		},
+	}
+
+	tests := []struct {
+		name          string
+		spec          string
+		expected      string
+		expectedError string
+		client        client.Client
+	}{
+		{
+			name: "when the spec is valid",
+			spec: `
+flavors: [orange]
+blocks:
+  http:
+    value: "# some http config"
+locations:
+- path: /api
+  destination: app.example.com
+- path: /static
+  destination: static.example.com
+autoscale:
+  minReplicas: 1
+  maxReplicas: 10
+  schedules:
+  - minReplicas: 5
+    start: "00 08 * * 1-5"
+    end: "00 20 * * 1-5"
+    timezone: America/Sao_Paulo
+`,
+			expected: "{{file}} is valid.\n",
+			client:   fakeClient,
+		},
+		{
+			name: "when the spec is a whole RpaasInstance manifest",
+			spec: `
+apiVersion: extensions.tsuru.io/v1alpha1
+kind: RpaasInstance
+metadata:
+  name: my-instance
+spec:
+  planName: basic
+  flavors: [mango]
+`,
+			expected: "{{file}} is valid.\n",
+			client:   fakeClient,
+		},
+		{
+			name: "reporting every problem at once",
+			spec: `
+flavors: [orange, banana]
+blocks:
+  http:
+    value: "# some http config"
+  location:
+    value: "# wrong"
+locations:
+- path: /api
+- path: /api
+- destination: app.example.com
+autoscale:
+  minReplicas: 20
+  maxReplicas: 10
+  schedules:
+  - minReplicas: 5
+    start: "99 08 * * 1-5"
+    end: "00 20 * * 1-5"
+    timezone: Mars/Olympus_Mons
+`,
+			expected: `Problems found in {{file}}:
+  - autoscale: min replicas (20) must not be greater than max replicas (10)
+  - autoscale: schedule #1: invalid start cron expression "99 08 * * 1-5": end of range (99) above maximum (59): 99
+  - autoscale: schedule #1: invalid timezone "Mars/Olympus_Mons"
+  - routes: path "/api" is defined more than once
+  - routes: route #3 has no path
+  - blocks: "location" is not a valid block name (one of: root, http, server, lua-server, lua-worker)
+  - flavors: "banana" does not exist
+`,
+			expectedError: "found 7 problem(s) in {{file}}",
+			client:        fakeClient,
+		},
+		{
+			name:          "when the spec has unknown fields",
+			spec:          "flavours: [orange]\n",
+			expectedError: `could not parse {{file}}: error unmarshaling JSON: while decoding JSON: json: unknown field "flavours"`,
+			client:        fakeClient,
+		},
+		{
+			name:          "when ListFlavors returns an error",
+			spec:          "flavors: [orange]\n",
+			expectedError: "some error",
+			client: &fake.FakeClient{
+				FakeListFlavors: func(args client.ListFlavorsArgs) ([]types.Flavor, error) {
+					return nil, fmt.Errorf("some error")
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "instance.yaml")
+			require.NoError(t, os.WriteFile(file, []byte(tt.spec), 0644))
+
+			replaceFile := func(s string) string {
+				return strings.ReplaceAll(s, "{{file}}", file)
+			}
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := NewApp(stdout, stderr, tt.client)
+			err := app.Run([]string{"./rpaasv2", "validate", "-i", "my-instance", "--from-file", file})
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, replaceFile(tt.expectedError))
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, replaceFile(tt.expected), stdout.String())
+			assert.Empty(t, stderr.String())
+		})
+	}
+}
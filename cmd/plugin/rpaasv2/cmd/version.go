@@ -0,0 +1,210 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/urfave/cli/v2"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/version"
+)
+
+// compatibilityRule tells that the plugin versions matching Plugin are known
+// not to work with the API versions matching API (both semver constraints).
+type compatibilityRule struct {
+	Plugin string
+	API    string
+	Reason string
+}
+
+// incompatibleVersions is the compatibility matrix checked by "version
+// --check-compat", any pair of versions not listed here is compatible.
+//
+// NOTE: no released pair of versions is known to be incompatible so far.
+var incompatibleVersions []compatibilityRule
+
+func NewCmdVersion() *cli.Command {
+	return &cli.Command{
+		Name:  "version",
+		Usage: "Shows the version of the plugin and of the RPaaS API",
+		Description: `The API version is only fetched when the API is reachable, i.e. either
+--rpaas-url or --instance (when going through Tsuru) is set.
+
+# Fail unless the plugin is compatible with the API:
+rpaasv2 version -s my-service -i my-instance --check-compat`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:    "service",
+				Aliases: []string{"tsuru-service", "s"},
+				Usage:   "the Tsuru service name",
+			},
+			&cli.StringFlag{
+				Name:    "instance",
+				Aliases: []string{"tsuru-service-instance", "i"},
+				Usage:   "the reverse proxy instance name (required to reach the API through Tsuru)",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "the output format (one of: json)",
+			},
+			&cli.BoolFlag{
+				Name:  "check-compat",
+				Usage: "exits with an error if the plugin is known to be incompatible with the API",
+			},
+		}, outputFieldFlags()...),
+		Action: runVersion,
+	}
+}
+
+type versionInfo struct {
+	Plugin     string `json:"plugin"`
+	API        string `json:"api,omitempty"`
+	Compatible *bool  `json:"compatible,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+func runVersion(c *cli.Context) error {
+	output := c.String("output")
+	if output != "" && output != "json" {
+		return fmt.Errorf("unsupported output format %q (one of: json)", output)
+	}
+
+	if err := checkOutputField(c, output); err != nil {
+		return err
+	}
+
+	hasServer := c.String("rpaas-url") != "" || c.String("instance") != ""
+	if c.Bool("check-compat") && !hasServer {
+		return fmt.Errorf("--check-compat requires reaching the API, either --rpaas-url or --instance must be provided")
+	}
+
+	info := versionInfo{Plugin: version.Version}
+	if hasServer {
+		if err := setAPIVersion(c, &info); err != nil {
+			return err
+		}
+	}
+
+	if output == "json" {
+		if err := writeJSONOutput(c, info); err != nil {
+			return err
+		}
+	} else {
+		writeVersion(c, info, hasServer)
+	}
+
+	if !c.Bool("check-compat") || info.Compatible == nil {
+		if c.Bool("check-compat") {
+			fmt.Fprintf(c.App.ErrWriter, "WARNING: could not check the compatibility: %s\n", info.Reason)
+		}
+
+		return nil
+	}
+
+	if !*info.Compatible {
+		return fmt.Errorf("plugin version %s is not compatible with API version %s: %s", info.Plugin, info.API, info.Reason)
+	}
+
+	return nil
+}
+
+func setAPIVersion(c *cli.Context, info *versionInfo) error {
+	if err := setupClient(c); err != nil {
+		return err
+	}
+
+	client, err := getClient(c)
+	if err != nil {
+		return err
+	}
+
+	v, err := client.GetVersion(c.Context, rpaasclient.GetVersionArgs{Instance: c.String("instance")})
+	if rpaasclient.IsNotFoundError(err) {
+		// NOTE: servers released before the version endpoint was added.
+		info.Reason = "the API does not expose its version"
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if v != nil {
+		info.API = v.Version
+	}
+
+	compatible, reason := checkCompatibility(info.Plugin, info.API)
+	info.Compatible, info.Reason = compatible, reason
+	return nil
+}
+
+// checkCompatibility looks the versions up in the compatibility matrix,
+// returning a nil result when either one is unknown (e.g. development builds).
+func checkCompatibility(plugin, api string) (*bool, string) {
+	pluginVersion, err := parseBuildVersion(plugin)
+	if err != nil {
+		return nil, fmt.Sprintf("unknown plugin version %q", plugin)
+	}
+
+	apiVersion, err := parseBuildVersion(api)
+	if err != nil {
+		return nil, fmt.Sprintf("unknown API version %q", api)
+	}
+
+	compatible := true
+	for _, rule := range incompatibleVersions {
+		pluginConstraint, err := semver.NewConstraint(rule.Plugin)
+		if err != nil {
+			continue
+		}
+
+		apiConstraint, err := semver.NewConstraint(rule.API)
+		if err != nil {
+			continue
+		}
+
+		if pluginConstraint.Check(pluginVersion) && apiConstraint.Check(apiVersion) {
+			compatible = false
+			return &compatible, rule.Reason
+		}
+	}
+
+	return &compatible, ""
+}
+
+// parseBuildVersion parses versions set on build, e.g. "v0.40.0/1a2b3c4",
+// ignoring the commit.
+func parseBuildVersion(v string) (*semver.Version, error) {
+	v, _, _ = strings.Cut(v, "/")
+	return semver.NewVersion(v)
+}
+
+func writeVersion(c *cli.Context, info versionInfo, hasServer bool) {
+	fmt.Fprintf(c.App.Writer, "Plugin version: %s\n", info.Plugin)
+	if !hasServer {
+		return
+	}
+
+	if info.API == "" {
+		fmt.Fprintf(c.App.Writer, "API version: unknown (%s)\n", info.Reason)
+		return
+	}
+
+	fmt.Fprintf(c.App.Writer, "API version: %s\n", info.API)
+
+	switch {
+	case info.Compatible == nil:
+		fmt.Fprintf(c.App.Writer, "Compatible: unknown (%s)\n", info.Reason)
+	case *info.Compatible:
+		fmt.Fprintln(c.App.Writer, "Compatible: yes")
+	default:
+		fmt.Fprintf(c.App.Writer, "Compatible: no (%s)\n", info.Reason)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+	"github.com/tsuru/rpaas-operator/version"
+)
+
+func TestVersion(t *testing.T) {
+	defer func(v string) { version.Version = v }(version.Version)
+	version.Version = "v0.40.0/1a2b3c4"
+
+	apiVersion := func(v string) *fake.FakeClient {
+		return &fake.FakeClient{
+			FakeGetVersion: func(args rpaasclient.GetVersionArgs) (*types.Version, error) {
+				assert.Equal(t, rpaasclient.GetVersionArgs{Instance: "my-instance"}, args)
+				return &types.Version{Version: v}, nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		args           []string
+		client         rpaasclient.Client
+		expected       string
+		expectedStderr string
+		expectedError  string
+	}{
+		{
+			name:          "with unsupported output format",
+			args:          []string{"./rpaasv2", "version", "-o", "yaml"},
+			expectedError: `unsupported output format "yaml" (one of: json)`,
+		},
+		{
+			name:     "without a server",
+			args:     []string{"./rpaasv2", "version"},
+			expected: "Plugin version: v0.40.0/1a2b3c4\n",
+		},
+		{
+			name:          "checking the compatibility without a server",
+			args:          []string{"./rpaasv2", "version", "--check-compat"},
+			expectedError: "--check-compat requires reaching the API, either --rpaas-url or --instance must be provided",
+		},
+		{
+			name:   "with a compatible API",
+			args:   []string{"./rpaasv2", "version", "-i", "my-instance", "--check-compat"},
+			client: apiVersion("v0.38.1/5d6e7f8"),
+			expected: `Plugin version: v0.40.0/1a2b3c4
+API version: v0.38.1/5d6e7f8
+Compatible: yes
+`,
+		},
+		{
+			name:   "checking the compatibility with an API built without version",
+			args:   []string{"./rpaasv2", "version", "-i", "my-instance", "--check-compat", "-o", "json"},
+			client: apiVersion("NA"),
+			expected: `{
+	"plugin": "v0.40.0/1a2b3c4",
+	"api": "NA",
+	"reason": "unknown API version \"NA\""
+}
+`,
+			expectedStderr: "WARNING: could not check the compatibility: unknown API version \"NA\"\n",
+		},
+		{
+			name: "when the server does not expose its version",
+			args: []string{"./rpaasv2", "version", "-i", "my-instance", "--check-compat"},
+			client: &fake.FakeClient{
+				FakeGetVersion: func(args rpaasclient.GetVersionArgs) (*types.Version, error) {
+					return nil, &rpaasclient.ErrUnexpectedStatusCode{Status: http.StatusNotFound, Body: "Not Found"}
+				},
+			},
+			expected: `Plugin version: v0.40.0/1a2b3c4
+API version: unknown (the API does not expose its version)
+`,
+			expectedStderr: "WARNING: could not check the compatibility: the API does not expose its version\n",
+		},
+		{
+			name: "when the server fails",
+			args: []string{"./rpaasv2", "version", "-i", "my-instance"},
+			client: &fake.FakeClient{
+				FakeGetVersion: func(args rpaasclient.GetVersionArgs) (*types.Version, error) {
+					return nil, fmt.Errorf("some error")
+				},
+			},
+			expectedError: "some error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			err := NewApp(stdout, stderr, tt.client).Run(tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stdout.String())
+			assert.Equal(t, tt.expectedStderr, stderr.String())
+		})
+	}
+
+	t.Run("reaching the API directly", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/version", r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"version": "v0.40.1/9a8b7c6"}`)
+		}))
+		defer server.Close()
+
+		stdout := &bytes.Buffer{}
+		err := NewApp(stdout, &bytes.Buffer{}, nil).Run([]string{"./rpaasv2", "--rpaas-url", server.URL, "version", "-o", "json"})
+		require.NoError(t, err)
+		assert.Equal(t, `{
+	"plugin": "v0.40.0/1a2b3c4",
+	"api": "v0.40.1/9a8b7c6",
+	"compatible": true
+}
+`, stdout.String())
+	})
+}
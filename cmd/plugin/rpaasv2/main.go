@@ -5,6 +5,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -14,6 +15,12 @@ import (
 func main() {
 	if err := cmd.NewDefaultApp().Run(os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+
+		var exitErr *cmd.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Status)
+		}
+
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,131 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package theme holds the color palettes shared by the plugin commands, so
+// that their output can be tuned to the terminal background.
+package theme
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Color is a named set of terminal attributes.
+type Color struct {
+	Name       string
+	Attributes []color.Attribute
+}
+
+// New returns a painter for c. It's always enabled, so the output is colored
+// even when it's not a terminal (e.g. piped into "less -R").
+func (c Color) New() *color.Color {
+	painter := color.New(c.Attributes...)
+	painter.EnableColor()
+	return painter
+}
+
+// Theme is the palette used to colorize the output.
+type Theme struct {
+	Name string
+
+	// Pods are assigned to pods in turn, telling apart their log lines.
+	Pods []Color
+
+	// Diff colors are used on the file headers, the removed and added
+	// lines and the hunk headers of unified diffs, respectively.
+	DiffHeader  Color
+	DiffRemoved Color
+	DiffAdded   Color
+	DiffHunk    Color
+}
+
+// Enabled tells whether the output should be colorized at all.
+func (t Theme) Enabled() bool {
+	return t.Name != None.Name
+}
+
+// PodColor returns the color of the i-th pod.
+func (t Theme) PodColor(i int) Color {
+	if len(t.Pods) == 0 {
+		return Color{}
+	}
+
+	return t.Pods[i%len(t.Pods)]
+}
+
+var (
+	// Dark suits terminals with a dark background. It's the default one.
+	Dark = Theme{
+		Name: "dark",
+		Pods: []Color{
+			{"cyan", []color.Attribute{color.FgCyan}},
+			{"green", []color.Attribute{color.FgGreen}},
+			{"magenta", []color.Attribute{color.FgMagenta}},
+			{"yellow", []color.Attribute{color.FgYellow}},
+			{"blue", []color.Attribute{color.FgBlue}},
+			{"red", []color.Attribute{color.FgRed}},
+		},
+		DiffHeader:  Color{"bold", []color.Attribute{color.Bold}},
+		DiffRemoved: Color{"red", []color.Attribute{color.FgRed}},
+		DiffAdded:   Color{"green", []color.Attribute{color.FgGreen}},
+		DiffHunk:    Color{"cyan", []color.Attribute{color.FgCyan}},
+	}
+
+	// Light suits terminals with a light background, avoiding the yellow
+	// and cyan colors which are barely readable on them.
+	Light = Theme{
+		Name: "light",
+		Pods: []Color{
+			{"blue", []color.Attribute{color.FgBlue}},
+			{"magenta", []color.Attribute{color.FgMagenta}},
+			{"green", []color.Attribute{color.FgGreen}},
+			{"red", []color.Attribute{color.FgRed}},
+			{"bold blue", []color.Attribute{color.FgBlue, color.Bold}},
+			{"bold magenta", []color.Attribute{color.FgMagenta, color.Bold}},
+		},
+		DiffHeader:  Color{"bold", []color.Attribute{color.Bold}},
+		DiffRemoved: Color{"red", []color.Attribute{color.FgRed}},
+		DiffAdded:   Color{"green", []color.Attribute{color.FgGreen}},
+		DiffHunk:    Color{"blue", []color.Attribute{color.FgBlue}},
+	}
+
+	// None disables colors altogether.
+	None = Theme{Name: "none"}
+
+	themes = []Theme{Dark, Light, None}
+)
+
+// Names returns the names of the available themes.
+func Names() []string {
+	names := make([]string, 0, len(themes))
+	for _, t := range themes {
+		names = append(names, t.Name)
+	}
+
+	return names
+}
+
+// Get returns the theme named name. An empty name means the default theme,
+// which is None when the NO_COLOR environment variable is set (see
+// https://no-color.org) and Dark otherwise.
+func Get(name string) (Theme, error) {
+	if name == "" {
+		if os.Getenv("NO_COLOR") != "" {
+			return None, nil
+		}
+
+		return Dark, nil
+	}
+
+	for _, t := range themes {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+
+	return Theme{}, fmt.Errorf("unknown theme %q (one of: %s)", name, strings.Join(Names(), ", "))
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theme
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name          string
+		theme         string
+		noColor       string
+		expected      Theme
+		expectedError string
+	}{
+		{
+			name:     "defaults to dark",
+			expected: Dark,
+		},
+		{
+			name:     "defaults to none when NO_COLOR is set",
+			noColor:  "1",
+			expected: None,
+		},
+		{
+			name:     "explicit themes take precedence over NO_COLOR",
+			theme:    "light",
+			noColor:  "1",
+			expected: Light,
+		},
+		{
+			name:     "none",
+			theme:    "none",
+			expected: None,
+		},
+		{
+			name:          "unknown theme",
+			theme:         "solarized",
+			expectedError: `unknown theme "solarized" (one of: dark, light, none)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+
+			got, err := Get(tt.theme)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestTheme(t *testing.T) {
+	assert.True(t, Dark.Enabled())
+	assert.True(t, Light.Enabled())
+	assert.False(t, None.Enabled())
+
+	assert.Equal(t, "cyan", Dark.PodColor(0).Name)
+	assert.Equal(t, "cyan", Dark.PodColor(len(Dark.Pods)).Name)
+	assert.Equal(t, Color{}, None.PodColor(1))
+
+	for _, c := range append(Light.Pods, Light.DiffRemoved, Light.DiffAdded, Light.DiffHunk) {
+		assert.NotContains(t, []string{"yellow", "cyan"}, c.Name, "colors barely readable on light backgrounds")
+	}
+
+	assert.Equal(t, "\x1b[34mhunk\x1b[0m", Light.DiffHunk.New().Sprint("hunk"))
+}
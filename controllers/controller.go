@@ -19,6 +19,7 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/imdario/mergo"
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
@@ -579,6 +580,10 @@ func (r *RpaasInstanceReconciler) reconcileHPA(ctx context.Context, instance *v1
 		r.EventRecorder.Event(instance, corev1.EventTypeWarning, "RpaasInstanceAutoscaleFailed", "native HPA controller doesn't support scheduled windows")
 	}
 
+	if a := instance.Spec.Autoscale; a != nil && a.Mirror != nil {
+		r.EventRecorder.Event(instance, corev1.EventTypeWarning, "RpaasInstanceAutoscaleFailed", "native HPA controller doesn't support mirroring another instance")
+	}
+
 	desired := newHPA(instance, nginx)
 
 	var observed autoscalingv2.HorizontalPodAutoscaler
@@ -651,7 +656,7 @@ func (r *RpaasInstanceReconciler) cleanUpKEDAScaledObject(ctx context.Context, i
 
 func isKEDAHandlingHPA(instance *v1alpha1.RpaasInstance) bool {
 	return instance.Spec.Autoscale != nil &&
-		(instance.Spec.Autoscale.TargetRequestsPerSecond != nil || len(instance.Spec.Autoscale.Schedules) > 0) &&
+		(instance.Spec.Autoscale.TargetRequestsPerSecond != nil || len(instance.Spec.Autoscale.Schedules) > 0 || instance.Spec.Autoscale.Mirror != nil) &&
 		instance.Spec.Autoscale.KEDAOptions != nil &&
 		instance.Spec.Autoscale.KEDAOptions.Enabled
 }
@@ -703,7 +708,7 @@ func (r *RpaasInstanceReconciler) reconcileKEDA(ctx context.Context, instance *v
 func isAutoscaleValid(a *v1alpha1.RpaasInstanceAutoscaleSpec) bool {
 	return a != nil &&
 		(a.MinReplicas != nil && a.MaxReplicas > 0) &&
-		(a.TargetCPUUtilizationPercentage != nil || a.TargetMemoryUtilizationPercentage != nil || a.TargetRequestsPerSecond != nil || len(a.Schedules) > 0)
+		(a.TargetCPUUtilizationPercentage != nil || a.TargetMemoryUtilizationPercentage != nil || a.TargetMemoryAverageValue != nil || a.TargetRequestsPerSecond != nil || len(a.Schedules) > 0 || a.Mirror != nil)
 }
 
 func isAutoscaleEnabled(instance *v1alpha1.RpaasInstanceSpec) bool {
@@ -732,6 +737,16 @@ func newKEDAScaledObject(instance *v1alpha1.RpaasInstance, nginx *nginxv1alpha1.
 		})
 	}
 
+	if instance.Spec.Autoscale != nil && instance.Spec.Autoscale.TargetMemoryAverageValue != nil {
+		triggers = append(triggers, kedav1alpha1.ScaleTriggers{
+			Type:       "memory",
+			MetricType: autoscalingv2.AverageValueMetricType,
+			Metadata: map[string]string{
+				"value": instance.Spec.Autoscale.TargetMemoryAverageValue.String(),
+			},
+		})
+	}
+
 	if instance.Spec.Autoscale != nil && instance.Spec.Autoscale.TargetRequestsPerSecond != nil {
 		kopts := instance.Spec.Autoscale.KEDAOptions
 		if kopts == nil {
@@ -752,7 +767,7 @@ func newKEDAScaledObject(instance *v1alpha1.RpaasInstance, nginx *nginxv1alpha1.
 			Type: "prometheus",
 			Metadata: map[string]string{
 				"serverAddress": instance.Spec.Autoscale.KEDAOptions.PrometheusServerAddress,
-				"query":         query.String(),
+				"query":         aggregateRPSQuery(query.String(), instance.Spec.Autoscale),
 				"threshold":     strconv.Itoa(int(*instance.Spec.Autoscale.TargetRequestsPerSecond)),
 			},
 			AuthenticationRef: kopts.RPSAuthenticationRef,
@@ -778,6 +793,28 @@ func newKEDAScaledObject(instance *v1alpha1.RpaasInstance, nginx *nginxv1alpha1.
 		}
 	}
 
+	if instance.Spec.Autoscale != nil && instance.Spec.Autoscale.Mirror != nil {
+		kopts := instance.Spec.Autoscale.KEDAOptions
+		if kopts == nil {
+			return nil, errors.New("keda options not provided")
+		}
+
+		query, err := mirrorReplicasQuery(instance.Namespace, instance.Spec.Autoscale.Mirror)
+		if err != nil {
+			return nil, err
+		}
+
+		triggers = append(triggers, kedav1alpha1.ScaleTriggers{
+			Type: "prometheus",
+			Metadata: map[string]string{
+				"serverAddress": kopts.PrometheusServerAddress,
+				"query":         query,
+				"threshold":     "1",
+			},
+			AuthenticationRef: kopts.RPSAuthenticationRef,
+		})
+	}
+
 	deployName := instance.Name
 	if deployments := nginx.Status.Deployments; len(deployments) > 0 {
 		deployName = deployments[0].Name
@@ -834,6 +871,50 @@ func newKEDAScaledObject(instance *v1alpha1.RpaasInstance, nginx *nginxv1alpha1.
 	}, nil
 }
 
+// aggregateRPSQuery wraps the RPS query in a subquery aggregating its values
+// over the RPS window, so short spikes don't trigger a scale up by themselves.
+// The query is kept as is when no window is set.
+func aggregateRPSQuery(query string, autoscale *v1alpha1.RpaasInstanceAutoscaleSpec) string {
+	if autoscale.RPSWindow == nil || autoscale.RPSWindow.Duration <= 0 {
+		return query
+	}
+
+	window := fmt.Sprintf("%ds", int64(autoscale.RPSWindow.Duration/time.Second))
+	if autoscale.RPSWindow.Duration%time.Second != 0 {
+		window = fmt.Sprintf("%dms", autoscale.RPSWindow.Duration.Milliseconds())
+	}
+
+	switch autoscale.RPSAggregation {
+	case v1alpha1.RPSAggregationMax:
+		return fmt.Sprintf("max_over_time((%s)[%s:])", query, window)
+	case v1alpha1.RPSAggregationP95:
+		return fmt.Sprintf("quantile_over_time(0.95, (%s)[%s:])", query, window)
+	default:
+		return fmt.Sprintf("avg_over_time((%s)[%s:])", query, window)
+	}
+}
+
+// mirrorReplicasQuery returns the query for the replicas of the mirrored
+// instance (as reported by kube-state-metrics) multiplied by the ratio. Along
+// with a threshold of 1, it makes KEDA keep ceil(replicas * ratio) replicas.
+func mirrorReplicasQuery(namespace string, mirror *v1alpha1.AutoscaleMirror) (string, error) {
+	if mirror.Instance == "" {
+		return "", errors.New("mirrored instance not provided")
+	}
+
+	ratio := "1"
+	if mirror.Ratio != "" {
+		r, err := strconv.ParseFloat(mirror.Ratio, 64)
+		if err != nil || r <= 0 {
+			return "", fmt.Errorf("invalid mirror ratio %q: it must be a number greater than zero", mirror.Ratio)
+		}
+
+		ratio = strconv.FormatFloat(r, 'f', -1, 64)
+	}
+
+	return fmt.Sprintf(`sum(kube_deployment_status_replicas{namespace=%q, deployment=%q}) * %s`, namespace, mirror.Instance, ratio), nil
+}
+
 func (r *RpaasInstanceReconciler) reconcilePDB(ctx context.Context, instance *v1alpha1.RpaasInstance, nginx *nginxv1alpha1.Nginx) (hasChanged bool, err error) {
 	if nginx.Status.PodSelector == "" {
 		return false, nil
@@ -1334,6 +1415,19 @@ func newHPA(instance *v1alpha1.RpaasInstance, nginx *nginxv1alpha1.Nginx) *autos
 		})
 	}
 
+	if a := instance.Spec.Autoscale; a != nil && a.TargetMemoryAverageValue != nil {
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: corev1.ResourceMemory,
+				Target: autoscalingv2.MetricTarget{
+					Type:         autoscalingv2.AverageValueMetricType,
+					AverageValue: instance.Spec.Autoscale.TargetMemoryAverageValue,
+				},
+			},
+		})
+	}
+
 	minReplicas := instance.Spec.Replicas
 	if a := instance.Spec.Autoscale; a != nil && a.MinReplicas != nil {
 		minReplicas = a.MinReplicas
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	"github.com/stretchr/testify/assert"
@@ -1205,6 +1206,42 @@ func Test_reconcileHPA(t *testing.T) {
 			expectedChanged: true,
 		},
 
+		"(native HPA controller) with memory value": {
+			instance: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
+				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+					MinReplicas:              func(n int32) *int32 { return &n }(2),
+					MaxReplicas:              10,
+					TargetMemoryAverageValue: resource.NewQuantity(512*1024*1024, resource.BinarySI),
+				}
+				return ri
+			},
+			expectedHPA: func(hpa *autoscalingv2.HorizontalPodAutoscaler) *autoscalingv2.HorizontalPodAutoscaler {
+				hpa.Spec = autoscalingv2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "my-instance",
+					},
+					MinReplicas: func(n int32) *int32 { return &n }(2),
+					MaxReplicas: 10,
+					Metrics: []autoscalingv2.MetricSpec{
+						{
+							Type: autoscalingv2.ResourceMetricSourceType,
+							Resource: &autoscalingv2.ResourceMetricSource{
+								Name: "memory",
+								Target: autoscalingv2.MetricTarget{
+									Type:         autoscalingv2.AverageValueMetricType,
+									AverageValue: resource.NewQuantity(512*1024*1024, resource.BinarySI),
+								},
+							},
+						},
+					},
+				}
+				return hpa
+			},
+			expectedChanged: true,
+		},
+
 		"(native HPA controller) with scheduled windows": {
 			instance: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
 				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
@@ -1256,6 +1293,80 @@ func Test_reconcileHPA(t *testing.T) {
 			expectedChanged: true,
 		},
 
+		"(KEDA controller) with memory value": {
+			instance: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
+				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+					MinReplicas:              func(n int32) *int32 { return &n }(2),
+					MaxReplicas:              500,
+					TargetMemoryAverageValue: resource.NewQuantity(512*1024*1024, resource.BinarySI),
+					TargetRequestsPerSecond:  func(n int32) *int32 { return &n }(50),
+					KEDAOptions: &v1alpha1.AutoscaleKEDAOptions{
+						Enabled:                 true,
+						PrometheusServerAddress: "https://prometheus.example.com",
+						RPSQueryTemplate:        `sum(rate(nginx_vts_requests_total{instance="{{ .Name }}", namespace="{{ .Namespace }}"}[5m]))`,
+					},
+				}
+				return ri
+			},
+			expectedScaledObject: func(so *kedav1alpha1.ScaledObject) *kedav1alpha1.ScaledObject {
+				so.Spec.MinReplicaCount = func(n int32) *int32 { return &n }(2)
+				so.Spec.MaxReplicaCount = func(n int32) *int32 { return &n }(500)
+				so.Spec.Triggers = []kedav1alpha1.ScaleTriggers{
+					{
+						Type:       "memory",
+						MetricType: autoscalingv2.AverageValueMetricType,
+						Metadata: map[string]string{
+							"value": "512Mi",
+						},
+					},
+					{
+						Type: "prometheus",
+						Metadata: map[string]string{
+							"serverAddress": "https://prometheus.example.com",
+							"query":         `sum(rate(nginx_vts_requests_total{instance="my-instance", namespace="default"}[5m]))`,
+							"threshold":     "50",
+						},
+					},
+				}
+				return so
+			},
+			expectedChanged: true,
+		},
+
+		"(KEDA controller) with RPS window and aggregation": {
+			instance: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
+				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+					MinReplicas:             func(n int32) *int32 { return &n }(2),
+					MaxReplicas:             500,
+					TargetRequestsPerSecond: func(n int32) *int32 { return &n }(50),
+					RPSWindow:               &metav1.Duration{Duration: 2 * time.Minute},
+					RPSAggregation:          v1alpha1.RPSAggregationMax,
+					KEDAOptions: &v1alpha1.AutoscaleKEDAOptions{
+						Enabled:                 true,
+						PrometheusServerAddress: "https://prometheus.example.com",
+						RPSQueryTemplate:        `sum(rate(nginx_vts_requests_total{instance="{{ .Name }}", namespace="{{ .Namespace }}"}[5m]))`,
+					},
+				}
+				return ri
+			},
+			expectedScaledObject: func(so *kedav1alpha1.ScaledObject) *kedav1alpha1.ScaledObject {
+				so.Spec.MinReplicaCount = func(n int32) *int32 { return &n }(2)
+				so.Spec.MaxReplicaCount = func(n int32) *int32 { return &n }(500)
+				so.Spec.Triggers = []kedav1alpha1.ScaleTriggers{
+					{
+						Type: "prometheus",
+						Metadata: map[string]string{
+							"serverAddress": "https://prometheus.example.com",
+							"query":         `max_over_time((sum(rate(nginx_vts_requests_total{instance="my-instance", namespace="default"}[5m])))[120s:])`,
+							"threshold":     "50",
+						},
+					},
+				}
+				return so
+			},
+			expectedChanged: true,
+		},
+
 		"(KEDA controller) updating autoscaling params": {
 			resources: []runtime.Object{
 				func(so *kedav1alpha1.ScaledObject) runtime.Object {
@@ -1536,6 +1647,40 @@ func Test_reconcileHPA(t *testing.T) {
 				return so
 			},
 		},
+
+		"(KEDA controller) mirroring another instance": {
+			instance: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
+				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+					MinReplicas: func(n int32) *int32 { return &n }(1),
+					MaxReplicas: 20,
+					Mirror: &v1alpha1.AutoscaleMirror{
+						Instance: "another-instance",
+						Ratio:    "0.50",
+					},
+					KEDAOptions: &v1alpha1.AutoscaleKEDAOptions{
+						Enabled:                 true,
+						PrometheusServerAddress: "https://prometheus.example.com",
+					},
+				}
+				return ri
+			},
+			expectedChanged: true,
+			expectedScaledObject: func(so *kedav1alpha1.ScaledObject) *kedav1alpha1.ScaledObject {
+				so.Spec.MinReplicaCount = func(n int32) *int32 { return &n }(1)
+				so.Spec.MaxReplicaCount = func(n int32) *int32 { return &n }(20)
+				so.Spec.Triggers = []kedav1alpha1.ScaleTriggers{
+					{
+						Type: "prometheus",
+						Metadata: map[string]string{
+							"serverAddress": "https://prometheus.example.com",
+							"query":         `sum(kube_deployment_status_replicas{namespace="default", deployment="another-instance"}) * 0.5`,
+							"threshold":     "1",
+						},
+					},
+				}
+				return so
+			},
+		},
 	}
 
 	for name, tt := range tests {
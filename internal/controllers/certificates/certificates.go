@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"slices"
 
 	"github.com/cert-manager/cert-manager/pkg/util/pki"
 	nginxv1alpha1 "github.com/tsuru/nginx-operator/api/v1alpha1"
@@ -136,6 +137,8 @@ func DeleteCertificate(ctx context.Context, c client.Client, instance *v1alpha1.
 		instance.Spec.TLS = append(instance.Spec.TLS[:index], instance.Spec.TLS[index+1:]...) // removes the i-th element
 	}
 
+	instance.Spec.OCSPStapling = slices.DeleteFunc(instance.Spec.OCSPStapling, func(name string) bool { return name == s.Name })
+
 	delete(instance.Spec.PodTemplate.Annotations, certificateHashAnnotationKey(certificateName))
 	delete(instance.Spec.PodTemplate.Annotations, keyHashAnnotationKey(certificateName))
 
@@ -146,6 +149,43 @@ func DeleteCertificate(ctx context.Context, c client.Client, instance *v1alpha1.
 	return c.Delete(ctx, s)
 }
 
+// SetOCSPStapling enables or disables the OCSP stapling for the certificate
+// on the instance.
+func SetOCSPStapling(ctx context.Context, c client.Client, instance *v1alpha1.RpaasInstance, certificateName string, enabled bool) error {
+	if c == nil {
+		return fmt.Errorf("kubernetes client cannot be nil")
+	}
+
+	if instance == nil {
+		return fmt.Errorf("rpaasinstance cannot be nil")
+	}
+
+	if certificateName == "" {
+		return fmt.Errorf("certificate name cannot be empty")
+	}
+
+	s, err := getTLSSecretByCertificateName(ctx, c, instance, certificateName)
+	if err != nil && err == ErrTLSSecretNotFound {
+		return fmt.Errorf("certificate %q does not exist", certificateName)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if enabled == slices.Contains(instance.Spec.OCSPStapling, s.Name) {
+		return nil
+	}
+
+	if enabled {
+		instance.Spec.OCSPStapling = append(instance.Spec.OCSPStapling, s.Name)
+	} else {
+		instance.Spec.OCSPStapling = slices.DeleteFunc(instance.Spec.OCSPStapling, func(name string) bool { return name == s.Name })
+	}
+
+	return c.Update(ctx, instance)
+}
+
 func updateInstanceWithCertificateInfos(ctx context.Context, c client.Client, i *v1alpha1.RpaasInstance, s *corev1.Secret) error {
 	hosts, err := extractDNSNames(s.Data[corev1.TLSCertKey])
 	if err != nil {
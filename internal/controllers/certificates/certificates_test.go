@@ -471,3 +471,82 @@ func Test_DeleteCertificate(t *testing.T) {
 		})
 	}
 }
+
+func Test_SetOCSPStapling(t *testing.T) {
+	tests := map[string]struct {
+		instance        string
+		certificateName string
+		enabled         bool
+		expected        []string
+		expectedError   string
+	}{
+		"when certificate name is not provided": {
+			instance:      "my-instance-1",
+			expectedError: "certificate name cannot be empty",
+		},
+
+		"when certificate does not exist": {
+			instance:        "my-instance-1",
+			certificateName: "not-found-certificates",
+			enabled:         true,
+			expectedError:   "certificate \"not-found-certificates\" does not exist",
+		},
+
+		"enabling OCSP stapling": {
+			instance:        "my-instance-2",
+			certificateName: "www.example.com",
+			enabled:         true,
+			expected:        []string{"my-instance-2-certs-abc123"},
+		},
+
+		"disabling OCSP stapling when it is already disabled": {
+			instance:        "my-instance-2",
+			certificateName: "www.example.com",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			resources := k8sResources()
+
+			client := fake.NewClientBuilder().
+				WithScheme(runtime.NewScheme()).
+				WithRuntimeObjects(resources...).
+				Build()
+
+			var instance *v1alpha1.RpaasInstance
+			for _, object := range resources {
+				if i, found := object.(*v1alpha1.RpaasInstance); found && i.Name == tt.instance {
+					instance = i
+					break
+				}
+			}
+
+			require.NotNil(t, instance, "you should select a RpaasInstance from resources")
+
+			err := certificates.SetOCSPStapling(context.TODO(), client, instance, tt.certificateName, tt.enabled)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+
+			var i v1alpha1.RpaasInstance
+			err = client.Get(context.TODO(), types.NamespacedName{Name: tt.instance, Namespace: "rpaasv2"}, &i)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, i.Spec.OCSPStapling)
+
+			if !tt.enabled {
+				return
+			}
+
+			err = certificates.DeleteCertificate(context.TODO(), client, &i, tt.certificateName)
+			require.NoError(t, err)
+
+			err = client.Get(context.TODO(), types.NamespacedName{Name: tt.instance, Namespace: "rpaasv2"}, &i)
+			require.NoError(t, err)
+			assert.Empty(t, i.Spec.OCSPStapling)
+		})
+	}
+}
@@ -7,8 +7,20 @@ package rpaas
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	cron "github.com/robfig/cron/v3"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 
 	"github.com/tsuru/rpaas-operator/api/v1alpha1"
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
@@ -25,6 +37,41 @@ func (m *k8sRpaasManager) GetAutoscale(ctx context.Context, instanceName string)
 	return m.getAutoscale(instance), nil
 }
 
+// GetAutoscaleObject returns the object the controller generated from the
+// autoscale settings of the instance, either the KEDA ScaledObject or the
+// HorizontalPodAutoscaler, whichever exists (both are named after the
+// instance).
+func (m *k8sRpaasManager) GetAutoscaleObject(ctx context.Context, instanceName string) (client.Object, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	key := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+	for _, obj := range []client.Object{&kedav1alpha1.ScaledObject{}, &autoscalingv2.HorizontalPodAutoscaler{}} {
+		err = m.cli.Get(ctx, key, obj)
+		// NOTE: KEDA's custom resources may not be installed in the cluster.
+		if k8sErrors.IsNotFound(err) || meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		gvk, err := apiutil.GVKForObject(obj, m.cli.Scheme())
+		if err != nil {
+			return nil, err
+		}
+
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		obj.SetManagedFields(nil)
+		return obj, nil
+	}
+
+	return nil, NotFoundError{Msg: fmt.Sprintf("no autoscaler found for instance %q: autoscale is either not set or not applied yet", instanceName)}
+}
+
 func (m *k8sRpaasManager) UpdateAutoscale(ctx context.Context, instanceName string, autoscale autogenerated.Autoscale) error {
 	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
@@ -66,7 +113,7 @@ func (m *k8sRpaasManager) getAutoscale(instance *v1alpha1.RpaasInstance) *autoge
 		})
 	}
 
-	return &autogenerated.Autoscale{
+	autoscale := &autogenerated.Autoscale{
 		MinReplicas: minReplicas,
 		MaxReplicas: a.MaxReplicas,
 		Cpu:         a.TargetCPUUtilizationPercentage,
@@ -74,6 +121,27 @@ func (m *k8sRpaasManager) getAutoscale(instance *v1alpha1.RpaasInstance) *autoge
 		Rps:         a.TargetRequestsPerSecond,
 		Schedules:   sws,
 	}
+
+	if a.TargetMemoryAverageValue != nil {
+		autoscale.SetMemoryValue(a.TargetMemoryAverageValue.String())
+	}
+
+	if a.RPSWindow != nil {
+		autoscale.SetRpsWindow(a.RPSWindow.Duration.String())
+	}
+
+	if a.RPSAggregation != "" {
+		autoscale.SetRpsAggregation(a.RPSAggregation)
+	}
+
+	if a.Mirror != nil {
+		autoscale.SetMirrorInstance(a.Mirror.Instance)
+		if ratio, err := strconv.ParseFloat(a.Mirror.Ratio, 64); err == nil {
+			autoscale.SetMirrorRatio(ratio)
+		}
+	}
+
+	return autoscale
 }
 
 func (m *k8sRpaasManager) updateAutoscale(ctx context.Context, instance *v1alpha1.RpaasInstance, autoscale autogenerated.Autoscale) error {
@@ -81,6 +149,10 @@ func (m *k8sRpaasManager) updateAutoscale(ctx context.Context, instance *v1alpha
 		return err
 	}
 
+	if err := m.validateAutoscaleMirror(ctx, instance, &autoscale); err != nil {
+		return err
+	}
+
 	originalInstance := instance.DeepCopy()
 
 	var sws []v1alpha1.ScheduledWindow
@@ -98,9 +170,29 @@ func (m *k8sRpaasManager) updateAutoscale(ctx context.Context, instance *v1alpha
 		TargetCPUUtilizationPercentage:    autoscale.Cpu,
 		TargetMemoryUtilizationPercentage: autoscale.Memory,
 		TargetRequestsPerSecond:           autoscale.Rps,
+		RPSAggregation:                    autoscale.GetRpsAggregation(),
 		Schedules:                         sws,
 	}
 
+	if v := autoscale.GetMemoryValue(); v != "" {
+		// NOTE: already validated by validateAutoscale
+		memory := resource.MustParse(v)
+		instance.Spec.Autoscale.TargetMemoryAverageValue = &memory
+	}
+
+	if w := autoscale.GetRpsWindow(); w != "" {
+		// NOTE: already validated by validateAutoscale
+		window, _ := time.ParseDuration(w)
+		instance.Spec.Autoscale.RPSWindow = &metav1.Duration{Duration: window}
+	}
+
+	if source := autoscale.GetMirrorInstance(); source != "" {
+		instance.Spec.Autoscale.Mirror = &v1alpha1.AutoscaleMirror{Instance: source}
+		if autoscale.MirrorRatio != nil {
+			instance.Spec.Autoscale.Mirror.Ratio = strconv.FormatFloat(*autoscale.MirrorRatio, 'f', -1, 64)
+		}
+	}
+
 	return m.patchInstance(ctx, originalInstance, instance)
 }
 
@@ -121,8 +213,8 @@ func validateAutoscale(a *autogenerated.Autoscale) error {
 		return &ValidationError{Msg: "min replicas must not be greater than max replicas"}
 	}
 
-	if a.Cpu == nil && a.Memory == nil && a.Rps == nil && len(a.Schedules) == 0 {
-		return &ValidationError{Msg: "you must provide either CPU, memory, RPS targets, or schedules"}
+	if a.Cpu == nil && a.Memory == nil && a.MemoryValue == nil && a.Rps == nil && len(a.Schedules) == 0 && a.MirrorInstance == nil {
+		return &ValidationError{Msg: "you must provide either CPU, memory, RPS targets, schedules, or an instance to mirror"}
 	}
 
 	if cpu := a.Cpu; cpu != nil && *cpu <= 0 {
@@ -133,10 +225,32 @@ func validateAutoscale(a *autogenerated.Autoscale) error {
 		return &ValidationError{Msg: "memory must be greater than zero"}
 	}
 
+	if err := validateMemoryValue(a); err != nil {
+		return err
+	}
+
 	if rps := a.Rps; rps != nil && *rps <= 0 {
 		return &ValidationError{Msg: "RPS must be greater than zero"}
 	}
 
+	if err := validateRPSAggregation(a); err != nil {
+		return err
+	}
+
+	if ratio := a.MirrorRatio; ratio != nil {
+		if a.GetMirrorInstance() == "" {
+			return &ValidationError{Msg: "mirror ratio requires an instance to mirror"}
+		}
+
+		if *ratio <= 0 {
+			return &ValidationError{Msg: "mirror ratio must be greater than zero"}
+		}
+	}
+
+	if a.MirrorInstance != nil && *a.MirrorInstance == "" {
+		return &ValidationError{Msg: "instance to mirror cannot be empty"}
+	}
+
 	for _, s := range a.Schedules {
 		if s.MinReplicas <= 0 {
 			return &ValidationError{Msg: "scheduled window min replicas must be greater than zero"}
@@ -171,3 +285,75 @@ func validateAutoscale(a *autogenerated.Autoscale) error {
 
 	return nil
 }
+
+func validateMemoryValue(a *autogenerated.Autoscale) error {
+	if a.MemoryValue == nil {
+		return nil
+	}
+
+	if a.Memory != nil {
+		return &ValidationError{Msg: "memory utilization and memory value targets cannot be set together"}
+	}
+
+	memory, err := resource.ParseQuantity(*a.MemoryValue)
+	if err != nil {
+		return &ValidationError{Msg: fmt.Sprintf("could not parse the memory value %q: %s", *a.MemoryValue, err)}
+	}
+
+	if memory.Sign() <= 0 {
+		return &ValidationError{Msg: "memory value must be greater than zero"}
+	}
+
+	return nil
+}
+
+func validateRPSAggregation(a *autogenerated.Autoscale) error {
+	if a.RpsWindow == nil && a.RpsAggregation == nil {
+		return nil
+	}
+
+	if a.Rps == nil {
+		return &ValidationError{Msg: "RPS window and aggregation require an RPS target"}
+	}
+
+	if a.RpsWindow == nil {
+		return &ValidationError{Msg: "RPS aggregation requires an RPS window"}
+	}
+
+	window, err := time.ParseDuration(*a.RpsWindow)
+	if err != nil {
+		return &ValidationError{Msg: fmt.Sprintf("could not parse the RPS window %q: %s", *a.RpsWindow, err)}
+	}
+
+	if window <= 0 {
+		return &ValidationError{Msg: "RPS window must be a positive duration"}
+	}
+
+	switch agg := a.GetRpsAggregation(); agg {
+	case "", v1alpha1.RPSAggregationAverage, v1alpha1.RPSAggregationMax, v1alpha1.RPSAggregationP95:
+	default:
+		return &ValidationError{Msg: fmt.Sprintf("unknown RPS aggregation %q, it must be one of: avg, max, p95", agg)}
+	}
+
+	return nil
+}
+
+// validateAutoscaleMirror checks the mirrored instance exists alongside the
+// instance, as its replicas are looked up by namespace and name.
+func (m *k8sRpaasManager) validateAutoscaleMirror(ctx context.Context, instance *v1alpha1.RpaasInstance, a *autogenerated.Autoscale) error {
+	source := a.GetMirrorInstance()
+	if source == "" {
+		return nil
+	}
+
+	if source == instance.Name {
+		return &ValidationError{Msg: "an instance cannot mirror itself"}
+	}
+
+	err := m.cli.Get(ctx, types.NamespacedName{Name: source, Namespace: instance.Namespace}, &v1alpha1.RpaasInstance{})
+	if k8sErrors.IsNotFound(err) {
+		return &ValidationError{Msg: fmt.Sprintf("instance to mirror %q not found in the same namespace as %q", source, instance.Name)}
+	}
+
+	return err
+}
@@ -7,10 +7,17 @@ package rpaas
 import (
 	"context"
 	"testing"
+	"time"
 
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/tsuru/rpaas-operator/api/v1alpha1"
@@ -65,6 +72,59 @@ func Test_k8sRpaasManager_GetAutoscale(t *testing.T) {
 				},
 			},
 		},
+
+		"autoscale set with RPS window and aggregation": {
+			instance: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
+				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+					MinReplicas:             autogenerated.PtrInt32(2),
+					MaxReplicas:             10,
+					TargetRequestsPerSecond: autogenerated.PtrInt32(100),
+					RPSWindow:               &metav1.Duration{Duration: 2 * time.Minute},
+					RPSAggregation:          "p95",
+				}
+				return ri
+			},
+			expected: &autogenerated.Autoscale{
+				MinReplicas:    2,
+				MaxReplicas:    10,
+				Rps:            autogenerated.PtrInt32(100),
+				RpsWindow:      autogenerated.PtrString("2m0s"),
+				RpsAggregation: autogenerated.PtrString("p95"),
+			},
+		},
+
+		"autoscale set with memory value": {
+			instance: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
+				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+					MinReplicas:              autogenerated.PtrInt32(2),
+					MaxReplicas:              10,
+					TargetMemoryAverageValue: resource.NewQuantity(512*1024*1024, resource.BinarySI),
+				}
+				return ri
+			},
+			expected: &autogenerated.Autoscale{
+				MinReplicas: 2,
+				MaxReplicas: 10,
+				MemoryValue: autogenerated.PtrString("512Mi"),
+			},
+		},
+
+		"autoscale set mirroring another instance": {
+			instance: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
+				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+					MinReplicas: autogenerated.PtrInt32(1),
+					MaxReplicas: 20,
+					Mirror:      &v1alpha1.AutoscaleMirror{Instance: "another-instance", Ratio: "0.5"},
+				}
+				return ri
+			},
+			expected: &autogenerated.Autoscale{
+				MinReplicas:    1,
+				MaxReplicas:    20,
+				MirrorInstance: autogenerated.PtrString("another-instance"),
+				MirrorRatio:    autogenerated.PtrFloat64(0.5),
+			},
+		},
 	}
 
 	for name, tt := range tests {
@@ -93,12 +153,77 @@ func Test_k8sRpaasManager_GetAutoscale(t *testing.T) {
 	}
 }
 
+func Test_k8sRpaasManager_GetAutoscaleObject(t *testing.T) {
+	t.Parallel()
+
+	instance := newEmptyRpaasInstance()
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			MinReplicas: autogenerated.PtrInt32(2),
+			MaxReplicas: 10,
+		},
+	}
+
+	scaledObject := &kedav1alpha1.ScaledObject{
+		ObjectMeta: metav1.ObjectMeta{Name: instance.Name, Namespace: instance.Namespace},
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			MinReplicaCount: autogenerated.PtrInt32(2),
+			MaxReplicaCount: autogenerated.PtrInt32(10),
+		},
+	}
+
+	tests := map[string]struct {
+		objects      []k8sruntime.Object
+		expectedKind string
+		expectedErr  string
+	}{
+		"when autoscale is handled by the HPA": {
+			objects:      []k8sruntime.Object{hpa},
+			expectedKind: "autoscaling/v2, Kind=HorizontalPodAutoscaler",
+		},
+
+		"when autoscale is handled by KEDA": {
+			objects:      []k8sruntime.Object{scaledObject},
+			expectedKind: "keda.sh/v1alpha1, Kind=ScaledObject",
+		},
+
+		"when there's no autoscaler": {
+			expectedErr: `no autoscaler found for instance "my-instance": autoscale is either not set or not applied yet`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &k8sRpaasManager{
+				cli: fake.NewClientBuilder().
+					WithScheme(runtime.NewScheme()).
+					WithRuntimeObjects(append(tt.objects, instance.DeepCopy())...).
+					Build(),
+			}
+
+			obj, err := m.GetAutoscaleObject(context.Background(), instance.Name)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedKind, obj.GetObjectKind().GroupVersionKind().String())
+			assert.Equal(t, instance.Name, obj.GetName())
+			assert.Empty(t, obj.GetManagedFields())
+		})
+	}
+}
+
 func Test_k8sRpaasManager_UpdateAutoscale(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
 		instance    func(*v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance
 		autoscale   autogenerated.Autoscale
+		resources   []client.Object
 		expected    func(*v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance
 		expectedErr string
 	}{
@@ -133,7 +258,7 @@ func Test_k8sRpaasManager_UpdateAutoscale(t *testing.T) {
 			autoscale: autogenerated.Autoscale{
 				MaxReplicas: 42,
 			},
-			expectedErr: "you must provide either CPU, memory, RPS targets, or schedules",
+			expectedErr: "you must provide either CPU, memory, RPS targets, schedules, or an instance to mirror",
 		},
 
 		"cpu < 0": {
@@ -168,6 +293,31 @@ func Test_k8sRpaasManager_UpdateAutoscale(t *testing.T) {
 			expectedErr: "memory must be greater than zero",
 		},
 
+		"memory value along with memory utilization": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas: 42,
+				Memory:      autogenerated.PtrInt32(80),
+				MemoryValue: autogenerated.PtrString("512Mi"),
+			},
+			expectedErr: "memory utilization and memory value targets cannot be set together",
+		},
+
+		"invalid memory value": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas: 42,
+				MemoryValue: autogenerated.PtrString("512MB"),
+			},
+			expectedErr: `could not parse the memory value "512MB": quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'`,
+		},
+
+		"memory value = 0": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas: 42,
+				MemoryValue: autogenerated.PtrString("0Mi"),
+			},
+			expectedErr: "memory value must be greater than zero",
+		},
+
 		"RPS < 0": {
 			autoscale: autogenerated.Autoscale{
 				MaxReplicas: 42,
@@ -184,6 +334,86 @@ func Test_k8sRpaasManager_UpdateAutoscale(t *testing.T) {
 			expectedErr: "RPS must be greater than zero",
 		},
 
+		"RPS window without RPS target": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas: 42,
+				Cpu:         autogenerated.PtrInt32(75),
+				RpsWindow:   autogenerated.PtrString("2m"),
+			},
+			expectedErr: "RPS window and aggregation require an RPS target",
+		},
+
+		"RPS aggregation without RPS window": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas:    42,
+				Rps:            autogenerated.PtrInt32(100),
+				RpsAggregation: autogenerated.PtrString("max"),
+			},
+			expectedErr: "RPS aggregation requires an RPS window",
+		},
+
+		"invalid RPS window": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas: 42,
+				Rps:         autogenerated.PtrInt32(100),
+				RpsWindow:   autogenerated.PtrString("two minutes"),
+			},
+			expectedErr: `could not parse the RPS window "two minutes": time: invalid duration "two minutes"`,
+		},
+
+		"RPS window = 0": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas: 42,
+				Rps:         autogenerated.PtrInt32(100),
+				RpsWindow:   autogenerated.PtrString("0s"),
+			},
+			expectedErr: "RPS window must be a positive duration",
+		},
+
+		"unknown RPS aggregation": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas:    42,
+				Rps:            autogenerated.PtrInt32(100),
+				RpsWindow:      autogenerated.PtrString("2m"),
+				RpsAggregation: autogenerated.PtrString("p99"),
+			},
+			expectedErr: `unknown RPS aggregation "p99", it must be one of: avg, max, p95`,
+		},
+
+		"mirror ratio without an instance to mirror": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas: 42,
+				Cpu:         autogenerated.PtrInt32(80),
+				MirrorRatio: autogenerated.PtrFloat64(0.5),
+			},
+			expectedErr: "mirror ratio requires an instance to mirror",
+		},
+
+		"mirror ratio == 0": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas:    42,
+				MirrorInstance: autogenerated.PtrString("another-instance"),
+				MirrorRatio:    autogenerated.PtrFloat64(0),
+			},
+			expectedErr: "mirror ratio must be greater than zero",
+		},
+
+		"mirroring itself": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas:    42,
+				MirrorInstance: autogenerated.PtrString("my-instance"),
+			},
+			expectedErr: "an instance cannot mirror itself",
+		},
+
+		"mirroring an instance which does not exist": {
+			autoscale: autogenerated.Autoscale{
+				MaxReplicas:    42,
+				MirrorInstance: autogenerated.PtrString("another-instance"),
+			},
+			expectedErr: `instance to mirror "another-instance" not found in the same namespace as "my-instance"`,
+		},
+
 		"schedule with min replicas < 0": {
 			autoscale: autogenerated.Autoscale{
 				MaxReplicas: 42,
@@ -306,6 +536,44 @@ func Test_k8sRpaasManager_UpdateAutoscale(t *testing.T) {
 			},
 		},
 
+		"autoscale with RPS window and aggregation": {
+			autoscale: autogenerated.Autoscale{
+				MinReplicas:    2,
+				MaxReplicas:    10,
+				Rps:            autogenerated.PtrInt32(100),
+				RpsWindow:      autogenerated.PtrString("90s"),
+				RpsAggregation: autogenerated.PtrString("max"),
+			},
+			expected: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
+				ri.ResourceVersion = "1000" // means it was updated
+				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+					MinReplicas:             autogenerated.PtrInt32(2),
+					MaxReplicas:             10,
+					TargetRequestsPerSecond: autogenerated.PtrInt32(100),
+					RPSWindow:               &metav1.Duration{Duration: 90 * time.Second},
+					RPSAggregation:          "max",
+				}
+				return ri
+			},
+		},
+
+		"autoscale with memory value": {
+			autoscale: autogenerated.Autoscale{
+				MinReplicas: 2,
+				MaxReplicas: 10,
+				MemoryValue: autogenerated.PtrString("512Mi"),
+			},
+			expected: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
+				ri.ResourceVersion = "1000" // means it was updated
+				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+					MinReplicas:              autogenerated.PtrInt32(2),
+					MaxReplicas:              10,
+					TargetMemoryAverageValue: resource.NewQuantity(512*1024*1024, resource.BinarySI),
+				}
+				return ri
+			},
+		},
+
 		"autoscale with schedules": {
 			autoscale: autogenerated.Autoscale{
 				MinReplicas: 0,
@@ -328,6 +596,33 @@ func Test_k8sRpaasManager_UpdateAutoscale(t *testing.T) {
 				return ri
 			},
 		},
+
+		"autoscale mirroring another instance": {
+			autoscale: autogenerated.Autoscale{
+				MinReplicas:    1,
+				MaxReplicas:    20,
+				MirrorInstance: autogenerated.PtrString("another-instance"),
+				MirrorRatio:    autogenerated.PtrFloat64(0.5),
+			},
+			resources: []client.Object{
+				&v1alpha1.RpaasInstance{
+					TypeMeta:   newEmptyRpaasInstance().TypeMeta,
+					ObjectMeta: metav1.ObjectMeta{Name: "another-instance", Namespace: getServiceName()},
+				},
+			},
+			expected: func(ri *v1alpha1.RpaasInstance) *v1alpha1.RpaasInstance {
+				ri.ResourceVersion = "1000" // means it was updated
+				ri.Spec.Autoscale = &v1alpha1.RpaasInstanceAutoscaleSpec{
+					MinReplicas: autogenerated.PtrInt32(1),
+					MaxReplicas: 20,
+					Mirror: &v1alpha1.AutoscaleMirror{
+						Instance: "another-instance",
+						Ratio:    "0.5",
+					},
+				}
+				return ri
+			},
+		},
 	}
 
 	for name, tt := range tests {
@@ -341,6 +636,7 @@ func Test_k8sRpaasManager_UpdateAutoscale(t *testing.T) {
 				cli: fake.NewClientBuilder().
 					WithScheme(runtime.NewScheme()).
 					WithRuntimeObjects(instance).
+					WithObjects(tt.resources...).
 					Build(),
 			}
 
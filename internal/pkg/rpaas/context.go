@@ -8,7 +8,12 @@ import "context"
 
 type contextKey struct{}
 
-var rpaasManagerKey = contextKey{}
+type fieldManagerContextKey struct{}
+
+var (
+	rpaasManagerKey = contextKey{}
+	fieldManagerKey = fieldManagerContextKey{}
+)
 
 func ContextWithRpaasManager(ctx context.Context, manager RpaasManager) context.Context {
 	return context.WithValue(ctx, rpaasManagerKey, manager)
@@ -22,3 +27,15 @@ func RpaasManagerFromContext(ctx context.Context) RpaasManager {
 
 	return nil
 }
+
+// ContextWithFieldManager sets the field manager the changes made to the
+// instances through ctx are attributed to, see FieldManagerFromContext.
+func ContextWithFieldManager(ctx context.Context, fieldManager string) context.Context {
+	return context.WithValue(ctx, fieldManagerKey, fieldManager)
+}
+
+// FieldManagerFromContext returns the field manager set on ctx, if any.
+func FieldManagerFromContext(ctx context.Context) string {
+	fieldManager, _ := ctx.Value(fieldManagerKey).(string)
+	return fieldManager
+}
@@ -9,6 +9,7 @@ import (
 	"crypto/tls"
 
 	nginxv1alpha1 "github.com/tsuru/nginx-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/tsuru/rpaas-operator/api/v1alpha1"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
@@ -19,45 +20,63 @@ import (
 var _ rpaas.RpaasManager = &RpaasManager{}
 
 type RpaasManager struct {
-	FakeUpdateCertificate        func(instance, name string, cert tls.Certificate) error
-	FakeGetCertificates          func(instanceName string) ([]rpaas.CertificateData, error)
-	FakeDeleteCertificate        func(instance, name string) error
-	FakeCreateInstance           func(args rpaas.CreateArgs) error
-	FakeDeleteInstance           func(instanceName string) error
-	FakeUpdateInstance           func(instanceName string, args rpaas.UpdateInstanceArgs) error
-	FakeGetInstance              func(instanceName string) (*v1alpha1.RpaasInstance, error)
-	FakeDeleteBlock              func(instanceName, blockName string) error
-	FakeListBlocks               func(instanceName string) ([]rpaas.ConfigurationBlock, error)
-	FakeUpdateBlock              func(instanceName string, block rpaas.ConfigurationBlock) error
-	FakeInstanceAddress          func(name string) (string, error)
-	FakeInstanceStatus           func(name string) (*nginxv1alpha1.Nginx, rpaas.PodStatusMap, error)
-	FakeScale                    func(instanceName string, replicas int32) error
-	FakeGetPlans                 func() ([]rpaas.Plan, error)
-	FakeGetFlavors               func() ([]rpaas.Flavor, error)
-	FakeCreateExtraFiles         func(instanceName string, files ...rpaas.File) error
-	FakeDeleteExtraFiles         func(instanceName string, filenames ...string) error
-	FakeGetExtraFiles            func(instanceName string) ([]rpaas.File, error)
-	FakeUpdateExtraFiles         func(instanceName string, files ...rpaas.File) error
-	FakeBindApp                  func(instanceName string, args rpaas.BindAppArgs) error
-	FakeUnbindApp                func(instanceName, appName string) error
-	FakePurgeCache               func(instanceName string, args rpaas.PurgeCacheArgs) (int, error)
-	FakeDeleteRoute              func(instanceName, path string) error
-	FakeGetRoutes                func(instanceName string) ([]rpaas.Route, error)
-	FakeUpdateRoute              func(instanceName string, route rpaas.Route) error
-	FakeGetAutoscale             func(name string) (*autogenerated.Autoscale, error)
-	FakeCreateAutoscale          func(instanceName string, autoscale autogenerated.Autoscale) error
-	FakeUpdateAutoscale          func(instanceName string, autoscale autogenerated.Autoscale) error
-	FakeDeleteAutoscale          func(name string) error
-	FakeGetInstanceInfo          func(instanceName string) (*clientTypes.InstanceInfo, error)
-	FakeExec                     func(instanceName string, args rpaas.ExecArgs) error
-	FakeDebug                    func(instanceName string, args rpaas.DebugArgs) error
-	FakeLog                      func(instanceName string, args rpaas.LogArgs) error
-	FakeAddUpstream              func(instanceName string, upstream v1alpha1.AllowedUpstream) error
-	FakeGetUpstreams             func(instanceName string) ([]v1alpha1.AllowedUpstream, error)
-	FakeDeleteUpstream           func(instanceName string, upstream v1alpha1.AllowedUpstream) error
-	FakeGetCertManagerRequests   func(instanceName string) ([]clientTypes.CertManager, error)
-	FakeUpdateCertManagerRequest func(instanceName string, in clientTypes.CertManager) error
-	FakeDeleteCertManagerRequest func(instanceName, issuer string) error
+	FakeUpdateCertificate          func(instance, name string, cert tls.Certificate) error
+	FakeUpdateRotatingCertificate  func(instance, name, counterpart string, cert tls.Certificate) error
+	FakeGetCertificates            func(instanceName string) ([]rpaas.CertificateData, error)
+	FakeDeleteCertificate          func(instance, name string) error
+	FakeCreateInstance             func(args rpaas.CreateArgs) error
+	FakeDeleteInstance             func(instanceName string) error
+	FakeUpdateInstance             func(instanceName string, args rpaas.UpdateInstanceArgs) error
+	FakeGetInstance                func(instanceName string) (*v1alpha1.RpaasInstance, error)
+	FakeDeleteBlock                func(instanceName, blockName string) error
+	FakeListBlocks                 func(instanceName string) ([]rpaas.ConfigurationBlock, error)
+	FakeUpdateBlock                func(instanceName string, block rpaas.ConfigurationBlock) error
+	FakeValidateBlock              func(instanceName string, block rpaas.ConfigurationBlock) (*clientTypes.BlockValidation, error)
+	FakeInstanceAddress            func(name string) (string, error)
+	FakeInstanceStatus             func(name string) (*nginxv1alpha1.Nginx, rpaas.PodStatusMap, error)
+	FakeScale                      func(instanceName string, replicas int32) error
+	FakeRestart                    func(instanceName string, args rpaas.RestartArgs) ([]string, error)
+	FakeGetPlans                   func() ([]rpaas.Plan, error)
+	FakeGetFlavors                 func() ([]rpaas.Flavor, error)
+	FakeGetFlavor                  func(name string) (*rpaas.FlavorInfo, error)
+	FakeUpdateFlavors              func(instanceName string, flavors []string) error
+	FakeGetConnectionStats         func(instanceName string) ([]clientTypes.PodConnectionStats, error)
+	FakeGetPodsHealth              func(instanceName string) ([]clientTypes.PodHealth, error)
+	FakeGetPodsUsage               func(instanceName string) ([]clientTypes.PodUsage, error)
+	FakeListPods                   func(instanceName, selector string) ([]clientTypes.Pod, error)
+	FakeGetBindsStatus             func(instanceName string) ([]clientTypes.BindStatus, error)
+	FakeGetCertificateStatus       func(instanceName, name string) ([]clientTypes.PodCertificateStatus, error)
+	FakeSetCertificateOCSPStapling func(instanceName, name string, enabled bool) error
+	FakeGetMetadata                func(instanceName string) (*clientTypes.Metadata, error)
+	FakeSetMetadata                func(instanceName string, metadata *clientTypes.Metadata) error
+	FakeUnsetMetadata              func(instanceName string, metadata *clientTypes.Metadata) error
+	FakeCreateExtraFiles           func(instanceName string, files ...rpaas.File) error
+	FakeDeleteExtraFiles           func(instanceName string, filenames ...string) error
+	FakeGetExtraFiles              func(instanceName string) ([]rpaas.File, error)
+	FakeUpdateExtraFiles           func(instanceName string, files ...rpaas.File) error
+	FakeBindApp                    func(instanceName string, args rpaas.BindAppArgs) error
+	FakeUnbindApp                  func(instanceName, appName string) error
+	FakePurgeCache                 func(instanceName string, args rpaas.PurgeCacheArgs) (int, error)
+	FakePurgeCacheOnPods           func(instanceName string, args rpaas.PurgeCacheArgs) ([]rpaas.PurgeCachePodResult, error)
+	FakeDeleteRoute                func(instanceName, path string) error
+	FakeGetRoutes                  func(instanceName string) ([]rpaas.Route, error)
+	FakeUpdateRoute                func(instanceName string, route rpaas.Route) error
+	FakeGetAutoscale               func(name string) (*autogenerated.Autoscale, error)
+	FakeGetAutoscaleObject         func(instanceName string) (client.Object, error)
+	FakeCreateAutoscale            func(instanceName string, autoscale autogenerated.Autoscale) error
+	FakeUpdateAutoscale            func(instanceName string, autoscale autogenerated.Autoscale) error
+	FakeDeleteAutoscale            func(name string) error
+	FakeGetInstanceInfo            func(instanceName string) (*clientTypes.InstanceInfo, error)
+	FakeGetInstanceHistory         func(instanceName string) ([]clientTypes.HistoryEntry, error)
+	FakeExec                       func(instanceName string, args rpaas.ExecArgs) error
+	FakeDebug                      func(instanceName string, args rpaas.DebugArgs) error
+	FakeLog                        func(instanceName string, args rpaas.LogArgs) error
+	FakeAddUpstream                func(instanceName string, upstream v1alpha1.AllowedUpstream) error
+	FakeGetUpstreams               func(instanceName string) ([]v1alpha1.AllowedUpstream, error)
+	FakeDeleteUpstream             func(instanceName string, upstream v1alpha1.AllowedUpstream) error
+	FakeGetCertManagerRequests     func(instanceName string) ([]clientTypes.CertManager, error)
+	FakeUpdateCertManagerRequest   func(instanceName string, in clientTypes.CertManager) error
+	FakeDeleteCertManagerRequest   func(instanceName, issuer string) error
 }
 
 func (m *RpaasManager) Log(ctx context.Context, instanceName string, args rpaas.LogArgs) error {
@@ -74,6 +93,13 @@ func (m *RpaasManager) GetInstanceInfo(ctx context.Context, instanceName string)
 	return nil, nil
 }
 
+func (m *RpaasManager) GetInstanceHistory(ctx context.Context, instanceName string) ([]clientTypes.HistoryEntry, error) {
+	if m.FakeGetInstanceHistory != nil {
+		return m.FakeGetInstanceHistory(instanceName)
+	}
+	return nil, nil
+}
+
 func (m *RpaasManager) GetCertificates(ctx context.Context, instanceName string) ([]rpaas.CertificateData, error) {
 	if m.FakeGetCertificates != nil {
 		return m.FakeGetCertificates(instanceName)
@@ -96,6 +122,13 @@ func (m *RpaasManager) UpdateCertificate(ctx context.Context, instance, name str
 	return nil
 }
 
+func (m *RpaasManager) UpdateRotatingCertificate(ctx context.Context, instance, name, counterpart string, c tls.Certificate) error {
+	if m.FakeUpdateRotatingCertificate != nil {
+		return m.FakeUpdateRotatingCertificate(instance, name, counterpart, c)
+	}
+	return nil
+}
+
 func (m *RpaasManager) CreateInstance(ctx context.Context, args rpaas.CreateArgs) error {
 	if m.FakeCreateInstance != nil {
 		return m.FakeCreateInstance(args)
@@ -145,6 +178,13 @@ func (m *RpaasManager) UpdateBlock(ctx context.Context, instanceName string, blo
 	return nil
 }
 
+func (m *RpaasManager) ValidateBlock(ctx context.Context, instanceName string, block rpaas.ConfigurationBlock) (*clientTypes.BlockValidation, error) {
+	if m.FakeValidateBlock != nil {
+		return m.FakeValidateBlock(instanceName, block)
+	}
+	return nil, nil
+}
+
 func (m *RpaasManager) GetInstanceAddress(ctx context.Context, name string) (string, error) {
 	if m.FakeInstanceAddress != nil {
 		return m.FakeInstanceAddress(name)
@@ -166,6 +206,13 @@ func (m *RpaasManager) Scale(ctx context.Context, instanceName string, replicas
 	return nil
 }
 
+func (m *RpaasManager) Restart(ctx context.Context, instanceName string, args rpaas.RestartArgs) ([]string, error) {
+	if m.FakeRestart != nil {
+		return m.FakeRestart(instanceName, args)
+	}
+	return nil, nil
+}
+
 func (m *RpaasManager) GetPlans(ctx context.Context) ([]rpaas.Plan, error) {
 	if m.FakeGetPlans != nil {
 		return m.FakeGetPlans()
@@ -180,6 +227,90 @@ func (m *RpaasManager) GetFlavors(ctx context.Context) ([]rpaas.Flavor, error) {
 	return nil, nil
 }
 
+func (m *RpaasManager) GetFlavor(ctx context.Context, name string) (*rpaas.FlavorInfo, error) {
+	if m.FakeGetFlavor != nil {
+		return m.FakeGetFlavor(name)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) UpdateFlavors(ctx context.Context, instanceName string, flavors []string) error {
+	if m.FakeUpdateFlavors != nil {
+		return m.FakeUpdateFlavors(instanceName, flavors)
+	}
+	return nil
+}
+
+func (m *RpaasManager) GetConnectionStats(ctx context.Context, instanceName string) ([]clientTypes.PodConnectionStats, error) {
+	if m.FakeGetConnectionStats != nil {
+		return m.FakeGetConnectionStats(instanceName)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) GetPodsHealth(ctx context.Context, instanceName string) ([]clientTypes.PodHealth, error) {
+	if m.FakeGetPodsHealth != nil {
+		return m.FakeGetPodsHealth(instanceName)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) GetPodsUsage(ctx context.Context, instanceName string) ([]clientTypes.PodUsage, error) {
+	if m.FakeGetPodsUsage != nil {
+		return m.FakeGetPodsUsage(instanceName)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) ListPods(ctx context.Context, instanceName, selector string) ([]clientTypes.Pod, error) {
+	if m.FakeListPods != nil {
+		return m.FakeListPods(instanceName, selector)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) GetBindsStatus(ctx context.Context, instanceName string) ([]clientTypes.BindStatus, error) {
+	if m.FakeGetBindsStatus != nil {
+		return m.FakeGetBindsStatus(instanceName)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) GetCertificateStatus(ctx context.Context, instanceName, name string) ([]clientTypes.PodCertificateStatus, error) {
+	if m.FakeGetCertificateStatus != nil {
+		return m.FakeGetCertificateStatus(instanceName, name)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) SetCertificateOCSPStapling(ctx context.Context, instanceName, name string, enabled bool) error {
+	if m.FakeSetCertificateOCSPStapling != nil {
+		return m.FakeSetCertificateOCSPStapling(instanceName, name, enabled)
+	}
+	return nil
+}
+
+func (m *RpaasManager) GetMetadata(ctx context.Context, instanceName string) (*clientTypes.Metadata, error) {
+	if m.FakeGetMetadata != nil {
+		return m.FakeGetMetadata(instanceName)
+	}
+	return nil, nil
+}
+
+func (m *RpaasManager) SetMetadata(ctx context.Context, instanceName string, metadata *clientTypes.Metadata) error {
+	if m.FakeSetMetadata != nil {
+		return m.FakeSetMetadata(instanceName, metadata)
+	}
+	return nil
+}
+
+func (m *RpaasManager) UnsetMetadata(ctx context.Context, instanceName string, metadata *clientTypes.Metadata) error {
+	if m.FakeUnsetMetadata != nil {
+		return m.FakeUnsetMetadata(instanceName, metadata)
+	}
+	return nil
+}
+
 func (m *RpaasManager) CreateExtraFiles(ctx context.Context, instanceName string, files ...rpaas.File) error {
 	if m.FakeCreateExtraFiles != nil {
 		return m.FakeCreateExtraFiles(instanceName, files...)
@@ -229,6 +360,13 @@ func (m *RpaasManager) PurgeCache(ctx context.Context, instanceName string, args
 	return 0, nil
 }
 
+func (m *RpaasManager) PurgeCacheOnPods(ctx context.Context, instanceName string, args rpaas.PurgeCacheArgs) ([]rpaas.PurgeCachePodResult, error) {
+	if m.FakePurgeCacheOnPods != nil {
+		return m.FakePurgeCacheOnPods(instanceName, args)
+	}
+	return nil, nil
+}
+
 func (m *RpaasManager) DeleteRoute(ctx context.Context, instanceName, path string) error {
 	if m.FakeDeleteRoute != nil {
 		return m.FakeDeleteRoute(instanceName, path)
@@ -257,6 +395,13 @@ func (m *RpaasManager) GetAutoscale(ctx context.Context, instanceName string) (*
 	return nil, nil
 }
 
+func (m *RpaasManager) GetAutoscaleObject(ctx context.Context, instanceName string) (client.Object, error) {
+	if m.FakeGetAutoscaleObject != nil {
+		return m.FakeGetAutoscaleObject(instanceName)
+	}
+	return nil, nil
+}
+
 func (m *RpaasManager) CreateAutoscale(ctx context.Context, instanceName string, autoscale autogenerated.Autoscale) error {
 	if m.FakeCreateAutoscale != nil {
 		return m.FakeCreateAutoscale(instanceName, autoscale)
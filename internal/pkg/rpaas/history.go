@@ -0,0 +1,182 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpaas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+// historyKinds maps the fields of the instance spec to the kind of the changes
+// made to them, the other fields being reported as "config" changes.
+var historyKinds = map[string]string{
+	"replicas":            "scale",
+	"shutdown":            "scale",
+	"autoscale":           "autoscale",
+	"certificates":        "certificate",
+	"dynamicCertificates": "certificate",
+	"tls":                 "certificate",
+	"blocks":              "block",
+	"locations":           "route",
+}
+
+// GetInstanceHistory returns the recent changes to the instance, sorted from
+// the oldest one. They're derived from the managed fields of the instance,
+// which only keep when each manager last changed its fields, along with the
+// events of the instance and of its Nginx, which expire after a while.
+func (m *k8sRpaasManager) GetInstanceHistory(ctx context.Context, instanceName string) ([]clientTypes.HistoryEntry, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	history := managedFieldsHistory(instance.ManagedFields)
+
+	events, err := m.eventsForObject(ctx, instance.Namespace, "RpaasInstance", instance.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	nginx, err := m.getNginx(ctx, instance)
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if nginx != nil {
+		nginxEvents, err := m.eventsForObject(ctx, nginx.Namespace, "Nginx", nginx.UID)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, nginxEvents...)
+	}
+
+	for _, evt := range events {
+		actor := evt.Source.Component
+		if actor == "" {
+			actor = evt.ReportingController
+		}
+
+		history = append(history, clientTypes.HistoryEntry{
+			Time:    eventTime(evt),
+			Kind:    "event",
+			Actor:   actor,
+			Action:  evt.Reason,
+			Message: evt.Message,
+		})
+	}
+
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Time.Before(history[j].Time) })
+
+	return history, nil
+}
+
+func eventTime(evt corev1.Event) time.Time {
+	switch {
+	case !evt.LastTimestamp.IsZero():
+		return evt.LastTimestamp.Time.UTC()
+	case !evt.EventTime.IsZero():
+		return evt.EventTime.Time.UTC()
+	default:
+		return evt.CreationTimestamp.Time.UTC()
+	}
+}
+
+// managedFieldsHistory reports the spec fields set by each manager, grouped
+// by the kind of change, at the time the manager last changed them.
+func managedFieldsHistory(entries []metav1.ManagedFieldsEntry) []clientTypes.HistoryEntry {
+	var history []clientTypes.HistoryEntry
+	for _, entry := range entries {
+		if entry.Subresource != "" || entry.Time == nil || entry.FieldsV1 == nil {
+			continue
+		}
+
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+
+		spec, _ := fields["f:spec"].(map[string]interface{})
+
+		changes := make(map[string][]string)
+		for key, value := range spec {
+			name, ok := strings.CutPrefix(key, "f:")
+			if !ok {
+				continue
+			}
+
+			kind, found := historyKinds[name]
+			if !found {
+				kind = "config"
+			}
+
+			if keys := fieldSetKeys(value); len(keys) > 0 {
+				name = fmt.Sprintf("%s (%s)", name, strings.Join(keys, ", "))
+			}
+
+			changes[kind] = append(changes[kind], name)
+		}
+
+		kinds := make([]string, 0, len(changes))
+		for kind := range changes {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+
+		for _, kind := range kinds {
+			sort.Strings(changes[kind])
+			history = append(history, clientTypes.HistoryEntry{
+				Time:    entry.Time.Time.UTC(),
+				Kind:    kind,
+				Actor:   entry.Manager,
+				Action:  string(entry.Operation),
+				Message: fmt.Sprintf("set %s", strings.Join(changes[kind], ", ")),
+			})
+		}
+	}
+
+	return history
+}
+
+// fieldSetKeys returns the names of the map keys (e.g. block names) or of the
+// list item keys (e.g. route paths) held by a field set.
+func fieldSetKeys(value interface{}) []string {
+	set, _ := value.(map[string]interface{})
+
+	var keys []string
+	for key := range set {
+		switch {
+		case strings.HasPrefix(key, "f:"):
+			keys = append(keys, strings.TrimPrefix(key, "f:"))
+
+		case strings.HasPrefix(key, "k:"):
+			var itemKey map[string]interface{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(key, "k:")), &itemKey); err != nil {
+				continue
+			}
+
+			var values []string
+			for _, v := range itemKey {
+				values = append(values, fmt.Sprint(v))
+			}
+			sort.Strings(values)
+
+			keys = append(keys, strings.Join(values, " "))
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpaas
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func Test_managedFieldsHistory(t *testing.T) {
+	t0 := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	history := managedFieldsHistory([]metav1.ManagedFieldsEntry{
+		{
+			Manager:   "rpaas-api",
+			Operation: metav1.ManagedFieldsOperationUpdate,
+			Time:      &metav1.Time{Time: t0},
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{
+				"f:metadata": {"f:labels": {"f:team": {}}},
+				"f:spec": {
+					"f:replicas": {},
+					"f:blocks": {"f:http": {}, "f:server": {}},
+					"f:locations": {".": {}, "k:{\"path\":\"/\"}": {"f:destination": {}}},
+					"f:planName": {}
+				}
+			}`)},
+		},
+		{
+			Manager:   "kubectl-edit",
+			Operation: metav1.ManagedFieldsOperationUpdate,
+			Time:      &metav1.Time{Time: t0.Add(time.Hour)},
+			FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:spec": {"f:autoscale": {"f:maxReplicas": {}}}}`)},
+		},
+		{
+			Manager:     "rpaas-operator",
+			Operation:   metav1.ManagedFieldsOperationUpdate,
+			Subresource: "status",
+			Time:        &metav1.Time{Time: t0},
+			FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{"f:status": {"f:revisionHash": {}}}`)},
+		},
+	})
+
+	assert.Equal(t, []clientTypes.HistoryEntry{
+		{Time: t0, Kind: "block", Actor: "rpaas-api", Action: "Update", Message: "set blocks (http, server)"},
+		{Time: t0, Kind: "config", Actor: "rpaas-api", Action: "Update", Message: "set planName"},
+		{Time: t0, Kind: "route", Actor: "rpaas-api", Action: "Update", Message: "set locations (/)"},
+		{Time: t0, Kind: "scale", Actor: "rpaas-api", Action: "Update", Message: "set replicas"},
+		{Time: t0.Add(time.Hour), Kind: "autoscale", Actor: "kubectl-edit", Action: "Update", Message: "set autoscale (maxReplicas)"},
+	}, history)
+}
+
+func Test_k8sRpaasManager_GetInstanceHistory(t *testing.T) {
+	t0 := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	instance := newEmptyRpaasInstance()
+	instance.UID = types.UID("my-instance-uid")
+
+	events := []runtime.Object{
+		&corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-instance.1", Namespace: instance.Namespace},
+			InvolvedObject: corev1.ObjectReference{
+				Kind: "RpaasInstance",
+				UID:  instance.UID,
+			},
+			Source:        corev1.EventSource{Component: "rpaas-api"},
+			Reason:        "CertificateUpdated",
+			Message:       "certificate \"default\" updated",
+			LastTimestamp: metav1.NewTime(t0.Add(2 * time.Hour)),
+		},
+		&corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-instance.2", Namespace: instance.Namespace},
+			InvolvedObject: corev1.ObjectReference{
+				Kind: "RpaasInstance",
+				UID:  instance.UID,
+			},
+			ReportingController: "rpaas-operator",
+			Reason:              "RpaasInstanceUpdated",
+			Message:             "scaled to 3 replicas",
+			EventTime:           metav1.NewMicroTime(t0.Add(time.Hour)),
+		},
+		&corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-instance.1", Namespace: instance.Namespace},
+			InvolvedObject: corev1.ObjectReference{
+				Kind: "RpaasInstance",
+				UID:  types.UID("other-instance-uid"),
+			},
+			Reason:        "RpaasInstanceUpdated",
+			LastTimestamp: metav1.NewTime(t0),
+		},
+	}
+
+	manager := &k8sRpaasManager{cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(append(events, instance)...).Build()}
+
+	history, err := manager.GetInstanceHistory(context.TODO(), "my-instance")
+	require.NoError(t, err)
+	assert.Equal(t, []clientTypes.HistoryEntry{
+		{Time: t0.Add(time.Hour), Kind: "event", Actor: "rpaas-operator", Action: "RpaasInstanceUpdated", Message: "scaled to 3 replicas"},
+		{Time: t0.Add(2 * time.Hour), Kind: "event", Actor: "rpaas-api", Action: "CertificateUpdated", Message: "certificate \"default\" updated"},
+	}, history)
+
+	_, err = manager.GetInstanceHistory(context.TODO(), "not-found")
+	assert.Equal(t, NotFoundError{Msg: "rpaas instance \"not-found\" not found"}, err)
+}
@@ -16,11 +16,14 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -50,6 +53,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/remotecommand"
 	watchtools "k8s.io/client-go/tools/watch"
+	utilexec "k8s.io/client-go/util/exec"
 	"k8s.io/kubectl/pkg/cmd/logs"
 	"k8s.io/kubectl/pkg/scheme"
 	"k8s.io/kubectl/pkg/util/interrupt"
@@ -71,6 +75,7 @@ const (
 
 	externalDNSHostnameLabel  = "external-dns.alpha.kubernetes.io/hostname"
 	allowedDNSZonesAnnotation = "rpaas.extensions.tsuru.io/allowed-dns-zones"
+	restartedAtAnnotation     = "rpaas.extensions.tsuru.io/restarted-at"
 
 	nginxContainerName = "nginx"
 )
@@ -86,21 +91,30 @@ var podAllowedReasonsToFail = map[string]bool{
 }
 
 type k8sRpaasManager struct {
-	cli          client.Client
-	cacheManager CacheManager
-	restConfig   *rest.Config
-	kcs          kubernetes.Interface
-	clusterName  string
-	poolName     string
+	cli                client.Client
+	cacheManager       CacheManager
+	statsManager       StatsManager
+	certificateManager CertificateManager
+	healthChecker      HealthChecker
+	backendChecker     BackendChecker
+	restConfig         *rest.Config
+	kcs                kubernetes.Interface
+	clusterName        string
+	poolName           string
+	serviceName        string
 }
 
 func NewK8S(cfg *rest.Config, k8sClient client.Client, clusterName string, poolName string) (RpaasManager, error) {
 	m := &k8sRpaasManager{
-		cli:          k8sClient,
-		cacheManager: nginxManager.NewNginxManager(),
-		restConfig:   cfg,
-		clusterName:  clusterName,
-		poolName:     poolName,
+		cli:                k8sClient,
+		cacheManager:       nginxManager.NewNginxManager(),
+		statsManager:       nginxManager.NewNginxManager(),
+		certificateManager: nginxManager.NewNginxManager(),
+		healthChecker:      nginxManager.NewNginxManager(),
+		backendChecker:     nginxManager.NewNginxManager(),
+		restConfig:         cfg,
+		clusterName:        clusterName,
+		poolName:           poolName,
 	}
 
 	if cfg == nil {
@@ -116,6 +130,19 @@ func NewK8S(cfg *rest.Config, k8sClient client.Client, clusterName string, poolN
 	return m, nil
 }
 
+// NewK8SForService is like NewK8S, but the manager looks for the instances
+// (and their plans and flavors) on the namespace of service, rather than on
+// the one of the service set on the API config.
+func NewK8SForService(cfg *rest.Config, k8sClient client.Client, service string) (RpaasManager, error) {
+	manager, err := NewK8S(cfg, k8sClient, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	manager.(*k8sRpaasManager).serviceName = service
+	return manager, nil
+}
+
 func keepAliveSpdyExecutor(config *rest.Config, method string, url *url.URL) (remotecommand.Executor, error) {
 	tlsConfig, err := rest.TLSConfigFor(config)
 	if err != nil {
@@ -448,7 +475,7 @@ func (m *k8sRpaasManager) CreateInstance(ctx context.Context, args CreateArgs) e
 	}
 
 	if config.Get().NamespacedInstances {
-		instance.Spec.PlanNamespace = getServiceName()
+		instance.Spec.PlanNamespace = m.getServiceName()
 	}
 
 	setDescription(instance, args.Description)
@@ -520,7 +547,7 @@ func (m *k8sRpaasManager) ensureNamespaceExists(ctx context.Context) (string, er
 	if poolNamespace != "" {
 		nsName = poolNamespace
 	} else {
-		nsName = getServiceName()
+		nsName = m.getServiceName()
 	}
 
 	ns := newNamespace(nsName)
@@ -558,6 +585,10 @@ func (m *k8sRpaasManager) ListBlocks(ctx context.Context, instanceName string) (
 		return nil, err
 	}
 
+	return m.blocksOf(ctx, instance)
+}
+
+func (m *k8sRpaasManager) blocksOf(ctx context.Context, instance *v1alpha1.RpaasInstance) ([]ConfigurationBlock, error) {
 	var blocks []ConfigurationBlock
 	for blockType, blockValue := range instance.Spec.Blocks {
 		content, err := util.GetValue(ctx, m.cli, instance.Namespace, &blockValue)
@@ -587,6 +618,17 @@ func (m *k8sRpaasManager) UpdateBlock(ctx context.Context, instanceName string,
 		return err
 	}
 
+	if block.IfVersion != "" {
+		blocks, err := m.blocksOf(ctx, instance)
+		if err != nil {
+			return err
+		}
+
+		if version := BlocksVersion(blocks); version != block.IfVersion {
+			return ConflictError{Msg: fmt.Sprintf("blocks were changed meanwhile (expected version %s, current one is %s)", block.IfVersion, version)}
+		}
+	}
+
 	if instance.Spec.Blocks == nil {
 		instance.Spec.Blocks = make(map[v1alpha1.BlockType]v1alpha1.Value)
 	}
@@ -594,7 +636,133 @@ func (m *k8sRpaasManager) UpdateBlock(ctx context.Context, instanceName string,
 	blockType := v1alpha1.BlockType(block.Name)
 	instance.Spec.Blocks[blockType] = v1alpha1.Value{Value: block.Content}
 
-	return m.patchInstance(ctx, originalInstance, instance)
+	if block.IfVersion == "" {
+		return m.patchInstance(ctx, originalInstance, instance)
+	}
+
+	// NOTE: the patch carries the resource version the blocks were checked
+	// on, so that changes made since then fail the patch rather than being
+	// overwritten.
+	err = m.cli.Patch(ctx, instance, client.MergeFromWithOptions(originalInstance, client.MergeFromWithOptimisticLock{}), patchOptions(ctx)...)
+	if k8sErrors.IsConflict(err) {
+		return ConflictError{Msg: fmt.Sprintf("blocks were changed meanwhile (expected version %s)", block.IfVersion), Internal: err}
+	}
+
+	return err
+}
+
+// ValidateBlock renders the NGINX configuration of the instance along with
+// block, then checks it with "nginx -t" on one of the instance pods, so that
+// the very NGINX build (and modules) of the instance is used.
+//
+// NOTE: the configuration is rendered out of the instance and its plan, so
+// what the flavors add to the instance isn't taken into account.
+func (m *k8sRpaasManager) ValidateBlock(ctx context.Context, instanceName string, block ConfigurationBlock) (*clientTypes.BlockValidation, error) {
+	if err := validateBlock(block); err != nil {
+		return nil, err
+	}
+
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	_, podMap, err := m.GetInstanceStatus(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]string, 0, len(podMap))
+	for name, podStatus := range podMap {
+		if podStatus.Running {
+			pods = append(pods, name)
+		}
+	}
+
+	if len(pods) == 0 {
+		return nil, ValidationError{Msg: fmt.Sprintf("no running pod of instance %q to validate the block on", instanceName)}
+	}
+
+	sort.Strings(pods)
+	result := &clientTypes.BlockValidation{Pod: pods[0]}
+
+	rendered, err := m.renderConfigurationWithBlock(ctx, instance, block)
+	if err != nil {
+		// NOTE: blocks are templates themselves, so failing to render them
+		// means they're invalid.
+		result.Output = err.Error()
+		return result, nil
+	}
+
+	var output bytes.Buffer
+	err = m.Exec(ctx, instanceName, ExecArgs{
+		Command: validateConfigurationCommand(),
+		CommonTerminalArgs: CommonTerminalArgs{
+			Pod:       result.Pod,
+			Container: nginxContainerName,
+			Stdin:     strings.NewReader(rendered),
+			Stdout:    &output,
+			Stderr:    io.Discard,
+		},
+	})
+
+	var exitErr utilexec.CodeExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+
+	result.Valid = err == nil
+	result.Output = validateConfigurationDirRegexp.ReplaceAllString(output.String(), "")
+	return result, nil
+}
+
+func (m *k8sRpaasManager) renderConfigurationWithBlock(ctx context.Context, instance *v1alpha1.RpaasInstance, block ConfigurationBlock) (string, error) {
+	plan, err := m.getPlan(ctx, instance.Spec.PlanName)
+	if err != nil {
+		return "", err
+	}
+
+	var blocks nginxManager.ConfigurationBlocks
+	if plan.Spec.Template != nil {
+		if blocks.MainBlock, err = util.GetValue(ctx, m.cli, "", plan.Spec.Template); err != nil {
+			return "", err
+		}
+	}
+
+	contents := map[v1alpha1.BlockType]string{v1alpha1.BlockType(block.Name): block.Content}
+	for blockType, blockValue := range instance.Spec.Blocks {
+		if _, found := contents[blockType]; found {
+			continue
+		}
+
+		if contents[blockType], err = util.GetValue(ctx, m.cli, instance.Namespace, &blockValue); err != nil {
+			return "", err
+		}
+	}
+
+	blocks.RootBlock = contents[v1alpha1.BlockTypeRoot]
+	blocks.HttpBlock = contents[v1alpha1.BlockTypeHTTP]
+	blocks.ServerBlock = contents[v1alpha1.BlockTypeServer]
+	blocks.LuaServerBlock = contents[v1alpha1.BlockTypeLuaServer]
+	blocks.LuaWorkerBlock = contents[v1alpha1.BlockTypeLuaWorker]
+
+	renderer, err := nginxManager.NewConfigurationRenderer(blocks)
+	if err != nil {
+		return "", err
+	}
+
+	return renderer.Render(nginxManager.ConfigurationData{Instance: instance, Config: &plan.Spec.Config})
+}
+
+var validateConfigurationDirRegexp = regexp.MustCompile(`/tmp/rpaas-validate\.[^/]+/`)
+
+// validateConfigurationCommand checks the configuration read from the stdin
+// with "nginx -t". It's placed in a directory mirroring /etc/nginx, so that
+// the relative paths (e.g. "include mime.types") are resolved just as on the
+// current configuration.
+func validateConfigurationCommand() []string {
+	script := `exec 2>&1; d=$(mktemp -d /tmp/rpaas-validate.XXXXXX) || exit; ln -s /etc/nginx/* "$d" && rm -f "$d/nginx.conf" && cat > "$d/nginx.conf" && nginx -t -c "$d/nginx.conf"; rc=$?; rm -rf "$d"; exit $rc`
+	return []string{"sh", "-c", script}
 }
 
 func (m *k8sRpaasManager) Scale(ctx context.Context, instanceName string, replicas int32) error {
@@ -615,6 +783,56 @@ func (m *k8sRpaasManager) Scale(ctx context.Context, instanceName string, replic
 	return m.patchInstance(ctx, originalInstance, instance)
 }
 
+func (m *k8sRpaasManager) Restart(ctx context.Context, instanceName string, args RestartArgs) ([]string, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	nginx, err := m.getNginx(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := m.getPods(ctx, nginx)
+	if err != nil {
+		return nil, err
+	}
+
+	var podNames []string
+	for _, pod := range pods {
+		podNames = append(podNames, pod.Name)
+	}
+
+	sort.Strings(podNames)
+
+	if args.Immediate {
+		for i := range pods {
+			if err = m.cli.Delete(ctx, &pods[i]); err != nil && !k8sErrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+
+		return podNames, nil
+	}
+
+	// NOTE: changing an annotation on pod template makes the underlying
+	// Deployment to perform a rolling update on the pods, the same trick used
+	// by "kubectl rollout restart".
+	originalInstance := instance.DeepCopy()
+	if instance.Spec.PodTemplate.Annotations == nil {
+		instance.Spec.PodTemplate.Annotations = make(map[string]string)
+	}
+
+	instance.Spec.PodTemplate.Annotations[restartedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+
+	if err = m.patchInstance(ctx, originalInstance, instance); err != nil {
+		return nil, err
+	}
+
+	return podNames, nil
+}
+
 func (m *k8sRpaasManager) GetCertificates(ctx context.Context, instanceName string) ([]CertificateData, error) {
 	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
@@ -629,9 +847,10 @@ func (m *k8sRpaasManager) GetCertificates(ctx context.Context, instanceName stri
 		}
 
 		certList = append(certList, CertificateData{
-			Name:        s.Labels[certificates.CertificateNameLabel],
-			Certificate: string(s.Data[corev1.TLSCertKey]),
-			Key:         string(s.Data[corev1.TLSPrivateKeyKey]),
+			Name:         s.Labels[certificates.CertificateNameLabel],
+			Certificate:  string(s.Data[corev1.TLSCertKey]),
+			Key:          string(s.Data[corev1.TLSPrivateKeyKey]),
+			OCSPStapling: slices.Contains(instance.Spec.OCSPStapling, tls.SecretName),
 		})
 	}
 
@@ -659,6 +878,21 @@ func (m *k8sRpaasManager) DeleteCertificate(ctx context.Context, instanceName, n
 }
 
 func (m *k8sRpaasManager) UpdateCertificate(ctx context.Context, instanceName, name string, c tls.Certificate) error {
+	return m.updateCertificate(ctx, instanceName, name, "", c)
+}
+
+// UpdateRotatingCertificate updates a certificate taking part in a rotation,
+// which may share its DNS names with its counterpart, i.e. the certificate
+// being rotated or the temporary one holding its replacement.
+func (m *k8sRpaasManager) UpdateRotatingCertificate(ctx context.Context, instanceName, name, counterpart string, c tls.Certificate) error {
+	if !clientTypes.IsCertificateRotation(certificateName(name), certificateName(counterpart)) {
+		return &ValidationError{Msg: fmt.Sprintf("certificate %q is not the rotation counterpart of %q", counterpart, certificateName(name))}
+	}
+
+	return m.updateCertificate(ctx, instanceName, name, certificateName(counterpart), c)
+}
+
+func (m *k8sRpaasManager) updateCertificate(ctx context.Context, instanceName, name, counterpart string, c tls.Certificate) error {
 	instance, err := m.GetInstance(ctx, instanceName)
 	if err != nil {
 		return err
@@ -689,7 +923,7 @@ func (m *k8sRpaasManager) UpdateCertificate(ctx context.Context, instanceName, n
 	}
 
 	for _, ci := range certsInfo {
-		if ci.Name == name {
+		if ci.Name == name || (counterpart != "" && ci.Name == counterpart) {
 			continue
 		}
 
@@ -701,6 +935,22 @@ func (m *k8sRpaasManager) UpdateCertificate(ctx context.Context, instanceName, n
 	return certificates.UpdateCertificate(ctx, m.cli, instance, name, rawCertificate, rawKey)
 }
 
+func (m *k8sRpaasManager) SetCertificateOCSPStapling(ctx context.Context, instanceName, name string, enabled bool) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	name = certificateName(name)
+
+	err = certificates.SetOCSPStapling(ctx, m.cli, instance, name, enabled)
+	if err != nil && err.Error() == fmt.Sprintf("certificate %q does not exist", name) {
+		return &NotFoundError{Msg: err.Error()}
+	}
+
+	return err
+}
+
 func (m *k8sRpaasManager) GetInstanceAddress(ctx context.Context, name string) (string, error) {
 	instance, err := m.GetInstance(ctx, name)
 	if err != nil {
@@ -749,7 +999,7 @@ func (m *k8sRpaasManager) GetInstance(ctx context.Context, name string) (*v1alph
 			return &instance, nil
 		}
 	}
-	err = m.cli.Get(ctx, types.NamespacedName{Name: name, Namespace: getServiceName()}, &instance)
+	err = m.cli.Get(ctx, types.NamespacedName{Name: name, Namespace: m.getServiceName()}, &instance)
 	if err != nil && k8sErrors.IsNotFound(err) {
 		return nil, NotFoundError{Msg: fmt.Sprintf("rpaas instance %q not found", name)}
 	}
@@ -763,7 +1013,7 @@ func (m *k8sRpaasManager) GetInstance(ctx context.Context, name string) (*v1alph
 
 func (m *k8sRpaasManager) GetPlans(ctx context.Context) ([]Plan, error) {
 	var planList v1alpha1.RpaasPlanList
-	if err := m.cli.List(ctx, &planList, client.InNamespace(getServiceName())); err != nil {
+	if err := m.cli.List(ctx, &planList, client.InNamespace(m.getServiceName())); err != nil {
 		return nil, err
 	}
 
@@ -818,12 +1068,49 @@ func (m *k8sRpaasManager) GetFlavors(ctx context.Context) ([]Flavor, error) {
 	return result, nil
 }
 
+func (m *k8sRpaasManager) GetFlavor(ctx context.Context, name string) (*FlavorInfo, error) {
+	var flavor v1alpha1.RpaasFlavor
+	err := m.cli.Get(ctx, types.NamespacedName{Name: name, Namespace: m.getServiceName()}, &flavor)
+	if k8sErrors.IsNotFound(err) {
+		return nil, NotFoundError{Msg: fmt.Sprintf("flavor %q not found", name)}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &FlavorInfo{
+		Name:                flavor.Name,
+		Description:         flavor.Spec.Description,
+		Default:             flavor.Spec.Default,
+		CreationOnly:        flavor.Spec.CreationOnly,
+		IncompatibleFlavors: flavor.Spec.IncompatibleFlavors,
+		InstanceTemplate:    flavor.Spec.InstanceTemplate,
+	}, nil
+}
+
+func (m *k8sRpaasManager) UpdateFlavors(ctx context.Context, instanceName string, flavors []string) error {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	if err = m.validateFlavors(ctx, instance, flavors); err != nil {
+		return err
+	}
+
+	originalInstance := instance.DeepCopy()
+	instance.Spec.Flavors = flavors
+
+	return m.patchInstance(ctx, originalInstance, instance)
+}
+
 func (m *k8sRpaasManager) poolNamespace() (string, error) {
 	if config.Get().NamespacedInstances {
 		if m.poolName == "" {
 			return "", ErrNoPoolDefined
 		}
-		return fmt.Sprintf("%s-%s", getServiceName(), m.poolName), nil
+		return fmt.Sprintf("%s-%s", m.getServiceName(), m.poolName), nil
 	}
 
 	return "", nil
@@ -831,7 +1118,7 @@ func (m *k8sRpaasManager) poolNamespace() (string, error) {
 
 func (m *k8sRpaasManager) getFlavors(ctx context.Context) ([]v1alpha1.RpaasFlavor, error) {
 	flavorList := &v1alpha1.RpaasFlavorList{}
-	if err := m.cli.List(ctx, flavorList, client.InNamespace(getServiceName())); err != nil {
+	if err := m.cli.List(ctx, flavorList, client.InNamespace(m.getServiceName())); err != nil {
 		return nil, err
 	}
 
@@ -929,32 +1216,393 @@ func (m *k8sRpaasManager) UnbindApp(ctx context.Context, instanceName, appName s
 	return m.patchInstance(ctx, originalInstance, instance)
 }
 
+func (m *k8sRpaasManager) GetBindsStatus(ctx context.Context, instanceName string) ([]clientTypes.BindStatus, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]clientTypes.BindStatus, len(instance.Spec.Binds))
+
+	var wg sync.WaitGroup
+	for i, bind := range instance.Spec.Binds {
+		wg.Add(1)
+		go func(i int, bind v1alpha1.Bind) {
+			defer wg.Done()
+
+			bs := clientTypes.BindStatus{App: bind.Name, Address: bind.Host, Healthy: true}
+			if err := m.backendChecker.CheckBackend(bind.Host); err != nil {
+				bs.Healthy, bs.Error = false, err.Error()
+			}
+
+			result[i] = bs
+		}(i, bind)
+	}
+
+	wg.Wait()
+
+	return result, nil
+}
+
 func (m *k8sRpaasManager) PurgeCache(ctx context.Context, instanceName string, args PurgeCacheArgs) (int, error) {
-	nginx, podMap, err := m.GetInstanceStatus(ctx, instanceName)
+	results, err := m.purgeCache(ctx, instanceName, args)
 	if err != nil {
 		return 0, err
 	}
+
+	var purgeErrors error
+	purgeCount := 0
+	for _, r := range results {
+		if r.err != nil {
+			purgeErrors = multierror.Append(purgeErrors, fmt.Errorf("pod %s failed: %w", r.address, r.err))
+			continue
+		}
+		if r.purged {
+			purgeCount++
+		}
+	}
+	return purgeCount, purgeErrors
+}
+
+func (m *k8sRpaasManager) PurgeCacheOnPods(ctx context.Context, instanceName string, args PurgeCacheArgs) ([]PurgeCachePodResult, error) {
+	results, err := m.purgeCache(ctx, instanceName, args)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]PurgeCachePodResult, 0, len(results))
+	for _, r := range results {
+		pod := PurgeCachePodResult{Pod: r.pod, Purged: r.purged, Objects: r.objects}
+		if r.err != nil {
+			pod.Error = r.err.Error()
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+type podPurgeResult struct {
+	pod     string
+	address string
+	purged  bool
+	objects int
+	err     error
+}
+
+func (m *k8sRpaasManager) purgeCache(ctx context.Context, instanceName string, args PurgeCacheArgs) ([]podPurgeResult, error) {
+	nginx, podMap, err := m.GetInstanceStatus(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
 	if args.Path == "" {
-		return 0, ValidationError{Msg: "path is required"}
+		return nil, ValidationError{Msg: "path is required"}
+	}
+	if nginx.Spec.Cache.Path == "" {
+		return nil, ValidationError{Msg: fmt.Sprintf("cache is not enabled on instance %q", instanceName)}
+	}
+	if args.PathRegexp {
+		if _, err = regexp.CompilePOSIX(args.Path); err != nil {
+			return nil, ValidationError{Msg: fmt.Sprintf("path is not a valid regular expression: %v", err)}
+		}
+	}
+
+	pods := make([]string, 0, len(podMap))
+	for name := range podMap {
+		pods = append(pods, name)
 	}
+	sort.Strings(pods)
+
 	port := util.PortByName(nginx.Spec.PodTemplate.Ports, nginxManager.PortNameManagement)
-	var purgeErrors error
-	purgeCount := 0
-	status := false
-	for _, podStatus := range podMap {
+	var results []podPurgeResult
+	for _, name := range pods {
+		podStatus := podMap[name]
 		if !podStatus.Running {
 			continue
 		}
-		status, err = m.cacheManager.PurgeCache(podStatus.Address, args.Path, port, args.PreservePath, args.ExtraHeaders)
+		r := podPurgeResult{pod: name, address: podStatus.Address}
+		if args.PathRegexp {
+			r.objects, r.err = m.purgeCacheByRegexp(ctx, instanceName, name, nginx.Spec.Cache.Path, args.Path)
+			r.purged = r.objects > 0
+		} else {
+			r.purged, r.err = m.cacheManager.PurgeCache(podStatus.Address, args.Path, port, args.PreservePath, args.ExtraHeaders)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// purgeCacheByRegexp removes the cached objects whose keys match expr from the
+// pod, returning how many of them were removed. NGINX has no way to purge by
+// regular expression, so the cache files are looked up by their "KEY: "
+// header line.
+func (m *k8sRpaasManager) purgeCacheByRegexp(ctx context.Context, instanceName, pod, cachePath, expr string) (int, error) {
+	var stdout, stderr bytes.Buffer
+	err := m.Exec(ctx, instanceName, ExecArgs{
+		Command: purgeCacheByRegexpCommand(cachePath, expr),
+		CommonTerminalArgs: CommonTerminalArgs{
+			Pod:       pod,
+			Container: nginxContainerName,
+			Stdout:    &stdout,
+			Stderr:    &stderr,
+		},
+	})
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return 0, fmt.Errorf("%w: %s", err, msg)
+		}
+		return 0, err
+	}
+	return strings.Count(stdout.String(), "\n"), nil
+}
+
+func purgeCacheByRegexpCommand(cachePath, expr string) []string {
+	// NOTE: arguments are passed as positional parameters so that the
+	// expression is never interpreted by the shell.
+	script := `grep -rlsaE -e "$1" "$2" | while IFS= read -r f; do rm -f "$f" && echo "$f"; done`
+	return []string{"sh", "-c", script, "purge", cacheKeyRegexp(expr), fmt.Sprintf("%s/nginx", cachePath)}
+}
+
+// cacheKeyRegexp returns the expression matching the "KEY: " line of the
+// cached objects whose path matches expr. The keys start with the scheme and
+// host (e.g. "http://app.example.com/index.html" or "http/index.html"), so a
+// leading ^ anchors expr right after them, at the start of the path.
+func cacheKeyRegexp(expr string) string {
+	if rest, ok := strings.CutPrefix(expr, "^"); ok {
+		return fmt.Sprintf("^KEY: ([a-z]+://[^/]*|[^/]*)(%s)", rest)
+	}
+
+	return fmt.Sprintf("^KEY: .*(%s)", expr)
+}
+
+func (m *k8sRpaasManager) GetConnectionStats(ctx context.Context, instanceName string) ([]clientTypes.PodConnectionStats, error) {
+	nginx, podMap, err := m.GetInstanceStatus(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	port := util.PortByName(nginx.Spec.PodTemplate.Ports, nginxManager.PortNameManagement)
+
+	return fanOutRunningPods(podMap, func(name string) clientTypes.PodConnectionStats {
+		return clientTypes.PodConnectionStats{Pod: name, Error: "pod is not running"}
+	}, func(name string, podStatus PodStatus) clientTypes.PodConnectionStats {
+		ps := clientTypes.PodConnectionStats{Pod: name}
+		stats, err := m.statsManager.ConnectionStats(podStatus.Address, port)
 		if err != nil {
-			purgeErrors = multierror.Append(purgeErrors, fmt.Errorf("pod %s failed: %w", podStatus.Address, err))
+			ps.Error = err.Error()
+			return ps
+		}
+
+		ps.Stats = &clientTypes.ConnectionStats{
+			Active:  stats.Active,
+			Reading: stats.Reading,
+			Writing: stats.Writing,
+			Waiting: stats.Waiting,
+		}
+		return ps
+	}), nil
+}
+
+func (m *k8sRpaasManager) GetPodsHealth(ctx context.Context, instanceName string) ([]clientTypes.PodHealth, error) {
+	nginx, podMap, err := m.GetInstanceStatus(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	port := util.PortByName(nginx.Spec.PodTemplate.Ports, nginxManager.PortNameHTTP)
+
+	return fanOutRunningPods(podMap, func(name string) clientTypes.PodHealth {
+		return clientTypes.PodHealth{Pod: name, Error: "pod is not running"}
+	}, func(name string, podStatus PodStatus) clientTypes.PodHealth {
+		ph := clientTypes.PodHealth{Pod: name, Healthy: true}
+		if err := m.healthChecker.Healthcheck(podStatus.Address, port); err != nil {
+			ph.Healthy, ph.Error = false, err.Error()
+		}
+		return ph
+	}), nil
+}
+
+// fanOutRunningPods calls fn concurrently on every running pod and notRunning
+// on the remaining ones, returning the results sorted by pod name.
+func fanOutRunningPods[T any](podMap PodStatusMap, notRunning func(name string) T, fn func(name string, podStatus PodStatus) T) []T {
+	names := make([]string, 0, len(podMap))
+	for name := range podMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]T, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		podStatus := podMap[name]
+		if !podStatus.Running {
+			result[i] = notRunning(name)
 			continue
 		}
-		if status {
-			purgeCount++
+
+		wg.Add(1)
+		go func(i int, name string, podStatus PodStatus) {
+			defer wg.Done()
+			result[i] = fn(name, podStatus)
+		}(i, name, podStatus)
+	}
+
+	wg.Wait()
+
+	return result
+}
+
+func (m *k8sRpaasManager) GetPodsUsage(ctx context.Context, instanceName string) ([]clientTypes.PodUsage, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	nginx, err := m.getNginx(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := m.getPods(ctx, nginx)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: missing metrics are reported per pod rather than failing the
+	// whole request, since metrics-server is not always available.
+	podMetrics, err := m.getPodMetrics(ctx, nginx)
+	if err != nil {
+		logrus.Errorf("Failed to fetch pod metrics of instance %s: %s", instanceName, err.Error())
+	}
+
+	result := make([]clientTypes.PodUsage, 0, len(pods))
+	for _, pod := range pods {
+		if podIsAllowedToFail(pod) {
+			continue
 		}
+
+		result = append(result, clientTypes.PodUsage{Pod: pod.Name, Metrics: podMetrics[pod.Name]})
 	}
-	return purgeCount, purgeErrors
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Pod < result[j].Pod })
+
+	return result, nil
+}
+
+func (m *k8sRpaasManager) ListPods(ctx context.Context, instanceName, selector string) ([]clientTypes.Pod, error) {
+	ls, err := labels.Parse(selector)
+	if err != nil {
+		return nil, &ValidationError{Msg: fmt.Sprintf("invalid pod selector %q: %s", selector, err)}
+	}
+
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	nginx, err := m.getNginx(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := m.getPods(ctx, nginx)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: only running pods are listed since the ones in other phases
+	// cannot run commands (see Exec).
+	result := make([]clientTypes.Pod, 0, len(pods))
+	for i := range pods {
+		if pods[i].Status.Phase != corev1.PodRunning || pods[i].DeletionTimestamp != nil || !ls.Matches(labels.Set(pods[i].Labels)) {
+			continue
+		}
+
+		pod, err := m.newPodStatus(ctx, &pods[i])
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, pod)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
+
+func (m *k8sRpaasManager) GetCertificateStatus(ctx context.Context, instanceName, name string) ([]clientTypes.PodCertificateStatus, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	name = certificateName(name)
+
+	var hosts []string
+	found := false
+	for _, tls := range instance.Spec.TLS {
+		var s corev1.Secret
+		if err = m.cli.Get(ctx, types.NamespacedName{Name: tls.SecretName, Namespace: instance.Namespace}, &s); err != nil {
+			return nil, err
+		}
+
+		if s.Labels[certificates.CertificateNameLabel] == name {
+			hosts, found = tls.Hosts, true
+			break
+		}
+	}
+
+	if !found {
+		return nil, &NotFoundError{Msg: fmt.Sprintf("certificate %q does not exist", name)}
+	}
+
+	// NOTE: NGINX picks the certificate by SNI, so any of its hosts does the
+	// job. Wildcards are replaced by an arbitrary label to match them.
+	var serverName string
+	if len(hosts) > 0 {
+		serverName = strings.Replace(hosts[0], "*", "rpaas", 1)
+	}
+
+	nginx, podMap, err := m.GetInstanceStatus(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	port := util.PortByName(nginx.Spec.PodTemplate.Ports, nginxManager.PortNameHTTPS)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	result := make([]clientTypes.PodCertificateStatus, 0, len(podMap))
+	for podName, podStatus := range podMap {
+		// pods not running serve no certificate at all, so they're left out
+		if !podStatus.Running {
+			continue
+		}
+
+		wg.Add(1)
+		go func(podName string, podStatus PodStatus) {
+			defer wg.Done()
+
+			ps := clientTypes.PodCertificateStatus{Pod: podName}
+			fingerprints, err := m.certificateManager.CertificateFingerprints(podStatus.Address, port, serverName)
+			if err != nil {
+				ps.Error = err.Error()
+			} else {
+				ps.Fingerprints = fingerprints
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			result = append(result, ps)
+		}(podName, podStatus)
+	}
+
+	wg.Wait()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Pod < result[j].Pod })
+
+	return result, nil
 }
 
 func (m *k8sRpaasManager) DeleteRoute(ctx context.Context, instanceName, path string) error {
@@ -991,15 +1639,16 @@ func (m *k8sRpaasManager) GetRoutes(ctx context.Context, instanceName string) ([
 			}
 		}
 
-		if location.Destination == "" && content == "" {
+		if location.Destination == "" && len(location.Destinations) == 0 && content == "" {
 			continue
 		}
 
 		routes = append(routes, Route{
-			Path:        location.Path,
-			Destination: location.Destination,
-			HTTPSOnly:   location.ForceHTTPS,
-			Content:     content,
+			Path:         location.Path,
+			Destination:  location.Destination,
+			Destinations: location.Destinations,
+			HTTPSOnly:    location.ForceHTTPS,
+			Content:      content,
 		})
 	}
 
@@ -1023,10 +1672,11 @@ func (m *k8sRpaasManager) UpdateRoute(ctx context.Context, instanceName string,
 	}
 
 	newLocation := v1alpha1.Location{
-		Path:        route.Path,
-		Destination: route.Destination,
-		ForceHTTPS:  route.HTTPSOnly,
-		Content:     content,
+		Path:         route.Path,
+		Destination:  route.Destination,
+		Destinations: route.Destinations,
+		ForceHTTPS:   route.HTTPSOnly,
+		Content:      content,
 	}
 
 	if index, found := hasPath(*instance, route.Path); found {
@@ -1082,6 +1732,10 @@ func validateRoute(r Route) error {
 		return &ValidationError{Msg: "invalid path format"}
 	}
 
+	if len(r.Destinations) > 0 {
+		return validateWeightedDestinations(r)
+	}
+
 	if r.Content == "" && r.Destination == "" {
 		return &ValidationError{Msg: "either content or destination are required"}
 	}
@@ -1104,6 +1758,49 @@ func validateRoute(r Route) error {
 	return nil
 }
 
+func validateWeightedDestinations(r Route) error {
+	if r.Content != "" || r.Destination != "" {
+		return &ValidationError{Msg: "cannot set destinations along with content or destination"}
+	}
+
+	if len(r.Destinations) < 2 {
+		return &ValidationError{Msg: "weighted destinations require at least two destinations"}
+	}
+
+	var total int64
+	seen := make(map[string]bool)
+	for _, d := range r.Destinations {
+		if d.Destination == "" {
+			return &ValidationError{Msg: "destination of weighted destinations cannot be empty"}
+		}
+
+		if strings.ContainsAny(d.Destination, " \t\r\n;'\"{}") {
+			return &ValidationError{Msg: fmt.Sprintf("invalid destination %q", d.Destination)}
+		}
+
+		if d.Weight <= 0 {
+			return &ValidationError{Msg: fmt.Sprintf("weight of destination %q must be a positive integer", d.Destination)}
+		}
+
+		if seen[d.Destination] {
+			return &ValidationError{Msg: fmt.Sprintf("destination %q is duplicated", d.Destination)}
+		}
+
+		seen[d.Destination] = true
+		total += int64(d.Weight)
+	}
+
+	// NOTE: the traffic is split by percentages with two decimal places, see
+	// the NGINX configuration.
+	for _, d := range r.Destinations {
+		if int64(d.Weight)*10000 < total {
+			return &ValidationError{Msg: fmt.Sprintf("weight of destination %q is too small, it must take at least 0.01%% of the requests", d.Destination)}
+		}
+	}
+
+	return nil
+}
+
 func (m *k8sRpaasManager) getPlan(ctx context.Context, name string) (*v1alpha1.RpaasPlan, error) {
 	if name == "" {
 		return m.getDefaultPlan(ctx)
@@ -1111,7 +1808,7 @@ func (m *k8sRpaasManager) getPlan(ctx context.Context, name string) (*v1alpha1.R
 
 	planName := types.NamespacedName{
 		Name:      name,
-		Namespace: getServiceName(),
+		Namespace: m.getServiceName(),
 	}
 	var plan v1alpha1.RpaasPlan
 	if err := m.cli.Get(ctx, planName, &plan); err != nil {
@@ -1480,6 +2177,16 @@ func labelKey(name string) string {
 	return fmt.Sprintf("%s/%s", defaultKeyLabelPrefix, name)
 }
 
+// getServiceName returns the service the manager was made for, see
+// NewK8SForService.
+func (m *k8sRpaasManager) getServiceName() string {
+	if m.serviceName != "" {
+		return m.serviceName
+	}
+
+	return getServiceName()
+}
+
 func getServiceName() string {
 	serviceName := config.Get().ServiceName
 	if serviceName == "" {
@@ -2045,6 +2752,15 @@ func (m *k8sRpaasManager) getPodStatuses(ctx context.Context, nginx *nginxv1alph
 		}
 	}
 
+	nodeZones, err := m.getNodeZones(ctx, pods)
+	if err != nil {
+		if m.clusterName == "" {
+			logrus.Errorf("[local cluster] Failed to fetch node zones: %s", err.Error())
+		} else {
+			logrus.Errorf("[cluster %s] Failed to fetch node zones: %s", m.clusterName, err.Error())
+		}
+	}
+
 	var podStatuses []clientTypes.Pod
 	for _, pod := range pods {
 		if podIsAllowedToFail(pod) {
@@ -2055,6 +2771,7 @@ func (m *k8sRpaasManager) getPodStatuses(ctx context.Context, nginx *nginxv1alph
 			return nil, err
 		}
 		ps.Metrics = podMetrics[pod.ObjectMeta.Name]
+		ps.Zone = nodeZones[pod.Spec.NodeName]
 		podStatuses = append(podStatuses, ps)
 	}
 
@@ -2065,6 +2782,36 @@ func (m *k8sRpaasManager) getPodStatuses(ctx context.Context, nginx *nginxv1alph
 	return podStatuses, nil
 }
 
+// getNodeZones returns the availability zone of each node where the pods are
+// scheduled, indexed by node name.
+func (m *k8sRpaasManager) getNodeZones(ctx context.Context, pods []corev1.Pod) (map[string]string, error) {
+	zones := make(map[string]string)
+	for _, pod := range pods {
+		nodeName := pod.Spec.NodeName
+		if nodeName == "" {
+			continue
+		}
+
+		if _, found := zones[nodeName]; found {
+			continue
+		}
+
+		var node corev1.Node
+		if err := m.cli.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
+			return zones, err
+		}
+
+		zone, found := node.Labels[corev1.LabelTopologyZone]
+		if !found {
+			zone = node.Labels[corev1.LabelFailureDomainBetaZone]
+		}
+
+		zones[nodeName] = zone
+	}
+
+	return zones, nil
+}
+
 func (m *k8sRpaasManager) newPodStatus(ctx context.Context, pod *corev1.Pod) (clientTypes.Pod, error) {
 	phase := pod.Status.Phase
 	if phase == "" {
@@ -2102,8 +2849,10 @@ func (m *k8sRpaasManager) newPodStatus(ctx context.Context, pod *corev1.Pod) (cl
 		Name:         pod.Name,
 		IP:           pod.Status.PodIP,
 		HostIP:       pod.Status.HostIP,
+		NodeName:     pod.Spec.NodeName,
 		Status:       string(phase),
 		Ports:        getPortsForPod(pod),
+		Containers:   getContainerNamesForPod(pod),
 		Errors:       errors,
 		Restarts:     restarts,
 		Ready:        ready,
@@ -2162,6 +2911,14 @@ func getPortsForPod(pod *corev1.Pod) []clientTypes.PodPort {
 	return ports
 }
 
+func getContainerNamesForPod(pod *corev1.Pod) []string {
+	var names []string
+	for _, container := range pod.Spec.Containers {
+		names = append(names, container.Name)
+	}
+	return names
+}
+
 func (m *k8sRpaasManager) getErrorsForPod(ctx context.Context, pod *corev1.Pod) ([]clientTypes.PodError, error) {
 	for _, cs := range pod.Status.ContainerStatuses {
 		if cs.Name == nginxContainerName && cs.State.Running != nil {
@@ -2246,7 +3003,18 @@ func (m *k8sRpaasManager) patchInstance(ctx context.Context, originalInstance *v
 		return err
 	}
 
-	return m.cli.Patch(ctx, originalInstance, client.RawPatch(types.MergePatchType, data))
+	return m.cli.Patch(ctx, originalInstance, client.RawPatch(types.MergePatchType, data), patchOptions(ctx)...)
+}
+
+// patchOptions returns the options of the patches made through ctx, so that
+// their fields are owned by the field manager of the request (e.g. the one
+// the plugin sends), see ContextWithFieldManager.
+func patchOptions(ctx context.Context) []client.PatchOption {
+	if fieldManager := FieldManagerFromContext(ctx); fieldManager != "" {
+		return []client.PatchOption{client.FieldOwner(fieldManager)}
+	}
+
+	return nil
 }
 
 func buildServiceInstanceParametersForPlan(flavors []Flavor) interface{} {
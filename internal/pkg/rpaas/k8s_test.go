@@ -11,7 +11,12 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -100,6 +105,50 @@ func (f fakeCacheManager) PurgeCache(host, path string, port int32, preservePath
 	return false, nil
 }
 
+type fakeStatsManager struct {
+	connectionStatsFunc func(host string, port int32) (nginxManager.ConnectionStats, error)
+}
+
+func (f fakeStatsManager) ConnectionStats(host string, port int32) (nginxManager.ConnectionStats, error) {
+	if f.connectionStatsFunc != nil {
+		return f.connectionStatsFunc(host, port)
+	}
+	return nginxManager.ConnectionStats{}, nil
+}
+
+type fakeCertificateManager struct {
+	certificateFingerprintsFunc func(host string, port int32, serverName string) ([]string, error)
+}
+
+func (f fakeCertificateManager) CertificateFingerprints(host string, port int32, serverName string) ([]string, error) {
+	if f.certificateFingerprintsFunc != nil {
+		return f.certificateFingerprintsFunc(host, port, serverName)
+	}
+	return nil, nil
+}
+
+type fakeHealthChecker struct {
+	healthcheckFunc func(host string, port int32) error
+}
+
+func (f fakeHealthChecker) Healthcheck(host string, port int32) error {
+	if f.healthcheckFunc != nil {
+		return f.healthcheckFunc(host, port)
+	}
+	return nil
+}
+
+type fakeBackendChecker struct {
+	checkBackendFunc func(address string) error
+}
+
+func (f fakeBackendChecker) CheckBackend(address string) error {
+	if f.checkBackendFunc != nil {
+		return f.checkBackendFunc(address)
+	}
+	return nil
+}
+
 func Test_k8sRpaasManager_DeleteBlock(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -315,6 +364,49 @@ func Test_k8sRpaasManager_UpdateBlock(t *testing.T) {
 				}, instance.Spec.Blocks)
 			},
 		},
+		{
+			name: "when blocks are still at the expected version",
+			resources: func() []runtime.Object {
+				instance := newEmptyRpaasInstance()
+				instance.Spec.Blocks = map[v1alpha1.BlockType]v1alpha1.Value{
+					v1alpha1.BlockTypeRoot: {Value: "# some old root configuration"},
+				}
+				return []runtime.Object{instance}
+			},
+			instance: "my-instance",
+			block: ConfigurationBlock{
+				Name:      "http",
+				Content:   "# my custom http configuration",
+				IfVersion: BlocksVersion([]ConfigurationBlock{{Name: "root", Content: "# some old root configuration"}}),
+			},
+			assertion: func(t *testing.T, err error, instance *v1alpha1.RpaasInstance) {
+				require.NoError(t, err)
+				assert.Equal(t, map[v1alpha1.BlockType]v1alpha1.Value{
+					v1alpha1.BlockTypeHTTP: {Value: "# my custom http configuration"},
+					v1alpha1.BlockTypeRoot: {Value: "# some old root configuration"},
+				}, instance.Spec.Blocks)
+			},
+		},
+		{
+			name: "when blocks were changed since the expected version",
+			resources: func() []runtime.Object {
+				instance := newEmptyRpaasInstance()
+				instance.Spec.Blocks = map[v1alpha1.BlockType]v1alpha1.Value{
+					v1alpha1.BlockTypeRoot: {Value: "# some newer root configuration"},
+				}
+				return []runtime.Object{instance}
+			},
+			instance: "my-instance",
+			block: ConfigurationBlock{
+				Name:      "root",
+				Content:   "# my custom root configuration",
+				IfVersion: BlocksVersion([]ConfigurationBlock{{Name: "root", Content: "# some old root configuration"}}),
+			},
+			assertion: func(t *testing.T, err error, _ *v1alpha1.RpaasInstance) {
+				require.Error(t, err)
+				assert.True(t, IsConflictError(err))
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -344,6 +436,7 @@ func Test_k8sRpaasManager_GetCertificates(t *testing.T) {
 				TLS: []nginxv1alpha1.NginxTLS{
 					{SecretName: "my-instance-certs-abc123"},
 				},
+				OCSPStapling: []string{"my-instance-certs-abc123"},
 			},
 		},
 
@@ -472,6 +565,7 @@ chxTez6BxMi3zHR6uEgL5Yv/yfnOldoq1RK1XaChNix+QnLBy2ZZbLkd6P8tEtsd
 WE9pct0+193ace/J7fECQQDAhwHBpJjhM+k97D92akneKXIUBo+Egr5E5qF9/g5I
 sM5FaDCEIJVbWjPDluxUGbVOQlFHsJs+pZv0Anf9DPwU
 -----END RSA PRIVATE KEY-----`,
+					OCSPStapling: true,
 				},
 			},
 		},
@@ -657,6 +751,92 @@ JUNDAKEYJUNDAKEYJUNDAKEY
 	}
 }
 
+func Test_k8sRpaasManager_SetCertificateOCSPStapling(t *testing.T) {
+	instance1 := newEmptyRpaasInstance()
+	instance1.Spec.TLS = []nginxv1alpha1.NginxTLS{
+		{SecretName: "my-instance-certs-01"},
+		{SecretName: "my-instance-certs-02"},
+	}
+	instance1.Spec.OCSPStapling = []string{"my-instance-certs-02"}
+
+	secret1 := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-instance-certs-01",
+			Namespace: instance1.Namespace,
+			Labels: map[string]string{
+				"rpaas.extensions.tsuru.io/certificate-name": "default",
+				"rpaas.extensions.tsuru.io/instance-name":    "my-instance",
+			},
+		},
+	}
+
+	secret2 := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-instance-certs-02",
+			Namespace: instance1.Namespace,
+			Labels: map[string]string{
+				"rpaas.extensions.tsuru.io/certificate-name": "www.example.com",
+				"rpaas.extensions.tsuru.io/instance-name":    "my-instance",
+			},
+		},
+	}
+
+	resources := []runtime.Object{instance1, secret1, secret2}
+
+	tests := map[string]struct {
+		instanceName  string
+		certName      string
+		enabled       bool
+		expected      []string
+		expectedError string
+	}{
+		"instance not found": {
+			instanceName:  "instance-not-found",
+			expectedError: "rpaas instance \"instance-not-found\" not found",
+		},
+
+		"certificate not found": {
+			instanceName:  "my-instance",
+			certName:      "not-found",
+			enabled:       true,
+			expectedError: "certificate \"not-found\" does not exist",
+		},
+
+		"enabling on the default certificate": {
+			instanceName: "my-instance",
+			enabled:      true,
+			expected:     []string{"my-instance-certs-02", "my-instance-certs-01"},
+		},
+
+		"disabling on a certificate": {
+			instanceName: "my-instance",
+			certName:     "www.example.com",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := fake.NewClientBuilder().
+				WithScheme(newScheme()).
+				WithRuntimeObjects(resources...).
+				Build()
+
+			err := (&k8sRpaasManager{cli: client}).SetCertificateOCSPStapling(context.TODO(), tt.instanceName, tt.certName, tt.enabled)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+
+			var instance v1alpha1.RpaasInstance
+			err = client.Get(context.TODO(), types.NamespacedName{Name: tt.instanceName, Namespace: getServiceName()}, &instance)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, instance.Spec.OCSPStapling)
+		})
+	}
+}
+
 func Test_k8sRpaasManager_UpdateCertificate(t *testing.T) {
 	ecdsaCertPem := `-----BEGIN CERTIFICATE-----
 MIIBhTCCASugAwIBAgIQIRi6zePL6mKjOipn+dNuaTAKBggqhkjOPQQDAjASMRAw
@@ -774,6 +954,7 @@ x2cJyBkkBQV9WB34oGtnZzQ0nKtzsY6FVlNGSeyCJ3OD2dHXO5komJY=
 	tests := map[string]struct {
 		instanceName    string
 		certificateName string
+		rotationOf      string
 		certificate     tls.Certificate
 		expectedError   string
 		assert          func(t *testing.T, c client.Client)
@@ -806,6 +987,34 @@ x2cJyBkkBQV9WB34oGtnZzQ0nKtzsY6FVlNGSeyCJ3OD2dHXO5komJY=
 			expectedError:   `certificate DNS name is forbidden: you cannot use a already used dns name, currently in use use in "default" certificate`,
 		},
 
+		"adding a certificate named like a rotation without rotating it": {
+			instanceName:    "my-instance-2",
+			certificateName: "default-rotate",
+			certificate:     rsaCertificate,
+			expectedError:   `certificate DNS name is forbidden: you cannot use a already used dns name, currently in use use in "default" certificate`,
+		},
+
+		"rotating a certificate along with other than its counterpart": {
+			instanceName:    "my-instance-2",
+			certificateName: "lets-duplicate",
+			rotationOf:      "default",
+			certificate:     rsaCertificate,
+			expectedError:   `certificate "default" is not the rotation counterpart of "lets-duplicate"`,
+		},
+
+		"adding the replacement of a certificate being rotated": {
+			instanceName:    "my-instance-2",
+			certificateName: "default-rotate",
+			rotationOf:      "default",
+			certificate:     rsaCertificate,
+			assert: func(t *testing.T, c client.Client) {
+				var instance v1alpha1.RpaasInstance
+				err := c.Get(context.Background(), types.NamespacedName{Name: "my-instance-2", Namespace: getServiceName()}, &instance)
+				require.NoError(t, err)
+				assert.Len(t, instance.Spec.TLS, 2)
+			},
+		},
+
 		"adding a new certificate with a custom name": {
 			instanceName:    "my-instance-1",
 			certificateName: "custom-name",
@@ -836,7 +1045,18 @@ x2cJyBkkBQV9WB34oGtnZzQ0nKtzsY6FVlNGSeyCJ3OD2dHXO5komJY=
 				var instance v1alpha1.RpaasInstance
 				err := c.Get(context.Background(), types.NamespacedName{Name: "my-instance-2", Namespace: getServiceName()}, &instance)
 				require.NoError(t, err)
-				assert.NotNil(t, instance.Spec.TLS)
+				require.Len(t, instance.Spec.TLS, 2)
+				assert.Equal(t, nginxv1alpha1.NginxTLS{SecretName: "my-instance-2-certs-abc123", Hosts: []string{"rpaas-operator.io"}}, instance.Spec.TLS[0])
+				assert.Regexp(t, `^my-instance-2-certs-`, instance.Spec.TLS[1].SecretName)
+				assert.Equal(t, []string{"localhost:5453", "127.0.0.1:5453"}, instance.Spec.TLS[1].Hosts)
+
+				certs, err := (&k8sRpaasManager{cli: c}).GetCertificates(context.Background(), "my-instance-2")
+				require.NoError(t, err)
+				var names []string
+				for _, cert := range certs {
+					names = append(names, cert.Name)
+				}
+				assert.ElementsMatch(t, []string{"default", "custom-name"}, names)
 			},
 		},
 
@@ -862,7 +1082,13 @@ x2cJyBkkBQV9WB34oGtnZzQ0nKtzsY6FVlNGSeyCJ3OD2dHXO5komJY=
 				WithRuntimeObjects(resources...).
 				Build()
 
-			err := (&k8sRpaasManager{cli: client}).UpdateCertificate(context.TODO(), tt.instanceName, tt.certificateName, tt.certificate)
+			manager := &k8sRpaasManager{cli: client}
+			var err error
+			if tt.rotationOf != "" {
+				err = manager.UpdateRotatingCertificate(context.TODO(), tt.instanceName, tt.certificateName, tt.rotationOf, tt.certificate)
+			} else {
+				err = manager.UpdateCertificate(context.TODO(), tt.instanceName, tt.certificateName, tt.certificate)
+			}
 			if tt.expectedError != "" {
 				assert.EqualError(t, err, tt.expectedError)
 				return
@@ -874,6 +1100,46 @@ x2cJyBkkBQV9WB34oGtnZzQ0nKtzsY6FVlNGSeyCJ3OD2dHXO5komJY=
 	}
 }
 
+func Test_k8sRpaasManager_ValidateBlock(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	instance.Spec.PlanName = "my-plan"
+	instance.Spec.Blocks = map[v1alpha1.BlockType]v1alpha1.Value{
+		v1alpha1.BlockTypeHTTP:   {Value: "# current http block"},
+		v1alpha1.BlockTypeServer: {Value: "# current server block"},
+	}
+
+	plan := &v1alpha1.RpaasPlan{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-plan", Namespace: getServiceName()},
+		Spec: v1alpha1.RpaasPlanSpec{
+			Config: v1alpha1.NginxConfig{WorkerProcesses: 3},
+		},
+	}
+
+	manager := &k8sRpaasManager{cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(instance, plan).Build()}
+
+	t.Run("when the block is not allowed", func(t *testing.T) {
+		_, err := manager.ValidateBlock(context.TODO(), "my-instance", ConfigurationBlock{Name: "unknown", Content: "# some content"})
+		assert.Equal(t, ValidationError{Msg: "block \"unknown\" is not allowed"}, err)
+	})
+
+	t.Run("renders the configuration replacing just the given block", func(t *testing.T) {
+		rendered, err := manager.renderConfigurationWithBlock(context.TODO(), instance, ConfigurationBlock{Name: "server", Content: "# new server block of {{ .Instance.Name }}"})
+		require.NoError(t, err)
+		assert.Contains(t, rendered, "worker_processes 3;")
+		assert.Contains(t, rendered, "# current http block")
+		assert.Contains(t, rendered, "# new server block of my-instance")
+		assert.NotContains(t, rendered, "# current server block")
+	})
+
+	t.Run("fails to render invalid templates", func(t *testing.T) {
+		_, err := manager.renderConfigurationWithBlock(context.TODO(), instance, ConfigurationBlock{Name: "server", Content: "{{ .Unknown }"})
+		assert.ErrorContains(t, err, `template: server:1: unexpected "}" in operand`)
+	})
+
+	assert.Equal(t, "nginx: [emerg] unknown directive \"lisen\" in nginx.conf:42\n",
+		validateConfigurationDirRegexp.ReplaceAllString("nginx: [emerg] unknown directive \"lisen\" in /tmp/rpaas-validate.a1B2c3/nginx.conf:42\n", ""))
+}
+
 func newEmptyRpaasInstance() *v1alpha1.RpaasInstance {
 	return &v1alpha1.RpaasInstance{
 		TypeMeta: metav1.TypeMeta{
@@ -1317,18 +1583,32 @@ func Test_k8sRpaasManager_PurgeCache(t *testing.T) {
 	instance1.ObjectMeta.Name = "my-instance"
 	instance2 := newEmptyRpaasInstance()
 	instance2.ObjectMeta.Name = "not-running-instance"
+	instance3 := newEmptyRpaasInstance()
+	instance3.ObjectMeta.Name = "no-cache-instance"
 	nginx1 := &nginxv1alpha1.Nginx{
 		ObjectMeta: instance1.ObjectMeta,
+		Spec: nginxv1alpha1.NginxSpec{
+			Cache: nginxv1alpha1.NginxCacheSpec{Path: "/var/cache/nginx/rpaas"},
+		},
 		Status: nginxv1alpha1.NginxStatus{
 			PodSelector: "nginx.tsuru.io/app=nginx,nginx.tsuru.io/resource-name=my-instance",
 		},
 	}
 	nginx2 := &nginxv1alpha1.Nginx{
 		ObjectMeta: instance2.ObjectMeta,
+		Spec: nginxv1alpha1.NginxSpec{
+			Cache: nginxv1alpha1.NginxCacheSpec{Path: "/var/cache/nginx/rpaas"},
+		},
 		Status: nginxv1alpha1.NginxStatus{
 			PodSelector: "nginx.tsuru.io/app=nginx,nginx.tsuru.io/resource-name=not-running-instance",
 		},
 	}
+	nginx3 := &nginxv1alpha1.Nginx{
+		ObjectMeta: instance3.ObjectMeta,
+		Status: nginxv1alpha1.NginxStatus{
+			PodSelector: "nginx.tsuru.io/app=nginx,nginx.tsuru.io/resource-name=no-cache-instance",
+		},
+	}
 	pod1 := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "my-instance-pod-1",
@@ -1373,7 +1653,7 @@ func Test_k8sRpaasManager_PurgeCache(t *testing.T) {
 	}
 
 	scheme := newScheme()
-	resources := []runtime.Object{instance1, instance2, nginx1, nginx2, pod1, pod2, pod3}
+	resources := []runtime.Object{instance1, instance2, instance3, nginx1, nginx2, nginx3, pod1, pod2, pod3}
 
 	tests := []struct {
 		name         string
@@ -1404,6 +1684,24 @@ func Test_k8sRpaasManager_PurgeCache(t *testing.T) {
 				assert.Equal(t, expected, err)
 			},
 		},
+		{
+			name:         "return ValidationError when cache is not enabled",
+			instance:     "no-cache-instance",
+			args:         PurgeCacheArgs{Path: "/index.html"},
+			cacheManager: fakeCacheManager{},
+			assertion: func(t *testing.T, count int, err error) {
+				assert.Equal(t, ValidationError{Msg: `cache is not enabled on instance "no-cache-instance"`}, err)
+			},
+		},
+		{
+			name:         "return ValidationError when path is not a valid regular expression",
+			instance:     "my-instance",
+			args:         PurgeCacheArgs{Path: "/static/(.*", PathRegexp: true},
+			cacheManager: fakeCacheManager{},
+			assertion: func(t *testing.T, count int, err error) {
+				assert.EqualError(t, err, "path is not a valid regular expression: error parsing regexp: missing closing ): `/static/(.*`")
+			},
+		},
 		{
 			name:         "return 0 when instance doesn't have any running pods",
 			instance:     "not-running-instance",
@@ -1456,6 +1754,451 @@ func Test_k8sRpaasManager_PurgeCache(t *testing.T) {
 	}
 }
 
+func Test_k8sRpaasManager_PurgeCacheOnPods(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	nginx := &nginxv1alpha1.Nginx{
+		ObjectMeta: instance.ObjectMeta,
+		Spec: nginxv1alpha1.NginxSpec{
+			Cache: nginxv1alpha1.NginxCacheSpec{Path: "/var/cache/nginx/rpaas"},
+		},
+		Status: nginxv1alpha1.NginxStatus{
+			PodSelector: "nginx.tsuru.io/app=nginx,nginx.tsuru.io/resource-name=my-instance",
+		},
+	}
+	newPod := func(name, ip string, ready bool) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: instance.Namespace,
+				Labels: map[string]string{
+					"nginx.tsuru.io/app":           "nginx",
+					"nginx.tsuru.io/resource-name": "my-instance",
+				},
+			},
+			Status: corev1.PodStatus{
+				PodIP:             ip,
+				ContainerStatuses: []corev1.ContainerStatus{{Ready: ready}},
+			},
+		}
+	}
+
+	fakeCli := fake.NewClientBuilder().
+		WithScheme(newScheme()).
+		WithRuntimeObjects(instance, nginx, newPod("my-instance-2", "10.0.0.2", true), newPod("my-instance-1", "10.0.0.1", true), newPod("my-instance-3", "10.0.0.3", false)).
+		Build()
+
+	manager := &k8sRpaasManager{
+		cli: fakeCli,
+		cacheManager: fakeCacheManager{
+			purgeCacheFunc: func(host, path string, port int32, preservePath bool, extraHeaders http.Header) (bool, error) {
+				assert.Equal(t, "/index.html", path)
+				assert.True(t, preservePath)
+				if host == "10.0.0.2" {
+					return false, nginxManager.NginxError{Msg: "some nginx error"}
+				}
+				return true, nil
+			},
+		},
+	}
+
+	pods, err := manager.PurgeCacheOnPods(context.Background(), "my-instance", PurgeCacheArgs{Path: "/index.html", PreservePath: true})
+	require.NoError(t, err)
+	assert.Equal(t, []PurgeCachePodResult{
+		{Pod: "my-instance-1", Purged: true},
+		{Pod: "my-instance-2", Error: "some nginx error"},
+	}, pods)
+}
+
+func Test_purgeCacheByRegexpCommand(t *testing.T) {
+	assert.Equal(t, []string{
+		"sh", "-c", `grep -rlsaE -e "$1" "$2" | while IFS= read -r f; do rm -f "$f" && echo "$f"; done`,
+		"purge", `^KEY: .*(/static/.*\.css$)`, "/var/cache/nginx/rpaas/nginx",
+	}, purgeCacheByRegexpCommand("/var/cache/nginx/rpaas", `/static/.*\.css$`))
+}
+
+func Test_purgeCacheByRegexpCommand_Run(t *testing.T) {
+	keys := map[string]string{
+		"a": "http://app.example.com/static/app.css",
+		"b": "https://app.example.com/static/app.js",
+		"c": "http://app.example.com/index.html?q=/static/x",
+		"d": "http/static/other.css",
+		"e": "httprpaas_locations_root/static/root.css",
+	}
+
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{expr: `^/static/.*`, expected: []string{"a", "b", "d", "e"}},
+		{expr: `^/static/.*\.css$`, expected: []string{"a", "d", "e"}},
+		{expr: `/static/x`, expected: []string{"c"}},
+		{expr: `^/index\.html$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cachePath := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(cachePath, "nginx", "1"), 0755))
+			for name, key := range keys {
+				content := fmt.Sprintf("\x00\x01binary header\nKEY: %s\nHTTP/1.1 200 OK\n", key)
+				require.NoError(t, os.WriteFile(filepath.Join(cachePath, "nginx", "1", name), []byte(content), 0644))
+			}
+
+			command := purgeCacheByRegexpCommand(cachePath, tt.expr)
+			output, err := exec.Command(command[0], command[1:]...).Output()
+			require.NoError(t, err)
+
+			var purged []string
+			for _, f := range strings.Fields(string(output)) {
+				purged = append(purged, filepath.Base(f))
+				assert.NoFileExists(t, f)
+			}
+
+			sort.Strings(purged)
+			assert.Equal(t, tt.expected, purged)
+		})
+	}
+}
+
+func Test_k8sRpaasManager_GetBindsStatus(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	instance.Spec.Binds = []v1alpha1.Bind{
+		{Name: "app2", Host: "app2.tsuru.example.com"},
+		{Name: "app1", Host: "app1.tsuru.example.com:8080"},
+	}
+
+	manager := &k8sRpaasManager{
+		cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(instance).Build(),
+		backendChecker: fakeBackendChecker{
+			checkBackendFunc: func(address string) error {
+				if address == "app1.tsuru.example.com:8080" {
+					return nginxManager.NginxError{Msg: "cannot connect to backend: connection refused"}
+				}
+				return nil
+			},
+		},
+	}
+
+	_, err := manager.GetBindsStatus(context.TODO(), "not-found")
+	assert.True(t, IsNotFoundError(err))
+
+	binds, err := manager.GetBindsStatus(context.TODO(), "my-instance")
+	require.NoError(t, err)
+	assert.Equal(t, []clientTypes.BindStatus{
+		{App: "app2", Address: "app2.tsuru.example.com", Healthy: true},
+		{App: "app1", Address: "app1.tsuru.example.com:8080", Error: "cannot connect to backend: connection refused"},
+	}, binds)
+}
+
+func Test_k8sRpaasManager_GetConnectionStats(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	nginx := &nginxv1alpha1.Nginx{
+		ObjectMeta: instance.ObjectMeta,
+		Status: nginxv1alpha1.NginxStatus{
+			PodSelector: "nginx.tsuru.io/app=nginx,nginx.tsuru.io/resource-name=my-instance",
+		},
+	}
+	newPod := func(name, ip string, ready bool) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: instance.Namespace,
+				Labels: map[string]string{
+					"nginx.tsuru.io/app":           "nginx",
+					"nginx.tsuru.io/resource-name": "my-instance",
+				},
+			},
+			Status: corev1.PodStatus{
+				PodIP:             ip,
+				ContainerStatuses: []corev1.ContainerStatus{{Ready: ready}},
+			},
+		}
+	}
+
+	resources := []runtime.Object{
+		instance,
+		nginx,
+		newPod("my-instance-pod-1", "10.0.0.9", true),
+		newPod("my-instance-pod-2", "10.0.0.10", true),
+		newPod("my-instance-pod-3", "10.0.0.11", false),
+	}
+
+	manager := &k8sRpaasManager{
+		cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(resources...).Build(),
+		statsManager: fakeStatsManager{
+			connectionStatsFunc: func(host string, port int32) (nginxManager.ConnectionStats, error) {
+				if host == "10.0.0.10" {
+					return nginxManager.ConnectionStats{}, nginxManager.NginxError{Msg: "some nginx error"}
+				}
+				return nginxManager.ConnectionStats{Active: 10, Reading: 1, Writing: 2, Waiting: 7}, nil
+			},
+		},
+	}
+
+	_, err := manager.GetConnectionStats(context.TODO(), "not-found")
+	assert.True(t, IsNotFoundError(err))
+
+	stats, err := manager.GetConnectionStats(context.TODO(), "my-instance")
+	require.NoError(t, err)
+	assert.Equal(t, []clientTypes.PodConnectionStats{
+		{Pod: "my-instance-pod-1", Stats: &clientTypes.ConnectionStats{Active: 10, Reading: 1, Writing: 2, Waiting: 7}},
+		{Pod: "my-instance-pod-2", Error: "some nginx error"},
+		{Pod: "my-instance-pod-3", Error: "pod is not running"},
+	}, stats)
+}
+
+func Test_k8sRpaasManager_GetPodsHealth(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	nginx := &nginxv1alpha1.Nginx{
+		ObjectMeta: instance.ObjectMeta,
+		Status: nginxv1alpha1.NginxStatus{
+			PodSelector: "nginx.tsuru.io/app=nginx,nginx.tsuru.io/resource-name=my-instance",
+		},
+	}
+	newPod := func(name, ip string, ready bool) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: instance.Namespace,
+				Labels: map[string]string{
+					"nginx.tsuru.io/app":           "nginx",
+					"nginx.tsuru.io/resource-name": "my-instance",
+				},
+			},
+			Status: corev1.PodStatus{
+				PodIP:             ip,
+				ContainerStatuses: []corev1.ContainerStatus{{Ready: ready}},
+			},
+		}
+	}
+
+	resources := []runtime.Object{
+		instance,
+		nginx,
+		newPod("my-instance-pod-1", "10.0.0.9", true),
+		newPod("my-instance-pod-2", "10.0.0.10", true),
+		newPod("my-instance-pod-3", "10.0.0.11", false),
+	}
+
+	manager := &k8sRpaasManager{
+		cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(resources...).Build(),
+		healthChecker: fakeHealthChecker{
+			healthcheckFunc: func(host string, port int32) error {
+				if host == "10.0.0.10" {
+					return nginxManager.NginxError{Msg: "some nginx error"}
+				}
+				return nil
+			},
+		},
+	}
+
+	_, err := manager.GetPodsHealth(context.TODO(), "not-found")
+	assert.True(t, IsNotFoundError(err))
+
+	health, err := manager.GetPodsHealth(context.TODO(), "my-instance")
+	require.NoError(t, err)
+	assert.Equal(t, []clientTypes.PodHealth{
+		{Pod: "my-instance-pod-1", Healthy: true},
+		{Pod: "my-instance-pod-2", Error: "some nginx error"},
+		{Pod: "my-instance-pod-3", Error: "pod is not running"},
+	}, health)
+}
+
+func Test_k8sRpaasManager_GetPodsUsage(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	nginx := &nginxv1alpha1.Nginx{
+		ObjectMeta: instance.ObjectMeta,
+		Status: nginxv1alpha1.NginxStatus{
+			PodSelector: "nginx.tsuru.io/app=nginx,nginx.tsuru.io/resource-name=my-instance",
+		},
+	}
+	labels := map[string]string{
+		"nginx.tsuru.io/app":           "nginx",
+		"nginx.tsuru.io/resource-name": "my-instance",
+	}
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: instance.Namespace, Labels: labels},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	resources := []runtime.Object{
+		instance,
+		nginx,
+		newPod("my-instance-pod-2"),
+		newPod("my-instance-pod-1"),
+		&metricsv1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-instance-pod-1", Namespace: instance.Namespace, Labels: labels},
+			Containers: []metricsv1beta1.ContainerMetrics{
+				{Name: "nginx", Usage: corev1.ResourceList{"cpu": resource.MustParse("150m"), "memory": resource.MustParse("64Mi")}},
+				{Name: "sidecar", Usage: corev1.ResourceList{"cpu": resource.MustParse("1"), "memory": resource.MustParse("1Gi")}},
+			},
+		},
+	}
+
+	manager := &k8sRpaasManager{
+		cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(resources...).Build(),
+	}
+
+	_, err := manager.GetPodsUsage(context.TODO(), "not-found")
+	assert.True(t, IsNotFoundError(err))
+
+	usage, err := manager.GetPodsUsage(context.TODO(), "my-instance")
+	require.NoError(t, err)
+	assert.Equal(t, []clientTypes.PodUsage{
+		{Pod: "my-instance-pod-1", Metrics: &clientTypes.PodMetrics{CPU: "150m", Memory: "64Mi"}},
+		{Pod: "my-instance-pod-2"},
+	}, usage)
+
+	t.Run("when metrics are not available", func(t *testing.T) {
+		scheme := runtime.NewScheme()
+		corev1.AddToScheme(scheme)
+		v1alpha1.SchemeBuilder.AddToScheme(scheme)
+		nginxv1alpha1.SchemeBuilder.AddToScheme(scheme)
+
+		manager := &k8sRpaasManager{
+			cli: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(resources[:4]...).Build(),
+		}
+
+		usage, err := manager.GetPodsUsage(context.TODO(), "my-instance")
+		require.NoError(t, err)
+		assert.Equal(t, []clientTypes.PodUsage{{Pod: "my-instance-pod-1"}, {Pod: "my-instance-pod-2"}}, usage)
+	})
+}
+
+func Test_k8sRpaasManager_ListPods(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	nginx := &nginxv1alpha1.Nginx{
+		ObjectMeta: instance.ObjectMeta,
+		Status: nginxv1alpha1.NginxStatus{
+			PodSelector: "nginx.tsuru.io/app=nginx,nginx.tsuru.io/resource-name=my-instance",
+		},
+	}
+	newPod := func(name, version string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: instance.Namespace,
+				Labels: map[string]string{
+					"nginx.tsuru.io/app":           "nginx",
+					"nginx.tsuru.io/resource-name": "my-instance",
+					"version":                      version,
+				},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	resources := []runtime.Object{
+		instance,
+		nginx,
+		newPod("my-instance-pod-3", "v2", corev1.PodRunning),
+		newPod("my-instance-pod-1", "v1", corev1.PodRunning),
+		newPod("my-instance-pod-2", "v2", corev1.PodRunning),
+		newPod("my-instance-pod-4", "v2", corev1.PodSucceeded),
+	}
+
+	manager := &k8sRpaasManager{
+		cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(resources...).Build(),
+	}
+
+	_, err := manager.ListPods(context.TODO(), "my-instance", "version in (v1")
+	assert.True(t, IsValidationError(err))
+
+	_, err = manager.ListPods(context.TODO(), "not-found", "version=v2")
+	assert.True(t, IsNotFoundError(err))
+
+	pods, err := manager.ListPods(context.TODO(), "my-instance", "version=v2")
+	require.NoError(t, err)
+	var names []string
+	for _, p := range pods {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"my-instance-pod-2", "my-instance-pod-3"}, names)
+
+	pods, err = manager.ListPods(context.TODO(), "my-instance", "")
+	require.NoError(t, err)
+	assert.Len(t, pods, 3)
+}
+
+func Test_k8sRpaasManager_GetCertificateStatus(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	instance.Spec.TLS = []nginxv1alpha1.NginxTLS{
+		{SecretName: "my-instance-certs-abc", Hosts: []string{"*.example.com", "example.com"}},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-instance-certs-abc",
+			Namespace: instance.Namespace,
+			Labels:    map[string]string{certificates.CertificateNameLabel: "default"},
+		},
+	}
+	nginx := &nginxv1alpha1.Nginx{
+		ObjectMeta: instance.ObjectMeta,
+		Spec: nginxv1alpha1.NginxSpec{
+			PodTemplate: nginxv1alpha1.NginxPodTemplateSpec{
+				Ports: []corev1.ContainerPort{{Name: "https", ContainerPort: 20002}},
+			},
+		},
+		Status: nginxv1alpha1.NginxStatus{
+			PodSelector: "nginx.tsuru.io/app=nginx,nginx.tsuru.io/resource-name=my-instance",
+		},
+	}
+	newPod := func(name, ip string, ready bool) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: instance.Namespace,
+				Labels: map[string]string{
+					"nginx.tsuru.io/app":           "nginx",
+					"nginx.tsuru.io/resource-name": "my-instance",
+				},
+			},
+			Status: corev1.PodStatus{
+				PodIP:             ip,
+				ContainerStatuses: []corev1.ContainerStatus{{Ready: ready}},
+			},
+		}
+	}
+
+	resources := []runtime.Object{
+		instance,
+		secret,
+		nginx,
+		newPod("my-instance-pod-1", "10.0.0.9", true),
+		newPod("my-instance-pod-2", "10.0.0.10", true),
+		newPod("my-instance-pod-3", "10.0.0.11", false),
+	}
+
+	manager := &k8sRpaasManager{
+		cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(resources...).Build(),
+		certificateManager: fakeCertificateManager{
+			certificateFingerprintsFunc: func(host string, port int32, serverName string) ([]string, error) {
+				assert.Equal(t, int32(20002), port)
+				assert.Equal(t, "rpaas.example.com", serverName)
+				if host == "10.0.0.10" {
+					return nil, nginxManager.NginxError{Msg: "some nginx error"}
+				}
+				return []string{"abc", "def"}, nil
+			},
+		},
+	}
+
+	_, err := manager.GetCertificateStatus(context.TODO(), "not-found", "default")
+	assert.True(t, IsNotFoundError(err))
+
+	_, err = manager.GetCertificateStatus(context.TODO(), "my-instance", "other")
+	assert.Equal(t, &NotFoundError{Msg: `certificate "other" does not exist`}, err)
+
+	status, err := manager.GetCertificateStatus(context.TODO(), "my-instance", "")
+	require.NoError(t, err)
+	assert.Equal(t, []clientTypes.PodCertificateStatus{
+		{Pod: "my-instance-pod-1", Fingerprints: []string{"abc", "def"}},
+		{Pod: "my-instance-pod-2", Error: "some nginx error"},
+	}, status)
+}
+
 func Test_k8sRpaasManager_BindApp(t *testing.T) {
 	instance1 := newEmptyRpaasInstance()
 
@@ -2223,6 +2966,97 @@ func Test_k8sRpaasManager_UpdateRoute(t *testing.T) {
 				}, ri.Spec.Locations)
 			},
 		},
+		{
+			name:     "when weighted destinations are defined along with destination",
+			instance: "my-instance",
+			route: Route{
+				Path:        "/my/custom/path",
+				Destination: "app1.tsuru.example.com",
+				Destinations: []v1alpha1.WeightedDestination{
+					{Destination: "app2.tsuru.example.com", Weight: 1},
+					{Destination: "app3.tsuru.example.com", Weight: 1},
+				},
+			},
+			assertion: func(t *testing.T, err error, _ *v1alpha1.RpaasInstance, _ *corev1.ConfigMap) {
+				assert.Equal(t, &ValidationError{Msg: "cannot set destinations along with content or destination"}, err)
+			},
+		},
+		{
+			name:     "when weighted destinations have a single destination",
+			instance: "my-instance",
+			route: Route{
+				Path:         "/my/custom/path",
+				Destinations: []v1alpha1.WeightedDestination{{Destination: "app1.tsuru.example.com", Weight: 1}},
+			},
+			assertion: func(t *testing.T, err error, _ *v1alpha1.RpaasInstance, _ *corev1.ConfigMap) {
+				assert.Equal(t, &ValidationError{Msg: "weighted destinations require at least two destinations"}, err)
+			},
+		},
+		{
+			name:     "when a weighted destination has no positive weight",
+			instance: "my-instance",
+			route: Route{
+				Path: "/my/custom/path",
+				Destinations: []v1alpha1.WeightedDestination{
+					{Destination: "app1.tsuru.example.com", Weight: 1},
+					{Destination: "app2.tsuru.example.com"},
+				},
+			},
+			assertion: func(t *testing.T, err error, _ *v1alpha1.RpaasInstance, _ *corev1.ConfigMap) {
+				assert.Equal(t, &ValidationError{Msg: `weight of destination "app2.tsuru.example.com" must be a positive integer`}, err)
+			},
+		},
+		{
+			name:     "when a weighted destination is duplicated",
+			instance: "my-instance",
+			route: Route{
+				Path: "/my/custom/path",
+				Destinations: []v1alpha1.WeightedDestination{
+					{Destination: "app1.tsuru.example.com", Weight: 1},
+					{Destination: "app1.tsuru.example.com", Weight: 2},
+				},
+			},
+			assertion: func(t *testing.T, err error, _ *v1alpha1.RpaasInstance, _ *corev1.ConfigMap) {
+				assert.Equal(t, &ValidationError{Msg: `destination "app1.tsuru.example.com" is duplicated`}, err)
+			},
+		},
+		{
+			name:     "when a weighted destination takes less than the smallest share",
+			instance: "my-instance",
+			route: Route{
+				Path: "/my/custom/path",
+				Destinations: []v1alpha1.WeightedDestination{
+					{Destination: "app1.tsuru.example.com", Weight: 10000},
+					{Destination: "app1-canary.tsuru.example.com", Weight: 1},
+				},
+			},
+			assertion: func(t *testing.T, err error, _ *v1alpha1.RpaasInstance, _ *corev1.ConfigMap) {
+				assert.Equal(t, &ValidationError{Msg: `weight of destination "app1-canary.tsuru.example.com" is too small, it must take at least 0.01% of the requests`}, err)
+			},
+		},
+		{
+			name:     "when updating a route to split the traffic among weighted destinations",
+			instance: "another-instance",
+			route: Route{
+				Path: "/path2",
+				Destinations: []v1alpha1.WeightedDestination{
+					{Destination: "app2.tsuru.example.com", Weight: 9},
+					{Destination: "app2-canary.tsuru.example.com", Weight: 1},
+				},
+				HTTPSOnly: true,
+			},
+			assertion: func(t *testing.T, err error, ri *v1alpha1.RpaasInstance, _ *corev1.ConfigMap) {
+				assert.NoError(t, err)
+				assert.Equal(t, v1alpha1.Location{
+					Path: "/path2",
+					Destinations: []v1alpha1.WeightedDestination{
+						{Destination: "app2.tsuru.example.com", Weight: 9},
+						{Destination: "app2-canary.tsuru.example.com", Weight: 1},
+					},
+					ForceHTTPS: true,
+				}, ri.Spec.Locations[1])
+			},
+		},
 		{
 			name:     "when adding a route with custom NGINX config",
 			instance: "my-instance",
@@ -3614,6 +4448,179 @@ func Test_k8sRpaasManager_GetFlavors(t *testing.T) {
 	}
 }
 
+func Test_k8sRpaasManager_GetFlavor(t *testing.T) {
+	resources := []runtime.Object{
+		&v1alpha1.RpaasFlavor{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "mint",
+				Namespace: getServiceName(),
+			},
+			Spec: v1alpha1.RpaasFlavorSpec{
+				Description:         "Awesome description about mint flavor",
+				IncompatibleFlavors: []string{"mango"},
+				InstanceTemplate: &v1alpha1.RpaasInstanceSpec{
+					Service: &nginxv1alpha1.NginxService{
+						Annotations: map[string]string{"mint": "true"},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		flavor        string
+		expected      *FlavorInfo
+		expectedError string
+	}{
+		{
+			flavor:        "not-found",
+			expectedError: `flavor "not-found" not found`,
+		},
+		{
+			flavor: "mint",
+			expected: &FlavorInfo{
+				Name:                "mint",
+				Description:         "Awesome description about mint flavor",
+				IncompatibleFlavors: []string{"mango"},
+				InstanceTemplate: &v1alpha1.RpaasInstanceSpec{
+					Service: &nginxv1alpha1.NginxService{
+						Annotations: map[string]string{"mint": "true"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.flavor, func(t *testing.T) {
+			manager := &k8sRpaasManager{
+				cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(resources...).Build(),
+			}
+
+			flavor, err := manager.GetFlavor(context.TODO(), tt.flavor)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.True(t, IsNotFoundError(err))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, flavor)
+		})
+	}
+}
+
+func Test_k8sRpaasManager_UpdateFlavors(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	instance.Spec.Flavors = []string{"mint"}
+
+	resources := []runtime.Object{
+		instance,
+		&v1alpha1.RpaasFlavor{
+			ObjectMeta: metav1.ObjectMeta{Name: "mint", Namespace: getServiceName()},
+		},
+		&v1alpha1.RpaasFlavor{
+			ObjectMeta: metav1.ObjectMeta{Name: "mango", Namespace: getServiceName()},
+		},
+		&v1alpha1.RpaasFlavor{
+			ObjectMeta: metav1.ObjectMeta{Name: "orange", Namespace: getServiceName()},
+			Spec:       v1alpha1.RpaasFlavorSpec{CreationOnly: true},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		instance      string
+		flavors       []string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:          "when instance does not exist",
+			instance:      "not-found",
+			expectedError: `rpaas instance "not-found" not found`,
+		},
+		{
+			name:          "when flavor does not exist",
+			instance:      "my-instance",
+			flavors:       []string{"mint", "banana"},
+			expectedError: `flavor "banana" not found`,
+		},
+		{
+			name:          "when flavor can only be set on creation",
+			instance:      "my-instance",
+			flavors:       []string{"mint", "orange"},
+			expectedError: `flavor "orange" can used only in the creation of instance`,
+		},
+		{
+			name:     "adding a flavor",
+			instance: "my-instance",
+			flavors:  []string{"mint", "mango"},
+			expected: []string{"mint", "mango"},
+		},
+		{
+			name:     "removing all flavors",
+			instance: "my-instance",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &k8sRpaasManager{
+				cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(resources...).Build(),
+			}
+
+			err := manager.UpdateFlavors(context.TODO(), tt.instance, tt.flavors)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+
+			instance, err := manager.GetInstance(context.TODO(), tt.instance)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, instance.Spec.Flavors)
+		})
+	}
+}
+
+func Test_k8sRpaasManager_getNodeZones(t *testing.T) {
+	resources := []runtime.Object{
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node-1",
+				Labels: map[string]string{"topology.kubernetes.io/zone": "us-east-1a"},
+			},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node-2",
+				Labels: map[string]string{"failure-domain.beta.kubernetes.io/zone": "us-east-1b"},
+			},
+		},
+	}
+
+	manager := &k8sRpaasManager{
+		cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(resources...).Build(),
+	}
+
+	pods := []corev1.Pod{
+		{Spec: corev1.PodSpec{NodeName: "node-1"}},
+		{Spec: corev1.PodSpec{NodeName: "node-2"}},
+		{Spec: corev1.PodSpec{NodeName: "node-1"}},
+		{Spec: corev1.PodSpec{}},
+	}
+
+	zones, err := manager.getNodeZones(context.TODO(), pods)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"node-1": "us-east-1a", "node-2": "us-east-1b"}, zones)
+
+	zones, err = manager.getNodeZones(context.TODO(), []corev1.Pod{{Spec: corev1.PodSpec{NodeName: "node-3"}}})
+	assert.Error(t, err)
+	assert.Empty(t, zones)
+}
+
 func newScheme() *runtime.Scheme {
 	scheme := runtime.NewScheme()
 	corev1.AddToScheme(scheme)
@@ -3695,6 +4702,90 @@ func Test_k8sRpaasManager_Scale(t *testing.T) {
 	}
 }
 
+func Test_k8sRpaasManager_Restart(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+
+	nginx := &nginxv1alpha1.Nginx{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-instance",
+			Namespace: getServiceName(),
+		},
+		Status: nginxv1alpha1.NginxStatus{
+			PodSelector: "nginx.tsuru.io/resource-name=my-instance",
+		},
+	}
+
+	newPod := func(name string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: getServiceName(),
+				Labels:    map[string]string{"nginx.tsuru.io/resource-name": "my-instance"},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		instance  string
+		args      RestartArgs
+		assertion func(t *testing.T, pods []string, err error, m *k8sRpaasManager)
+	}{
+		"instance not found": {
+			instance: "not-found-instance",
+			assertion: func(t *testing.T, pods []string, err error, m *k8sRpaasManager) {
+				assert.Error(t, err)
+				assert.True(t, IsNotFoundError(err))
+			},
+		},
+
+		"rolling restart": {
+			instance: "my-instance",
+			assertion: func(t *testing.T, pods []string, err error, m *k8sRpaasManager) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"my-instance-abc", "my-instance-def"}, pods)
+
+				var i v1alpha1.RpaasInstance
+				err = m.cli.Get(context.TODO(), types.NamespacedName{Name: "my-instance", Namespace: getServiceName()}, &i)
+				require.NoError(t, err)
+				assert.NotEmpty(t, i.Spec.PodTemplate.Annotations["rpaas.extensions.tsuru.io/restarted-at"])
+
+				var podList corev1.PodList
+				err = m.cli.List(context.TODO(), &podList)
+				require.NoError(t, err)
+				assert.Len(t, podList.Items, 2)
+			},
+		},
+
+		"immediate restart": {
+			instance: "my-instance",
+			args:     RestartArgs{Immediate: true},
+			assertion: func(t *testing.T, pods []string, err error, m *k8sRpaasManager) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"my-instance-abc", "my-instance-def"}, pods)
+
+				var i v1alpha1.RpaasInstance
+				err = m.cli.Get(context.TODO(), types.NamespacedName{Name: "my-instance", Namespace: getServiceName()}, &i)
+				require.NoError(t, err)
+				assert.Empty(t, i.Spec.PodTemplate.Annotations)
+
+				var podList corev1.PodList
+				err = m.cli.List(context.TODO(), &podList)
+				require.NoError(t, err)
+				assert.Len(t, podList.Items, 0)
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			resources := []runtime.Object{instance.DeepCopy(), nginx.DeepCopy(), newPod("my-instance-def"), newPod("my-instance-abc")}
+			manager := &k8sRpaasManager{cli: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(resources...).Build()}
+			pods, err := manager.Restart(context.TODO(), tt.instance, tt.args)
+			tt.assertion(t, pods, err, manager)
+		})
+	}
+}
+
 func Test_k8sRpaasManager_GetInstanceInfo(t *testing.T) {
 	cfg := config.Get()
 	defer func() { config.Set(cfg) }()
@@ -4278,6 +5369,7 @@ func Test_k8sRpaasManager_GetInstanceInfo(t *testing.T) {
 							{Name: "https", HostPort: int32(30001)},
 							{Name: "nginx-metrics", HostPort: int32(30002)},
 						},
+						Containers: []string{"nginx"},
 						Metrics: &clientTypes.PodMetrics{
 							CPU:    "100m",
 							Memory: "100Mi",
@@ -4292,7 +5384,8 @@ func Test_k8sRpaasManager_GetInstanceInfo(t *testing.T) {
 							{Name: "https", HostPort: int32(30001)},
 							{Name: "nginx-metrics", HostPort: int32(30002)},
 						},
-						Ready: false,
+						Containers: []string{"nginx"},
+						Ready:      false,
 						Errors: []clientTypes.PodError{
 							{
 								First:   t0.Add(-time.Hour).In(time.UTC),
@@ -4321,6 +5414,7 @@ func Test_k8sRpaasManager_GetInstanceInfo(t *testing.T) {
 							{Name: "https", HostPort: int32(30001)},
 							{Name: "nginx-metrics", HostPort: int32(30002)},
 						},
+						Containers: []string{"nginx"},
 						Metrics: &clientTypes.PodMetrics{
 							CPU:    "30m",
 							Memory: "10Mi",
@@ -5132,3 +6226,107 @@ func Test_k8sRpaasManager_Debug(t *testing.T) {
 	}
 
 }
+
+func Test_NewK8SForService(t *testing.T) {
+	cfg := config.Get()
+	defer func() { config.Set(cfg) }()
+	config.Set(config.RpaasConfig{ServiceName: "rpaasv2"})
+
+	cli := fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(
+		&v1alpha1.RpaasInstance{ObjectMeta: metav1.ObjectMeta{Name: "my-instance", Namespace: "rpaasv2-be"}},
+		&v1alpha1.RpaasPlan{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "rpaasv2-be"}, Spec: v1alpha1.RpaasPlanSpec{Default: true}},
+	).Build()
+
+	manager, err := NewK8SForService(nil, cli, "rpaasv2-be")
+	require.NoError(t, err)
+
+	instance, err := manager.GetInstance(context.Background(), "my-instance")
+	require.NoError(t, err)
+	assert.Equal(t, "rpaasv2-be", instance.Namespace)
+
+	plans, err := manager.GetPlans(context.Background())
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	assert.Equal(t, "default", plans[0].Name)
+
+	assert.Equal(t, "rpaasv2", config.Get().ServiceName)
+
+	manager, err = NewK8S(nil, cli, "", "")
+	require.NoError(t, err)
+
+	_, err = manager.GetInstance(context.Background(), "my-instance")
+	assert.Error(t, err)
+}
+
+// patchOptionsRecorder records the options of the patches made through it.
+type patchOptionsRecorder struct {
+	client.Client
+	options []client.PatchOptions
+}
+
+func (c *patchOptionsRecorder) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	var options client.PatchOptions
+	options.ApplyOptions(opts)
+	c.options = append(c.options, options)
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func Test_k8sRpaasManager_patchInstanceFieldManager(t *testing.T) {
+	cli := &patchOptionsRecorder{
+		Client: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(newEmptyRpaasInstance()).Build(),
+	}
+	manager := &k8sRpaasManager{cli: cli}
+
+	block := ConfigurationBlock{Name: "http", Content: "# some config"}
+	require.NoError(t, manager.UpdateBlock(ContextWithFieldManager(context.Background(), "nightly-job"), "my-instance", block))
+	require.NoError(t, manager.UpdateBlock(context.Background(), "my-instance", block))
+
+	require.Len(t, cli.options, 2)
+	assert.Equal(t, "nightly-job", cli.options[0].FieldManager)
+	assert.Empty(t, cli.options[1].FieldManager)
+}
+
+// racingClient changes the instance right before the first patch made
+// through it, as a concurrent writer would.
+type racingClient struct {
+	client.Client
+	raced bool
+}
+
+func (c *racingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if !c.raced {
+		c.raced = true
+		var instance v1alpha1.RpaasInstance
+		if err := c.Client.Get(ctx, client.ObjectKeyFromObject(obj), &instance); err != nil {
+			return err
+		}
+		instance.Spec.Blocks[v1alpha1.BlockTypeRoot] = v1alpha1.Value{Value: "# concurrent root configuration"}
+		if err := c.Client.Update(ctx, &instance); err != nil {
+			return err
+		}
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func Test_k8sRpaasManager_UpdateBlockChangedBeforeWrite(t *testing.T) {
+	instance := newEmptyRpaasInstance()
+	instance.Spec.Blocks = map[v1alpha1.BlockType]v1alpha1.Value{
+		v1alpha1.BlockTypeRoot: {Value: "# some old root configuration"},
+	}
+	cli := &racingClient{
+		Client: fake.NewClientBuilder().WithScheme(newScheme()).WithRuntimeObjects(instance).Build(),
+	}
+	manager := &k8sRpaasManager{cli: cli}
+
+	err := manager.UpdateBlock(context.TODO(), "my-instance", ConfigurationBlock{
+		Name:      "root",
+		Content:   "# my custom root configuration",
+		IfVersion: BlocksVersion([]ConfigurationBlock{{Name: "root", Content: "# some old root configuration"}}),
+	})
+	require.Error(t, err)
+	assert.True(t, IsConflictError(err))
+
+	var got v1alpha1.RpaasInstance
+	require.NoError(t, cli.Get(context.TODO(), types.NamespacedName{Name: "my-instance", Namespace: getServiceName()}, &got))
+	assert.Equal(t, "# concurrent root configuration", got.Spec.Blocks[v1alpha1.BlockTypeRoot].Value)
+}
@@ -18,10 +18,12 @@ import (
 
 	nginxv1alpha1 "github.com/tsuru/nginx-operator/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	osb "sigs.k8s.io/go-open-service-broker-client/v2"
 
 	"github.com/tsuru/rpaas-operator/api/v1alpha1"
 	"github.com/tsuru/rpaas-operator/internal/config"
+	nginxManager "github.com/tsuru/rpaas-operator/internal/pkg/rpaas/nginx"
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
 	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
@@ -29,6 +31,25 @@ import (
 type ConfigurationBlock struct {
 	Name    string `form:"block_name" json:"block_name"`
 	Content string `form:"content" json:"content"`
+
+	// IfVersion, when set, makes UpdateBlock fail with a ConflictError unless
+	// the blocks of the instance are still at this version.
+	IfVersion string `form:"-" json:"-"`
+}
+
+// BlocksVersion returns a token which changes whenever any block is added,
+// removed or changed, so that concurrent updates can be told apart.
+func BlocksVersion(blocks []ConfigurationBlock) string {
+	sorted := make([]ConfigurationBlock, len(blocks))
+	copy(sorted, blocks)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	h := sha256.New()
+	for _, b := range sorted {
+		fmt.Fprintf(h, "%s\x00%s\x00", b.Name, b.Content)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
 }
 
 // ConfigurationBlockHandler defines some functions to handle the custom
@@ -48,7 +69,14 @@ type ConfigurationBlockHandler interface {
 	// Whether the configuration block entry does not exist, it will already be
 	// created with the new content. It returns a nil error meaning it was
 	// successful, otherwise a non-nil one which describes the reached problem.
+	// When block.IfVersion is set, a ConflictError is returned if the blocks
+	// were changed since that version (see BlocksVersion).
 	UpdateBlock(ctx context.Context, instanceName string, block ConfigurationBlock) error
+
+	// ValidateBlock checks whether the NGINX configuration of the instance
+	// would still be valid with block, without applying it. An invalid
+	// configuration isn't an error, it's reported on the result instead.
+	ValidateBlock(ctx context.Context, instanceName string, block ConfigurationBlock) (*clientTypes.BlockValidation, error)
 }
 
 type File struct {
@@ -76,10 +104,11 @@ type ExtraFileHandler interface {
 }
 
 type Route struct {
-	Path        string `json:"path" form:"path"`
-	Destination string `json:"destination" form:"destination"`
-	Content     string `json:"content" form:"content"`
-	HTTPSOnly   bool   `json:"https_only" form:"https_only"`
+	Path         string                         `json:"path" form:"path"`
+	Destination  string                         `json:"destination" form:"destination"`
+	Destinations []v1alpha1.WeightedDestination `json:"destinations,omitempty" form:"-"`
+	Content      string                         `json:"content" form:"content"`
+	HTTPSOnly    bool                           `json:"https_only" form:"https_only"`
 }
 
 type RouteHandler interface {
@@ -145,6 +174,10 @@ func (args UpdateInstanceArgs) Annotations() (map[string]string, error) {
 	return getAnnotations(args.Parameters)
 }
 
+type RestartArgs struct {
+	Immediate bool `form:"immediate"`
+}
+
 type PodStatusMap map[string]PodStatus
 
 type PodStatus struct {
@@ -166,16 +199,45 @@ type CacheManager interface {
 	PurgeCache(host, path string, port int32, preservePath bool, extraHeaders http.Header) (bool, error)
 }
 
+type StatsManager interface {
+	ConnectionStats(host string, port int32) (nginxManager.ConnectionStats, error)
+}
+
+type CertificateManager interface {
+	CertificateFingerprints(host string, port int32, serverName string) ([]string, error)
+}
+
+type HealthChecker interface {
+	Healthcheck(host string, port int32) error
+}
+
+type BackendChecker interface {
+	CheckBackend(address string) error
+}
+
 type PurgeCacheArgs struct {
 	Path         string      `json:"path" form:"path"`
 	PreservePath bool        `json:"preserve_path" form:"preserve_path"`
 	ExtraHeaders http.Header `json:"extra_headers" form:"extra_headers"`
+	// PathRegexp indicates whether Path is a POSIX extended regular
+	// expression matched against the keys of the cached objects.
+	PathRegexp bool `json:"path_regexp" form:"path_regexp"`
 }
 
 type PurgeCacheBulkResult struct {
-	Path            string `json:"path"`
-	InstancesPurged int    `json:"instances_purged,omitempty"`
-	Error           string `json:"error,omitempty"`
+	Path            string                `json:"path"`
+	InstancesPurged int                   `json:"instances_purged,omitempty"`
+	Error           string                `json:"error,omitempty"`
+	Pods            []PurgeCachePodResult `json:"pods,omitempty"`
+}
+
+type PurgeCachePodResult struct {
+	Pod    string `json:"pod"`
+	Purged bool   `json:"purged"`
+	// Objects is the number of cached objects removed, only known when
+	// purging by regular expression.
+	Objects int    `json:"objects,omitempty"`
+	Error   string `json:"error,omitempty"`
 }
 
 type Plan struct {
@@ -189,8 +251,18 @@ type Flavor struct {
 	Description string `json:"description"`
 }
 
+type FlavorInfo struct {
+	Name                string                      `json:"name"`
+	Description         string                      `json:"description,omitempty"`
+	Default             bool                        `json:"default,omitempty"`
+	CreationOnly        bool                        `json:"creationOnly,omitempty"`
+	IncompatibleFlavors []string                    `json:"incompatibleFlavors,omitempty"`
+	InstanceTemplate    *v1alpha1.RpaasInstanceSpec `json:"instanceTemplate,omitempty"`
+}
+
 type AutoscaleHandler interface {
 	GetAutoscale(ctx context.Context, name string) (*autogenerated.Autoscale, error)
+	GetAutoscaleObject(ctx context.Context, instanceName string) (client.Object, error)
 	UpdateAutoscale(ctx context.Context, instanceName string, autoscale autogenerated.Autoscale) error
 	DeleteAutoscale(ctx context.Context, name string) error
 }
@@ -255,6 +327,7 @@ type RpaasManager interface {
 	AutoscaleHandler
 
 	UpdateCertificate(ctx context.Context, instance, name string, cert tls.Certificate) error
+	UpdateRotatingCertificate(ctx context.Context, instance, name, counterpart string, cert tls.Certificate) error
 	DeleteCertificate(ctx context.Context, instance, name string) error
 	GetCertificates(ctx context.Context, instanceName string) ([]CertificateData, error)
 	CreateInstance(ctx context.Context, args CreateArgs) error
@@ -264,12 +337,27 @@ type RpaasManager interface {
 	GetInstanceAddress(ctx context.Context, name string) (string, error)
 	GetInstanceStatus(ctx context.Context, name string) (*nginxv1alpha1.Nginx, PodStatusMap, error)
 	Scale(ctx context.Context, name string, replicas int32) error
+	Restart(ctx context.Context, name string, args RestartArgs) ([]string, error)
 	GetPlans(ctx context.Context) ([]Plan, error)
 	GetFlavors(ctx context.Context) ([]Flavor, error)
+	GetFlavor(ctx context.Context, name string) (*FlavorInfo, error)
+	UpdateFlavors(ctx context.Context, instanceName string, flavors []string) error
 	BindApp(ctx context.Context, instanceName string, args BindAppArgs) error
 	UnbindApp(ctx context.Context, instanceName, appName string) error
+	GetBindsStatus(ctx context.Context, instanceName string) ([]clientTypes.BindStatus, error)
 	PurgeCache(ctx context.Context, instanceName string, args PurgeCacheArgs) (int, error)
+	PurgeCacheOnPods(ctx context.Context, instanceName string, args PurgeCacheArgs) ([]PurgeCachePodResult, error)
+	GetConnectionStats(ctx context.Context, instanceName string) ([]clientTypes.PodConnectionStats, error)
+	GetPodsHealth(ctx context.Context, instanceName string) ([]clientTypes.PodHealth, error)
+	GetPodsUsage(ctx context.Context, instanceName string) ([]clientTypes.PodUsage, error)
+	ListPods(ctx context.Context, instanceName, selector string) ([]clientTypes.Pod, error)
+	GetCertificateStatus(ctx context.Context, instanceName, name string) ([]clientTypes.PodCertificateStatus, error)
+	SetCertificateOCSPStapling(ctx context.Context, instanceName, name string, enabled bool) error
+	GetMetadata(ctx context.Context, instanceName string) (*clientTypes.Metadata, error)
+	SetMetadata(ctx context.Context, instanceName string, metadata *clientTypes.Metadata) error
+	UnsetMetadata(ctx context.Context, instanceName string, metadata *clientTypes.Metadata) error
 	GetInstanceInfo(ctx context.Context, instanceName string) (*clientTypes.InstanceInfo, error)
+	GetInstanceHistory(ctx context.Context, instanceName string) ([]clientTypes.HistoryEntry, error)
 	Exec(ctx context.Context, instanceName string, args ExecArgs) error
 	Debug(ctx context.Context, instanceName string, args DebugArgs) error
 	Log(ctx context.Context, intanceName string, args LogArgs) error
@@ -284,9 +372,10 @@ type RpaasManager interface {
 }
 
 type CertificateData struct {
-	Name        string `json:"name"`
-	Certificate string `json:"certificate"`
-	Key         string `json:"key"`
+	Name         string `json:"name"`
+	Certificate  string `json:"certificate"`
+	Key          string `json:"key"`
+	OCSPStapling bool   `json:"ocspStapling,omitempty"`
 }
 
 func getAnnotations(params map[string]interface{}) (map[string]string, error) {
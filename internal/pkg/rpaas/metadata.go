@@ -0,0 +1,147 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpaas
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/tsuru/rpaas-operator/internal/config"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func (m *k8sRpaasManager) GetMetadata(ctx context.Context, instanceName string) (*clientTypes.Metadata, error) {
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clientTypes.Metadata{
+		Labels:      metadataItems(instance.Labels),
+		Annotations: metadataItems(instance.Annotations),
+	}, nil
+}
+
+func (m *k8sRpaasManager) SetMetadata(ctx context.Context, instanceName string, metadata *clientTypes.Metadata) error {
+	if err := validateMetadata(metadata, true); err != nil {
+		return err
+	}
+
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	originalInstance := instance.DeepCopy()
+
+	for _, item := range metadata.Labels {
+		if instance.Labels == nil {
+			instance.Labels = make(map[string]string)
+		}
+
+		instance.Labels[item.Name] = item.Value
+	}
+
+	for _, item := range metadata.Annotations {
+		if instance.Annotations == nil {
+			instance.Annotations = make(map[string]string)
+		}
+
+		instance.Annotations[item.Name] = item.Value
+	}
+
+	return m.patchInstance(ctx, originalInstance, instance)
+}
+
+func (m *k8sRpaasManager) UnsetMetadata(ctx context.Context, instanceName string, metadata *clientTypes.Metadata) error {
+	if err := validateMetadata(metadata, false); err != nil {
+		return err
+	}
+
+	instance, err := m.GetInstance(ctx, instanceName)
+	if err != nil {
+		return err
+	}
+
+	originalInstance := instance.DeepCopy()
+
+	for _, item := range metadata.Labels {
+		if _, found := instance.Labels[item.Name]; !found {
+			return &NotFoundError{Msg: fmt.Sprintf("label %q not found in instance %q", item.Name, instanceName)}
+		}
+
+		delete(instance.Labels, item.Name)
+	}
+
+	for _, item := range metadata.Annotations {
+		if _, found := instance.Annotations[item.Name]; !found {
+			return &NotFoundError{Msg: fmt.Sprintf("annotation %q not found in instance %q", item.Name, instanceName)}
+		}
+
+		delete(instance.Annotations, item.Name)
+	}
+
+	return m.patchInstance(ctx, originalInstance, instance)
+}
+
+func metadataItems(m map[string]string) []clientTypes.MetadataItem {
+	items := []clientTypes.MetadataItem{}
+	for name, value := range m {
+		items = append(items, clientTypes.MetadataItem{Name: name, Value: value})
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return items
+}
+
+func validateMetadata(metadata *clientTypes.Metadata, withValues bool) error {
+	if metadata == nil || (len(metadata.Labels) == 0 && len(metadata.Annotations) == 0) {
+		return &ValidationError{Msg: "metadata not provided"}
+	}
+
+	for _, item := range metadata.Labels {
+		if err := validateMetadataKey("label", item.Name); err != nil {
+			return err
+		}
+
+		if !withValues {
+			continue
+		}
+
+		if errs := validation.IsValidLabelValue(item.Value); len(errs) > 0 {
+			return &ValidationError{Msg: fmt.Sprintf("invalid value for label %q: %s", item.Name, strings.Join(errs, "; "))}
+		}
+	}
+
+	for _, item := range metadata.Annotations {
+		if err := validateMetadataKey("annotation", item.Name); err != nil {
+			return err
+		}
+
+		for _, prefix := range config.Get().ForbiddenAnnotationsPrefixes {
+			if strings.HasPrefix(item.Name, prefix) {
+				return &ValidationError{Msg: fmt.Sprintf("annotation %q is not allowed", item.Name)}
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateMetadataKey(kind, key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return &ValidationError{Msg: fmt.Sprintf("invalid %s key %q: %s", kind, key, strings.Join(errs, "; "))}
+	}
+
+	if clientTypes.IsReservedMetadataKey(key) {
+		return &ValidationError{Msg: fmt.Sprintf("%s %q is managed by the operator and cannot be changed", kind, key)}
+	}
+
+	return nil
+}
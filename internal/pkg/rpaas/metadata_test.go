@@ -0,0 +1,167 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rpaas
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/tsuru/rpaas-operator/api/v1alpha1"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+	"github.com/tsuru/rpaas-operator/pkg/runtime"
+)
+
+func newInstanceWithMetadata() *v1alpha1.RpaasInstance {
+	instance := newEmptyRpaasInstance()
+	instance.Labels = map[string]string{
+		"rpaas.extensions.tsuru.io/team-owner": "team-one",
+		"env":                                  "dev",
+	}
+	instance.Annotations = map[string]string{
+		"owner": "team-x",
+	}
+	return instance
+}
+
+func Test_k8sRpaasManager_GetMetadata(t *testing.T) {
+	instance := newInstanceWithMetadata()
+	m := &k8sRpaasManager{
+		cli: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(instance).Build(),
+	}
+
+	metadata, err := m.GetMetadata(context.Background(), instance.Name)
+	require.NoError(t, err)
+	assert.Equal(t, &clientTypes.Metadata{
+		Labels: []clientTypes.MetadataItem{
+			{Name: "env", Value: "dev"},
+			{Name: "rpaas.extensions.tsuru.io/team-owner", Value: "team-one"},
+		},
+		Annotations: []clientTypes.MetadataItem{
+			{Name: "owner", Value: "team-x"},
+		},
+	}, metadata)
+}
+
+func Test_k8sRpaasManager_SetMetadata(t *testing.T) {
+	tests := map[string]struct {
+		metadata            *clientTypes.Metadata
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+		expectedErr         string
+	}{
+		"without metadata": {
+			metadata:    &clientTypes.Metadata{},
+			expectedErr: "metadata not provided",
+		},
+
+		"with an invalid label key": {
+			metadata:    &clientTypes.Metadata{Labels: []clientTypes.MetadataItem{{Name: "env!", Value: "prod"}}},
+			expectedErr: `invalid label key "env!": name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')`,
+		},
+
+		"with an invalid label value": {
+			metadata:    &clientTypes.Metadata{Labels: []clientTypes.MetadataItem{{Name: "env", Value: "prod env"}}},
+			expectedErr: `invalid value for label "env": a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+		},
+
+		"changing a label managed by the operator": {
+			metadata:    &clientTypes.Metadata{Labels: []clientTypes.MetadataItem{{Name: "rpaas.extensions.tsuru.io/team-owner", Value: "team-two"}}},
+			expectedErr: `label "rpaas.extensions.tsuru.io/team-owner" is managed by the operator and cannot be changed`,
+		},
+
+		"setting labels and annotations": {
+			metadata: &clientTypes.Metadata{
+				Labels:      []clientTypes.MetadataItem{{Name: "env", Value: "prod"}, {Name: "cost-center", Value: "1234"}},
+				Annotations: []clientTypes.MetadataItem{{Name: "example.com/description", Value: "some free text: here!"}},
+			},
+			expectedLabels: map[string]string{
+				"rpaas.extensions.tsuru.io/team-owner": "team-one",
+				"env":                                  "prod",
+				"cost-center":                          "1234",
+			},
+			expectedAnnotations: map[string]string{
+				"owner":                   "team-x",
+				"example.com/description": "some free text: here!",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			instance := newInstanceWithMetadata()
+			m := &k8sRpaasManager{
+				cli: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(instance).Build(),
+			}
+
+			err := m.SetMetadata(context.Background(), instance.Name, tt.metadata)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var got v1alpha1.RpaasInstance
+			err = m.cli.Get(context.Background(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, &got)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedLabels, got.Labels)
+			assert.Equal(t, tt.expectedAnnotations, got.Annotations)
+		})
+	}
+}
+
+func Test_k8sRpaasManager_UnsetMetadata(t *testing.T) {
+	tests := map[string]struct {
+		metadata       *clientTypes.Metadata
+		expectedLabels map[string]string
+		expectedErr    string
+	}{
+		"when label does not exist": {
+			metadata:    &clientTypes.Metadata{Labels: []clientTypes.MetadataItem{{Name: "tier"}}},
+			expectedErr: `label "tier" not found in instance "my-instance"`,
+		},
+
+		"removing a label managed by the operator": {
+			metadata:    &clientTypes.Metadata{Labels: []clientTypes.MetadataItem{{Name: "rpaas.extensions.tsuru.io/team-owner"}}},
+			expectedErr: `label "rpaas.extensions.tsuru.io/team-owner" is managed by the operator and cannot be changed`,
+		},
+
+		"removing labels and annotations": {
+			metadata: &clientTypes.Metadata{
+				Labels:      []clientTypes.MetadataItem{{Name: "env"}},
+				Annotations: []clientTypes.MetadataItem{{Name: "owner"}},
+			},
+			expectedLabels: map[string]string{
+				"rpaas.extensions.tsuru.io/team-owner": "team-one",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			instance := newInstanceWithMetadata()
+			m := &k8sRpaasManager{
+				cli: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithRuntimeObjects(instance).Build(),
+			}
+
+			err := m.UnsetMetadata(context.Background(), instance.Name, tt.metadata)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var got v1alpha1.RpaasInstance
+			err = m.cli.Get(context.Background(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, &got)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedLabels, got.Labels)
+			assert.Empty(t, got.Annotations)
+		})
+	}
+}
@@ -132,6 +132,45 @@ func hasRootPath(locations []v1alpha1.Location) bool {
 	return false
 }
 
+// weightedDestination is a destination of a location splitting its traffic,
+// proxied through an upstream of its own so that the Host header can follow
+// the destination picked for each request. As proxy_pass cannot replace the
+// location path when its upstream comes from a variable, the path is
+// rewritten upfront.
+type weightedDestination struct {
+	Upstream string
+	Host     string
+	// Share is the percentage of requests taken by split_clients, or "*"
+	// for the remaining ones.
+	Share string
+}
+
+func weightedDestinations(location v1alpha1.Location) []weightedDestination {
+	var total int64
+	for _, d := range location.Destinations {
+		total += int64(d.Weight)
+	}
+
+	destinations := make([]weightedDestination, 0, len(location.Destinations))
+	for i, d := range location.Destinations {
+		share := "*"
+		if i < len(location.Destinations)-1 {
+			// NOTE: split_clients takes at most two decimal places, so shares
+			// are rounded down and the last destination takes what is left.
+			hundredths := int64(d.Weight) * 10000 / total
+			share = fmt.Sprintf("%d.%02d%%", hundredths/100, hundredths%100)
+		}
+
+		destinations = append(destinations, weightedDestination{
+			Upstream: fmt.Sprintf("%s_%d", buildLocationKey("", location.Path), i),
+			Host:     d.Destination,
+			Share:    share,
+		})
+	}
+
+	return destinations
+}
+
 func httpPort(instance *v1alpha1.RpaasInstance) int32 {
 	if instance != nil {
 		port := util.PortByName(instance.Spec.PodTemplate.Ports, PortNameHTTP)
@@ -144,7 +183,7 @@ func httpPort(instance *v1alpha1.RpaasInstance) int32 {
 		}
 	}
 
-	return 8080
+	return DefaultHTTPPort
 }
 
 func httpsPort(instance *v1alpha1.RpaasInstance) int32 {
@@ -249,6 +288,8 @@ var internalTemplateFuncs = template.FuncMap(map[string]interface{}{
 	"boolValue":               v1alpha1.BoolValue,
 	"buildLocationKey":        buildLocationKey,
 	"hasRootPath":             hasRootPath,
+	"quoteMeta":               regexp.QuoteMeta,
+	"weightedDestinations":    weightedDestinations,
 	"toLower":                 strings.ToLower,
 	"toUpper":                 strings.ToUpper,
 	"managePort":              managePort,
@@ -421,8 +462,31 @@ http {
 
     {{- end }}
 
-    {{- range $_, $location := $instance.Spec.Locations }}
-    {{- if $location.Destination }}
+    {{- range $index, $location := $instance.Spec.Locations }}
+    {{- if $location.Destinations }}
+    {{- $destinations := weightedDestinations $location }}
+    split_clients "${request_id}" $rpaas_location_{{ $index }}_upstream {
+        {{- range $_, $d := $destinations }}
+        {{ $d.Share }} {{ $d.Upstream }};
+        {{- end }}
+    }
+
+    map $rpaas_location_{{ $index }}_upstream $rpaas_location_{{ $index }}_host {
+        {{- range $_, $d := $destinations }}
+        {{ $d.Upstream }} {{ $d.Host }};
+        {{- end }}
+    }
+
+    {{- range $_, $d := $destinations }}
+    upstream {{ $d.Upstream }} {
+        server {{ $d.Host }};
+
+        {{- with $config.UpstreamKeepalive }}
+        keepalive {{ . }};
+        {{- end }}
+    }
+    {{- end }}
+    {{- else if $location.Destination }}
     upstream {{ buildLocationKey "" $location.Path }} {
         server {{ $location.Destination }};
 
@@ -489,6 +553,10 @@ http {
 
         ssl_certificate     certs/{{ $tls.SecretName }}/tls.crt;
         ssl_certificate_key certs/{{ $tls.SecretName }}/tls.key;
+        {{- if has $tls.SecretName $instance.Spec.OCSPStapling }}
+
+        ssl_stapling on;
+        {{- end }}
 
         {{ template "rpaasv2.internal.server" $all }}
     }
@@ -517,9 +585,9 @@ http {
         }
 
         {{- if $instance.Spec.Locations }}
-        {{- range $_, $location := $instance.Spec.Locations }}
+        {{- range $index, $location := $instance.Spec.Locations }}
         location {{ $location.Path }} {
-        {{- if $location.Destination }}
+        {{- if or $location.Destination $location.Destinations }}
             {{- if $location.ForceHTTPS }}
             if ($scheme = 'http') {
                 return 301 https://$http_host$request_uri;
@@ -527,10 +595,18 @@ http {
             {{- end }}
 
             proxy_set_header Connection "";
+            {{- if $location.Destinations }}
+            proxy_set_header Host $rpaas_location_{{ $index }}_host;
+            rewrite "^{{ quoteMeta $location.Path }}(.*)$" /$1 break;
+
+            proxy_pass     http://$rpaas_location_{{ $index }}_upstream;
+            proxy_redirect ~^http://{{ buildLocationKey "" $location.Path }}_\d+(:\d+)?/(.*)$ {{ $location.Path }}$2;
+            {{- else }}
             proxy_set_header Host {{ $location.Destination }};
 
             proxy_pass     http://{{ buildLocationKey "" $location.Path }}/;
             proxy_redirect ~^http://{{ buildLocationKey "" $location.Path }}(:\d+)?/(.*)$ {{ $location.Path }}$2;
+            {{- end }}
         {{- else }}
         {{- with $location.Content.Value }}
             {{ . }}
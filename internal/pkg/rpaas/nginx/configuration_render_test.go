@@ -230,6 +230,25 @@ func TestRpaasConfigurationRenderer_Render(t *testing.T) {
 \s+ssl_certificate_key certs/my-cert-02/tls.key;`, result)
 			},
 		},
+		{
+			name: "with OCSP stapling on one of the certs",
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						TLS: []nginxv1alpha1.NginxTLS{
+							{SecretName: "my-cert-01", Hosts: []string{"*.example.com"}},
+							{SecretName: "my-cert-02", Hosts: []string{"www.example.org"}},
+						},
+						OCSPStapling: []string{"my-cert-02"},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string) {
+				assert.NotRegexp(t, `ssl_certificate_key certs/my-cert-01/tls.key;\s+ssl_stapling on;`, result)
+				assert.Regexp(t, `ssl_certificate_key certs/my-cert-02/tls.key;\s+ssl_stapling on;`, result)
+			},
+		},
 		{
 			name: "with TLS actived and custom listen options",
 			data: ConfigurationData{
@@ -396,6 +415,48 @@ func TestRpaasConfigurationRenderer_Render(t *testing.T) {
 \s+}`, result)
 			},
 		},
+		{
+			name: "with path splitting the traffic among weighted destinations",
+			data: ConfigurationData{
+				Config: &v1alpha1.NginxConfig{},
+				Instance: &v1alpha1.RpaasInstance{
+					Spec: v1alpha1.RpaasInstanceSpec{
+						Locations: []v1alpha1.Location{
+							{
+								Path: "/api",
+								Destinations: []v1alpha1.WeightedDestination{
+									{Destination: "app-stable.tsuru.example.com", Weight: 9},
+									{Destination: "app-canary.tsuru.example.com", Weight: 1},
+								},
+							},
+						},
+					},
+				},
+			},
+			assertion: func(t *testing.T, result string) {
+				assert.Regexp(t, `split_clients "\$\{request_id\}" \$rpaas_location_0_upstream {
+\s+90\.00% rpaas_locations__api_0;
+\s+\* rpaas_locations__api_1;
+\s+}`, result)
+				assert.Regexp(t, `map \$rpaas_location_0_upstream \$rpaas_location_0_host {
+\s+rpaas_locations__api_0 app-stable\.tsuru\.example\.com;
+\s+rpaas_locations__api_1 app-canary\.tsuru\.example\.com;
+\s+}`, result)
+				assert.Regexp(t, `upstream rpaas_locations__api_0 {
+\s+server app-stable\.tsuru\.example\.com;
+\s+}`, result)
+				assert.Regexp(t, `upstream rpaas_locations__api_1 {
+\s+server app-canary\.tsuru\.example\.com;
+\s+}`, result)
+				assert.Regexp(t, `location /api {\n+
+\s+proxy_set_header Connection "";
+\s+proxy_set_header Host \$rpaas_location_0_host;
+\s+rewrite "\^/api\(\.\*\)\$" /\$1 break;
+
+\s+proxy_pass     http://\$rpaas_location_0_upstream;
+\s+proxy_redirect ~\^http://rpaas_locations__api_\\d\+\(:\\d\+\)\?/\(\.\*\)\$ /api\$2;`, result)
+			},
+		},
 		{
 			name: "with custom NGINX config template",
 			blocks: ConfigurationBlocks{
@@ -727,6 +788,51 @@ func Test_hasRootPath(t *testing.T) {
 	}
 }
 
+func Test_weightedDestinations(t *testing.T) {
+	tests := []struct {
+		name     string
+		location v1alpha1.Location
+		expected []weightedDestination
+	}{
+		{
+			name: "when the weights split the requests evenly",
+			location: v1alpha1.Location{
+				Path: "/",
+				Destinations: []v1alpha1.WeightedDestination{
+					{Destination: "app1.tsuru.example.com", Weight: 1},
+					{Destination: "app2.tsuru.example.com", Weight: 1},
+				},
+			},
+			expected: []weightedDestination{
+				{Upstream: "rpaas_locations_root_0", Host: "app1.tsuru.example.com", Share: "50.00%"},
+				{Upstream: "rpaas_locations_root_1", Host: "app2.tsuru.example.com", Share: "*"},
+			},
+		},
+		{
+			name: "when the shares must be rounded",
+			location: v1alpha1.Location{
+				Path: "/api",
+				Destinations: []v1alpha1.WeightedDestination{
+					{Destination: "app1.tsuru.example.com", Weight: 1},
+					{Destination: "app2.tsuru.example.com", Weight: 1},
+					{Destination: "app3.tsuru.example.com:8080", Weight: 1},
+				},
+			},
+			expected: []weightedDestination{
+				{Upstream: "rpaas_locations__api_0", Host: "app1.tsuru.example.com", Share: "33.33%"},
+				{Upstream: "rpaas_locations__api_1", Host: "app2.tsuru.example.com", Share: "33.33%"},
+				{Upstream: "rpaas_locations__api_2", Host: "app3.tsuru.example.com:8080", Share: "*"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, weightedDestinations(tt.location))
+		})
+	}
+}
+
 func TestK8sQuantityToNginx(t *testing.T) {
 	type expectation struct {
 		k8sQuantity   string
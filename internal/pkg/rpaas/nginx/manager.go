@@ -5,8 +5,15 @@
 package nginx
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,6 +30,8 @@ const (
 	PortNameManagement         = PortNameMetrics
 
 	DefaultManagePort             = 8800
+	DefaultHTTPPort               = 8080
+	DefaultHTTPSPort              = 8443
 	DefaultProxyProtocolHTTPPort  = 9080
 	DefaultProxyProtocolHTTPSPort = 9443
 
@@ -30,6 +39,7 @@ const (
 	defaultPurgeLocation      = "/purge"
 	defaultPurgeLocationMatch = "^/purge/(.+)"
 	defaultVTSLocationMatch   = "/status"
+	healthcheckLocation       = "/_nginx_healthcheck"
 )
 
 type NginxManager struct {
@@ -45,6 +55,15 @@ func (e NginxError) Error() string {
 	return e.Msg
 }
 
+// ConnectionStats holds the client connections of a NGINX server, the same
+// counters exposed by the stub_status module.
+type ConnectionStats struct {
+	Active  int64
+	Reading int64
+	Writing int64
+	Waiting int64
+}
+
 func NewNginxManager() NginxManager {
 	return NginxManager{
 		purgeLocation: purgeLocationMatch(),
@@ -151,3 +170,134 @@ func (m NginxManager) requestNginx(host, path string, port int32, headers map[st
 	}
 	return resp, nil
 }
+
+// ConnectionStats fetches the current client connections from the VTS
+// status page, so it's only available on instances with VTS enabled.
+func (m NginxManager) ConnectionStats(host string, port int32) (ConnectionStats, error) {
+	resp, err := m.requestNginx(host, vtsLocationMatch(), port, nil)
+	if err != nil {
+		return ConnectionStats{}, NginxError{Msg: fmt.Sprintf("cannot get connection stats - error requesting nginx server: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ConnectionStats{}, NginxError{Msg: fmt.Sprintf("cannot get connection stats - unexpected response from nginx server: %d", resp.StatusCode)}
+	}
+
+	var stats ConnectionStats
+	found := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		status, value, ok := parseVTSConnectionsMetric(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		found = true
+		switch status {
+		case "active":
+			stats.Active = value
+		case "reading":
+			stats.Reading = value
+		case "writing":
+			stats.Writing = value
+		case "waiting":
+			stats.Waiting = value
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return ConnectionStats{}, err
+	}
+
+	if !found {
+		return ConnectionStats{}, NginxError{Msg: "cannot get connection stats - no connection metrics found on nginx status"}
+	}
+
+	return stats, nil
+}
+
+// Healthcheck requests the health check location on the HTTP port, failing
+// unless NGINX answers it with 200 OK.
+func (m NginxManager) Healthcheck(host string, port int32) error {
+	if port == 0 {
+		port = DefaultHTTPPort
+	}
+
+	resp, err := m.requestNginx(host, healthcheckLocation, port, nil)
+	if err != nil {
+		return NginxError{Msg: fmt.Sprintf("cannot check health - error requesting nginx server: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return NginxError{Msg: fmt.Sprintf("cannot check health - unexpected response from nginx server: %d", resp.StatusCode)}
+	}
+
+	return nil
+}
+
+// CertificateFingerprints performs a TLS handshake against the HTTPS port,
+// sending serverName as SNI, and returns the SHA-256 of every certificate
+// (in DER) served by NGINX, sorted so the chain order does not matter.
+func (m NginxManager) CertificateFingerprints(host string, port int32, serverName string) ([]string, error) {
+	if port == 0 {
+		port = DefaultHTTPSPort
+	}
+
+	dialer := &net.Dialer{Timeout: m.client.Timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, strconv.Itoa(int(port))), &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true, // only the served certificates matter here
+	})
+	if err != nil {
+		return nil, NginxError{Msg: fmt.Sprintf("cannot get certificate - error connecting to nginx server: %v", err)}
+	}
+	defer conn.Close()
+
+	var fingerprints []string
+	for _, cert := range conn.ConnectionState().PeerCertificates {
+		sum := sha256.Sum256(cert.Raw)
+		fingerprints = append(fingerprints, hex.EncodeToString(sum[:]))
+	}
+
+	sort.Strings(fingerprints)
+	return fingerprints, nil
+}
+
+// CheckBackend reports whether a TCP connection can be established to the
+// backend address (port 80 when omitted), the same way NGINX reaches it.
+func (m NginxManager) CheckBackend(address string) error {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "80")
+	}
+
+	conn, err := net.DialTimeout("tcp", address, m.client.Timeout)
+	if err != nil {
+		return NginxError{Msg: fmt.Sprintf("cannot connect to backend: %v", err)}
+	}
+
+	return conn.Close()
+}
+
+// parseVTSConnectionsMetric parses lines like:
+//
+//	nginx_vts_main_connections{status="active"} 10
+func parseVTSConnectionsMetric(line string) (string, int64, bool) {
+	const prefix = `nginx_vts_main_connections{status="`
+	if !strings.HasPrefix(line, prefix) {
+		return "", 0, false
+	}
+
+	status, rest, found := strings.Cut(strings.TrimPrefix(line, prefix), `"}`)
+	if !found {
+		return "", 0, false
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(rest), 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return status, int64(value), true
+}
@@ -5,6 +5,9 @@
 package nginx
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -177,3 +180,151 @@ func TestNginxManager_PurgeCache(t *testing.T) {
 		})
 	}
 }
+
+func TestNginxManager_ConnectionStats(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       http.HandlerFunc
+		expected      ConnectionStats
+		expectedError string
+	}{
+		{
+			name: "when VTS is disabled",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			expectedError: "cannot get connection stats - unexpected response from nginx server: 404",
+		},
+		{
+			name: "when there are no connection metrics",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("# HELP nginx_vts_info Nginx info\n"))
+			},
+			expectedError: "cannot get connection stats - no connection metrics found on nginx status",
+		},
+		{
+			name: "parsing the connection metrics",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/status", r.URL.Path)
+				w.Write([]byte(`# HELP nginx_vts_main_connections Nginx connections
+# TYPE nginx_vts_main_connections gauge
+nginx_vts_main_connections{status="accepted"} 7153
+nginx_vts_main_connections{status="active"} 12
+nginx_vts_main_connections{status="handled"} 7153
+nginx_vts_main_connections{status="reading"} 1
+nginx_vts_main_connections{status="requests"} 26710
+nginx_vts_main_connections{status="waiting"} 8
+nginx_vts_main_connections{status="writing"} 3
+`))
+			},
+			expected: ConnectionStats{Active: 12, Reading: 1, Writing: 3, Waiting: 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			u, err := url.Parse(server.URL)
+			require.NoError(t, err)
+			port, err := strconv.Atoi(u.Port())
+			require.NoError(t, err)
+
+			stats, err := NewNginxManager().ConnectionStats(u.Hostname(), int32(port))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stats)
+		})
+	}
+}
+
+func TestNginxManager_Healthcheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       http.HandlerFunc
+		expectedError string
+	}{
+		{
+			name: "when NGINX is healthy",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/_nginx_healthcheck", r.URL.Path)
+				w.Write([]byte("WORKING\n"))
+			},
+		},
+		{
+			name: "when NGINX answers with an error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			expectedError: "cannot check health - unexpected response from nginx server: 503",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			u, err := url.Parse(server.URL)
+			require.NoError(t, err)
+			port, err := strconv.Atoi(u.Port())
+			require.NoError(t, err)
+
+			err = NewNginxManager().Healthcheck(u.Hostname(), int32(port))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNginxManager_CertificateFingerprints(t *testing.T) {
+	var serverName string
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			serverName = hello.ServerName
+			return nil, nil
+		},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+
+	fingerprints, err := NewNginxManager().CertificateFingerprints(u.Hostname(), int32(port), "www.example.com")
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(server.Certificate().Raw)
+	assert.Equal(t, []string{hex.EncodeToString(sum[:])}, fingerprints)
+	assert.Equal(t, "www.example.com", serverName)
+
+	server.Close()
+	_, err = NewNginxManager().CertificateFingerprints(u.Hostname(), int32(port), "www.example.com")
+	assert.ErrorContains(t, err, "cannot get certificate - error connecting to nginx server:")
+}
+
+func TestNginxManager_CheckBackend(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	assert.NoError(t, NewNginxManager().CheckBackend(u.Host))
+
+	server.Close()
+	err = NewNginxManager().CheckBackend(u.Host)
+	assert.ErrorContains(t, err, "cannot connect to backend:")
+}
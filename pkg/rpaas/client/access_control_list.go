@@ -108,7 +108,7 @@ func (c *client) ListAccessControlList(ctx context.Context, instance string) ([]
 
 	defer resp.Body.Close()
 	var acls []types.AllowedUpstream
-	err = json.NewDecoder(resp.Body).Decode(&acls)
+	err = c.unmarshalBody(resp, &acls)
 	if err != nil {
 		return nil, err
 	}
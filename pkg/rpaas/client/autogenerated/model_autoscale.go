@@ -28,8 +28,18 @@ type Autoscale struct {
 	Cpu *int32 `json:"cpu,omitempty"`
 	// Target average of memory utilization over running replicas (e.g. 80 means 80%)
 	Memory *int32 `json:"memory,omitempty"`
+	// Target average of memory usage over running replicas as an absolute quantity (e.g. 512Mi). It cannot be set along with `memory`.
+	MemoryValue *string `json:"memoryValue,omitempty"`
 	// Target average of HTTP requests per seconds over running replicas (e.g. 100 means 100 req/s)
 	Rps *int32 `json:"rps,omitempty"`
+	// Period over which the requests per second are aggregated before being compared to `rps` (e.g. 2m). Defaults to the range of the RPS query.
+	RpsWindow *string `json:"rpsWindow,omitempty"`
+	// How the requests per second are aggregated over `rpsWindow`. Defaults to avg.
+	RpsAggregation *string `json:"rpsAggregation,omitempty"`
+	// Name of another instance whose number of replicas is followed by this one, scaled by `mirrorRatio`.
+	MirrorInstance *string `json:"mirrorInstance,omitempty"`
+	// Number of replicas of this instance for each replica of `mirrorInstance` (e.g. 0.5 means half of them). Defaults to 1.
+	MirrorRatio *float64 `json:"mirrorRatio,omitempty"`
 	// Schedules are recurring or not time-windows where the instance can scale in/out regardless of traffic or resource utilization.
 	Schedules []ScheduledWindow `json:"schedules,omitempty"`
 }
@@ -165,6 +175,38 @@ func (o *Autoscale) SetMemory(v int32) {
 	o.Memory = &v
 }
 
+// GetMemoryValue returns the MemoryValue field value if set, zero value otherwise.
+func (o *Autoscale) GetMemoryValue() string {
+	if o == nil || IsNil(o.MemoryValue) {
+		var ret string
+		return ret
+	}
+	return *o.MemoryValue
+}
+
+// GetMemoryValueOk returns a tuple with the MemoryValue field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Autoscale) GetMemoryValueOk() (*string, bool) {
+	if o == nil || IsNil(o.MemoryValue) {
+		return nil, false
+	}
+	return o.MemoryValue, true
+}
+
+// HasMemoryValue returns a boolean if a field has been set.
+func (o *Autoscale) HasMemoryValue() bool {
+	if o != nil && !IsNil(o.MemoryValue) {
+		return true
+	}
+
+	return false
+}
+
+// SetMemoryValue gets a reference to the given string and assigns it to the MemoryValue field.
+func (o *Autoscale) SetMemoryValue(v string) {
+	o.MemoryValue = &v
+}
+
 // GetRps returns the Rps field value if set, zero value otherwise.
 func (o *Autoscale) GetRps() int32 {
 	if o == nil || IsNil(o.Rps) {
@@ -197,6 +239,134 @@ func (o *Autoscale) SetRps(v int32) {
 	o.Rps = &v
 }
 
+// GetRpsWindow returns the RpsWindow field value if set, zero value otherwise.
+func (o *Autoscale) GetRpsWindow() string {
+	if o == nil || IsNil(o.RpsWindow) {
+		var ret string
+		return ret
+	}
+	return *o.RpsWindow
+}
+
+// GetRpsWindowOk returns a tuple with the RpsWindow field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Autoscale) GetRpsWindowOk() (*string, bool) {
+	if o == nil || IsNil(o.RpsWindow) {
+		return nil, false
+	}
+	return o.RpsWindow, true
+}
+
+// HasRpsWindow returns a boolean if a field has been set.
+func (o *Autoscale) HasRpsWindow() bool {
+	if o != nil && !IsNil(o.RpsWindow) {
+		return true
+	}
+
+	return false
+}
+
+// SetRpsWindow gets a reference to the given string and assigns it to the RpsWindow field.
+func (o *Autoscale) SetRpsWindow(v string) {
+	o.RpsWindow = &v
+}
+
+// GetRpsAggregation returns the RpsAggregation field value if set, zero value otherwise.
+func (o *Autoscale) GetRpsAggregation() string {
+	if o == nil || IsNil(o.RpsAggregation) {
+		var ret string
+		return ret
+	}
+	return *o.RpsAggregation
+}
+
+// GetRpsAggregationOk returns a tuple with the RpsAggregation field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Autoscale) GetRpsAggregationOk() (*string, bool) {
+	if o == nil || IsNil(o.RpsAggregation) {
+		return nil, false
+	}
+	return o.RpsAggregation, true
+}
+
+// HasRpsAggregation returns a boolean if a field has been set.
+func (o *Autoscale) HasRpsAggregation() bool {
+	if o != nil && !IsNil(o.RpsAggregation) {
+		return true
+	}
+
+	return false
+}
+
+// SetRpsAggregation gets a reference to the given string and assigns it to the RpsAggregation field.
+func (o *Autoscale) SetRpsAggregation(v string) {
+	o.RpsAggregation = &v
+}
+
+// GetMirrorInstance returns the MirrorInstance field value if set, zero value otherwise.
+func (o *Autoscale) GetMirrorInstance() string {
+	if o == nil || IsNil(o.MirrorInstance) {
+		var ret string
+		return ret
+	}
+	return *o.MirrorInstance
+}
+
+// GetMirrorInstanceOk returns a tuple with the MirrorInstance field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Autoscale) GetMirrorInstanceOk() (*string, bool) {
+	if o == nil || IsNil(o.MirrorInstance) {
+		return nil, false
+	}
+	return o.MirrorInstance, true
+}
+
+// HasMirrorInstance returns a boolean if a field has been set.
+func (o *Autoscale) HasMirrorInstance() bool {
+	if o != nil && !IsNil(o.MirrorInstance) {
+		return true
+	}
+
+	return false
+}
+
+// SetMirrorInstance gets a reference to the given string and assigns it to the MirrorInstance field.
+func (o *Autoscale) SetMirrorInstance(v string) {
+	o.MirrorInstance = &v
+}
+
+// GetMirrorRatio returns the MirrorRatio field value if set, zero value otherwise.
+func (o *Autoscale) GetMirrorRatio() float64 {
+	if o == nil || IsNil(o.MirrorRatio) {
+		var ret float64
+		return ret
+	}
+	return *o.MirrorRatio
+}
+
+// GetMirrorRatioOk returns a tuple with the MirrorRatio field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+func (o *Autoscale) GetMirrorRatioOk() (*float64, bool) {
+	if o == nil || IsNil(o.MirrorRatio) {
+		return nil, false
+	}
+	return o.MirrorRatio, true
+}
+
+// HasMirrorRatio returns a boolean if a field has been set.
+func (o *Autoscale) HasMirrorRatio() bool {
+	if o != nil && !IsNil(o.MirrorRatio) {
+		return true
+	}
+
+	return false
+}
+
+// SetMirrorRatio gets a reference to the given float64 and assigns it to the MirrorRatio field.
+func (o *Autoscale) SetMirrorRatio(v float64) {
+	o.MirrorRatio = &v
+}
+
 // GetSchedules returns the Schedules field value if set, zero value otherwise.
 func (o *Autoscale) GetSchedules() []ScheduledWindow {
 	if o == nil || IsNil(o.Schedules) {
@@ -247,9 +417,24 @@ func (o Autoscale) ToMap() (map[string]interface{}, error) {
 	if !IsNil(o.Memory) {
 		toSerialize["memory"] = o.Memory
 	}
+	if !IsNil(o.MemoryValue) {
+		toSerialize["memoryValue"] = o.MemoryValue
+	}
 	if !IsNil(o.Rps) {
 		toSerialize["rps"] = o.Rps
 	}
+	if !IsNil(o.RpsWindow) {
+		toSerialize["rpsWindow"] = o.RpsWindow
+	}
+	if !IsNil(o.RpsAggregation) {
+		toSerialize["rpsAggregation"] = o.RpsAggregation
+	}
+	if !IsNil(o.MirrorInstance) {
+		toSerialize["mirrorInstance"] = o.MirrorInstance
+	}
+	if !IsNil(o.MirrorRatio) {
+		toSerialize["mirrorRatio"] = o.MirrorRatio
+	}
 	if !IsNil(o.Schedules) {
 		toSerialize["schedules"] = o.Schedules
 	}
@@ -0,0 +1,59 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func (args GetAutoscaleArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	return nil
+}
+
+// GetAutoscaleObject returns the HorizontalPodAutoscaler or KEDA ScaledObject
+// which the operator generated from the autoscale settings of the instance.
+// It fails with ErrAutoscaleObjectUnsupported when the API is too old to
+// expose it.
+func (c *client) GetAutoscaleObject(ctx context.Context, args GetAutoscaleArgs) (*unstructured.Unstructured, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	pathName := fmt.Sprintf("/resources/%s/autoscale/object", args.Instance)
+	req, err := c.newRequest("GET", pathName, nil, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		err = newErrUnexpectedStatusCodeFromResponse(response)
+		if isMissingEndpoint(err) {
+			return nil, ErrAutoscaleObjectUnsupported
+		}
+
+		return nil, err
+	}
+
+	var obj unstructured.Unstructured
+	if err = c.unmarshalBody(response, &obj.Object); err != nil {
+		return nil, err
+	}
+
+	return &obj, nil
+}
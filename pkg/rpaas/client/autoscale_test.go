@@ -0,0 +1,50 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientThroughTsuru_GetAutoscaleObject(t *testing.T) {
+	t.Run("when instance is empty", func(t *testing.T) {
+		client, server := newClientThroughTsuru(t, nil)
+		defer server.Close()
+
+		_, err := client.GetAutoscaleObject(context.TODO(), GetAutoscaleArgs{})
+		assert.EqualError(t, err, "rpaasv2: instance cannot be empty")
+	})
+
+	t.Run("returns the generated object", func(t *testing.T) {
+		client, server := newClientThroughTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "GET", r.Method)
+			assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/autoscale/object"), r.URL.RequestURI())
+			fmt.Fprint(w, `{"apiVersion":"keda.sh/v1alpha1","kind":"ScaledObject","metadata":{"name":"my-instance"},"spec":{"maxReplicaCount":10}}`)
+		}))
+		defer server.Close()
+
+		obj, err := client.GetAutoscaleObject(context.TODO(), GetAutoscaleArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, "ScaledObject", obj.GetKind())
+		assert.Equal(t, "my-instance", obj.GetName())
+	})
+
+	t.Run("when the API does not expose the object", func(t *testing.T) {
+		client, server := newClientThroughTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"message":"Not Found"}`)
+		}))
+		defer server.Close()
+
+		_, err := client.GetAutoscaleObject(context.TODO(), GetAutoscaleArgs{Instance: "my-instance"})
+		assert.ErrorIs(t, err, ErrAutoscaleObjectUnsupported)
+	})
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestClientThroughTsuru_ListBinds(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          ListBindsArgs
+		expected      []types.BindStatus
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when server returns an unexpected status code",
+			args:          ListBindsArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: unexpected status code: 404 Not Found, detail: instance not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "instance not found")
+			},
+		},
+		{
+			name: "when server returns the binds",
+			args: ListBindsArgs{Instance: "my-instance"},
+			expected: []types.BindStatus{
+				{App: "app1", Address: "app1.tsuru.example.com", Healthy: true},
+				{App: "app2", Address: "app2.tsuru.example.com", Error: "connection refused"},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "GET")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/binds"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				fmt.Fprintf(w, `[{"app": "app1", "address": "app1.tsuru.example.com", "healthy": true}, {"app": "app2", "address": "app2.tsuru.example.com", "healthy": false, "error": "connection refused"}]`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			binds, err := client.ListBinds(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, binds)
+		})
+	}
+}
@@ -53,12 +53,19 @@ func (c *client) UpdateBlock(ctx context.Context, args UpdateBlockArgs) error {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if args.Version != "" {
+		req.Header.Set("If-Match", fmt.Sprintf("%q", args.Version))
+	}
 
 	response, err := c.do(ctx, req)
 	if err != nil {
 		return err
 	}
 
+	if response.StatusCode == http.StatusConflict && args.Version != "" {
+		return ErrBlocksConflict
+	}
+
 	if response.StatusCode != http.StatusOK {
 		return newErrUnexpectedStatusCodeFromResponse(response)
 	}
@@ -66,6 +73,67 @@ func (c *client) UpdateBlock(ctx context.Context, args UpdateBlockArgs) error {
 	return nil
 }
 
+func (args ValidateBlockArgs) Validate() error {
+	return UpdateBlockArgs{Instance: args.Instance, Name: args.Name, Content: args.Content}.Validate()
+}
+
+// ValidateBlock checks the block with "nginx -t" on a pod of the instance,
+// without applying it. It returns ErrValidateBlockUnsupported when the API
+// lacks the validation endpoint.
+func (c *client) ValidateBlock(ctx context.Context, args ValidateBlockArgs) (*types.BlockValidation, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	b, err := form.EncodeToString(types.Block{Name: args.Name, Content: args.Content})
+	if err != nil {
+		return nil, err
+	}
+
+	pathName := fmt.Sprintf("/resources/%s/block/validate", args.Instance)
+	req, err := c.newRequest("POST", pathName, strings.NewReader(b), args.Instance)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		err = newErrUnexpectedStatusCodeFromResponse(response)
+		if isMissingEndpoint(err) {
+			return nil, ErrValidateBlockUnsupported
+		}
+
+		return nil, err
+	}
+
+	var result types.BlockValidation
+	if err = c.unmarshalBody(response, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// isMissingEndpoint tells whether err comes from an API server lacking the
+// requested route, which the router answers either with 405 Method Not
+// Allowed (when the path matches the route of another method) or with its
+// own 404 Not Found.
+func isMissingEndpoint(err error) bool {
+	httpErr, ok := err.(*ErrUnexpectedStatusCode)
+	if !ok {
+		return false
+	}
+
+	return httpErr.Status == http.StatusMethodNotAllowed ||
+		(httpErr.Status == http.StatusNotFound && strings.TrimSpace(httpErr.Body) == `{"message":"Not Found"}`)
+}
+
 func (args DeleteBlockArgs) Validate() error {
 	if args.Instance == "" {
 		return ErrMissingInstance
@@ -110,32 +178,40 @@ func (args ListBlocksArgs) Validate() error {
 }
 
 func (c *client) ListBlocks(ctx context.Context, args ListBlocksArgs) ([]types.Block, error) {
+	blocks, _, err := c.ListBlocksWithVersion(ctx, args)
+	return blocks, err
+}
+
+// ListBlocksWithVersion lists the blocks along with a version token, which
+// can be passed on UpdateBlockArgs to reject updates over stale blocks. The
+// version is empty when the API does not support it.
+func (c *client) ListBlocksWithVersion(ctx context.Context, args ListBlocksArgs) ([]types.Block, string, error) {
 	if err := args.Validate(); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	pathName := fmt.Sprintf("/resources/%s/block", args.Instance)
 	req, err := c.newRequest("GET", pathName, nil, args.Instance)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	response, err := c.do(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
-		return nil, newErrUnexpectedStatusCodeFromResponse(response)
+		return nil, "", newErrUnexpectedStatusCodeFromResponse(response)
 	}
 
 	var blockList struct {
 		Blocks []types.Block `json:"blocks"`
 	}
-	if err = unmarshalBody(response, &blockList); err != nil {
-		return nil, err
+	if err = c.unmarshalBody(response, &blockList); err != nil {
+		return nil, "", err
 	}
 
-	return blockList.Blocks, nil
+	return blockList.Blocks, strings.Trim(response.Header.Get("ETag"), `"`), nil
 }
@@ -59,6 +59,33 @@ func TestClientThroughTsuru_UpdateBlock(t *testing.T) {
 				w.WriteHeader(http.StatusOK)
 			},
 		},
+		{
+			name: "when version is set",
+			args: UpdateBlockArgs{
+				Instance: "my-instance",
+				Name:     "http",
+				Content:  "# NGINX configuration block",
+				Version:  "abc123",
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, `"abc123"`, r.Header.Get("If-Match"))
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		{
+			name: "when version is set and blocks were changed meanwhile",
+			args: UpdateBlockArgs{
+				Instance: "my-instance",
+				Name:     "http",
+				Content:  "# NGINX configuration block",
+				Version:  "abc123",
+			},
+			expectedError: "rpaasv2: blocks were changed concurrently",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprintf(w, "blocks were changed meanwhile")
+			},
+		},
 		{
 			name: "when the server returns an error",
 			args: UpdateBlockArgs{
@@ -88,6 +115,67 @@ func TestClientThroughTsuru_UpdateBlock(t *testing.T) {
 	}
 }
 
+func TestClientThroughTsuru_ValidateBlock(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          ValidateBlockArgs
+		expected      *types.BlockValidation
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when content is empty",
+			args:          ValidateBlockArgs{Instance: "my-instance", Name: "server"},
+			expectedError: "rpaasv2: content cannot be empty",
+		},
+		{
+			name: "when the server returns the expected response",
+			args: ValidateBlockArgs{Instance: "my-instance", Name: "server", Content: "lisen 80;"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method)
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/block/validate"), r.URL.RequestURI())
+				assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+				assert.Equal(t, "block_name=server&content=lisen+80%3B", getBody(t, r))
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"pod":"my-instance-0","valid":false,"output":"nginx: [emerg] unknown directive \"lisen\""}`)
+			},
+			expected: &types.BlockValidation{Pod: "my-instance-0", Output: `nginx: [emerg] unknown directive "lisen"`},
+		},
+		{
+			name: "when the server lacks the validation endpoint",
+			args: ValidateBlockArgs{Instance: "my-instance", Name: "server", Content: "listen 80;"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				fmt.Fprint(w, `{"message":"Method Not Allowed"}`)
+			},
+			expectedError: "rpaasv2: the API does not support validating blocks",
+		},
+		{
+			name: "when the instance is not found",
+			args: ValidateBlockArgs{Instance: "my-instance", Name: "server", Content: "listen 80;"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message":"rpaas instance \"my-instance\" not found"}`)
+			},
+			expectedError: `rpaasv2: unexpected status code: 404 Not Found, detail: {"message":"rpaas instance \"my-instance\" not found"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			result, err := client.ValidateBlock(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 func TestClientThroughTsuru_DeleteBlock(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -209,3 +297,18 @@ func TestClientThroughTsuru_ListBlocks(t *testing.T) {
 		})
 	}
 }
+
+func TestClientThroughTsuru_ListBlocksWithVersion(t *testing.T) {
+	client, server := newClientThroughTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, "GET")
+		assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/block"), r.URL.RequestURI())
+		w.Header().Set("ETag", `"abc123"`)
+		fmt.Fprintf(w, `{"blocks": [{"block_name": "http", "content": "Some HTTP conf"}]}`)
+	}))
+	defer server.Close()
+
+	blocks, version, err := client.ListBlocksWithVersion(context.TODO(), ListBlocksArgs{Instance: "my-instance"})
+	require.NoError(t, err)
+	assert.Equal(t, []types.Block{{Name: "http", Content: "Some HTTP conf"}}, blocks)
+	assert.Equal(t, "abc123", version)
+}
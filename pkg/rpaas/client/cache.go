@@ -0,0 +1,161 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+const DefaultCacheTTL = 5 * time.Minute
+
+type CacheOptions struct {
+	// Dir is the directory where the cached responses are stored.
+	Dir string
+
+	// TTL is how long a cached response is valid (default: 5m).
+	TTL time.Duration
+
+	// Refresh ignores the cached responses, fetching them again from the API.
+	Refresh bool
+
+	// Endpoint and Service identify the API the responses come from, so
+	// that responses from different APIs never get mixed up.
+	Endpoint string
+	Service  string
+}
+
+// NewCachingClient wraps c so that rarely-changing data (e.g. the available
+// flavors) is cached on disk, sparing requests to the API on commands which
+// run often.
+func NewCachingClient(c Client, opts CacheOptions) Client {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+
+	return &cachingClient{Client: c, opts: opts, now: time.Now}
+}
+
+type cachingClient struct {
+	Client
+
+	opts CacheOptions
+	now  func() time.Time
+}
+
+type cacheEntry struct {
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+var _ Client = &cachingClient{}
+
+func (c *cachingClient) ListFlavors(ctx context.Context, args ListFlavorsArgs) ([]types.Flavor, error) {
+	key := c.key("ListFlavors", args.Instance)
+
+	var flavors []types.Flavor
+	if c.load(key, &flavors) {
+		return flavors, nil
+	}
+
+	flavors, err := c.Client.ListFlavors(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(key, flavors)
+	return flavors, nil
+}
+
+func (c *cachingClient) SetService(service string) (Client, error) {
+	client, err := c.Client.SetService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := c.opts
+	opts.Service = service
+	return &cachingClient{Client: client, opts: opts, now: c.now}, nil
+}
+
+func (c *cachingClient) key(method string, args ...string) string {
+	parts := append([]string{c.opts.Endpoint, c.opts.Service, method}, args...)
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *cachingClient) path(key string) string {
+	return filepath.Join(c.opts.Dir, key+".json")
+}
+
+// load fills v with the cached response under key, returning whether it was
+// found and is still valid. Any failure reading the cache is handled as a
+// miss, so that it never prevents the command from working.
+func (c *cachingClient) load(key string, v interface{}) bool {
+	if c.opts.Refresh {
+		return false
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return false
+	}
+
+	var entry cacheEntry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return false
+	}
+
+	if age := c.now().Sub(entry.CreatedAt); age < 0 || age >= c.opts.TTL {
+		return false
+	}
+
+	return json.Unmarshal(entry.Data, v) == nil
+}
+
+// store writes v as the cached response under key. The cache file is written
+// elsewhere and then renamed, so that concurrent commands never read it half
+// written. Failures are ignored as the response is cached at best effort.
+func (c *cachingClient) store(key string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	data, err = json.Marshal(cacheEntry{CreatedAt: c.now(), Data: data})
+	if err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(c.opts.Dir, 0700); err != nil {
+		return
+	}
+
+	f, err := os.CreateTemp(c.opts.Dir, key+".*.tmp")
+	if err != nil {
+		return
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), c.path(key))
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+type flavorsClient struct {
+	Client
+
+	service string
+	calls   int
+	err     error
+}
+
+func (c *flavorsClient) ListFlavors(ctx context.Context, args ListFlavorsArgs) ([]types.Flavor, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return []types.Flavor{{Name: fmt.Sprintf("%s-%s-%d", c.service, args.Instance, c.calls)}}, nil
+}
+
+func (c *flavorsClient) SetService(service string) (Client, error) {
+	c.service = service
+	return c, nil
+}
+
+func TestCachingClient_ListFlavors(t *testing.T) {
+	now := time.Date(2023, time.May, 1, 12, 0, 0, 0, time.UTC)
+
+	newCachingClient := func(inner Client, opts CacheOptions) *cachingClient {
+		c := NewCachingClient(inner, opts).(*cachingClient)
+		c.now = func() time.Time { return now }
+		return c
+	}
+
+	t.Run("caches the flavors until the TTL expires", func(t *testing.T) {
+		dir := t.TempDir()
+		inner := &flavorsClient{service: "rpaasv2"}
+		c := newCachingClient(inner, CacheOptions{Dir: dir, TTL: time.Minute, Endpoint: "https://tsuru.example.com", Service: "rpaasv2"})
+
+		flavors, err := c.ListFlavors(context.TODO(), ListFlavorsArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, []types.Flavor{{Name: "rpaasv2-my-instance-1"}}, flavors)
+
+		now = now.Add(59 * time.Second)
+		flavors, err = c.ListFlavors(context.TODO(), ListFlavorsArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, []types.Flavor{{Name: "rpaasv2-my-instance-1"}}, flavors)
+		assert.Equal(t, 1, inner.calls)
+
+		now = now.Add(time.Second)
+		flavors, err = c.ListFlavors(context.TODO(), ListFlavorsArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, []types.Flavor{{Name: "rpaasv2-my-instance-2"}}, flavors)
+		assert.Equal(t, 2, inner.calls)
+
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		require.NoError(t, err)
+		assert.Len(t, files, 1, "temporary files should not be left behind")
+	})
+
+	t.Run("keeps a cache entry per endpoint, service and instance", func(t *testing.T) {
+		dir := t.TempDir()
+		inner := &flavorsClient{service: "rpaasv2"}
+		c := newCachingClient(inner, CacheOptions{Dir: dir, Endpoint: "https://tsuru.example.com", Service: "rpaasv2"})
+
+		_, err := c.ListFlavors(context.TODO(), ListFlavorsArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+
+		_, err = c.ListFlavors(context.TODO(), ListFlavorsArgs{Instance: "other-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, 2, inner.calls)
+
+		other, err := c.SetService("rpaasv2-other")
+		require.NoError(t, err)
+
+		flavors, err := other.ListFlavors(context.TODO(), ListFlavorsArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, []types.Flavor{{Name: "rpaasv2-other-my-instance-3"}}, flavors)
+
+		c = newCachingClient(inner, CacheOptions{Dir: dir, Endpoint: "https://other-tsuru.example.com", Service: "rpaasv2"})
+		_, err = c.ListFlavors(context.TODO(), ListFlavorsArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, 4, inner.calls)
+	})
+
+	t.Run("refreshing the cache", func(t *testing.T) {
+		dir := t.TempDir()
+		inner := &flavorsClient{}
+
+		_, err := newCachingClient(inner, CacheOptions{Dir: dir}).ListFlavors(context.TODO(), ListFlavorsArgs{})
+		require.NoError(t, err)
+
+		flavors, err := newCachingClient(inner, CacheOptions{Dir: dir, Refresh: true}).ListFlavors(context.TODO(), ListFlavorsArgs{})
+		require.NoError(t, err)
+		assert.Equal(t, []types.Flavor{{Name: "--2"}}, flavors)
+
+		flavors, err = newCachingClient(inner, CacheOptions{Dir: dir}).ListFlavors(context.TODO(), ListFlavorsArgs{})
+		require.NoError(t, err)
+		assert.Equal(t, []types.Flavor{{Name: "--2"}}, flavors)
+		assert.Equal(t, 2, inner.calls)
+	})
+
+	t.Run("does not cache errors nor fail on a corrupted cache", func(t *testing.T) {
+		dir := t.TempDir()
+		inner := &flavorsClient{err: fmt.Errorf("some error")}
+		c := newCachingClient(inner, CacheOptions{Dir: dir})
+
+		_, err := c.ListFlavors(context.TODO(), ListFlavorsArgs{})
+		assert.EqualError(t, err, "some error")
+
+		inner.err = nil
+		require.NoError(t, os.WriteFile(c.path(c.key("ListFlavors", "")), []byte("not a JSON"), 0600))
+
+		flavors, err := c.ListFlavors(context.TODO(), ListFlavorsArgs{})
+		require.NoError(t, err)
+		assert.Equal(t, []types.Flavor{{Name: "--2"}}, flavors)
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
@@ -71,6 +72,18 @@ func (c *client) UpdateCertificate(ctx context.Context, args UpdateCertificateAr
 		return err
 	}
 
+	if args.StapleOCSP != nil {
+		if err = w.WriteField("staple-ocsp", strconv.FormatBool(*args.StapleOCSP)); err != nil {
+			return err
+		}
+	}
+
+	if args.RotationOf != "" {
+		if err = w.WriteField("rotation-of", args.RotationOf); err != nil {
+			return err
+		}
+	}
+
 	if err = w.Close(); err != nil {
 		return err
 	}
@@ -127,6 +140,125 @@ func (c *client) DeleteCertificate(ctx context.Context, args DeleteCertificateAr
 	return nil
 }
 
+func (args ListCertificatesArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	return nil
+}
+
+func (c *client) ListCertificates(ctx context.Context, args ListCertificatesArgs) ([]types.Certificate, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest("GET", fmt.Sprintf("/resources/%s/certificate", args.Instance), nil, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	var certs []types.Certificate
+	if err = c.unmarshalBody(response, &certs); err != nil {
+		return nil, err
+	}
+
+	return certs, nil
+}
+
+func (args CertificateStatusArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	if args.Name == "" {
+		return ErrMissingCertificateName
+	}
+
+	return nil
+}
+
+func (c *client) GetCertificateStatus(ctx context.Context, args CertificateStatusArgs) ([]types.PodCertificateStatus, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	pathName := fmt.Sprintf("/resources/%s/certificate/%s/status", args.Instance, url.PathEscape(args.Name))
+	req, err := c.newRequest("GET", pathName, nil, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	var status []types.PodCertificateStatus
+	if err = c.unmarshalBody(response, &status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+func (args ExportCertificatesArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	return nil
+}
+
+func (c *client) ExportCertificates(ctx context.Context, args ExportCertificatesArgs) ([]types.PublicCertificate, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	qs := url.Values{}
+	if args.Name != "" {
+		qs.Set("name", args.Name)
+	}
+
+	pathName := fmt.Sprintf("/resources/%s/certificate/export", args.Instance)
+	req, err := c.newRequestWithQueryString("GET", pathName, nil, args.Instance, qs)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	var certs []types.PublicCertificate
+	if err = c.unmarshalBody(response, &certs); err != nil {
+		return nil, err
+	}
+
+	return certs, nil
+}
+
 func (c *client) ListCertManagerRequests(ctx context.Context, instance string) ([]types.CertManager, error) {
 	if instance == "" {
 		return nil, ErrMissingInstance
@@ -148,7 +280,7 @@ func (c *client) ListCertManagerRequests(ctx context.Context, instance string) (
 	}
 
 	var cmRequests []types.CertManager
-	err = json.NewDecoder(response.Body).Decode(&cmRequests)
+	err = c.unmarshalBody(response, &cmRequests)
 	if err != nil {
 		return nil, err
 	}
@@ -65,6 +65,36 @@ func TestClientThroughTsuru_UpdateCertificate(t *testing.T) {
 				w.WriteHeader(http.StatusOK)
 			},
 		},
+		{
+			name: "when enabling OCSP stapling",
+			args: UpdateCertificateArgs{
+				Instance:    "my-instance",
+				Name:        "my-cert",
+				Certificate: `my certificate`,
+				Key:         `my key`,
+				StapleOCSP:  func(b bool) *bool { return &b }(true),
+				boundary:    "custom-boundary",
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "--custom-boundary\r\nContent-Disposition: form-data; name=\"cert\"; filename=\"cert.pem\"\r\nContent-Type: application/octet-stream\r\n\r\nmy certificate\r\n--custom-boundary\r\nContent-Disposition: form-data; name=\"key\"; filename=\"key.pem\"\r\nContent-Type: application/octet-stream\r\n\r\nmy key\r\n--custom-boundary\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\nmy-cert\r\n--custom-boundary\r\nContent-Disposition: form-data; name=\"staple-ocsp\"\r\n\r\ntrue\r\n--custom-boundary--\r\n", getBody(t, r))
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+		{
+			name: "when updating a certificate taking part in a rotation",
+			args: UpdateCertificateArgs{
+				Instance:    "my-instance",
+				Name:        "my-cert-rotate",
+				Certificate: `my certificate`,
+				Key:         `my key`,
+				RotationOf:  "my-cert",
+				boundary:    "custom-boundary",
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "--custom-boundary\r\nContent-Disposition: form-data; name=\"cert\"; filename=\"cert.pem\"\r\nContent-Type: application/octet-stream\r\n\r\nmy certificate\r\n--custom-boundary\r\nContent-Disposition: form-data; name=\"key\"; filename=\"key.pem\"\r\nContent-Type: application/octet-stream\r\n\r\nmy key\r\n--custom-boundary\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\nmy-cert-rotate\r\n--custom-boundary\r\nContent-Disposition: form-data; name=\"rotation-of\"\r\n\r\nmy-cert\r\n--custom-boundary--\r\n", getBody(t, r))
+				w.WriteHeader(http.StatusOK)
+			},
+		},
 		{
 			name: "when the server returns an error",
 			args: UpdateCertificateArgs{
@@ -171,6 +201,178 @@ func TestClientThroughTsuru_DeleteCertificate(t *testing.T) {
 	}
 }
 
+func TestClientThroughTsuru_ListCertificates(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          ListCertificatesArgs
+		expected      []types.Certificate
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when the server returns an error",
+			args:          ListCertificatesArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: unexpected status code: 404 Not Found, detail: instance not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "instance not found")
+			},
+		},
+		{
+			name: "when the server returns the certificates",
+			args: ListCertificatesArgs{Instance: "my-instance"},
+			expected: []types.Certificate{
+				{Name: "default", Certificate: "--- some cert ---", Key: "*** private ***"},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "GET")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/certificate"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				fmt.Fprintf(w, `[{"name": "default", "certificate": "--- some cert ---", "key": "*** private ***"}]`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			certs, err := client.ListCertificates(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, certs)
+		})
+	}
+}
+
+func TestClientThroughTsuru_GetCertificateStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          CertificateStatusArgs
+		expected      []types.PodCertificateStatus
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when certificate name is empty",
+			args:          CertificateStatusArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: certificate name cannot be empty",
+		},
+		{
+			name:          "when the server returns an error",
+			args:          CertificateStatusArgs{Instance: "my-instance", Name: "default"},
+			expectedError: "rpaasv2: unexpected status code: 404 Not Found, detail: certificate not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "certificate not found")
+			},
+		},
+		{
+			name: "when the server returns the certificate status",
+			args: CertificateStatusArgs{Instance: "my-instance", Name: "default"},
+			expected: []types.PodCertificateStatus{
+				{Pod: "my-instance-abc", Fingerprints: []string{"abc", "def"}},
+				{Pod: "my-instance-def", Error: "pod is not running"},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "GET")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/certificate/default/status"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				fmt.Fprintf(w, `[{"pod": "my-instance-abc", "fingerprints": ["abc", "def"]}, {"pod": "my-instance-def", "error": "pod is not running"}]`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			status, err := client.GetCertificateStatus(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, status)
+		})
+	}
+}
+
+func TestClientThroughTsuru_ExportCertificates(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          ExportCertificatesArgs
+		expected      []types.PublicCertificate
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when the server returns an error",
+			args:          ExportCertificatesArgs{Instance: "my-instance", Name: "not-found"},
+			expectedError: "rpaasv2: unexpected status code: 404 Not Found, detail: certificate not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "certificate not found")
+			},
+		},
+		{
+			name: "when exporting every certificate",
+			args: ExportCertificatesArgs{Instance: "my-instance"},
+			expected: []types.PublicCertificate{
+				{Name: "default", Certificate: "my certificate chain"},
+				{Name: "example.com", Certificate: "another certificate chain"},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "GET")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/certificate/export"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				fmt.Fprintf(w, `[{"name": "default", "certificate": "my certificate chain"}, {"name": "example.com", "certificate": "another certificate chain"}]`)
+			},
+		},
+		{
+			name: "when exporting a single certificate",
+			args: ExportCertificatesArgs{Instance: "my-instance", Name: "example.com"},
+			expected: []types.PublicCertificate{
+				{Name: "example.com", Certificate: "another certificate chain"},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "GET")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s&name=example.com", FakeTsuruService, "my-instance", "/resources/my-instance/certificate/export"), r.URL.RequestURI())
+				fmt.Fprintf(w, `[{"name": "example.com", "certificate": "another certificate chain"}]`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			certs, err := client.ExportCertificates(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, certs)
+		})
+	}
+}
+
 func TestClientThroughTsuru_ListCertManagerRequests(t *testing.T) {
 	tests := map[string]struct {
 		instance      string
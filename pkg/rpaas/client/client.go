@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
@@ -19,6 +20,72 @@ type ScaleArgs struct {
 	Replicas int32
 }
 
+type RestartArgs struct {
+	Instance  string
+	Immediate bool
+}
+
+type PurgeCacheArgs struct {
+	Instance     string
+	Paths        []string
+	PreservePath bool
+	// PathRegexp makes every path be taken as a POSIX extended regular
+	// expression matched against the keys of the cached objects.
+	PathRegexp bool
+}
+
+type ConnectionStatsArgs struct {
+	Instance string
+}
+
+type PodsHealthArgs struct {
+	Instance string
+}
+
+type PodsUsageArgs struct {
+	Instance string
+}
+
+type ListPodsArgs struct {
+	Instance string
+	Selector string
+}
+
+type ListBindsArgs struct {
+	Instance string
+}
+
+type GetVersionArgs struct {
+	Instance string
+}
+
+type ListFlavorsArgs struct {
+	Instance string
+}
+
+type GetFlavorArgs struct {
+	Instance string
+	Name     string
+}
+
+type CreateInstanceArgs struct {
+	Instance    string
+	Team        string
+	Plan        string
+	Description string
+	Tags        []string
+	Flavors     []string
+}
+
+type DeleteInstanceArgs struct {
+	Instance string
+}
+
+type UpdateFlavorsArgs struct {
+	Instance string
+	Flavors  []string
+}
+
 type ExtraFilesArgs struct {
 	Instance string
 	Files    []types.RpaasFile
@@ -44,18 +111,65 @@ type UpdateCertificateArgs struct {
 	Certificate string
 	Key         string
 
+	// StapleOCSP enables or disables the OCSP stapling for the certificate,
+	// keeping it unchanged when nil.
+	StapleOCSP *bool
+
+	// RotationOf is the rotation counterpart of the certificate (see
+	// types.CertificateRotationSuffix), whose DNS names it's allowed to share.
+	RotationOf string
+
 	boundary string
 }
 
+type GetMetadataArgs struct {
+	Instance string
+}
+
+type SetMetadataArgs struct {
+	Instance string
+	Metadata types.Metadata
+}
+
+type UnsetMetadataArgs struct {
+	Instance string
+	Metadata types.Metadata
+}
+
+type ListCertificatesArgs struct {
+	Instance string
+}
+
+type ExportCertificatesArgs struct {
+	Instance string
+	Name     string
+}
+
+type CertificateStatusArgs struct {
+	Instance string
+	Name     string
+}
+
 type DeleteCertificateArgs struct {
 	Instance string
 	Name     string
 }
 
+type ValidateBlockArgs struct {
+	Instance string
+	Name     string
+	Content  string
+}
+
 type UpdateBlockArgs struct {
 	Instance string
 	Name     string
 	Content  string
+
+	// Version, when set, makes the update fail with ErrBlocksConflict
+	// if the blocks were changed since they were listed with this
+	// version (see ListBlocksWithVersion).
+	Version string
 }
 
 type DeleteBlockArgs struct {
@@ -82,6 +196,10 @@ type UpdateRouteArgs struct {
 	Destination string
 	HTTPSOnly   bool
 	Content     string
+
+	// Destinations splits the traffic of the route among many backends
+	// according to their weights (should not be combined with Destination).
+	Destinations []types.WeightedDestination
 }
 
 type InfoArgs struct {
@@ -89,6 +207,10 @@ type InfoArgs struct {
 	Raw      bool
 }
 
+type HistoryArgs struct {
+	Instance string
+}
+
 type GetAutoscaleArgs struct {
 	Instance string
 	Raw      bool
@@ -141,6 +263,13 @@ type LogArgs struct {
 	Lines     int
 	Follow    bool
 	Color     bool
+
+	// RunningOnly restricts the logs to pods in the Running phase. It's
+	// ignored when Pod is set.
+	RunningOnly bool
+	// RelistInterval is how often the running pods are listed again while
+	// following logs with RunningOnly (default: 5s).
+	RelistInterval time.Duration
 }
 
 type UpdateCertManagerArgs struct {
@@ -149,15 +278,38 @@ type UpdateCertManagerArgs struct {
 }
 
 type Client interface {
+	CreateInstance(ctx context.Context, args CreateInstanceArgs) error
+	DeleteInstance(ctx context.Context, args DeleteInstanceArgs) error
 	GetPlans(ctx context.Context, instance string) ([]types.Plan, error)
+	GetVersion(ctx context.Context, args GetVersionArgs) (*types.Version, error)
 	GetFlavors(ctx context.Context, instance string) ([]types.Flavor, error)
+	ListFlavors(ctx context.Context, args ListFlavorsArgs) ([]types.Flavor, error)
+	GetFlavor(ctx context.Context, args GetFlavorArgs) (*types.FlavorInfo, error)
+	UpdateFlavors(ctx context.Context, args UpdateFlavorsArgs) error
 	Scale(ctx context.Context, args ScaleArgs) error
+	Restart(ctx context.Context, args RestartArgs) ([]string, error)
+	PurgeCache(ctx context.Context, args PurgeCacheArgs) ([]types.PurgeCacheResult, error)
 	Info(ctx context.Context, args InfoArgs) (*types.InstanceInfo, error)
+	GetHistory(ctx context.Context, args HistoryArgs) ([]types.HistoryEntry, error)
+	GetAutoscaleObject(ctx context.Context, args GetAutoscaleArgs) (*unstructured.Unstructured, error)
+	GetConnectionStats(ctx context.Context, args ConnectionStatsArgs) ([]types.PodConnectionStats, error)
+	GetPodsHealth(ctx context.Context, args PodsHealthArgs) ([]types.PodHealth, error)
+	GetPodsUsage(ctx context.Context, args PodsUsageArgs) ([]types.PodUsage, error)
+	ListPods(ctx context.Context, args ListPodsArgs) ([]types.Pod, error)
+	ListBinds(ctx context.Context, args ListBindsArgs) ([]types.BindStatus, error)
+	GetMetadata(ctx context.Context, args GetMetadataArgs) (*types.Metadata, error)
+	SetMetadata(ctx context.Context, args SetMetadataArgs) error
+	UnsetMetadata(ctx context.Context, args UnsetMetadataArgs) error
 	UpdateCertificate(ctx context.Context, args UpdateCertificateArgs) error
 	DeleteCertificate(ctx context.Context, args DeleteCertificateArgs) error
+	ListCertificates(ctx context.Context, args ListCertificatesArgs) ([]types.Certificate, error)
+	GetCertificateStatus(ctx context.Context, args CertificateStatusArgs) ([]types.PodCertificateStatus, error)
+	ExportCertificates(ctx context.Context, args ExportCertificatesArgs) ([]types.PublicCertificate, error)
 	UpdateBlock(ctx context.Context, args UpdateBlockArgs) error
+	ValidateBlock(ctx context.Context, args ValidateBlockArgs) (*types.BlockValidation, error)
 	DeleteBlock(ctx context.Context, args DeleteBlockArgs) error
 	ListBlocks(ctx context.Context, args ListBlocksArgs) ([]types.Block, error)
+	ListBlocksWithVersion(ctx context.Context, args ListBlocksArgs) ([]types.Block, string, error)
 	DeleteRoute(ctx context.Context, args DeleteRouteArgs) error
 	ListRoutes(ctx context.Context, args ListRoutesArgs) ([]types.Route, error)
 	UpdateRoute(ctx context.Context, args UpdateRouteArgs) error
@@ -52,7 +52,7 @@ func (c *client) Debug(ctx context.Context, args DebugArgs) (*websocket.Conn, er
 
 	u.RawQuery = qs.Encode()
 
-	conn, _, err := c.ws.DialContext(ctx, u.String(), c.baseAuthHeader(nil))
+	conn, err := c.dialWebsocket(ctx, u.String())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,350 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package direct implements the RPaaS client on top of the Kubernetes API,
+// reading the RpaasInstance resources (and the objects generated from them)
+// straight from the cluster. It's meant for clusters whose RPaaS API is not
+// reachable, e.g. air-gapped ones.
+//
+// Only read operations are supported: the API is in charge of validating
+// and defaulting what's written to the instances, so every other operation
+// fails with ErrReadOnly.
+package direct
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gorilla/websocket"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	sigsk8sclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+	extensionsruntime "github.com/tsuru/rpaas-operator/pkg/runtime"
+)
+
+// ErrReadOnly is returned by the operations which need the RPaaS API.
+var ErrReadOnly = errors.New("rpaasv2: only info, autoscale info, blocks list and routes list are supported when talking directly to the cluster")
+
+var _ rpaasclient.Client = (*Client)(nil)
+
+type Options struct {
+	// Kubeconfig is the path of the kubeconfig file. When empty, the usual
+	// rules apply: the KUBECONFIG environment variable, ~/.kube/config or
+	// the in-cluster config, in this order.
+	Kubeconfig string
+
+	// Context is the kubeconfig context to use, defaults to the current one.
+	Context string
+
+	// Service is the name of the service the instances belong to, which is
+	// also the namespace they live in (defaults to rpaasv2).
+	Service string
+}
+
+type Client struct {
+	manager    rpaas.RpaasManager
+	newManager func(service string) (rpaas.RpaasManager, error)
+}
+
+// New returns a client which reads the instances from the cluster set on
+// the kubeconfig.
+func New(opts Options) (*Client, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = opts.Kubeconfig
+
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: opts.Context}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	k8sClient, err := sigsk8sclient.New(restConfig, sigsk8sclient.Options{Scheme: extensionsruntime.NewScheme()})
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(opts.Service, func(service string) (rpaas.RpaasManager, error) {
+		return rpaas.NewK8SForService(restConfig, k8sClient, service)
+	})
+}
+
+// newClient returns a client whose manager, made by newManager, looks for the
+// instances on the namespace of service.
+func newClient(service string, newManager func(service string) (rpaas.RpaasManager, error)) (*Client, error) {
+	manager, err := newManager(service)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{manager: manager, newManager: newManager}, nil
+}
+
+func (c *Client) Info(ctx context.Context, args rpaasclient.InfoArgs) (*types.InstanceInfo, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c.manager.GetInstanceInfo(ctx, args.Instance)
+}
+
+// GetAutoscale returns the autoscale settings of the instance, which the
+// CLI otherwise gets from the API through the autogenerated client.
+func (c *Client) GetAutoscale(ctx context.Context, instance string) (*autogenerated.Autoscale, error) {
+	return c.manager.GetAutoscale(ctx, instance)
+}
+
+func (c *Client) GetAutoscaleObject(ctx context.Context, args rpaasclient.GetAutoscaleArgs) (*unstructured.Unstructured, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	obj, err := c.manager.GetAutoscaleObject(ctx, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &unstructured.Unstructured{Object: content}, nil
+}
+
+func (c *Client) ListBlocks(ctx context.Context, args rpaasclient.ListBlocksArgs) ([]types.Block, error) {
+	blocks, _, err := c.ListBlocksWithVersion(ctx, args)
+	return blocks, err
+}
+
+func (c *Client) ListBlocksWithVersion(ctx context.Context, args rpaasclient.ListBlocksArgs) ([]types.Block, string, error) {
+	if err := args.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	blocks, err := c.manager.ListBlocks(ctx, args.Instance)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var result []types.Block
+	for _, b := range blocks {
+		result = append(result, types.Block{Name: b.Name, Content: b.Content})
+	}
+
+	return result, rpaas.BlocksVersion(blocks), nil
+}
+
+func (c *Client) ListRoutes(ctx context.Context, args rpaasclient.ListRoutesArgs) ([]types.Route, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	routes, err := c.manager.GetRoutes(ctx, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []types.Route
+	for _, r := range routes {
+		route := types.Route{
+			Path:        r.Path,
+			Destination: r.Destination,
+			HTTPSOnly:   r.HTTPSOnly,
+			Content:     r.Content,
+		}
+
+		for _, d := range r.Destinations {
+			route.Destinations = append(route.Destinations, types.WeightedDestination{Destination: d.Destination, Weight: d.Weight})
+		}
+
+		result = append(result, route)
+	}
+
+	return result, nil
+}
+
+func (c *Client) SetService(service string) (rpaasclient.Client, error) {
+	return newClient(service, c.newManager)
+}
+
+func (c *Client) CreateInstance(ctx context.Context, args rpaasclient.CreateInstanceArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) DeleteInstance(ctx context.Context, args rpaasclient.DeleteInstanceArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) GetPlans(ctx context.Context, instance string) ([]types.Plan, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) GetVersion(ctx context.Context, args rpaasclient.GetVersionArgs) (*types.Version, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) GetFlavors(ctx context.Context, instance string) ([]types.Flavor, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) ListFlavors(ctx context.Context, args rpaasclient.ListFlavorsArgs) ([]types.Flavor, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) GetFlavor(ctx context.Context, args rpaasclient.GetFlavorArgs) (*types.FlavorInfo, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) UpdateFlavors(ctx context.Context, args rpaasclient.UpdateFlavorsArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) Scale(ctx context.Context, args rpaasclient.ScaleArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) Restart(ctx context.Context, args rpaasclient.RestartArgs) ([]string, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) PurgeCache(ctx context.Context, args rpaasclient.PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) GetHistory(ctx context.Context, args rpaasclient.HistoryArgs) ([]types.HistoryEntry, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) GetConnectionStats(ctx context.Context, args rpaasclient.ConnectionStatsArgs) ([]types.PodConnectionStats, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) GetPodsHealth(ctx context.Context, args rpaasclient.PodsHealthArgs) ([]types.PodHealth, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) GetPodsUsage(ctx context.Context, args rpaasclient.PodsUsageArgs) ([]types.PodUsage, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) ListPods(ctx context.Context, args rpaasclient.ListPodsArgs) ([]types.Pod, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) ListBinds(ctx context.Context, args rpaasclient.ListBindsArgs) ([]types.BindStatus, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) GetMetadata(ctx context.Context, args rpaasclient.GetMetadataArgs) (*types.Metadata, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) SetMetadata(ctx context.Context, args rpaasclient.SetMetadataArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) UnsetMetadata(ctx context.Context, args rpaasclient.UnsetMetadataArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) UpdateCertificate(ctx context.Context, args rpaasclient.UpdateCertificateArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) DeleteCertificate(ctx context.Context, args rpaasclient.DeleteCertificateArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) ListCertificates(ctx context.Context, args rpaasclient.ListCertificatesArgs) ([]types.Certificate, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) GetCertificateStatus(ctx context.Context, args rpaasclient.CertificateStatusArgs) ([]types.PodCertificateStatus, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) ExportCertificates(ctx context.Context, args rpaasclient.ExportCertificatesArgs) ([]types.PublicCertificate, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) UpdateBlock(ctx context.Context, args rpaasclient.UpdateBlockArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) ValidateBlock(ctx context.Context, args rpaasclient.ValidateBlockArgs) (*types.BlockValidation, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) DeleteBlock(ctx context.Context, args rpaasclient.DeleteBlockArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) DeleteRoute(ctx context.Context, args rpaasclient.DeleteRouteArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) UpdateRoute(ctx context.Context, args rpaasclient.UpdateRouteArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) Exec(ctx context.Context, args rpaasclient.ExecArgs) (*websocket.Conn, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) Debug(ctx context.Context, args rpaasclient.DebugArgs) (*websocket.Conn, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) Log(ctx context.Context, args rpaasclient.LogArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) AddExtraFiles(ctx context.Context, args rpaasclient.ExtraFilesArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) UpdateExtraFiles(ctx context.Context, args rpaasclient.ExtraFilesArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) DeleteExtraFiles(ctx context.Context, args rpaasclient.DeleteExtraFilesArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) ListExtraFiles(ctx context.Context, args rpaasclient.ListExtraFilesArgs) ([]types.RpaasFile, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) GetExtraFile(ctx context.Context, args rpaasclient.GetExtraFileArgs) (types.RpaasFile, error) {
+	return types.RpaasFile{}, ErrReadOnly
+}
+
+func (c *Client) AddAccessControlList(ctx context.Context, instance, host string, port int) error {
+	return ErrReadOnly
+}
+
+func (c *Client) ListAccessControlList(ctx context.Context, instance string) ([]types.AllowedUpstream, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) RemoveAccessControlList(ctx context.Context, instance, host string, port int) error {
+	return ErrReadOnly
+}
+
+func (c *Client) ListCertManagerRequests(ctx context.Context, instance string) ([]types.CertManager, error) {
+	return nil, ErrReadOnly
+}
+
+func (c *Client) UpdateCertManager(ctx context.Context, args rpaasclient.UpdateCertManagerArgs) error {
+	return ErrReadOnly
+}
+
+func (c *Client) DeleteCertManager(ctx context.Context, instance, issuer string) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package direct
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tsuru/rpaas-operator/api/v1alpha1"
+	"github.com/tsuru/rpaas-operator/internal/config"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+	rpaasclient "github.com/tsuru/rpaas-operator/pkg/rpaas/client"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestClient_ReadOperations(t *testing.T) {
+	manager := &fake.RpaasManager{
+		FakeGetInstanceInfo: func(instanceName string) (*types.InstanceInfo, error) {
+			assert.Equal(t, "my-instance", instanceName)
+			return &types.InstanceInfo{Name: "my-instance", Team: "team-one"}, nil
+		},
+		FakeGetAutoscale: func(name string) (*autogenerated.Autoscale, error) {
+			return &autogenerated.Autoscale{MinReplicas: 2, MaxReplicas: 10}, nil
+		},
+		FakeGetAutoscaleObject: func(instanceName string) (client.Object, error) {
+			return &autoscalingv2.HorizontalPodAutoscaler{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler"},
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "rpaasv2-be"},
+				Spec:       autoscalingv2.HorizontalPodAutoscalerSpec{MaxReplicas: 10},
+			}, nil
+		},
+		FakeListBlocks: func(instanceName string) ([]rpaas.ConfigurationBlock, error) {
+			return []rpaas.ConfigurationBlock{{Name: "http", Content: "# some nginx config"}}, nil
+		},
+		FakeGetRoutes: func(instanceName string) ([]rpaas.Route, error) {
+			return []rpaas.Route{
+				{Path: "/app", Destination: "app.tsuru.example.com", HTTPSOnly: true},
+				{Path: "/canary", Destinations: []v1alpha1.WeightedDestination{{Destination: "v1.example.com", Weight: 9}, {Destination: "v2.example.com", Weight: 1}}},
+			}, nil
+		},
+	}
+
+	c, err := newClient("rpaasv2-be", func(service string) (rpaas.RpaasManager, error) {
+		assert.Equal(t, "rpaasv2-be", service)
+		return manager, nil
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	info, err := c.Info(ctx, rpaasclient.InfoArgs{Instance: "my-instance"})
+	require.NoError(t, err)
+	assert.Equal(t, &types.InstanceInfo{Name: "my-instance", Team: "team-one"}, info)
+
+	autoscale, err := c.GetAutoscale(ctx, "my-instance")
+	require.NoError(t, err)
+	assert.Equal(t, &autogenerated.Autoscale{MinReplicas: 2, MaxReplicas: 10}, autoscale)
+
+	obj, err := c.GetAutoscaleObject(ctx, rpaasclient.GetAutoscaleArgs{Instance: "my-instance"})
+	require.NoError(t, err)
+	assert.Equal(t, "HorizontalPodAutoscaler", obj.GetKind())
+	assert.Equal(t, "my-instance", obj.GetName())
+
+	blocks, version, err := c.ListBlocksWithVersion(ctx, rpaasclient.ListBlocksArgs{Instance: "my-instance"})
+	require.NoError(t, err)
+	assert.Equal(t, []types.Block{{Name: "http", Content: "# some nginx config"}}, blocks)
+	assert.Equal(t, rpaas.BlocksVersion([]rpaas.ConfigurationBlock{{Name: "http", Content: "# some nginx config"}}), version)
+
+	routes, err := c.ListRoutes(ctx, rpaasclient.ListRoutesArgs{Instance: "my-instance"})
+	require.NoError(t, err)
+	assert.Equal(t, []types.Route{
+		{Path: "/app", Destination: "app.tsuru.example.com", HTTPSOnly: true},
+		{Path: "/canary", Destinations: []types.WeightedDestination{{Destination: "v1.example.com", Weight: 9}, {Destination: "v2.example.com", Weight: 1}}},
+	}, routes)
+
+	_, err = c.ListRoutes(ctx, rpaasclient.ListRoutesArgs{})
+	assert.EqualError(t, err, "rpaasv2: instance cannot be empty")
+}
+
+func TestClient_SetService(t *testing.T) {
+	cfg := config.Get()
+	defer config.Set(cfg)
+
+	managers := map[string]rpaas.RpaasManager{
+		"rpaasv2-be": &fake.RpaasManager{
+			FakeGetInstanceInfo: func(instanceName string) (*types.InstanceInfo, error) {
+				return &types.InstanceInfo{Name: instanceName, Service: "rpaasv2-be"}, nil
+			},
+		},
+		"rpaasv2-fe": &fake.RpaasManager{
+			FakeGetInstanceInfo: func(instanceName string) (*types.InstanceInfo, error) {
+				return &types.InstanceInfo{Name: instanceName, Service: "rpaasv2-fe"}, nil
+			},
+		},
+	}
+
+	c, err := newClient("rpaasv2-be", func(service string) (rpaas.RpaasManager, error) {
+		return managers[service], nil
+	})
+	require.NoError(t, err)
+
+	other, err := c.SetService("rpaasv2-fe")
+	require.NoError(t, err)
+
+	info, err := other.Info(context.Background(), rpaasclient.InfoArgs{Instance: "my-instance"})
+	require.NoError(t, err)
+	assert.Equal(t, "rpaasv2-fe", info.Service)
+
+	info, err = c.Info(context.Background(), rpaasclient.InfoArgs{Instance: "my-instance"})
+	require.NoError(t, err)
+	assert.Equal(t, "rpaasv2-be", info.Service)
+
+	assert.Equal(t, cfg, config.Get())
+}
+
+func TestClient_WriteOperations(t *testing.T) {
+	c, err := newClient("", func(service string) (rpaas.RpaasManager, error) {
+		return &fake.RpaasManager{}, nil
+	})
+	require.NoError(t, err)
+
+	err = c.Scale(context.Background(), rpaasclient.ScaleArgs{Instance: "my-instance", Replicas: 2})
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	err = c.UpdateRoute(context.Background(), rpaasclient.UpdateRouteArgs{Instance: "my-instance", Path: "/app", Destination: "app.tsuru.example.com"})
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	_, err = c.ListPods(context.Background(), rpaasclient.ListPodsArgs{Instance: "my-instance"})
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
@@ -12,6 +12,8 @@ import (
 	"strconv"
 
 	"github.com/gorilla/websocket"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func (args ExecArgs) Validate() error {
@@ -55,7 +57,7 @@ func (c *client) Exec(ctx context.Context, args ExecArgs) (*websocket.Conn, erro
 
 	u.RawQuery = qs.Encode()
 
-	conn, _, err := c.ws.DialContext(ctx, u.String(), c.baseAuthHeader(nil))
+	conn, err := c.dialWebsocket(ctx, u.String(), types.ExecChannelProtocol)
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +50,7 @@ func TestClientThroughTsuru_Exec(t *testing.T) {
 				called = true
 				assert.True(t, websocket.IsWebSocketUpgrade(r))
 				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				assert.Equal(t, []string{"v1.channel.rpaas.tsuru.io"}, websocket.Subprotocols(r))
 				expectedQS := url.Values{}
 				expectedQS.Set("callback", "/resources/my-instance/exec")
 				expectedQS.Set("ws", "true")
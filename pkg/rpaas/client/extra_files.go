@@ -180,7 +180,7 @@ func (c *client) ListExtraFiles(ctx context.Context, args ListExtraFilesArgs) ([
 	}
 
 	var fileList []types.RpaasFile
-	err = json.NewDecoder(response.Body).Decode(&fileList)
+	err = c.unmarshalBody(response, &fileList)
 	if err != nil {
 		return nil, err
 	}
@@ -208,7 +208,7 @@ func (c *client) GetExtraFile(ctx context.Context, args GetExtraFileArgs) (types
 	}
 
 	var file types.RpaasFile
-	err = json.NewDecoder(response.Body).Decode(&file)
+	err = c.unmarshalBody(response, &file)
 	if err != nil {
 		return types.RpaasFile{}, err
 	}
@@ -8,6 +8,7 @@ import (
 	"context"
 
 	"github.com/gorilla/websocket"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client"
 	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
@@ -16,18 +17,40 @@ import (
 var _ client.Client = (*FakeClient)(nil)
 
 type FakeClient struct {
+	FakeGetVersion              func(args client.GetVersionArgs) (*types.Version, error)
+	FakeCreateInstance          func(args client.CreateInstanceArgs) error
+	FakeDeleteInstance          func(args client.DeleteInstanceArgs) error
 	FakeGetPlans                func(instance string) ([]types.Plan, error)
 	FakeGetFlavors              func(instance string) ([]types.Flavor, error)
+	FakeListFlavors             func(args client.ListFlavorsArgs) ([]types.Flavor, error)
+	FakeGetFlavor               func(args client.GetFlavorArgs) (*types.FlavorInfo, error)
+	FakeUpdateFlavors           func(args client.UpdateFlavorsArgs) error
 	FakeScale                   func(args client.ScaleArgs) error
+	FakeRestart                 func(args client.RestartArgs) ([]string, error)
+	FakePurgeCache              func(args client.PurgeCacheArgs) ([]types.PurgeCacheResult, error)
 	FakeUpdateCertificate       func(args client.UpdateCertificateArgs) error
 	FakeDeleteCertificate       func(args client.DeleteCertificateArgs) error
+	FakeListCertificates        func(args client.ListCertificatesArgs) ([]types.Certificate, error)
+	FakeGetCertificateStatus    func(args client.CertificateStatusArgs) ([]types.PodCertificateStatus, error)
+	FakeExportCertificates      func(args client.ExportCertificatesArgs) ([]types.PublicCertificate, error)
 	FakeUpdateBlock             func(args client.UpdateBlockArgs) error
+	FakeValidateBlock           func(args client.ValidateBlockArgs) (*types.BlockValidation, error)
 	FakeDeleteBlock             func(args client.DeleteBlockArgs) error
 	FakeListBlocks              func(args client.ListBlocksArgs) ([]types.Block, error)
+	FakeListBlocksWithVersion   func(args client.ListBlocksArgs) ([]types.Block, string, error)
 	FakeDeleteRoute             func(args client.DeleteRouteArgs) error
 	FakeListRoutes              func(args client.ListRoutesArgs) ([]types.Route, error)
 	FakeUpdateRoute             func(args client.UpdateRouteArgs) error
 	FakeInfo                    func(args client.InfoArgs) (*types.InstanceInfo, error)
+	FakeGetHistory              func(args client.HistoryArgs) ([]types.HistoryEntry, error)
+	FakeGetConnectionStats      func(args client.ConnectionStatsArgs) ([]types.PodConnectionStats, error)
+	FakeGetPodsHealth           func(args client.PodsHealthArgs) ([]types.PodHealth, error)
+	FakeGetPodsUsage            func(args client.PodsUsageArgs) ([]types.PodUsage, error)
+	FakeListPods                func(args client.ListPodsArgs) ([]types.Pod, error)
+	FakeListBinds               func(args client.ListBindsArgs) ([]types.BindStatus, error)
+	FakeGetMetadata             func(args client.GetMetadataArgs) (*types.Metadata, error)
+	FakeSetMetadata             func(args client.SetMetadataArgs) error
+	FakeUnsetMetadata           func(args client.UnsetMetadataArgs) error
 	FakeExec                    func(ctx context.Context, args client.ExecArgs) (*websocket.Conn, error)
 	FakeDebug                   func(ctx context.Context, args client.DebugArgs) (*websocket.Conn, error)
 	FakeAddAccessControlList    func(instance, host string, port int) error
@@ -43,6 +66,7 @@ type FakeClient struct {
 	FakeDeleteExtraFiles        func(args client.DeleteExtraFilesArgs) error
 	FakeListExtraFiles          func(args client.ListExtraFilesArgs) ([]types.RpaasFile, error)
 	FakeGetExtraFile            func(args client.GetExtraFileArgs) (types.RpaasFile, error)
+	FakeGetAutoscaleObject      func(args client.GetAutoscaleArgs) (*unstructured.Unstructured, error)
 }
 
 func (f *FakeClient) Info(ctx context.Context, args client.InfoArgs) (*types.InstanceInfo, error) {
@@ -53,6 +77,102 @@ func (f *FakeClient) Info(ctx context.Context, args client.InfoArgs) (*types.Ins
 	return nil, nil
 }
 
+func (f *FakeClient) GetAutoscaleObject(ctx context.Context, args client.GetAutoscaleArgs) (*unstructured.Unstructured, error) {
+	if f.FakeGetAutoscaleObject != nil {
+		return f.FakeGetAutoscaleObject(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) GetHistory(ctx context.Context, args client.HistoryArgs) ([]types.HistoryEntry, error) {
+	if f.FakeGetHistory != nil {
+		return f.FakeGetHistory(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) GetConnectionStats(ctx context.Context, args client.ConnectionStatsArgs) ([]types.PodConnectionStats, error) {
+	if f.FakeGetConnectionStats != nil {
+		return f.FakeGetConnectionStats(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) GetPodsHealth(ctx context.Context, args client.PodsHealthArgs) ([]types.PodHealth, error) {
+	if f.FakeGetPodsHealth != nil {
+		return f.FakeGetPodsHealth(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) GetPodsUsage(ctx context.Context, args client.PodsUsageArgs) ([]types.PodUsage, error) {
+	if f.FakeGetPodsUsage != nil {
+		return f.FakeGetPodsUsage(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) ListPods(ctx context.Context, args client.ListPodsArgs) ([]types.Pod, error) {
+	if f.FakeListPods != nil {
+		return f.FakeListPods(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) ListBinds(ctx context.Context, args client.ListBindsArgs) ([]types.BindStatus, error) {
+	if f.FakeListBinds != nil {
+		return f.FakeListBinds(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) GetMetadata(ctx context.Context, args client.GetMetadataArgs) (*types.Metadata, error) {
+	if f.FakeGetMetadata != nil {
+		return f.FakeGetMetadata(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) SetMetadata(ctx context.Context, args client.SetMetadataArgs) error {
+	if f.FakeSetMetadata != nil {
+		return f.FakeSetMetadata(args)
+	}
+
+	return nil
+}
+
+func (f *FakeClient) UnsetMetadata(ctx context.Context, args client.UnsetMetadataArgs) error {
+	if f.FakeUnsetMetadata != nil {
+		return f.FakeUnsetMetadata(args)
+	}
+
+	return nil
+}
+
+func (f *FakeClient) CreateInstance(ctx context.Context, args client.CreateInstanceArgs) error {
+	if f.FakeCreateInstance != nil {
+		return f.FakeCreateInstance(args)
+	}
+
+	return nil
+}
+
+func (f *FakeClient) DeleteInstance(ctx context.Context, args client.DeleteInstanceArgs) error {
+	if f.FakeDeleteInstance != nil {
+		return f.FakeDeleteInstance(args)
+	}
+
+	return nil
+}
+
 func (f *FakeClient) GetPlans(ctx context.Context, instance string) ([]types.Plan, error) {
 	if f.FakeGetPlans != nil {
 		return f.FakeGetPlans(instance)
@@ -69,6 +189,30 @@ func (f *FakeClient) GetFlavors(ctx context.Context, instance string) ([]types.F
 	return nil, nil
 }
 
+func (f *FakeClient) ListFlavors(ctx context.Context, args client.ListFlavorsArgs) ([]types.Flavor, error) {
+	if f.FakeListFlavors != nil {
+		return f.FakeListFlavors(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) GetFlavor(ctx context.Context, args client.GetFlavorArgs) (*types.FlavorInfo, error) {
+	if f.FakeGetFlavor != nil {
+		return f.FakeGetFlavor(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) UpdateFlavors(ctx context.Context, args client.UpdateFlavorsArgs) error {
+	if f.FakeUpdateFlavors != nil {
+		return f.FakeUpdateFlavors(args)
+	}
+
+	return nil
+}
+
 func (f *FakeClient) Scale(ctx context.Context, args client.ScaleArgs) error {
 	if f.FakeScale != nil {
 		return f.FakeScale(args)
@@ -77,6 +221,22 @@ func (f *FakeClient) Scale(ctx context.Context, args client.ScaleArgs) error {
 	return nil
 }
 
+func (f *FakeClient) Restart(ctx context.Context, args client.RestartArgs) ([]string, error) {
+	if f.FakeRestart != nil {
+		return f.FakeRestart(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) PurgeCache(ctx context.Context, args client.PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+	if f.FakePurgeCache != nil {
+		return f.FakePurgeCache(args)
+	}
+
+	return nil, nil
+}
+
 func (f *FakeClient) UpdateCertificate(ctx context.Context, args client.UpdateCertificateArgs) error {
 	if f.FakeUpdateCertificate != nil {
 		return f.FakeUpdateCertificate(args)
@@ -93,6 +253,30 @@ func (f *FakeClient) DeleteCertificate(ctx context.Context, args client.DeleteCe
 	return nil
 }
 
+func (f *FakeClient) ListCertificates(ctx context.Context, args client.ListCertificatesArgs) ([]types.Certificate, error) {
+	if f.FakeListCertificates != nil {
+		return f.FakeListCertificates(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) GetCertificateStatus(ctx context.Context, args client.CertificateStatusArgs) ([]types.PodCertificateStatus, error) {
+	if f.FakeGetCertificateStatus != nil {
+		return f.FakeGetCertificateStatus(args)
+	}
+
+	return nil, nil
+}
+
+func (f *FakeClient) ExportCertificates(ctx context.Context, args client.ExportCertificatesArgs) ([]types.PublicCertificate, error) {
+	if f.FakeExportCertificates != nil {
+		return f.FakeExportCertificates(args)
+	}
+
+	return nil, nil
+}
+
 func (f *FakeClient) UpdateBlock(ctx context.Context, args client.UpdateBlockArgs) error {
 	if f.FakeUpdateBlock != nil {
 		return f.FakeUpdateBlock(args)
@@ -101,6 +285,14 @@ func (f *FakeClient) UpdateBlock(ctx context.Context, args client.UpdateBlockArg
 	return nil
 }
 
+func (f *FakeClient) ValidateBlock(ctx context.Context, args client.ValidateBlockArgs) (*types.BlockValidation, error) {
+	if f.FakeValidateBlock != nil {
+		return f.FakeValidateBlock(args)
+	}
+
+	return nil, nil
+}
+
 func (f *FakeClient) DeleteBlock(ctx context.Context, args client.DeleteBlockArgs) error {
 	if f.FakeDeleteBlock != nil {
 		return f.FakeDeleteBlock(args)
@@ -117,6 +309,15 @@ func (f *FakeClient) ListBlocks(ctx context.Context, args client.ListBlocksArgs)
 	return nil, nil
 }
 
+func (f *FakeClient) ListBlocksWithVersion(ctx context.Context, args client.ListBlocksArgs) ([]types.Block, string, error) {
+	if f.FakeListBlocksWithVersion != nil {
+		return f.FakeListBlocksWithVersion(args)
+	}
+
+	blocks, err := f.ListBlocks(ctx, args)
+	return blocks, "", err
+}
+
 func (f *FakeClient) DeleteRoute(ctx context.Context, args client.DeleteRouteArgs) error {
 	if f.FakeDeleteRoute != nil {
 		return f.FakeDeleteRoute(args)
@@ -255,3 +456,11 @@ func (f *FakeClient) GetExtraFile(ctx context.Context, args client.GetExtraFileA
 
 	return types.RpaasFile{}, nil
 }
+
+func (f *FakeClient) GetVersion(ctx context.Context, args client.GetVersionArgs) (*types.Version, error) {
+	if f.FakeGetVersion != nil {
+		return f.FakeGetVersion(args)
+	}
+
+	return nil, nil
+}
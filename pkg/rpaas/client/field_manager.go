@@ -0,0 +1,94 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+// FieldManagerHeader carries the field manager of the requests changing
+// instances, see ClientOptions.FieldManager.
+const FieldManagerHeader = types.FieldManagerHeader
+
+// conflictRegexp matches the managers of a Kubernetes server-side apply
+// conflict, e.g. `conflict with "some-manager" using apps/v1: .spec.replicas`
+// or `conflicts with "some-manager" using apps/v1:\n- .spec.replicas\n- ...`.
+var conflictRegexp = regexp.MustCompile(`conflicts? with "([^"]+)"[^:\n]*:`)
+
+// FieldConflict is a field owned by another field manager.
+type FieldConflict struct {
+	Manager string
+	Field   string
+}
+
+// ErrFieldConflict is returned when the API rejects a change with 409
+// Conflict since it touches fields owned by other field managers (e.g. a
+// controller acting on the instance as well).
+type ErrFieldConflict struct {
+	Conflicts []FieldConflict
+
+	err *ErrUnexpectedStatusCode
+}
+
+func (e *ErrFieldConflict) Error() string {
+	var owners []string
+	for _, c := range e.Conflicts {
+		owners = append(owners, fmt.Sprintf("%s is owned by field manager %q", c.Field, c.Manager))
+	}
+
+	return fmt.Sprintf("rpaasv2: conflicting change: %s", strings.Join(owners, ", "))
+}
+
+func (e *ErrFieldConflict) Unwrap() error {
+	return e.err
+}
+
+// parseFieldConflicts returns the conflicts described on the body of a 409
+// Conflict response, either the plain message or the JSON error of the API.
+func parseFieldConflicts(body string) []FieldConflict {
+	var apiErr struct {
+		Message string `json:"message"`
+		Msg     string `json:"Msg"`
+	}
+	if err := json.Unmarshal([]byte(body), &apiErr); err == nil {
+		body = apiErr.Message + apiErr.Msg
+	}
+
+	var conflicts []FieldConflict
+	matches := conflictRegexp.FindAllStringSubmatchIndex(body, -1)
+	for i, m := range matches {
+		end := len(body)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+
+		manager := body[m[2]:m[3]]
+		for _, field := range strings.Split(body[m[1]:end], "\n") {
+			field = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(field), "- "))
+			if field == "" {
+				continue
+			}
+
+			conflicts = append(conflicts, FieldConflict{Manager: manager, Field: field})
+		}
+	}
+
+	return conflicts
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	return true
+}
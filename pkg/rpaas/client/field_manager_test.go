@@ -0,0 +1,101 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientFieldManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			assert.Empty(t, r.Header.Get(FieldManagerHeader))
+			fmt.Fprintf(w, `{"paths": []}`)
+
+		case "POST":
+			assert.Equal(t, "my-tool", r.Header.Get(FieldManagerHeader))
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, "", "", ClientOptions{FieldManager: "my-tool"})
+	require.NoError(t, err)
+
+	_, err = client.ListRoutes(context.TODO(), ListRoutesArgs{Instance: "my-instance"})
+	require.NoError(t, err)
+
+	err = client.UpdateRoute(context.TODO(), UpdateRouteArgs{Instance: "my-instance", Path: "/app", Destination: "app.tsuru.example.com"})
+	require.NoError(t, err)
+}
+
+func TestClientFieldConflict(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expected      []FieldConflict
+		expectedError string
+	}{
+		{
+			name:          "with a single conflict",
+			body:          `{"message": "Apply failed with 1 conflict: conflict with \"kube-controller-manager\" using apps/v1: .spec.replicas"}`,
+			expected:      []FieldConflict{{Manager: "kube-controller-manager", Field: ".spec.replicas"}},
+			expectedError: `rpaasv2: conflicting change: .spec.replicas is owned by field manager "kube-controller-manager"`,
+		},
+		{
+			name: "with conflicts from many managers",
+			body: "Apply failed with 3 conflicts: conflicts with \"some-controller\" using extensions.tsuru.io/v1alpha1:\n- .spec.replicas\n- .spec.flavors\nconflict with \"other-tool\" with subresource \"scale\" using extensions.tsuru.io/v1alpha1: .spec.autoscale",
+			expected: []FieldConflict{
+				{Manager: "some-controller", Field: ".spec.replicas"},
+				{Manager: "some-controller", Field: ".spec.flavors"},
+				{Manager: "other-tool", Field: ".spec.autoscale"},
+			},
+			expectedError: `rpaasv2: conflicting change: .spec.replicas is owned by field manager "some-controller", .spec.flavors is owned by field manager "some-controller", .spec.autoscale is owned by field manager "other-tool"`,
+		},
+		{
+			name:          "with another kind of conflict",
+			body:          `{"message": "instance is being updated"}`,
+			expectedError: `rpaasv2: unexpected status code: 409 Conflict, detail: {"message": "instance is being updated"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			client, err := NewClientWithOptions(server.URL, "", "", ClientOptions{FieldManager: "rpaasv2-cli"})
+			require.NoError(t, err)
+
+			err = client.UpdateRoute(context.TODO(), UpdateRouteArgs{Instance: "my-instance", Path: "/app", Destination: "app.tsuru.example.com"})
+			assert.EqualError(t, err, tt.expectedError)
+
+			var statusErr *ErrUnexpectedStatusCode
+			require.True(t, errors.As(err, &statusErr))
+			assert.Equal(t, http.StatusConflict, statusErr.Status)
+
+			var conflictErr *ErrFieldConflict
+			if tt.expected == nil {
+				assert.False(t, errors.As(err, &conflictErr))
+				return
+			}
+
+			require.True(t, errors.As(err, &conflictErr))
+			assert.Equal(t, tt.expected, conflictErr.Conflicts)
+		})
+	}
+}
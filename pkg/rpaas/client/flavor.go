@@ -0,0 +1,127 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func (args GetFlavorArgs) Validate() error {
+	if args.Name == "" {
+		return ErrMissingFlavor
+	}
+
+	return nil
+}
+
+func (args UpdateFlavorsArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	return nil
+}
+
+func (c *client) ListFlavors(ctx context.Context, args ListFlavorsArgs) ([]types.Flavor, error) {
+	if c.throughTsuru && args.Instance == "" {
+		return nil, ErrMissingInstance
+	}
+
+	pathName := "/resources/flavors"
+	if args.Instance != "" {
+		pathName = fmt.Sprintf("/resources/%s/flavors", args.Instance)
+	}
+
+	req, err := c.newRequest("GET", pathName, nil, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	var flavors []types.Flavor
+	if err = c.unmarshalBody(response, &flavors); err != nil {
+		return nil, err
+	}
+
+	return flavors, nil
+}
+
+func (c *client) GetFlavor(ctx context.Context, args GetFlavorArgs) (*types.FlavorInfo, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	if c.throughTsuru && args.Instance == "" {
+		return nil, ErrMissingInstance
+	}
+
+	pathName := fmt.Sprintf("/resources/flavors/%s", args.Name)
+	if args.Instance != "" {
+		pathName = fmt.Sprintf("/resources/%s/flavors/%s", args.Instance, args.Name)
+	}
+
+	req, err := c.newRequest("GET", pathName, nil, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	var flavor types.FlavorInfo
+	if err = c.unmarshalBody(response, &flavor); err != nil {
+		return nil, err
+	}
+
+	return &flavor, nil
+}
+
+func (c *client) UpdateFlavors(ctx context.Context, args UpdateFlavorsArgs) error {
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	values := url.Values{"flavors": args.Flavors}
+	pathName := fmt.Sprintf("/resources/%s/flavors", args.Instance)
+	req, err := c.newRequest("PUT", pathName, strings.NewReader(values.Encode()), args.Instance)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	return nil
+}
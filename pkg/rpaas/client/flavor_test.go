@@ -0,0 +1,176 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestClientThroughTsuru_ListFlavors(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          ListFlavorsArgs
+		expected      []types.Flavor
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when server returns an unexpected status code",
+			args:          ListFlavorsArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: unexpected status code: 500 Internal Server Error, detail: some error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(w, "some error")
+			},
+		},
+		{
+			name: "when server returns the flavors",
+			args: ListFlavorsArgs{Instance: "my-instance"},
+			expected: []types.Flavor{
+				{Name: "mango", Description: "Mango flavor"},
+				{Name: "mint", Description: "Mint flavor"},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "GET")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/flavors"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				fmt.Fprintf(w, `[{"name": "mango", "description": "Mango flavor"}, {"name": "mint", "description": "Mint flavor"}]`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			flavors, err := client.ListFlavors(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, flavors)
+		})
+	}
+}
+
+func TestClientThroughTsuru_GetFlavor(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          GetFlavorArgs
+		expected      *types.FlavorInfo
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when flavor is empty",
+			args:          GetFlavorArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: flavor cannot be empty",
+		},
+		{
+			name:          "when instance is empty",
+			args:          GetFlavorArgs{Name: "mint"},
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when flavor does not exist",
+			args:          GetFlavorArgs{Instance: "my-instance", Name: "mint"},
+			expectedError: "rpaasv2: unexpected status code: 404 Not Found, detail: flavor not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "flavor not found")
+			},
+		},
+		{
+			name: "when server returns the flavor",
+			args: GetFlavorArgs{Instance: "my-instance", Name: "mint"},
+			expected: &types.FlavorInfo{
+				Name:                "mint",
+				Description:         "Mint flavor",
+				IncompatibleFlavors: []string{"mango"},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "GET")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/flavors/mint"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				fmt.Fprintf(w, `{"name": "mint", "description": "Mint flavor", "incompatibleFlavors": ["mango"]}`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			flavor, err := client.GetFlavor(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, flavor)
+		})
+	}
+}
+
+func TestClientThroughTsuru_UpdateFlavors(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          UpdateFlavorsArgs
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when server returns an unexpected status code",
+			args:          UpdateFlavorsArgs{Instance: "my-instance", Flavors: []string{"banana"}},
+			expectedError: "rpaasv2: unexpected status code: 400 Bad Request, detail: flavor not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "flavor not found")
+			},
+		},
+		{
+			name: "when updating the flavors",
+			args: UpdateFlavorsArgs{Instance: "my-instance", Flavors: []string{"mint", "mango"}},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "PUT")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/flavors"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+				assert.Equal(t, "flavors=mint&flavors=mango", getBody(t, r))
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			err := client.UpdateFlavors(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
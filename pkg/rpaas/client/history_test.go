@@ -0,0 +1,70 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestClientThroughTsuru_GetHistory(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          HistoryArgs
+		expected      []types.HistoryEntry
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when server returns an unexpected status code",
+			args:          HistoryArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: unexpected status code: 404 Not Found, detail: instance not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "instance not found")
+			},
+		},
+		{
+			name: "when server returns the history",
+			args: HistoryArgs{Instance: "my-instance"},
+			expected: []types.HistoryEntry{
+				{Time: time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC), Kind: "scale", Actor: "rpaas-api", Action: "Update", Message: "set replicas"},
+				{Time: time.Date(2023, 3, 1, 11, 0, 0, 0, time.UTC), Kind: "event", Message: "instance updated"},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "GET")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/history"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				fmt.Fprintf(w, `[{"time": "2023-03-01T10:00:00Z", "kind": "scale", "actor": "rpaas-api", "action": "Update", "message": "set replicas"}, {"time": "2023-03-01T11:00:00Z", "kind": "event", "message": "instance updated"}]`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			history, err := client.GetHistory(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, history)
+		})
+	}
+}
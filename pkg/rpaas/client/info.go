@@ -6,7 +6,6 @@ package client
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -43,7 +42,7 @@ func (c *client) Info(ctx context.Context, args InfoArgs) (*types.InstanceInfo,
 	}
 
 	var infoPayload types.InstanceInfo
-	err = json.NewDecoder(response.Body).Decode(&infoPayload)
+	err = c.unmarshalBody(response, &infoPayload)
 	if err != nil {
 		return nil, err
 	}
@@ -6,11 +6,22 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/tsuru/rpaas-operator/api/v1alpha1"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func TestClientThroughTsuru_Info(t *testing.T) {
@@ -52,3 +63,95 @@ func TestClientThroughTsuru_Info(t *testing.T) {
 		})
 	}
 }
+
+func TestClientInfoWithResponseFormat(t *testing.T) {
+	createdAt := time.Date(2023, time.March, 14, 15, 9, 26, 535897932, time.UTC)
+	cacheSize := resource.MustParse("100Mi")
+	info := types.InstanceInfo{
+		Name:     "my-instance",
+		Replicas: func(n int32) *int32 { return &n }(3),
+		Plan:     "basic",
+		Addresses: []types.InstanceAddress{
+			{Type: types.InstanceAddressTypeClusterExternal, ServiceName: "my-instance-service", IP: "192.168.10.10", Status: "ready"},
+		},
+		Blocks: []types.Block{{Name: "http", Content: "# some nginx config"}},
+		Routes: []types.Route{{Path: "/app", Destination: "app.tsuru.example.com", HTTPSOnly: true}},
+		Autoscale: &autogenerated.Autoscale{
+			MinReplicas: 1,
+			MaxReplicas: 10,
+			Cpu:         autogenerated.PtrInt32(70),
+			Schedules:   []autogenerated.ScheduledWindow{{MinReplicas: 2, Start: "00 08 * * 1-5", End: "00 20 * * 1-5"}},
+		},
+		Binds: []v1alpha1.Bind{{Name: "my-app", Host: "my-app.tsuru.example.com"}},
+		Tags:  []string{"tag1", "tag2"},
+		Pods: []types.Pod{
+			{
+				Name:      "my-instance-6f86f957b7-abcde",
+				IP:        "172.16.10.10",
+				Status:    "Running",
+				CreatedAt: createdAt,
+				Ports:     []types.PodPort{{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP}},
+				Errors:    []types.PodError{{First: createdAt, Last: createdAt.Add(time.Minute), Message: "Back-off restarting failed container", Count: 2}},
+				Ready:     true,
+				Metrics:   &types.PodMetrics{CPU: "100m", Memory: "64Mi"},
+			},
+		},
+		Certificates: []types.CertificateInfo{
+			{Name: "default", ValidFrom: createdAt, ValidUntil: createdAt.AddDate(1, 0, 0), DNSNames: []string{"my-instance.example.com"}, PublicKeyAlgorithm: "ECDSA", PublicKeyBitSize: 256},
+		},
+		PlanOverride: &v1alpha1.RpaasPlanSpec{
+			Image:  "tsuru/nginx:1.22",
+			Config: v1alpha1.NginxConfig{CacheSize: &cacheSize},
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		},
+		ExtraFiles: []types.RpaasFile{{Name: "index.html", Content: []byte("<h1>Hello world!</h1>")}},
+	}
+
+	newServer := func(t *testing.T, supportsMsgpack bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if supportsMsgpack && strings.HasPrefix(r.Header.Get("Accept"), types.MsgpackContentType) {
+				data, err := types.MarshalMsgpack(info)
+				require.NoError(t, err)
+				w.Header().Set("Content-Type", types.MsgpackContentType)
+				w.Write(data)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			require.NoError(t, json.NewEncoder(w).Encode(info))
+		}))
+	}
+
+	getInfo := func(t *testing.T, server *httptest.Server, opts ClientOptions) *types.InstanceInfo {
+		c, err := NewClientThroughTsuruWithOptions(server.URL, FakeTsuruToken, FakeTsuruService, opts)
+		require.NoError(t, err)
+
+		got, err := c.Info(context.TODO(), InfoArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+		return got
+	}
+
+	server := newServer(t, true)
+	defer server.Close()
+
+	expected := getInfo(t, server, DefaultClientOptions)
+
+	t.Run("decoding MessagePack is the same as JSON", func(t *testing.T) {
+		assert.Equal(t, expected, getInfo(t, server, DefaultClientOptions.WithResponseFormat(ResponseFormatMsgpack)))
+		assert.Equal(t, expected, getInfo(t, server, DefaultClientOptions.WithResponseFormat(ResponseFormatMsgpack).WithStrictDecoding(true)))
+	})
+
+	t.Run("falls back to JSON when the API doesn't support MessagePack", func(t *testing.T) {
+		jsonOnly := newServer(t, false)
+		defer jsonOnly.Close()
+
+		assert.Equal(t, expected, getInfo(t, jsonOnly, DefaultClientOptions.WithResponseFormat(ResponseFormatMsgpack)))
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := NewClientThroughTsuruWithOptions(server.URL, FakeTsuruToken, FakeTsuruService, DefaultClientOptions.WithResponseFormat("protobuf"))
+		assert.EqualError(t, err, `rpaasv2: unsupported response format "protobuf" (one of: json, msgpack)`)
+	})
+}
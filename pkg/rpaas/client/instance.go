@@ -0,0 +1,102 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func (args CreateInstanceArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	if args.Team == "" {
+		return ErrMissingTeam
+	}
+
+	return nil
+}
+
+func (c *client) CreateInstance(ctx context.Context, args CreateInstanceArgs) error {
+	if c.throughTsuru {
+		return ErrManagedByTsuru
+	}
+
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	values := url.Values{}
+	values.Set("name", args.Instance)
+	values.Set("team", args.Team)
+	values.Set("plan", args.Plan)
+	values.Set("description", args.Description)
+	for _, tag := range args.Tags {
+		values.Add("tags", tag)
+	}
+
+	if len(args.Flavors) > 0 {
+		values.Set("parameters.flavors", strings.Join(args.Flavors, ","))
+	}
+
+	req, err := c.newRequest("POST", "/resources", strings.NewReader(values.Encode()), args.Instance)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		return newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	return nil
+}
+
+func (args DeleteInstanceArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	return nil
+}
+
+func (c *client) DeleteInstance(ctx context.Context, args DeleteInstanceArgs) error {
+	if c.throughTsuru {
+		return ErrManagedByTsuru
+	}
+
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	pathName := fmt.Sprintf("/resources/%s", args.Instance)
+	req, err := c.newRequest("DELETE", pathName, nil, args.Instance)
+	if err != nil {
+		return err
+	}
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"time"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+// InstanceInfo is a summary of an instance meant to be consumed by scripts.
+// Its JSON schema is stable: fields may be added, but never renamed nor
+// removed.
+type InstanceInfo struct {
+	Name         string                `json:"name"`
+	Service      string                `json:"service"`
+	Plan         string                `json:"plan"`
+	Flavors      []string              `json:"flavors"`
+	Replicas     InstanceReplicas      `json:"replicas"`
+	Autoscale    *InstanceAutoscale    `json:"autoscale"`
+	Certificates []InstanceCertificate `json:"certificates"`
+	BoundApps    []string              `json:"boundApps"`
+}
+
+type InstanceReplicas struct {
+	// Desired is the number of replicas requested for the instance.
+	Desired int32 `json:"desired"`
+	// Ready is the number of pods passing their readiness checks.
+	Ready int32 `json:"ready"`
+	// Available is the number of ready pods which are not being terminated,
+	// that is, the ones able to serve traffic.
+	Available int32 `json:"available"`
+}
+
+type InstanceAutoscale struct {
+	MinReplicas int32  `json:"minReplicas"`
+	MaxReplicas int32  `json:"maxReplicas"`
+	CPU         *int32 `json:"cpu"`
+	Memory      *int32 `json:"memory"`
+	RPS         *int32 `json:"rps"`
+	Schedules   int    `json:"schedules"`
+}
+
+type InstanceCertificate struct {
+	Name      string    `json:"name"`
+	DNSNames  []string  `json:"dnsNames"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Expired   bool      `json:"expired"`
+}
+
+// NewInstanceInfo summarizes the info returned by the API, evaluating the
+// certificates expiration at now.
+func NewInstanceInfo(info *types.InstanceInfo, now time.Time) *InstanceInfo {
+	summary := &InstanceInfo{
+		Name:         info.Name,
+		Service:      info.Service,
+		Plan:         info.Plan,
+		Flavors:      append([]string{}, info.Flavors...),
+		Certificates: []InstanceCertificate{},
+		BoundApps:    []string{},
+	}
+
+	if info.Replicas != nil {
+		summary.Replicas.Desired = *info.Replicas
+	}
+
+	for _, pod := range info.Pods {
+		if !pod.Ready {
+			continue
+		}
+
+		summary.Replicas.Ready++
+		if pod.Status != "Terminating" {
+			summary.Replicas.Available++
+		}
+	}
+
+	if a := info.Autoscale; a != nil {
+		summary.Autoscale = &InstanceAutoscale{
+			MinReplicas: a.MinReplicas,
+			MaxReplicas: a.MaxReplicas,
+			CPU:         a.Cpu,
+			Memory:      a.Memory,
+			RPS:         a.Rps,
+			Schedules:   len(a.Schedules),
+		}
+	}
+
+	for _, c := range info.Certificates {
+		summary.Certificates = append(summary.Certificates, InstanceCertificate{
+			Name:      c.Name,
+			DNSNames:  append([]string{}, c.DNSNames...),
+			ExpiresAt: c.ValidUntil,
+			Expired:   !c.ValidUntil.After(now),
+		})
+	}
+
+	for _, b := range info.Binds {
+		summary.BoundApps = append(summary.BoundApps, b.Name)
+	}
+
+	return summary
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/api/v1alpha1"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/autogenerated"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestNewInstanceInfo(t *testing.T) {
+	now := time.Date(2023, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("pinning the JSON schema", func(t *testing.T) {
+		info := &types.InstanceInfo{
+			Name:     "my-instance",
+			Service:  "rpaasv2",
+			Plan:     "basic",
+			Flavors:  []string{"orange"},
+			Replicas: autogenerated.PtrInt32(3),
+			Pods: []types.Pod{
+				{Name: "pod-1", Status: "Running", Ready: true},
+				{Name: "pod-2", Status: "Terminating", Ready: true},
+				{Name: "pod-3", Status: "Pending"},
+			},
+			Autoscale: &autogenerated.Autoscale{
+				MinReplicas: 2,
+				MaxReplicas: 10,
+				Cpu:         autogenerated.PtrInt32(75),
+				Schedules:   []autogenerated.ScheduledWindow{{MinReplicas: 5, Start: "00 08 * * 1-5", End: "00 20 * * 1-5"}},
+			},
+			Certificates: []types.CertificateInfo{
+				{Name: "default", DNSNames: []string{"my-instance.example.com"}, ValidUntil: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+				{Name: "legacy", DNSNames: []string{"legacy.example.com"}, ValidUntil: time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			Binds: []v1alpha1.Bind{{Name: "app1", Host: "app1.tsuru.example.com"}, {Name: "app2", Host: "app2.tsuru.example.com"}},
+			Team:  "team-one",
+		}
+
+		got, err := json.MarshalIndent(NewInstanceInfo(info, now), "", "\t")
+		require.NoError(t, err)
+
+		expected, err := os.ReadFile("testdata/instance_info.golden.json")
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(got)+"\n")
+	})
+
+	t.Run("without optional parts", func(t *testing.T) {
+		got, err := json.Marshal(NewInstanceInfo(&types.InstanceInfo{Name: "my-instance"}, now))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"name": "my-instance",
+			"service": "",
+			"plan": "",
+			"flavors": [],
+			"replicas": {"desired": 0, "ready": 0, "available": 0},
+			"autoscale": null,
+			"certificates": [],
+			"boundApps": []
+		}`, string(got))
+	})
+}
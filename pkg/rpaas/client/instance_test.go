@@ -0,0 +1,133 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func newClientWithoutTsuru(t *testing.T, h http.Handler) (Client, *httptest.Server) {
+	server := httptest.NewServer(h)
+	client, err := NewClient(server.URL, "admin", "s3cr3t")
+	require.NoError(t, err)
+	return client, server
+}
+
+func TestClient_CreateInstance(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          CreateInstanceArgs
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			args:          CreateInstanceArgs{Team: "my-team"},
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when team is empty",
+			args:          CreateInstanceArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: team cannot be empty",
+		},
+		{
+			name:          "when the server returns an error",
+			args:          CreateInstanceArgs{Instance: "my-instance", Team: "my-team"},
+			expectedError: `rpaasv2: unexpected status code: 409 Conflict, detail: rpaas instance named "my-instance" already exists`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `rpaas instance named "my-instance" already exists`)
+			},
+		},
+		{
+			name: "when the instance is created",
+			args: CreateInstanceArgs{
+				Instance:    "my-instance",
+				Team:        "my-team",
+				Plan:        "small",
+				Description: "My instance",
+				Tags:        []string{"tag1", "tag2"},
+				Flavors:     []string{"strawberry", "chocolate"},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method)
+				assert.Equal(t, "/resources", r.URL.RequestURI())
+				assert.Equal(t, "Basic "+basicAuth("admin", "s3cr3t"), r.Header.Get("Authorization"))
+				values, err := url.ParseQuery(getBody(t, r))
+				require.NoError(t, err)
+				assert.Equal(t, url.Values{
+					"name":               {"my-instance"},
+					"team":               {"my-team"},
+					"plan":               {"small"},
+					"description":        {"My instance"},
+					"tags":               {"tag1", "tag2"},
+					"parameters.flavors": {"strawberry,chocolate"},
+				}, values)
+				w.WriteHeader(http.StatusCreated)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientWithoutTsuru(t, tt.handler)
+			defer server.Close()
+			err := client.CreateInstance(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestClient_DeleteInstance(t *testing.T) {
+	client, server := newClientWithoutTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "/resources/my-instance", r.URL.RequestURI())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	assert.EqualError(t, client.DeleteInstance(context.TODO(), DeleteInstanceArgs{}), "rpaasv2: instance cannot be empty")
+	assert.NoError(t, client.DeleteInstance(context.TODO(), DeleteInstanceArgs{Instance: "my-instance"}))
+}
+
+func TestClientThroughTsuru_CreateAndDeleteInstance(t *testing.T) {
+	client, server := newClientThroughTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "no request should be sent")
+	}))
+	defer server.Close()
+
+	err := client.CreateInstance(context.TODO(), CreateInstanceArgs{Instance: "my-instance", Team: "my-team"})
+	assert.Equal(t, ErrManagedByTsuru, err)
+
+	err = client.DeleteInstance(context.TODO(), DeleteInstanceArgs{Instance: "my-instance"})
+	assert.Equal(t, ErrManagedByTsuru, err)
+}
+
+func TestClient_GetPlans(t *testing.T) {
+	client, server := newClientWithoutTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/resources/plans", r.URL.RequestURI())
+		fmt.Fprint(w, `[{"name": "small", "description": "Small plan", "default": true}, {"name": "large"}]`)
+	}))
+	defer server.Close()
+
+	plans, err := client.GetPlans(context.TODO(), "")
+	require.NoError(t, err)
+	assert.Equal(t, []types.Plan{{Name: "small", Description: "Small plan", Default: true}, {Name: "large"}}, plans)
+}
@@ -5,10 +5,24 @@
 package client
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
 	"io"
+	"log"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -140,3 +154,229 @@ func getBody(t *testing.T, r *http.Request) string {
 	defer r.Body.Close()
 	return string(body)
 }
+
+func TestClientWithProxy(t *testing.T) {
+	var proxied bool
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = true
+		assert.Equal(t, "rpaas.example.com", r.URL.Host)
+		assert.Equal(t, "/resources/my-instance/stats", r.URL.Path)
+		assert.Equal(t, "Basic "+basicAuth("proxy-user", "proxy-pass"), r.Header.Get("Proxy-Authorization"))
+		assert.Equal(t, "Basic "+basicAuth("admin", "s3cr3t"), r.Header.Get("Authorization"))
+		fmt.Fprint(w, `[{"pod": "my-instance-abc", "stats": {"active": 1}}]`)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+	proxyURL.User = url.UserPassword("proxy-user", "proxy-pass")
+
+	c, err := NewClientWithOptions("http://rpaas.example.com", "admin", "s3cr3t", ClientOptions{ProxyURL: proxyURL.String()})
+	require.NoError(t, err)
+
+	stats, err := c.GetConnectionStats(context.TODO(), ConnectionStatsArgs{Instance: "my-instance"})
+	require.NoError(t, err)
+	assert.True(t, proxied, "request should be routed through the proxy")
+	assert.Len(t, stats, 1)
+}
+
+func TestClientWithInvalidProxy(t *testing.T) {
+	_, err := NewClientWithOptions("http://rpaas.example.com", "", "", ClientOptions{ProxyURL: "proxy.example.com"})
+	assert.EqualError(t, err, `rpaasv2: invalid proxy URL "proxy.example.com"`)
+
+	_, err = NewClientThroughTsuruWithOptions("https://tsuru.example.com", "t0k3n", "rpaasv2", ClientOptions{ProxyURL: "://"})
+	assert.EqualError(t, err, `rpaasv2: invalid proxy URL "://"`)
+}
+
+// silentListener accepts connections but never writes to them, as a server
+// hanging on the TLS handshake.
+func silentListener(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			conns = append(conns, conn)
+		}
+	}()
+
+	return l
+}
+
+func TestClientWithConnectTimeout(t *testing.T) {
+	t.Run("hanging TLS handshake", func(t *testing.T) {
+		l := silentListener(t)
+
+		c, err := NewClientWithOptions("https://"+l.Addr().String(), "", "", ClientOptions{Timeout: 10 * time.Second, ConnectTimeout: 100 * time.Millisecond})
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, err = c.GetConnectionStats(context.TODO(), ConnectionStatsArgs{Instance: "my-instance"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "TLS handshake timeout")
+		assert.Less(t, time.Since(start), 5*time.Second, "the connect timeout should fire way before the request one")
+	})
+
+	t.Run("slow response after connecting", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(300 * time.Millisecond)
+			fmt.Fprint(w, `[{"pod": "my-instance-abc", "stats": {"active": 1}}]`)
+		}))
+		defer server.Close()
+
+		c, err := NewClientWithOptions(server.URL, "", "", ClientOptions{Timeout: 10 * time.Second, ConnectTimeout: 100 * time.Millisecond})
+		require.NoError(t, err)
+
+		stats, err := c.GetConnectionStats(context.TODO(), ConnectionStatsArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+		assert.Len(t, stats, 1)
+	})
+}
+
+func TestNewHTTPTransport(t *testing.T) {
+	transport := NewHTTPTransport(ClientOptions{}, nil, nil)
+	assert.Equal(t, 10*time.Second, transport.TLSHandshakeTimeout)
+
+	transport = NewHTTPTransport(ClientOptions{ConnectTimeout: 2 * time.Second}, nil, nil)
+	assert.Equal(t, 2*time.Second, transport.TLSHandshakeTimeout)
+}
+
+func TestClientWithClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(name string, template *x509.Certificate) (certFile, keyFile string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+
+		certFile, keyFile = filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+		require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+		return
+	}
+
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600))
+
+	serverCertFile, serverKeyFile := issue("server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	clientCertFile, clientKeyFile := issue("client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "rpaasv2-cli"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	serverCert, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+	require.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Len(t, r.TLS.PeerCertificates, 1)
+		assert.Equal(t, "rpaasv2-cli", r.TLS.PeerCertificates[0].Subject.CommonName)
+		fmt.Fprint(w, `[]`)
+	}))
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	t.Run("presenting the client certificate", func(t *testing.T) {
+		c, err := NewClientWithOptions(server.URL, "", "", ClientOptions{
+			ClientCertificateFile: clientCertFile,
+			ClientKeyFile:         clientKeyFile,
+			CAFile:                caFile,
+		})
+		require.NoError(t, err)
+
+		_, err = c.GetConnectionStats(context.TODO(), ConnectionStatsArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+	})
+
+	t.Run("without the client certificate", func(t *testing.T) {
+		c, err := NewClientWithOptions(server.URL, "", "", ClientOptions{CAFile: caFile})
+		require.NoError(t, err)
+
+		_, err = c.GetConnectionStats(context.TODO(), ConnectionStatsArgs{Instance: "my-instance"})
+		require.Error(t, err)
+	})
+
+	t.Run("with invalid options", func(t *testing.T) {
+		_, err := NewClientWithOptions(server.URL, "", "", ClientOptions{ClientCertificateFile: clientCertFile})
+		assert.EqualError(t, err, "rpaasv2: client certificate and key must be provided together")
+
+		_, err = NewClientWithOptions(server.URL, "", "", ClientOptions{ClientCertificateFile: clientCertFile, ClientKeyFile: serverKeyFile})
+		assert.ErrorContains(t, err, "rpaasv2: could not load client certificate:")
+
+		_, err = NewClientThroughTsuruWithOptions("https://tsuru.example.com", "t0k3n", "rpaasv2", ClientOptions{CAFile: clientKeyFile})
+		assert.EqualError(t, err, fmt.Sprintf("rpaasv2: no certificate found in CA file %q", clientKeyFile))
+	})
+}
+
+func TestClientWithStrictDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"version": "v0.40.0", "buildDate": "2023-01-01"}`)
+	}))
+	defer server.Close()
+
+	t.Run("unknown fields are ignored by default", func(t *testing.T) {
+		c, err := NewClientThroughTsuruWithOptions(server.URL, FakeTsuruToken, FakeTsuruService, DefaultClientOptions)
+		require.NoError(t, err)
+
+		version, err := c.GetVersion(context.TODO(), GetVersionArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+		assert.Equal(t, "v0.40.0", version.Version)
+	})
+
+	t.Run("unknown fields fail in strict mode", func(t *testing.T) {
+		c, err := NewClientThroughTsuruWithOptions(server.URL, FakeTsuruToken, FakeTsuruService, DefaultClientOptions.WithStrictDecoding(true))
+		require.NoError(t, err)
+
+		_, err = c.GetVersion(context.TODO(), GetVersionArgs{Instance: "my-instance"})
+		assert.EqualError(t, err, `rpaasv2: could not decode the response strictly: json: unknown field "buildDate"`)
+	})
+}
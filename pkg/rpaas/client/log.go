@@ -11,9 +11,16 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/tsuru/rpaas-operator/pkg/util"
 )
 
+const defaultLogRelistInterval = 5 * time.Second
+
 func (args LogArgs) Validate() error {
 	if args.Instance == "" {
 		return ErrMissingInstance
@@ -27,6 +34,10 @@ func (c *client) Log(ctx context.Context, args LogArgs) error {
 		return err
 	}
 
+	if args.RunningOnly && args.Pod == "" {
+		return c.logFromRunningPods(ctx, args)
+	}
+
 	httpClient := *c.client
 	httpClient.Timeout = time.Duration(0)
 
@@ -66,9 +77,108 @@ func (c *client) Log(ctx context.Context, args LogArgs) error {
 	}
 	defer resp.Body.Close()
 
-	if _, err = io.Copy(args.Out, resp.Body); err != io.EOF {
+	if resp.StatusCode != http.StatusOK {
+		return newErrUnexpectedStatusCodeFromResponse(resp)
+	}
+
+	// NOTE: the API sends the log lines as a chunked stream, so they're
+	// copied as they arrive, keeping the memory usage bounded no matter how
+	// many lines were asked for.
+	_, err = io.Copy(args.Out, resp.Body)
+	return err
+}
+
+func (c *client) logFromRunningPods(ctx context.Context, args LogArgs) error {
+	pods, err := c.listRunningPods(ctx, args.Instance)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	if !args.Follow {
+		if len(pods) == 0 {
+			return fmt.Errorf("rpaasv2: no running pods found in instance %q", args.Instance)
+		}
+
+		for _, pod := range pods {
+			pargs := args
+			pargs.Pod = pod
+			if err = c.Log(ctx, pargs); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	interval := args.RelistInterval
+	if interval <= 0 {
+		interval = defaultLogRelistInterval
+	}
+
+	var outMu, mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs *multierror.Error
+
+	// NOTE: pod names are never reused, so a pod is streamed only once even
+	// if its stream ends before it leaves the Running phase.
+	streamed := make(map[string]bool)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, pod := range pods {
+			if streamed[pod] {
+				continue
+			}
+
+			streamed[pod] = true
+
+			wg.Add(1)
+			go func(pod string) {
+				defer wg.Done()
+
+				w := util.NewLineForwarder(args.Out, &outMu, "")
+				defer w.Flush()
+
+				pargs := args
+				pargs.Pod, pargs.Out = pod, w
+				if err := c.Log(ctx, pargs); err != nil && ctx.Err() == nil {
+					mu.Lock()
+					errs = multierror.Append(errs, fmt.Errorf("pod %s: %w", pod, err))
+					mu.Unlock()
+				}
+			}(pod)
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return errs.ErrorOrNil()
+
+		case <-ticker.C:
+		}
+
+		// NOTE: a failure while listing pods again is likely transient, so
+		// the current streams keep going until the next attempt.
+		if newPods, err := c.listRunningPods(ctx, args.Instance); err == nil {
+			pods = newPods
+		}
+	}
+}
+
+func (c *client) listRunningPods(ctx context.Context, instance string) ([]string, error) {
+	info, err := c.Info(ctx, InfoArgs{Instance: instance})
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []string
+	for _, pod := range info.Pods {
+		if pod.Status == "Running" {
+			pods = append(pods, pod.Name)
+		}
+	}
+
+	return pods, nil
 }
@@ -1,12 +1,19 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestClientThroughTsuru_Log(t *testing.T) {
@@ -32,6 +39,18 @@ func TestClientThroughTsuru_Log(t *testing.T) {
 				w.WriteHeader(http.StatusOK)
 			},
 		},
+		{
+			name: "when the API returns an error",
+			args: LogArgs{
+				Instance: "my-instance",
+				Out:      io.Discard,
+			},
+			expectedError: "rpaasv2: unexpected status code: 404 Not Found, detail: instance not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, "instance not found")
+			},
+		},
 		{
 			name: "all arguments log request",
 			args: LogArgs{
@@ -62,3 +81,183 @@ func TestClientThroughTsuru_Log(t *testing.T) {
 		})
 	}
 }
+
+// lineCounter signals on reached once the given number of lines was written.
+type lineCounter struct {
+	lines   int
+	target  int
+	reached chan struct{}
+}
+
+func (lc *lineCounter) Write(p []byte) (int, error) {
+	before := lc.lines
+	lc.lines += bytes.Count(p, []byte{'\n'})
+	if before < lc.target && lc.lines >= lc.target {
+		close(lc.reached)
+	}
+	return len(p), nil
+}
+
+func TestClientThroughTsuru_LogStreamsLines(t *testing.T) {
+	const half = 50000
+
+	out := &lineCounter{target: half, reached: make(chan struct{})}
+	client, server := newClientThroughTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "100000", r.URL.Query().Get("lines"))
+
+		writeLines := func(from int) {
+			for i := from; i < from+half; i++ {
+				fmt.Fprintf(w, "2023-01-01T00:00:00Z [pod-1][nginx]: some log line #%d\n", i)
+			}
+			w.(http.Flusher).Flush()
+		}
+
+		writeLines(0)
+
+		// the second half is only sent once the client has written the first
+		// one, which would never happen if the whole response were buffered
+		select {
+		case <-out.reached:
+		case <-time.After(5 * time.Second):
+			t.Errorf("client did not write the lines as they arrived")
+			return
+		}
+
+		writeLines(half)
+	}))
+	defer server.Close()
+
+	err := client.Log(context.TODO(), LogArgs{Instance: "my-instance", Lines: 2 * half, Out: out})
+	require.NoError(t, err)
+	assert.Equal(t, 2*half, out.lines)
+}
+
+func BenchmarkClientThroughTsuru_LogLargeTail(b *testing.B) {
+	const lines = 100000
+	line := fmt.Sprintf("2023-01-01T00:00:00Z [pod-1][nginx]: %s\n", strings.Repeat("x", 64))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < lines; i++ {
+			io.WriteString(w, line)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientThroughTsuru(server.URL, FakeTsuruToken, FakeTsuruService)
+	require.NoError(b, err)
+
+	b.SetBytes(int64(lines * len(line)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := client.Log(context.TODO(), LogArgs{Instance: "my-instance", Lines: lines, Out: io.Discard}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestClientThroughTsuru_LogRunningOnly(t *testing.T) {
+	infoHandler := func(w http.ResponseWriter, pods ...string) {
+		var items []string
+		for _, p := range pods {
+			name, status, _ := strings.Cut(p, ":")
+			items = append(items, fmt.Sprintf(`{"name": %q, "status": %q}`, name, status))
+		}
+		fmt.Fprintf(w, `{"pods": [%s]}`, strings.Join(items, ","))
+	}
+
+	t.Run("streams logs only from running pods", func(t *testing.T) {
+		client, server := newClientThroughTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("callback") {
+			case "/resources/my-instance/info":
+				infoHandler(w, "pod-1:Running", "pod-2:Errored", "pod-3:Running", "pod-4:Pending")
+			case "/resources/my-instance/log":
+				fmt.Fprintf(w, "log from %s\n", r.URL.Query().Get("pod"))
+			default:
+				t.Errorf("unexpected request: %s", r.URL.RequestURI())
+			}
+		}))
+		defer server.Close()
+
+		var out bytes.Buffer
+		err := client.Log(context.TODO(), LogArgs{Instance: "my-instance", Out: &out, RunningOnly: true})
+		require.NoError(t, err)
+		assert.Equal(t, "log from pod-1\nlog from pod-3\n", out.String())
+	})
+
+	t.Run("when there are no running pods", func(t *testing.T) {
+		client, server := newClientThroughTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			infoHandler(w, "pod-1:Errored")
+		}))
+		defer server.Close()
+
+		err := client.Log(context.TODO(), LogArgs{Instance: "my-instance", Out: io.Discard, RunningOnly: true})
+		assert.EqualError(t, err, `rpaasv2: no running pods found in instance "my-instance"`)
+	})
+
+	t.Run("pod overrides the running only filter", func(t *testing.T) {
+		client, server := newClientThroughTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/resources/my-instance/log", r.URL.Query().Get("callback"))
+			assert.Equal(t, "pod-2", r.URL.Query().Get("pod"))
+		}))
+		defer server.Close()
+
+		err := client.Log(context.TODO(), LogArgs{Instance: "my-instance", Out: io.Discard, Pod: "pod-2", RunningOnly: true})
+		require.NoError(t, err)
+	})
+
+	t.Run("picks up newly running pods when following", func(t *testing.T) {
+		var mu sync.Mutex
+		var listings int
+		streamed := make(chan string, 10)
+
+		client, server := newClientThroughTsuru(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Query().Get("callback") {
+			case "/resources/my-instance/info":
+				mu.Lock()
+				listings++
+				n := listings
+				mu.Unlock()
+
+				if n == 1 {
+					infoHandler(w, "pod-1:Running", "pod-2:Pending")
+					return
+				}
+
+				infoHandler(w, "pod-1:Running", "pod-2:Running")
+
+			case "/resources/my-instance/log":
+				pod := r.URL.Query().Get("pod")
+				assert.Equal(t, "true", r.URL.Query().Get("follow"))
+				fmt.Fprintf(w, "log from %s\n", pod)
+				streamed <- pod
+			}
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var out bytes.Buffer
+		done := make(chan error)
+		go func() {
+			done <- client.Log(ctx, LogArgs{Instance: "my-instance", Out: &out, Follow: true, RunningOnly: true, RelistInterval: 10 * time.Millisecond})
+		}()
+
+		assert.Equal(t, "pod-1", <-streamed)
+		assert.Equal(t, "pod-2", <-streamed)
+
+		// waits for another listing to make sure no pod is streamed twice
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return listings > 3
+		}, time.Second, 5*time.Millisecond)
+
+		cancel()
+		require.NoError(t, <-done)
+		assert.Empty(t, streamed)
+		assert.Equal(t, "log from pod-1\nlog from pod-2\n", out.String())
+	})
+}
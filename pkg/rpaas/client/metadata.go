@@ -0,0 +1,153 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func (args GetMetadataArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	return nil
+}
+
+func (c *client) GetMetadata(ctx context.Context, args GetMetadataArgs) (*types.Metadata, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	pathName := fmt.Sprintf("/resources/%s/metadata", args.Instance)
+	req, err := c.newRequest("GET", pathName, nil, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	var metadata types.Metadata
+	if err = c.unmarshalBody(response, &metadata); err != nil {
+		return nil, err
+	}
+
+	return &metadata, nil
+}
+
+func (args SetMetadataArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	return validateMetadata(args.Metadata, true)
+}
+
+func (c *client) SetMetadata(ctx context.Context, args SetMetadataArgs) error {
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	return c.sendMetadata(ctx, "POST", args.Instance, args.Metadata)
+}
+
+func (args UnsetMetadataArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	return validateMetadata(args.Metadata, false)
+}
+
+func (c *client) UnsetMetadata(ctx context.Context, args UnsetMetadataArgs) error {
+	if err := args.Validate(); err != nil {
+		return err
+	}
+
+	return c.sendMetadata(ctx, "DELETE", args.Instance, args.Metadata)
+}
+
+func (c *client) sendMetadata(ctx context.Context, method, instance string, metadata types.Metadata) error {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&metadata); err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(method, fmt.Sprintf("/resources/%s/metadata", instance), &body, instance)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	return nil
+}
+
+func validateMetadata(metadata types.Metadata, withValues bool) error {
+	if len(metadata.Labels) == 0 && len(metadata.Annotations) == 0 {
+		return ErrMissingMetadata
+	}
+
+	for _, item := range metadata.Labels {
+		if err := validateMetadataKey("label", item.Name); err != nil {
+			return err
+		}
+
+		if !withValues {
+			continue
+		}
+
+		if errs := validation.IsValidLabelValue(item.Value); len(errs) > 0 {
+			return fmt.Errorf("rpaasv2: invalid value for label %q: %s", item.Name, strings.Join(errs, "; "))
+		}
+	}
+
+	for _, item := range metadata.Annotations {
+		if err := validateMetadataKey("annotation", item.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateMetadataKey(kind, key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("rpaasv2: invalid %s key %q: %s", kind, key, strings.Join(errs, "; "))
+	}
+
+	if types.IsReservedMetadataKey(key) {
+		return fmt.Errorf("rpaasv2: %s %q is managed by the operator and cannot be changed", kind, key)
+	}
+
+	return nil
+}
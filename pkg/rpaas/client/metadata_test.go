@@ -0,0 +1,207 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestClientThroughTsuru_GetMetadata(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          GetMetadataArgs
+		expected      *types.Metadata
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when server returns an unexpected status code",
+			args:          GetMetadataArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: unexpected status code: 404 Not Found, detail: instance not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "instance not found")
+			},
+		},
+		{
+			name: "when server returns the instance metadata",
+			args: GetMetadataArgs{Instance: "my-instance"},
+			expected: &types.Metadata{
+				Labels:      []types.MetadataItem{{Name: "team", Value: "platform"}},
+				Annotations: []types.MetadataItem{{Name: "example.com/owner", Value: "someone"}},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "GET", r.Method)
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/metadata"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				fmt.Fprintf(w, `{"labels": [{"name": "team", "value": "platform"}], "annotations": [{"name": "example.com/owner", "value": "someone"}]}`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			metadata, err := client.GetMetadata(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, metadata)
+		})
+	}
+}
+
+func TestClientThroughTsuru_SetMetadata(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          SetMetadataArgs
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when metadata is empty",
+			args:          SetMetadataArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: metadata cannot be empty",
+		},
+		{
+			name: "when label key is invalid",
+			args: SetMetadataArgs{
+				Instance: "my-instance",
+				Metadata: types.Metadata{Labels: []types.MetadataItem{{Name: "invalid key", Value: "v"}}},
+			},
+			expectedError: `rpaasv2: invalid label key "invalid key": name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')`,
+		},
+		{
+			name: "when label value is invalid",
+			args: SetMetadataArgs{
+				Instance: "my-instance",
+				Metadata: types.Metadata{Labels: []types.MetadataItem{{Name: "team", Value: "not valid"}}},
+			},
+			expectedError: `rpaasv2: invalid value for label "team": a valid label must be an empty string or consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyValue',  or 'my_value',  or '12345', regex used for validation is '(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?')`,
+		},
+		{
+			name: "when annotation is managed by the operator",
+			args: SetMetadataArgs{
+				Instance: "my-instance",
+				Metadata: types.Metadata{Annotations: []types.MetadataItem{{Name: "rpaas_instance", Value: "other"}}},
+			},
+			expectedError: `rpaasv2: annotation "rpaas_instance" is managed by the operator and cannot be changed`,
+		},
+		{
+			name: "when metadata is successfully set",
+			args: SetMetadataArgs{
+				Instance: "my-instance",
+				Metadata: types.Metadata{
+					Labels:      []types.MetadataItem{{Name: "team", Value: "platform"}},
+					Annotations: []types.MetadataItem{{Name: "example.com/owner", Value: "some one"}},
+				},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method)
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/metadata"), r.URL.RequestURI())
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"labels": [{"name": "team", "value": "platform"}], "annotations": [{"name": "example.com/owner", "value": "some one"}]}`, string(body))
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			err := client.SetMetadata(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestClientThroughTsuru_UnsetMetadata(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          UnsetMetadataArgs
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when metadata is empty",
+			args:          UnsetMetadataArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: metadata cannot be empty",
+		},
+		{
+			name: "when server returns an unexpected status code",
+			args: UnsetMetadataArgs{
+				Instance: "my-instance",
+				Metadata: types.Metadata{Labels: []types.MetadataItem{{Name: "team"}}},
+			},
+			expectedError: `rpaasv2: unexpected status code: 404 Not Found, detail: label "team" not found in instance "my-instance"`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, `label "team" not found in instance "my-instance"`)
+			},
+		},
+		{
+			name: "when metadata is successfully unset",
+			args: UnsetMetadataArgs{
+				Instance: "my-instance",
+				Metadata: types.Metadata{
+					Labels:      []types.MetadataItem{{Name: "team"}},
+					Annotations: []types.MetadataItem{{Name: "example.com/owner"}},
+				},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "DELETE", r.Method)
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/metadata"), r.URL.RequestURI())
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"labels": [{"name": "team"}], "annotations": [{"name": "example.com/owner"}]}`, string(body))
+				w.WriteHeader(http.StatusOK)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			err := client.UnsetMetadata(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
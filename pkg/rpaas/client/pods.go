@@ -0,0 +1,56 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func (args ListPodsArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	return nil
+}
+
+func (c *client) ListPods(ctx context.Context, args ListPodsArgs) ([]types.Pod, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	qs := url.Values{}
+	if args.Selector != "" {
+		qs.Set("selector", args.Selector)
+	}
+
+	pathName := fmt.Sprintf("/resources/%s/pods", args.Instance)
+	req, err := c.newRequestWithQueryString("GET", pathName, nil, args.Instance, qs)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	var pods []types.Pod
+	if err = c.unmarshalBody(response, &pods); err != nil {
+		return nil, err
+	}
+
+	return pods, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestClientThroughTsuru_ListPods(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          ListPodsArgs
+		expected      []types.Pod
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when server returns an unexpected status code",
+			args:          ListPodsArgs{Instance: "my-instance", Selector: "version in (v1"},
+			expectedError: "rpaasv2: unexpected status code: 400 Bad Request, detail: invalid pod selector",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "invalid pod selector")
+			},
+		},
+		{
+			name: "when server returns the pods matching the selector",
+			args: ListPodsArgs{Instance: "my-instance", Selector: "version=v2"},
+			expected: []types.Pod{
+				{Name: "my-instance-abc", IP: "10.0.0.1", Status: "Running", Ready: true},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "GET")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s&selector=version%%3Dv2", FakeTsuruService, "my-instance", "/resources/my-instance/pods"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				fmt.Fprintf(w, `[{"name": "my-instance-abc", "ip": "10.0.0.1", "status": "Running", "ready": true}]`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			pods, err := client.ListPods(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, pods)
+		})
+	}
+}
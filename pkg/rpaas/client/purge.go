@@ -0,0 +1,107 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func (args PurgeCacheArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	if len(args.Paths) == 0 {
+		return ErrMissingPath
+	}
+
+	for _, path := range args.Paths {
+		if path == "" {
+			return ErrMissingPath
+		}
+
+		if args.PathRegexp {
+			if err := validatePathRegexp(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePathRegexp checks that path is a valid POSIX extended regular
+// expression, so that mistakes are caught before reaching every pod.
+func validatePathRegexp(path string) error {
+	if _, err := regexp.CompilePOSIX(path); err != nil {
+		return fmt.Errorf("rpaasv2: invalid path regular expression %q: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *client) PurgeCache(ctx context.Context, args PurgeCacheArgs) ([]types.PurgeCacheResult, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	type purgeArgs struct {
+		Path         string `json:"path"`
+		PreservePath bool   `json:"preserve_path"`
+		PathRegexp   bool   `json:"path_regexp"`
+	}
+
+	var reqArgs []purgeArgs
+	for _, path := range args.Paths {
+		reqArgs = append(reqArgs, purgeArgs{Path: path, PreservePath: args.PreservePath, PathRegexp: args.PathRegexp})
+	}
+
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(reqArgs); err != nil {
+		return nil, err
+	}
+
+	pathName := fmt.Sprintf("/resources/%s/purge/bulk", args.Instance)
+	req, err := c.newRequest("POST", pathName, &buffer, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: the API answers with 500 Internal Server Error when purging fails
+	// on any path or pod, but the results of the other ones are still there.
+	var results []types.PurgeCacheResult
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusInternalServerError:
+		if err = c.unmarshal(body, &results); err == nil {
+			return results, nil
+		}
+
+		if response.StatusCode == http.StatusOK {
+			return nil, err
+		}
+	}
+
+	return nil, &ErrUnexpectedStatusCode{Status: response.StatusCode, Body: string(body)}
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func TestClientThroughTsuru_PurgeCache(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          PurgeCacheArgs
+		expected      []types.PurgeCacheResult
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when no path is provided",
+			args:          PurgeCacheArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: path cannot be empty",
+		},
+		{
+			name:          "when some path is empty",
+			args:          PurgeCacheArgs{Instance: "my-instance", Paths: []string{"/index.html", ""}},
+			expectedError: "rpaasv2: path cannot be empty",
+		},
+		{
+			name:          "when some path is an invalid regular expression",
+			args:          PurgeCacheArgs{Instance: "my-instance", Paths: []string{"/index.html", "*.css"}, PathRegexp: true},
+			expectedError: "rpaasv2: invalid path regular expression \"*.css\": error parsing regexp: missing argument to repetition operator: `*`",
+		},
+		{
+			name: "when purging regular expressions",
+			args: PurgeCacheArgs{Instance: "my-instance", Paths: []string{"/index.html", "^/static/.*"}, PathRegexp: true},
+			expected: []types.PurgeCacheResult{
+				{Path: "/index.html", InstancesPurged: 1, Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true}}},
+				{Path: "^/static/.*", InstancesPurged: 1, Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true, Objects: 3}}},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, `[{"path":"/index.html","preserve_path":false,"path_regexp":true},{"path":"^/static/.*","preserve_path":false,"path_regexp":true}]`+"\n", getBody(t, r))
+				fmt.Fprintf(w, `[{"path": "/index.html", "instances_purged": 1, "pods": [{"pod": "my-instance-1", "purged": true}]}, {"path": "^/static/.*", "instances_purged": 1, "pods": [{"pod": "my-instance-1", "purged": true, "objects": 3}]}]`)
+			},
+		},
+		{
+			name:          "when server returns an unexpected status code",
+			args:          PurgeCacheArgs{Instance: "my-instance", Paths: []string{"/index.html"}},
+			expectedError: "rpaasv2: unexpected status code: 404 Not Found, detail: instance not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "instance not found")
+			},
+		},
+		{
+			name:          "when server fails without results",
+			args:          PurgeCacheArgs{Instance: "my-instance", Paths: []string{"/index.html"}},
+			expectedError: "rpaasv2: unexpected status code: 500 Internal Server Error, detail: some error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(w, "some error")
+			},
+		},
+		{
+			name: "when purging several paths",
+			args: PurgeCacheArgs{Instance: "my-instance", Paths: []string{"/index.html", "/static/.*"}, PreservePath: true, PathRegexp: true},
+			expected: []types.PurgeCacheResult{
+				{Path: "/index.html", InstancesPurged: 2, Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true, Objects: 1}, {Pod: "my-instance-2", Purged: true, Objects: 1}}},
+				{Path: "/static/.*", InstancesPurged: 2, Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true, Objects: 10}, {Pod: "my-instance-2", Purged: true, Objects: 7}}},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "POST", r.Method)
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/purge/bulk"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.Equal(t, `[{"path":"/index.html","preserve_path":true,"path_regexp":true},{"path":"/static/.*","preserve_path":true,"path_regexp":true}]`+"\n", getBody(t, r))
+				fmt.Fprintf(w, `[{"path": "/index.html", "instances_purged": 2, "pods": [{"pod": "my-instance-1", "purged": true, "objects": 1}, {"pod": "my-instance-2", "purged": true, "objects": 1}]}, {"path": "/static/.*", "instances_purged": 2, "pods": [{"pod": "my-instance-1", "purged": true, "objects": 10}, {"pod": "my-instance-2", "purged": true, "objects": 7}]}]`)
+			},
+		},
+		{
+			name: "when purging fails on some pod",
+			args: PurgeCacheArgs{Instance: "my-instance", Paths: []string{"/index.html"}},
+			expected: []types.PurgeCacheResult{
+				{Path: "/index.html", InstancesPurged: 1, Error: "purge failed on pod(s): my-instance-2", Pods: []types.PurgeCachePodResult{{Pod: "my-instance-1", Purged: true}, {Pod: "my-instance-2", Error: "connection refused"}}},
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(w, `[{"path": "/index.html", "instances_purged": 1, "error": "purge failed on pod(s): my-instance-2", "pods": [{"pod": "my-instance-1", "purged": true}, {"pod": "my-instance-2", "purged": false, "error": "connection refused"}]}]`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			results, err := client.PurgeCache(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, results)
+		})
+	}
+}
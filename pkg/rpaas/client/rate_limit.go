@@ -0,0 +1,254 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// maxRetries is how many times a request answered with 429 Too Many
+	// Requests, or whose attempt timed out, is sent again before giving up.
+	maxRetries = 3
+
+	// maxRetryAfter is the longest Retry-After honored, the response is
+	// returned as is when the server asks to wait longer than that.
+	maxRetryAfter = time.Minute
+)
+
+// rateLimitTransport paces the requests going through base, retrying the ones
+// rejected by the server with 429 Too Many Requests once their Retry-After
+// has elapsed. When tryTimeout is set, each attempt gets that long to receive
+// the response headers, and the idempotent requests whose attempt timed out
+// are sent again, so that a single slow attempt doesn't take up the whole
+// timeout of the request.
+type rateLimitTransport struct {
+	base       http.RoundTripper
+	limiter    *rate.Limiter
+	tryTimeout time.Duration
+}
+
+func newRateLimitTransport(base http.RoundTripper, rps float64, burst int, tryTimeout time.Duration) *rateLimitTransport {
+	if burst < 1 {
+		burst = 1
+	}
+
+	limit := rate.Inf
+	if rps > 0 {
+		limit = rate.Limit(rps)
+	}
+
+	return &rateLimitTransport{
+		base:       base,
+		limiter:    rate.NewLimiter(limit, burst),
+		tryTimeout: tryTimeout,
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		rsp, timedOut, err := t.try(req)
+		if timedOut {
+			if attempt == maxRetries || !isIdempotent(req.Method) {
+				return nil, err
+			}
+
+			var ok bool
+			if req, ok = rewind(req); !ok {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if err != nil || rsp.StatusCode != http.StatusTooManyRequests || attempt == maxRetries {
+			return rsp, err
+		}
+
+		delay, ok := parseRetryAfter(rsp.Header.Get("Retry-After"), time.Now())
+		if !ok || delay > maxRetryAfter {
+			return rsp, nil
+		}
+
+		if req, ok = rewind(req); !ok {
+			return rsp, nil
+		}
+
+		io.Copy(io.Discard, rsp.Body)
+		rsp.Body.Close()
+
+		if err = sleep(req.Context(), delay+jitter(delay)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// try sends req once, telling whether the attempt timed out. The per attempt
+// timeout only lasts until the response headers arrive, so that streamed
+// responses (e.g. logs being followed) are bounded by the request context
+// alone.
+func (t *rateLimitTransport) try(req *http.Request) (*http.Response, bool, error) {
+	if t.tryTimeout <= 0 {
+		rsp, err := t.base.RoundTrip(req)
+		return rsp, false, err
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(t.tryTimeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+
+	rsp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if timer.Stop() && err == nil {
+		rsp.Body = &cancelOnCloseBody{ReadCloser: rsp.Body, cancel: cancel}
+		return rsp, false, nil
+	}
+
+	if err == nil {
+		// NOTE: the response arrived along with the timeout, but its body
+		// can't be read anymore.
+		rsp.Body.Close()
+	}
+
+	cancel()
+
+	if !timedOut.Load() || req.Context().Err() != nil {
+		return nil, false, err
+	}
+
+	return nil, true, fmt.Errorf("rpaasv2: attempt timed out after %s: %w", t.tryTimeout, context.DeadlineExceeded)
+}
+
+// cancelOnCloseBody releases the context of an attempt once its response body
+// is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// rewind returns a copy of req ready to be sent again. The body was consumed
+// by the previous attempt, so the request can only be sent again when it can
+// be rewound.
+func rewind(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, true
+	}
+
+	if req.GetBody == nil {
+		return nil, false
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = body
+	return req, true
+}
+
+// isIdempotent tells whether a request with method may be sent again after
+// an attempt timed out, as the server might have handled it meanwhile.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// wait blocks until the limiter allows one more request. Waits are jittered
+// so that processes started together (e.g. by a script running commands in
+// parallel) don't keep hitting the server at the very same time.
+func (t *rateLimitTransport) wait(ctx context.Context) error {
+	r := t.limiter.Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+
+	if err := sleep(ctx, delay+jitter(delay)); err != nil {
+		r.Cancel()
+		return err
+	}
+
+	return nil
+}
+
+// parseRetryAfter reads the Retry-After header, given either in seconds or as
+// an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+
+	return 0, true
+}
+
+// jitter returns a random duration of up to 10% of d.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(d)/10 + 1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestRecorder is a fake server recording when each request arrives.
+type requestRecorder struct {
+	sync.Mutex
+	timestamps []time.Time
+	bodies     []string
+	handler    func(w http.ResponseWriter, r *http.Request, n int)
+}
+
+func (rr *requestRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rr.Lock()
+	rr.timestamps = append(rr.timestamps, time.Now())
+	rr.bodies = append(rr.bodies, string(body))
+	n := len(rr.timestamps)
+	rr.Unlock()
+
+	if rr.handler != nil {
+		rr.handler(w, r, n)
+	}
+}
+
+// attempts returns how many requests arrived so far. Requests abandoned by
+// the client can still be arriving, so it must be read under the lock.
+func (rr *requestRecorder) attempts() int {
+	rr.Lock()
+	defer rr.Unlock()
+
+	return len(rr.timestamps)
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	t.Run("paces the requests", func(t *testing.T) {
+		recorder := &requestRecorder{}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		httpClient := &http.Client{Transport: NewTransport(nil, ClientOptions{}.WithRateLimit(20, 2))}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rsp, err := httpClient.Get(server.URL)
+				if assert.NoError(t, err) {
+					rsp.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+
+		require.Len(t, recorder.timestamps, 6)
+		first, last := recorder.timestamps[0], recorder.timestamps[len(recorder.timestamps)-1]
+		// NOTE: the first two requests go right away (burst), the remaining
+		// ones wait at least 50ms each.
+		assert.GreaterOrEqual(t, last.Sub(first), 200*time.Millisecond)
+	})
+
+	t.Run("retries after the time given by the server on 429", func(t *testing.T) {
+		recorder := &requestRecorder{
+			handler: func(w http.ResponseWriter, r *http.Request, n int) {
+				if n == 1 {
+					w.Header().Set("Retry-After", "1")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+
+				fmt.Fprint(w, "ok")
+			},
+		}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		httpClient := &http.Client{Transport: NewTransport(nil, ClientOptions{}.WithRateLimit(100, 1))}
+		rsp, err := httpClient.Post(server.URL, "text/plain", strings.NewReader("some body"))
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, rsp.StatusCode)
+		require.Len(t, recorder.timestamps, 2)
+		assert.GreaterOrEqual(t, recorder.timestamps[1].Sub(recorder.timestamps[0]), time.Second)
+		assert.Equal(t, []string{"some body", "some body"}, recorder.bodies)
+	})
+
+	t.Run("gives up after too many retries", func(t *testing.T) {
+		recorder := &requestRecorder{
+			handler: func(w http.ResponseWriter, r *http.Request, n int) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+		}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		httpClient := &http.Client{Transport: NewTransport(nil, ClientOptions{}.WithRateLimit(100, 1))}
+		rsp, err := httpClient.Get(server.URL)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+
+		assert.Equal(t, http.StatusTooManyRequests, rsp.StatusCode)
+		assert.Equal(t, maxRetries+1, recorder.attempts())
+	})
+
+	t.Run("does not retry when the server asks to wait too long", func(t *testing.T) {
+		recorder := &requestRecorder{
+			handler: func(w http.ResponseWriter, r *http.Request, n int) {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+		}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		httpClient := &http.Client{Transport: NewTransport(nil, ClientOptions{}.WithRateLimit(100, 1))}
+		rsp, err := httpClient.Get(server.URL)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+
+		assert.Equal(t, http.StatusTooManyRequests, rsp.StatusCode)
+		assert.Equal(t, 1, recorder.attempts())
+	})
+
+	t.Run("stops waiting when the context is done", func(t *testing.T) {
+		recorder := &requestRecorder{}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		httpClient := &http.Client{Transport: NewTransport(nil, ClientOptions{}.WithRateLimit(0.1, 1))}
+		rsp, err := httpClient.Get(server.URL)
+		require.NoError(t, err)
+		rsp.Body.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		_, err = httpClient.Do(req)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 1, recorder.attempts())
+	})
+}
+
+func TestRateLimitTransportTryTimeout(t *testing.T) {
+	// NOTE: blocks the request until the client gives up on it.
+	hang := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+
+	t.Run("each attempt gets its own timeout", func(t *testing.T) {
+		recorder := &requestRecorder{
+			handler: func(w http.ResponseWriter, r *http.Request, n int) {
+				if n < 3 {
+					hang(w, r)
+					return
+				}
+
+				fmt.Fprint(w, "ok")
+			},
+		}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		httpClient := &http.Client{
+			Timeout:   time.Second,
+			Transport: NewTransport(nil, ClientOptions{TryTimeout: 100 * time.Millisecond}),
+		}
+		rsp, err := httpClient.Get(server.URL)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+
+		body, err := io.ReadAll(rsp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", string(body))
+
+		require.Len(t, recorder.timestamps, 3)
+		for i := 1; i < len(recorder.timestamps); i++ {
+			assert.GreaterOrEqual(t, recorder.timestamps[i].Sub(recorder.timestamps[i-1]), 100*time.Millisecond)
+		}
+	})
+
+	t.Run("attempts respect the overall deadline", func(t *testing.T) {
+		recorder := &requestRecorder{
+			handler: func(w http.ResponseWriter, r *http.Request, n int) { hang(w, r) },
+		}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		httpClient := &http.Client{
+			Timeout:   250 * time.Millisecond,
+			Transport: NewTransport(nil, ClientOptions{TryTimeout: 100 * time.Millisecond}),
+		}
+
+		start := time.Now()
+		_, err := httpClient.Get(server.URL)
+		elapsed := time.Since(start)
+
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		assert.True(t, netErr.Timeout())
+		assert.Less(t, elapsed, time.Second)
+		assert.Eventually(t, func() bool { return recorder.attempts() == 3 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("gives up after too many attempts", func(t *testing.T) {
+		recorder := &requestRecorder{
+			handler: func(w http.ResponseWriter, r *http.Request, n int) { hang(w, r) },
+		}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		httpClient := &http.Client{Transport: NewTransport(nil, ClientOptions{TryTimeout: 20 * time.Millisecond})}
+		_, err := httpClient.Get(server.URL)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "rpaasv2: attempt timed out after 20ms")
+		assert.Eventually(t, func() bool { return recorder.attempts() == maxRetries+1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("does not retry non idempotent requests", func(t *testing.T) {
+		recorder := &requestRecorder{
+			handler: func(w http.ResponseWriter, r *http.Request, n int) { hang(w, r) },
+		}
+		server := httptest.NewServer(recorder)
+		defer server.Close()
+
+		httpClient := &http.Client{Transport: NewTransport(nil, ClientOptions{TryTimeout: 20 * time.Millisecond})}
+		_, err := httpClient.Post(server.URL, "text/plain", strings.NewReader("some body"))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Eventually(t, func() bool { return recorder.attempts() == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("does not bound the reading of the body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "streamed ")
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+			fmt.Fprint(w, "body")
+		}))
+		defer server.Close()
+
+		httpClient := &http.Client{Transport: NewTransport(nil, ClientOptions{TryTimeout: 50 * time.Millisecond})}
+		rsp, err := httpClient.Get(server.URL)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+
+		body, err := io.ReadAll(rsp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "streamed body", string(body))
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, time.May, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: ""},
+		{value: "not a number"},
+		{value: "-1"},
+		{value: "0", ok: true},
+		{value: "120", expected: 2 * time.Minute, ok: true},
+		{value: "Wed, 10 May 2023 12:00:30 GMT", expected: 30 * time.Second, ok: true},
+		{value: "Wed, 10 May 2023 11:00:00 GMT", ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			delay, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, delay)
+		})
+	}
+}
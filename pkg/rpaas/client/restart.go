@@ -0,0 +1,57 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func (args RestartArgs) Validate() error {
+	if args.Instance == "" {
+		return ErrMissingInstance
+	}
+
+	return nil
+}
+
+func (c *client) Restart(ctx context.Context, args RestartArgs) ([]string, error) {
+	if err := args.Validate(); err != nil {
+		return nil, err
+	}
+
+	pathName := fmt.Sprintf("/resources/%s/restart", args.Instance)
+	values := url.Values{}
+	values.Set("immediate", strconv.FormatBool(args.Immediate))
+	body := strings.NewReader(values.Encode())
+	req, err := c.newRequest("POST", pathName, body, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	var restarted struct {
+		Pods []string `json:"pods"`
+	}
+	if err = c.unmarshalBody(response, &restarted); err != nil {
+		return nil, err
+	}
+
+	return restarted.Pods, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientThroughTsuru_Restart(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          RestartArgs
+		expected      []string
+		expectedError string
+		handler       http.HandlerFunc
+	}{
+		{
+			name:          "when instance is empty",
+			expectedError: "rpaasv2: instance cannot be empty",
+		},
+		{
+			name:          "when server returns an unexpected status code",
+			args:          RestartArgs{Instance: "my-instance"},
+			expectedError: "rpaasv2: unexpected status code: 404 Not Found, detail: instance not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprintf(w, "instance not found")
+			},
+		},
+		{
+			name:     "when performing a rolling restart",
+			args:     RestartArgs{Instance: "my-instance"},
+			expected: []string{"my-instance-abc", "my-instance-def"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "POST")
+				assert.Equal(t, fmt.Sprintf("/services/%s/proxy/%s?callback=%s", FakeTsuruService, "my-instance", "/resources/my-instance/restart"), r.URL.RequestURI())
+				assert.Equal(t, "Bearer f4k3t0k3n", r.Header.Get("Authorization"))
+				assert.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+				assert.Equal(t, "immediate=false", getBody(t, r))
+				fmt.Fprintf(w, `{"pods": ["my-instance-abc", "my-instance-def"]}`)
+			},
+		},
+		{
+			name:     "when performing an immediate restart",
+			args:     RestartArgs{Instance: "my-instance", Immediate: true},
+			expected: []string{"my-instance-abc"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "immediate=true", getBody(t, r))
+				fmt.Fprintf(w, `{"pods": ["my-instance-abc"]}`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := newClientThroughTsuru(t, tt.handler)
+			defer server.Close()
+			pods, err := client.Restart(context.TODO(), tt.args)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, pods)
+		})
+	}
+}
@@ -6,6 +6,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -88,7 +89,7 @@ func (c *client) ListRoutes(ctx context.Context, args ListRoutesArgs) ([]types.R
 	var routes struct {
 		Routes []types.Route `json:"paths"`
 	}
-	if err = unmarshalBody(response, &routes); err != nil {
+	if err = c.unmarshalBody(response, &routes); err != nil {
 		return nil, err
 	}
 
@@ -113,13 +114,24 @@ func (c *client) UpdateRoute(ctx context.Context, args UpdateRouteArgs) error {
 	}
 
 	values := types.Route{
-		Path:        args.Path,
-		Destination: args.Destination,
-		HTTPSOnly:   args.HTTPSOnly,
-		Content:     args.Content,
+		Path:         args.Path,
+		Destination:  args.Destination,
+		Destinations: args.Destinations,
+		HTTPSOnly:    args.HTTPSOnly,
+		Content:      args.Content,
 	}
 
+	// NOTE: the API cannot bind a list of weighted destinations from a form,
+	// so only such routes are sent as JSON, keeping the others compatible
+	// with older API versions.
+	contentType := "application/x-www-form-urlencoded"
 	b, err := form.EncodeToString(values)
+	if len(values.Destinations) > 0 {
+		var data []byte
+		data, err = json.Marshal(values)
+		contentType, b = "application/json", string(data)
+	}
+
 	if err != nil {
 		return err
 	}
@@ -130,7 +142,7 @@ func (c *client) UpdateRoute(ctx context.Context, args UpdateRouteArgs) error {
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Type", contentType)
 
 	response, err := c.do(ctx, req)
 	if err != nil {
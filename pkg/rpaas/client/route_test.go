@@ -197,6 +197,24 @@ func TestClientThroughTsuru_UpdateRoute(t *testing.T) {
 				w.WriteHeader(http.StatusCreated)
 			},
 		},
+		{
+			name: "when the route has weighted destinations",
+			args: UpdateRouteArgs{
+				Instance: "my-instance",
+				Path:     "/app",
+				Destinations: []types.WeightedDestination{
+					{Destination: "app.tsuru.example.com", Weight: 9},
+					{Destination: "app-canary.tsuru.example.com", Weight: 1},
+				},
+				HTTPSOnly: true,
+			},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.Method, "POST")
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.JSONEq(t, `{"path": "/app", "destinations": [{"destination": "app.tsuru.example.com", "weight": 9}, {"destination": "app-canary.tsuru.example.com", "weight": 1}], "https_only": true}`, getBody(t, r))
+				w.WriteHeader(http.StatusCreated)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,123 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// commandTokens holds the token source of each command, so that every client
+// built in the process (e.g. the ones targeting several instances) shares the
+// cached token rather than running the command again.
+var commandTokens sync.Map
+
+// commandToken is a bearer token printed by a command, kept until the server
+// rejects it.
+type commandToken struct {
+	command string
+
+	mu    sync.Mutex
+	token string
+}
+
+func newCommandToken(command string) *commandToken {
+	t, _ := commandTokens.LoadOrStore(command, &commandToken{command: command})
+	return t.(*commandToken)
+}
+
+// TokenFromCommand returns the token printed by command, running it only when
+// there's no token cached from a previous run.
+func TokenFromCommand(ctx context.Context, command string) (string, error) {
+	return newCommandToken(command).get(ctx)
+}
+
+func (t *commandToken) get(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" {
+		return t.token, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", t.command)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	// NOTE: the output holds the token, so it never makes it into errors.
+	if err := cmd.Run(); err != nil {
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return "", fmt.Errorf("rpaasv2: auth token command failed: %w: %s", err, detail)
+		}
+
+		return "", fmt.Errorf("rpaasv2: auth token command failed: %w", err)
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("rpaasv2: auth token command printed no token")
+	}
+
+	t.token = token
+	return token, nil
+}
+
+// invalidate drops token from the cache, unless it was already replaced by a
+// newer one (e.g. by a concurrent request rejected as well).
+func (t *commandToken) invalidate(token string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token == token {
+		t.token = ""
+	}
+}
+
+// tokenTransport sends the token from a command as the bearer token of every
+// request. Once a request is rejected with 401 Unauthorized, the command runs
+// again and the request is sent once more with the new token (as long as its
+// body can be rewound).
+type tokenTransport struct {
+	base  http.RoundTripper
+	token *commandToken
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := t.token.get(req.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		// NOTE: round trippers must not modify the original request.
+		r := req.Clone(req.Context())
+		r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+		rsp, err := t.base.RoundTrip(r)
+		if err != nil || rsp.StatusCode != http.StatusUnauthorized {
+			return rsp, err
+		}
+
+		t.token.invalidate(token)
+
+		if attempt > 0 {
+			return rsp, nil
+		}
+
+		var ok bool
+		if req, ok = rewind(req); !ok {
+			return rsp, nil
+		}
+
+		io.Copy(io.Discard, rsp.Body)
+		rsp.Body.Close()
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientWithTokenCommand(t *testing.T) {
+	// newTokenCommand returns a command printing the token held on a file,
+	// along with functions to replace the token and count the runs.
+	newTokenCommand := func(t *testing.T, token string) (string, func(string), func() int) {
+		dir := t.TempDir()
+		tokenFile, runsFile := filepath.Join(dir, "token"), filepath.Join(dir, "runs")
+		require.NoError(t, os.WriteFile(tokenFile, []byte(token+"\n"), 0600))
+
+		setToken := func(token string) {
+			require.NoError(t, os.WriteFile(tokenFile, []byte(token+"\n"), 0600))
+		}
+
+		runs := func() int {
+			data, _ := os.ReadFile(runsFile)
+			return strings.Count(string(data), "\n")
+		}
+
+		return fmt.Sprintf("echo >> %s && cat %s", runsFile, tokenFile), setToken, runs
+	}
+
+	t.Run("runs the command once for all the requests", func(t *testing.T) {
+		command, _, runs := newTokenCommand(t, "first-token")
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer first-token", r.Header.Get("Authorization"))
+			fmt.Fprintf(w, `[]`)
+		}))
+		defer server.Close()
+
+		client, err := NewClientThroughTsuruWithOptions(server.URL, "", FakeTsuruService, ClientOptions{TokenCommand: command})
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err = client.GetPodsUsage(context.TODO(), PodsUsageArgs{Instance: "my-instance"})
+			require.NoError(t, err)
+		}
+
+		other, err := NewClientThroughTsuruWithOptions(server.URL, "", FakeTsuruService, ClientOptions{TokenCommand: command})
+		require.NoError(t, err)
+
+		_, err = other.GetPodsUsage(context.TODO(), PodsUsageArgs{Instance: "my-instance"})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, runs())
+	})
+
+	t.Run("runs the command again once the token is rejected", func(t *testing.T) {
+		command, setToken, runs := newTokenCommand(t, "expired-token")
+
+		var bodies []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bodies = append(bodies, getBody(t, r))
+			if r.Header.Get("Authorization") != "Bearer renewed-token" {
+				setToken("renewed-token")
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}))
+		defer server.Close()
+
+		var verbose bytes.Buffer
+		client, err := NewClientThroughTsuruWithOptions(server.URL, "", FakeTsuruService, ClientOptions{TokenCommand: command, VerboseOutput: &verbose})
+		require.NoError(t, err)
+
+		err = client.Scale(context.TODO(), ScaleArgs{Instance: "my-instance", Replicas: 2})
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, runs())
+		assert.Equal(t, []string{"quantity=2", "quantity=2"}, bodies)
+		assert.Equal(t, 2, strings.Count(verbose.String(), "Authorization: [REDACTED]"))
+		assert.Contains(t, verbose.String(), "<-- 401 Unauthorized")
+		assert.NotContains(t, verbose.String(), "expired-token")
+		assert.NotContains(t, verbose.String(), "renewed-token")
+	})
+
+	t.Run("gives up when the new token is rejected as well", func(t *testing.T) {
+		command, _, runs := newTokenCommand(t, "invalid-token")
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		client, err := NewClientThroughTsuruWithOptions(server.URL, "", FakeTsuruService, ClientOptions{TokenCommand: command})
+		require.NoError(t, err)
+
+		_, err = client.GetPodsUsage(context.TODO(), PodsUsageArgs{Instance: "my-instance"})
+		assert.EqualError(t, err, "rpaasv2: unexpected status code: 401 Unauthorized")
+		assert.Equal(t, 2, runs())
+	})
+
+	t.Run("when the command fails", func(t *testing.T) {
+		client, err := NewClientThroughTsuruWithOptions("https://tsuru.example.com", "", FakeTsuruService, ClientOptions{TokenCommand: "echo not-a-token; echo permission denied >&2; exit 2"})
+		require.NoError(t, err)
+
+		_, err = client.GetPodsUsage(context.TODO(), PodsUsageArgs{Instance: "my-instance"})
+		assert.ErrorContains(t, err, "rpaasv2: auth token command failed: exit status 2: permission denied")
+		assert.NotContains(t, err.Error(), "not-a-token")
+	})
+
+	t.Run("when the command prints no token", func(t *testing.T) {
+		client, err := NewClientThroughTsuruWithOptions("https://tsuru.example.com", "", FakeTsuruService, ClientOptions{TokenCommand: "true"})
+		require.NoError(t, err)
+
+		_, err = client.GetPodsUsage(context.TODO(), PodsUsageArgs{Instance: "my-instance"})
+		assert.ErrorContains(t, err, "rpaasv2: auth token command printed no token")
+	})
+}
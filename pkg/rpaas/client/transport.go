@@ -5,9 +5,30 @@
 package client
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+const requestIDHeader = "X-Request-Id"
+
+var (
+	sensitiveHeaderWords = []string{"authorization", "cookie", "token", "secret", "password", "key"}
+
+	pemBlockRegexp = regexp.MustCompile(`(?s)-----BEGIN ([A-Z0-9 ]+)-----.*?-----END [A-Z0-9 ]+-----`)
 )
 
 var _ http.RoundTripper = (*BasicAuthTransport)(nil)
@@ -29,3 +50,228 @@ func (bat BasicAuthTransport) RoundTrip(req *http.Request) (*http.Response, erro
 
 	return base.RoundTrip(req)
 }
+
+// NewTransport wraps base so that every request carries the headers from
+// opts.Headers (along with the impersonation ones and, on the ones changing
+// instances, the field manager) and, when opts.VerboseOutput is set, gets an
+// X-Request-Id and is logged there. Requests are also paced according to
+// opts.RateLimit, and their attempts are bounded by opts.TryTimeout. When
+// opts.TokenCommand is set, its token is sent on every request. It returns
+// base itself when there's nothing to do.
+func NewTransport(base http.RoundTripper, opts ClientOptions) http.RoundTripper {
+	headers := opts.requestHeaders()
+	if len(headers) == 0 && opts.FieldManager == "" && opts.VerboseOutput == nil && opts.RateLimit <= 0 && opts.TryTimeout <= 0 && opts.TokenCommand == "" {
+		return base
+	}
+
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	rt := base
+	if len(headers) > 0 || opts.FieldManager != "" || opts.VerboseOutput != nil {
+		rt = &transport{
+			base:         base,
+			headers:      headers,
+			fieldManager: opts.FieldManager,
+			verbose:      opts.VerboseOutput,
+			bodies:       opts.VerboseBodies,
+		}
+	}
+
+	if opts.RateLimit > 0 || opts.TryTimeout > 0 {
+		rt = newRateLimitTransport(rt, opts.RateLimit, opts.RateLimitBurst, opts.TryTimeout)
+	}
+
+	if opts.TokenCommand != "" {
+		// NOTE: each attempt goes through the inner transports, so that
+		// the retry after a rejected token is logged (and paced) as well.
+		rt = &tokenTransport{base: rt, token: newCommandToken(opts.TokenCommand)}
+	}
+
+	return rt
+}
+
+type transport struct {
+	base         http.RoundTripper
+	headers      http.Header
+	fieldManager string
+	verbose      io.Writer
+	bodies       bool
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// NOTE: round trippers must not modify the original request.
+	req = req.Clone(req.Context())
+	setHeaders(req.Header, t.headers)
+
+	if t.fieldManager != "" && isMutatingMethod(req.Method) {
+		req.Header.Set(FieldManagerHeader, t.fieldManager)
+	}
+
+	if t.verbose == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	requestID := setRequestID(req.Header)
+	logRequest(t.verbose, req.Method, req.URL.Redacted(), req.Header)
+
+	if t.bodies && req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		logBody(t.verbose, "> ", body)
+	}
+
+	start := time.Now()
+	rsp, err := t.base.RoundTrip(req)
+	logResponse(t.verbose, rsp, requestID, start, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.bodies {
+		// NOTE: streamed responses (e.g. logs being followed) have no end, so
+		// only the bounded ones are buffered to be printed.
+		if rsp.ContentLength < 0 && !strings.HasPrefix(rsp.Header.Get("Content-Type"), "application/json") {
+			fmt.Fprintln(t.verbose, "    < (streamed body not shown)")
+			return rsp, nil
+		}
+
+		body, err := io.ReadAll(rsp.Body)
+		rsp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		rsp.Body = io.NopCloser(bytes.NewReader(body))
+		if strings.HasPrefix(rsp.Header.Get("Content-Type"), types.MsgpackContentType) {
+			fmt.Fprintf(t.verbose, "    < (%d bytes of MessagePack not shown)\n", len(body))
+			return rsp, nil
+		}
+
+		logBody(t.verbose, "< ", body)
+	}
+
+	return rsp, nil
+}
+
+// dialWebsocket opens a websocket connection sending the extra headers and
+// logging the handshake in verbose mode, just like the HTTP transport does
+// for regular requests. The server may accept one of the given subprotocols,
+// see websocket.Conn.Subprotocol.
+func (c *client) dialWebsocket(ctx context.Context, u string, subprotocols ...string) (*websocket.Conn, error) {
+	header := c.baseAuthHeader(nil)
+	setHeaders(header, c.headers)
+	if len(subprotocols) > 0 {
+		header["Sec-WebSocket-Protocol"] = subprotocols
+	}
+
+	if c.tokens != nil {
+		token, err := c.tokens.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	if c.verbose == nil {
+		conn, _, err := c.ws.DialContext(ctx, u, header)
+		return conn, err
+	}
+
+	requestID := setRequestID(header)
+	logRequest(c.verbose, "GET", u, header)
+
+	start := time.Now()
+	conn, rsp, err := c.ws.DialContext(ctx, u, header)
+	logResponse(c.verbose, rsp, requestID, start, err)
+	return conn, err
+}
+
+func setHeaders(dst, src http.Header) {
+	for name, values := range src {
+		dst[http.CanonicalHeaderKey(name)] = values
+	}
+}
+
+func setRequestID(h http.Header) string {
+	requestID := h.Get(requestIDHeader)
+	if requestID == "" {
+		requestID = newRequestID()
+		h.Set(requestIDHeader, requestID)
+	}
+
+	return requestID
+}
+
+func logRequest(w io.Writer, method, u string, h http.Header) {
+	fmt.Fprintf(w, "--> %s %s\n", method, u)
+
+	var names []string
+	for name := range h {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		value := strings.Join(h.Values(name), ", ")
+		if isSensitiveHeader(name) {
+			value = "[REDACTED]"
+		}
+
+		fmt.Fprintf(w, "    %s: %s\n", name, value)
+	}
+}
+
+func logResponse(w io.Writer, rsp *http.Response, requestID string, start time.Time, err error) {
+	elapsed := time.Since(start).Truncate(time.Microsecond)
+
+	if err != nil {
+		status := "request failed"
+		if rsp != nil {
+			status = rsp.Status
+		}
+
+		fmt.Fprintf(w, "<-- %s in %s (%s: %s): %v\n", status, elapsed, requestIDHeader, requestID, err)
+		return
+	}
+
+	fmt.Fprintf(w, "<-- %s in %s (%s: %s)\n", rsp.Status, elapsed, requestIDHeader, requestID)
+}
+
+func logBody(w io.Writer, prefix string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	body = pemBlockRegexp.ReplaceAll(body, []byte("[REDACTED $1]"))
+	for _, line := range strings.Split(strings.TrimRight(string(body), "\r\n"), "\n") {
+		fmt.Fprintf(w, "    %s%s\n", prefix, strings.TrimRight(line, "\r"))
+	}
+}
+
+func isSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b)
+}
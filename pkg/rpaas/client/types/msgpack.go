@@ -0,0 +1,75 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// MsgpackContentType is the media type of the compact binary encoding the
+// API answers the info and list endpoints with, when asked for it.
+const MsgpackContentType = "application/msgpack"
+
+// msgpackStructTag makes the MessagePack fields carry the same names (and
+// omitempty rules) as the JSON ones, so both encodings hold the same data.
+const msgpackStructTag = "json"
+
+func init() {
+	// NOTE: these types have their own JSON representation (e.g. RFC 3339
+	// times, "100Mi" quantities), which MessagePack would otherwise encode
+	// field by field or even lose (unexported fields), so they're carried
+	// in their JSON form instead to decode exactly as JSON does.
+	for _, v := range []interface{}{time.Time{}, resource.Quantity{}, intstr.IntOrString{}} {
+		msgpack.Register(v, encodeMsgpackAsJSON, decodeMsgpackAsJSON)
+	}
+}
+
+func encodeMsgpackAsJSON(e *msgpack.Encoder, v reflect.Value) error {
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+
+	return e.EncodeBytes(data)
+}
+
+func decodeMsgpackAsJSON(d *msgpack.Decoder, v reflect.Value) error {
+	data, err := d.DecodeBytes()
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v.Addr().Interface())
+}
+
+// MarshalMsgpack returns the MessagePack encoding of v, whose decoding is the
+// same as the one of its JSON encoding.
+func MarshalMsgpack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag(msgpackStructTag)
+	enc.UseCompactInts(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgpack decodes the MessagePack data into v, refusing fields
+// unknown to v when strict is set.
+func UnmarshalMsgpack(data []byte, v interface{}, strict bool) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag(msgpackStructTag)
+	dec.DisallowUnknownFields(strict)
+	return dec.Decode(v)
+}
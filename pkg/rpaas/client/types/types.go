@@ -6,6 +6,7 @@ package types
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -25,10 +26,16 @@ type Block struct {
 }
 
 type Route struct {
-	Path        string `json:"path" form:"path"`
-	Destination string `json:"destination,omitempty" form:"destination,omitempty"`
-	HTTPSOnly   bool   `json:"https_only,omitempty" form:"https_only,omitempty"`
-	Content     string `json:"content,omitempty" form:"content,omitempty"`
+	Path         string                `json:"path" form:"path"`
+	Destination  string                `json:"destination,omitempty" form:"destination,omitempty"`
+	Destinations []WeightedDestination `json:"destinations,omitempty" form:"-"`
+	HTTPSOnly    bool                  `json:"https_only,omitempty" form:"https_only,omitempty"`
+	Content      string                `json:"content,omitempty" form:"content,omitempty"`
+}
+
+type WeightedDestination struct {
+	Destination string `json:"destination"`
+	Weight      int32  `json:"weight"`
 }
 
 type Autoscale struct {
@@ -44,6 +51,15 @@ type Flavor struct {
 	Description string `json:"description"`
 }
 
+type FlavorInfo struct {
+	Name                string                      `json:"name"`
+	Description         string                      `json:"description,omitempty"`
+	Default             bool                        `json:"default,omitempty"`
+	CreationOnly        bool                        `json:"creationOnly,omitempty"`
+	IncompatibleFlavors []string                    `json:"incompatibleFlavors,omitempty"`
+	InstanceTemplate    *v1alpha1.RpaasInstanceSpec `json:"instanceTemplate,omitempty"`
+}
+
 type Plan struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
@@ -95,19 +111,138 @@ type Pod struct {
 	Name         string      `json:"name"`
 	IP           string      `json:"ip"`
 	HostIP       string      `json:"host"`
+	NodeName     string      `json:"nodeName,omitempty"`
+	Zone         string      `json:"zone,omitempty"`
 	Status       string      `json:"status"`
 	Ports        []PodPort   `json:"ports,omitempty"`
+	Containers   []string    `json:"containers,omitempty"`
 	Errors       []PodError  `json:"errors,omitempty"`
 	Restarts     int32       `json:"restarts"`
 	Ready        bool        `json:"ready"`
 	Metrics      *PodMetrics `json:"metrics,omitempty"`
 }
 
+type ConnectionStats struct {
+	Active  int64 `json:"active"`
+	Reading int64 `json:"reading"`
+	Writing int64 `json:"writing"`
+	Waiting int64 `json:"waiting"`
+}
+
+type PodConnectionStats struct {
+	Pod   string           `json:"pod"`
+	Stats *ConnectionStats `json:"stats,omitempty"`
+	Error string           `json:"error,omitempty"`
+}
+
+// PodHealth reports whether the NGINX of a pod answers its health check.
+type PodHealth struct {
+	Pod     string `json:"pod"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BlockValidation is the result of checking the NGINX configuration of an
+// instance along with a block, with "nginx -t" on one of its pods. Output is
+// what NGINX wrote out.
+type BlockValidation struct {
+	Pod    string `json:"pod,omitempty"`
+	Valid  bool   `json:"valid"`
+	Output string `json:"output,omitempty"`
+}
+
+// BindStatus describes an app bound to the instance, where Healthy reports
+// whether its address is reachable from the instance's network.
+type BindStatus struct {
+	App     string `json:"app"`
+	Address string `json:"address"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+type PurgeCacheResult struct {
+	Path            string                `json:"path"`
+	InstancesPurged int                   `json:"instances_purged,omitempty"`
+	Error           string                `json:"error,omitempty"`
+	Pods            []PurgeCachePodResult `json:"pods,omitempty"`
+}
+
+type PurgeCachePodResult struct {
+	Pod     string `json:"pod"`
+	Purged  bool   `json:"purged"`
+	Objects int    `json:"objects,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type PodCertificateStatus struct {
+	Pod string `json:"pod"`
+	// Fingerprints holds the SHA-256 of every certificate (in DER) served by
+	// the pod, sorted so the chain order does not matter.
+	Fingerprints []string `json:"fingerprints,omitempty"`
+	Error        string   `json:"error,omitempty"`
+}
+
 type PodMetrics struct {
 	CPU    string `json:"cpu"`
 	Memory string `json:"memory"`
 }
 
+// PodUsage is the resources usage of a pod, whose Metrics are missing when
+// they aren't available (e.g. metrics-server is not running).
+type PodUsage struct {
+	Pod     string      `json:"pod"`
+	Metrics *PodMetrics `json:"metrics,omitempty"`
+}
+
+type Certificate struct {
+	Name         string `json:"name"`
+	Certificate  string `json:"certificate"`
+	Key          string `json:"key"`
+	OCSPStapling bool   `json:"ocspStapling,omitempty"`
+}
+
+// PublicCertificate is a certificate chain (leaf first) in PEM, carrying
+// only its public parts.
+type PublicCertificate struct {
+	Name        string `json:"name"`
+	Certificate string `json:"certificate"`
+}
+
+// Version is the build version of a component, e.g. "v0.40.0/1a2b3c4", or
+// "NA" when it wasn't set on build.
+type Version struct {
+	Version string `json:"version"`
+}
+
+type MetadataItem struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+type Metadata struct {
+	Labels      []MetadataItem `json:"labels"`
+	Annotations []MetadataItem `json:"annotations"`
+}
+
+// ReservedMetadataPrefixes are the key prefixes of labels and annotations
+// managed by the operator itself, which users are not allowed to change.
+var ReservedMetadataPrefixes = []string{
+	"rpaas.extensions.tsuru.io/",
+	"rpaas_",
+}
+
+// IsReservedMetadataKey reports whether the label or annotation key is
+// managed by the operator.
+func IsReservedMetadataKey(key string) bool {
+	for _, prefix := range ReservedMetadataPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type CertificateInfo struct {
 	Name               string
 	ValidFrom          time.Time
@@ -117,6 +252,28 @@ type CertificateInfo struct {
 	PublicKeyBitSize   int
 }
 
+// CertificateRotationSuffix is appended to a certificate name to hold its
+// replacement while it's being rotated.
+const CertificateRotationSuffix = "-rotate"
+
+// IsCertificateRotation reports whether one of the names is the temporary
+// rotation counterpart of the other.
+func IsCertificateRotation(a, b string) bool {
+	return a+CertificateRotationSuffix == b || b+CertificateRotationSuffix == a
+}
+
+// HistoryEntry is a change made to an instance, or an event reported on it.
+// Actor is who made the change (i.e. the field manager or the component
+// reporting the event) and Action is either the operation (e.g. Update) or
+// the event reason.
+type HistoryEntry struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Actor   string    `json:"actor,omitempty"`
+	Action  string    `json:"action,omitempty"`
+	Message string    `json:"message"`
+}
+
 type Event struct {
 	First   time.Time `json:"first"`
 	Last    time.Time `json:"last"`
@@ -161,3 +318,19 @@ type CertManager struct {
 	DNSNames    []string `json:"dnsNames,omitempty"`
 	IPAddresses []string `json:"ipAddresses,omitempty"`
 }
+
+// ExecChannelProtocol is the WebSocket subprotocol of the exec endpoint on
+// which every message starts with the byte of its channel, telling apart
+// the standard output and error of the remote command as well as its exit
+// status (a decimal number sent right before the connection is closed).
+const ExecChannelProtocol = "v1.channel.rpaas.tsuru.io"
+
+const (
+	ExecStdoutChannel byte = 1
+	ExecStderrChannel byte = 2
+	ExecStatusChannel byte = 3
+)
+
+// FieldManagerHeader carries the field manager of the requests changing
+// instances, which the API sets as the owner of the fields it writes.
+const FieldManagerHeader = "X-Field-Manager"
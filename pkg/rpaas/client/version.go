@@ -0,0 +1,42 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func (c *client) GetVersion(ctx context.Context, args GetVersionArgs) (*types.Version, error) {
+	// NOTE: Tsuru only proxies requests to the service on behalf of an
+	// instance, even though the version doesn't depend on it.
+	if c.throughTsuru && args.Instance == "" {
+		return nil, ErrMissingInstance
+	}
+
+	req, err := c.newRequest("GET", "/version", nil, args.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, newErrUnexpectedStatusCodeFromResponse(response)
+	}
+
+	var version types.Version
+	if err = c.unmarshalBody(response, &version); err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
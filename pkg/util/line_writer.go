@@ -0,0 +1,92 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// LineWriter splits the data written into it in lines, calling onLine on
+// every complete one (trailing newline included).
+//
+// Writers created with the same mutex never run their callbacks at the same
+// time, so that concurrent streams sharing the same output do not interleave
+// their lines.
+type LineWriter struct {
+	onLine func(line []byte) error
+	mu     *sync.Mutex
+	buf    bytes.Buffer
+}
+
+var _ io.Writer = (*LineWriter)(nil)
+
+// NewLineWriter returns a LineWriter calling onLine under mu, which may be
+// nil when the writer is not shared.
+func NewLineWriter(mu *sync.Mutex, onLine func(line []byte) error) *LineWriter {
+	if mu == nil {
+		mu = &sync.Mutex{}
+	}
+
+	return &LineWriter{onLine: onLine, mu: mu}
+}
+
+// NewLineForwarder returns a LineWriter forwarding every complete line into
+// w, prefixed with prefix.
+func NewLineForwarder(w io.Writer, mu *sync.Mutex, prefix string) *LineWriter {
+	return NewLineWriter(mu, func(line []byte) error {
+		if prefix != "" {
+			if _, err := io.WriteString(w, prefix); err != nil {
+				return err
+			}
+		}
+
+		_, err := w.Write(line)
+		return err
+	})
+}
+
+func (lw *LineWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	lw.buf.Write(p)
+
+	for {
+		idx := bytes.IndexByte(lw.buf.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+
+		if err := lw.onLine(lw.buf.Next(idx + 1)); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush passes the partial line left, if any, to the callback as if it were
+// complete.
+func (lw *LineWriter) Flush() error {
+	return lw.Finish(nil)
+}
+
+// Finish is like Flush, but appends end to the partial line before its
+// newline, e.g. the escape sequence resetting the terminal colors which a
+// line cut in half could leave set.
+func (lw *LineWriter) Finish(end []byte) error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if lw.buf.Len() == 0 {
+		return nil
+	}
+
+	line := append(append(lw.buf.Bytes(), end...), '\n')
+	lw.buf.Reset()
+	return lw.onLine(line)
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	lw := NewLineWriter(nil, func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+
+	n, err := lw.Write([]byte("first\nsec"))
+	require.NoError(t, err)
+	assert.Equal(t, 9, n)
+	assert.Equal(t, []string{"first\n"}, lines)
+
+	_, err = lw.Write([]byte("ond\nthird\npartial"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first\n", "second\n", "third\n"}, lines)
+
+	require.NoError(t, lw.Flush())
+	require.NoError(t, lw.Flush())
+	assert.Equal(t, []string{"first\n", "second\n", "third\n", "partial\n"}, lines)
+}
+
+func TestLineWriterFinish(t *testing.T) {
+	var out bytes.Buffer
+	lw := NewLineForwarder(&out, nil, "")
+
+	require.NoError(t, lw.Finish([]byte("<end>")))
+	assert.Equal(t, "", out.String())
+
+	fmt.Fprint(lw, "complete\npartial")
+	require.NoError(t, lw.Finish([]byte("<end>")))
+	assert.Equal(t, "complete\npartial<end>\n", out.String())
+}
+
+func TestLineWriterError(t *testing.T) {
+	lw := NewLineWriter(nil, func(line []byte) error {
+		return fmt.Errorf("some error")
+	})
+
+	_, err := lw.Write([]byte("line\n"))
+	assert.EqualError(t, err, "some error")
+}
+
+func TestNewLineForwarder(t *testing.T) {
+	var out bytes.Buffer
+	lw := NewLineForwarder(&out, nil, "[prefix] ")
+
+	fmt.Fprint(lw, "first\nsecond")
+	assert.Equal(t, "[prefix] first\n", out.String())
+
+	require.NoError(t, lw.Flush())
+	assert.Equal(t, "[prefix] first\n[prefix] second\n", out.String())
+}
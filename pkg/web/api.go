@@ -24,6 +24,7 @@ import (
 	"github.com/tsuru/rpaas-operator/internal/config"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
 	"github.com/tsuru/rpaas-operator/pkg/observability"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 	"github.com/tsuru/rpaas-operator/pkg/web/target"
 )
 
@@ -189,6 +190,7 @@ func newEcho(targetFactory target.Factory) *echo.Echo {
 
 	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
 	e.GET("/healthcheck", healthcheck)
+	e.GET("/version", getVersion)
 
 	group := e.Group("/resources", func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(echoCtx echo.Context) error {
@@ -198,6 +200,9 @@ func newEcho(targetFactory target.Factory) *echo.Echo {
 				return err
 			}
 			ctx := rpaas.ContextWithRpaasManager(req.Context(), manager)
+			if fieldManager := req.Header.Get(clientTypes.FieldManagerHeader); fieldManager != "" {
+				ctx = rpaas.ContextWithFieldManager(ctx, fieldManager)
+			}
 			req = req.WithContext(ctx)
 			echoCtx.SetRequest(req)
 			return next(echoCtx)
@@ -207,6 +212,9 @@ func newEcho(targetFactory target.Factory) *echo.Echo {
 	group.POST("", serviceCreate)
 	group.GET("/flavors", getServiceFlavors)
 	group.GET("/:instance/flavors", getInstanceFlavors)
+	group.PUT("/:instance/flavors", updateInstanceFlavors)
+	group.GET("/flavors/:flavor", getFlavor)
+	group.GET("/:instance/flavors/:flavor", getFlavor)
 	group.GET("/plans", servicePlans)
 	group.GET("/:instance/plans", servicePlans)
 	group.GET("/:instance", serviceInfo)
@@ -215,6 +223,7 @@ func newEcho(targetFactory target.Factory) *echo.Echo {
 	group.GET("/:instance/node_status", serviceNodeStatus)
 	group.DELETE("/:instance", serviceDelete)
 	group.GET("/:instance/autoscale", getAutoscale)
+	group.GET("/:instance/autoscale/object", getAutoscaleObject)
 	group.POST("/:instance/autoscale", updateAutoscale)
 	group.PUT("/:instance/autoscale", updateAutoscale)
 	group.PATCH("/:instance/autoscale", updateAutoscale)
@@ -223,17 +232,27 @@ func newEcho(targetFactory target.Factory) *echo.Echo {
 	group.DELETE("/:instance/bind-app", serviceUnbindApp)
 	group.POST("/:instance/bind", serviceBindUnit)
 	group.DELETE("/:instance/bind", serviceUnbindUnit)
+	group.GET("/:instance/binds", bindsStatus)
 	group.POST("/:instance/scale", scale)
+	group.POST("/:instance/restart", restart)
+	group.GET("/:instance/stats", connectionStats)
+	group.GET("/:instance/health", podsHealth)
+	group.GET("/:instance/top", podsUsage)
+	group.GET("/:instance/pods", listPods)
 	group.GET("/:instance/info", instanceInfo)
+	group.GET("/:instance/history", instanceHistory)
 	group.POST("/:instance/certificate", updateCertificate)
 	group.DELETE("/:instance/certificate/:name", deleteCertificate)
 	group.DELETE("/:instance/certificate", deleteCertificate)
 	group.GET("/:instance/certificate", getCertificates)
+	group.GET("/:instance/certificate/export", exportCertificates)
+	group.GET("/:instance/certificate/:name/status", getCertificateStatus)
 	group.GET("/:instance/cert-manager", listCertManagerRequests)
 	group.POST("/:instance/cert-manager", updateCertManagerRequest)
 	group.DELETE("/:instance/cert-manager", deleteCertManagerRequest)
 	group.GET("/:instance/block", listBlocks)
 	group.POST("/:instance/block", updateBlock)
+	group.POST("/:instance/block/validate", validateBlock)
 	group.DELETE("/:instance/block/:block", deleteBlock)
 	group.DELETE("/:instance/lua", deleteLuaBlock)
 	group.GET("/:instance/lua", listLuaBlocks)
@@ -254,6 +273,9 @@ func newEcho(targetFactory target.Factory) *echo.Echo {
 	group.POST("/:instance/acl", addUpstream)
 	group.DELETE("/:instance/acl", deleteUpstream)
 	group.GET("/:instance/log", log)
+	group.GET("/:instance/metadata", getMetadata)
+	group.POST("/:instance/metadata", setMetadata)
+	group.DELETE("/:instance/metadata", unsetMetadata)
 
 	return e
 }
@@ -27,6 +27,21 @@ func getAutoscale(c echo.Context) error {
 	return c.JSON(http.StatusOK, autoscale)
 }
 
+func getAutoscaleObject(c echo.Context) error {
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+
+	obj, err := manager.GetAutoscaleObject(ctx, c.Param("instance"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, obj)
+}
+
 func updateAutoscale(c echo.Context) error {
 	ctx := c.Request().Context()
 	manager, err := getManager(ctx)
@@ -10,6 +10,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
@@ -82,6 +86,34 @@ func Test_getAutoscale(t *testing.T) {
 	}
 }
 
+func Test_getAutoscaleObject(t *testing.T) {
+	manager := &fake.RpaasManager{
+		FakeGetAutoscaleObject: func(instance string) (client.Object, error) {
+			if instance != "my-instance" {
+				return nil, rpaas.NotFoundError{Msg: fmt.Sprintf("rpaas instance %q not found", instance)}
+			}
+
+			return &autoscalingv2.HorizontalPodAutoscaler{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler"},
+				ObjectMeta: metav1.ObjectMeta{Name: "my-instance", Namespace: "rpaasv2"},
+				Spec:       autoscalingv2.HorizontalPodAutoscalerSpec{MaxReplicas: 10},
+			}, nil
+		},
+	}
+
+	srv := newTestingServer(t, manager)
+	defer srv.Close()
+
+	rsp, err := srv.Client().Get(fmt.Sprintf("%s/resources/my-instance/autoscale/object", srv.URL))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.JSONEq(t, `{"apiVersion":"autoscaling/v2","kind":"HorizontalPodAutoscaler","metadata":{"name":"my-instance","namespace":"rpaasv2","creationTimestamp":null},"spec":{"scaleTargetRef":{"kind":"","name":""},"maxReplicas":10},"status":{"desiredReplicas":0,"currentMetrics":null}}`, bodyContent(rsp))
+
+	rsp, err = srv.Client().Get(fmt.Sprintf("%s/resources/invalid-instance/autoscale/object", srv.URL))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, rsp.StatusCode)
+}
+
 /*
 func Test_updateAutoscale(t *testing.T) {
 	tests := []struct {
@@ -45,7 +45,9 @@ func listBlocks(c echo.Context) error {
 		blocks = make([]rpaas.ConfigurationBlock, 0)
 	}
 
-	return c.JSON(http.StatusOK, struct {
+	c.Response().Header().Set("ETag", fmt.Sprintf("%q", rpaas.BlocksVersion(blocks)))
+
+	return encodedResponse(c, http.StatusOK, struct {
 		Blocks []rpaas.ConfigurationBlock `json:"blocks"`
 	}{blocks})
 }
@@ -65,6 +67,8 @@ func updateBlock(c echo.Context) error {
 		return err
 	}
 
+	block.IfVersion = strings.Trim(c.Request().Header.Get("If-Match"), `"`)
+
 	err = manager.UpdateBlock(ctx, c.Param("instance"), block)
 	if err != nil {
 		return err
@@ -73,6 +77,29 @@ func updateBlock(c echo.Context) error {
 	return c.NoContent(http.StatusOK)
 }
 
+func validateBlock(c echo.Context) error {
+	if c.Request().ContentLength == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Request body can't be empty")
+	}
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+
+	var block rpaas.ConfigurationBlock
+	if err = c.Bind(&block); err != nil {
+		return err
+	}
+
+	result, err := manager.ValidateBlock(ctx, c.Param("instance"), block)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
 func deleteLuaBlock(c echo.Context) error {
 	ctx := c.Request().Context()
 	manager, err := getManager(ctx)
@@ -16,6 +16,7 @@ import (
 
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func Test_deleteBlock(t *testing.T) {
@@ -131,6 +132,7 @@ func Test_listBlocks(t *testing.T) {
 			err = json.Unmarshal([]byte(bodyContent(rsp)), &got)
 			assert.NoError(t, err)
 			assert.Equal(t, got, tt.expectedBlocks)
+			assert.Equal(t, fmt.Sprintf("%q", rpaas.BlocksVersion(tt.expectedBlocks.Blocks)), rsp.Header.Get("ETag"))
 		})
 	}
 }
@@ -140,6 +142,7 @@ func Test_updateBlock(t *testing.T) {
 		name         string
 		instance     string
 		requestBody  string
+		ifMatch      string
 		expectedCode int
 		expectedBody string
 		manager      rpaas.RpaasManager
@@ -178,6 +181,20 @@ func Test_updateBlock(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:         "when the If-Match header is set",
+			instance:     "my-instance",
+			requestBody:  "block_name=server&content=%23%20My%20nginx%20custom%20conf",
+			ifMatch:      `"abc123"`,
+			expectedCode: http.StatusConflict,
+			expectedBody: "blocks were changed meanwhile",
+			manager: &fake.RpaasManager{
+				FakeUpdateBlock: func(instance string, block rpaas.ConfigurationBlock) error {
+					assert.Equal(t, rpaas.ConfigurationBlock{Name: "server", Content: "# My nginx custom conf", IfVersion: "abc123"}, block)
+					return rpaas.ConflictError{Msg: "blocks were changed meanwhile"}
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -188,6 +205,65 @@ func Test_updateBlock(t *testing.T) {
 			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
 			assert.NoError(t, err)
 			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			if tt.ifMatch != "" {
+				request.Header.Set("If-Match", tt.ifMatch)
+			}
+			rsp, err := srv.Client().Do(request)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Regexp(t, tt.expectedBody, bodyContent(rsp))
+		})
+	}
+}
+
+func Test_validateBlock(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when a request has no body message",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "{\"message\":\"Request body can't be empty\"}",
+			manager:      &fake.RpaasManager{},
+		},
+		{
+			name:         "when the manager returns an error",
+			requestBody:  "block_name=server&content=listen%2080%3B",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: "no running pod",
+			manager: &fake.RpaasManager{
+				FakeValidateBlock: func(instance string, block rpaas.ConfigurationBlock) (*clientTypes.BlockValidation, error) {
+					return nil, rpaas.ValidationError{Msg: "no running pod of instance \"my-instance\" to validate the block on"}
+				},
+			},
+		},
+		{
+			name:         "when the block is invalid",
+			requestBody:  "block_name=server&content=listen%2080",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"pod":"my-instance-0","valid":false,"output":"nginx: \[emerg\] unexpected end of file"}`,
+			manager: &fake.RpaasManager{
+				FakeValidateBlock: func(instance string, block rpaas.ConfigurationBlock) (*clientTypes.BlockValidation, error) {
+					assert.Equal(t, "my-instance", instance)
+					assert.Equal(t, rpaas.ConfigurationBlock{Name: "server", Content: "listen 80"}, block)
+					return &clientTypes.BlockValidation{Pod: "my-instance-0", Output: "nginx: [emerg] unexpected end of file"}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/block/validate", srv.URL)
+			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
+			assert.NoError(t, err)
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
 			rsp, err := srv.Client().Do(request)
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
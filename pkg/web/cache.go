@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 
@@ -64,13 +65,25 @@ func cachePurgeBulk(c echo.Context) error {
 	status := http.StatusOK
 	var results []rpaas.PurgeCacheBulkResult
 	for _, args := range argsList {
-		var r rpaas.PurgeCacheBulkResult
-		count, err := manager.PurgeCache(ctx, name, args)
+		pods, err := manager.PurgeCacheOnPods(ctx, name, args)
 		if err != nil {
 			status = http.StatusInternalServerError
-			r = rpaas.PurgeCacheBulkResult{Path: args.Path, Error: err.Error()}
-		} else {
-			r = rpaas.PurgeCacheBulkResult{Path: args.Path, InstancesPurged: count}
+			results = append(results, rpaas.PurgeCacheBulkResult{Path: args.Path, Error: err.Error()})
+			continue
+		}
+
+		r := rpaas.PurgeCacheBulkResult{Path: args.Path, Pods: pods}
+		var failed []string
+		for _, pod := range pods {
+			if pod.Error != "" {
+				failed = append(failed, pod.Pod)
+			} else if pod.Purged {
+				r.InstancesPurged++
+			}
+		}
+		if len(failed) > 0 {
+			status = http.StatusInternalServerError
+			r.Error = fmt.Sprintf("purge failed on pod(s): %s", strings.Join(failed, ", "))
 		}
 		results = append(results, r)
 	}
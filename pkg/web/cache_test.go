@@ -137,8 +137,8 @@ func Test_cachePurgeBulk(t *testing.T) {
 			expectedCode: http.StatusInternalServerError,
 			expectedBody: `[{"path":"/index.html","error":"Some validation failed"}]`,
 			manager: &fake.RpaasManager{
-				FakePurgeCache: func(instanceName string, args rpaas.PurgeCacheArgs) (int, error) {
-					return 0, rpaas.ValidationError{Msg: "Some validation failed"}
+				FakePurgeCacheOnPods: func(instanceName string, args rpaas.PurgeCacheArgs) ([]rpaas.PurgeCachePodResult, error) {
+					return nil, rpaas.ValidationError{Msg: "Some validation failed"}
 				},
 			},
 		},
@@ -149,8 +149,8 @@ func Test_cachePurgeBulk(t *testing.T) {
 			expectedCode: http.StatusInternalServerError,
 			expectedBody: `[{"path":"/index.html","error":"Something was not found"}]`,
 			manager: &fake.RpaasManager{
-				FakePurgeCache: func(instanceName string, args rpaas.PurgeCacheArgs) (int, error) {
-					return 0, rpaas.NotFoundError{Msg: "Something was not found"}
+				FakePurgeCacheOnPods: func(instanceName string, args rpaas.PurgeCacheArgs) ([]rpaas.PurgeCachePodResult, error) {
+					return nil, rpaas.NotFoundError{Msg: "Something was not found"}
 				},
 			},
 		},
@@ -161,8 +161,8 @@ func Test_cachePurgeBulk(t *testing.T) {
 			expectedCode: http.StatusInternalServerError,
 			expectedBody: `[{"path":"/index.html","error":"Something already exists"}]`,
 			manager: &fake.RpaasManager{
-				FakePurgeCache: func(instanceName string, args rpaas.PurgeCacheArgs) (int, error) {
-					return 0, rpaas.ConflictError{Msg: "Something already exists"}
+				FakePurgeCacheOnPods: func(instanceName string, args rpaas.PurgeCacheArgs) ([]rpaas.PurgeCachePodResult, error) {
+					return nil, rpaas.ConflictError{Msg: "Something already exists"}
 				},
 			},
 		},
@@ -171,10 +171,23 @@ func Test_cachePurgeBulk(t *testing.T) {
 			instanceName: "my-instance",
 			requestBody:  `[{"path":"/index.html","preserve_path":true}]`,
 			expectedCode: http.StatusOK,
-			expectedBody: `[{"path":"/index.html","instances_purged":36}]`,
+			expectedBody: `[{"path":"/index.html","instances_purged":2,"pods":[{"pod":"pod-1","purged":true},{"pod":"pod-2","purged":true},{"pod":"pod-3","purged":false}]}]`,
 			manager: &fake.RpaasManager{
-				FakePurgeCache: func(instanceName string, args rpaas.PurgeCacheArgs) (int, error) {
-					return 36, nil
+				FakePurgeCacheOnPods: func(instanceName string, args rpaas.PurgeCacheArgs) ([]rpaas.PurgeCachePodResult, error) {
+					return []rpaas.PurgeCachePodResult{{Pod: "pod-1", Purged: true}, {Pod: "pod-2", Purged: true}, {Pod: "pod-3"}}, nil
+				},
+			},
+		},
+		{
+			description:  "returns the results of each pod when some of them fail",
+			instanceName: "my-instance",
+			requestBody:  `[{"path":"/static/.*\\.css","path_regexp":true}]`,
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: `[{"path":"/static/.*\\.css","instances_purged":1,"error":"purge failed on pod(s): pod-2","pods":[{"pod":"pod-1","purged":true,"objects":3},{"pod":"pod-2","purged":false,"error":"some error"}]}]`,
+			manager: &fake.RpaasManager{
+				FakePurgeCacheOnPods: func(instanceName string, args rpaas.PurgeCacheArgs) ([]rpaas.PurgeCachePodResult, error) {
+					assert.Equal(t, rpaas.PurgeCacheArgs{Path: `/static/.*\.css`, PathRegexp: true}, args)
+					return []rpaas.PurgeCachePodResult{{Pod: "pod-1", Purged: true, Objects: 3}, {Pod: "pod-2", Error: "some error"}}, nil
 				},
 			},
 		},
@@ -6,12 +6,14 @@ package web
 
 import (
 	"crypto/tls"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
 	"mime"
 	"net/http"
 	"net/url"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 
@@ -58,16 +60,37 @@ func updateCertificate(c echo.Context) error {
 		return &rpaas.ValidationError{Msg: "could not load the given certificate and key", Internal: err}
 	}
 
+	var stapleOCSP *bool
+	if value := c.FormValue("staple-ocsp"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return &rpaas.ValidationError{Msg: "staple-ocsp must be a boolean", Internal: err}
+		}
+
+		stapleOCSP = &enabled
+	}
+
 	manager, err := getManager(ctx)
 	if err != nil {
 		return err
 	}
 
-	err = manager.UpdateCertificate(ctx, c.Param("instance"), c.FormValue("name"), certificate)
+	if rotationOf := c.FormValue("rotation-of"); rotationOf != "" {
+		err = manager.UpdateRotatingCertificate(ctx, c.Param("instance"), c.FormValue("name"), rotationOf, certificate)
+	} else {
+		err = manager.UpdateCertificate(ctx, c.Param("instance"), c.FormValue("name"), certificate)
+	}
 	if err != nil {
 		return err
 	}
 
+	if stapleOCSP != nil {
+		err = manager.SetCertificateOCSPStapling(ctx, c.Param("instance"), c.FormValue("name"), *stapleOCSP)
+		if err != nil {
+			return err
+		}
+	}
+
 	return c.NoContent(http.StatusOK)
 }
 
@@ -93,7 +116,75 @@ func getCertificates(c echo.Context) error {
 		}
 	}
 
-	return c.JSON(http.StatusOK, certList)
+	return encodedResponse(c, http.StatusOK, certList)
+}
+
+func exportCertificates(c echo.Context) error {
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+
+	certList, err := manager.GetCertificates(ctx, c.Param("instance"))
+	if err != nil {
+		return err
+	}
+
+	name := c.QueryParam("name")
+	certs := make([]types.PublicCertificate, 0, len(certList))
+	for _, cert := range certList {
+		if name != "" && cert.Name != name {
+			continue
+		}
+
+		certs = append(certs, types.PublicCertificate{
+			Name:        cert.Name,
+			Certificate: string(publicCertificatesPEM([]byte(cert.Certificate))),
+		})
+	}
+
+	if name != "" && len(certs) == 0 {
+		return &rpaas.NotFoundError{Msg: fmt.Sprintf("certificate %q not found", name)}
+	}
+
+	return c.JSON(http.StatusOK, certs)
+}
+
+// publicCertificatesPEM re-encodes only the CERTIFICATE blocks of data, so
+// private keys bundled along with the chain never leave the server.
+func publicCertificatesPEM(data []byte) []byte {
+	var out []byte
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return out
+		}
+
+		if block.Type == "CERTIFICATE" {
+			out = append(out, pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes})...)
+		}
+	}
+}
+
+func getCertificateStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+
+	status, err := manager.GetCertificateStatus(ctx, c.Param("instance"), c.Param("name"))
+	if err != nil {
+		return err
+	}
+
+	if status == nil {
+		status = make([]types.PodCertificateStatus, 0)
+	}
+
+	return c.JSON(http.StatusOK, status)
 }
 
 func listCertManagerRequests(c echo.Context) error {
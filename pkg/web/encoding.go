@@ -0,0 +1,61 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+// encodedResponse writes v as MessagePack when the client prefers it on the
+// Accept header, otherwise as JSON. Both encodings decode into the same data
+// (see clientTypes.MarshalMsgpack), so it's meant for the read-heavy info
+// and list endpoints, which scrapers poll often.
+func encodedResponse(c echo.Context, code int, v interface{}) error {
+	if !acceptsMsgpack(c.Request().Header.Get(echo.HeaderAccept)) {
+		return c.JSON(code, v)
+	}
+
+	data, err := clientTypes.MarshalMsgpack(v)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	return c.Blob(code, clientTypes.MsgpackContentType, data)
+}
+
+// acceptsMsgpack tells whether the Accept header ranks MessagePack at least
+// as high as JSON.
+func acceptsMsgpack(accept string) bool {
+	var msgpackQ, jsonQ float64
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if s, found := params["q"]; found {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case clientTypes.MsgpackContentType:
+			msgpackQ = q
+		case echo.MIMEApplicationJSON, "application/*", "*/*":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+
+	return msgpackQ > 0 && msgpackQ >= jsonQ
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/tsuru/rpaas-operator/internal/config"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func exec(c echo.Context) error {
@@ -21,6 +22,7 @@ func exec(c echo.Context) error {
 		ReadBufferSize:   config.Get().WebSocketReadBufferSize,
 		WriteBufferSize:  config.Get().WebSocketWriteBufferSize,
 		CheckOrigin:      checkOrigin,
+		Subprotocols:     []string{clientTypes.ExecChannelProtocol},
 	}
 	useWebSocket, _ := strconv.ParseBool(c.QueryParam("ws"))
 	if useWebSocket {
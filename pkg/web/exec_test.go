@@ -7,6 +7,7 @@ package web
 import (
 	"bufio"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -18,10 +19,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/http2"
+	utilexec "k8s.io/client-go/util/exec"
 
 	"github.com/tsuru/rpaas-operator/internal/config"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 var execH2cClient = &http.Client{
@@ -109,6 +112,84 @@ func Test_Exec(t *testing.T) {
 			},
 			expectedCalled: true,
 		},
+		{
+			name: "over websocket with the channel protocol",
+			request: func(t *testing.T, serverURL string) {
+				uri := fmt.Sprintf("ws://%s/resources/my-instance/exec?ws=true&command=cat&command=/missing", strings.TrimPrefix(serverURL, "http://"))
+				dialer := websocket.Dialer{Subprotocols: []string{clientTypes.ExecChannelProtocol}}
+				conn, _, err := dialer.Dial(uri, nil)
+				require.NoError(t, err)
+				defer conn.Close()
+				assert.Equal(t, clientTypes.ExecChannelProtocol, conn.Subprotocol())
+
+				for _, expected := range []string{"\x01some output\n", "\x02cat: can't open '/missing'\n", "\x032"} {
+					mtype, b, err := conn.ReadMessage()
+					require.NoError(t, err)
+					assert.Equal(t, websocket.BinaryMessage, mtype)
+					assert.Equal(t, expected, string(b))
+				}
+
+				_, _, err = conn.ReadMessage()
+				assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+			},
+			manager: &fake.RpaasManager{
+				FakeExec: func(instance string, args rpaas.ExecArgs) error {
+					called = true
+					assert.Equal(t, []string{"cat", "/missing"}, args.Command)
+					fmt.Fprint(args.Stdout, "some output\n")
+					fmt.Fprint(args.Stderr, "cat: can't open '/missing'\n")
+					return utilexec.CodeExitError{Err: errors.New("command terminated with exit code 2"), Code: 2}
+				},
+			},
+			expectedCalled: true,
+		},
+		{
+			name: "over websocket with the channel protocol when the command is not found",
+			request: func(t *testing.T, serverURL string) {
+				uri := fmt.Sprintf("ws://%s/resources/my-instance/exec?ws=true&command=cat", strings.TrimPrefix(serverURL, "http://"))
+				dialer := websocket.Dialer{Subprotocols: []string{clientTypes.ExecChannelProtocol}}
+				conn, _, err := dialer.Dial(uri, nil)
+				require.NoError(t, err)
+				defer conn.Close()
+
+				for _, expected := range []string{"\x02exec: \"cat\": executable file not found in $PATH\n", "\x03127"} {
+					_, b, err := conn.ReadMessage()
+					require.NoError(t, err)
+					assert.Equal(t, expected, string(b))
+				}
+
+				_, _, err = conn.ReadMessage()
+				assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+			},
+			manager: &fake.RpaasManager{
+				FakeExec: func(instance string, args rpaas.ExecArgs) error {
+					called = true
+					return errors.New(`exec: "cat": executable file not found in $PATH`)
+				},
+			},
+			expectedCalled: true,
+		},
+		{
+			name: "over websocket with the channel protocol when the command cannot run",
+			request: func(t *testing.T, serverURL string) {
+				uri := fmt.Sprintf("ws://%s/resources/my-instance/exec?ws=true&command=cat", strings.TrimPrefix(serverURL, "http://"))
+				dialer := websocket.Dialer{Subprotocols: []string{clientTypes.ExecChannelProtocol}}
+				conn, _, err := dialer.Dial(uri, nil)
+				require.NoError(t, err)
+				defer conn.Close()
+
+				_, _, err = conn.ReadMessage()
+				assert.True(t, websocket.IsCloseError(err, websocket.CloseInternalServerErr))
+				assert.EqualError(t, err, "websocket: close 1011 (internal server error): pod not found")
+			},
+			manager: &fake.RpaasManager{
+				FakeExec: func(instance string, args rpaas.ExecArgs) error {
+					called = true
+					return errors.New("pod not found")
+				},
+			},
+			expectedCalled: true,
+		},
 		{
 			name: "using HTTP/2 directly (h2c)",
 			request: func(t *testing.T, serverURL string) {
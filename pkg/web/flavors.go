@@ -34,3 +34,39 @@ func getServiceFlavors(c echo.Context) error {
 func getInstanceFlavors(c echo.Context) error {
 	return getServiceFlavors(c)
 }
+
+func getFlavor(c echo.Context) error {
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+
+	flavor, err := manager.GetFlavor(ctx, c.Param("flavor"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, flavor)
+}
+
+func updateInstanceFlavors(c echo.Context) error {
+	ctx := c.Request().Context()
+	var data struct {
+		Flavors []string `form:"flavors"`
+	}
+	if err := c.Bind(&data); err != nil {
+		return err
+	}
+
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = manager.UpdateFlavors(ctx, c.Param("instance"), data.Flavors); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
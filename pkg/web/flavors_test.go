@@ -7,6 +7,7 @@ package web
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -100,3 +101,103 @@ func Test_getInstanceFlavors(t *testing.T) {
 		})
 	}
 }
+
+func Test_getFlavor(t *testing.T) {
+	tests := []struct {
+		name         string
+		manager      rpaas.RpaasManager
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "when flavor does not exist",
+			manager: &fake.RpaasManager{
+				FakeGetFlavor: func(name string) (*rpaas.FlavorInfo, error) {
+					return nil, rpaas.NotFoundError{Msg: fmt.Sprintf("flavor %q not found", name)}
+				},
+			},
+			path:         "/resources/flavors/not-found",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `flavor \\"not-found\\" not found`,
+		},
+		{
+			name: "returning the flavor details",
+			manager: &fake.RpaasManager{
+				FakeGetFlavor: func(name string) (*rpaas.FlavorInfo, error) {
+					assert.Equal(t, "mint", name)
+					return &rpaas.FlavorInfo{
+						Name:                "mint",
+						Description:         "Awesome description about mint flavor",
+						IncompatibleFlavors: []string{"mango"},
+					}, nil
+				},
+			},
+			path:         "/resources/my-instance/flavors/mint",
+			expectedCode: http.StatusOK,
+			expectedBody: `^\{"name":"mint","description":"Awesome description about mint flavor","incompatibleFlavors":\["mango"\]\}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			request, err := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Regexp(t, tt.expectedBody, bodyContent(rsp))
+		})
+	}
+}
+
+func Test_updateInstanceFlavors(t *testing.T) {
+	tests := []struct {
+		name         string
+		manager      rpaas.RpaasManager
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "when manager returns a validation error",
+			manager: &fake.RpaasManager{
+				FakeUpdateFlavors: func(instanceName string, flavors []string) error {
+					return &rpaas.ValidationError{Msg: `flavor "banana" not found`}
+				},
+			},
+			body:         "flavors=banana",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `flavor \\"banana\\" not found`,
+		},
+		{
+			name: "updating the flavors",
+			manager: &fake.RpaasManager{
+				FakeUpdateFlavors: func(instanceName string, flavors []string) error {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Equal(t, []string{"mint", "mango"}, flavors)
+					return nil
+				},
+			},
+			body:         "flavors=mint&flavors=mango",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/flavors", srv.URL)
+			request, err := http.NewRequest(http.MethodPut, path, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Regexp(t, tt.expectedBody, bodyContent(rsp))
+		})
+	}
+}
@@ -8,6 +8,10 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+	"github.com/tsuru/rpaas-operator/version"
 )
 
 type scaleParameters struct {
@@ -30,6 +34,106 @@ func scale(c echo.Context) error {
 	return c.NoContent(http.StatusOK)
 }
 
+func restart(c echo.Context) error {
+	ctx := c.Request().Context()
+	var args rpaas.RestartArgs
+	if err := c.Bind(&args); err != nil {
+		return c.String(http.StatusBadRequest, "immediate is not valid")
+	}
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+	pods, err := manager.Restart(ctx, c.Param("instance"), args)
+	if err != nil {
+		return err
+	}
+	if pods == nil {
+		pods = make([]string, 0)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"pods": pods})
+}
+
+func connectionStats(c echo.Context) error {
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+	stats, err := manager.GetConnectionStats(ctx, c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	if stats == nil {
+		stats = make([]clientTypes.PodConnectionStats, 0)
+	}
+	return c.JSON(http.StatusOK, stats)
+}
+
+func podsHealth(c echo.Context) error {
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+	health, err := manager.GetPodsHealth(ctx, c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	if health == nil {
+		health = make([]clientTypes.PodHealth, 0)
+	}
+	return c.JSON(http.StatusOK, health)
+}
+
+func podsUsage(c echo.Context) error {
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+	usage, err := manager.GetPodsUsage(ctx, c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	if usage == nil {
+		usage = make([]clientTypes.PodUsage, 0)
+	}
+	return c.JSON(http.StatusOK, usage)
+}
+
+func listPods(c echo.Context) error {
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+	pods, err := manager.ListPods(ctx, c.Param("instance"), c.QueryParam("selector"))
+	if err != nil {
+		return err
+	}
+	if pods == nil {
+		pods = make([]clientTypes.Pod, 0)
+	}
+	return encodedResponse(c, http.StatusOK, pods)
+}
+
+func bindsStatus(c echo.Context) error {
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+	binds, err := manager.GetBindsStatus(ctx, c.Param("instance"))
+	if err != nil {
+		return err
+	}
+	if binds == nil {
+		binds = make([]clientTypes.BindStatus, 0)
+	}
+	return encodedResponse(c, http.StatusOK, binds)
+}
+
 func serviceNodeStatus(c echo.Context) error {
 	ctx := c.Request().Context()
 	manager, err := getManager(ctx)
@@ -47,3 +151,7 @@ func serviceNodeStatus(c echo.Context) error {
 func healthcheck(c echo.Context) error {
 	return c.String(http.StatusOK, "OK")
 }
+
+func getVersion(c echo.Context) error {
+	return c.JSON(http.StatusOK, clientTypes.Version{Version: version.Version})
+}
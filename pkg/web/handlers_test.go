@@ -5,14 +5,21 @@
 package web
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/tsuru/rpaas-operator/internal/config"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+	"github.com/tsuru/rpaas-operator/version"
 )
 
 func Test_healthcheck(t *testing.T) {
@@ -50,6 +57,18 @@ func Test_healthcheck(t *testing.T) {
 	}
 }
 
+func Test_getVersion(t *testing.T) {
+	defer func(v string) { version.Version = v }(version.Version)
+	version.Version = "v0.40.0/1a2b3c4"
+
+	srv := newTestingServer(t, nil)
+	defer srv.Close()
+	rsp, err := srv.Client().Get(fmt.Sprintf("%s/version", srv.URL))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	assert.Equal(t, `{"version":"v0.40.0/1a2b3c4"}`, bodyContent(rsp))
+}
+
 func Test_MiddlewareBasicAuth(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -110,3 +129,367 @@ func Test_MiddlewareBasicAuth(t *testing.T) {
 		})
 	}
 }
+
+// fieldManagerRecorder records the field manager of the blocks updates.
+type fieldManagerRecorder struct {
+	*fake.RpaasManager
+	fieldManagers []string
+}
+
+func (m *fieldManagerRecorder) UpdateBlock(ctx context.Context, instanceName string, block rpaas.ConfigurationBlock) error {
+	m.fieldManagers = append(m.fieldManagers, rpaas.FieldManagerFromContext(ctx))
+	return nil
+}
+
+func Test_MiddlewareFieldManager(t *testing.T) {
+	manager := &fieldManagerRecorder{RpaasManager: &fake.RpaasManager{}}
+	srv := newTestingServer(t, manager)
+	defer srv.Close()
+
+	for _, fieldManager := range []string{"nightly-job", ""} {
+		request, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/resources/my-instance/block", srv.URL), strings.NewReader("block_name=http&content=some%20config"))
+		require.NoError(t, err)
+		request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		if fieldManager != "" {
+			request.Header.Set(clientTypes.FieldManagerHeader, fieldManager)
+		}
+
+		rsp, err := srv.Client().Do(request)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rsp.StatusCode)
+	}
+
+	assert.Equal(t, []string{"nightly-job", ""}, manager.fieldManagers)
+}
+
+func Test_restart(t *testing.T) {
+	tests := []struct {
+		name         string
+		requestBody  string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when performing a rolling restart",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"pods":["my-instance-abc","my-instance-def"]}`,
+			manager: &fake.RpaasManager{
+				FakeRestart: func(instanceName string, args rpaas.RestartArgs) ([]string, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.False(t, args.Immediate)
+					return []string{"my-instance-abc", "my-instance-def"}, nil
+				},
+			},
+		},
+		{
+			name:         "when performing an immediate restart",
+			requestBody:  "immediate=true",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"pods":[]}`,
+			manager: &fake.RpaasManager{
+				FakeRestart: func(instanceName string, args rpaas.RestartArgs) ([]string, error) {
+					assert.True(t, args.Immediate)
+					return nil, nil
+				},
+			},
+		},
+		{
+			name:         "when restart returns an error",
+			expectedCode: http.StatusNotFound,
+			expectedBody: "{\"message\":\"instance not found\"}",
+			manager: &fake.RpaasManager{
+				FakeRestart: func(instanceName string, args rpaas.RestartArgs) ([]string, error) {
+					return nil, rpaas.NotFoundError{Msg: "instance not found"}
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/restart", srv.URL)
+			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
+			require.NoError(t, err)
+			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, bodyContent(rsp))
+		})
+	}
+}
+
+func Test_connectionStats(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when there are no pods",
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+			manager:      &fake.RpaasManager{},
+		},
+		{
+			name:         "when some pod fails to respond",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"pod":"my-instance-abc","stats":{"active":10,"reading":1,"writing":2,"waiting":7}},{"pod":"my-instance-def","error":"some error"}]`,
+			manager: &fake.RpaasManager{
+				FakeGetConnectionStats: func(instanceName string) ([]clientTypes.PodConnectionStats, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					return []clientTypes.PodConnectionStats{
+						{Pod: "my-instance-abc", Stats: &clientTypes.ConnectionStats{Active: 10, Reading: 1, Writing: 2, Waiting: 7}},
+						{Pod: "my-instance-def", Error: "some error"},
+					}, nil
+				},
+			},
+		},
+		{
+			name:         "when instance is not found",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"message":"instance not found"}`,
+			manager: &fake.RpaasManager{
+				FakeGetConnectionStats: func(instanceName string) ([]clientTypes.PodConnectionStats, error) {
+					return nil, rpaas.NotFoundError{Msg: "instance not found"}
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/stats", srv.URL)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(bodyContent(rsp)))
+		})
+	}
+}
+
+func Test_podsHealth(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when there are no pods",
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+			manager:      &fake.RpaasManager{},
+		},
+		{
+			name:         "when some pod is not healthy",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"pod":"my-instance-abc","healthy":true},{"pod":"my-instance-def","healthy":false,"error":"some error"}]`,
+			manager: &fake.RpaasManager{
+				FakeGetPodsHealth: func(instanceName string) ([]clientTypes.PodHealth, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					return []clientTypes.PodHealth{
+						{Pod: "my-instance-abc", Healthy: true},
+						{Pod: "my-instance-def", Error: "some error"},
+					}, nil
+				},
+			},
+		},
+		{
+			name:         "when instance is not found",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"message":"instance not found"}`,
+			manager: &fake.RpaasManager{
+				FakeGetPodsHealth: func(instanceName string) ([]clientTypes.PodHealth, error) {
+					return nil, rpaas.NotFoundError{Msg: "instance not found"}
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/health", srv.URL)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(bodyContent(rsp)))
+		})
+	}
+}
+
+func Test_podsUsage(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when there are no pods",
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+			manager:      &fake.RpaasManager{},
+		},
+		{
+			name:         "when some pod has no metrics",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"pod":"my-instance-abc","metrics":{"cpu":"150m","memory":"64Mi"}},{"pod":"my-instance-def"}]`,
+			manager: &fake.RpaasManager{
+				FakeGetPodsUsage: func(instanceName string) ([]clientTypes.PodUsage, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					return []clientTypes.PodUsage{
+						{Pod: "my-instance-abc", Metrics: &clientTypes.PodMetrics{CPU: "150m", Memory: "64Mi"}},
+						{Pod: "my-instance-def"},
+					}, nil
+				},
+			},
+		},
+		{
+			name:         "when instance is not found",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"message":"instance not found"}`,
+			manager: &fake.RpaasManager{
+				FakeGetPodsUsage: func(instanceName string) ([]clientTypes.PodUsage, error) {
+					return nil, rpaas.NotFoundError{Msg: "instance not found"}
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/top", srv.URL)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(bodyContent(rsp)))
+		})
+	}
+}
+
+func Test_listPods(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when no pod matches",
+			query:        "?selector=version%3Dv2",
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+			manager:      &fake.RpaasManager{},
+		},
+		{
+			name:         "when some pod matches the selector",
+			query:        "?selector=version%3Dv2",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"createdAt":"0001-01-01T00:00:00Z","terminatedAt":"0001-01-01T00:00:00Z","name":"my-instance-abc","ip":"10.0.0.1","host":"","status":"Running","restarts":0,"ready":true}]`,
+			manager: &fake.RpaasManager{
+				FakeListPods: func(instanceName, selector string) ([]clientTypes.Pod, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					assert.Equal(t, "version=v2", selector)
+					return []clientTypes.Pod{{Name: "my-instance-abc", IP: "10.0.0.1", Status: "Running", Ready: true}}, nil
+				},
+			},
+		},
+		{
+			name:         "when the selector is invalid",
+			query:        "?selector=version+in+%28v1",
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"message":"invalid pod selector"}`,
+			manager: &fake.RpaasManager{
+				FakeListPods: func(instanceName, selector string) ([]clientTypes.Pod, error) {
+					return nil, rpaas.ValidationError{Msg: "invalid pod selector"}
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/pods%s", srv.URL, tt.query)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(bodyContent(rsp)))
+		})
+	}
+}
+
+func Test_bindsStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when there are no binds",
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+			manager:      &fake.RpaasManager{},
+		},
+		{
+			name:         "when some backend is unhealthy",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"app":"app1","address":"app1.tsuru.example.com","healthy":true},{"app":"app2","address":"app2.tsuru.example.com","healthy":false,"error":"connection refused"}]`,
+			manager: &fake.RpaasManager{
+				FakeGetBindsStatus: func(instanceName string) ([]clientTypes.BindStatus, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					return []clientTypes.BindStatus{
+						{App: "app1", Address: "app1.tsuru.example.com", Healthy: true},
+						{App: "app2", Address: "app2.tsuru.example.com", Error: "connection refused"},
+					}, nil
+				},
+			},
+		},
+		{
+			name:         "when instance is not found",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"message":"instance not found"}`,
+			manager: &fake.RpaasManager{
+				FakeGetBindsStatus: func(instanceName string) ([]clientTypes.BindStatus, error) {
+					return nil, rpaas.NotFoundError{Msg: "instance not found"}
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/binds", srv.URL)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(bodyContent(rsp)))
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 func instanceInfo(c echo.Context) error {
@@ -22,5 +24,24 @@ func instanceInfo(c echo.Context) error {
 		return err
 	}
 
-	return c.JSON(http.StatusOK, info)
+	return encodedResponse(c, http.StatusOK, info)
+}
+
+func instanceHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+	manager, err := getManager(ctx)
+	if err != nil {
+		return err
+	}
+
+	history, err := manager.GetInstanceHistory(ctx, c.Param("instance"))
+	if err != nil {
+		return err
+	}
+
+	if history == nil {
+		history = make([]clientTypes.HistoryEntry, 0)
+	}
+
+	return c.JSON(http.StatusOK, history)
 }
@@ -5,10 +5,13 @@
 package web
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -119,3 +122,107 @@ func Test_instanceInfo(t *testing.T) {
 		})
 	}
 }
+
+func Test_instanceHistory(t *testing.T) {
+	tests := []struct {
+		name         string
+		manager      rpaas.RpaasManager
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name: "when instance has changes",
+			manager: &fake.RpaasManager{
+				FakeGetInstanceHistory: func(instanceName string) ([]clientTypes.HistoryEntry, error) {
+					assert.Equal(t, "my-instance", instanceName)
+					return []clientTypes.HistoryEntry{
+						{Time: time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC), Kind: "scale", Actor: "rpaas-api", Action: "Update", Message: "set replicas"},
+					}, nil
+				},
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"time":"2023-03-01T10:00:00Z","kind":"scale","actor":"rpaas-api","action":"Update","message":"set replicas"}]`,
+		},
+		{
+			name:         "when instance has no changes",
+			manager:      &fake.RpaasManager{},
+			expectedCode: http.StatusOK,
+			expectedBody: `[]`,
+		},
+		{
+			name: "when instance is not found",
+			manager: &fake.RpaasManager{
+				FakeGetInstanceHistory: func(instanceName string) ([]clientTypes.HistoryEntry, error) {
+					return nil, rpaas.NotFoundError{Msg: "rpaas instance \"my-instance\" not found"}
+				},
+			},
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"message":"rpaas instance \"my-instance\" not found"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			path := fmt.Sprintf("%s/resources/my-instance/history", srv.URL)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, bodyContent(rsp))
+		})
+	}
+}
+
+func Test_instanceInfoAsMsgpack(t *testing.T) {
+	info := &clientTypes.InstanceInfo{
+		Name:     "my-instance",
+		Replicas: int32Ptr(2),
+		Pods: []clientTypes.Pod{
+			{Name: "my-instance-6f86f957b7-abcde", CreatedAt: time.Date(2023, time.March, 14, 15, 9, 26, 0, time.UTC), Ready: true},
+		},
+	}
+
+	srv := newTestingServer(t, &fake.RpaasManager{
+		FakeGetInstanceInfo: func(instanceName string) (*clientTypes.InstanceInfo, error) {
+			return info, nil
+		},
+	})
+	defer srv.Close()
+
+	tests := []struct {
+		accept              string
+		expectedContentType string
+	}{
+		{accept: "", expectedContentType: "application/json; charset=UTF-8"},
+		{accept: "application/json", expectedContentType: "application/json; charset=UTF-8"},
+		{accept: "application/msgpack, application/json;q=0.9", expectedContentType: "application/msgpack"},
+		{accept: "application/msgpack;q=0.5, */*", expectedContentType: "application/json; charset=UTF-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/resources/my-instance/info", srv.URL), nil)
+			require.NoError(t, err)
+			request.Header.Set("Accept", tt.accept)
+
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			defer rsp.Body.Close()
+			assert.Equal(t, http.StatusOK, rsp.StatusCode)
+			assert.Equal(t, tt.expectedContentType, rsp.Header.Get("Content-Type"))
+
+			data, err := io.ReadAll(rsp.Body)
+			require.NoError(t, err)
+
+			var got clientTypes.InstanceInfo
+			if tt.expectedContentType == clientTypes.MsgpackContentType {
+				require.NoError(t, clientTypes.UnmarshalMsgpack(data, &got, true))
+			} else {
+				require.NoError(t, json.Unmarshal(data, &got))
+			}
+			assert.Equal(t, *info, got)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
+	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
+	"github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
+)
+
+func Test_getMetadata(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when instance does not exist",
+			expectedCode: http.StatusNotFound,
+			expectedBody: `{"message":"rpaas instance \"my-instance\" not found"}`,
+			manager: &fake.RpaasManager{
+				FakeGetMetadata: func(instance string) (*types.Metadata, error) {
+					return nil, rpaas.NotFoundError{Msg: fmt.Sprintf("rpaas instance %q not found", instance)}
+				},
+			},
+		},
+		{
+			name:         "when metadata is successfully returned",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"labels":[{"name":"env","value":"prod"}],"annotations":[{"name":"owner","value":"team-x"}]}`,
+			manager: &fake.RpaasManager{
+				FakeGetMetadata: func(instance string) (*types.Metadata, error) {
+					assert.Equal(t, "my-instance", instance)
+					return &types.Metadata{
+						Labels:      []types.MetadataItem{{Name: "env", Value: "prod"}},
+						Annotations: []types.MetadataItem{{Name: "owner", Value: "team-x"}},
+					}, nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			rsp, err := srv.Client().Get(fmt.Sprintf("%s/resources/my-instance/metadata", srv.URL))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(bodyContent(rsp)))
+		})
+	}
+}
+
+func Test_setAndUnsetMetadata(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		expectedCode int
+		expectedBody string
+		manager      rpaas.RpaasManager
+	}{
+		{
+			name:         "when metadata is successfully set",
+			method:       http.MethodPost,
+			expectedCode: http.StatusOK,
+			manager: &fake.RpaasManager{
+				FakeSetMetadata: func(instance string, metadata *types.Metadata) error {
+					assert.Equal(t, "my-instance", instance)
+					assert.Equal(t, &types.Metadata{Labels: []types.MetadataItem{{Name: "env", Value: "prod"}}}, metadata)
+					return nil
+				},
+			},
+		},
+		{
+			name:         "when setting metadata is invalid",
+			method:       http.MethodPost,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"message":"some validation error"}`,
+			manager: &fake.RpaasManager{
+				FakeSetMetadata: func(instance string, metadata *types.Metadata) error {
+					return &rpaas.ValidationError{Msg: "some validation error"}
+				},
+			},
+		},
+		{
+			name:         "when metadata is successfully unset",
+			method:       http.MethodDelete,
+			expectedCode: http.StatusOK,
+			manager: &fake.RpaasManager{
+				FakeUnsetMetadata: func(instance string, metadata *types.Metadata) error {
+					assert.Equal(t, "my-instance", instance)
+					assert.Equal(t, &types.Metadata{Labels: []types.MetadataItem{{Name: "env", Value: "prod"}}}, metadata)
+					return nil
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestingServer(t, tt.manager)
+			defer srv.Close()
+			body := strings.NewReader(`{"labels":[{"name":"env","value":"prod"}]}`)
+			request, err := http.NewRequest(tt.method, fmt.Sprintf("%s/resources/my-instance/metadata", srv.URL), body)
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			rsp, err := srv.Client().Do(request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rsp.StatusCode)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(bodyContent(rsp)))
+		})
+	}
+}
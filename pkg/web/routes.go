@@ -51,7 +51,7 @@ func getRoutes(c echo.Context) error {
 		routes = []rpaas.Route{}
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
+	return encodedResponse(c, http.StatusOK, map[string]interface{}{
 		"paths": routes,
 	})
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/tsuru/rpaas-operator/api/v1alpha1"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas"
 	"github.com/tsuru/rpaas-operator/internal/pkg/rpaas/fake"
 )
@@ -182,6 +183,7 @@ func Test_updateRoute(t *testing.T) {
 		name         string
 		instance     string
 		requestBody  string
+		contentType  string
 		expectedCode int
 		expectedBody string
 		manager      rpaas.RpaasManager
@@ -203,6 +205,25 @@ func Test_updateRoute(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:         "when updating a route with weighted destinations",
+			instance:     "my-instance",
+			requestBody:  `{"path": "/path1", "destinations": [{"destination": "app1.tsuru.example.com", "weight": 9}, {"destination": "app2.tsuru.example.com", "weight": 1}]}`,
+			contentType:  echo.MIMEApplicationJSON,
+			expectedCode: http.StatusCreated,
+			manager: &fake.RpaasManager{
+				FakeUpdateRoute: func(instanceName string, route rpaas.Route) error {
+					assert.Equal(t, rpaas.Route{
+						Path: "/path1",
+						Destinations: []v1alpha1.WeightedDestination{
+							{Destination: "app1.tsuru.example.com", Weight: 9},
+							{Destination: "app2.tsuru.example.com", Weight: 1},
+						},
+					}, route)
+					return nil
+				},
+			},
+		},
 		{
 			name:         "when update route returns some error",
 			instance:     "my-instance",
@@ -229,7 +250,11 @@ func Test_updateRoute(t *testing.T) {
 			path := fmt.Sprintf("%s/resources/%s/route", srv.URL, tt.instance)
 			request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tt.requestBody))
 			require.NoError(t, err)
-			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = echo.MIMEApplicationForm
+			}
+			request.Header.Set(echo.HeaderContentType, contentType)
 			rsp, err := srv.Client().Do(request)
 			require.NoError(t, err)
 			request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
@@ -5,15 +5,22 @@
 package web
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
+	utilexec "k8s.io/client-go/util/exec"
 
 	"github.com/tsuru/rpaas-operator/internal/config"
+	clientTypes "github.com/tsuru/rpaas-operator/pkg/rpaas/client/types"
 )
 
 type wsReadWriter struct {
@@ -37,6 +44,46 @@ func (r *wsReadWriter) Write(p []byte) (int, error) {
 	return len(p), r.WriteMessage(websocket.TextMessage, p)
 }
 
+// wsChannelWriter writes each chunk of data as a message on its channel of
+// the ExecChannelProtocol. The mutex is shared among the writers of the
+// same connection, since it supports only one concurrent writer.
+type wsChannelWriter struct {
+	conn    *websocket.Conn
+	mu      *sync.Mutex
+	channel byte
+}
+
+func (w *wsChannelWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return len(p), w.conn.WriteMessage(websocket.BinaryMessage, append([]byte{w.channel}, p...))
+}
+
+const exitStatusCommandNotFound = 127
+
+// remoteExitStatus returns the exit status of a remote command out of the
+// error returned running it. Commands terminated by a signal are reported by
+// the container runtime as 128 plus the signal number (e.g. 137 for SIGKILL),
+// just like shells do, while executables not found get 127. Any other error
+// means the command could not be run at all.
+func remoteExitStatus(err error) (int, bool) {
+	if err == nil {
+		return 0, true
+	}
+
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), true
+	}
+
+	if strings.Contains(err.Error(), "executable file not found") {
+		return exitStatusCommandNotFound, true
+	}
+
+	return 0, false
+}
+
 type commonArgs interface {
 	SetStdout(io.Writer)
 	SetStderr(io.Writer)
@@ -98,9 +145,14 @@ func (w *wsTransport) Run(c echo.Context, wsUpgrader *websocket.Upgrader) error
 	if args.GetInteractive() {
 		args.SetStdin(wsRW)
 	}
-	args.SetStdout(wsRW)
-	args.SetStderr(wsRW)
-	err = w.command(c, args)
+
+	if conn.Subprotocol() == clientTypes.ExecChannelProtocol {
+		err = w.runOnChannels(c, conn, args)
+	} else {
+		args.SetStdout(wsRW)
+		args.SetStderr(wsRW)
+		err = w.command(c, args)
+	}
 
 	// NOTE: avoiding to return error since the connection has already been
 	// hijacked by websocket at this point.
@@ -109,6 +161,30 @@ func (w *wsTransport) Run(c echo.Context, wsUpgrader *websocket.Upgrader) error
 	return nil
 }
 
+// runOnChannels runs the command writing its output on the channels of the
+// ExecChannelProtocol, followed by its exit status. The returned error means
+// the command could not be run at all.
+func (w *wsTransport) runOnChannels(c echo.Context, conn *websocket.Conn, args commonArgs) error {
+	var mu sync.Mutex
+	stderr := &wsChannelWriter{conn: conn, mu: &mu, channel: clientTypes.ExecStderrChannel}
+	args.SetStdout(&wsChannelWriter{conn: conn, mu: &mu, channel: clientTypes.ExecStdoutChannel})
+	args.SetStderr(stderr)
+
+	err := w.command(c, args)
+	status, ok := remoteExitStatus(err)
+	if !ok {
+		return err
+	}
+
+	if err != nil && status == exitStatusCommandNotFound {
+		fmt.Fprintln(stderr, err)
+	}
+
+	statusWriter := &wsChannelWriter{conn: conn, mu: &mu, channel: clientTypes.ExecStatusChannel}
+	_, err = statusWriter.Write([]byte(strconv.Itoa(status)))
+	return err
+}
+
 type http2Writer struct {
 	io.Writer
 }